@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/safety"
+)
+
+// benchQueries 未在参数中给出需求时轮流使用的常见需求
+var benchQueries = []string{
+	"查看当前目录下最大的 10 个文件",
+	"统计当前目录下 go 文件的总行数",
+	"查看 8080 端口被哪个进程占用",
+	"找出最近一天修改过的文件",
+	"查看磁盘占用",
+	"把当前目录打包成 tar.gz",
+	"查看本机 ip",
+	"列出正在运行的 docker 容器",
+}
+
+// benchMockModel 内置模拟提供商返回的模型名
+const benchMockModel = "termi-bench-mock"
+
+// benchReport 压测结果，耗时只统计成功的请求
+type benchReport struct {
+	Provider    string             `json:"provider"`
+	Model       string             `json:"model,omitempty"`
+	Concurrency int                `json:"concurrency"`
+	Requests    int                `json:"requests"` // 实际完成的请求数，中断时少于计划
+	Succeeded   int                `json:"succeeded"`
+	Failed      int                `json:"failed"`
+	ErrorRate   float64            `json:"error_rate"`
+	Duration    float64            `json:"duration_seconds"`
+	Throughput  float64            `json:"throughput"` // 每秒完成的请求数
+	Latency     map[string]float64 `json:"latency_ms,omitempty"`
+	Errors      []benchError       `json:"errors,omitempty"`
+	Interrupted bool               `json:"interrupted,omitempty"`
+}
+
+// benchError 同一种错误出现的次数
+type benchError struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+// benchPercentiles 报告的耗时分位
+var benchPercentiles = []struct {
+	name string
+	q    float64
+}{{"min", 0}, {"p50", 0.5}, {"p90", 0.9}, {"p95", 0.95}, {"p99", 0.99}, {"max", 1}}
+
+// runBench 处理 termi bench 子命令：并发地走完整的命令生成流程（组装提示词、请求提供商、
+// 解析与安全检查），报告耗时分位与错误率，用于评估缓存代理等部署的承载能力
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	concurrency := fs.Int("concurrency", 10, "同时进行的请求数")
+	requests := fs.Int("requests", 100, "请求总数")
+	mock := fs.Bool("mock", false, "使用内置的模拟提供商，不发送真实请求")
+	mockLatency := fs.Duration("mock-latency", 200*time.Millisecond, "模拟提供商的平均响应耗时")
+	mockErrors := fs.Float64("mock-error-rate", 0, "模拟提供商返回 503 的比例，0-1")
+	unique := fs.Bool("unique", false, "在每个需求末尾附加序号，绕过代理的共享缓存")
+	yes := fs.Bool("yes", false, "向真实提供商发送请求前不再确认")
+	asJSON := fs.Bool("json", false, "以 JSON 输出结果")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	switch {
+	case *concurrency <= 0 || *requests <= 0:
+		return fmt.Errorf("--concurrency 与 --requests 必须大于 0")
+	case *mockErrors < 0 || *mockErrors > 1:
+		return fmt.Errorf("--mock-error-rate 必须在 0 到 1 之间")
+	}
+	queries := benchQueries
+	if q := strings.TrimSpace(strings.Join(fs.Args(), " ")); q != "" {
+		queries = []string{q}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var cfg *config.Config
+	var err error
+	if *mock {
+		if cfg, err = loadConfigFile(); err != nil {
+			return err
+		}
+		cfg = cmp.Or(cfg, config.DefaultConfig())
+		url, err := serveBenchMock(ctx, *mockLatency, *mockErrors)
+		if err != nil {
+			return err
+		}
+		cfg.LLM = config.LLMConfig{
+			Provider: config.ProviderOpenAI,
+			OpenAI:   &config.OpenAIConfig{APIKey: "bench", Model: benchMockModel, BaseURL: url},
+			Retry:    cfg.LLM.Retry,
+		}
+		// 模拟请求不产生费用，不计入 termi stats
+		cfg.Stats.Disabled = true
+	} else if cfg, err = config.LoadConfig(); err != nil {
+		showConfigHelp(err)
+		return err
+	}
+
+	client, err := llm.NewClient(cfg, llm.WithBudget(nil), llm.WithoutLearning())
+	if err != nil {
+		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+	analyzer, err := safety.New(cfg.Safety)
+	if err != nil {
+		return err
+	}
+	if !*mock && !*yes {
+		if err := confirmBench(client.ProviderName(), *requests, *concurrency); err != nil {
+			return err
+		}
+	}
+
+	report := bench(ctx, client, analyzer, queries, *requests, *concurrency, *unique)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printBench(report)
+	return nil
+}
+
+// confirmBench 向真实提供商发送大量请求会消耗 token，先让用户确认
+func confirmBench(provider string, requests, concurrency int) error {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf("将向 %s 发送 %d 个真实请求，非交互运行时请加 --yes 确认，或用 --mock 压测模拟提供商", provider, requests)
+	}
+	fmt.Printf("将向 %s 发送 %d 个真实请求（并发 %d），会消耗 token。继续? [y/N] ", provider, requests, concurrency)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return fmt.Errorf("已取消")
+	}
+	return nil
+}
+
+// bench 以 concurrency 个并发请求完成 requests 个请求，ctx 结束时停止发出新请求
+func bench(ctx context.Context, client *llm.Client, analyzer *safety.Analyzer, queries []string, requests, concurrency int, unique bool) benchReport {
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		failures  = map[string]int{}
+		model     string
+		done      atomic.Int64
+	)
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range requests {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	stopProgress := benchProgress(&done, requests)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range min(concurrency, requests) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				query := queries[i%len(queries)]
+				if unique {
+					query = fmt.Sprintf("%s #%d", query, i+1)
+				}
+				began := time.Now()
+				reply, err := client.AskSmart(ctx, query)
+				elapsed := time.Since(began)
+				if err == nil && reply.Command == "" && reply.Ask == "" && reply.Answer == "" {
+					err = errors.New("响应中没有命令、追问或回答")
+				}
+				if err == nil && reply.Command != "" {
+					analyzer.Analyze(reply.Command)
+				}
+
+				mu.Lock()
+				if err != nil {
+					if ctx.Err() == nil {
+						failures[benchMessage(err)]++
+					}
+				} else {
+					latencies = append(latencies, elapsed)
+					model = cmp.Or(model, reply.Model)
+				}
+				mu.Unlock()
+				done.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	stopProgress()
+
+	r := benchReport{
+		Provider:    client.ProviderName(),
+		Model:       model,
+		Concurrency: concurrency,
+		Succeeded:   len(latencies),
+		Duration:    elapsed.Seconds(),
+		Interrupted: ctx.Err() != nil,
+	}
+	for msg, n := range failures {
+		r.Failed += n
+		r.Errors = append(r.Errors, benchError{Message: msg, Count: n})
+	}
+	sort.Slice(r.Errors, func(i, j int) bool { return r.Errors[i].Count > r.Errors[j].Count })
+	r.Requests = r.Succeeded + r.Failed
+	if r.Requests > 0 {
+		r.ErrorRate = float64(r.Failed) / float64(r.Requests)
+		r.Throughput = float64(r.Requests) / elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		r.Latency = map[string]float64{}
+		for _, p := range benchPercentiles {
+			r.Latency[p.name] = float64(quantile(latencies, p.q).Microseconds()) / 1000
+		}
+	}
+	return r
+}
+
+// benchMessage 归并同类错误：去掉首尾空白并截断过长的错误信息
+func benchMessage(err error) string {
+	msg := strings.Join(strings.Fields(err.Error()), " ")
+	if r := []rune(msg); len(r) > 120 {
+		msg = string(r[:120]) + "…"
+	}
+	return msg
+}
+
+// quantile 返回已排序耗时的 q 分位（最近秩法）
+func quantile(sorted []time.Duration, q float64) time.Duration {
+	i := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// benchProgress 在终端中显示进度，返回的函数停止显示并清除进度行
+func benchProgress(done *atomic.Int64, total int) func() {
+	if !term.IsTerminal(os.Stderr.Fd()) {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+				fmt.Fprintf(os.Stderr, "\r已完成 %d/%d", done.Load(), total)
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-finished
+	}
+}
+
+func printBench(r benchReport) {
+	fmt.Printf("提供商: %s", r.Provider)
+	if r.Model != "" {
+		fmt.Printf(" (%s)", r.Model)
+	}
+	fmt.Printf("，并发 %d\n", r.Concurrency)
+	if r.Interrupted {
+		fmt.Println("压测已中断，以下只统计已完成的请求")
+	}
+	fmt.Printf("完成 %d 个请求，用时 %.1fs，吞吐 %.1f 请求/秒\n", r.Requests, r.Duration, r.Throughput)
+	fmt.Printf("成功 %d，失败 %d，错误率 %.1f%%\n", r.Succeeded, r.Failed, r.ErrorRate*100)
+
+	if r.Latency != nil {
+		fmt.Println("\n耗时（成功的请求）:")
+		for _, p := range benchPercentiles {
+			fmt.Printf("  %-4s %10.0f ms\n", p.name, r.Latency[p.name])
+		}
+	}
+	if len(r.Errors) > 0 {
+		fmt.Println("\n错误:")
+		for _, e := range r.Errors {
+			fmt.Printf("  %6d  %s\n", e.Count, e.Message)
+		}
+	}
+}
+
+// serveBenchMock 在本机随机端口启动 OpenAI 兼容的模拟提供商，ctx 结束时关闭。
+// 响应耗时在 latency 的 0.5 到 1.5 倍之间均匀分布，errorRate 比例的请求返回 503
+func serveBenchMock(ctx context.Context, latency time.Duration, errorRate float64) (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("启动模拟提供商失败: %w", err)
+	}
+	content, _ := json.Marshal(map[string]string{
+		"command":     "du -ah . | sort -rh | head -n 10",
+		"description": "列出当前目录下最大的 10 个文件",
+		"risk":        "low",
+	})
+	reply, _ := json.Marshal(map[string]any{
+		"id":      "bench",
+		"object":  "chat.completion",
+		"model":   benchMockModel,
+		"choices": []any{map[string]any{"index": 0, "finish_reason": "stop", "message": map[string]string{"role": "assistant", "content": string(content)}}},
+		"usage":   map[string]int{"prompt_tokens": 800, "completion_tokens": 40, "total_tokens": 840},
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Duration(float64(latency) * (0.5 + rand.Float64()))):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if rand.Float64() < errorRate {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":{"message":"模拟的服务不可用","type":"server_error"}}`))
+			return
+		}
+		_, _ = w.Write(reply)
+	})
+	srv := &http.Server{Handler: mux}
+	go func() { _ = srv.Serve(ln) }()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+	return "http://" + ln.Addr().String() + "/v1", nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/ui"
+)
+
+const configUsage = `用法:
+  termi config init              交互式配置向导：选择提供商、填写 API Key 与模型并测试连接
+  termi config show              显示当前生效的配置（隐藏密钥）
+  termi config get <键>          读取配置项，例如 llm.openai.model
+  termi config set <键> <值>     修改配置文件中的配置项，例如 termi config set llm.candidates 1
+  termi config path              显示配置文件路径`
+
+// runConfig 处理 termi config 子命令
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(configUsage)
+		return nil
+	}
+
+	switch args[0] {
+	case "init":
+		cfg, err := loadConfigFile()
+		if err != nil {
+			return err
+		}
+		return ui.RunSetup(cfg)
+	case "path":
+		fmt.Println(config.Path())
+		return nil
+	case "show":
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		data, err := cfg.Redacted()
+		if err != nil {
+			return err
+		}
+		if _, err := config.LoadFile(); errors.Is(err, fs.ErrNotExist) {
+			fmt.Println("# 配置文件不存在，以下是从环境变量得到的配置")
+		}
+		fmt.Println(string(data))
+		return nil
+	case "get":
+		if len(args) != 2 {
+			return fmt.Errorf("用法: termi config get <键>")
+		}
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		v, err := cfg.Get(args[1])
+		if err != nil {
+			return err
+		}
+		if s, ok := v.(string); ok {
+			fmt.Println(s)
+			return nil
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	case "set":
+		if len(args) != 3 {
+			return fmt.Errorf("用法: termi config set <键> <值>")
+		}
+		cfg, err := loadConfigFile()
+		if err != nil {
+			return err
+		}
+		if cfg == nil {
+			cfg = config.DefaultConfig()
+		}
+		wasValid := cfg.Validate() == nil
+		if err := cfg.Set(args[1], args[2]); err != nil {
+			return err
+		}
+		// 从零开始逐项设置时中间状态必然不完整，只提示；原本有效的配置不允许改坏
+		if err := cfg.Validate(); err != nil {
+			if wasValid {
+				return fmt.Errorf("修改后配置无效，未保存: %w", err)
+			}
+			glyph.Printf("⚠ 配置尚不完整: %v\n", err)
+		}
+		return cfg.SaveConfig()
+	default:
+		return fmt.Errorf("未知的 config 子命令: %s\n\n%s", args[0], configUsage)
+	}
+}
+
+// loadConfigFile 读取配置文件，文件不存在时返回 nil；不使用环境变量中的配置，
+// 以免 API Key 等环境变量被写进配置文件
+func loadConfigFile() (*config.Config, error) {
+	cfg, err := config.LoadFile()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return cfg, err
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/llm"
+)
+
+// doctorTimeout 每个提供商连通性检查的时限
+const doctorTimeout = 10 * time.Second
+
+// proxyEnvKeys 影响提供商请求的代理环境变量
+var proxyEnvKeys = []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy", "NO_PROXY", "no_proxy"}
+
+// runDoctor 处理 termi doctor 子命令：检查配置能否加载、取自哪里，以及每个已配置的提供商
+// 能否按各自的代理设置连上服务地址。有问题时返回错误，便于在脚本中使用
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	providerFromEnv := fs.Bool("provider-from-env", false, "提供商取自环境变量，即使存在配置文件")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("用法: termi doctor [--provider-from-env]")
+	}
+
+	fmt.Printf("配置文件: %s\n", config.Path())
+	cfg, src, err := config.Load(*providerFromEnv)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	problems := 0
+	if src.Conflict() {
+		glyph.Printf("  ⚠ %s\n", src.Describe())
+	} else {
+		glyph.Printf("  ✓ %s\n", src.Describe())
+	}
+	if err := cfg.Validate(); err != nil {
+		glyph.Printf("  ✗ 配置无效: %v\n", err)
+		problems++
+	} else {
+		glyph.Println("  ✓ 配置有效")
+	}
+
+	fmt.Println("\n代理环境变量:")
+	found := false
+	for _, key := range proxyEnvKeys {
+		if v := os.Getenv(key); v != "" {
+			fmt.Printf("  %s=%s\n", key, redactProxy(v))
+			found = true
+		}
+	}
+	if !found {
+		fmt.Println("  未设置")
+	}
+
+	fmt.Println("\n提供商连通性:")
+	ctx, cancel := context.WithTimeout(context.Background(), doctorTimeout)
+	defer cancel()
+	for _, c := range llm.CheckNetwork(ctx, &cfg.LLM) {
+		name := c.Name
+		if c.Provider == cfg.LLM.Provider {
+			name += "（当前）"
+		}
+		route := "直连"
+		if c.Proxy != "" {
+			route = "经代理 " + c.Proxy
+		}
+		section := "llm." + strings.ReplaceAll(string(c.Provider), "-", "_")
+
+		if c.Err != nil {
+			problems++
+			glyph.Printf("  ✗ %s  %s\n      %s · %v\n", name, c.URL, route, c.Err)
+			if c.Proxy != "" {
+				fmt.Printf("      该地址不需要代理时，设置 %s.no_proxy 为 true 或将主机加入 NO_PROXY\n", section)
+			} else {
+				fmt.Printf("      需要经过代理时，设置 %s.proxy 或 HTTPS_PROXY 环境变量\n", section)
+			}
+			continue
+		}
+		glyph.Printf("  ✓ %s  %s\n      %s · HTTP %d · %dms\n", name, c.URL, route, c.Status, c.Latency.Milliseconds())
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("发现 %d 个问题", problems)
+	}
+	fmt.Println("\n一切正常")
+	return nil
+}
+
+// redactProxy 隐藏代理地址中的密码，NO_PROXY 这类非地址的取值原样返回
+func redactProxy(v string) string {
+	u, err := url.Parse(v)
+	if err != nil || u.User == nil {
+		return v
+	}
+	return u.Redacted()
+}
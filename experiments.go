@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/experiment"
+	"termi.sh/termi/internal/history"
+)
+
+// runExperiments 处理 termi experiments 子命令，按变体汇总历史记录中的采纳情况
+func runExperiments() error {
+	entries, err := history.Open(config.HistoryPath()).Load()
+	if err != nil {
+		return err
+	}
+	results := experiment.Report(entries)
+	if len(results) == 0 {
+		fmt.Println("历史记录中还没有参与实验的运行，请先在配置的 experiments 中添加变体")
+		return nil
+	}
+
+	fmt.Printf("  %s %s %s %s %s\n", runewidth.FillRight("变体", 16), runewidth.FillLeft("次数", 6),
+		runewidth.FillLeft("采纳", 6), runewidth.FillLeft("失败", 6), runewidth.FillLeft("采纳率", 8))
+	for _, r := range results {
+		fmt.Printf("  %s %6d %6d %6d %7.1f%%\n", runewidth.FillRight(r.Variant, 16), r.Total, r.Accepted, r.Failed, r.Rate()*100)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/scrollback"
+	"termi.sh/termi/internal/ui"
+)
+
+// 由 termi init 的 shell 集成脚本在每次调用 termi 时传入的上一条命令及其退出码
+const (
+	lastCommandEnv = "TERMI_LAST_COMMAND"
+	lastStatusEnv  = "TERMI_LAST_STATUS"
+)
+
+// fixScrollbackLines 在 tmux/screen 中附带的终端行数，让模型看到上一条命令的报错
+const fixScrollbackLines = 100
+
+// runFix 处理 termi fix 子命令：诊断 shell 中上一条命令失败的原因并给出修正后的命令
+func runFix(args []string) error {
+	command := strings.TrimSpace(os.Getenv(lastCommandEnv))
+	if command == "" {
+		return errors.New("未获取到上一条命令，termi fix 需要 shell 集成：在 ~/.bashrc 或 ~/.zshrc 中加入 eval \"$(termi init bash)\"（zsh 用 termi init zsh）")
+	}
+	status, err := strconv.Atoi(os.Getenv(lastStatusEnv))
+	if err != nil {
+		return fmt.Errorf("无法识别上一条命令的退出码: %q", os.Getenv(lastStatusEnv))
+	}
+	extra := strings.Join(args, " ")
+	if status == 0 && extra == "" {
+		return fmt.Errorf("上一条命令执行成功: %s\n结果不符合预期时请说明问题，例如 termi fix 输出是空的", command)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		showConfigHelp(err)
+		return err
+	}
+	// 在 tmux/screen 中顺带读取报错输出；读不到时只凭命令与退出码诊断
+	var opts []llm.Option
+	if output, err := scrollback.Capture(fixScrollbackLines); err == nil && strings.TrimSpace(output) != "" {
+		opts = append(opts, llm.WithTerminalOutput(output))
+	}
+	client, err := llm.NewClient(cfg, opts...)
+	if err != nil {
+		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+
+	// 上一条命令不是为了发给模型而输入的，可能带有令牌或密码，与命令输出一样先脱敏
+	command = client.Redact(command)
+	query := fmt.Sprintf("上一条命令 `%s` 执行失败（退出码 %d），诊断原因并给出修正后的命令", command, status)
+	if status == 0 {
+		query = fmt.Sprintf("上一条命令 `%s` 的结果不符合预期，诊断原因并给出修正后的命令", command)
+	}
+	if extra != "" {
+		query += "：" + extra
+	}
+	return ui.RunApp(cfg, client, query)
+}
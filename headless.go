@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"termi.sh/termi/internal/approval"
+	"termi.sh/termi/internal/backup"
+	"termi.sh/termi/internal/cmddb"
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/project"
+	"termi.sh/termi/internal/runner"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/telemetry"
+)
+
+// exitError 以指定退出码结束进程，不再输出错误信息（例如 --yes 执行的命令失败时透传其退出码）
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// exitAsk 模型需要追问而非交互模式无法回答时的退出码
+const exitAsk = 2
+
+// headlessOptions 非交互模式的输出与执行方式
+type headlessOptions struct {
+	print bool // 只向标准输出写入命令
+	json  bool // 以 JSON 输出完整结果
+	yes   bool // 不经选择直接执行最推荐的命令
+}
+
+func (o headlessOptions) enabled() bool {
+	return o.print || o.json || o.yes
+}
+
+// candidate JSON 输出中的一条候选命令
+type candidate struct {
+	Command     string   `json:"command"`
+	Approach    string   `json:"approach,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Risk        string   `json:"risk,omitempty"`      // 模型自评的风险等级
+	Safety      string   `json:"safety"`              // 本地规则检查的风险等级
+	Reasons     []string `json:"reasons,omitempty"`   // 本地规则命中的说明
+	Notes       []string `json:"notes,omitempty"`     // 自动调整或兼容性提示
+	Source      string   `json:"source"`              // llm 或 offline
+	Blocked     bool     `json:"blocked,omitempty"`   // 命中 safety.blocklist
+	Forbidden   bool     `json:"forbidden,omitempty"` // 命中 safety.forbidden，不能执行或复制
+	Injection   []string `json:"injection,omitempty"` // 疑似提示词注入的说明，--yes 不会执行
+}
+
+// result JSON 输出
+type result struct {
+	Query       string      `json:"query"`
+	Candidates  []candidate `json:"candidates,omitempty"`
+	Ask         string      `json:"ask,omitempty"`
+	Fields      []llm.Field `json:"fields,omitempty"` // 追问需要的结构化取值
+	Answer      string      `json:"answer,omitempty"` // 知识性问题的回答，此时没有候选命令
+	Explanation string      `json:"explanation,omitempty"`
+	Assumptions string      `json:"assumptions,omitempty"`
+	Provider    string      `json:"provider,omitempty"` // 实际回答的提供商，主提供商失败后可能是 failover 中的提供商
+	Model       string      `json:"model,omitempty"`    // 实际回答的模型
+	Intent      string      `json:"intent,omitempty"`   // 识别出的查询意图，未配置 intents 时为空
+	Executed    string      `json:"executed,omitempty"`
+	ExitCode    *int        `json:"exit_code,omitempty"`
+
+	slow *llm.Slow
+}
+
+// runHeadless 不启动终端界面，生成命令后按选项输出或直接执行，供脚本与 shell 快捷键使用。
+// 模型追问时无法回答，问题写入标准错误（--json 时写入 ask 字段）并以退出码 2 结束
+func runHeadless(cfg *config.Config, client *llm.Client, query string, opts headlessOptions) error {
+	ctx, span := telemetry.Start(context.Background(), "termi.run",
+		attribute.String("llm.provider", client.ProviderName()),
+		attribute.Bool("termi.headless", true))
+	defer span.End()
+
+	analyzer, err := safety.New(cfg.Safety)
+	if err != nil {
+		return err
+	}
+
+	warnUntrusted(cfg, client)
+	res, err := suggestHeadless(client.StartSession(ctx), cfg, client, query, analyzer)
+	if err != nil {
+		return err
+	}
+	if res.slow != nil {
+		warnSlow(cfg, res.slow)
+	}
+
+	if res.Ask != "" {
+		if opts.json {
+			if err := writeJSON(res); err != nil {
+				return err
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "需要补充信息: %s\n", res.Ask)
+		}
+		return exitError{code: exitAsk}
+	}
+
+	// 问题不需要执行命令，--yes 也只输出回答
+	if res.Answer != "" {
+		if opts.json {
+			return writeJSON(res)
+		}
+		fmt.Println(res.Answer)
+		return nil
+	}
+
+	best := res.Candidates[0]
+	if opts.yes {
+		code, err := executeHeadless(cfg, client, res, best, analyzer.WithStrict(cfg.StrictSafety(config.Intent(res.Intent))))
+		if err != nil {
+			return err
+		}
+		res.Executed, res.ExitCode = best.Command, &code
+		if opts.json {
+			if err := writeJSON(res); err != nil {
+				return err
+			}
+		}
+		if code != 0 {
+			return exitError{code: code}
+		}
+		return nil
+	}
+
+	if opts.json {
+		return writeJSON(res)
+	}
+	// 输出的命令会被放到命令行或脚本中，禁止的命令同样不能这样"复制"出去
+	if best.Forbidden {
+		return fmt.Errorf("%w，请在交互模式中输入覆盖码: %s", safety.ErrForbidden, best.Command)
+	}
+	fmt.Println(best.Command)
+	return nil
+}
+
+// suggestHeadless 先查离线命令库，未命中时请求 LLM
+func suggestHeadless(ctx context.Context, cfg *config.Config, client *llm.Client, query string, analyzer *safety.Analyzer) (*result, error) {
+	res := &result{Query: query}
+	if !cfg.DisableCommandDB && client.Host() == "" && client.PipedInput().Text == "" && len(client.Files()) == 0 {
+		if db, err := cmddb.Load(config.CommandDBPath()); err == nil {
+			if command, description, ok := db.Lookup(query); ok {
+				res.Candidates = append(res.Candidates, newCandidate(query, command, "", description, "", nil, "offline", analyzer))
+				return res, nil
+			}
+		}
+	}
+
+	reply, err := client.AskSmart(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	res.Ask = reply.Ask
+	res.Fields = reply.Fields
+	res.Answer = reply.Answer
+	res.Explanation = reply.Explanation
+	res.Assumptions = reply.Assumptions
+	res.Provider = reply.Provider
+	res.Model = reply.Model
+	res.Intent = string(reply.Intent)
+	res.slow = reply.Slow
+	if reply.Ask != "" || reply.Command == "" && reply.Answer != "" {
+		return res, nil
+	}
+	analyzer = analyzer.WithStrict(cfg.StrictSafety(reply.Intent))
+	if reply.Command == "" {
+		return nil, fmt.Errorf("LLM 未能生成可执行命令，请尝试提供更详细的描述")
+	}
+	res.Candidates = append(res.Candidates, newCandidate(query, reply.Command, reply.Approach, reply.Description, reply.Risk, reply.Notes, "llm", analyzer))
+	for _, alt := range reply.Alternatives {
+		res.Candidates = append(res.Candidates, newCandidate(query, alt.Command, alt.Approach, alt.Description, alt.Risk, alt.Notes, "llm", analyzer))
+	}
+	return res, nil
+}
+
+func newCandidate(query, command, approach, description, risk string, notes []string, source string, analyzer *safety.Analyzer) candidate {
+	r := analyzer.Analyze(command)
+	c := candidate{
+		Command:     command,
+		Approach:    approach,
+		Description: description,
+		Risk:        risk,
+		Safety:      r.Level.String(),
+		Notes:       notes,
+		Source:      source,
+		Blocked:     r.Blocked,
+		Forbidden:   r.Forbidden,
+		Injection:   analyzer.Inspect(command, query),
+	}
+	if !r.Allowed {
+		c.Reasons = r.Reasons
+	}
+	return c
+}
+
+// executeHeadless 执行命令并返回退出码。没有人确认，因此复制模式、禁止的命令、blocklist 与高危命令一律拒绝执行
+func executeHeadless(cfg *config.Config, client *llm.Client, res *result, c candidate, analyzer *safety.Analyzer) (int, error) {
+	r := analyzer.Analyze(c.Command)
+	switch {
+	case cfg.Safety.CopyOnly:
+		return 0, fmt.Errorf("已开启仅复制模式 (safety.copy_only)，不执行命令: %s", c.Command)
+	case r.Forbidden:
+		return 0, fmt.Errorf("%w，请在交互模式中输入覆盖码: %s", safety.ErrForbidden, c.Command)
+	case r.Blocked:
+		return 0, fmt.Errorf("%w: %s", safety.ErrBlocked, c.Command)
+	case !r.Allowed && r.Level >= safety.High:
+		return 0, fmt.Errorf("--yes 不会执行%s命令（%v），请在交互模式中确认: %s", r.Level, r.Reasons, c.Command)
+	case len(c.Injection) > 0:
+		return 0, fmt.Errorf("%w（%v），请在交互模式中审查: %s", safety.ErrNotReviewed, c.Injection, c.Command)
+	}
+	// 审批可能需要用户粘贴令牌，与其他需要确认的命令一样只在交互模式中执行
+	if gate, err := approval.New(cfg.Safety.Approval, config.ApprovalsPath()); err != nil {
+		return 0, err
+	} else if gate.Required(c.Command, r) {
+		return 0, fmt.Errorf("%w，--yes 不会提交审批，请在交互模式中执行: %s", approval.ErrNotApproved, c.Command)
+	}
+
+	opts := []runner.Option{runner.WithShell(client.Shell()), runner.WithLimits(runner.LimitsFrom(cfg.Exec.Limits))}
+	if host := client.Host(); host != "" {
+		opts = []runner.Option{runner.WithSSHHost(host)}
+	} else if cfg.Exec.Backup {
+		backupHeadless(cfg, c.Command)
+	}
+	command := c.Command
+	if cfg.Exec.Attribution {
+		a := runner.Attribution{Source: c.Source, Time: time.Now()}
+		if c.Source == "llm" {
+			a.Model = res.Model
+		}
+		sh := client.Shell()
+		if client.Host() != "" {
+			sh = ""
+		}
+		command = runner.Attribute(command, sh, a)
+	}
+	fmt.Fprintf(os.Stderr, "执行命令: %s\n", command)
+	execErr := runner.Run(command, opts...)
+	code := runner.ExitCode(execErr)
+	client.LearnExecution(c.Command, code)
+
+	if !cfg.History.Disabled {
+		e := history.Entry{Query: res.Query, Command: c.Command, Action: history.ActionExecuted, ExitCode: &code, Variant: client.Variant()}
+		if err := history.Open(config.HistoryPath()).Append(e); err != nil {
+			fmt.Fprintf(os.Stderr, "保存历史记录失败: %v\n", err)
+		}
+	}
+	return code, nil
+}
+
+// backupHeadless 执行前备份命令将要改动的文件，失败时只提示，不阻止执行
+func backupHeadless(cfg *config.Config, command string) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	op, err := backup.Protect(config.BackupsDir(), command, dir, cfg.Exec.BackupBytes())
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "未能备份受影响的文件: %v\n", err)
+	case op != nil:
+		fmt.Fprintf(os.Stderr, "已备份 %d 个受影响的路径，可用 termi undo 恢复\n", len(op.Entries))
+	}
+}
+
+func writeJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// warnSlow 提示本次请求明显慢于该提供商近期的请求，写入标准错误，不影响输出的命令
+func warnSlow(cfg *config.Config, s *llm.Slow) {
+	hint := "上下文过大？试试 --lite"
+	if cfg.Lite {
+		hint = "可能是网络或提供商本身较慢"
+	}
+	fmt.Fprintf(os.Stderr, "提示: 本次请求耗时 %.1fs，超过近期 95%% 的请求（%.1fs），提示词 %.1f KB，%s\n",
+		s.Latency.Seconds(), s.P95.Seconds(), float64(s.Prompt)/1024, hint)
+}
+
+// warnUntrusted 非交互模式下无法询问是否信任当前目录，未做过决定时提示项目文件没有被读取
+func warnUntrusted(cfg *config.Config, client *llm.Client) {
+	if cfg.DisableProjectTasks && !cfg.Context.Git || client.Host() != "" {
+		return
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if _, decided := client.Trust().Lookup(dir); !decided && project.HasContext(dir) {
+		fmt.Fprintln(os.Stderr, "提示: 当前目录未受信任，没有读取其中的项目任务与 git 状态；运行 termi trust 信任此目录")
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/ui"
+)
+
+// runHistory 处理 termi history 子命令：浏览、搜索历史记录，重新执行、复制或删除其中的命令，
+// 参数作为初始的搜索关键字。shell widget 通过 --edited 报告交还的命令实际是怎样执行的
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	edited := fs.String("edited", "", "交还给命令行的原命令（由 shell widget 使用）")
+	exitCode := fs.Int("exit", 0, "实际执行的命令的退出码（由 shell widget 使用）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		showConfigHelp(err)
+		return err
+	}
+	if *edited != "" {
+		return recordEdit(cfg, *edited, strings.Join(fs.Args(), " "), *exitCode)
+	}
+
+	client, err := llm.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+	return ui.RunHistory(cfg, client, strings.Join(fs.Args(), " "))
+}
+
+// recordEdit 记录交还给命令行的命令实际的执行结果：原样执行记为 executed，修改后执行记为
+// edited 并保存修改后的命令，供相似需求参考。需求取自最近一次交还该命令的记录；
+// 找不到对应记录时说明命令不是 termi 交还的，忽略
+func recordEdit(cfg *config.Config, generated, executed string, exitCode int) error {
+	executed = strings.TrimSpace(executed)
+	if cfg.History.Disabled || executed == "" {
+		return nil
+	}
+	store := history.Open(config.HistoryPath())
+	entries, err := store.Load()
+	if err != nil {
+		return err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Action != history.ActionInserted || e.Command != generated {
+			continue
+		}
+		if executed == generated {
+			return store.Append(history.Entry{Query: e.Query, Command: generated, Action: history.ActionExecuted, ExitCode: &exitCode, Variant: e.Variant})
+		}
+		return store.Append(history.Entry{
+			Query:    e.Query,
+			Command:  generated,
+			Action:   history.ActionEdited,
+			ExitCode: &exitCode,
+			Edited:   executed,
+			Variant:  e.Variant,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+// Package approval 实现执行前的变更管理审批：命中审批策略的命令先发送到外部审批 webhook
+// （ChatOps 机器人、ITSM 等），取得审批令牌后才执行。请求、批准、拒绝与执行结果都追加到审计日志
+package approval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/filelock"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/safety"
+)
+
+// ErrNotApproved 命令需要审批但没有取得有效的审批令牌
+var ErrNotApproved = errors.New("命令未通过变更审批，已取消执行")
+
+// Request 发送给审批 webhook 的待执行命令
+type Request struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Query   string    `json:"query,omitempty"`
+	Host    string    `json:"host,omitempty"` // SSH 目标主机，本机为空
+	Dir     string    `json:"dir,omitempty"`
+	User    string    `json:"user,omitempty"`
+	Level   string    `json:"level"`
+	Reasons []string  `json:"reasons,omitempty"`
+}
+
+// response 审批 webhook 的响应：approved 时附带令牌，denied 表示拒绝，
+// pending 表示审批在外部异步进行，批准后由用户粘贴令牌
+type response struct {
+	Status   string `json:"status"`
+	Token    string `json:"token,omitempty"`
+	Approver string `json:"approver,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// event 审计日志中的一条记录
+type event struct {
+	Time     time.Time `json:"time"`
+	ID       string    `json:"id"`
+	Event    string    `json:"event"` // requested、approved、denied、failed、executed
+	Command  string    `json:"command"`
+	Host     string    `json:"host,omitempty"`
+	Dir      string    `json:"dir,omitempty"`
+	User     string    `json:"user,omitempty"`
+	Via      string    `json:"via,omitempty"` // 令牌来自 webhook 还是手动粘贴
+	Approver string    `json:"approver,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+	ExitCode *int      `json:"exit_code,omitempty"`
+}
+
+// Gate 按配置的审批策略拦截命令
+type Gate struct {
+	url      string
+	headers  map[string]string
+	secret   string
+	timeout  time.Duration
+	level    safety.Level
+	commands []*regexp.Regexp
+	audit    string
+}
+
+// New 根据配置创建审批策略，未配置审批时返回 nil，nil Gate 不要求任何命令审批
+func New(ac *config.ApprovalConfig, audit string) (*Gate, error) {
+	if ac == nil {
+		return nil, nil
+	}
+	if err := ac.Validate(); err != nil {
+		return nil, err
+	}
+	g := &Gate{
+		url:     ac.URL,
+		headers: ac.Headers,
+		secret:  os.ExpandEnv(ac.Secret),
+		timeout: ac.ApprovalTimeout(),
+		level:   safety.High,
+		audit:   audit,
+	}
+	if ac.Level == "critical" {
+		g.level = safety.Critical
+	}
+	for _, p := range ac.Commands {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		g.commands = append(g.commands, re)
+	}
+	return g, nil
+}
+
+// Required 报告命令是否需要审批：风险达到配置的等级（allowlist 中的命令除外），或命中审批规则
+func (g *Gate) Required(command string, r safety.Result) bool {
+	if g == nil {
+		return false
+	}
+	if !r.Allowed && r.Level >= g.level {
+		return true
+	}
+	for _, re := range g.commands {
+		if re.MatchString(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRequest 创建待审批的请求，附带当前用户与工作目录
+func NewRequest(command, query, host string, r safety.Result) *Request {
+	req := &Request{
+		ID:      newID(),
+		Time:    time.Now(),
+		Command: command,
+		Query:   query,
+		Host:    host,
+		Level:   r.Level.String(),
+		Reasons: r.Reasons,
+	}
+	req.Dir, _ = os.Getwd()
+	if u, err := user.Current(); err == nil {
+		req.User = u.Username
+	}
+	return req
+}
+
+// Approve 把请求发送到审批 webhook 并等待结果。webhook 直接批准时返回，拒绝时返回
+// ErrNotApproved；审批异步进行或 webhook 不可用时，配置了 Secret 就提示用户粘贴审批令牌
+func (g *Gate) Approve(ctx context.Context, req *Request) error {
+	g.record(req, event{Event: "requested"})
+	glyph.Printf("📝 该命令需要变更审批（%s），已提交审批请求 %s\n", strings.Join(req.Reasons, "；"), req.ID)
+
+	resp, err := g.post(ctx, req)
+	switch {
+	case err != nil:
+		g.record(req, event{Event: "failed", Detail: err.Error()})
+		glyph.Printf("⚠️  %v\n", err)
+	case resp.Status == "approved" && resp.Token != "":
+		if !g.valid(req, resp.Token) {
+			g.record(req, event{Event: "denied", Via: "webhook", Approver: resp.Approver, Detail: "令牌校验失败"})
+			return fmt.Errorf("%w: 审批 webhook 返回的令牌校验失败", ErrNotApproved)
+		}
+		g.record(req, event{Event: "approved", Via: "webhook", Approver: resp.Approver, Detail: resp.Message})
+		glyph.Printf("✅ 已获得审批%s\n", approvedBy(resp.Approver))
+		return nil
+	case resp.Status == "denied":
+		g.record(req, event{Event: "denied", Via: "webhook", Approver: resp.Approver, Detail: resp.Message})
+		if resp.Message != "" {
+			return fmt.Errorf("%w: %s", ErrNotApproved, resp.Message)
+		}
+		return ErrNotApproved
+	case resp.Message != "":
+		fmt.Println(resp.Message)
+	}
+
+	if g.secret == "" {
+		g.record(req, event{Event: "denied", Detail: "未取得审批令牌"})
+		return ErrNotApproved
+	}
+	fmt.Printf("审批通过后请粘贴审批令牌（直接回车取消）: ")
+	token, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	token = strings.TrimSpace(token)
+	if token == "" || !g.valid(req, token) {
+		detail := "令牌校验失败"
+		if token == "" {
+			detail = "用户取消"
+		}
+		g.record(req, event{Event: "denied", Via: "pasted", Detail: detail})
+		return ErrNotApproved
+	}
+	g.record(req, event{Event: "approved", Via: "pasted"})
+	glyph.Println("✅ 审批令牌有效")
+	return nil
+}
+
+// Executed 在审计日志中记录已审批命令的退出码
+func (g *Gate) Executed(req *Request, exitCode int) {
+	if g == nil || req == nil {
+		return
+	}
+	g.record(req, event{Event: "executed", ExitCode: &exitCode})
+}
+
+// Token 返回请求 ID 对应的审批令牌，供审批方（机器人、ITSM 集成）签发
+func Token(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// valid 校验审批令牌；未配置 Secret 时信任 webhook 返回的任意令牌
+func (g *Gate) valid(req *Request, token string) bool {
+	if g.secret == "" {
+		return token != ""
+	}
+	return hmac.Equal([]byte(strings.ToLower(token)), []byte(Token(g.secret, req.ID)))
+}
+
+func (g *Gate) post(ctx context.Context, req *Request) (*response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("构建审批请求失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(g.url), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建审批请求失败: %w", err)
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+	for k, v := range g.headers {
+		hreq.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := http.DefaultClient.Do(hreq)
+	if err != nil {
+		return nil, fmt.Errorf("发送审批请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("发送审批请求失败: HTTP %d", resp.StatusCode)
+	}
+
+	var out response
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, fmt.Errorf("读取审批响应失败: %w", err)
+	}
+	// 只确认收到请求、不返回 JSON 的 webhook 视为审批在外部进行
+	if len(bytes.TrimSpace(body)) > 0 && json.Unmarshal(body, &out) != nil {
+		return nil, fmt.Errorf("审批响应不是有效的 JSON")
+	}
+	return &out, nil
+}
+
+// record 在文件锁内向审计日志追加一条记录，写入失败不影响审批流程
+func (g *Gate) record(req *Request, e event) {
+	e.Time, e.ID, e.Command, e.Host, e.Dir, e.User = time.Now(), req.ID, req.Command, req.Host, req.Dir, req.User
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	unlock, err := filelock.Lock(g.audit)
+	if err != nil {
+		return
+	}
+	defer unlock()
+	f, err := os.OpenFile(g.audit, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(line, '\n'))
+}
+
+func approvedBy(approver string) string {
+	if approver == "" {
+		return ""
+	}
+	return "（" + approver + "）"
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return time.Now().Format("20060102") + "-" + hex.EncodeToString(b)
+}
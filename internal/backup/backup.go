@@ -0,0 +1,421 @@
+// Package backup 在执行会修改或删除文件的命令前，把命令涉及的已有文件复制到备份目录，
+// 并恢复最近一次备份（termi undo）。受影响的路径从命令文本中静态提取：rm、mv、cp、
+// sed -i 等的操作对象以及重定向的目标，通配符在当前目录展开；变量、命令替换等
+// 无法静态确定的路径不备份，因此备份是尽力而为的补救手段，不能代替执行前的确认
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// keep 最多保留的备份数，更早的备份在新建备份时删除
+const keep = 20
+
+// ErrTooLarge 受影响的文件超过了备份大小上限
+var ErrTooLarge = errors.New("受影响的文件超过备份大小上限")
+
+// Entry 一个受影响的路径
+type Entry struct {
+	Path   string `json:"path"`             // 绝对路径
+	Stored string `json:"stored,omitempty"` // 备份目录中的副本，相对于备份目录
+	Absent bool   `json:"absent,omitempty"` // 执行前不存在，由命令新建，恢复时删除
+}
+
+// Operation 一次执行前的备份
+type Operation struct {
+	Command string    `json:"command"`
+	Dir     string    `json:"dir"` // 执行命令的工作目录
+	Time    time.Time `json:"time"`
+	Entries []Entry   `json:"entries"`
+
+	root string // 备份所在目录
+}
+
+var (
+	// segmentSep 拆分命令序列与管道
+	segmentSep = regexp.MustCompile(`;|&&|\|\||\||\n`)
+
+	// redirect 输出重定向及其目标，>&2 这类复制文件描述符的写法不算
+	redirect = regexp.MustCompile(`\d*>>?\|?\s*([^\s&;|<>()]+)`)
+)
+
+// Targets 返回命令会修改、删除或新建的路径，相对路径按 dir 解析
+func Targets(command, dir string) []string {
+	var paths []string
+	add := func(p string) {
+		if p = resolve(p, dir); p != "" && !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+
+	for _, seg := range segmentSep.Split(command, -1) {
+		for _, m := range redirect.FindAllStringSubmatch(seg, -1) {
+			if !strings.HasPrefix(m[1], "/dev/") {
+				add(m[1])
+			}
+		}
+		seg = redirect.ReplaceAllString(seg, " ")
+
+		program, args := split(seg)
+		flags, operands := classify(program, args)
+		switch program {
+		case "rm", "unlink", "shred", "truncate", "tee":
+			for _, o := range operands {
+				add(o)
+			}
+		case "sed":
+			if !inPlace(flags) {
+				continue
+			}
+			// 没有 -e、-f 时第一个操作数是脚本
+			if !slices.ContainsFunc(flags, func(f string) bool { return f == "-e" || f == "-f" || f == "--expression" || f == "--file" }) && len(operands) > 0 {
+				operands = operands[1:]
+			}
+			for _, o := range operands {
+				add(o)
+			}
+		case "mv", "cp":
+			if len(operands) < 2 {
+				continue
+			}
+			sources, dest := operands[:len(operands)-1], operands[len(operands)-1]
+			if program == "mv" {
+				for _, o := range sources {
+					add(o)
+				}
+			}
+			// 目标是已有目录时，被覆盖或新建的是目录下的同名文件
+			if info, err := os.Stat(resolve(dest, dir)); err == nil && info.IsDir() {
+				for _, src := range sources {
+					add(filepath.Join(dest, filepath.Base(src)))
+				}
+				continue
+			}
+			add(dest)
+		}
+	}
+
+	// 展开通配符，匹配不到时原样保留（可能由命令新建）
+	var out []string
+	for _, p := range paths {
+		if !strings.ContainsAny(p, "*?[") {
+			out = append(out, p)
+			continue
+		}
+		matches, _ := filepath.Glob(p)
+		for _, m := range matches {
+			if !slices.Contains(out, m) {
+				out = append(out, m)
+			}
+		}
+	}
+	return out
+}
+
+// split 返回命令的程序名与参数，跳过 sudo 与前置的环境变量赋值
+func split(seg string) (string, []string) {
+	fields := words(seg)
+	for i, f := range fields {
+		if f == "sudo" || strings.Contains(f, "=") && !strings.HasPrefix(f, "-") {
+			continue
+		}
+		return filepath.Base(f), fields[i+1:]
+	}
+	return "", nil
+}
+
+// words 按空白拆分参数并去掉引号，引号内的空白不拆分
+func words(s string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote rune
+	inWord := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				out = append(out, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// classify 把参数分为选项与操作数，带取值的选项连同取值一起归入选项
+func classify(program string, args []string) (flags, operands []string) {
+	withValue := map[string]bool{}
+	switch program {
+	case "truncate":
+		withValue = map[string]bool{"-s": true, "-r": true}
+	case "sed":
+		withValue = map[string]bool{"-e": true, "-f": true, "--expression": true, "--file": true}
+	case "cp", "mv":
+		withValue = map[string]bool{"-S": true}
+	}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			return flags, append(operands, args[i+1:]...)
+		case strings.HasPrefix(a, "-") && a != "-":
+			flags = append(flags, a)
+			if withValue[a] && i+1 < len(args) {
+				i++
+			}
+		default:
+			operands = append(operands, a)
+		}
+	}
+	return flags, operands
+}
+
+// inPlace 判断 sed 是否原地修改文件
+func inPlace(flags []string) bool {
+	for _, f := range flags {
+		if strings.HasPrefix(f, "--in-place") || !strings.HasPrefix(f, "--") && strings.Contains(f, "i") {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve 把路径转换为绝对路径；含变量或命令替换、无法静态确定的路径返回空字符串
+func resolve(p, dir string) string {
+	if p == "" || strings.ContainsAny(p, "$`") {
+		return ""
+	}
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return filepath.Clean(p)
+}
+
+// Protect 备份命令将要改动的路径，保存在 root 下的新目录中。没有任何已有文件受影响时
+// 返回 nil；大小超过 limit 时返回 ErrTooLarge，不做备份
+func Protect(root, command, dir string, limit int64) (*Operation, error) {
+	targets := Targets(command, dir)
+	var size int64
+	existing := 0
+	for _, p := range targets {
+		if _, err := os.Lstat(p); err != nil {
+			continue
+		}
+		existing++
+		n, err := usage(p, limit-size)
+		if err != nil {
+			return nil, err
+		}
+		if size += n; size > limit {
+			return nil, fmt.Errorf("%w（%d MB）", ErrTooLarge, limit>>20)
+		}
+	}
+	if existing == 0 {
+		return nil, nil
+	}
+
+	op := &Operation{
+		Command: command,
+		Dir:     dir,
+		Time:    time.Now(),
+		root:    filepath.Join(root, time.Now().Format("20060102-150405.000000000")),
+	}
+	for i, p := range targets {
+		if _, err := os.Lstat(p); err != nil {
+			op.Entries = append(op.Entries, Entry{Path: p, Absent: true})
+			continue
+		}
+		stored := filepath.Join("files", fmt.Sprint(i))
+		if err := copyPath(p, filepath.Join(op.root, stored)); err != nil {
+			os.RemoveAll(op.root)
+			return nil, fmt.Errorf("备份 %s 失败: %w", p, err)
+		}
+		op.Entries = append(op.Entries, Entry{Path: p, Stored: stored})
+	}
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(op.root, "manifest.json"), data, 0600); err != nil {
+		os.RemoveAll(op.root)
+		return nil, err
+	}
+	prune(root)
+	return op, nil
+}
+
+// usage 统计路径下普通文件的总大小，超过 limit 后停止统计
+func usage(path string, limit int64) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if total += info.Size(); total > limit {
+				return fs.SkipAll
+			}
+		}
+		return nil
+	})
+	return total, err
+}
+
+// copyPath 复制文件、目录或符号链接，保留权限
+func copyPath(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		// 设备、管道等特殊文件无法备份，跳过
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// List 返回 root 下的全部备份，从新到旧排列
+func List(root string) ([]*Operation, error) {
+	dirs, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ops []*Operation
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := filepath.Join(root, dirs[i].Name())
+		data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+		if err != nil {
+			continue
+		}
+		op := &Operation{root: dir}
+		if json.Unmarshal(data, op) == nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// Last 返回最近一次备份，没有备份时返回 nil
+func Last(root string) (*Operation, error) {
+	ops, err := List(root)
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+	return ops[0], nil
+}
+
+// Restore 把受影响的路径恢复到执行前的状态：删除命令新建的路径，用副本替换被改动的路径。
+// 全部恢复成功后删除这次备份
+func (op *Operation) Restore() error {
+	var errs []error
+	for _, e := range slices.Backward(op.Entries) {
+		if err := os.RemoveAll(e.Path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if e.Absent {
+			continue
+		}
+		if err := copyPath(filepath.Join(op.root, e.Stored), e.Path); err != nil {
+			errs = append(errs, fmt.Errorf("恢复 %s 失败: %w", e.Path, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return os.RemoveAll(op.root)
+}
+
+// Discard 删除这次备份
+func (op *Operation) Discard() error {
+	return os.RemoveAll(op.root)
+}
+
+// prune 只保留最近的 keep 次备份
+func prune(root string) {
+	ops, err := List(root)
+	if err != nil {
+		return
+	}
+	for _, op := range ops[min(len(ops), keep):] {
+		_ = op.Discard()
+	}
+}
@@ -0,0 +1,230 @@
+// Package clipboard 将文本复制到剪贴板，后端可以是系统剪贴板、tmux 或 screen 缓冲区、OSC 52 转义序列或文件
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/mux"
+)
+
+// Clipboard 剪贴板后端
+type Clipboard interface {
+	// Name 返回后端名称，用于提示复制到了哪里
+	Name() string
+
+	// Copy 复制文本
+	Copy(text string) error
+}
+
+// New 根据配置创建剪贴板后端。未配置时在 tmux 或 screen 会话中使用 Multiplexed，否则使用系统剪贴板
+func New(cc config.ClipboardConfig) (Clipboard, error) {
+	if err := cc.Validate(); err != nil {
+		return nil, err
+	}
+	switch cc.Backend {
+	case "":
+		if kind := mux.Detect(); kind != mux.None {
+			return NewMultiplexed(kind), nil
+		}
+		return System(), nil
+	case config.ClipboardSystem:
+		return System(), nil
+	case config.ClipboardTmux:
+		return Tmux{}, nil
+	case config.ClipboardScreen:
+		return Screen{}, nil
+	case config.ClipboardOSC52:
+		return OSC52{}, nil
+	case config.ClipboardFile:
+		return File{Path: expandHome(cc.Path)}, nil
+	default:
+		return nil, fmt.Errorf("不支持的剪贴板后端: %s", cc.Backend)
+	}
+}
+
+// pipe 运行命令并将文本写入其标准输入
+func pipe(text, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Tmux 写入 tmux 的粘贴缓冲区，可在 tmux 中用 prefix + ] 粘贴
+type Tmux struct{}
+
+func (Tmux) Name() string { return "tmux 缓冲区" }
+
+func (Tmux) Copy(text string) error {
+	if os.Getenv("TMUX") == "" {
+		return fmt.Errorf("当前不在 tmux 会话中")
+	}
+	return pipe(text, "tmux", "load-buffer", "-")
+}
+
+// Screen 写入 GNU screen 的粘贴缓冲区，可在 screen 中用 C-a ] 粘贴
+type Screen struct{}
+
+func (Screen) Name() string { return "screen 缓冲区" }
+
+func (Screen) Copy(text string) error {
+	if os.Getenv("STY") == "" {
+		return fmt.Errorf("当前不在 screen 会话中")
+	}
+	// screen 只能从文件读入缓冲区，经临时文件中转
+	f, err := os.CreateTemp("", "termi-clip-*.txt")
+	if err != nil {
+		return err
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	_, err = f.WriteString(text)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if out, err := exec.Command("screen", "-X", "readbuf", "-e", "utf8", path).CombinedOutput(); err != nil {
+		return fmt.Errorf("screen: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Multiplexed tmux 或 screen 会话中的默认后端：写入复用器的粘贴缓冲区，同时通过 OSC 52 透传让外层终端
+// 设置剪贴板，并尝试系统剪贴板。远程会话中没有 pbcopy、xclip 时也能复制到本地，任一方式成功即可
+type Multiplexed struct {
+	Kind mux.Kind
+
+	Buffer   Clipboard // 复用器的粘贴缓冲区
+	Terminal Clipboard // 外层终端的剪贴板，不会回报是否复制成功
+	System   Clipboard // 系统剪贴板
+}
+
+// NewMultiplexed 返回写入 kind 对应复用器的 Multiplexed
+func NewMultiplexed(kind mux.Kind) Multiplexed {
+	var buffer Clipboard = Tmux{}
+	if kind == mux.Screen {
+		buffer = Screen{}
+	}
+	return Multiplexed{Kind: kind, Buffer: buffer, Terminal: OSC52{}, System: System()}
+}
+
+func (m Multiplexed) Name() string { return string(m.Kind) + " 缓冲区与剪贴板" }
+
+func (m Multiplexed) Copy(text string) error {
+	err := m.Buffer.Copy(text)
+	// 终端不会回报是否支持 OSC 52，写入成功也不能说明已复制，因此不计入结果
+	_ = m.Terminal.Copy(text)
+	if serr := m.System.Copy(text); serr == nil {
+		return nil
+	}
+	return err
+}
+
+// screenChunk screen 透传 OSC 52 时每段的字节数，需小于其 768 字节的字符串上限
+const screenChunk = 512
+
+// OSC52 通过 OSC 52 转义序列让终端模拟器设置剪贴板，SSH 会话中也能复制到本地；
+// 需要终端支持（iTerm2、kitty、WezTerm、Windows Terminal 等），tmux 中需开启 set-clipboard 或 allow-passthrough
+type OSC52 struct {
+	// W 转义序列的写入目标，为空时写入 /dev/tty，无法打开时写入标准错误
+	W io.Writer
+}
+
+func (OSC52) Name() string { return "终端剪贴板 (OSC 52)" }
+
+func (o OSC52) Copy(text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	switch mux.Detect() {
+	case mux.Tmux:
+		// tmux passthrough：内部的 ESC 需要转义为两个 ESC
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case mux.Screen:
+		// screen 的 DCS 透传限制单段长度，需要拆成多段依次透传
+		var b strings.Builder
+		for len(seq) > 0 {
+			n := min(len(seq), screenChunk)
+			b.WriteString("\x1bP" + seq[:n] + "\x1b\\")
+			seq = seq[n:]
+		}
+		seq = b.String()
+	}
+
+	w := o.W
+	if w == nil {
+		if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+			defer tty.Close()
+			w = tty
+		} else {
+			w = os.Stderr
+		}
+	}
+	_, err := io.WriteString(w, seq)
+	return err
+}
+
+// File 将文本写入文件，适用于没有剪贴板的环境或交给其他工具读取
+type File struct {
+	Path string
+}
+
+func (f File) Name() string { return f.Path }
+
+func (f File) Copy(text string) error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.Path, []byte(text), 0600)
+}
+
+// Fake 内存中的剪贴板，供测试使用
+type Fake struct {
+	mu   sync.Mutex
+	text string
+
+	// Err 不为空时 Copy 返回该错误
+	Err error
+}
+
+func (*Fake) Name() string { return "fake" }
+
+func (f *Fake) Copy(text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.text = text
+	return nil
+}
+
+// Text 返回最近一次复制的文本
+func (f *Fake) Text() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.text
+}
+
+// expandHome 展开路径开头的 ~/
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
@@ -0,0 +1,145 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/mux"
+)
+
+func TestMultiplexedCopy(t *testing.T) {
+	errBuffer, errSystem := errors.New("buffer"), errors.New("system")
+	tests := []struct {
+		name              string
+		bufferErr, sysErr error
+		want              error
+	}{
+		{"AllSucceed", nil, nil, nil},
+		{"BufferOnly", nil, errSystem, nil},
+		{"SystemOnly", errBuffer, nil, nil},
+		{"AllFail", errBuffer, errSystem, errBuffer},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buffer, terminal, system := &Fake{Err: tc.bufferErr}, &Fake{}, &Fake{Err: tc.sysErr}
+			m := Multiplexed{Kind: mux.Tmux, Buffer: buffer, Terminal: terminal, System: system}
+			if err := m.Copy("ls -la"); !errors.Is(err, tc.want) {
+				t.Errorf("Copy() = %v, want %v", err, tc.want)
+			}
+			// OSC 52 不报告结果，无论其他方式是否成功都要发出
+			if got := terminal.Text(); got != "ls -la" {
+				t.Errorf("terminal text = %q, want %q", got, "ls -la")
+			}
+			if tc.bufferErr == nil && buffer.Text() != "ls -la" {
+				t.Errorf("buffer text = %q, want %q", buffer.Text(), "ls -la")
+			}
+		})
+	}
+}
+
+func TestMultiplexedTerminalErrorIgnored(t *testing.T) {
+	m := Multiplexed{Kind: mux.Screen, Buffer: &Fake{}, Terminal: &Fake{Err: errors.New("no tty")}, System: &Fake{Err: errors.New("no xclip")}}
+	if err := m.Copy("pwd"); err != nil {
+		t.Errorf("Copy() = %v, want nil", err)
+	}
+}
+
+func TestNewDetectsMultiplexer(t *testing.T) {
+	tests := []struct {
+		name, tmux, sty string
+		backend         config.ClipboardBackend
+		want            string
+	}{
+		{"Tmux", "/tmp/tmux-1000/default,1,0", "", "", "tmux 缓冲区与剪贴板"},
+		{"Screen", "", "1234.pts-0.host", "", "screen 缓冲区与剪贴板"},
+		{"TmuxInsideScreen", "/tmp/tmux-1000/default,1,0", "1234.pts-0.host", "", "tmux 缓冲区与剪贴板"},
+		{"Plain", "", "", "", System().Name()},
+		{"ExplicitSystem", "/tmp/tmux-1000/default,1,0", "", config.ClipboardSystem, System().Name()},
+		{"ExplicitScreen", "", "", config.ClipboardScreen, Screen{}.Name()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("TMUX", tc.tmux)
+			t.Setenv("STY", tc.sty)
+			cb, err := New(config.ClipboardConfig{Backend: tc.backend})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			if cb.Name() != tc.want {
+				t.Errorf("backend = %q, want %q", cb.Name(), tc.want)
+			}
+		})
+	}
+}
+
+func TestOSC52Passthrough(t *testing.T) {
+	payload := base64.StdEncoding.EncodeToString([]byte("echo hi"))
+
+	t.Run("Terminal", func(t *testing.T) {
+		t.Setenv("TMUX", "")
+		t.Setenv("STY", "")
+		var b bytes.Buffer
+		if err := (OSC52{W: &b}).Copy("echo hi"); err != nil {
+			t.Fatal(err)
+		}
+		if want := "\x1b]52;c;" + payload + "\a"; b.String() != want {
+			t.Errorf("sequence = %q, want %q", b.String(), want)
+		}
+	})
+
+	t.Run("Tmux", func(t *testing.T) {
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+		var b bytes.Buffer
+		if err := (OSC52{W: &b}).Copy("echo hi"); err != nil {
+			t.Fatal(err)
+		}
+		if want := "\x1bPtmux;\x1b\x1b]52;c;" + payload + "\a\x1b\\"; b.String() != want {
+			t.Errorf("sequence = %q, want %q", b.String(), want)
+		}
+	})
+
+	t.Run("ScreenChunks", func(t *testing.T) {
+		t.Setenv("TMUX", "")
+		t.Setenv("STY", "1234.pts-0.host")
+		text := strings.Repeat("x", 2000)
+		var b bytes.Buffer
+		if err := (OSC52{W: &b}).Copy(text); err != nil {
+			t.Fatal(err)
+		}
+		var joined strings.Builder
+		for _, chunk := range strings.SplitAfter(b.String(), "\x1b\\") {
+			if chunk == "" {
+				continue
+			}
+			body, ok := strings.CutPrefix(chunk, "\x1bP")
+			body, ok2 := strings.CutSuffix(body, "\x1b\\")
+			if !ok || !ok2 || len(body) > screenChunk {
+				t.Fatalf("bad chunk %q", chunk)
+			}
+			joined.WriteString(body)
+		}
+		if want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"; joined.String() != want {
+			t.Errorf("reassembled sequence does not match the OSC 52 sequence")
+		}
+	})
+}
+
+func TestFileCopy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip", "termi.txt")
+	cb, err := New(config.ClipboardConfig{Backend: config.ClipboardFile, Path: path})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := cb.Copy("uname -a"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "uname -a" {
+		t.Errorf("file = %q, %v, want %q", data, err, "uname -a")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !darwin && !windows
+
+package clipboard
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// system Linux 与 BSD 的剪贴板，Wayland 下优先使用 wl-copy，否则使用 xclip 或 xsel
+type system struct{}
+
+// System 返回当前平台的系统剪贴板
+func System() Clipboard { return system{} }
+
+func (system) Name() string { return "剪贴板" }
+
+func (system) Copy(text string) error {
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			return pipe(text, "wl-copy")
+		}
+	}
+	if _, err := exec.LookPath("xclip"); err == nil {
+		return pipe(text, "xclip", "-selection", "clipboard")
+	}
+	if _, err := exec.LookPath("xsel"); err == nil {
+		return pipe(text, "xsel", "--clipboard", "--input")
+	}
+	return fmt.Errorf("未找到剪贴板工具（请安装 wl-clipboard、xclip 或 xsel，或在配置中设置 clipboard.backend 为 osc52）")
+}
@@ -0,0 +1,13 @@
+package clipboard
+
+// system 使用 clip 的 Windows 剪贴板
+type system struct{}
+
+// System 返回当前平台的系统剪贴板
+func System() Clipboard { return system{} }
+
+func (system) Name() string { return "剪贴板" }
+
+func (system) Copy(text string) error {
+	return pipe(text, "clip")
+}
@@ -0,0 +1,93 @@
+// Package cmddb 常见自然语言需求到命令的离线数据库，简单查询无需等待 LLM 即可给出命令
+package cmddb
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"termi.sh/termi/internal/history"
+)
+
+// DefaultMinScore 视为命中的最低相似度，足够高以免把复杂需求误判为简单查询
+const DefaultMinScore = 0.6
+
+//go:embed commands.json
+var builtin []byte
+
+// Entry 一条需求到命令的映射，darwin、windows 为对应平台的替代写法
+type Entry struct {
+	Queries     []string `json:"queries"`
+	Command     string   `json:"command"`
+	Darwin      string   `json:"darwin,omitempty"`
+	Windows     string   `json:"windows,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// CommandFor 返回适用于 goos 的命令
+func (e *Entry) CommandFor(goos string) string {
+	switch {
+	case goos == "darwin" && e.Darwin != "":
+		return e.Darwin
+	case goos == "windows" && e.Windows != "":
+		return e.Windows
+	default:
+		return e.Command
+	}
+}
+
+// DB 离线命令数据库
+type DB struct {
+	entries []Entry
+}
+
+// Load 读取内置数据库，并追加用户在 path 中定义的条目；path 不存在时只使用内置条目
+func Load(path string) (*DB, error) {
+	var entries []Entry
+	if err := json.Unmarshal(builtin, &entries); err != nil {
+		return nil, fmt.Errorf("解析内置命令库失败: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &DB{entries: entries}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取命令库失败: %w", err)
+	}
+	var user []Entry
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("解析命令库 %s 失败: %w", path, err)
+	}
+	// 用户条目优先，可以覆盖内置写法
+	return &DB{entries: append(user, entries...)}, nil
+}
+
+// Match 返回与 query 最相似的条目，相似度低于 minScore 时返回 false
+func (db *DB) Match(query string, minScore float64) (Entry, bool) {
+	var best Entry
+	bestScore := 0.0
+	for _, e := range db.entries {
+		for _, q := range e.Queries {
+			if score := history.Similarity(query, q); score > bestScore {
+				best, bestScore = e, score
+			}
+		}
+	}
+	if bestScore < minScore {
+		return Entry{}, false
+	}
+	return best, true
+}
+
+// Lookup 返回 query 命中的当前平台命令及说明
+func (db *DB) Lookup(query string) (command, description string, ok bool) {
+	e, ok := db.Match(query, DefaultMinScore)
+	if !ok {
+		return "", "", false
+	}
+	return e.CommandFor(runtime.GOOS), e.Description, true
+}
@@ -2,10 +2,25 @@ package config
 
 import (
 	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+
+	"termi.sh/termi/internal/filelock"
 )
 
 // LLMProvider 定义支持的 LLM 提供商类型
@@ -17,12 +32,32 @@ const (
 	ProviderGemini      LLMProvider = "gemini"
 	ProviderClaude      LLMProvider = "claude"
 	ProviderLlamaCPP    LLMProvider = "llama-cpp"
+	ProviderOllama      LLMProvider = "ollama"
+	// ProviderOpenAICompatible 任意 OpenAI 兼容端点，例如 LM Studio、vLLM、LocalAI
+	ProviderOpenAICompatible LLMProvider = "openai-compatible"
+	// ProviderCommand 用户指定的外部程序，通过标准输入输出交换 JSON，用于接入内部或自建的模型
+	ProviderCommand LLMProvider = "command"
 )
 
+// defaultOllamaURL Ollama 服务的默认地址
+const defaultOllamaURL = "http://localhost:11434"
+
 // LLMConfig LLM 配置结构
 type LLMConfig struct {
 	Provider LLMProvider `json:"provider"`
 
+	// Fallback 备用提供商，主提供商响应缓慢时可切换，其配置同样写在对应的小节中
+	Fallback LLMProvider `json:"fallback,omitempty"`
+
+	// Failover 主提供商重试后仍失败时依次改用的提供商，其配置同样写在对应的小节中
+	Failover []LLMProvider `json:"failover,omitempty"`
+
+	// Retry 超时、网络错误与 429 等临时失败的重试策略
+	Retry RetryConfig `json:"retry,omitempty"`
+
+	// Candidates 每次请求的候选命令数量（含最推荐的一条），默认 3，设为 1 只生成一条
+	Candidates int `json:"candidates,omitempty"`
+
 	// OpenAI 配置
 	OpenAI *OpenAIConfig `json:"openai,omitempty"`
 
@@ -37,6 +72,15 @@ type LLMConfig struct {
 
 	// Llama-cpp 配置
 	LlamaCPP *LlamaCPPConfig `json:"llama_cpp,omitempty"`
+
+	// Ollama 配置
+	Ollama *OllamaConfig `json:"ollama,omitempty"`
+
+	// OpenAI 兼容端点配置
+	OpenAICompatible *OpenAICompatibleConfig `json:"openai_compatible,omitempty"`
+
+	// 外部命令配置
+	Command *CommandConfig `json:"command,omitempty"`
 }
 
 // OpenAIConfig OpenAI 配置
@@ -46,6 +90,16 @@ type OpenAIConfig struct {
 	BaseURL string `json:"base_url,omitempty"`
 	OrgID   string `json:"org_id,omitempty"`
 	Timeout int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
+
+	// UseResponsesAPI 使用 Responses API 代替默认的 Chat Completions
+	UseResponsesAPI bool `json:"use_responses_api,omitempty"`
+	// Tools 启用的托管工具（web_search、code_interpreter），仅在 Responses API 下生效，默认关闭
+	Tools []string `json:"tools,omitempty"`
+	// DisableJSONMode 不发送 response_format: json_object，用于不支持该参数的兼容端点。
+	// 未设置时首次被端点拒绝后也会自动降级
+	DisableJSONMode bool `json:"disable_json_mode,omitempty"`
 }
 
 // AzureOpenAIConfig Azure OpenAI 配置
@@ -55,6 +109,8 @@ type AzureOpenAIConfig struct {
 	DeploymentID string `json:"deployment_id"`
 	APIVersion   string `json:"api_version"`
 	Timeout      int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
 }
 
 // GeminiConfig Gemini 配置
@@ -63,6 +119,8 @@ type GeminiConfig struct {
 	Model   string `json:"model"`
 	BaseURL string `json:"base_url,omitempty"`
 	Timeout int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
 }
 
 // ClaudeConfig Claude 配置
@@ -71,6 +129,8 @@ type ClaudeConfig struct {
 	Model   string `json:"model"`
 	BaseURL string `json:"base_url,omitempty"`
 	Timeout int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
 }
 
 // LlamaCPPConfig Llama-cpp 配置
@@ -78,21 +138,1107 @@ type LlamaCPPConfig struct {
 	BaseURL string `json:"base_url"`
 	Model   string `json:"model,omitempty"`
 	Timeout int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
+}
+
+// OllamaConfig Ollama 配置，使用原生的 /api/chat 接口
+type OllamaConfig struct {
+	BaseURL string `json:"base_url,omitempty"` // 默认 http://localhost:11434，也接受 OLLAMA_HOST 的 host:port 写法
+	Model   string `json:"model"`
+	Timeout int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
+}
+
+// Endpoint 返回带协议的服务地址，未配置时使用默认地址。与 ollama 命令行一致，
+// 省略协议时使用 http，http 地址省略端口时使用 11434
+func (oc *OllamaConfig) Endpoint() string {
+	raw := cmp.Or(oc.BaseURL, defaultOllamaURL)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return strings.TrimSuffix(raw, "/")
+	}
+	if u.Scheme == "http" && u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "11434")
+	}
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// OpenAICompatibleConfig OpenAI 兼容端点配置，只需要地址与模型，本地服务通常不需要 API Key
+type OpenAICompatibleConfig struct {
+	BaseURL string `json:"base_url"` // 例如 http://localhost:1234/v1
+	Model   string `json:"model"`
+	APIKey  string `json:"api_key,omitempty"`
+	Timeout int    `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+	NetworkConfig
+
+	// DisableJSONMode 不发送 response_format: json_object，未设置时首次被端点拒绝后也会自动降级
+	DisableJSONMode bool `json:"disable_json_mode,omitempty"`
+}
+
+// CommandConfig 外部命令提供商配置。每次请求运行一次 path，系统提示词与用户提示词以 JSON
+// 写入其标准输入，从标准输出读取与其他提供商相同的 command/ask JSON
+type CommandConfig struct {
+	Path    string   `json:"path"`              // 可执行文件路径或 PATH 中的程序名
+	Args    []string `json:"args,omitempty"`    // 传给程序的参数
+	Model   string   `json:"model,omitempty"`   // 原样传给程序，也用于用量统计
+	Timeout int      `json:"timeout,omitempty"` // 秒
+	GenerationConfig
+}
+
+// RetryConfig 临时失败的重试策略，每次重试的等待时间翻倍
+type RetryConfig struct {
+	Attempts     int `json:"attempts,omitempty"`      // 每个提供商最多请求的次数（含首次），默认 2，1 表示不重试
+	InitialDelay int `json:"initial_delay,omitempty"` // 首次重试前等待的毫秒数，默认 500
+}
+
+// MaxAttempts 返回每个提供商最多请求的次数
+func (rc *RetryConfig) MaxAttempts() int {
+	if rc.Attempts <= 0 {
+		return 2
+	}
+	return rc.Attempts
+}
+
+// Delay 返回首次重试前的等待时间
+func (rc *RetryConfig) Delay() time.Duration {
+	if rc.InitialDelay <= 0 {
+		return 500 * time.Millisecond
+	}
+	return time.Duration(rc.InitialDelay) * time.Millisecond
+}
+
+// WithModel 返回将当前提供商的模型替换为 model 的副本，原配置不受影响；Azure OpenAI 替换部署名
+func (lc LLMConfig) WithModel(model string) LLMConfig {
+	switch lc.Provider {
+	case ProviderOpenAI:
+		if lc.OpenAI != nil {
+			c := *lc.OpenAI
+			c.Model = model
+			lc.OpenAI = &c
+		}
+	case ProviderAzureOpenAI:
+		if lc.AzureOpenAI != nil {
+			c := *lc.AzureOpenAI
+			c.DeploymentID = model
+			lc.AzureOpenAI = &c
+		}
+	case ProviderGemini:
+		if lc.Gemini != nil {
+			c := *lc.Gemini
+			c.Model = model
+			lc.Gemini = &c
+		}
+	case ProviderClaude:
+		if lc.Claude != nil {
+			c := *lc.Claude
+			c.Model = model
+			lc.Claude = &c
+		}
+	case ProviderLlamaCPP:
+		if lc.LlamaCPP != nil {
+			c := *lc.LlamaCPP
+			c.Model = model
+			lc.LlamaCPP = &c
+		}
+	case ProviderOllama:
+		if lc.Ollama != nil {
+			c := *lc.Ollama
+			c.Model = model
+			lc.Ollama = &c
+		}
+	case ProviderOpenAICompatible:
+		if lc.OpenAICompatible != nil {
+			c := *lc.OpenAICompatible
+			c.Model = model
+			lc.OpenAICompatible = &c
+		}
+	case ProviderCommand:
+		if lc.Command != nil {
+			c := *lc.Command
+			c.Model = model
+			lc.Command = &c
+		}
+	}
+	return lc
+}
+
+// maxStopSequences 停止序列的数量上限，取各提供商中最严格的 OpenAI
+const maxStopSequences = 4
+
+// GenerationConfig 生成参数，嵌入在各提供商的配置小节中
+type GenerationConfig struct {
+	MaxTokens int      `json:"max_tokens,omitempty"` // 单次响应的最大输出 token 数，0 使用提供商默认值
+	Stop      []string `json:"stop,omitempty"`       // 停止序列，最多 4 条
+}
+
+// OutputTokens 返回最大输出 token 数，未配置时使用 def
+func (gc *GenerationConfig) OutputTokens(def int) int {
+	if gc.MaxTokens > 0 {
+		return gc.MaxTokens
+	}
+	return def
+}
+
+// StopSequences 返回停止序列，未配置时使用 def
+func (gc *GenerationConfig) StopSequences(def []string) []string {
+	if len(gc.Stop) > 0 {
+		return gc.Stop
+	}
+	return def
+}
+
+// validate 验证生成参数，name 为所属提供商
+func (gc *GenerationConfig) validate(name string) error {
+	if gc.MaxTokens < 0 {
+		return fmt.Errorf("%s max_tokens 不能为负数", name)
+	}
+	if len(gc.Stop) > maxStopSequences {
+		return fmt.Errorf("%s stop 最多 %d 条，当前 %d 条", name, maxStopSequences, len(gc.Stop))
+	}
+	for _, s := range gc.Stop {
+		if s == "" {
+			return fmt.Errorf("%s stop 不能包含空字符串", name)
+		}
+	}
+	return nil
+}
+
+// NetworkConfig 网络设置，嵌入在各提供商的配置小节中。未设置时与其他程序一样使用
+// HTTPS_PROXY、HTTP_PROXY 与 NO_PROXY 环境变量，便于本地模型直连而云端模型经过公司代理
+type NetworkConfig struct {
+	Proxy   string `json:"proxy,omitempty"`    // 该提供商使用的代理，例如 http://proxy.corp:3128、socks5://127.0.0.1:1080；NO_PROXY 中的主机仍然直连
+	NoProxy bool   `json:"no_proxy,omitempty"` // 直连，不使用 proxy 与代理环境变量
+
+	// Headers 附加到发往该提供商的每个请求的请求头，供企业网关按团队统计用量，
+	// 例如 OpenRouter 的 HTTP-Referer、X-Title，或 Azure API Management 的 x-ms-* 元数据
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// reservedHeaders 由 termi 或提供商 SDK 设置的请求头，不允许在 headers 中覆盖
+var reservedHeaders = []string{"Authorization", "Api-Key", "X-Api-Key", "X-Goog-Api-Key", "Content-Type", "Content-Length", "Host"}
+
+// validateNetwork 验证网络设置，name 为所属提供商
+func (nc *NetworkConfig) validateNetwork(name string) error {
+	for _, key := range slices.Sorted(maps.Keys(nc.Headers)) {
+		value := nc.Headers[key]
+		if !httpguts.ValidHeaderFieldName(key) {
+			return fmt.Errorf("%s headers 中的请求头名称无效: %q", name, key)
+		}
+		if slices.Contains(reservedHeaders, http.CanonicalHeaderKey(key)) {
+			return fmt.Errorf("%s headers 不能设置 %s，该请求头由 termi 根据 api_key 等配置设置", name, key)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("%s headers 中 %s 的取值无效", name, key)
+		}
+	}
+	if nc.Proxy == "" {
+		return nil
+	}
+	if nc.NoProxy {
+		return fmt.Errorf("%s 不能同时设置 proxy 与 no_proxy", name)
+	}
+	u, err := url.Parse(nc.Proxy)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%s proxy 不是有效的地址: %s", name, nc.Proxy)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("%s proxy 只支持 http、https 与 socks5 代理: %s", name, nc.Proxy)
+	}
+	return nil
+}
+
+// RedactConfig 回传给 LLM 的命令输出的脱敏配置
+type RedactConfig struct {
+	IPs          bool     `json:"ips,omitempty"`           // 脱敏 IP 地址
+	KeepTokens   bool     `json:"keep_tokens,omitempty"`   // 不脱敏疑似密钥、令牌
+	KeepUsername bool     `json:"keep_username,omitempty"` // 不脱敏当前用户名
+	Usernames    []string `json:"usernames,omitempty"`     // 额外需要脱敏的用户名
+	Approve      bool     `json:"approve,omitempty"`       // 发送前展示脱敏结果并等待确认
+}
+
+// RecordConfig 命令执行过程录制配置
+type RecordConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Format  string `json:"format,omitempty"` // asciinema 或 script，默认 asciinema
+	Dir     string `json:"dir,omitempty"`    // 录制文件目录，默认为数据目录下的 transcripts
+}
+
+// HistoryConfig 历史记录配置
+type HistoryConfig struct {
+	Disabled bool `json:"disabled,omitempty"` // 不记录历史
+	FewShot  int  `json:"few_shot,omitempty"` // 作为示例附加到提示词中的相似历史条数，默认 3，负数表示关闭
+}
+
+// FewShotCount 返回附加到提示词中的相似历史条数
+func (hc *HistoryConfig) FewShotCount() int {
+	switch {
+	case hc.Disabled || hc.FewShot < 0:
+		return 0
+	case hc.FewShot == 0:
+		return 3
+	default:
+		return hc.FewShot
+	}
+}
+
+// ExecConfig 命令执行配置
+type ExecConfig struct {
+	NoSudoPrevalidate bool `json:"no_sudo_prevalidate,omitempty"` // 执行含 sudo 的命令前不预先验证凭据
+	Verify            bool `json:"verify,omitempty"`              // 执行成功后请求 LLM 生成验证命令并自动运行
+	Provenance        bool `json:"provenance,omitempty"`          // 经 shell 集成写入历史的命令前加上 ": termi '<需求>';"，便于按意图搜索
+	Attribution       bool `json:"attribution,omitempty"`         // 执行的命令末尾加上 "# termi:model=<模型> ts=<时间>"，便于审计日志与 shell 历史追溯来源
+	NoStderrCapture   bool `json:"no_stderr_capture,omitempty"`   // 不截取标准错误（用于分析失败原因），保持其直接连接终端
+	NoObjectCapture   bool `json:"no_object_capture,omitempty"`   // PowerShell、Nushell 中不捕获命令输出的对象供下一次请求使用
+	CopyOutput        bool `json:"copy_output,omitempty"`         // 执行后将命令的标准输出复制到剪贴板
+	CopyLines         int  `json:"copy_lines,omitempty"`          // 只复制输出的最后若干行，0 表示全部
+	Backup            bool `json:"backup,omitempty"`              // 执行会修改或删除文件的命令前先备份这些文件，可用 termi undo 恢复
+	BackupLimit       int  `json:"backup_limit,omitempty"`        // 单次备份的大小上限（MB），超过时不备份，默认 100
+
+	// Pager 输出超过一屏时的处理："auto" 超出部分不再刷屏，执行结束后用 $PAGER 查看完整输出
+	// （未设置时用内置分页器），"internal" 总是用内置分页器，空表示照常输出到终端
+	Pager string `json:"pager,omitempty"`
+
+	// Limits 本地执行命令时的资源上限，防止意外的大范围 find、失控的压缩拖垮整台机器
+	Limits LimitsConfig `json:"limits,omitempty"`
+}
+
+// exec.pager 的取值
+const (
+	PagerAuto     = "auto"
+	PagerInternal = "internal"
+)
+
+// LimitsConfig 执行命令的资源上限，0 表示不限制。CPU、内存与文件大小通过 ulimit 设置（类 Unix 系统），
+// 断网需要 Linux 与 bubblewrap；远程执行与放到命令行的命令不受限制
+type LimitsConfig struct {
+	CPU       int  `json:"cpu,omitempty"`        // CPU 时间上限（秒），超过时命令被终止
+	MemoryMB  int  `json:"memory_mb,omitempty"`  // 虚拟内存上限（MB），超过时内存分配失败
+	FileMB    int  `json:"file_mb,omitempty"`    // 单个文件可写入的大小上限（MB）
+	NoNetwork bool `json:"no_network,omitempty"` // 在断开网络的命名空间中执行
+}
+
+// Validate 验证资源上限配置
+func (lc *LimitsConfig) Validate() error {
+	if lc.CPU < 0 || lc.MemoryMB < 0 || lc.FileMB < 0 {
+		return fmt.Errorf("exec.limits 的 cpu、memory_mb、file_mb 不能为负数")
+	}
+	return nil
+}
+
+// Validate 验证执行配置
+func (ec *ExecConfig) Validate() error {
+	if ec.Pager != "" && ec.Pager != PagerAuto && ec.Pager != PagerInternal {
+		return fmt.Errorf("exec.pager 应为 %s 或 %s: %s", PagerAuto, PagerInternal, ec.Pager)
+	}
+	return ec.Limits.Validate()
+}
+
+// BackupBytes 返回单次备份的大小上限
+func (ec *ExecConfig) BackupBytes() int64 {
+	return int64(cmp.Or(max(ec.BackupLimit, 0), 100)) << 20
+}
+
+// NotifyConfig 分析完成或出现追问时的提示配置
+type NotifyConfig struct {
+	Bell  bool `json:"bell,omitempty"`  // 终端响铃
+	Flash bool `json:"flash,omitempty"` // 屏幕闪烁
+	Title bool `json:"title,omitempty"` // 修改终端标题
+}
+
+// SinkType 命令输出目的地的类型
+type SinkType string
+
+const (
+	SinkRunbook SinkType = "runbook" // 追加到 Markdown 运行手册
+	SinkSlack   SinkType = "slack"   // 发送到 Slack incoming webhook
+	SinkWebhook SinkType = "webhook" // 以 JSON 请求通用 REST 接口，例如创建工单
+)
+
+// SinkConfig 一个命令输出目的地，URL 与请求头中的 $VAR 会按环境变量展开
+type SinkConfig struct {
+	Name    string            `json:"name"`
+	Type    SinkType          `json:"type"`
+	Path    string            `json:"path,omitempty"`    // runbook 文件路径
+	URL     string            `json:"url,omitempty"`     // slack、webhook 的地址
+	Method  string            `json:"method,omitempty"`  // webhook 请求方法，默认 POST
+	Headers map[string]string `json:"headers,omitempty"` // webhook 请求头
+	Auto    bool              `json:"auto,omitempty"`    // 执行命令后自动发送
+}
+
+// Validate 验证 sink 配置
+func (sc *SinkConfig) Validate() error {
+	if sc.Name == "" {
+		return fmt.Errorf("sink 名称不能为空")
+	}
+	switch sc.Type {
+	case SinkRunbook:
+		if sc.Path == "" {
+			return fmt.Errorf("sink %s 缺少 path", sc.Name)
+		}
+	case SinkSlack, SinkWebhook:
+		if sc.URL == "" {
+			return fmt.Errorf("sink %s 缺少 url", sc.Name)
+		}
+	default:
+		return fmt.Errorf("sink %s 的类型不受支持: %s", sc.Name, sc.Type)
+	}
+	return nil
+}
+
+// Expertise 用户的命令行熟练程度
+type Expertise string
+
+const (
+	Beginner     Expertise = "beginner"     // 说明详细、解释术语，风险提示附带后果与建议
+	Intermediate Expertise = "intermediate" // 默认
+	Expert       Expertise = "expert"       // 说明简短，风险提示只列出原因
+)
+
+// Validate 验证熟练程度
+func (e Expertise) Validate() error {
+	switch e {
+	case "", Beginner, Intermediate, Expert:
+		return nil
+	}
+	return fmt.Errorf("expertise 只能是 beginner、intermediate 或 expert: %s", e)
+}
+
+// ShareType 会话记录的分享方式
+type ShareType string
+
+const (
+	ShareGist  ShareType = "gist"  // 创建 GitHub gist，默认私密
+	SharePaste ShareType = "paste" // 把 Markdown 正文 POST 到内部粘贴服务
+)
+
+// ShareConfig 分享脱敏后的会话记录（需求、选中的命令与说明）所用的粘贴服务，
+// URL 与请求头中的 $VAR 会按环境变量展开
+type ShareConfig struct {
+	Type    ShareType         `json:"type"`
+	URL     string            `json:"url,omitempty"`     // paste 的上传地址；gist 默认 https://api.github.com/gists，GitHub Enterprise 可改为其 API 地址
+	Headers map[string]string `json:"headers,omitempty"` // 鉴权等请求头，例如 gist 的 "Authorization": "Bearer $GITHUB_TOKEN"
+	Public  bool              `json:"public,omitempty"`  // gist 设为公开
+	// URLField paste 响应中分享链接所在的 JSON 字段，默认 url；响应不是 JSON 时以响应正文作为链接
+	URLField string `json:"url_field,omitempty"`
+}
+
+// Validate 验证分享配置
+func (sc *ShareConfig) Validate() error {
+	switch sc.Type {
+	case ShareGist:
+	case SharePaste:
+		if sc.URL == "" {
+			return fmt.Errorf("share 类型为 paste 时需要设置 url")
+		}
+	default:
+		return fmt.Errorf("share 的类型不受支持: %s（可用: gist, paste）", sc.Type)
+	}
+	return nil
+}
+
+// SafetyConfig 执行前的本地风险检查，blocklist 与 allowlist 为匹配完整命令的正则表达式
+type SafetyConfig struct {
+	Blocklist []string `json:"blocklist,omitempty"` // 命中的命令只能复制，不允许执行
+	Allowlist []string `json:"allowlist,omitempty"` // 命中的命令不提示风险也不要求确认
+	CopyOnly  bool     `json:"copy_only,omitempty"` // 从不执行命令，选择后改为复制
+	Preview   bool     `json:"preview,omitempty"`   // 高危命令确认前先在沙箱中预演，报告会改动的文件与网络访问（Linux，需要 bwrap 与 strace）
+
+	// TrustedHosts 提示词注入检查中可以接收本地数据的主机，同时信任其子域名
+	TrustedHosts []string `json:"trusted_hosts,omitempty"`
+
+	// Forbidden 绝对禁止的命令片段，例如 "rm -rf /"、生产数据库名或主机名。命中的命令
+	// 既不能执行也不能复制，除非输入 OverrideCode。默认按整词匹配、不区分大小写，
+	// 以 re: 开头时作为正则表达式；优先于 blocklist 与 allowlist
+	Forbidden []string `json:"forbidden,omitempty"`
+	// OverrideCode 解除 forbidden 限制的覆盖码，建议写成 "sha256:<十六进制摘要>" 以免明文保存；
+	// 未设置时禁止的命令无法以任何方式使用
+	OverrideCode string `json:"override_code,omitempty"`
+
+	// Approval 执行前需要外部审批的命令及审批 webhook，未配置时不要求审批
+	Approval *ApprovalConfig `json:"approval,omitempty"`
+}
+
+// ApprovalConfig 变更管理审批：需要审批的命令执行前 POST 到 URL（ChatOps 机器人、ITSM 等），
+// 拿到审批令牌才执行。URL、请求头与 Secret 中的 $VAR 会按环境变量展开
+type ApprovalConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Level 需要审批的最低风险等级：high（默认）或 critical
+	Level string `json:"level,omitempty"`
+	// Commands 无论风险等级都需要审批的命令，正则表达式，例如 "kubectl .*--context[= ]prod"
+	Commands []string `json:"commands,omitempty"`
+	// Secret 审批令牌的 HMAC-SHA256 密钥，令牌为以其对请求 ID 计算的十六进制摘要。
+	// 配置后 webhook 返回的令牌同样会校验，并允许在审批异步完成后手动粘贴令牌
+	Secret string `json:"secret,omitempty"`
+	// Timeout 等待 webhook 响应的秒数，默认 30
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// Validate 验证审批配置
+func (ac *ApprovalConfig) Validate() error {
+	if ac.URL == "" {
+		return fmt.Errorf("safety.approval 缺少 url")
+	}
+	switch ac.Level {
+	case "", "high", "critical":
+	default:
+		return fmt.Errorf("safety.approval.level 只能是 high 或 critical: %s", ac.Level)
+	}
+	for _, p := range ac.Commands {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("safety.approval 规则 %q 不是有效的正则表达式: %w", p, err)
+		}
+	}
+	if ac.Timeout < 0 {
+		return fmt.Errorf("safety.approval.timeout 不能为负数")
+	}
+	return nil
+}
+
+// ApprovalTimeout 返回等待审批 webhook 响应的时长
+func (ac *ApprovalConfig) ApprovalTimeout() time.Duration {
+	return time.Duration(cmp.Or(ac.Timeout, 30)) * time.Second
+}
+
+// Validate 验证风险检查配置
+func (sc *SafetyConfig) Validate() error {
+	for _, list := range [][]string{sc.Blocklist, sc.Allowlist} {
+		for _, p := range list {
+			if _, err := regexp.Compile(p); err != nil {
+				return fmt.Errorf("safety 规则 %q 不是有效的正则表达式: %w", p, err)
+			}
+		}
+	}
+	for _, p := range sc.Forbidden {
+		if strings.TrimSpace(strings.TrimPrefix(p, "re:")) == "" {
+			return fmt.Errorf("safety.forbidden 中不能有空规则")
+		}
+		if re, ok := strings.CutPrefix(p, "re:"); ok {
+			if _, err := regexp.Compile(re); err != nil {
+				return fmt.Errorf("safety.forbidden 规则 %q 不是有效的正则表达式: %w", p, err)
+			}
+		}
+	}
+	if hash, ok := strings.CutPrefix(sc.OverrideCode, "sha256:"); ok {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("safety.override_code 的 sha256 摘要无效")
+		}
+	}
+	if sc.Approval != nil {
+		return sc.Approval.Validate()
+	}
+	return nil
+}
+
+// ContextConfig 附加到提示词中的本机环境信息，出于隐私考虑每一项都需要单独开启
+type ContextConfig struct {
+	Cwd            bool `json:"cwd,omitempty"`             // 当前工作目录路径
+	OS             bool `json:"os,omitempty"`              // 操作系统与架构
+	Shell          bool `json:"shell,omitempty"`           // 当前 shell
+	PackageManager bool `json:"package_manager,omitempty"` // 检测到的系统包管理器
+	Git            bool `json:"git,omitempty"`             // 当前目录是否为 git 仓库及 git status --short，仅限受信任目录
+	Tools          bool `json:"tools,omitempty"`           // 已安装的常用工具
+}
+
+// Enabled 报告是否开启了任意一项
+func (cc ContextConfig) Enabled() bool {
+	return cc.Cwd || cc.OS || cc.Shell || cc.PackageManager || cc.Git || cc.Tools
+}
+
+// StdinConfig 通过管道传给 termi 的内容（日志、报错输出、文件片段）作为上下文附加到提示词
+type StdinConfig struct {
+	Disabled bool   `json:"disabled,omitempty"` // 不读取管道输入
+	MaxKB    int    `json:"max_kb,omitempty"`   // 附加的最大长度（KB），默认 32
+	Truncate string `json:"truncate,omitempty"` // 超出时保留的部分：tail（默认）、head 或 both（开头与结尾各一半）
+}
+
+// Limit 返回附加的最大字节数
+func (sc *StdinConfig) Limit() int {
+	return limit(sc.MaxKB, 32) << 10
+}
+
+// Validate 验证管道输入配置
+func (sc *StdinConfig) Validate() error {
+	switch sc.Truncate {
+	case "", "tail", "head", "both":
+		return nil
+	default:
+		return fmt.Errorf("不支持的 stdin.truncate: %s（可选 tail、head、both）", sc.Truncate)
+	}
+}
+
+// StatsConfig 本地记录每次请求的 token 用量，供 termi stats 汇总用量与估算费用
+type StatsConfig struct {
+	Disabled bool `json:"disabled,omitempty"` // 不记录用量
+	// Pricing 模型价格（美元 / 百万 token），键为模型名或模型名前缀，覆盖内置价格表
+	Pricing map[string]Price `json:"pricing,omitempty"`
+}
+
+// Price 模型的 token 单价，单位为美元 / 百万 token
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Validate 验证用量统计配置
+func (sc *StatsConfig) Validate() error {
+	for model, p := range sc.Pricing {
+		if p.Input < 0 || p.Output < 0 {
+			return fmt.Errorf("stats.pricing 中 %s 的价格不能为负数", model)
+		}
+	}
+	return nil
+}
+
+// ClipboardBackend 剪贴板后端
+type ClipboardBackend string
+
+const (
+	ClipboardSystem ClipboardBackend = "system" // 系统剪贴板工具（pbcopy、wl-copy、xclip、clip）
+	ClipboardTmux   ClipboardBackend = "tmux"   // tmux 粘贴缓冲区
+	ClipboardScreen ClipboardBackend = "screen" // GNU screen 粘贴缓冲区
+	ClipboardOSC52  ClipboardBackend = "osc52"  // OSC 52 终端转义序列，适用于 SSH 会话
+	ClipboardFile   ClipboardBackend = "file"   // 写入文件
+)
+
+// ClipboardConfig 复制命令时使用的剪贴板后端。未设置 backend 时自动选择：在 tmux 或 screen 中
+// 同时写入其粘贴缓冲区、OSC 52 与系统剪贴板，否则使用系统剪贴板
+type ClipboardConfig struct {
+	Backend ClipboardBackend `json:"backend,omitempty"`
+	Path    string           `json:"path,omitempty"` // file 后端的文件路径
+}
+
+// Validate 验证剪贴板配置
+func (cc *ClipboardConfig) Validate() error {
+	switch cc.Backend {
+	case "", ClipboardSystem, ClipboardTmux, ClipboardScreen, ClipboardOSC52:
+	case ClipboardFile:
+		if cc.Path == "" {
+			return fmt.Errorf("剪贴板后端 file 缺少 path")
+		}
+	default:
+		return fmt.Errorf("不支持的剪贴板后端: %s", cc.Backend)
+	}
+	return nil
+}
+
+// ThemeName 内置的界面配色主题
+type ThemeName string
+
+const (
+	ThemeDefault      ThemeName = "default"
+	ThemeHighContrast ThemeName = "high-contrast" // 高对比度：亮色前景，次要文字不使用暗淡效果
+	ThemeColorblind   ThemeName = "colorblind"    // 色盲友好：Okabe-Ito 配色，不依赖红绿区分
+)
+
+// ThemeColors 主题中可以覆盖的颜色，按用途命名
+var ThemeColors = []string{"title", "selected", "accent", "muted", "faint", "danger", "warning", "success", "code"}
+
+// themeColorRe 终端颜色：0-255 的颜色编号或 #RRGGBB
+var themeColorRe = regexp.MustCompile(`^(?:[0-9]{1,3}|#[0-9A-Fa-f]{6})$`)
+
+// GlyphMode 界面中 emoji、箭头、框线等符号的显示方式
+type GlyphMode string
+
+const (
+	GlyphAuto    GlyphMode = "auto"    // 语言环境为 UTF-8 时使用 Unicode 符号，否则改用 ASCII（默认）
+	GlyphUnicode GlyphMode = "unicode" // 始终使用 Unicode 符号
+	GlyphASCII   GlyphMode = "ascii"   // 始终使用 ASCII，适合缺少字体或字符集的终端
+)
+
+// ThemeConfig 界面配色：选择内置主题，并可按用途覆盖其中的颜色
+type ThemeConfig struct {
+	Name   ThemeName         `json:"name,omitempty"`
+	Colors map[string]string `json:"colors,omitempty"` // 用途 → 0-255 的颜色编号或 #RRGGBB
+	Glyphs GlyphMode         `json:"glyphs,omitempty"`
+}
+
+// Validate 验证配色配置
+func (tc *ThemeConfig) Validate() error {
+	switch tc.Name {
+	case "", ThemeDefault, ThemeHighContrast, ThemeColorblind:
+	default:
+		return fmt.Errorf("不支持的主题: %s（可选 %s、%s、%s）", tc.Name, ThemeDefault, ThemeHighContrast, ThemeColorblind)
+	}
+	switch tc.Glyphs {
+	case "", GlyphAuto, GlyphUnicode, GlyphASCII:
+	default:
+		return fmt.Errorf("theme.glyphs 不支持 %s（可选 %s、%s、%s）", tc.Glyphs, GlyphAuto, GlyphUnicode, GlyphASCII)
+	}
+	for _, role := range slices.Sorted(maps.Keys(tc.Colors)) {
+		if !slices.Contains(ThemeColors, role) {
+			return fmt.Errorf("theme.colors 中不支持的用途: %s（可选 %s）", role, strings.Join(ThemeColors, "、"))
+		}
+		if color := tc.Colors[role]; !validColor(color) {
+			return fmt.Errorf("theme.colors.%s 的颜色无效: %s（应为 0-255 的颜色编号或 #RRGGBB）", role, color)
+		}
+	}
+	return nil
+}
+
+func validColor(color string) bool {
+	if !themeColorRe.MatchString(color) {
+		return false
+	}
+	n, err := strconv.Atoi(color)
+	return err != nil || n <= 255
+}
+
+// ExperimentConfig 提示词 A/B 实验的一个变体：按比例把查询分配到替代的系统提示词或模型，
+// 未分配到任何变体的查询属于对照组 control
+type ExperimentConfig struct {
+	Name    string `json:"name"`
+	Percent int    `json:"percent"`          // 分配到该变体的查询百分比，所有变体合计不超过 100
+	Prompt  string `json:"prompt,omitempty"` // 替代的系统提示词，{goos} 会替换为操作系统名
+	Model   string `json:"model,omitempty"`  // 替代的模型，使用当前提供商
 }
 
+// validateExperiments 验证实验变体配置
+func validateExperiments(exps []ExperimentConfig) error {
+	total := 0
+	seen := map[string]bool{}
+	for _, e := range exps {
+		switch {
+		case e.Name == "" || e.Name == "control":
+			return fmt.Errorf("实验变体名称不能为空或为 control")
+		case seen[e.Name]:
+			return fmt.Errorf("实验变体名称重复: %s", e.Name)
+		case e.Percent <= 0 || e.Percent > 100:
+			return fmt.Errorf("实验变体 %s 的 percent 必须在 1-100 之间", e.Name)
+		case e.Prompt == "" && e.Model == "":
+			return fmt.Errorf("实验变体 %s 需要设置 prompt 或 model", e.Name)
+		}
+		seen[e.Name] = true
+		total += e.Percent
+	}
+	if total > 100 {
+		return fmt.Errorf("实验变体的 percent 合计为 %d，不能超过 100", total)
+	}
+	return nil
+}
+
+// Intent 查询意图，按关键词从查询中识别
+type Intent string
+
+const (
+	IntentFile    Intent = "file"    // 文件与目录操作
+	IntentNetwork Intent = "network" // 网络、端口、防火墙与远程连接
+	IntentGit     Intent = "git"     // 版本控制
+	IntentPackage Intent = "package" // 软件包的安装、卸载与升级
+	IntentText    Intent = "text"    // 文本查找、替换、统计与格式转换
+)
+
+// Intents 所有意图，同时命中多个意图且关键词数相同时排在前面的优先
+var Intents = []Intent{IntentGit, IntentPackage, IntentNetwork, IntentText, IntentFile}
+
+// SafetyStrictness 安全检查的严格程度
+type SafetyStrictness string
+
+const (
+	SafetyNormal SafetyStrictness = "normal" // 默认
+	SafetyStrict SafetyStrictness = "strict" // 命中的风险等级提高一级：需要留意的命令也要输入 yes 确认
+)
+
+// IntentConfig 一类查询的路由与约束，未设置的项沿用全局配置
+type IntentConfig struct {
+	// Keywords 识别该意图的额外关键词，与内置关键词一起使用，不区分大小写
+	Keywords []string `json:"keywords,omitempty"`
+	// Provider 改用的提供商，其配置同样写在对应的小节中
+	Provider LLMProvider `json:"provider,omitempty"`
+	// Model 替代的模型，未设置 provider 时用于当前提供商
+	Model string `json:"model,omitempty"`
+	// Temperature 采样温度，默认 0.2
+	Temperature *float64 `json:"temperature,omitempty"`
+	// Skills 总是附加的技能包，不论其关键词是否与查询匹配；未安装的技能包被忽略
+	Skills []string `json:"skills,omitempty"`
+	// Safety 安全检查的严格程度：normal（默认）或 strict
+	Safety SafetyStrictness `json:"safety,omitempty"`
+}
+
+// validate 验证意图配置，lc 用于检查改用的提供商是否配置完整
+func (ic *IntentConfig) validate(intent Intent, lc *LLMConfig) error {
+	if !slices.Contains(Intents, intent) {
+		return fmt.Errorf("未知的意图: %s（可选 file、network、git、package、text）", intent)
+	}
+	if ic.Provider != "" {
+		if err := lc.validateProvider(ic.Provider); err != nil {
+			return fmt.Errorf("意图 %s 的提供商配置无效: %w", intent, err)
+		}
+	}
+	if t := ic.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("意图 %s 的 temperature 必须在 0-2 之间", intent)
+	}
+	switch ic.Safety {
+	case "", SafetyNormal, SafetyStrict:
+	default:
+		return fmt.Errorf("意图 %s 的 safety 只能是 normal 或 strict: %s", intent, ic.Safety)
+	}
+	for _, kw := range ic.Keywords {
+		if strings.TrimSpace(kw) == "" {
+			return fmt.Errorf("意图 %s 的 keywords 不能包含空字符串", intent)
+		}
+	}
+	return nil
+}
+
+// StrictSafety 报告意图为 intent 的查询生成的命令是否使用严格的安全检查
+func (c *Config) StrictSafety(intent Intent) bool {
+	return c.Intents[intent].Safety == SafetyStrict
+}
+
+// Routed 报告意图是否改用了其他提供商或模型
+func (ic *IntentConfig) Routed() bool {
+	return ic.Provider != "" || ic.Model != ""
+}
+
+// LocaleConfig 平台与地区相关的提示词预设
+type LocaleConfig struct {
+	Disabled bool     `json:"disabled,omitempty"` // 不附加任何预设
+	Presets  []string `json:"presets,omitempty"`  // 指定预设（macos、debian、dnf、yum、arch、alpine、suse、cn），覆盖自动检测
+
+	NoTranslate bool `json:"no_translate,omitempty"` // 不自动转换本机核心工具（GNU/BSD）不支持的写法
+}
+
+// BudgetConfig 单次调用的 LLM 用量上限
+type BudgetConfig struct {
+	MaxCalls  int `json:"max_calls,omitempty"`  // 最大请求次数，默认 10，负数表示不限制
+	MaxTokens int `json:"max_tokens,omitempty"` // 最大 token 用量，默认 50000，负数表示不限制
+}
+
+// CallLimit 返回单次调用的最大请求次数，0 表示不限制
+func (bc *BudgetConfig) CallLimit() int {
+	return limit(bc.MaxCalls, 10)
+}
+
+// TokenLimit 返回单次调用的最大 token 用量，0 表示不限制
+func (bc *BudgetConfig) TokenLimit() int {
+	return limit(bc.MaxTokens, 50000)
+}
+
+// TimeoutsConfig 分阶段的请求时限（秒），0 表示不另设时限，单次请求仍受各提供商 timeout 的限制
+type TimeoutsConfig struct {
+	Analysis int `json:"analysis,omitempty"` // 首次分析的时限，含重试、failover 与环境探测
+	Clarify  int `json:"clarify,omitempty"`  // 回答追问后每一轮分析的时限，未设置时与 analysis 相同
+	Session  int `json:"session,omitempty"`  // 从首次分析到生成命令的总时限，含等待用户回答追问的时间
+}
+
+// AnalysisLimit 返回首次分析的时限，0 表示不限制
+func (tc *TimeoutsConfig) AnalysisLimit() time.Duration {
+	return time.Duration(tc.Analysis) * time.Second
+}
+
+// ClarifyLimit 返回追问后每一轮分析的时限，0 表示不限制
+func (tc *TimeoutsConfig) ClarifyLimit() time.Duration {
+	return time.Duration(cmp.Or(tc.Clarify, tc.Analysis)) * time.Second
+}
+
+// SessionLimit 返回整次会话的时限，0 表示不限制
+func (tc *TimeoutsConfig) SessionLimit() time.Duration {
+	return time.Duration(tc.Session) * time.Second
+}
+
+// Validate 验证时限配置
+func (tc *TimeoutsConfig) Validate() error {
+	if tc.Analysis < 0 || tc.Clarify < 0 || tc.Session < 0 {
+		return fmt.Errorf("timeouts 中的时限不能为负数")
+	}
+	if tc.Session > 0 && tc.Analysis > tc.Session {
+		return fmt.Errorf("timeouts.analysis (%d 秒) 不能超过 timeouts.session (%d 秒)", tc.Analysis, tc.Session)
+	}
+	return nil
+}
+
+// ServeConfig termi serve --cache-proxy 团队代理的配置
+type ServeConfig struct {
+	Listen      string   `json:"listen,omitempty"`       // 监听地址，默认 127.0.0.1:8787
+	APIKeys     []string `json:"api_keys,omitempty"`     // 客户端需以 Bearer token 提供其中之一，为空时不鉴权
+	CacheTTL    int      `json:"cache_ttl,omitempty"`    // 缓存有效秒数，默认 86400，负数表示不缓存
+	CacheSize   int      `json:"cache_size,omitempty"`   // 最多缓存的响应数，默认 1000
+	DailyCalls  int      `json:"daily_calls,omitempty"`  // 所有客户端每天的请求次数上限，0 表示不限制
+	DailyTokens int      `json:"daily_tokens,omitempty"` // 所有客户端每天的 token 用量上限，0 表示不限制
+}
+
+// Address 返回监听地址
+func (sc *ServeConfig) Address() string {
+	return cmp.Or(sc.Listen, "127.0.0.1:8787")
+}
+
+// TTL 返回缓存有效期，0 表示不缓存
+func (sc *ServeConfig) TTL() time.Duration {
+	return time.Duration(limit(sc.CacheTTL, 86400)) * time.Second
+}
+
+// Size 返回最多缓存的响应数
+func (sc *ServeConfig) Size() int {
+	return cmp.Or(max(sc.CacheSize, 0), 1000)
+}
+
+// Validate 验证代理配置
+func (sc *ServeConfig) Validate() error {
+	for _, key := range sc.APIKeys {
+		if key == "" {
+			return fmt.Errorf("serve.api_keys 中不能有空字符串")
+		}
+	}
+	if sc.DailyCalls < 0 || sc.DailyTokens < 0 {
+		return fmt.Errorf("serve.daily_calls 与 serve.daily_tokens 不能为负数")
+	}
+	return nil
+}
+
+// TelemetryConfig OpenTelemetry 追踪配置，请求头中的 $VAR 会按环境变量展开
+type TelemetryConfig struct {
+	Endpoint    string            `json:"endpoint,omitempty"`     // OTLP/HTTP 端点，例如 http://localhost:4318，为空时读取 OTEL_EXPORTER_OTLP_ENDPOINT
+	Headers     map[string]string `json:"headers,omitempty"`      // 导出请求附带的请求头，例如鉴权 token
+	ServiceName string            `json:"service_name,omitempty"` // 上报的服务名，默认 termi
+}
+
+// OTLPEndpoint 返回生效的 OTLP 端点，为空表示不启用追踪
+func (tc *TelemetryConfig) OTLPEndpoint() string {
+	if tc.Endpoint != "" {
+		return tc.Endpoint
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+}
+
+// limit 解析上限配置：0 使用默认值，负数表示不限制
+func limit(v, def int) int {
+	switch {
+	case v < 0:
+		return 0
+	case v == 0:
+		return def
+	default:
+		return v
+	}
+}
+
+// shells 配置中可以指定的 shell
+var shells = []string{"bash", "zsh", "sh", "fish", "pwsh", "powershell", "cmd", "nu"}
+
 // Config 应用配置
 type Config struct {
-	LLM LLMConfig `json:"llm"`
+	Version int `json:"version"`
+
+	LLM     LLMConfig     `json:"llm"`
+	Redact  RedactConfig  `json:"redact,omitempty"`
+	Record  RecordConfig  `json:"record,omitempty"`
+	History HistoryConfig `json:"history,omitempty"`
+	Exec    ExecConfig    `json:"exec,omitempty"`
+	Notify  NotifyConfig  `json:"notify,omitempty"`
+	Budget  BudgetConfig  `json:"budget,omitempty"`
+	Sinks   []SinkConfig  `json:"sinks,omitempty"`
+	Share   *ShareConfig  `json:"share,omitempty"`
+	Locale  LocaleConfig  `json:"locale,omitempty"`
+
+	Clipboard ClipboardConfig `json:"clipboard,omitempty"`
+	Theme     ThemeConfig     `json:"theme,omitempty"`
+	Safety    SafetyConfig    `json:"safety,omitempty"`
+	Context   ContextConfig   `json:"context,omitempty"`
+	Stdin     StdinConfig     `json:"stdin,omitempty"`
+	Stats     StatsConfig     `json:"stats,omitempty"`
+	Timeouts  TimeoutsConfig  `json:"timeouts,omitempty"`
+	Serve     ServeConfig     `json:"serve,omitempty"`
+
+	Experiments []ExperimentConfig `json:"experiments,omitempty"`
+
+	// Intents 按查询意图（file、network、git、package、text）覆盖提供商、模型、温度、技能包与安全检查
+	Intents map[Intent]IntentConfig `json:"intents,omitempty"`
+
+	Telemetry TelemetryConfig `json:"telemetry,omitempty"`
+
+	// Shell 生成并执行命令所用的 shell：bash、zsh、sh、fish、pwsh、powershell、cmd 或 nu，
+	// 默认取 $SHELL，未设置时类 Unix 系统为 bash、Windows 为 PowerShell
+	Shell string `json:"shell,omitempty"`
+
+	// DisableSnapshot 性能类查询（如"电脑为什么这么慢"）不附加本机负载、进程与磁盘快照
+	DisableSnapshot bool `json:"disable_snapshot,omitempty"`
+
+	// DisableClock 与日期时间相关的查询（如"昨天 9 点以后修改的文件"、crontab）不附加当前时间与时区
+	DisableClock bool `json:"disable_clock,omitempty"`
+
+	// DisableProjectTasks 不在提示词中附加当前目录的 npm scripts、Makefile 目标等项目任务及项目工具链信息
+	DisableProjectTasks bool `json:"disable_project_tasks,omitempty"`
+
+	// TrustAllWorkspaces 跳过目录信任确认，任何目录的项目文件都直接注入提示词
+	TrustAllWorkspaces bool `json:"trust_all_workspaces,omitempty"`
+
+	// DisableCommandDB 不使用离线命令库，所有查询都交给 LLM
+	DisableCommandDB bool `json:"disable_command_db,omitempty"`
+
+	// DisableNormalize 关闭发送前的拼写纠正与别名替换
+	DisableNormalize bool `json:"disable_normalize,omitempty"`
+
+	// AskTimeout 追问的等待秒数，超时后按模型的最佳猜测生成命令，0 表示一直等待
+	AskTimeout int `json:"ask_timeout,omitempty"`
+
+	// Lite 低带宽模式：精简提示词与输出长度，不附加环境上下文，适合计量网络或小型本地模型
+	Lite bool `json:"lite,omitempty"`
+
+	// Expertise 用户的命令行熟练程度，决定命令说明、解释与风险提示的详略，默认 intermediate
+	Expertise Expertise `json:"expertise,omitempty"`
+
+	// SoftTimeout 分析超过该秒数仍未返回时提供继续等待、切换备用提供商等选项，默认 8，负数表示关闭
+	SoftTimeout int `json:"soft_timeout,omitempty"`
+}
+
+// SoftTimeoutSeconds 返回分析的软超时秒数，0 表示关闭
+func (c *Config) SoftTimeoutSeconds() int {
+	switch {
+	case c.SoftTimeout < 0:
+		return 0
+	case c.SoftTimeout == 0:
+		return 8
+	default:
+		return c.SoftTimeout
+	}
 }
 
 // Validate 验证配置是否有效
 func (c *Config) Validate() error {
-	return c.LLM.Validate()
+	if err := c.LLM.Validate(); err != nil {
+		return err
+	}
+	for _, sc := range c.Sinks {
+		if err := sc.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Share != nil {
+		if err := c.Share.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.Clipboard.Validate(); err != nil {
+		return err
+	}
+	if err := c.Theme.Validate(); err != nil {
+		return err
+	}
+	if err := c.Safety.Validate(); err != nil {
+		return err
+	}
+	if err := c.Serve.Validate(); err != nil {
+		return err
+	}
+	if err := c.Stdin.Validate(); err != nil {
+		return err
+	}
+	if err := c.Stats.Validate(); err != nil {
+		return err
+	}
+	if err := c.Timeouts.Validate(); err != nil {
+		return err
+	}
+	if err := c.Exec.Validate(); err != nil {
+		return err
+	}
+	if err := c.Expertise.Validate(); err != nil {
+		return err
+	}
+	if c.Shell != "" && !slices.Contains(shells, c.Shell) {
+		return fmt.Errorf("不支持的 shell: %s（可选 %s）", c.Shell, strings.Join(shells, "、"))
+	}
+	if err := validateExperiments(c.Experiments); err != nil {
+		return err
+	}
+	for intent, ic := range c.Intents {
+		if err := ic.validate(intent, &c.LLM); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Validate 验证 LLM 配置
 func (lc *LLMConfig) Validate() error {
-	switch lc.Provider {
+	if err := lc.validateProvider(lc.Provider); err != nil {
+		return err
+	}
+	if lc.Fallback != "" {
+		if lc.Fallback == lc.Provider {
+			return fmt.Errorf("备用提供商不能与主提供商相同: %s", lc.Fallback)
+		}
+		if err := lc.validateProvider(lc.Fallback); err != nil {
+			return fmt.Errorf("备用提供商配置无效: %w", err)
+		}
+	}
+	seen := map[LLMProvider]bool{lc.Provider: true}
+	for _, p := range lc.Failover {
+		if seen[p] {
+			return fmt.Errorf("failover 中的提供商重复或与主提供商相同: %s", p)
+		}
+		seen[p] = true
+		if err := lc.validateProvider(p); err != nil {
+			return fmt.Errorf("failover 提供商 %s 配置无效: %w", p, err)
+		}
+	}
+	if lc.Retry.Attempts < 0 || lc.Retry.InitialDelay < 0 {
+		return fmt.Errorf("retry.attempts 与 retry.initial_delay 不能为负数")
+	}
+	return nil
+}
+
+// CandidateCount 返回每次请求的候选命令数量
+func (lc *LLMConfig) CandidateCount() int {
+	if lc.Candidates <= 0 {
+		return 3
+	}
+	return lc.Candidates
+}
+
+// Model 返回当前提供商配置的模型名称，未配置时返回空字符串
+func (lc *LLMConfig) Model() string {
+	switch {
+	case lc.Provider == ProviderOpenAI && lc.OpenAI != nil:
+		return lc.OpenAI.Model
+	case lc.Provider == ProviderAzureOpenAI && lc.AzureOpenAI != nil:
+		return lc.AzureOpenAI.DeploymentID
+	case lc.Provider == ProviderGemini && lc.Gemini != nil:
+		return lc.Gemini.Model
+	case lc.Provider == ProviderClaude && lc.Claude != nil:
+		return lc.Claude.Model
+	case lc.Provider == ProviderLlamaCPP && lc.LlamaCPP != nil:
+		return lc.LlamaCPP.Model
+	case lc.Provider == ProviderOllama && lc.Ollama != nil:
+		return lc.Ollama.Model
+	case lc.Provider == ProviderOpenAICompatible && lc.OpenAICompatible != nil:
+		return lc.OpenAICompatible.Model
+	case lc.Provider == ProviderCommand && lc.Command != nil:
+		return lc.Command.Model
+	default:
+		return ""
+	}
+}
+
+// providerOrder 列出已配置提供商时的顺序
+var providerOrder = []LLMProvider{
+	ProviderOpenAI, ProviderAzureOpenAI, ProviderGemini, ProviderClaude,
+	ProviderLlamaCPP, ProviderOllama, ProviderOpenAICompatible, ProviderCommand,
+}
+
+// Configured 返回配置完整、可以直接使用的提供商，包括当前提供商
+func (lc *LLMConfig) Configured() []LLMProvider {
+	var out []LLMProvider
+	for _, p := range providerOrder {
+		if lc.validateProvider(p) == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// validateProvider 验证指定提供商的配置
+func (lc *LLMConfig) validateProvider(provider LLMProvider) error {
+	switch provider {
 	case ProviderOpenAI:
 		if lc.OpenAI == nil {
 			return fmt.Errorf("OpenAI 配置缺失")
@@ -118,8 +1264,23 @@ func (lc *LLMConfig) Validate() error {
 			return fmt.Errorf("Llama-cpp 配置缺失")
 		}
 		return lc.LlamaCPP.Validate()
+	case ProviderOllama:
+		if lc.Ollama == nil {
+			return fmt.Errorf("Ollama 配置缺失")
+		}
+		return lc.Ollama.Validate()
+	case ProviderOpenAICompatible:
+		if lc.OpenAICompatible == nil {
+			return fmt.Errorf("OpenAI 兼容端点配置缺失")
+		}
+		return lc.OpenAICompatible.Validate()
+	case ProviderCommand:
+		if lc.Command == nil {
+			return fmt.Errorf("外部命令配置缺失")
+		}
+		return lc.Command.Validate()
 	default:
-		return fmt.Errorf("不支持的 LLM 提供商: %s", lc.Provider)
+		return fmt.Errorf("不支持的 LLM 提供商: %s", provider)
 	}
 }
 
@@ -131,7 +1292,21 @@ func (oc *OpenAIConfig) Validate() error {
 	if oc.Model == "" {
 		return fmt.Errorf("OpenAI Model 不能为空")
 	}
-	return nil
+	for _, tool := range oc.Tools {
+		if tool != "web_search" && tool != "code_interpreter" {
+			return fmt.Errorf("不支持的 OpenAI 工具: %s", tool)
+		}
+	}
+	if len(oc.Tools) > 0 && !oc.UseResponsesAPI {
+		return fmt.Errorf("OpenAI 工具仅在 use_responses_api 开启时可用")
+	}
+	if len(oc.Stop) > 0 && oc.UseResponsesAPI {
+		return fmt.Errorf("OpenAI Responses API 不支持 stop")
+	}
+	if err := oc.validateNetwork("OpenAI"); err != nil {
+		return err
+	}
+	return oc.validate("OpenAI")
 }
 
 // Validate 验证 Azure OpenAI 配置
@@ -145,7 +1320,10 @@ func (ac *AzureOpenAIConfig) Validate() error {
 	if ac.DeploymentID == "" {
 		return fmt.Errorf("Azure OpenAI Deployment ID 不能为空")
 	}
-	return nil
+	if err := ac.validateNetwork("Azure OpenAI"); err != nil {
+		return err
+	}
+	return ac.validate("Azure OpenAI")
 }
 
 // Validate 验证 Gemini 配置
@@ -156,7 +1334,10 @@ func (gc *GeminiConfig) Validate() error {
 	if gc.Model == "" {
 		return fmt.Errorf("Gemini Model 不能为空")
 	}
-	return nil
+	if err := gc.validateNetwork("Gemini"); err != nil {
+		return err
+	}
+	return gc.validate("Gemini")
 }
 
 // Validate 验证 Claude 配置
@@ -167,7 +1348,10 @@ func (cc *ClaudeConfig) Validate() error {
 	if cc.Model == "" {
 		return fmt.Errorf("Claude Model 不能为空")
 	}
-	return nil
+	if err := cc.validateNetwork("Claude"); err != nil {
+		return err
+	}
+	return cc.validate("Claude")
 }
 
 // Validate 验证 Llama-cpp 配置
@@ -175,12 +1359,49 @@ func (lc *LlamaCPPConfig) Validate() error {
 	if lc.BaseURL == "" {
 		return fmt.Errorf("Llama-cpp Base URL 不能为空")
 	}
-	return nil
+	if err := lc.validateNetwork("Llama-cpp"); err != nil {
+		return err
+	}
+	return lc.validate("Llama-cpp")
+}
+
+// Validate 验证 Ollama 配置
+func (oc *OllamaConfig) Validate() error {
+	if oc.Model == "" {
+		return fmt.Errorf("Ollama Model 不能为空")
+	}
+	if err := oc.validateNetwork("Ollama"); err != nil {
+		return err
+	}
+	return oc.validate("Ollama")
+}
+
+// Validate 验证 OpenAI 兼容端点配置
+func (oc *OpenAICompatibleConfig) Validate() error {
+	if oc.BaseURL == "" {
+		return fmt.Errorf("OpenAI 兼容端点 Base URL 不能为空")
+	}
+	if oc.Model == "" {
+		return fmt.Errorf("OpenAI 兼容端点 Model 不能为空")
+	}
+	if err := oc.validateNetwork("OpenAI 兼容端点"); err != nil {
+		return err
+	}
+	return oc.validate("OpenAI 兼容端点")
+}
+
+// Validate 验证外部命令配置
+func (cc *CommandConfig) Validate() error {
+	if cc.Path == "" {
+		return fmt.Errorf("外部命令 path 不能为空")
+	}
+	return cc.validate("外部命令")
 }
 
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
+		Version: CurrentVersion,
 		LLM: LLMConfig{
 			Provider: ProviderOpenAI,
 			OpenAI: &OpenAIConfig{
@@ -193,14 +1414,8 @@ func DefaultConfig() *Config {
 
 // LoadConfig 从文件加载配置，如果文件不存在则从环境变量加载
 func LoadConfig() (*Config, error) {
-	// 首先尝试从配置文件加载
-	configPath := getConfigPath()
-	if _, err := os.Stat(configPath); err == nil {
-		return loadFromFile(configPath)
-	}
-
-	// 如果配置文件不存在，从环境变量加载
-	return loadFromEnv()
+	cfg, _, err := Load(false)
+	return cfg, err
 }
 
 // SaveConfig 保存配置到文件
@@ -212,12 +1427,19 @@ func (c *Config) SaveConfig() error {
 		return fmt.Errorf("创建配置目录失败: %w", err)
 	}
 
+	c.Version = CurrentVersion
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %w", err)
 	}
 
-	if err := os.WriteFile(configPath, data, 0600); err != nil {
+	// 加锁并原子写入，另一个 termi 进程不会读到写了一半的配置
+	unlock, err := filelock.Lock(configPath)
+	if err != nil {
+		return fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	defer unlock()
+	if err := filelock.WriteFile(configPath, data, 0600); err != nil {
 		return fmt.Errorf("写入配置文件失败: %w", err)
 	}
 
@@ -233,6 +1455,112 @@ func getConfigPath() string {
 	return filepath.Join(homeDir, ".config", "termi", "config.json")
 }
 
+// Dir 返回配置目录，附属的配置文件（词典等）都放在该目录下
+func Dir() string {
+	return filepath.Dir(getConfigPath())
+}
+
+// DataDir 返回数据目录（历史、录制文件等），遵循 XDG_DATA_HOME
+func DataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "termi")
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "./termi-data"
+	}
+	return filepath.Join(homeDir, ".local", "share", "termi")
+}
+
+// CacheDir 返回缓存目录（环境探测结果等），可随时安全删除
+func CacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(DataDir(), "cache")
+	}
+	return filepath.Join(dir, "termi")
+}
+
+// TranscriptDir 返回录制文件目录
+func (rc *RecordConfig) TranscriptDir() string {
+	return cmp.Or(rc.Dir, filepath.Join(DataDir(), "transcripts"))
+}
+
+// ObjectsPath 返回最近一条 PowerShell、Nushell 命令输出对象的保存路径
+func ObjectsPath() string {
+	return filepath.Join(CacheDir(), "objects.json")
+}
+
+// HistoryPath 返回历史记录文件路径
+func HistoryPath() string {
+	return filepath.Join(DataDir(), "history.jsonl")
+}
+
+// StatsPath 返回 token 用量统计文件路径
+func StatsPath() string {
+	return filepath.Join(DataDir(), "stats.json")
+}
+
+// BackupsDir 返回执行前备份文件的保存目录
+func BackupsDir() string {
+	return filepath.Join(DataDir(), "backups")
+}
+
+// HabitsPath 返回用户对手动修改习惯所做决定的保存路径
+func HabitsPath() string {
+	return filepath.Join(Dir(), "habits.json")
+}
+
+// CommandDBPath 返回用户自定义的离线命令库路径，其中的条目优先于内置条目
+func CommandDBPath() string {
+	return filepath.Join(Dir(), "commands.json")
+}
+
+// HostsDir 返回 SSH 主机档案目录
+func HostsDir() string {
+	return filepath.Join(DataDir(), "hosts")
+}
+
+// ProjectsDir 返回项目快捷操作与任务索引缓存目录
+func ProjectsDir() string {
+	return filepath.Join(DataDir(), "projects")
+}
+
+// TrustPath 返回工作目录信任记录文件路径
+func TrustPath() string {
+	return filepath.Join(DataDir(), "trust.json")
+}
+
+// SkillsDir 返回技能包安装目录
+func SkillsDir() string {
+	return filepath.Join(Dir(), "skills")
+}
+
+// SafetyRulesDir 返回用户补充的风险规则目录，其中的 .yaml 文件由 safety 包加载
+func SafetyRulesDir() string {
+	return filepath.Join(Dir(), "safety.d")
+}
+
+// LibraryPath 返回共享仓库订阅记录文件路径
+func LibraryPath() string {
+	return filepath.Join(Dir(), "library.json")
+}
+
+// LibraryDir 返回共享仓库的本地克隆目录
+func LibraryDir() string {
+	return filepath.Join(DataDir(), "library")
+}
+
+// ApprovalsPath 返回变更审批审计日志路径
+func ApprovalsPath() string {
+	return filepath.Join(DataDir(), "approvals.jsonl")
+}
+
+// DictionaryPath 返回查询预处理词典文件路径
+func DictionaryPath() string {
+	return filepath.Join(Dir(), "dictionary.json")
+}
+
 // loadFromFile 从文件加载配置
 func loadFromFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -240,41 +1568,52 @@ func loadFromFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
+	data, err = migrateFile(path, data)
+	if err != nil {
+		return nil, err
+	}
+
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %w", err)
 	}
+	if config.Version == 0 {
+		config.Version = baselineVersion
+	}
 
 	return &config, nil
 }
 
-// loadFromEnv 从环境变量加载配置
-func loadFromEnv() (*Config, error) {
-	providers := []struct {
-		name      LLMProvider
-		envKey    string
-		configure func(*Config, string) error
-	}{
-		{ProviderOpenAI, "OPENAI_API_KEY", configureOpenAI},
-		{ProviderAzureOpenAI, "AZURE_OPENAI_API_KEY", configureAzureOpenAI},
-		{ProviderGemini, "GEMINI_API_KEY", configureGemini},
-		{ProviderClaude, "ANTHROPIC_API_KEY", configureClaude},
-		{ProviderLlamaCPP, "LLAMA_CPP_BASE_URL", configureLlamaCPP},
-	}
+// providerEnv 可以单独配置出一个提供商的环境变量，按优先级排列
+var providerEnv = []struct {
+	name      LLMProvider
+	envKey    string
+	configure func(*Config, string) error
+}{
+	{ProviderOpenAI, "OPENAI_API_KEY", configureOpenAI},
+	{ProviderAzureOpenAI, "AZURE_OPENAI_API_KEY", configureAzureOpenAI},
+	{ProviderGemini, "GEMINI_API_KEY", configureGemini},
+	{ProviderClaude, "ANTHROPIC_API_KEY", configureClaude},
+	{ProviderLlamaCPP, "LLAMA_CPP_BASE_URL", configureLlamaCPP},
+	{ProviderOpenAICompatible, "OPENAI_COMPATIBLE_BASE_URL", configureOpenAICompatible},
+	{ProviderOllama, "OLLAMA_HOST", configureOllama},
+}
 
+// loadFromEnv 从环境变量加载配置，返回使用的环境变量
+func loadFromEnv() (*Config, string, error) {
 	config := DefaultConfig()
 
-	for _, provider := range providers {
+	for _, provider := range providerEnv {
 		if value := os.Getenv(provider.envKey); value != "" {
 			config.LLM.Provider = provider.name
 			if err := provider.configure(config, value); err != nil {
-				return nil, fmt.Errorf("配置 %s 失败: %w", provider.name, err)
+				return nil, "", fmt.Errorf("配置 %s 失败: %w", provider.name, err)
 			}
-			return config, nil
+			return config, provider.envKey, nil
 		}
 	}
 
-	return nil, fmt.Errorf("未找到任何 LLM 提供商配置")
+	return nil, "", fmt.Errorf("未找到任何 LLM 提供商配置")
 }
 
 func configureOpenAI(config *Config, apiKey string) error {
@@ -328,6 +1667,25 @@ func configureLlamaCPP(config *Config, baseURL string) error {
 	return nil
 }
 
+func configureOllama(config *Config, host string) error {
+	config.LLM.Ollama = &OllamaConfig{
+		BaseURL: host,
+		Model:   getEnvOrDefault("OLLAMA_MODEL", "llama3.2"),
+		Timeout: 60,
+	}
+	return nil
+}
+
+func configureOpenAICompatible(config *Config, baseURL string) error {
+	config.LLM.OpenAICompatible = &OpenAICompatibleConfig{
+		BaseURL: baseURL,
+		Model:   os.Getenv("OPENAI_COMPATIBLE_MODEL"),
+		APIKey:  os.Getenv("OPENAI_COMPATIBLE_API_KEY"),
+		Timeout: 60,
+	}
+	return nil
+}
+
 // getEnvOrDefault 获取环境变量，如果不存在则返回默认值
 func getEnvOrDefault(key, defaultValue string) string {
 	return cmp.Or(os.Getenv(key), defaultValue)
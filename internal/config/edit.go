@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// secretKeys 展示配置时需要隐藏取值的键：API key、代理鉴权用的 key、请求头、slack/webhook 地址、覆盖码以及审批令牌密钥
+var secretKeys = map[string]bool{"api_key": true, "api_keys": true, "headers": true, "url": true, "override_code": true, "secret": true}
+
+// Path 返回配置文件路径
+func Path() string {
+	return getConfigPath()
+}
+
+// LoadFile 只从配置文件加载配置，不回退到环境变量，也不替换 ${VAR}，以便修改后原样写回；
+// 文件不存在时返回 os.ErrNotExist
+func LoadFile() (*Config, error) {
+	path := getConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return loadFromFile(path)
+}
+
+// Get 按点分隔的 JSON 键路径读取配置项，例如 llm.openai.model、sinks.0.type。
+// 未设置的配置项返回错误
+func (c *Config) Get(key string) (any, error) {
+	tree, err := c.tree()
+	if err != nil {
+		return nil, err
+	}
+	var cur any = tree
+	for _, part := range strings.Split(key, ".") {
+		switch node := cur.(type) {
+		case map[string]any:
+			v, ok := node[part]
+			if !ok {
+				return nil, fmt.Errorf("配置项 %s 未设置", key)
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("配置项 %s 未设置", key)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("配置项 %s 未设置", key)
+		}
+	}
+	return cur, nil
+}
+
+// Set 按点分隔的 JSON 键路径设置配置项。value 能解析为 JSON（数字、布尔、数组、对象）
+// 且符合该项的类型时按 JSON 处理，否则作为字符串；键不存在或类型不符时返回错误，不修改配置
+func (c *Config) Set(key, value string) error {
+	var parsed any
+	if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+		if err := c.set(key, parsed); err == nil {
+			return nil
+		}
+	}
+	return c.set(key, value)
+}
+
+func (c *Config) set(key string, value any) error {
+	tree, err := c.tree()
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(key, ".")
+	var cur any = tree
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch node := cur.(type) {
+		case map[string]any:
+			if last {
+				node[part] = value
+				break
+			}
+			if _, ok := node[part]; !ok {
+				// 省略的小节（例如尚未配置的提供商）按需创建
+				node[part] = map[string]any{}
+			}
+			cur = node[part]
+		case []any:
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 || n >= len(node) {
+				return fmt.Errorf("无效的配置项 %s: 下标 %s 超出范围", key, part)
+			}
+			if last {
+				node[n] = value
+				break
+			}
+			cur = node[n]
+		default:
+			return fmt.Errorf("无效的配置项 %s: %s 不是对象", key, strings.Join(parts[:i], "."))
+		}
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	// 拒绝未知的键，避免拼写错误的配置项被悄悄忽略
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var updated Config
+	if err := dec.Decode(&updated); err != nil {
+		return fmt.Errorf("无效的配置项 %s: %w", key, err)
+	}
+	*c = updated
+	return nil
+}
+
+// Redacted 返回格式化的配置 JSON，其中的 API key、请求头与通知地址只保留末尾几位，
+// 没有设置任何项的小节省略不显示
+func (c *Config) Redacted() ([]byte, error) {
+	tree, err := c.tree()
+	if err != nil {
+		return nil, err
+	}
+	redactTree(tree)
+	pruneEmpty(tree)
+	return json.MarshalIndent(tree, "", "  ")
+}
+
+// pruneEmpty 递归删除空对象
+func pruneEmpty(node map[string]any) {
+	for k, v := range node {
+		if child, ok := v.(map[string]any); ok {
+			if pruneEmpty(child); len(child) == 0 {
+				delete(node, k)
+			}
+		}
+	}
+}
+
+// tree 把配置转换为 JSON 对象，便于按键路径读写
+func (c *Config) tree() (map[string]any, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	if tree == nil {
+		return nil, errors.New("配置为空")
+	}
+	return tree, nil
+}
+
+// redactTree 递归隐藏 secretKeys 中的取值
+func redactTree(node any) {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, v := range n {
+			if secretKeys[k] {
+				n[k] = maskValue(v)
+				continue
+			}
+			redactTree(v)
+		}
+	case []any:
+		for _, v := range n {
+			redactTree(v)
+		}
+	}
+}
+
+// maskValue 隐藏字符串，以及数组、对象中的各个字符串
+func maskValue(v any) any {
+	switch x := v.(type) {
+	case string:
+		return Mask(x)
+	case []any:
+		for i := range x {
+			x[i] = maskValue(x[i])
+		}
+	case map[string]any:
+		for k := range x {
+			x[k] = maskValue(x[k])
+		}
+	}
+	return v
+}
+
+// Mask 隐藏密钥，只保留末尾 4 位供辨认；较短的值全部隐藏
+func Mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 12 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// envNameRe 环境变量名：字母或下划线开头，由字母、数字、下划线组成
+var envNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExpandEnv 返回把字符串取值中的 ${VAR} 替换为环境变量后的配置，使配置文件可以提交到
+// dotfiles 而不包含密钥明文；$${ 表示字面的 ${。引用了未设置的环境变量时返回错误，
+// 列出每个出错的配置项。配置本身不被修改，保存时仍写回 ${VAR}
+func (c *Config) ExpandEnv() (*Config, error) {
+	tree, err := c.tree()
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	expandTree(tree, "", &errs)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	var expanded Config
+	if err := json.Unmarshal(data, &expanded); err != nil {
+		return nil, fmt.Errorf("解析替换环境变量后的配置失败: %w", err)
+	}
+	return &expanded, nil
+}
+
+// expandTree 递归替换字符串取值中的环境变量，key 为当前节点点分隔的键路径
+func expandTree(node any, key string, errs *[]error) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(n)) {
+			n[k] = expandTree(n[k], joinKey(key, k), errs)
+		}
+	case []any:
+		for i, v := range n {
+			n[i] = expandTree(v, joinKey(key, strconv.Itoa(i)), errs)
+		}
+	case string:
+		s, err := expandString(n)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("配置项 %s %w", key, err))
+			return n
+		}
+		return s
+	}
+	return node
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// expandString 替换 s 中的 ${VAR}；设置为空字符串的变量按空字符串替换
+func expandString(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("中的 ${ 缺少对应的 }（字面的 ${ 请写作 $${）")
+		}
+		name := s[i+2 : i+end]
+		if !envNameRe.MatchString(name) {
+			return "", fmt.Errorf("中的 ${%s} 不是有效的环境变量名", name)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("引用的环境变量 %s 未设置", name)
+		}
+		b.WriteString(value)
+		s = s[i+end+1:]
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// CurrentVersion 当前配置文件结构版本，每次不兼容的结构调整都需要递增并追加迁移
+const CurrentVersion = 1
+
+// baselineVersion 引入 version 字段时的配置结构。此前的配置没有 version 字段，结构与该版本相同，
+// 加载时不需要迁移也不会改写文件，下次保存配置时写入 version
+const baselineVersion = 1
+
+// migration 将配置从 from 版本升级到 from+1 版本
+type migration struct {
+	from  int
+	desc  string
+	apply func(raw map[string]any) error
+}
+
+// migrations 从基线版本起按版本顺序排列的迁移步骤，第 i 步的 from 为 baselineVersion+i。
+// 目前的结构就是基线版本，尚无需要迁移的旧结构；密钥仍保存在配置文件或环境变量中，迁移不会将其移入系统钥匙串
+var migrations []migration
+
+// latestVersion 执行全部迁移步骤后的版本，应与 CurrentVersion 一致
+func latestVersion() int {
+	return baselineVersion + len(migrations)
+}
+
+// migrate 将原始配置升级到最新版本，返回是否发生了迁移
+func migrate(raw map[string]any) (bool, error) {
+	version, latest := rawVersion(raw), latestVersion()
+	if version > latest {
+		return false, fmt.Errorf("配置文件版本 %d 高于当前支持的版本 %d，请升级 termi", version, latest)
+	}
+	if version == latest {
+		return false, nil
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		if err := m.apply(raw); err != nil {
+			return false, fmt.Errorf("配置迁移 v%d→v%d (%s) 失败: %w", m.from, m.from+1, m.desc, err)
+		}
+	}
+	raw["version"] = latest
+	return true, nil
+}
+
+// migrateFile 在需要时迁移配置文件：先备份原文件，再写回升级后的内容
+func migrateFile(path string, data []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	if rawVersion(raw) == latestVersion() {
+		return data, nil
+	}
+
+	// 多个 termi 同时启动时只由一个进程迁移：拿到锁后重新读取，其他进程可能已经迁移完成
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return nil, fmt.Errorf("迁移配置文件失败: %w", err)
+	}
+	defer unlock()
+	if data, err = os.ReadFile(path); err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	raw = nil
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	from := rawVersion(raw)
+	changed, err := migrate(raw)
+	if err != nil || !changed {
+		return data, err
+	}
+
+	backup := fmt.Sprintf("%s.bak-v%d-%s", path, from, time.Now().Format("20060102150405"))
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return nil, fmt.Errorf("备份配置文件失败: %w", err)
+	}
+
+	migrated, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("序列化迁移后的配置失败: %w", err)
+	}
+	if err := filelock.WriteFile(path, migrated, 0600); err != nil {
+		return nil, fmt.Errorf("写入迁移后的配置失败: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "配置文件已从 v%d 升级到 v%d，原文件备份为 %s\n", from, latestVersion(), backup)
+	return migrated, nil
+}
+
+// rawVersion 读取原始配置中的版本号，缺失时视为基线版本
+func rawVersion(raw map[string]any) int {
+	if v, ok := raw["version"].(float64); ok {
+		return int(v)
+	}
+	if v, ok := raw["version"].(int); ok {
+		return v
+	}
+	return baselineVersion
+}
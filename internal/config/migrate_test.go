@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withMigrations 在测试期间替换迁移步骤
+func withMigrations(t *testing.T, steps []migration) {
+	t.Helper()
+	old := migrations
+	migrations = steps
+	t.Cleanup(func() { migrations = old })
+}
+
+// writeConfig 将 data 写入临时目录中的配置文件并返回路径
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// backups 返回配置文件旁的备份文件
+func backups(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".bak-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestMigrationsContiguous(t *testing.T) {
+	for i, m := range migrations {
+		if m.from != baselineVersion+i {
+			t.Errorf("migration %d (%s) starts at v%d, want v%d", i, m.desc, m.from, baselineVersion+i)
+		}
+	}
+	if latestVersion() != CurrentVersion {
+		t.Errorf("migrations end at v%d, CurrentVersion is %d", latestVersion(), CurrentVersion)
+	}
+}
+
+func TestMigrateOrder(t *testing.T) {
+	var applied []int
+	step := func(from int) migration {
+		return migration{from: from, desc: "test", apply: func(raw map[string]any) error {
+			applied = append(applied, from)
+			raw["step"] = float64(from)
+			return nil
+		}}
+	}
+	withMigrations(t, []migration{step(1), step(2), step(3)})
+
+	tests := []struct {
+		name    string
+		raw     map[string]any
+		applied []int
+		changed bool
+	}{
+		{"Missing", map[string]any{}, []int{1, 2, 3}, true},
+		{"Baseline", map[string]any{"version": float64(1)}, []int{1, 2, 3}, true},
+		{"Partial", map[string]any{"version": float64(3)}, []int{3}, true},
+		{"Latest", map[string]any{"version": float64(4)}, nil, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			applied = nil
+			changed, err := migrate(tc.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tc.changed || !reflect.DeepEqual(applied, tc.applied) {
+				t.Errorf("migrate() = %v, applied %v; want %v, applied %v", changed, applied, tc.changed, tc.applied)
+			}
+			if got := rawVersion(tc.raw); got != 4 {
+				t.Errorf("version = %d, want 4", got)
+			}
+		})
+	}
+}
+
+func TestMigrateRejectsNewer(t *testing.T) {
+	const data = `{"version": 99, "llm": {"provider": "openai"}}`
+	path := writeConfig(t, data)
+	if _, err := migrateFile(path, []byte(data)); err == nil || !strings.Contains(err.Error(), "请升级 termi") {
+		t.Fatalf("migrateFile() error = %v, want newer-version error", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != data {
+		t.Errorf("config rewritten to %s", got)
+	}
+	if b := backups(t, path); len(b) != 0 {
+		t.Errorf("unexpected backups %v", b)
+	}
+}
+
+func TestMigrateFileBaseline(t *testing.T) {
+	// 引入 version 之前的配置：没有 version 字段，键的顺序与格式都应保持原样
+	const data = "{\n  \"llm\": {\"provider\": \"ollama\"},\n  \"shell\": \"zsh\"\n}\n"
+	path := writeConfig(t, data)
+	got, err := migrateFile(path, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != data {
+		t.Errorf("migrateFile() = %s, want unchanged", got)
+	}
+	if disk, _ := os.ReadFile(path); string(disk) != data {
+		t.Errorf("config rewritten to %s", disk)
+	}
+	if b := backups(t, path); len(b) != 0 {
+		t.Errorf("unexpected backups %v", b)
+	}
+
+	cfg, err := loadFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != CurrentVersion || cfg.Shell != "zsh" {
+		t.Errorf("loaded version %d shell %q", cfg.Version, cfg.Shell)
+	}
+}
+
+func TestMigrateFileBackup(t *testing.T) {
+	withMigrations(t, []migration{{from: 1, desc: "rename", apply: func(raw map[string]any) error {
+		raw["shell"] = raw["sh"]
+		delete(raw, "sh")
+		return nil
+	}}})
+	const data = `{"version": 1, "sh": "fish"}`
+	path := writeConfig(t, data)
+
+	got, err := migrateFile(path, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(got, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"version": float64(2), "shell": "fish"}; !reflect.DeepEqual(raw, want) {
+		t.Errorf("migrated = %v, want %v", raw, want)
+	}
+	if disk, _ := os.ReadFile(path); string(disk) != string(got) {
+		t.Errorf("config on disk = %s, want %s", disk, got)
+	}
+
+	b := backups(t, path)
+	if len(b) != 1 || !strings.Contains(b[0], ".bak-v1-") {
+		t.Fatalf("backups = %v, want one v1 backup", b)
+	}
+	if old, _ := os.ReadFile(b[0]); string(old) != data {
+		t.Errorf("backup = %s, want %s", old, data)
+	}
+
+	// 已迁移的文件不会再次迁移或备份
+	if _, err := migrateFile(path, got); err != nil {
+		t.Fatal(err)
+	}
+	if b := backups(t, path); len(b) != 1 {
+		t.Errorf("backups after second load = %v", b)
+	}
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Source 说明提供商配置取自哪里，以及为什么。优先级：
+//
+//  1. 指定 --provider-from-env 时，提供商取自环境变量，其余设置仍取自配置文件；
+//  2. 存在配置文件时，整份配置取自配置文件，提供商环境变量不生效；
+//  3. 没有配置文件时，取第一个设置了的提供商环境变量（顺序见 EnvKeys）
+type Source struct {
+	Path    string   // 读取的配置文件，不存在时为空
+	EnvKey  string   // 提供商取自的环境变量，取自配置文件时为空
+	Ignored []string // 设置了但没有生效的提供商环境变量
+}
+
+// EnvKeys 按优先级返回可以配置出提供商的环境变量
+func EnvKeys() []string {
+	keys := make([]string, len(providerEnv))
+	for i, p := range providerEnv {
+		keys[i] = p.envKey
+	}
+	return keys
+}
+
+// Conflict 是否有设置了却被忽略的提供商环境变量
+func (s Source) Conflict() bool {
+	return len(s.Ignored) > 0
+}
+
+// Describe 用一句话说明使用了哪个来源以及原因
+func (s Source) Describe() string {
+	ignored := strings.Join(s.Ignored, "、")
+	switch {
+	case s.EnvKey != "" && s.Path != "":
+		return fmt.Sprintf("提供商取自环境变量 %s（--provider-from-env），其余设置取自配置文件 %s", s.EnvKey, s.Path)
+	case s.EnvKey != "" && ignored != "":
+		return fmt.Sprintf("没有配置文件，提供商取自环境变量 %s；%s 优先级更低，未生效", s.EnvKey, ignored)
+	case s.EnvKey != "":
+		return fmt.Sprintf("没有配置文件，提供商取自环境变量 %s", s.EnvKey)
+	case ignored != "":
+		return fmt.Sprintf("配置文件 %s 优先于环境变量，%s 未生效；改用环境变量请加 --provider-from-env", s.Path, ignored)
+	}
+	return fmt.Sprintf("使用配置文件 %s", s.Path)
+}
+
+// Load 按 Source 中说明的优先级加载配置。providerFromEnv 为 true 时提供商取自环境变量
+func Load(providerFromEnv bool) (*Config, Source, error) {
+	var src Source
+	var cfg *Config
+	path := getConfigPath()
+	if _, err := os.Stat(path); err == nil {
+		raw, err := loadFromFile(path)
+		if err != nil {
+			return nil, src, err
+		}
+		if cfg, err = raw.ExpandEnv(); err != nil {
+			return nil, src, err
+		}
+		src.Path = path
+
+		if !providerFromEnv {
+			// 配置文件通过 ${VAR} 引用的环境变量正在生效，不算冲突
+			for _, key := range setEnvKeys() {
+				if !raw.references(key) {
+					src.Ignored = append(src.Ignored, key)
+				}
+			}
+			return cfg, src, nil
+		}
+	}
+
+	env, key, err := loadFromEnv()
+	if err != nil {
+		if providerFromEnv {
+			return nil, src, fmt.Errorf("--provider-from-env 需要设置以下环境变量之一: %s", strings.Join(EnvKeys(), "、"))
+		}
+		return nil, src, err
+	}
+	src.EnvKey = key
+	for _, k := range setEnvKeys() {
+		if k != key {
+			src.Ignored = append(src.Ignored, k)
+		}
+	}
+	if cfg == nil {
+		return env, src, nil
+	}
+	cfg.LLM.useProvider(&env.LLM)
+	return cfg, src, nil
+}
+
+// useProvider 改用 env 中的提供商及其小节，保留重试、failover 等其余设置
+func (lc *LLMConfig) useProvider(env *LLMConfig) {
+	lc.Provider = env.Provider
+	switch env.Provider {
+	case ProviderOpenAI:
+		lc.OpenAI = env.OpenAI
+	case ProviderAzureOpenAI:
+		lc.AzureOpenAI = env.AzureOpenAI
+	case ProviderGemini:
+		lc.Gemini = env.Gemini
+	case ProviderClaude:
+		lc.Claude = env.Claude
+	case ProviderLlamaCPP:
+		lc.LlamaCPP = env.LlamaCPP
+	case ProviderOpenAICompatible:
+		lc.OpenAICompatible = env.OpenAICompatible
+	case ProviderOllama:
+		lc.Ollama = env.Ollama
+	case ProviderCommand:
+		lc.Command = env.Command
+	}
+}
+
+// setEnvKeys 按优先级返回已设置的提供商环境变量
+func setEnvKeys() []string {
+	var keys []string
+	for _, key := range EnvKeys() {
+		if os.Getenv(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// references 配置中是否有取值通过 ${name} 引用了该环境变量
+func (c *Config) references(name string) bool {
+	data, err := json.Marshal(c)
+	return err == nil && bytes.Contains(data, []byte("${"+name+"}"))
+}
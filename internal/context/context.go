@@ -0,0 +1,165 @@
+// Package context 采集本机环境信息（工作目录、系统、shell、包管理器、git 状态、已安装工具），
+// 生成简短摘要附加到提示词中，避免模型在 macOS 上建议 apt 或凭空猜测路径。
+// 每一项都需要在配置中单独开启
+package context
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+)
+
+// timeout 采集 git 状态的超时时间
+const timeout = 2 * time.Second
+
+// gitStatusLines git status --short 最多保留的行数
+const gitStatusLines = 20
+
+// packageManagers 各平台按优先级检测的系统包管理器
+var packageManagers = map[string][]string{
+	"darwin":  {"brew", "port"},
+	"linux":   {"apt", "dnf", "yum", "pacman", "zypper", "apk", "nix"},
+	"windows": {"winget", "scoop", "choco"},
+}
+
+// tools 与生成命令相关、值得告知模型是否已安装的工具
+var tools = []string{
+	"git", "docker", "podman", "kubectl", "helm", "terraform",
+	"python3", "node", "go", "cargo", "java",
+	"jq", "yq", "rg", "fd", "fzf", "curl", "wget", "rsync", "ffmpeg",
+}
+
+// Environment 采集到的环境信息，未开启或采集失败的项为空
+type Environment struct {
+	Cwd            string
+	OS             string
+	Arch           string
+	Shell          string
+	PackageManager string
+	GitRepo        bool
+	GitStatus      string
+	Tools          []string
+}
+
+// Installed 检测工具是否位于 PATH 中，nil 时直接查找 PATH
+type Installed func(names ...string) map[string]bool
+
+// Collect 按配置采集环境信息；git 信息来自当前目录的项目数据，gitAllowed 为 false 时跳过
+func Collect(ctx context.Context, cc config.ContextConfig, installed Installed, gitAllowed bool) Environment {
+	if installed == nil {
+		installed = lookPath
+	}
+
+	var env Environment
+	if cc.Cwd {
+		env.Cwd, _ = os.Getwd()
+	}
+	if cc.OS {
+		env.OS, env.Arch = runtime.GOOS, runtime.GOARCH
+	}
+	if cc.Shell {
+		env.Shell = shell()
+	}
+	if cc.PackageManager {
+		found := installed(packageManagers[runtime.GOOS]...)
+		for _, name := range packageManagers[runtime.GOOS] {
+			if found[name] {
+				env.PackageManager = name
+				break
+			}
+		}
+	}
+	if cc.Git && gitAllowed {
+		env.GitRepo, env.GitStatus = gitStatus(ctx)
+	}
+	if cc.Tools {
+		found := installed(tools...)
+		for _, name := range tools {
+			if found[name] {
+				env.Tools = append(env.Tools, name)
+			}
+		}
+	}
+	return env
+}
+
+// Render 将环境信息格式化为提示词片段，没有任何信息时返回空字符串
+func (e Environment) Render() string {
+	var lines []string
+	add := func(format string, args ...any) {
+		lines = append(lines, "- "+fmt.Sprintf(format, args...))
+	}
+	if e.Cwd != "" {
+		add("当前目录: %s", e.Cwd)
+	}
+	if e.OS != "" {
+		add("系统: %s/%s", e.OS, e.Arch)
+	}
+	if e.Shell != "" {
+		add("shell: %s", e.Shell)
+	}
+	if e.PackageManager != "" {
+		add("包管理器: %s", e.PackageManager)
+	}
+	if e.GitRepo {
+		if e.GitStatus == "" {
+			add("当前目录是 git 仓库，工作区干净")
+		} else {
+			add("当前目录是 git 仓库，git status --short:\n%s", indent(e.GitStatus))
+		}
+	}
+	if len(e.Tools) > 0 {
+		add("已安装的工具: %s", strings.Join(e.Tools, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shell 返回用户的 shell 名称，例如 zsh
+func shell() string {
+	if runtime.GOOS == "windows" {
+		if os.Getenv("PSModulePath") != "" {
+			return "powershell"
+		}
+		return "cmd"
+	}
+	return filepath.Base(os.Getenv("SHELL"))
+}
+
+// gitStatus 报告当前目录是否位于 git 仓库中及其简短状态，状态过长时截断
+func gitStatus(ctx context.Context) (bool, string) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := exec.CommandContext(ctx, "git", "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		return false, ""
+	}
+	out, err := exec.CommandContext(ctx, "git", "status", "--short").Output()
+	if err != nil {
+		return true, ""
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) > gitStatusLines {
+		lines = append(lines[:gitStatusLines], fmt.Sprintf("...（共 %d 项）", len(lines)))
+	}
+	return true, strings.Join(lines, "\n")
+}
+
+func lookPath(names ...string) map[string]bool {
+	found := make(map[string]bool, len(names))
+	for _, name := range names {
+		_, err := exec.LookPath(name)
+		found[name] = err == nil
+	}
+	return found
+}
+
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
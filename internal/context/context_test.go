@@ -0,0 +1,134 @@
+package context
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"termi.sh/termi/internal/config"
+)
+
+// chdir 切换到 dir，测试结束后恢复原目录
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(old) })
+}
+
+// gitRepo 创建只含一个未跟踪文件的 git 仓库
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// fakeInstalled 只有 names 中的工具视为已安装
+func fakeInstalled(names ...string) Installed {
+	return func(query ...string) map[string]bool {
+		found := make(map[string]bool, len(query))
+		for _, name := range query {
+			found[name] = slices.Contains(names, name)
+		}
+		return found
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+	t.Setenv("SHELL", "/bin/zsh")
+	t.Setenv("PSModulePath", "")
+	cwd, _ := os.Getwd()
+
+	managers := packageManagers[runtime.GOOS]
+	if len(managers) < 2 {
+		t.Skipf("no package managers known for %s", runtime.GOOS)
+	}
+	// 同时安装了多个包管理器时取优先级最高的一个
+	installed := fakeInstalled(managers[len(managers)-1], managers[1], "jq", "git")
+	wantShell := "zsh"
+	if runtime.GOOS == "windows" {
+		wantShell = "cmd"
+	}
+
+	tests := []struct {
+		name string
+		cc   config.ContextConfig
+		want Environment
+	}{
+		{"None", config.ContextConfig{}, Environment{}},
+		{"Cwd", config.ContextConfig{Cwd: true}, Environment{Cwd: cwd}},
+		{"OS", config.ContextConfig{OS: true}, Environment{OS: runtime.GOOS, Arch: runtime.GOARCH}},
+		{"Shell", config.ContextConfig{Shell: true}, Environment{Shell: wantShell}},
+		{"PackageManager", config.ContextConfig{PackageManager: true}, Environment{PackageManager: managers[1]}},
+		{"Tools", config.ContextConfig{Tools: true}, Environment{Tools: []string{"git", "jq"}}},
+		// 临时目录不是 git 仓库
+		{"GitOutsideRepo", config.ContextConfig{Git: true}, Environment{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Collect(context.Background(), tc.cc, installed, true)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Collect() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollectGit(t *testing.T) {
+	chdir(t, gitRepo(t))
+	tests := []struct {
+		name       string
+		git        bool
+		gitAllowed bool
+		wantRepo   bool
+	}{
+		{"Enabled", true, true, true},
+		{"NotAllowed", true, false, false},
+		{"Disabled", false, true, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := Collect(context.Background(), config.ContextConfig{Git: tc.git}, fakeInstalled(), tc.gitAllowed)
+			if env.GitRepo != tc.wantRepo {
+				t.Fatalf("GitRepo = %v, want %v", env.GitRepo, tc.wantRepo)
+			}
+			if tc.wantRepo != strings.Contains(env.GitStatus, "?? a.txt") {
+				t.Errorf("GitStatus = %q", env.GitStatus)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	if got := (Environment{}).Render(); got != "" {
+		t.Errorf("empty Render() = %q, want empty", got)
+	}
+	env := Environment{OS: "linux", Arch: "amd64", GitRepo: true, GitStatus: " M a.go", Tools: []string{"git", "jq"}}
+	want := "- 系统: linux/amd64\n- 当前目录是 git 仓库，git status --short:\n   M a.go\n- 已安装的工具: git, jq"
+	if got := env.Render(); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
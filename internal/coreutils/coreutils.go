@@ -0,0 +1,142 @@
+// Package coreutils 识别本机核心工具是 GNU 还是 BSD 版本，并转换二者间已知不兼容的写法
+package coreutils
+
+import (
+	"context"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Flavor 核心工具的实现版本
+type Flavor string
+
+const (
+	Unknown Flavor = ""
+	GNU     Flavor = "GNU"
+	BSD     Flavor = "BSD"
+	BusyBox Flavor = "BusyBox"
+)
+
+// Runner 执行只读探测命令，通常为带缓存的 probe.Cache.Run
+type Runner func(ctx context.Context, cmd string) (string, error)
+
+// Detect 通过 `sed --version` 判断核心工具的版本：GNU 与 BusyBox 会输出版本信息，BSD sed 不支持该参数
+func Detect(ctx context.Context, run Runner) Flavor {
+	out, err := run(ctx, "sed --version")
+	switch {
+	case strings.Contains(out, "BusyBox") || strings.Contains(out, "not GNU"):
+		return BusyBox
+	case err == nil && strings.Contains(out, "GNU"):
+		return GNU
+	case err != nil && isBSD(runtime.GOOS):
+		return BSD
+	default:
+		return Unknown
+	}
+}
+
+func isBSD(goos string) bool {
+	switch goos {
+	case "darwin", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return true
+	}
+	return false
+}
+
+// Hint 返回告知模型本机工具版本的提示词片段
+func Hint(f Flavor) string {
+	switch f {
+	case GNU:
+		return "本机核心工具（sed、date、grep 等）为 GNU 版本。"
+	case BSD:
+		return "本机核心工具（sed、date、grep 等）为 BSD 版本，不支持 GNU 专有参数。"
+	case BusyBox:
+		return "本机核心工具为 BusyBox 版本，仅支持常用的短参数。"
+	default:
+		return ""
+	}
+}
+
+// Result 转换结果
+type Result struct {
+	Command  string
+	Changes  []string // 已自动转换的写法
+	Warnings []string // 无法自动转换、可能不兼容的写法
+}
+
+var (
+	// sedInPlace 匹配 sed 的 -i 参数及其后的空白
+	sedInPlace = regexp.MustCompile(`\bsed(?:\s+-[A-Za-z]+)*\s+-i\s+`)
+	// sedEmptySuffix 匹配 BSD 写法的 sed -i ''
+	sedEmptySuffix = regexp.MustCompile(`(\bsed(?:\s+-[A-Za-z]+)*\s+-i)\s+(?:''|"")`)
+	dateEpochGNU   = regexp.MustCompile(`\bdate(\s+(?:-u\s+)?)-d\s+@(\d+)`)
+	dateEpochBSD   = regexp.MustCompile(`\bdate(\s+(?:-u\s+)?)-r\s+(\d+)\b`)
+	xargsNoRun     = regexp.MustCompile(`\bxargs\s+-r\s+`)
+
+	// gnuOnlyUsages 无法自动转换的 GNU 专有写法
+	gnuOnlyUsages = []struct {
+		re   *regexp.Regexp
+		desc string
+	}{
+		{regexp.MustCompile(`\bgrep(?:\s+-[A-Za-z]+)*\s+-[A-Za-z]*P`), "grep -P（Perl 正则）"},
+		{regexp.MustCompile(`\bdate\b[^|;&]*\s-d\s`), "date -d"},
+		{regexp.MustCompile(`\bstat\s+-c\b`), "stat -c"},
+		{regexp.MustCompile(`\bfind\b[^|;&]*\s-printf\b`), "find -printf"},
+		{regexp.MustCompile(`\b(?:ls|cp|mv|rm|mkdir|sort|du|df)\s[^|;&]*--[a-z]`), "GNU 长参数"},
+	}
+)
+
+// Translate 将命令转换为适用于目标版本的写法；版本未知或无需转换时原样返回
+func Translate(cmd string, f Flavor) Result {
+	r := Result{Command: cmd}
+	switch f {
+	case BSD:
+		r.toBSD()
+	case GNU:
+		r.toGNU()
+	}
+	return r
+}
+
+func (r *Result) toBSD() {
+	// sed -i 'script' → sed -i '' 'script'：BSD sed 的 -i 必须带后缀参数
+	var b strings.Builder
+	last, changed := 0, false
+	for _, loc := range sedInPlace.FindAllStringIndex(r.Command, -1) {
+		rest := r.Command[loc[1]:]
+		if strings.HasPrefix(rest, "''") || strings.HasPrefix(rest, `""`) {
+			continue
+		}
+		b.WriteString(r.Command[last:loc[1]])
+		b.WriteString("'' ")
+		last, changed = loc[1], true
+	}
+	if changed {
+		b.WriteString(r.Command[last:])
+		r.Command = b.String()
+		r.Changes = append(r.Changes, "sed -i → sed -i ''")
+	}
+
+	r.replace(dateEpochGNU, "date${1}-r ${2}", "date -d @时间戳 → date -r 时间戳")
+	r.replace(xargsNoRun, "xargs ", "去掉 xargs -r（BSD xargs 默认不在空输入时执行）")
+
+	for _, u := range gnuOnlyUsages {
+		if u.re.MatchString(r.Command) {
+			r.Warnings = append(r.Warnings, u.desc+" 在 BSD 版本中不可用")
+		}
+	}
+}
+
+func (r *Result) toGNU() {
+	r.replace(sedEmptySuffix, "$1", "sed -i '' → sed -i")
+	r.replace(dateEpochBSD, "date${1}-d @${2}", "date -r 时间戳 → date -d @时间戳")
+}
+
+// replace 应用一条转换规则，发生替换时记录说明
+func (r *Result) replace(re *regexp.Regexp, repl, desc string) {
+	if out := re.ReplaceAllString(r.Command, repl); out != r.Command {
+		r.Command = out
+		r.Changes = append(r.Changes, desc)
+	}
+}
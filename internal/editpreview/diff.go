@@ -0,0 +1,134 @@
+package editpreview
+
+import "fmt"
+
+const (
+	// contextLines 每处修改前后保留的未修改行数
+	contextLines = 3
+	// maxCells 求最长公共子序列时比较表的上限，超过时把整段视为先删除后插入
+	maxCells = 4 << 20
+)
+
+// line 逐行比较的一行结果，kind 为 ' '、'-' 或 '+'
+type line struct {
+	kind byte
+	text string
+}
+
+// Unified 比较修改前后的内容，返回统一 diff 格式的各个修改块（不含文件头），内容相同时返回 nil
+func Unified(a, b []string) []string {
+	ops := compare(a, b)
+
+	// 每一行在修改前后文件中的行号（从 0 开始，指向该行之前已有的行数）
+	aPos, bPos := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if op.kind != '+' {
+			aPos[i+1]++
+		}
+		if op.kind != '-' {
+			bPos[i+1]++
+		}
+	}
+
+	var out []string
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// 合并间隔不超过两倍上下文的修改
+		start, end := max(0, i-contextLines), i
+		for j := i; j < len(ops) && j < end+2*contextLines+1; j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			}
+		}
+		stop := min(len(ops), end+contextLines)
+
+		aCount, bCount := aPos[stop]-aPos[start], bPos[stop]-bPos[start]
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@", hunkRange(aPos[start], aCount), hunkRange(bPos[start], bCount)))
+		for _, op := range ops[start:stop] {
+			out = append(out, string(op.kind)+op.text)
+		}
+		i = stop
+	}
+	return out
+}
+
+// hunkRange 按 diff -u 的写法给出修改块的起始行与行数
+func hunkRange(pos, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", pos+1)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, count)
+}
+
+// compare 逐行比较，先去掉相同的开头与结尾，再对中间部分求最长公共子序列
+func compare(a, b []string) []line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []line
+	for _, t := range a[:prefix] {
+		ops = append(ops, line{' ', t})
+	}
+	am, bm := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(am)+1)*(len(bm)+1) > maxCells {
+		for _, t := range am {
+			ops = append(ops, line{'-', t})
+		}
+		for _, t := range bm {
+			ops = append(ops, line{'+', t})
+		}
+	} else {
+		ops = append(ops, lcs(am, bm)...)
+	}
+	for _, t := range a[len(a)-suffix:] {
+		ops = append(ops, line{' ', t})
+	}
+	return ops
+}
+
+// lcs 按最长公共子序列给出逐行编辑，删除排在插入之前
+func lcs(a, b []string) []line {
+	w := len(b) + 1
+	// table[i*w+j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	table := make([]int32, (len(a)+1)*w)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*w+j] = table[(i+1)*w+j+1] + 1
+			} else {
+				table[i*w+j] = max(table[(i+1)*w+j], table[i*w+j+1])
+			}
+		}
+	}
+
+	var ops []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || table[(i+1)*w+j] >= table[i*w+j+1]):
+			ops = append(ops, line{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, line{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
@@ -0,0 +1,602 @@
+// Package editpreview 预览就地修改文件的命令（sed -i、perl -i、gawk -i inplace、patch）：
+// 把要修改的文件复制到临时目录，在副本上运行同一条命令，再以统一 diff 格式展示修改结果，
+// 原文件不受影响。命令的脚本可以执行任意程序（perl 的 system、GNU sed 的 e 与 w、awk 的 system），
+// 因此只在 bubblewrap 沙箱中运行：根目录只读、只有临时目录可写、网络与本机服务断开
+package editpreview
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/preview"
+)
+
+const (
+	// timeout 在副本上运行命令的最长时间
+	timeout = 5 * time.Second
+	// maxFileSize 可以预览的单个文件的大小上限
+	maxFileSize = 1 << 20
+)
+
+// ErrUnsupported 命令不是可以预览的单条就地修改命令
+var ErrUnsupported = errors.New("只能预览单条 sed -i、perl -i、gawk -i inplace 或 patch 命令")
+
+// File 命令修改的一个文件
+type File struct {
+	Path  string   // 命令中给出的路径
+	Lines []string // 统一 diff 格式的修改（以 @@、空格、-、+ 开头），没有修改时为空
+}
+
+// edit 解析出的就地修改命令
+type edit struct {
+	env     []string // 命令前的变量赋值
+	args    []string // 程序及其参数
+	targets []target
+	patch   bool // 在临时目录中按补丁里的相对路径放置副本
+}
+
+// target 要修改的文件；arg 为它在 args 中的位置，取自补丁内容时为 -1
+type target struct {
+	arg  int
+	path string
+}
+
+// Available 检查预览所需的沙箱是否可用，结果在进程内缓存
+var Available = sync.OnceValue(preview.Sandboxable)
+
+// Supported 报告命令是否为可以预览的就地修改命令，不读取任何文件
+func Supported(command string) bool {
+	_, err := parse(command, "")
+	return err == nil
+}
+
+// Run 以 dir 为工作目录，在临时副本上运行命令并返回各文件的修改
+func Run(ctx context.Context, command, dir string) ([]File, error) {
+	e, err := parse(command, dir)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "termi-edit-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	originals := make([][]byte, len(e.targets))
+	copies := make([]string, len(e.targets))
+	args := append([]string(nil), e.args...)
+	for i, t := range e.targets {
+		src := t.path
+		if !filepath.IsAbs(src) {
+			src = filepath.Join(dir, src)
+		}
+		data, err := readText(src)
+		if err != nil && !(e.patch && errors.Is(err, os.ErrNotExist)) {
+			return nil, err
+		}
+		originals[i] = data
+
+		copies[i] = filepath.Join(tmp, strconv.Itoa(i), filepath.Base(t.path))
+		if e.patch {
+			copies[i] = filepath.Join(tmp, t.path)
+		}
+		if err := os.MkdirAll(filepath.Dir(copies[i]), 0700); err != nil {
+			return nil, err
+		}
+		if data != nil {
+			if err := os.WriteFile(copies[i], data, 0600); err != nil {
+				return nil, err
+			}
+		}
+		if t.arg >= 0 {
+			args[t.arg] = copies[i]
+		}
+	}
+
+	isolation, seccomp, err := preview.Isolation(dir, tmp)
+	if err != nil {
+		return nil, err
+	}
+	defer seccomp.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "bwrap", sandboxArgs(args, e.env, dir, tmp, isolation)...)
+	cmd.ExtraFiles = []*os.File{seccomp}
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("预览超时（%s）", timeout)
+		}
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	files := make([]File, len(e.targets))
+	for i, t := range e.targets {
+		after, err := os.ReadFile(copies[i])
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		files[i] = File{Path: t.path, Lines: Unified(lines(originals[i]), lines(after))}
+	}
+	return files, nil
+}
+
+// sandboxArgs 构建在沙箱中运行 args 的 bwrap 参数：根目录只读，只有存放副本的 tmp 可写，
+// 其余临时文件被遮住；dir 位于临时目录下时保留原有的临时目录，以便读取其中的补丁。
+// 命令前的变量赋值以 --setenv 只交给沙箱中的命令，不影响 bwrap 自身（如 LD_PRELOAD）
+func sandboxArgs(args, env []string, dir, tmp string, isolation []string) []string {
+	sandbox := []string{"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc"}
+	if rel, err := filepath.Rel(os.TempDir(), dir); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		sandbox = append(sandbox, "--tmpfs", os.TempDir())
+	}
+	sandbox = append(sandbox, "--bind", tmp, tmp)
+	sandbox = append(sandbox, isolation...)
+	for _, kv := range env {
+		name, value, _ := strings.Cut(kv, "=")
+		sandbox = append(sandbox, "--setenv", name, value)
+	}
+	sandbox = append(sandbox, "--unshare-pid", "--die-with-parent", "--new-session", "--chdir", tmp, "--")
+	return append(sandbox, args...)
+}
+
+// readText 读取要预览的文件，拒绝过大的文件与二进制文件
+func readText(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s 不是普通文件", path)
+	}
+	if info.Size() > maxFileSize {
+		return nil, fmt.Errorf("%s 超过 %d KB，不做预览", path, maxFileSize>>10)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, fmt.Errorf("%s 不是文本文件", path)
+	}
+	return data, nil
+}
+
+func lines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// assignment 命令前的变量赋值，如 LC_ALL=C
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// parse 拆分命令并找出要修改的文件；dir 为空时不读取补丁内容，只判断命令形式
+func parse(command, dir string) (*edit, error) {
+	words, globs, redirect, ok := split(command)
+	if !ok || len(words) == 0 {
+		return nil, ErrUnsupported
+	}
+	if dir != "" {
+		words = expand(words, globs, dir)
+	}
+	e := &edit{}
+	for len(words) > 0 && assignment.MatchString(words[0]) {
+		e.env = append(e.env, words[0])
+		words = words[1:]
+	}
+	// 副本属于当前用户，预览不需要提权
+	if len(words) > 1 && words[0] == "sudo" && !strings.HasPrefix(words[1], "-") {
+		words = words[1:]
+	}
+	if len(words) < 2 {
+		return nil, ErrUnsupported
+	}
+	e.args = words
+
+	var files []int
+	var err error
+	switch filepath.Base(words[0]) {
+	case "sed", "gsed":
+		files, err = sedFiles(words)
+	case "perl":
+		files, err = perlFiles(words)
+	case "awk", "gawk":
+		files, err = awkFiles(words)
+	case "patch":
+		return e, patchTargets(e, redirect, dir)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	if redirect != "" || len(files) == 0 {
+		return nil, ErrUnsupported
+	}
+	for _, i := range files {
+		e.targets = append(e.targets, target{arg: i, path: expandHome(words[i])})
+	}
+	return e, nil
+}
+
+// sedFiles 返回 sed -i 命令中文件参数的位置；macOS 的 BSD sed 中 -i 的下一个参数是备份后缀
+func sedFiles(words []string) ([]int, error) {
+	inPlace, script := false, false
+	var files []int
+	for i := 1; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w == "--":
+			for i++; i < len(words); i++ {
+				if !script {
+					script = true
+					continue
+				}
+				files = append(files, i)
+			}
+		case w == "--in-place" || strings.HasPrefix(w, "--in-place="):
+			inPlace = true
+		case w == "--expression" || w == "--file":
+			script = true
+			i++
+		case strings.HasPrefix(w, "--expression=") || strings.HasPrefix(w, "--file="):
+			script = true
+		case strings.HasPrefix(w, "--"):
+		case strings.HasPrefix(w, "-") && w != "-":
+			for j := 1; j < len(w); j++ {
+				if w[j] == 'i' {
+					inPlace = true
+					if j == len(w)-1 && runtime.GOOS == "darwin" {
+						i++
+					}
+					break
+				}
+				if w[j] == 'e' || w[j] == 'f' {
+					script = true
+					if j == len(w)-1 {
+						i++
+					}
+					break
+				}
+			}
+		case !script:
+			script = true
+		default:
+			files = append(files, i)
+		}
+	}
+	if !inPlace {
+		return nil, ErrUnsupported
+	}
+	return files, nil
+}
+
+// perlFiles 返回 perl -i 命令中文件参数的位置
+func perlFiles(words []string) ([]int, error) {
+	inPlace, script := false, false
+	var files []int
+	for i := 1; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w == "--":
+			for i++; i < len(words); i++ {
+				if !script {
+					script = true
+					continue
+				}
+				files = append(files, i)
+			}
+		case strings.HasPrefix(w, "-") && w != "-":
+		flags:
+			for j := 1; j < len(w); j++ {
+				switch w[j] {
+				case 'i':
+					inPlace = true
+					break flags
+				case 'e', 'E':
+					script = true
+					if j == len(w)-1 {
+						i++
+					}
+					break flags
+				case 'I', 'M', 'm', 'l', '0', 'x', 'd', 'D', 'C':
+					// 其后的字符是该选项的参数
+					break flags
+				}
+			}
+		case !script:
+			script = true
+		default:
+			files = append(files, i)
+		}
+	}
+	if !inPlace {
+		return nil, ErrUnsupported
+	}
+	return files, nil
+}
+
+// awkFiles 返回 gawk -i inplace 命令中文件参数的位置，跳过 var=value 形式的赋值参数
+func awkFiles(words []string) ([]int, error) {
+	inPlace, program := false, false
+	var files []int
+	for i := 1; i < len(words); i++ {
+		w := words[i]
+		switch {
+		case w == "-i" || w == "--include":
+			inPlace = inPlace || i+1 < len(words) && words[i+1] == "inplace"
+			i++
+		case w == "-iinplace" || w == "--include=inplace":
+			inPlace = true
+		case w == "-f" || w == "--file":
+			program = true
+			i++
+		case w == "-v" || w == "-F" || w == "-l" || w == "-E":
+			i++
+		case strings.HasPrefix(w, "-") && w != "-":
+		case !program:
+			program = true
+		case assignment.MatchString(w):
+		default:
+			files = append(files, i)
+		}
+	}
+	if !inPlace {
+		return nil, ErrUnsupported
+	}
+	return files, nil
+}
+
+// patchTargets 找出补丁要修改的文件：命令中给出的原文件，或补丁中 +++ 行的路径按 -p 去掉前缀
+// （未指定 -p 时与 patch 一样只取文件名）。补丁文件改为以 -i 传入绝对路径，在临时目录中应用
+func patchTargets(e *edit, redirect, dir string) error {
+	e.patch = true
+	strip, input := -1, redirect
+	var positional []int
+	for i := 1; i < len(e.args); i++ {
+		w := e.args[i]
+		switch {
+		case w == "-p" || w == "--strip":
+			if i+1 < len(e.args) {
+				strip, _ = strconv.Atoi(e.args[i+1])
+			}
+			i++
+		case strings.HasPrefix(w, "-p"):
+			strip, _ = strconv.Atoi(w[2:])
+		case strings.HasPrefix(w, "--strip="):
+			strip, _ = strconv.Atoi(strings.TrimPrefix(w, "--strip="))
+		case w == "-i" || w == "--input":
+			if i+1 >= len(e.args) {
+				return ErrUnsupported
+			}
+			input = e.args[i+1]
+			e.args = append(e.args[:i], e.args[i+2:]...)
+			i--
+		case strings.HasPrefix(w, "--input="):
+			input = strings.TrimPrefix(w, "--input=")
+			e.args = append(e.args[:i], e.args[i+1:]...)
+			i--
+		case w == "-o" || w == "-d" || w == "-B" || w == "-r" || strings.HasPrefix(w, "--output") ||
+			strings.HasPrefix(w, "--directory") || strings.HasPrefix(w, "--prefix") || strings.HasPrefix(w, "--reject-file"):
+			// 输出到其他位置的补丁不是就地修改
+			return ErrUnsupported
+		case strings.HasPrefix(w, "-") && w != "-":
+		default:
+			positional = append(positional, i)
+		}
+	}
+	if len(positional) == 2 && input == "" {
+		input = e.args[positional[1]]
+		e.args = append(e.args[:positional[1]], e.args[positional[1]+1:]...)
+		positional = positional[:1]
+	}
+	if input == "" || len(positional) > 1 {
+		return ErrUnsupported
+	}
+	if dir == "" {
+		return nil
+	}
+
+	input = expandHome(input)
+	if !filepath.IsAbs(input) {
+		input = filepath.Join(dir, input)
+	}
+	e.args = append(e.args, "-i", input)
+	if len(positional) == 1 {
+		// 明确给出了原文件时补丁中的路径不起作用
+		e.patch = false
+		e.targets = []target{{arg: positional[0], path: expandHome(e.args[positional[0]])}}
+		return nil
+	}
+
+	paths, err := patchPaths(input, strip)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		e.targets = append(e.targets, target{arg: -1, path: p})
+	}
+	return nil
+}
+
+// patchPaths 读取补丁中各文件的路径，绝对路径或包含 .. 的路径不做预览
+func patchPaths(input string, strip int) ([]string, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	var from string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			from = headerPath(line)
+		case strings.HasPrefix(line, "+++ "):
+			p := headerPath(line)
+			if p == "/dev/null" {
+				p = from // 删除文件的补丁
+			}
+			if p = stripPath(p, strip); p == "" {
+				return nil, ErrUnsupported
+			}
+			paths = append(paths, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("补丁中没有找到要修改的文件")
+	}
+	return paths, nil
+}
+
+// headerPath 取出 ---/+++ 行中的路径，去掉其后的时间戳
+func headerPath(line string) string {
+	p, _, _ := strings.Cut(line[4:], "\t")
+	return strings.TrimSpace(p)
+}
+
+func stripPath(p string, strip int) string {
+	if strip < 0 {
+		p = filepath.Base(p)
+	} else {
+		parts := strings.Split(p, "/")
+		if strip >= len(parts) {
+			return ""
+		}
+		p = strings.Join(parts[strip:], "/")
+	}
+	p = filepath.Clean(p)
+	if filepath.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+		return ""
+	}
+	return p
+}
+
+func expandHome(p string) string {
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return p
+}
+
+// expand 像 shell 一样在 dir 中展开含通配符的词，没有匹配时保留原样
+func expand(words []string, globs []bool, dir string) []string {
+	var out []string
+	for i, w := range words {
+		matches, _ := filepath.Glob(filepath.Join(dir, expandHome(w)))
+		if !globs[i] || len(matches) == 0 {
+			out = append(out, w)
+			continue
+		}
+		for _, m := range matches {
+			if rel, err := filepath.Rel(dir, m); err == nil && !filepath.IsAbs(w) {
+				m = rel
+			}
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// split 按 POSIX shell 的引号规则拆分单条简单命令，返回各个词、其中哪些含有未加引号的通配符，
+// 以及 < 重定向的文件。管道、命令连接、子 shell、变量展开与其他重定向都无法安全地在副本上重现，ok 为 false
+func split(command string) (words []string, globs []bool, redirect string, ok bool) {
+	var b strings.Builder
+	inWord, redirecting, glob := false, false, false
+	end := func() {
+		if !inWord {
+			return
+		}
+		if redirecting {
+			redirect, redirecting = b.String(), false
+		} else {
+			words = append(words, b.String())
+			globs = append(globs, glob)
+		}
+		b.Reset()
+		inWord, glob = false, false
+	}
+
+	s := strings.TrimSpace(command)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			end()
+		case c == '\'':
+			j := strings.IndexByte(s[i+1:], '\'')
+			if j < 0 {
+				return nil, nil, "", false
+			}
+			b.WriteString(s[i+1 : i+1+j])
+			i += j + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				switch {
+				case s[i] == '$' || s[i] == '`':
+					return nil, nil, "", false
+				case s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0:
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, nil, "", false
+			}
+			inWord = true
+		case c == '\\':
+			if i+1 >= len(s) {
+				return nil, nil, "", false
+			}
+			i++
+			b.WriteByte(s[i])
+			inWord = true
+		case c == '<':
+			if inWord || redirecting || redirect != "" {
+				return nil, nil, "", false
+			}
+			redirecting = true
+		case strings.IndexByte("*?[", c) >= 0:
+			b.WriteByte(c)
+			inWord, glob = true, true
+		case strings.IndexByte("|&;()`$>\n", c) >= 0:
+			return nil, nil, "", false
+		default:
+			b.WriteByte(c)
+			inWord = true
+		}
+	}
+	if redirecting && !inWord {
+		return nil, nil, "", false
+	}
+	end()
+	return words, globs, redirect, true
+}
@@ -0,0 +1,78 @@
+// Package experiment 提示词 A/B 实验：为每次运行分配变体，并按历史记录统计各变体的效果
+package experiment
+
+import (
+	"math/rand/v2"
+	"sort"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/history"
+)
+
+// Control 未分配到任何变体的对照组名称
+const Control = "control"
+
+// Assign 按配置的百分比随机分配变体，返回 nil 表示属于对照组
+func Assign(exps []config.ExperimentConfig) *config.ExperimentConfig {
+	return pick(exps, rand.IntN(100))
+}
+
+// pick 按累计百分比选出 roll（0-99）落入的变体
+func pick(exps []config.ExperimentConfig, roll int) *config.ExperimentConfig {
+	for i := range exps {
+		if roll < exps[i].Percent {
+			return &exps[i]
+		}
+		roll -= exps[i].Percent
+	}
+	return nil
+}
+
+// Result 一个变体的统计结果
+type Result struct {
+	Variant  string
+	Total    int // 产生了命令的运行次数
+	Accepted int // 用户复制、保存、发送或执行成功的次数
+	Failed   int // 执行失败的次数
+}
+
+// Rate 返回采纳率
+func (r Result) Rate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Accepted) / float64(r.Total)
+}
+
+// Report 按变体汇总历史记录，只统计参与实验期间的记录（variant 非空），对照组排在最前
+func Report(entries []history.Entry) []Result {
+	byVariant := map[string]*Result{}
+	for _, e := range entries {
+		if e.Variant == "" {
+			continue
+		}
+		r := byVariant[e.Variant]
+		if r == nil {
+			r = &Result{Variant: e.Variant}
+			byVariant[e.Variant] = r
+		}
+		r.Total++
+		if e.Accepted() {
+			r.Accepted++
+		} else if e.Action == history.ActionExecuted {
+			r.Failed++
+		}
+	}
+
+	results := make([]Result, 0, len(byVariant))
+	for _, r := range byVariant {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if (results[i].Variant == Control) != (results[j].Variant == Control) {
+			return results[i].Variant == Control
+		}
+		return results[i].Variant < results[j].Variant
+	})
+	return results
+}
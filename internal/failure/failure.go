@@ -0,0 +1,133 @@
+package failure
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Class 命令失败的类别
+type Class int
+
+const (
+	Unknown    Class = iota
+	NotFound         // 找不到命令
+	Permission       // 权限不足
+	Syntax           // shell 语法错误
+	Network          // 网络错误：无法解析主机、连接被拒绝或超时
+)
+
+// String 返回类别的中文说明
+func (c Class) String() string {
+	switch c {
+	case NotFound:
+		return "找不到命令"
+	case Permission:
+		return "权限不足"
+	case Syntax:
+		return "语法错误"
+	case Network:
+		return "网络错误"
+	default:
+		return "未知原因"
+	}
+}
+
+// patterns 根据错误输出判断类别，按顺序匹配
+var patterns = []struct {
+	class Class
+	re    *regexp.Regexp
+}{
+	{NotFound, regexp.MustCompile(`(?i)command not found|not found in \$?PATH|no such file or directory.*(?:exec|bin)|is not recognized as an internal or external command|未找到命令`)},
+	{Syntax, regexp.MustCompile(`(?i)syntax error|unexpected (?:EOF|token|end of file)|unterminated (?:quoted|string)|bad substitution|unmatched|invalid option|unrecognized option|illegal option|usage:`)},
+	{Permission, regexp.MustCompile(`(?i)permission denied|operation not permitted|access is denied|not permitted|must be (?:run as )?root|are you root|EACCES|EPERM|权限不够|拒绝访问`)},
+	{Network, regexp.MustCompile(`(?i)could not resolve|name or service not known|temporary failure in name resolution|connection (?:refused|timed out|reset)|network is unreachable|no route to host|timed out|ssl|tls handshake|certificate|unable to access 'http|failed to connect|EAI_AGAIN|ECONNREFUSED|ETIMEDOUT`)},
+}
+
+// networkExitCodes 常见网络工具的网络类退出码
+var networkExitCodes = map[string]map[int]bool{
+	"curl": {5: true, 6: true, 7: true, 28: true, 35: true, 52: true, 56: true, 60: true},
+	"wget": {4: true, 5: true},
+	"ssh":  {255: true},
+	"scp":  {255: true},
+}
+
+// Classify 根据退出码和错误输出在本地判断失败类别，不调用 LLM
+func Classify(command string, exitCode int, stderr string) Class {
+	switch exitCode {
+	case 127:
+		return NotFound
+	case 126:
+		return Permission
+	}
+	for _, p := range patterns {
+		if p.re.MatchString(stderr) {
+			return p.class
+		}
+	}
+	for _, name := range programs(command) {
+		if networkExitCodes[name][exitCode] {
+			return Network
+		}
+	}
+	return Unknown
+}
+
+// separators 命令中的管道与连接符
+var separators = regexp.MustCompile(`\|\||&&|[|;&]`)
+
+// programs 返回命令各段（按管道与连接符拆分）的程序名，忽略 sudo、env 等前缀
+func programs(command string) []string {
+	var names []string
+	for _, seg := range separators.Split(command, -1) {
+		fields := strings.Fields(seg)
+		for len(fields) > 0 && (fields[0] == "sudo" || fields[0] == "env" || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
+
+// guidance 各类别的修复要求
+var guidance = map[Class]string{
+	NotFound: "失败原因是找不到命令。请判断是未安装还是名称拼写错误：未安装时给出适合本机平台的安装命令并用 && 连接原命令；拼写错误或本机有同类替代工具时，直接给出更正后的命令。",
+	Permission: "失败原因是权限不足。请先判断是否真的需要提权：能改为操作用户可写的路径、给脚本加执行权限或调整文件归属时优先这样做；" +
+		"确实需要 root 时再使用 sudo，不要使用 chmod 777 之类放宽权限的做法。",
+	Syntax: "失败原因是 shell 语法或参数错误。请对照错误输出修正引号、括号、转义、管道或当前平台不支持的参数，保持原命令的意图不变。",
+	Network: "失败原因是网络问题（域名解析、连接被拒绝、超时或证书）。请给出改进后的命令：必要时加上超时与重试参数、使用代理或镜像源；" +
+		"如果无法判断原因，给出定位网络问题的诊断命令（例如检查 DNS 解析与端口连通性）。",
+	Unknown: "请根据退出码和错误输出判断失败原因，给出修复后的命令。",
+}
+
+// Attempt 一次失败的执行
+type Attempt struct {
+	Command  string
+	ExitCode int
+	Class    Class
+
+	// SideEffects 命令中的非幂等操作，失败前可能已经部分生效
+	SideEffects []string
+}
+
+// Reprompt 生成针对最近一次失败（attempts 的最后一项）的修复提示词，更早的失败尝试一并列出，
+// 避免模型在修复循环中重复同样的做法；错误输出不在其中，应作为终端输出另行附加以便脱敏
+func Reprompt(query string, attempts []Attempt) string {
+	last := attempts[len(attempts)-1]
+	var b strings.Builder
+	fmt.Fprintf(&b, "原始需求: %s\n执行的命令: %s\n命令以退出码 %d 失败（本地判断: %s）。\n%s",
+		query, last.Command, last.ExitCode, last.Class, guidance[last.Class])
+	if len(last.SideEffects) > 0 {
+		fmt.Fprintf(&b, "\n该命令包含非幂等操作（%s），失败前可能已经部分生效。修复后的命令不要原样重复这些操作：先检查已经产生的结果，或改为可以安全重复执行的写法。",
+			strings.Join(last.SideEffects, "；"))
+	}
+	if earlier := attempts[:len(attempts)-1]; len(earlier) > 0 {
+		b.WriteString("\n此前已经尝试过以下命令并同样失败，不要重复这些做法:")
+		for _, a := range earlier {
+			fmt.Fprintf(&b, "\n- %s（退出码 %d，%s）", a.Command, a.ExitCode, a.Class)
+		}
+	}
+	return b.String()
+}
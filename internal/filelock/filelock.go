@@ -0,0 +1,104 @@
+// Package filelock 协调同时运行的多个 termi 进程（例如多个 tmux 窗格）对共享文件的写入：
+// 用 <文件>.lock 上的建议锁串行化"读取-合并-写回"，避免后写的进程覆盖先写的修改；
+// 写入先落到同目录的临时文件再重命名，其他进程不会读到写了一半的文件
+package filelock
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Timeout 等待其他进程释放锁的最长时间。锁只在一次读写期间持有，超时通常意味着
+// 持锁的进程卡住了，放弃这次写入比让用户等待更好
+const Timeout = 5 * time.Second
+
+// retryInterval 锁被占用时重试的间隔
+const retryInterval = 10 * time.Millisecond
+
+// ErrTimeout 在 Timeout 内没有等到锁
+var ErrTimeout = errors.New("等待其他 termi 进程释放文件锁超时")
+
+// Lock 获取 path 的排他锁，返回释放锁的函数。锁加在旁边的 path.lock 上，
+// 进程退出时由系统自动释放，不会因为崩溃而残留
+func Lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(Timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			return func() {
+				_ = unlock(f)
+				f.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrTimeout
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// WriteFile 原子地写入文件：先写同目录下的临时文件再重命名，并创建缺少的父目录
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Update 在锁内读取 path 的当前内容（文件不存在时为 nil），由 fn 把本进程的修改合并进去，
+// 再原子写回。fn 返回错误时不写入
+func Update(path string, perm os.FileMode, fn func(data []byte) ([]byte, error)) error {
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	data, err = fn(data)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, perm)
+}
@@ -0,0 +1,108 @@
+// Package fix 识别用户直接粘贴的 shell 命令，并生成"诊断并修复"模式的提示词
+package fix
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// builtins 不在 PATH 中但常被直接粘贴的 shell 内建命令
+var builtins = map[string]bool{
+	"cd": true, "export": true, "source": true, "alias": true, "echo": true,
+	"for": true, "while": true, "if": true, "test": true, "set": true, "unset": true,
+}
+
+// LooksLikeCommand 判断查询本身是否像一条 shell 命令而不是自然语言描述：
+// 首个词是可执行程序或内建命令，不含中日韩文字，且带有参数、路径或 shell 操作符
+func LooksLikeCommand(query string) bool {
+	query = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(query), "$ "))
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, r := range query {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return false
+		}
+	}
+
+	name := fields[0]
+	if name == "sudo" && len(fields) > 1 {
+		name = fields[1]
+	}
+	if !builtins[name] {
+		if _, err := exec.LookPath(name); err != nil {
+			return false
+		}
+	}
+
+	for _, f := range fields[1:] {
+		if strings.HasPrefix(f, "-") || strings.ContainsAny(f, "/.|&;<>=$*'\"") {
+			return true
+		}
+	}
+	return false
+}
+
+// Prompt 返回"诊断并修复"模式下发送给模型的查询
+func Prompt(command string) string {
+	return fmt.Sprintf(`用户粘贴了一条可能无法正常工作的命令，请诊断其中的问题（语法错误、参数错误、引号或转义问题、平台差异等）并给出修正后的命令:
+%s
+
+approach 字段用简短中文说明修改了什么；如果命令本身没有问题，原样返回并在 approach 中说明"命令无需修改"。`, strings.TrimSpace(command))
+}
+
+// OpKind 差异片段的类型
+type OpKind int
+
+const (
+	OpEqual OpKind = iota
+	OpDelete
+	OpInsert
+)
+
+// Op 差异中的一个片段
+type Op struct {
+	Kind OpKind
+	Text string
+}
+
+// Diff 按空白分隔的词比较原命令与修正后的命令，返回依次排列的差异片段
+func Diff(from, to string) []Op {
+	a, b := strings.Fields(from), strings.Fields(to)
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []Op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, Op{OpEqual, a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, Op{OpDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, Op{OpInsert, b[j]})
+			j++
+		}
+	}
+	return ops
+}
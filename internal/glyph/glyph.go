@@ -0,0 +1,98 @@
+// Package glyph 在终端不支持 Unicode 时（C、POSIX 等非 UTF-8 语言环境，精简的服务器镜像常见）
+// 把界面中的 emoji、箭头、框线与进度符号替换为 ASCII，避免输出乱码
+package glyph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/locale"
+)
+
+// asciiOnly 为 true 时输出前替换非 ASCII 符号，默认按语言环境判断
+var asciiOnly = !locale.UTF8()
+
+// Configure 按配置的 theme.glyphs 决定是否替换，auto 或未设置时按语言环境判断
+func Configure(mode config.GlyphMode) {
+	switch mode {
+	case config.GlyphUnicode:
+		asciiOnly = false
+	case config.GlyphASCII:
+		asciiOnly = true
+	default:
+		asciiOnly = !locale.UTF8()
+	}
+}
+
+// ASCII 报告是否只使用 ASCII 符号
+func ASCII() bool {
+	return asciiOnly
+}
+
+// replacements 有对应含义的符号；其余 emoji 与图形符号连同其后的空格一起去掉，
+// 它们只起装饰作用，后面的文字已经说明了含义
+var replacements = map[rune]string{
+	'↑': "Up", '↓': "Down", '←': "<-", '→': "->", '↩': "\\",
+	'➜': ">", '▶': ">", '▸': ">", '▼': "v", '▲': "^",
+	'●': "*", '○': "o", '•': "*", '·': "-", '…': "...", '⋮': ":",
+	'✓': "+", '✗': "x", '✅': "OK", '❌': "X", '⛔': "X", '⚠': "!", 'ⓘ': "i",
+	'─': "-", '━': "-", '═': "=", '│': "|", '┃': "|", '║': "|",
+}
+
+// Text 在只使用 ASCII 时替换 s 中的符号，否则原样返回
+func Text(s string) string {
+	if !asciiOnly {
+		return s
+	}
+	var b strings.Builder
+	dropSpace := false
+	for _, r := range s {
+		if r == '\uFE0F' { // emoji 变体选择符
+			continue
+		}
+		if dropSpace && r == ' ' {
+			continue
+		}
+		dropSpace = false
+		switch a, ok := replacements[r]; {
+		case ok:
+			b.WriteString(a)
+		case r >= 0x2500 && r <= 0x257F: // 框线的拐角与交叉
+			b.WriteByte('+')
+		case r >= 0x2580 && r <= 0x259F: // 方块，用于进度条
+			b.WriteByte('#')
+		case r >= 0x2800 && r <= 0x28FF: // 盲文点阵，用于动画
+			b.WriteByte('.')
+		case pictograph(r):
+			dropSpace = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pictograph 报告 r 是否为 emoji 或杂项图形符号
+func pictograph(r rune) bool {
+	return r >= 0x1F000 && r <= 0x1FFFF ||
+		r >= 0x2300 && r <= 0x23FF ||
+		r >= 0x2600 && r <= 0x27BF ||
+		r >= 0x2B00 && r <= 0x2BFF
+}
+
+// Printf 格式化后替换符号并写到标准输出
+func Printf(format string, a ...any) {
+	fmt.Print(Text(fmt.Sprintf(format, a...)))
+}
+
+// Println 替换符号后写到标准输出并换行
+func Println(a ...any) {
+	fmt.Print(Text(fmt.Sprintln(a...)))
+}
+
+// Fprintf 格式化后替换符号并写到 w
+func Fprintf(w io.Writer, format string, a ...any) {
+	fmt.Fprint(w, Text(fmt.Sprintf(format, a...)))
+}
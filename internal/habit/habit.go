@@ -0,0 +1,218 @@
+// Package habit 从用户对生成命令的手动修改中发现重复出现的习惯（例如 kubectl 命令总是补上
+// --namespace prod），并把用户认可的习惯保存为提示词偏好或自动添加的改写规则。
+// 只统计修改后执行成功的记录，失败的尝试不算习惯
+package habit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+	"termi.sh/termi/internal/history"
+)
+
+// MinEdits 同一修改至少在这么多次成功执行的命令中出现才被视为习惯
+const MinEdits = 3
+
+// Mode 用户对习惯的处理方式
+type Mode string
+
+const (
+	ModePrompt  Mode = "prompt"  // 作为偏好附加到提示词，由模型决定何时适用
+	ModeRewrite Mode = "rewrite" // 直接添加到生成的命令中
+	ModeIgnore  Mode = "ignore"  // 不再提示
+)
+
+// Pattern 在历史中发现的重复修改
+type Pattern struct {
+	Program string // 命令名，例如 kubectl
+	Args    string // 用户补上的参数，例如 --namespace prod
+	Count   int    // 出现的次数
+}
+
+// Rule 用户对某个习惯做出的决定
+type Rule struct {
+	Program   string    `json:"program"`
+	Args      string    `json:"args"`
+	Mode      Mode      `json:"mode"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Preference 以自然语言描述习惯，附加到提示词中
+func (r Rule) Preference() string {
+	return fmt.Sprintf("生成 %s 命令时加上 %s", r.Program, r.Args)
+}
+
+// Detect 统计历史中修改后执行成功的记录，返回出现至少 MinEdits 次的修改，按次数从多到少排列
+func Detect(entries []history.Entry) []Pattern {
+	counts := map[Pattern]int{}
+	for _, e := range entries {
+		if e.Action != history.ActionEdited || e.ExitCode == nil || *e.ExitCode != 0 {
+			continue
+		}
+		program := Program(e.Command)
+		if program == "" || program != Program(e.Edited) {
+			continue
+		}
+		// 同一条记录中重复的修改只计一次
+		seen := map[string]bool{}
+		for _, args := range Added(e.Command, e.Edited) {
+			if !seen[args] {
+				seen[args] = true
+				counts[Pattern{Program: program, Args: args}]++
+			}
+		}
+	}
+
+	var patterns []Pattern
+	for p, n := range counts {
+		if n >= MinEdits {
+			p.Count = n
+			patterns = append(patterns, p)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
+		}
+		return patterns[i].Program+patterns[i].Args < patterns[j].Program+patterns[j].Args
+	})
+	return patterns
+}
+
+// Program 返回命令的程序名，跳过 sudo 与前置的环境变量赋值
+func Program(command string) string {
+	for _, field := range strings.Fields(command) {
+		if field == "sudo" || strings.Contains(field, "=") && !strings.HasPrefix(field, "-") {
+			continue
+		}
+		return filepath.Base(field)
+	}
+	return ""
+}
+
+// Added 返回 edited 相对 generated 新增的选项，选项后紧跟的新增取值与选项合为一项，
+// 例如 "--namespace prod"；新增的普通参数（文件名等）因需求而异，不视为习惯
+func Added(generated, edited string) []string {
+	remaining := map[string]int{}
+	for _, field := range strings.Fields(generated) {
+		remaining[field]++
+	}
+	fields := strings.Fields(edited)
+	added := make([]bool, len(fields))
+	for i, field := range fields {
+		if remaining[field] > 0 {
+			remaining[field]--
+			continue
+		}
+		added[i] = true
+	}
+
+	var out []string
+	for i := 0; i < len(fields); i++ {
+		if !added[i] || !strings.HasPrefix(fields[i], "-") || fields[i] == "-" || fields[i] == "--" {
+			continue
+		}
+		args := fields[i]
+		if !strings.Contains(args, "=") && i+1 < len(fields) && added[i+1] && !strings.HasPrefix(fields[i+1], "-") {
+			args += " " + fields[i+1]
+			i++
+		}
+		out = append(out, args)
+	}
+	return out
+}
+
+// Apply 把改写规则中的参数追加到程序名匹配、且尚未包含这些参数的命令末尾。
+// 管道、命令串联、重定向和命令替换无法确定参数该放在哪里，保持原样
+func Apply(command string, rules []Rule) (string, []string) {
+	if strings.ContainsAny(command, "|;&<>`\n") || strings.Contains(command, "$(") {
+		return command, nil
+	}
+	program := Program(command)
+	var notes []string
+	for _, r := range rules {
+		if r.Mode != ModeRewrite || r.Program != program {
+			continue
+		}
+		if strings.Contains(" "+command+" ", " "+r.Args+" ") {
+			continue
+		}
+		command += " " + r.Args
+		notes = append(notes, fmt.Sprintf("已按习惯规则添加 %s", r.Args))
+	}
+	return command, notes
+}
+
+// Store 习惯决定的磁盘存储，文件不存在或损坏时视为没有任何决定
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	rules []Rule
+}
+
+// Open 打开习惯规则文件
+func Open(path string) *Store {
+	s := &Store{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &s.rules)
+	}
+	return s
+}
+
+// Rules 返回全部决定，nil Store 返回 nil
+func (s *Store) Rules() []Rule {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Rule(nil), s.rules...)
+}
+
+// Pending 返回历史中尚未做出决定的第一个习惯，没有时返回 nil
+func (s *Store) Pending(entries []history.Entry) *Pattern {
+	if s == nil {
+		return nil
+	}
+	decided := map[[2]string]bool{}
+	for _, r := range s.Rules() {
+		decided[[2]string{r.Program, r.Args}] = true
+	}
+	for _, p := range Detect(entries) {
+		if !decided[[2]string{p.Program, p.Args}] {
+			return &p
+		}
+	}
+	return nil
+}
+
+// Decide 记录对习惯的决定并立即写回磁盘，同一习惯的旧决定被替换。
+// 在文件锁内基于磁盘上最新的决定修改，其他 termi 进程同时做出的决定不会被覆盖
+func (s *Store) Decide(p Pattern, mode Mode) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filelock.Update(s.path, 0600, func(data []byte) ([]byte, error) {
+		var disk []Rule
+		if json.Unmarshal(data, &disk) == nil {
+			s.rules = disk
+		}
+		rules := make([]Rule, 0, len(s.rules)+1)
+		for _, r := range s.rules {
+			if r.Program != p.Program || r.Args != p.Args {
+				rules = append(rules, r)
+			}
+		}
+		s.rules = append(rules, Rule{Program: p.Program, Args: p.Args, Mode: mode, DecidedAt: time.Now()})
+		return json.MarshalIndent(s.rules, "", "  ")
+	})
+}
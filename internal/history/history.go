@@ -0,0 +1,151 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// Action 用户对生成命令采取的操作
+type Action string
+
+const (
+	ActionExecuted Action = "executed"
+	ActionCopied   Action = "copied"
+	ActionSaved    Action = "saved"
+	ActionSent     Action = "sent"
+	ActionInserted Action = "inserted" // 交还给 shell 的命令行，由用户编辑后执行
+	ActionEdited   Action = "edited"   // 生成的命令被用户在命令行上或 termi 中修改后执行，修改后的命令见 Edited
+)
+
+// Entry 一条历史记录
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Query      string    `json:"query"`
+	Command    string    `json:"command"`
+	Action     Action    `json:"action"`
+	ExitCode   *int      `json:"exit_code,omitempty"`
+	Transcript string    `json:"transcript,omitempty"` // 执行过程录制文件
+	Variant    string    `json:"variant,omitempty"`    // 提示词实验中分配到的变体
+	Edited     string    `json:"edited,omitempty"`     // 用户修改后实际执行的命令
+}
+
+// Accepted 返回该记录是否代表用户认可的命令：复制或执行成功
+func (e *Entry) Accepted() bool {
+	switch e.Action {
+	case ActionCopied, ActionSaved, ActionSent, ActionInserted:
+		return true
+	case ActionExecuted:
+		return e.ExitCode != nil && *e.ExitCode == 0
+	default:
+		return false
+	}
+}
+
+// Final 返回用户最终使用的命令：修改过的以修改后的为准
+func (e *Entry) Final() string {
+	if e.Edited != "" {
+		return e.Edited
+	}
+	return e.Command
+}
+
+// Store 以 JSONL 文件保存的历史记录
+type Store struct {
+	path string
+}
+
+// Open 打开历史记录文件，文件在首次写入时创建
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// Append 追加一条历史记录。持有文件锁，不会与其他 termi 进程的 Delete 交错而丢失
+func (s *Store) Append(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("序列化历史记录失败: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return fmt.Errorf("创建历史目录失败: %w", err)
+	}
+	unlock, err := filelock.Lock(s.path)
+	if err != nil {
+		return fmt.Errorf("写入历史记录失败: %w", err)
+	}
+	defer unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("打开历史文件失败: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入历史记录失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取全部历史记录，按时间从旧到新排列，忽略损坏的行
+func (s *Store) Load() ([]Entry, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开历史文件失败: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Delete 删除与 e 时间和命令都相同的记录；文件在锁内整体重写（先写临时文件再替换，
+// 中途失败不会损坏原有历史），损坏的行会一并丢弃
+func (s *Store) Delete(e Entry) error {
+	err := filelock.Update(s.path, 0600, func([]byte) ([]byte, error) {
+		entries, err := s.Load()
+		if err != nil {
+			return nil, err
+		}
+		var b []byte
+		for _, old := range entries {
+			if old.Time.Equal(e.Time) && old.Command == e.Command {
+				continue
+			}
+			data, err := json.Marshal(old)
+			if err != nil {
+				return nil, fmt.Errorf("序列化历史记录失败: %w", err)
+			}
+			b = append(append(b, data...), '\n')
+		}
+		return b, nil
+	})
+	if err != nil {
+		return fmt.Errorf("写入历史文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package history
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// minSimilarity 被视为相似查询的最低余弦相似度
+const minSimilarity = 0.2
+
+// vector 查询的稀疏特征向量
+type vector map[string]float64
+
+// Similar 返回与 query 最相似的至多 n 条已接受或修改后执行成功的记录，
+// 最终命令相同的只保留最相似的一条
+func Similar(entries []Entry, query string, n int) []Entry {
+	return Matches(entries, query, n, minSimilarity)
+}
+
+// Matches 与 Similar 相同，但只返回相似度不低于 minScore 的记录
+func Matches(entries []Entry, query string, n int, minScore float64) []Entry {
+	if n <= 0 {
+		return nil
+	}
+	qv := embed(query)
+
+	// 交还给命令行的命令之后实际怎样执行了，以 shell 报告的结果为准
+	ran := map[[2]string]int{}
+	for i, e := range entries {
+		if e.Action == ActionExecuted || e.Action == ActionEdited {
+			ran[[2]string{e.Query, e.Command}] = i
+		}
+	}
+
+	type scored struct {
+		entry Entry
+		score float64
+	}
+	best := map[string]scored{}
+	for i, e := range entries {
+		if e.Query == "" {
+			continue
+		}
+		if e.Action == ActionInserted {
+			if j, ok := ran[[2]string{e.Query, e.Command}]; ok && j > i {
+				continue
+			}
+		}
+		// 修改后执行成功的命令同样是用户认可的写法
+		edited := e.Action == ActionEdited && e.ExitCode != nil && *e.ExitCode == 0
+		if !e.Accepted() && !edited {
+			continue
+		}
+		score := cosine(qv, embed(e.Query))
+		if score < minScore {
+			continue
+		}
+		if prev, ok := best[e.Final()]; !ok || score > prev.score {
+			best[e.Final()] = scored{e, score}
+		}
+	}
+
+	list := make([]scored, 0, len(best))
+	for _, s := range best {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].score > list[j].score })
+
+	var out []Entry
+	for i := 0; i < len(list) && i < n; i++ {
+		out = append(out, list[i].entry)
+	}
+	return out
+}
+
+// Similarity 返回两段查询文本的相似度，范围 0-1
+func Similarity(a, b string) float64 {
+	return cosine(embed(a), embed(b))
+}
+
+// embed 将查询转换为特征向量：英文按单词、中文等按字符二元组
+func embed(text string) vector {
+	v := vector{}
+	var word strings.Builder
+	var prev rune
+	flush := func() {
+		if word.Len() > 0 {
+			v["w:"+word.String()]++
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(r)
+			prev = 0
+		case unicode.IsLetter(r):
+			flush()
+			v["c:"+string(r)] += 0.5
+			if prev != 0 {
+				v["b:"+string(prev)+string(r)]++
+			}
+			prev = r
+		default:
+			flush()
+			prev = 0
+		}
+	}
+	flush()
+	return v
+}
+
+func cosine(a, b vector) float64 {
+	var dot, na, nb float64
+	for k, x := range a {
+		dot += x * b[k]
+		na += x * x
+	}
+	for _, y := range b {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
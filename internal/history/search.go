@@ -0,0 +1,44 @@
+package history
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Search 按模糊匹配筛选记录并按时间从新到旧返回。pattern 按空白拆分为多个词，
+// 每个词的字符都须依次出现在查询、命令或修改后的命令中（不要求相邻，忽略大小写），空 pattern 返回全部记录
+func Search(entries []Entry, pattern string) []Entry {
+	terms := strings.Fields(strings.ToLower(pattern))
+	var out []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		text := strings.ToLower(e.Query + " " + e.Command + " " + e.Edited)
+		if matchAll(text, terms) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func matchAll(text string, terms []string) bool {
+	for _, term := range terms {
+		if !subsequence(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// subsequence 判断 term 的字符是否按顺序出现在 text 中
+func subsequence(text, term string) bool {
+	rest := []rune(term)
+	for _, r := range text {
+		if len(rest) == 0 {
+			break
+		}
+		if unicode.ToLower(r) == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
@@ -0,0 +1,116 @@
+package hosts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// factTTL 远程主机事实的有效期，过期后重新探测
+const factTTL = 7 * 24 * time.Hour
+
+// Fact 从远程执行中学到的一条主机信息
+type Fact struct {
+	Value   string    `json:"value"`
+	Updated time.Time `json:"updated"`
+}
+
+// Profile 单台 SSH 主机的知识档案
+type Profile struct {
+	Host  string          `json:"host"`
+	Facts map[string]Fact `json:"facts"`
+}
+
+// Store 按主机名保存档案的目录
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewStore 创建主机档案存储
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Load 读取主机档案，不存在时返回空档案
+func (s *Store) Load(host string) (*Profile, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := &Profile{Host: host, Facts: map[string]Fact{}}
+	data, err := os.ReadFile(s.path(host))
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取主机档案失败: %w", err)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("解析主机档案失败: %w", err)
+	}
+	if p.Facts == nil {
+		p.Facts = map[string]Fact{}
+	}
+	return p, nil
+}
+
+// Learn 记录一条主机信息并立即写回磁盘。在文件锁内读取最新的档案再修改，
+// 同时操作同一主机的其他 termi 进程学到的信息不会被覆盖
+func (s *Store) Learn(host, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return filelock.Update(s.path(host), 0600, func(data []byte) ([]byte, error) {
+		p := &Profile{Host: host}
+		if data != nil {
+			if err := json.Unmarshal(data, p); err != nil {
+				return nil, fmt.Errorf("解析主机档案失败: %w", err)
+			}
+		}
+		if p.Facts == nil {
+			p.Facts = map[string]Fact{}
+		}
+		p.Facts[key] = Fact{Value: strings.TrimSpace(value), Updated: time.Now()}
+		return json.MarshalIndent(p, "", "  ")
+	})
+}
+
+// Lookup 返回未过期的主机信息
+func (p *Profile) Lookup(key string) (string, bool) {
+	f, ok := p.Facts[key]
+	if !ok || time.Since(f.Updated) > factTTL {
+		return "", false
+	}
+	return f.Value, true
+}
+
+// Render 将未过期的主机信息格式化为提示词片段
+func (p *Profile) Render() string {
+	keys := make([]string, 0, len(p.Facts))
+	for k := range p.Facts {
+		if _, ok := p.Lookup(k); ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "- %s: %s\n", k, p.Facts[k].Value)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// path 返回主机档案文件路径，主机名中的路径分隔符会被替换
+func (s *Store) path(host string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(host)
+	return filepath.Join(s.dir, name+".json")
+}
@@ -0,0 +1,312 @@
+// Package library 管理团队共享的技能包仓库订阅。订阅的 git 仓库同步到本地后，
+// 其中的技能包要逐个审阅确认才会安装到技能包目录；仓库中的技能包更新后需要重新审阅，
+// 审阅前继续使用上次确认的版本，避免共享仓库被篡改后直接注入提示词
+package library
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+	"termi.sh/termi/internal/skills"
+)
+
+// Source 订阅的共享仓库
+type Source struct {
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	SyncedAt time.Time `json:"synced_at"`
+}
+
+// Status 仓库中技能包的审阅状态
+type Status int
+
+const (
+	// Pending 尚未审阅
+	Pending Status = iota
+	// Changed 确认后仓库中的内容又有更新，审阅前继续使用确认过的版本
+	Changed
+	// Approved 已确认并安装当前版本
+	Approved
+)
+
+func (s Status) String() string {
+	switch s {
+	case Changed:
+		return "有更新"
+	case Approved:
+		return "已启用"
+	default:
+		return "待审阅"
+	}
+}
+
+// Item 共享仓库中的一个技能包
+type Item struct {
+	Source string
+	Path   string // 仓库内的相对路径
+	Skill  skills.Skill
+	Data   []byte
+	Status Status
+}
+
+// Key 返回技能包在审阅记录中的键
+func (it Item) Key() string {
+	return it.Source + ":" + it.Path
+}
+
+// state 订阅列表与已确认的技能包内容摘要
+type state struct {
+	Sources  []Source          `json:"sources"`
+	Approved map[string]string `json:"approved,omitempty"`
+}
+
+// Library 共享仓库订阅，path 为订阅记录文件，dir 为仓库的本地克隆目录
+type Library struct {
+	path string
+	dir  string
+}
+
+// Open 打开共享仓库订阅
+func Open(path, dir string) *Library {
+	return &Library{path: path, dir: dir}
+}
+
+// Sources 返回订阅的仓库，按名称排序
+func (l *Library) Sources() ([]Source, error) {
+	st, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(st.Sources, func(i, j int) bool { return st.Sources[i].Name < st.Sources[j].Name })
+	return st.Sources, nil
+}
+
+// Add 订阅 git 仓库，name 为空时取 URL 的最后一段
+func (l *Library) Add(url, name string) (*Source, error) {
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(strings.TrimRight(url, "/")), ".git")
+		// scp 风格的地址（git@host:repo）没有斜杠时取冒号后的部分
+		if _, after, ok := strings.Cut(name, ":"); ok {
+			name = after
+		}
+	}
+	if name == "" || strings.ContainsAny(name, `/\: `) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("无法使用 %q 作为仓库名称，请另行指定", name)
+	}
+
+	src := Source{Name: name, URL: url}
+	err := l.update(func(st *state) error {
+		for _, s := range st.Sources {
+			if s.Name == name {
+				return fmt.Errorf("已订阅名为 %s 的仓库: %s", name, s.URL)
+			}
+		}
+		st.Sources = append(st.Sources, src)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &src, nil
+}
+
+// Remove 取消订阅并删除本地克隆，已安装的技能包保留在技能包目录中
+func (l *Library) Remove(name string) error {
+	err := l.update(func(st *state) error {
+		i := l.index(st, name)
+		if i < 0 {
+			return fmt.Errorf("未订阅仓库 %s", name)
+		}
+		st.Sources = append(st.Sources[:i], st.Sources[i+1:]...)
+		for key := range st.Approved {
+			if strings.HasPrefix(key, name+":") {
+				delete(st.Approved, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(l.dir, name))
+}
+
+// Sync 拉取仓库的最新内容，首次同步时浅克隆
+func (l *Library) Sync(ctx context.Context, name string) error {
+	st, err := l.load()
+	if err != nil {
+		return err
+	}
+	i := l.index(st, name)
+	if i < 0 {
+		return fmt.Errorf("未订阅仓库 %s", name)
+	}
+
+	clone := filepath.Join(l.dir, name)
+	if _, err := os.Stat(filepath.Join(clone, ".git")); err == nil {
+		if err := git(ctx, "-C", clone, "fetch", "--depth", "1", "origin"); err != nil {
+			return err
+		}
+		if err := git(ctx, "-C", clone, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(l.dir, 0755); err != nil {
+			return fmt.Errorf("创建仓库目录失败: %w", err)
+		}
+		_ = os.RemoveAll(clone)
+		if err := git(ctx, "clone", "--depth", "1", st.Sources[i].URL, clone); err != nil {
+			return err
+		}
+	}
+
+	return l.update(func(st *state) error {
+		if i := l.index(st, name); i >= 0 {
+			st.Sources[i].SyncedAt = time.Now()
+		}
+		return nil
+	})
+}
+
+// Items 返回已同步仓库中的技能包及其审阅状态，name 为空时返回所有仓库的技能包。
+// 仓库中任意位置带 front matter 的 Markdown 文件都视为技能包，其余文件忽略
+func (l *Library) Items(name string) ([]Item, error) {
+	st, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, src := range st.Sources {
+		if name != "" && src.Name != name {
+			continue
+		}
+		root := filepath.Join(l.dir, src.Name)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(d.Name(), ".md") {
+				return nil
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			sk, err := skills.Parse(data)
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, p)
+			it := Item{Source: src.Name, Path: filepath.ToSlash(rel), Skill: *sk, Data: data}
+			switch hash, ok := st.Approved[it.Key()]; {
+			case !ok:
+				it.Status = Pending
+			case hash == digest(data):
+				it.Status = Approved
+			default:
+				it.Status = Changed
+			}
+			items = append(items, it)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("读取仓库 %s 失败: %w", src.Name, err)
+		}
+	}
+	return items, nil
+}
+
+// Approve 确认技能包的当前内容并安装到技能包存储
+func (l *Library) Approve(it Item, store *skills.Store) error {
+	if err := store.Add(it.Data); err != nil {
+		return err
+	}
+	return l.update(func(st *state) error {
+		if st.Approved == nil {
+			st.Approved = map[string]string{}
+		}
+		st.Approved[it.Key()] = digest(it.Data)
+		return nil
+	})
+}
+
+func (l *Library) index(st *state, name string) int {
+	for i, s := range st.Sources {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *Library) load() (*state, error) {
+	var st state
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取共享仓库订阅失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("解析共享仓库订阅失败: %w", err)
+	}
+	return &st, nil
+}
+
+// update 在文件锁内读取最新的订阅记录，修改后写回
+func (l *Library) update(fn func(st *state) error) error {
+	return filelock.Update(l.path, 0644, func([]byte) ([]byte, error) {
+		st, err := l.load()
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(st); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(st, "", "  ")
+	})
+}
+
+// git 执行 git 命令，失败时附带其错误输出
+func git(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	// 不弹出凭据输入提示，私有仓库依赖已配置的 SSH key 或凭据助手
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("同步共享仓库需要安装 git")
+	}
+	if err != nil {
+		return fmt.Errorf("git %s 失败: %w\n%s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
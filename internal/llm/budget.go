@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExceeded 本次调用的 LLM 请求次数或 token 用量已达上限
+var ErrBudgetExceeded = errors.New("已达到本次调用的 LLM 用量上限")
+
+// Budget 单次调用中 LLM 请求次数与 token 用量的上限，防止追问或探测循环失控。
+// 同一 Budget 在 Client 的各个副本间共享，可安全地并发使用
+type Budget struct {
+	mu sync.Mutex
+
+	// 上限，0 表示不限制
+	maxCalls  int
+	maxTokens int
+	// 每次 Extend 追加的额度
+	stepCalls  int
+	stepTokens int
+
+	calls  int
+	tokens int
+}
+
+// NewBudget 创建用量上限，0 表示不限制
+func NewBudget(maxCalls, maxTokens int) *Budget {
+	return &Budget{
+		maxCalls:   maxCalls,
+		maxTokens:  maxTokens,
+		stepCalls:  maxCalls,
+		stepTokens: maxTokens,
+	}
+}
+
+// WithBudget 设置本次调用的 LLM 用量上限，nil 表示不限制
+func WithBudget(b *Budget) Option {
+	return func(c *Client) {
+		c.budget = b
+	}
+}
+
+// Budget 返回客户端使用的用量上限，未设置时为 nil
+func (c *Client) Budget() *Budget {
+	return c.budget
+}
+
+// reserve 在发起请求前检查上限并立即计入这次请求，并发的请求不会都通过检查而超出次数上限。
+// token 在请求结束后才能得知，进行中的请求仍可能使 token 用量略微超出上限
+func (b *Budget) reserve() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if (b.maxCalls > 0 && b.calls >= b.maxCalls) || (b.maxTokens > 0 && b.tokens >= b.maxTokens) {
+		return ErrBudgetExceeded
+	}
+	b.calls++
+	return nil
+}
+
+// settle 在请求结束后记录其消耗的 token
+func (b *Budget) settle(tokens int) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += tokens
+}
+
+// Extend 在当前上限的基础上再追加一份初始额度
+func (b *Budget) Extend() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxCalls > 0 {
+		b.maxCalls += b.stepCalls
+	}
+	if b.maxTokens > 0 {
+		b.maxTokens += b.stepTokens
+	}
+}
+
+// Tokens 返回已消耗的 token 数
+func (b *Budget) Tokens() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// Summary 返回当前用量的简要说明
+func (b *Budget) Summary() string {
+	if b == nil {
+		return ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return fmt.Sprintf("LLM 请求 %s 次，token %s", usage(b.calls, b.maxCalls), usage(b.tokens, b.maxTokens))
+}
+
+func usage(n, limit int) string {
+	if limit <= 0 {
+		return fmt.Sprint(n)
+	}
+	return fmt.Sprintf("%d/%d", n, limit)
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// Classify 将提供商返回的错误归类为 LLMError，便于界面给出针对性的提示；无法归类时原样返回
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var llmErr *LLMError
+	if errors.As(err, &llmErr) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewTimeoutError("请求超时", err)
+	}
+	switch code := providers.StatusCode(err); {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return NewAuthError("认证失败", err)
+	case code == http.StatusTooManyRequests:
+		return NewQuotaError("请求过于频繁或配额不足", err)
+	case code >= http.StatusInternalServerError:
+		return NewGeneralError("服务端错误", err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return NewTimeoutError("请求超时", err)
+		}
+		return NewNetworkError("网络连接失败", err)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// timeKeywords 与日期时间相关的中文查询，命中时才附加当前时间
+var timeKeywords = regexp.MustCompile(`昨[天日晚]|前天|今[天日晚早]|明[天日晚]|后天|[上本下这][个]?(?:周|星期|礼拜|月)|[周星期礼拜][一二三四五六日天]|去年|今年|[0-9一二三四五六七八九十两]+\s*(?:点|时|分钟|小时|天|周|个?月|年)(?:前|以?来|以?内|之?后|以后)?|[0-9]+\s*[:：][0-9]{2}|凌晨|早上|上午|中午|下午|傍晚|晚上|半夜|午夜|定时|计划任务|每[天日周月年]|每隔|每小时|每分钟|日期|时间戳|时区|最近|以来|截至|过期`)
+
+// englishTimeKeywords 英文关键词按单词匹配
+var englishTimeKeywords = regexp.MustCompile(`(?i)\b(yesterday|today|tonight|tomorrow|ago|since|until|last (?:night|week|month|year|monday|tuesday|wednesday|thursday|friday|saturday|sunday)|this (?:morning|week|month|year)|next (?:week|month|year)|(?:mon|tues|wednes|thurs|fri|satur|sun)day|midnight|noon|\d{1,2}\s*(?:am|pm)|o'clock|cron\w*|schedul\w*|timers?|timezone|timestamp|date|expir\w*|every (?:day|hour|minute|week|month))\b`)
+
+// timeRelevant 判断查询是否涉及日期时间，需要当前时间才能得到准确的绝对值
+func timeRelevant(query string) bool {
+	return timeKeywords.MatchString(query) || englishTimeKeywords.MatchString(query)
+}
+
+// weekdays 星期的中文名称
+var weekdays = [...]string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"}
+
+// withClock 查询与日期时间相关时附加本机当前时间、时区与日期格式约定，使"昨天 9 点"、"上周一"、
+// crontab 等换算为正确的绝对值而不是由模型猜测。远程执行时提醒模型目标主机的时区可能不同
+func (c *Client) withClock(ctx context.Context, prompt, query string) string {
+	if !c.clock || !timeRelevant(query) {
+		return prompt
+	}
+	text, ok := c.prepareOutput(ctx, renderClock(time.Now()))
+	if !ok {
+		return prompt
+	}
+	note := "请据此把相对时间换算为绝对的日期时间，不要猜测当前日期"
+	if c.host != "" {
+		note += "；这是本机的时间，目标主机 " + c.host + " 的时区可能不同，需要时在命令中显式指定 TZ"
+	}
+	return fmt.Sprintf("%s\n\n当前时间（%s）:\n%s", prompt, note, text)
+}
+
+// renderClock 列出当前的本地时间、星期、时区、UTC 时间以及命令中使用的日期格式
+func renderClock(now time.Time) string {
+	abbr, offset := now.Zone()
+	zone := fmt.Sprintf("UTC%s", now.Format("-07:00"))
+	if abbr != "" && !strings.HasPrefix(abbr, "+") && !strings.HasPrefix(abbr, "-") {
+		zone += " " + abbr
+	}
+	if name := zoneName(); name != "" {
+		zone = name + "，" + zone
+	}
+
+	lines := []string{
+		"- 本地时间: " + now.Format("2006-01-02 15:04:05") + " " + weekdays[now.Weekday()],
+		"- 时区: " + zone,
+		"- UTC 时间: " + now.UTC().Format(time.RFC3339),
+		"- 日期格式: 命令中使用 YYYY-MM-DD HH:MM:SS（如 find -newermt、journalctl --since、date -d）",
+	}
+	if offset != 0 {
+		lines = append(lines, "- crontab 与 systemd 定时器按本地时区解释，不是 UTC")
+	}
+	if lcTime := localeTime(); lcTime != "" {
+		lines = append(lines, "- LC_TIME: "+lcTime+"，解析 date、ls -l 等命令的输出时注意其日期格式")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// zoneName 返回本机时区的 IANA 名称（如 Asia/Shanghai），优先取 TZ 环境变量，
+// 其次为 /etc/localtime 指向的时区文件，无法确定时返回空字符串
+func zoneName() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" && !filepath.IsAbs(tz) {
+		return tz
+	}
+	target, err := os.Readlink("/etc/localtime")
+	if err != nil {
+		return ""
+	}
+	if _, name, ok := strings.Cut(target, "zoneinfo/"); ok {
+		return name
+	}
+	return ""
+}
+
+// localeTime 返回决定日期显示格式的语言环境，与 C、POSIX 或未设置时返回空字符串
+func localeTime() string {
+	for _, key := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			if v == "C" || v == "POSIX" || strings.HasPrefix(v, "C.") {
+				return ""
+			}
+			return v
+		}
+	}
+	return ""
+}
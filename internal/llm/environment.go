@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	envctx "termi.sh/termi/internal/context"
+)
+
+// withEnvironment 附加配置中开启的本机环境信息摘要（工作目录、系统、shell、包管理器、git 状态、已安装工具），
+// 经过脱敏，开启发送前确认时需用户同意。远程执行时本机环境没有意义；git 状态只在受信任目录中采集
+func (c *Client) withEnvironment(ctx context.Context, prompt string) string {
+	if !c.environment.Enabled() || c.host != "" {
+		return prompt
+	}
+	var installed envctx.Installed
+	if c.probes != nil {
+		installed = c.probes.Installed
+	}
+	dir, err := os.Getwd()
+	env := envctx.Collect(ctx, c.environment, installed, err == nil && c.trust.Trusted(dir))
+	_ = c.probes.Save()
+	summary := env.Render()
+	if summary == "" {
+		return prompt
+	}
+	text, ok := c.prepareOutput(ctx, summary)
+	if !ok {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\n本机环境（请据此选择适用的命令、包管理器与路径）:\n%s", prompt, text)
+}
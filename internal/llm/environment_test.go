@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/trust"
+)
+
+func TestWithEnvironmentGitTrust(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	old, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(old) })
+	cwd, _ := os.Getwd()
+
+	store := trust.Open(filepath.Join(t.TempDir(), "trust.json"))
+	c := &Client{environment: config.ContextConfig{OS: true, Git: true}, trust: store}
+	const repo = "当前目录是 git 仓库"
+
+	// 尚未决定的目录视为不受信任：只附加系统信息，不读取 git 状态
+	got := c.withEnvironment(context.Background(), "q")
+	if !strings.Contains(got, "系统: ") || strings.Contains(got, repo) {
+		t.Errorf("undecided directory: prompt = %q", got)
+	}
+
+	if err := store.Set(cwd, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.withEnvironment(context.Background(), "q"); !strings.Contains(got, repo) {
+		t.Errorf("trusted directory: prompt = %q", got)
+	}
+
+	if err := store.Set(cwd, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.withEnvironment(context.Background(), "q"); strings.Contains(got, repo) {
+		t.Errorf("untrusted directory: prompt = %q", got)
+	}
+
+	// 远程执行时不附加本机环境
+	c.host = "web1"
+	if got := c.withEnvironment(context.Background(), "q"); got != "q" {
+		t.Errorf("remote host: prompt = %q, want unchanged", got)
+	}
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// ExplainStages 请求模型用一句话说明管道中每个阶段的作用，返回的说明与 stages 一一对应
+func (c *Client) ExplainStages(ctx context.Context, stages []string) ([]string, error) {
+	if c == nil || c.provider == nil {
+		return nil, fmt.Errorf("LLM 提供商未初始化")
+	}
+
+	var b strings.Builder
+	b.WriteString("请逐一解释下面管道中每个阶段的作用，以及它向下一阶段输出什么数据:\n")
+	for i, s := range stages {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, s)
+	}
+	fmt.Fprintf(&b, "\n返回 JSON {\"stages\":[...]}，stages 恰好包含 %d 条不超过 20 个字的中文说明，顺序与上面一致。不要提问，也不要发起探测。", len(stages))
+	b.WriteString(c.explainDepth())
+
+	reply, err := c.ask(ctx, b.String())
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Stages) != len(stages) {
+		return nil, fmt.Errorf("模型返回了 %d 条说明，期望 %d 条", len(reply.Stages), len(stages))
+	}
+	return reply.Stages, nil
+}
+
+// Line 命令中的一个片段及其含义
+type Line = providers.Line
+
+// ExplainCommand 请求模型把命令拆成程序、子命令、选项等片段并逐一解释，片段按在命令中出现的顺序排列
+func (c *Client) ExplainCommand(ctx context.Context, command string) ([]Line, error) {
+	if c == nil || c.provider == nil {
+		return nil, fmt.Errorf("LLM 提供商未初始化")
+	}
+
+	prompt := "请逐段解释下面这条命令，把它拆成程序名、子命令、选项及其取值、参数、管道与重定向等片段，按出现顺序逐一说明含义和作用:\n" +
+		command + "\n\n" +
+		"返回 JSON {\"lines\":[{\"code\":\"片段原文\",\"note\":\"中文说明\"}]}，code 为命令中的原文，选项与它的取值合为一段，note 不超过 40 个字；" +
+		"值得注意的副作用或风险写进对应片段的说明里。不要提问，也不要发起探测。" + c.explainDepth()
+
+	reply, err := c.ask(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]Line, 0, len(reply.Lines))
+	for _, l := range reply.Lines {
+		l.Code, l.Note = strings.TrimSpace(l.Code), strings.TrimSpace(l.Note)
+		if l.Code != "" || l.Note != "" {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("模型没有返回命令的解释")
+	}
+	return lines, nil
+}
+
+// Tradeoff 一条候选命令在可移植性、速度与安全性上的取舍
+type Tradeoff = providers.Tradeoff
+
+// Rationale 选择某条候选命令的理由，以及各候选命令的取舍
+type Rationale struct {
+	Why       string
+	Tradeoffs []Tradeoff // 与传入的候选命令一一对应，模型没有提到的候选为空
+}
+
+// ExplainChoice 请求模型说明为什么 chosen 适合 query，并与其他候选命令比较可移植性、速度与安全性，
+// 帮助用户理解取舍而不是盲目执行
+func (c *Client) ExplainChoice(ctx context.Context, query, chosen string, candidates []string) (*Rationale, error) {
+	if c == nil || c.provider == nil {
+		return nil, fmt.Errorf("LLM 提供商未初始化")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "用户的需求: %s\n\n候选命令:\n", query)
+	for i, cmd := range candidates {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, cmd)
+	}
+	fmt.Fprintf(&b, "\n请说明为什么选择下面这条命令:\n%s\n\n", chosen)
+	if len(candidates) > 1 {
+		b.WriteString("并从可移植性（是否依赖特定系统、版本或额外工具）、速度（数据量大时的表现）、安全性（是否修改数据、能否撤销）三方面简要比较每条候选命令。")
+	} else {
+		b.WriteString("没有其他候选，请与常见的替代做法对比，并从可移植性（是否依赖特定系统、版本或额外工具）、速度（数据量大时的表现）、安全性（是否修改数据、能否撤销）三方面评价这条命令。")
+	}
+	b.WriteString("\n返回 JSON {\"rationale\":\"...\",\"tradeoffs\":[{\"command\":\"候选原文\",\"portability\":\"...\",\"speed\":\"...\",\"safety\":\"...\"}]}，" +
+		"rationale 用中文说明选择理由，不超过 80 个字；tradeoffs 按上面的顺序覆盖每条候选，每项说明不超过 30 个字。不要提问，也不要发起探测。")
+	b.WriteString(c.explainDepth())
+
+	reply, err := c.ask(ctx, b.String())
+	if err != nil {
+		return nil, err
+	}
+	r := &Rationale{Why: strings.TrimSpace(reply.Rationale), Tradeoffs: make([]Tradeoff, len(candidates))}
+	if r.Why == "" {
+		return nil, fmt.Errorf("模型没有说明选择理由")
+	}
+	// 模型可能改写命令的空白或引号，按原文匹配不到时按顺序对应
+	for i, t := range reply.Tradeoffs {
+		j := slices.Index(candidates, strings.TrimSpace(t.Command))
+		if j < 0 && len(reply.Tradeoffs) == len(candidates) {
+			j = i
+		}
+		if j >= 0 {
+			t.Command = candidates[j]
+			r.Tradeoffs[j] = t
+		}
+	}
+	return r, nil
+}
+
+// explainDepth 按用户熟练程度调整解释的详略，默认程度时返回空字符串
+func (c *Client) explainDepth() string {
+	switch c.expertise {
+	case config.Beginner:
+		return "\n用户是命令行新手：用通俗的语言说明，遇到术语时顺带解释，说明的字数上限可以放宽一倍。"
+	case config.Expert:
+		return "\n用户是熟练的命令行用户：说明尽量简短，常见的程序与选项一笔带过，只写出不显而易见的细节。"
+	default:
+		return ""
+	}
+}
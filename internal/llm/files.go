@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/piped"
+)
+
+// File 通过 termi --file 附加的文件，按与管道内容相同的规则截断与清理
+type File struct {
+	Path string
+	piped.Input
+}
+
+// WithFiles 附加用户指定的文件（配置文件、脚本等），发送前同样经过脱敏与确认
+func WithFiles(files []File) Option {
+	return func(c *Client) {
+		c.files = files
+	}
+}
+
+// Files 返回附加的文件
+func (c *Client) Files() []File {
+	return c.files
+}
+
+// withFiles 将附加的文件逐个附加到提示词，标明路径，截断时告诉模型只看到了哪一部分
+func (c *Client) withFiles(ctx context.Context, prompt string) (string, error) {
+	if len(c.files) == 0 {
+		return prompt, nil
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	for _, f := range c.files {
+		text, ok := c.prepareOutput(ctx, f.Text)
+		if !ok {
+			return "", fmt.Errorf("用户拒绝发送文件 %s", f.Path)
+		}
+		note := ""
+		if f.Truncated {
+			note = fmt.Sprintf("（原文件共 %d 字节，过长，只保留了%s部分）", f.Size, f.Kept())
+		}
+		fmt.Fprintf(&b, "\n\n用户附加的文件 %s%s，请结合它理解需求:\n```\n%s\n```", f.Path, note, text)
+	}
+	return b.String(), nil
+}
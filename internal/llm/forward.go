@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"context"
+
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// Forward 将其他 termi 客户端组装好的系统提示词与用户提示词原样转发给提供商，
+// 不再附加本机上下文，供缓存代理使用。system 为空时使用本机配置的系统提示词
+func (c *Client) Forward(ctx context.Context, system, prompt string) (*Reply, error) {
+	fc := *c
+	if system != "" {
+		fc.systemPrompt = system
+	}
+	return fc.ask(ctx, prompt)
+}
+
+// Redact 按配置的脱敏规则处理文本
+func (c *Client) Redact(text string) string {
+	return c.redactor.Redact(text)
+}
+
+// Ping 向主提供商发送一个极短的请求，检查 API key、地址与模型是否可用。
+// 不重试、不改用其他提供商，也不计入健康记录，供配置向导测试连接
+func (c *Client) Ping(ctx context.Context) error {
+	ctx = providers.WithSystemPrompt(ctx, `只返回 JSON {"command":"true"}`)
+	_, err := c.provider.AskSmart(ctx, "ping")
+	return err
+}
@@ -0,0 +1,49 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/habit"
+)
+
+// withHabits 附加用户确认作为偏好的修改习惯；它们来自用户自己的选择，低带宽模式下同样附加
+func (c *Client) withHabits(prompt string) string {
+	var prefs []string
+	for _, r := range c.habits.Rules() {
+		if r.Mode == habit.ModePrompt {
+			prefs = append(prefs, r.Preference())
+		}
+	}
+	if len(prefs) == 0 {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n用户的使用习惯（需求没有另行指定时遵循）:")
+	for _, p := range prefs {
+		fmt.Fprintf(&b, "\n- %s", p)
+	}
+	return b.String()
+}
+
+// applyHabits 按用户确认的改写规则补全生成的命令，所做的调整追加到 notes
+func (c *Client) applyHabits(reply *Reply) {
+	rules := c.habits.Rules()
+	if len(rules) == 0 || reply.Command == "" {
+		return
+	}
+	var notes []string
+	reply.Command, notes = habit.Apply(reply.Command, rules)
+	reply.Notes = append(reply.Notes, notes...)
+	for i := range reply.Alternatives {
+		alt := &reply.Alternatives[i]
+		alt.Command, notes = habit.Apply(alt.Command, rules)
+		alt.Notes = append(alt.Notes, notes...)
+	}
+}
+
+// Habits 返回手动修改习惯的决定记录，未记录历史时为 nil
+func (c *Client) Habits() *habit.Store {
+	return c.habits
+}
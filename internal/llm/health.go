@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+const (
+	// healthWindow 只统计这段时间内的请求结果，更早的失败不再影响提供商的排序
+	healthWindow = 10 * time.Minute
+	// demoteAfter 窗口内失败达到这么多次且最近一次仍失败时，提供商被降级
+	demoteAfter = 3
+	// maxOutcomes 每个提供商保留的最近结果数
+	maxOutcomes = 10
+)
+
+// outcome 一次请求（含重试）的最终结果
+type outcome struct {
+	At time.Time `json:"at"`
+	OK bool      `json:"ok"`
+}
+
+// healthCache 各提供商最近请求结果的磁盘缓存。每次调用 termi 都是新进程，
+// 持久化后连续失败的提供商在随后的查询中直接排到最后，不必每次都等它超时
+type healthCache struct {
+	mu       sync.Mutex
+	path     string
+	outcomes map[string][]outcome
+}
+
+// openHealthCache 打开健康缓存文件，文件不存在或损坏时从空缓存开始
+func openHealthCache(path string) *healthCache {
+	h := &healthCache{path: path, outcomes: map[string][]outcome{}}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &h.outcomes)
+	}
+	return h
+}
+
+// record 记录提供商的一次请求结果并立即写回磁盘，写入失败时忽略。
+// 追加到磁盘上的最新记录之后，同时运行的其他 termi 进程记录的结果不会被覆盖
+func (h *healthCache) record(name string, ok bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	o := outcome{At: time.Now(), OK: ok}
+	merged := false
+	_ = filelock.Update(h.path, 0600, func(data []byte) ([]byte, error) {
+		if err := json.Unmarshal(data, &h.outcomes); err != nil || h.outcomes == nil {
+			h.outcomes = map[string][]outcome{}
+		}
+		h.append(name, o)
+		merged = true
+		return json.Marshal(h.outcomes)
+	})
+	if !merged {
+		// 没有等到文件锁时本进程内仍然记住这次结果
+		h.append(name, o)
+	}
+}
+
+func (h *healthCache) append(name string, o outcome) {
+	list := append(h.outcomes[name], o)
+	if len(list) > maxOutcomes {
+		list = list[len(list)-maxOutcomes:]
+	}
+	h.outcomes[name] = list
+}
+
+// demoted 判断提供商最近是否反复失败：窗口内失败至少 demoteAfter 次且最近一次仍是失败。
+// 一次成功即恢复，窗口过后旧的失败也不再计入，提供商会重新被优先尝试
+func (h *healthCache) demoted(name string) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	since := time.Now().Add(-healthWindow)
+	failures := 0
+	last := true
+	for _, o := range h.outcomes[name] {
+		if o.At.Before(since) {
+			continue
+		}
+		if !o.OK {
+			failures++
+		}
+		last = o.OK
+	}
+	return failures >= demoteAfter && !last
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/hosts"
+	"termi.sh/termi/internal/probe"
+	"termi.sh/termi/internal/suggest"
+)
+
+// exitCommandNotFound shell 中命令不存在时的退出码
+const exitCommandNotFound = 127
+
+// WithHost 将命令目标设为 SSH 远程主机，探测结果会写入该主机的档案供后续复用
+func WithHost(host string, store *hosts.Store) Option {
+	return func(c *Client) {
+		c.host = host
+		c.hosts = store
+	}
+}
+
+// Shell 返回本机生成并执行命令所用的 shell；远程主机上的命令总是交给远端的默认 shell
+func (c *Client) Shell() string {
+	return c.shell
+}
+
+// Host 返回命令的目标 SSH 主机，本机执行时为空
+func (c *Client) Host() string {
+	return c.host
+}
+
+// withHostContext 告知模型命令的目标主机及已知的主机信息
+func (c *Client) withHostContext(prompt string) string {
+	if c.host == "" {
+		return prompt
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n注意：命令将通过 SSH 在远程主机 %s 上执行，而不是本机。", prompt, c.host)
+	if c.hosts != nil && !c.lite {
+		if p, err := c.hosts.Load(c.host); err == nil {
+			if known := p.Render(); known != "" {
+				fmt.Fprintf(&b, "\n该主机的已知信息（无需重复探测）:\n%s", known)
+			}
+		}
+	}
+	return b.String()
+}
+
+// probeRemote 在远程主机上执行只读探测，优先使用主机档案中未过期的结果
+func (c *Client) probeRemote(ctx context.Context, cmdStr string) (string, error) {
+	if !probe.AllowedRemote(cmdStr) {
+		return "", fmt.Errorf("该命令不在只读探测白名单内")
+	}
+
+	var profile *hosts.Profile
+	if c.hosts != nil {
+		profile, _ = c.hosts.Load(c.host)
+	}
+	if profile != nil {
+		if v, ok := profile.Lookup(cmdStr); ok {
+			return v, nil
+		}
+	}
+
+	out, err := probe.RunRemote(ctx, c.host, cmdStr)
+	if err == nil && c.hosts != nil {
+		_ = c.hosts.Learn(c.host, cmdStr, out)
+	}
+	return out, err
+}
+
+// LearnExecution 根据远程命令的执行结果更新主机档案：记录主程序是否可用
+func (c *Client) LearnExecution(command string, exitCode int) {
+	if c.host == "" || c.hosts == nil {
+		return
+	}
+	tool := strings.TrimPrefix(suggest.Approach(command), "使用 ")
+	switch {
+	case tool == "":
+		return
+	case exitCode == 0:
+		_ = c.hosts.Learn(c.host, "which "+tool, "已安装")
+	case exitCode == exitCommandNotFound:
+		_ = c.hosts.Learn(c.host, "which "+tool, "未安装")
+	}
+}
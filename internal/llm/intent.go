@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+)
+
+// intentKeywords 识别各意图的内置关键词。英文关键词按单词匹配，中文关键词按子串匹配
+var intentKeywords = map[config.Intent][]string{
+	config.IntentGit: {
+		"git", "commit", "branch", "rebase", "stash", "cherry-pick", "merge", "checkout",
+		"提交", "分支", "变基", "暂存区", "合并请求", "仓库",
+	},
+	config.IntentPackage: {
+		"apt", "apt-get", "yum", "dnf", "brew", "pacman", "apk", "zypper", "snap", "flatpak",
+		"npm", "pnpm", "yarn", "pip", "pip3", "pipx", "cargo", "gem", "go install", "choco", "winget", "scoop",
+		"install", "uninstall", "upgrade", "package",
+		"安装", "卸载", "升级", "软件包", "依赖", "包管理",
+	},
+	config.IntentNetwork: {
+		"firewall", "iptables", "nftables", "ufw", "firewalld", "port", "ports", "ping", "curl", "wget",
+		"dns", "ssh", "scp", "rsync", "proxy", "ip", "ifconfig", "netstat", "ss", "route", "tcp", "udp",
+		"http", "https", "nc", "traceroute", "vpn",
+		"网络", "防火墙", "端口", "代理", "域名", "网卡", "路由", "连通", "监听", "下载", "带宽",
+	},
+	config.IntentText: {
+		"grep", "sed", "awk", "jq", "yq", "sort", "uniq", "wc", "cut", "tr", "csv", "json", "regex",
+		"文本", "替换", "正则", "每行", "行数", "字符串", "日志", "统计", "去重", "排序", "提取", "匹配",
+	},
+	config.IntentFile: {
+		"file", "files", "directory", "folder", "find", "rm", "cp", "mv", "chmod", "chown", "ls", "du",
+		"tar", "zip", "unzip", "symlink",
+		"文件", "目录", "文件夹", "删除", "复制", "移动", "重命名", "压缩", "解压", "权限", "磁盘", "软链接",
+	},
+}
+
+// classifyIntent 按关键词识别查询的意图：命中关键词最多的意图胜出，数量相同时按 config.Intents
+// 的顺序取前面的，没有命中任何关键词时返回空字符串。intents 中配置的 keywords 与内置关键词一起使用
+func classifyIntent(query string, intents map[config.Intent]config.IntentConfig) config.Intent {
+	q := strings.ToLower(query)
+	var (
+		best config.Intent
+		most int
+	)
+	for _, intent := range config.Intents {
+		hits := 0
+		for _, kw := range slices.Concat(intentKeywords[intent], intents[intent].Keywords) {
+			if containsKeyword(q, strings.ToLower(kw)) {
+				hits++
+			}
+		}
+		if hits > most {
+			best, most = intent, hits
+		}
+	}
+	return best
+}
+
+// containsKeyword 判断 q 是否包含关键词 kw。纯 ASCII 的关键词前后不能紧接字母、数字、_ 或 -，
+// 以免 ip 命中 zip、pip，rm 命中 format；其余关键词按子串匹配
+func containsKeyword(q, kw string) bool {
+	if kw == "" {
+		return false
+	}
+	for _, r := range kw {
+		if r >= 0x80 {
+			return strings.Contains(q, kw)
+		}
+	}
+	for i := 0; i < len(q); {
+		j := strings.Index(q[i:], kw)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(kw)
+		if !wordByte(q, start-1) && !wordByte(q, end) {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+// wordByte 报告 q[i] 是否为英文单词中的字符，越界时为 false
+func wordByte(q string, i int) bool {
+	if i < 0 || i >= len(q) {
+		return false
+	}
+	c := q[i]
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// routed 返回按查询意图调整后的客户端副本与识别出的意图：改用意图配置的提供商或模型，
+// 其失败后依次改用主提供商与 failover；请求时使用其温度与技能包。未配置 intents 时不识别，直接返回 c
+func (c *Client) routed(ctx context.Context, prompt string) (*Client, config.Intent) {
+	if len(c.intents) == 0 {
+		return c, ""
+	}
+	intent := classifyIntent(c.normalized(ctx, prompt), c.intents)
+	if intent == "" {
+		return c, ""
+	}
+	clone := *c
+	clone.intent = intent
+	if p := c.routes[intent]; p != nil {
+		clone.provider = p
+		others := append([]Provider{c.provider}, c.failover...)
+		clone.failover = slices.DeleteFunc(others, func(f Provider) bool { return f.Name() == p.Name() })
+	}
+	return &clone, intent
+}
+
+// ProviderFor 返回意图 intent 的查询使用的提供商名称，没有为其改用提供商或模型时为主提供商
+func (c *Client) ProviderFor(intent config.Intent) string {
+	if p := c.routes[intent]; p != nil {
+		return p.Name()
+	}
+	return c.ProviderName()
+}
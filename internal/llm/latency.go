@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+const (
+	// maxLatencySamples 每个提供商保留的最近耗时记录数
+	maxLatencySamples = 200
+	// minLatencySamples 记录少于这么多次时分布还不可靠，不判断是否过慢
+	minLatencySamples = 20
+	// minSlowLatency 短于这个耗时的请求不提示，响应很快的提供商只是略有波动时不必打扰用户
+	minSlowLatency = 2 * time.Second
+)
+
+// latencySample 一次成功请求的耗时与提示词大小
+type latencySample struct {
+	At     time.Time `json:"at"`
+	MS     int64     `json:"ms"`
+	Prompt int       `json:"prompt"` // 提示词字节数
+	Lite   bool      `json:"lite,omitempty"`
+}
+
+// latencyStore 各提供商最近请求耗时的磁盘记录，用来得到每个提供商自己的耗时分布。
+// 不同提供商、不同模型的正常耗时差别很大，固定的阈值没有意义，只与同一提供商的历史比较
+type latencyStore struct {
+	mu      sync.Mutex
+	path    string
+	samples map[string][]latencySample
+}
+
+// openLatencyStore 打开耗时记录文件，文件不存在或损坏时从空记录开始
+func openLatencyStore(path string) *latencyStore {
+	l := &latencyStore{path: path, samples: map[string][]latencySample{}}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &l.samples)
+	}
+	return l
+}
+
+// observe 记录提供商一次成功请求的耗时并立即写回磁盘。耗时超过记录前的 p95 时返回 Slow，
+// 本次请求不计入比较的基准，否则一次异常的慢请求会拉高自己的阈值
+func (l *latencyStore) observe(name string, d time.Duration, prompt int, lite bool) *Slow {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var slow *Slow
+	if p95, ok := percentile(l.samples[name], 0.95); ok && d > p95 && d >= minSlowLatency {
+		slow = &Slow{Latency: d, P95: p95, Prompt: prompt}
+	}
+	sample := latencySample{At: time.Now(), MS: d.Milliseconds(), Prompt: prompt, Lite: lite}
+	// 追加到磁盘上的最新记录之后，同时运行的其他 termi 进程记录的耗时不会被覆盖
+	merged := false
+	_ = filelock.Update(l.path, 0600, func(data []byte) ([]byte, error) {
+		if err := json.Unmarshal(data, &l.samples); err != nil || l.samples == nil {
+			l.samples = map[string][]latencySample{}
+		}
+		l.append(name, sample)
+		merged = true
+		return json.Marshal(l.samples)
+	})
+	if !merged {
+		l.append(name, sample)
+	}
+	return slow
+}
+
+func (l *latencyStore) append(name string, s latencySample) {
+	list := append(l.samples[name], s)
+	if len(list) > maxLatencySamples {
+		list = list[len(list)-maxLatencySamples:]
+	}
+	l.samples[name] = list
+}
+
+// percentile 返回耗时的 q 分位数，记录不足 minLatencySamples 次时 ok 为 false
+func percentile(samples []latencySample, q float64) (time.Duration, bool) {
+	if len(samples) < minLatencySamples {
+		return 0, false
+	}
+	ms := make([]int64, len(samples))
+	for i, s := range samples {
+		ms[i] = s.MS
+	}
+	slices.Sort(ms)
+	i := int(float64(len(ms)-1) * q)
+	return time.Duration(ms[i]) * time.Millisecond, true
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// lazyProvider 在第一次请求时才创建提供商的 SDK 客户端。离线命令库直接回答、
+// 用户在追问或信任确认时退出等不请求模型的运行不必付出初始化的代价，
+// 备用与 failover 提供商通常整个运行都用不到
+type lazyProvider struct {
+	name   string
+	create func() (Provider, error)
+
+	once sync.Once
+	p    Provider
+	err  error
+}
+
+// lazily 返回按 cfg 中的 LLM.Provider 延迟创建的提供商
+func lazily(cfg *config.Config) Provider {
+	return &lazyProvider{
+		name:   providers.Name(cfg.LLM.Provider),
+		create: func() (Provider, error) { return createProvider(cfg) },
+	}
+}
+
+func (l *lazyProvider) get() (Provider, error) {
+	l.once.Do(func() {
+		l.p, l.err = l.create()
+	})
+	return l.p, l.err
+}
+
+func (l *lazyProvider) Name() string {
+	return l.name
+}
+
+// Enabled 创建前以配置已通过验证为准；创建失败的原因在第一次请求时返回
+func (l *lazyProvider) Enabled() bool {
+	return true
+}
+
+func (l *lazyProvider) AskSmart(ctx context.Context, prompt string) (*providers.Reply, error) {
+	p, err := l.get()
+	if err != nil {
+		return nil, fmt.Errorf("创建 LLM 提供商 %s 失败: %w", l.name, err)
+	}
+	if !p.Enabled() {
+		return nil, fmt.Errorf("LLM 提供商 %s 未正确配置", l.name)
+	}
+	return p.AskSmart(ctx, prompt)
+}
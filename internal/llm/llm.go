@@ -1,18 +1,44 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/coreutils"
+	"termi.sh/termi/internal/experiment"
+	"termi.sh/termi/internal/habit"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/hosts"
 	"termi.sh/termi/internal/llm/providers"
+	"termi.sh/termi/internal/locale"
+	"termi.sh/termi/internal/normalize"
+	"termi.sh/termi/internal/objects"
+	"termi.sh/termi/internal/piped"
+	"termi.sh/termi/internal/probe"
+	"termi.sh/termi/internal/project"
+	"termi.sh/termi/internal/redact"
+	"termi.sh/termi/internal/shellquote"
+	"termi.sh/termi/internal/skills"
+	"termi.sh/termi/internal/stats"
+	"termi.sh/termi/internal/telemetry"
+	"termi.sh/termi/internal/trust"
 )
 
+// defaultMaxProbeRounds 单次请求中允许模型发起的默认最大探测次数
+const defaultMaxProbeRounds = 3
+
 // Provider 定义 LLM 提供商接口
 type Provider interface {
-	// AskSmart 根据用户 query 返回 command 或 ask
-	// 如果需要更多信息，则 ask 字段非空
-	AskSmart(ctx context.Context, prompt string) (command string, ask string, err error)
+	// AskSmart 根据用户 query 返回 command、ask 或探测请求 need
+	AskSmart(ctx context.Context, prompt string) (*providers.Reply, error)
 
 	// Name 返回提供商名称
 	Name() string
@@ -21,21 +47,353 @@ type Provider interface {
 	Enabled() bool
 }
 
-var currentProvider Provider
+// Reply 模型的结构化响应
+type Reply = providers.Reply
+
+// Slow 耗时超过提供商近期 p95 的请求
+type Slow = providers.Slow
+
+// Field 追问中需要用户填写的一项结构化取值
+type Field = providers.Field
+
+// Client LLM 客户端，封装提供商及多轮请求流程，可安全地创建多个实例
+type Client struct {
+	provider       Provider
+	fallback       Provider
+	failover       []Provider
+	alternatives   []Provider // 所有配置完整的提供商，供出错后手动切换
+	retry          config.RetryConfig
+	timeouts       config.TimeoutsConfig
+	health         *healthCache
+	latency        *latencyStore
+	stats          *stats.Store
+	maxProbeRounds int
+	dictionary     *normalize.Dictionary
+	probes         *probe.Cache
+	skills         *skills.Store
+	history        *history.Store
+	fewShot        int
+	budget         *Budget
+	lite           bool
+	expertise      config.Expertise
+	presets        []string
+	translate      bool
+	terminal       string
+	piped          piped.Input
+	files          []File
+	snapshot       bool
+	clock          bool
+	objects        *objects.Output
+	projects       *project.Store
+	trust          *trust.Store
+	environment    config.ContextConfig
+	candidates     int
+	pinned         []string
+	habits         *habit.Store
+	shell          string
+
+	// 提示词实验分配到的变体及其替代系统提示词
+	variant      string
+	systemPrompt string
+
+	// 按查询意图的路由：各意图的配置、改用的提供商，以及本次请求识别出的意图
+	intents map[config.Intent]config.IntentConfig
+	routes  map[config.Intent]Provider
+	intent  config.Intent
+
+	// SSH 远程目标主机及其知识档案
+	host  string
+	hosts *hosts.Store
+
+	// 回传命令输出前的脱敏与确认
+	redactor        *redact.Redactor
+	requireApproval bool
+	approver        Approver
+}
+
+// Approver 在命令输出发送给 LLM 前展示给用户确认，返回 false 表示拒绝发送
+type Approver func(ctx context.Context, text string) bool
+
+// Option Client 的函数式选项
+type Option func(*Client)
 
-// Initialize 初始化 LLM 提供商
-func Initialize(cfg *config.Config) error {
-	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("配置验证失败: %w", err)
+// WithProvider 使用指定的提供商，而不是根据配置创建
+func WithProvider(p Provider) Option {
+	return func(c *Client) {
+		c.provider = p
 	}
+}
 
-	provider, err := createProvider(cfg)
-	if err != nil {
-		return fmt.Errorf("创建 LLM 提供商失败: %w", err)
+// WithMaxProbeRounds 设置单次请求中允许模型发起的最大探测次数
+func WithMaxProbeRounds(n int) Option {
+	return func(c *Client) {
+		c.maxProbeRounds = n
+	}
+}
+
+// verbatimKey 标记查询中包含用户粘贴的命令，不做拼写纠正
+type verbatimKey struct{}
+
+// WithVerbatim 标记查询中包含用户原样粘贴的命令（诊断并修复模式），经返回的 ctx 发起的分析
+// 不用词典改写查询，以免改动要诊断的命令本身
+func WithVerbatim(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verbatimKey{}, true)
+}
+
+// normalized 返回按词典预处理后的查询，查询经 WithVerbatim 标记时原样返回
+func (c *Client) normalized(ctx context.Context, query string) string {
+	if verbatim, _ := ctx.Value(verbatimKey{}).(bool); verbatim {
+		return query
+	}
+	return c.dictionary.Apply(query)
+}
+
+// WithDictionary 设置发送前的查询预处理词典，nil 表示不做预处理
+func WithDictionary(d *normalize.Dictionary) Option {
+	return func(c *Client) {
+		c.dictionary = d
+	}
+}
+
+// WithProbeCache 设置环境探测结果缓存，nil 表示每次都重新探测
+func WithProbeCache(pc *probe.Cache) Option {
+	return func(c *Client) {
+		c.probes = pc
+	}
+}
+
+// WithSkills 设置技能包存储，匹配到的技能包会附加到提示词中
+func WithSkills(st *skills.Store) Option {
+	return func(c *Client) {
+		c.skills = st
+	}
+}
+
+// WithFewShot 从历史记录中选取至多 n 条相似的已接受命令作为提示词示例
+func WithFewShot(store *history.Store, n int) Option {
+	return func(c *Client) {
+		c.history = store
+		c.fewShot = n
+	}
+}
+
+// WithRedactor 设置回传命令输出时使用的脱敏器
+func WithRedactor(r *redact.Redactor) Option {
+	return func(c *Client) {
+		c.redactor = r
+	}
+}
+
+// WithApprover 设置回传命令输出前的用户确认回调
+func WithApprover(a Approver) Option {
+	return func(c *Client) {
+		c.approver = a
+	}
+}
+
+// WithLite 开启低带宽模式：精简系统提示词、限制输出长度，且不附加技能包、历史示例等环境上下文
+func WithLite(lite bool) Option {
+	return func(c *Client) {
+		c.lite = lite
+	}
+}
+
+// WithPresets 设置附加到提示词中的平台与地区预设
+func WithPresets(names []string) Option {
+	return func(c *Client) {
+		c.presets = names
+	}
+}
+
+// WithoutLearning 不记录请求耗时与提供商的成败，用于压测等不代表日常使用的请求，
+// 避免其改变慢请求提示的基准或让主提供商被暂时降级
+func WithoutLearning() Option {
+	return func(c *Client) {
+		c.health = nil
+		c.latency = nil
+	}
+}
+
+// NewClient 根据配置创建 LLM 客户端
+func NewClient(cfg *config.Config, opts ...Option) (*Client, error) {
+	c := &Client{
+		maxProbeRounds: defaultMaxProbeRounds,
+	}
+	if cfg != nil {
+		c.redactor = newRedactor(cfg.Redact)
+		c.requireApproval = cfg.Redact.Approve
+		c.probes = probe.OpenCache(filepath.Join(config.CacheDir(), "probes.json"))
+		c.health = openHealthCache(filepath.Join(config.CacheDir(), "health.json"))
+		c.latency = openLatencyStore(filepath.Join(config.CacheDir(), "latency.json"))
+		if !cfg.Stats.Disabled {
+			c.stats = stats.Open(config.StatsPath())
+		}
+		c.skills = skills.NewStore(config.SkillsDir())
+		c.history = history.Open(config.HistoryPath())
+		c.fewShot = cfg.History.FewShotCount()
+		if !cfg.History.Disabled {
+			c.habits = habit.Open(config.HabitsPath())
+		}
+		c.budget = NewBudget(cfg.Budget.CallLimit(), cfg.Budget.TokenLimit())
+		c.lite = cfg.Lite
+		c.expertise = cfg.Expertise
+		c.shell = shellquote.Name(cfg.Shell)
+		presets, err := localePresets(cfg.Locale)
+		if err != nil {
+			return nil, err
+		}
+		c.presets = presets
+		c.translate = !cfg.Locale.NoTranslate
+		c.snapshot = !cfg.DisableSnapshot
+		c.clock = !cfg.DisableClock
+		c.candidates = cfg.LLM.CandidateCount()
+		c.retry = cfg.LLM.Retry
+		c.timeouts = cfg.Timeouts
+		c.environment = cfg.Context
+		if !cfg.DisableProjectTasks {
+			c.projects = project.NewStore(config.ProjectsDir())
+		}
+		if !cfg.TrustAllWorkspaces {
+			c.trust = trust.Open(config.TrustPath())
+		}
+		if !cfg.DisableNormalize {
+			dict, err := normalize.Load(config.DictionaryPath())
+			if err != nil {
+				return nil, err
+			}
+			c.dictionary = dict
+		}
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if dir := os.Getenv("TERMI_REPLAY"); dir != "" && c.provider == nil {
+		c.provider = newReplayProvider(dir)
+	}
+
+	if c.provider == nil {
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("配置验证失败: %w", err)
+		}
+
+		pcfg := cfg
+		if len(cfg.Experiments) > 0 {
+			c.variant = experiment.Control
+			if exp := experiment.Assign(cfg.Experiments); exp != nil {
+				c.variant = exp.Name
+				c.systemPrompt = exp.Prompt
+				if exp.Model != "" {
+					ecfg := *cfg
+					ecfg.LLM = cfg.LLM.WithModel(exp.Model)
+					pcfg = &ecfg
+				}
+			}
+		}
+
+		// 提供商在第一次请求时才创建，配置已在上面验证过
+		c.provider = lazily(pcfg)
+		if cfg.LLM.Fallback != "" {
+			fcfg := *cfg
+			fcfg.LLM.Provider = cfg.LLM.Fallback
+			c.fallback = lazily(&fcfg)
+		}
+		for _, name := range cfg.LLM.Failover {
+			fcfg := *cfg
+			fcfg.LLM.Provider = name
+			c.failover = append(c.failover, lazily(&fcfg))
+		}
+		for _, name := range cfg.LLM.Configured() {
+			acfg := *cfg
+			acfg.LLM.Provider = name
+			c.alternatives = append(c.alternatives, lazily(&acfg))
+		}
+		c.intents = cfg.Intents
+		for intent, ic := range cfg.Intents {
+			if !ic.Routed() {
+				continue
+			}
+			icfg := *cfg
+			icfg.LLM.Provider = cmp.Or(ic.Provider, cfg.LLM.Provider)
+			if ic.Model != "" {
+				icfg.LLM = icfg.LLM.WithModel(ic.Model)
+			}
+			if c.routes == nil {
+				c.routes = map[config.Intent]Provider{}
+			}
+			c.routes[intent] = lazily(&icfg)
+		}
+
+		if os.Getenv("TERMI_RECORD") == "1" {
+			dir := cmp.Or(os.Getenv("TERMI_RECORD_DIR"), filepath.Join(config.DataDir(), "recordings"))
+			c.provider = newRecordingProvider(c.provider, dir, c.redactor)
+			if c.fallback != nil {
+				c.fallback = newRecordingProvider(c.fallback, dir, c.redactor)
+			}
+			for i, p := range c.failover {
+				c.failover[i] = newRecordingProvider(p, dir, c.redactor)
+			}
+			for i, p := range c.alternatives {
+				c.alternatives[i] = newRecordingProvider(p, dir, c.redactor)
+			}
+			for intent, p := range c.routes {
+				c.routes[intent] = newRecordingProvider(p, dir, c.redactor)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// With 返回应用了额外选项的客户端副本，原客户端不受影响
+func (c *Client) With(opts ...Option) *Client {
+	clone := *c
+	for _, opt := range opts {
+		opt(&clone)
+	}
+	return &clone
+}
+
+// Fallback 返回改用备用提供商的客户端副本，不再按查询意图改用其他提供商；未配置备用提供商时返回 nil
+func (c *Client) Fallback() *Client {
+	if c == nil || c.fallback == nil {
+		return nil
+	}
+	clone := *c
+	clone.provider = c.fallback
+	clone.fallback = nil
+	clone.routes = nil
+	clone.failover = slices.DeleteFunc(slices.Clone(c.failover), func(p Provider) bool { return p.Name() == c.fallback.Name() })
+	return &clone
+}
+
+// Switch 返回改用已配置的提供商 p 的客户端副本，用于主提供商不可用时手动切换后重试，
+// 之后的请求不再按查询意图改用其他提供商；p 没有完整配置或客户端使用指定的提供商创建时返回 nil
+func (c *Client) Switch(p config.LLMProvider) *Client {
+	name := providers.Name(p)
+	i := slices.IndexFunc(c.alternatives, func(a Provider) bool { return a.Name() == name })
+	if i < 0 {
+		return nil
 	}
+	clone := *c
+	clone.provider = c.alternatives[i]
+	clone.fallback = nil
+	clone.routes = nil
+	clone.failover = slices.DeleteFunc(slices.Clone(c.failover), func(f Provider) bool { return f.Name() == name })
+	return &clone
+}
 
-	currentProvider = provider
-	return nil
+// newRedactor 根据配置创建脱敏器
+func newRedactor(rc config.RedactConfig) *redact.Redactor {
+	usernames := rc.Usernames
+	if !rc.KeepUsername {
+		usernames = append([]string{redact.CurrentUsername()}, usernames...)
+	}
+	return redact.New(redact.Options{
+		IPs:       rc.IPs,
+		Tokens:    !rc.KeepTokens,
+		Usernames: usernames,
+	})
 }
 
 // createProvider 根据配置创建相应的 LLM 提供商
@@ -51,35 +409,249 @@ func createProvider(cfg *config.Config) (Provider, error) {
 		return providers.NewClaudeProvider(cfg.LLM.Claude)
 	case config.ProviderLlamaCPP:
 		return providers.NewLlamaCPPProvider(cfg.LLM.LlamaCPP)
+	case config.ProviderOllama:
+		return providers.NewOllamaProvider(cfg.LLM.Ollama)
+	case config.ProviderOpenAICompatible:
+		return providers.NewOpenAICompatibleProvider(cfg.LLM.OpenAICompatible)
+	case config.ProviderCommand:
+		return providers.NewCommandProvider(cfg.LLM.Command)
 	default:
 		return nil, fmt.Errorf("不支持的 LLM 提供商: %s", cfg.LLM.Provider)
 	}
 }
 
+// Variant 返回本次运行在提示词实验中分配到的变体，未配置实验时返回空字符串
+func (c *Client) Variant() string {
+	return c.variant
+}
+
 // Enabled 返回是否已正确配置 LLM
-func Enabled() bool {
-	return currentProvider != nil && currentProvider.Enabled()
+func (c *Client) Enabled() bool {
+	return c != nil && c.provider != nil && c.provider.Enabled()
+}
+
+// AskSmart 根据用户 query 返回包含 command 或 ask 的响应
+// 如果需要更多信息，则 Ask 字段非空；模型发起的探测请求在内部处理完毕
+func (c *Client) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
+	if c == nil || c.provider == nil {
+		return nil, fmt.Errorf("LLM 提供商未初始化")
+	}
+
+	if !c.provider.Enabled() {
+		return nil, fmt.Errorf("LLM 提供商 %s 未正确配置", c.provider.Name())
+	}
+
+	c, intent := c.routed(ctx, prompt)
+	ctx, span := telemetry.Start(ctx, "llm.analyze",
+		attribute.String("llm.provider", c.provider.Name()),
+		attribute.String("termi.variant", c.variant),
+		attribute.String("termi.intent", string(intent)))
+	ctx, cancel := c.withDeadlines(ctx)
+	defer cancel()
+	reply, err := c.askSmart(ctx, prompt)
+	err = deadlineErr(ctx, err)
+	telemetry.End(span, err)
+	if reply != nil {
+		reply.Intent = intent
+	}
+	return reply, err
+}
+
+// askSmart 组装提示词并处理模型发起的探测请求，直到得到命令或追问
+func (c *Client) askSmart(ctx context.Context, prompt string) (*Reply, error) {
+	userland := c.detectUserland(ctx)
+	prompt, err := c.buildPrompt(ctx, prompt, userland)
+	if err != nil {
+		return nil, err
+	}
+	for round := 0; ; round++ {
+		reply, err := c.ask(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if reply.Need == nil {
+			c.adaptCommand(reply, userland)
+			c.applyHabits(reply)
+			return reply, nil
+		}
+		if round >= c.maxProbeRounds {
+			return nil, fmt.Errorf("LLM 探测次数超过上限 (%d)", c.maxProbeRounds)
+		}
+		prompt += "\n" + c.runProbe(ctx, reply.Need.Run)
+	}
+}
+
+// buildPrompt 把终端输出、管道输入、附加文件与环境信息等上下文附加到用户需求，得到发送给模型的提示词
+func (c *Client) buildPrompt(ctx context.Context, prompt string, userland coreutils.Flavor) (string, error) {
+	prompt = c.normalized(ctx, prompt)
+	query := prompt
+	prompt, err := c.withTerminalOutput(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	prompt, err = c.withPipedInput(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	prompt, err = c.withFiles(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	if !c.lite {
+		prompt, err = c.withSkills(prompt, query)
+		if err != nil {
+			return "", err
+		}
+		prompt = c.withExamples(prompt, query)
+		prompt = c.withPresets(prompt, userland)
+		prompt = c.withEnvironment(ctx, prompt)
+		prompt = c.withSnapshot(ctx, prompt, query)
+		prompt = c.withObjects(ctx, prompt)
+		prompt = c.withToolchains(prompt)
+		prompt = c.withProjectTasks(prompt)
+	}
+	prompt = c.withClock(ctx, prompt, query)
+	prompt = c.withHostContext(prompt)
+	prompt = c.withPinned(prompt)
+	prompt = c.withHabits(prompt)
+	return prompt, nil
+}
+
+// withSkills 将与查询匹配的技能包以及查询意图配置的技能包附加到提示词。
+// 只按用户的查询匹配，终端输出、管道输入与附加文件中的词语不会引入无关的技能包
+func (c *Client) withSkills(prompt, query string) (string, error) {
+	matched, err := c.skills.Match(query)
+	if err != nil {
+		return "", fmt.Errorf("加载技能包失败: %w", err)
+	}
+	if names := c.intents[c.intent].Skills; len(names) > 0 {
+		named, err := c.skills.Named(names)
+		if err != nil {
+			return "", fmt.Errorf("加载技能包失败: %w", err)
+		}
+		for _, sk := range named {
+			if !slices.ContainsFunc(matched, func(m skills.Skill) bool { return m.Name == sk.Name }) {
+				matched = append(matched, sk)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return prompt, nil
+	}
+	return prompt + "\n\n参考以下技能包中的约束与示例:\n" + skills.Render(matched), nil
+}
+
+// localePresets 返回配置指定或自动检测的平台与地区预设
+func localePresets(lc config.LocaleConfig) ([]string, error) {
+	if lc.Disabled {
+		return nil, nil
+	}
+	if len(lc.Presets) == 0 {
+		return locale.Detect(), nil
+	}
+	for _, name := range lc.Presets {
+		if !locale.Known(name) {
+			return nil, fmt.Errorf("未知的提示词预设: %s", name)
+		}
+	}
+	return lc.Presets, nil
+}
+
+// withPresets 附加本机平台、地区与核心工具版本的约定；远程执行时本机的检测结果不适用
+func (c *Client) withPresets(prompt string, userland coreutils.Flavor) string {
+	if c.host != "" {
+		return prompt
+	}
+	if hints := locale.Render(c.presets); hints != "" {
+		prompt += "\n\n" + hints
+	}
+	if hint := coreutils.Hint(userland); hint != "" {
+		prompt += "\n" + hint
+	}
+	return prompt
 }
 
-// AskSmart 根据用户 query 返回 command 或 ask
-// 如果需要更多信息，则 ask 字段非空
-func AskSmart(prompt string) (command string, ask string, err error) {
-	if currentProvider == nil {
-		return "", "", fmt.Errorf("LLM 提供商未初始化")
+// withExamples 将用户历史中与查询相似的已接受命令作为示例附加到提示词，帮助模型贴合用户习惯。
+// 只按用户的查询挑选示例，不受附加的日志等内容影响；历史中的查询与命令可能含有令牌或密码，需先脱敏
+func (c *Client) withExamples(prompt, query string) string {
+	if c.history == nil || c.fewShot <= 0 {
+		return prompt
+	}
+	entries, err := c.history.Load()
+	if err != nil {
+		return prompt
+	}
+	similar := history.Similar(entries, query, c.fewShot)
+	if len(similar) == 0 {
+		return prompt
+	}
+
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\n用户过去接受过的类似命令（参考其偏好的工具和参数习惯）:")
+	for _, e := range similar {
+		fmt.Fprintf(&b, "\n- 需求: %s → 命令: %s", c.redactor.Redact(e.Query), c.redactor.Redact(e.Final()))
+		if e.Edited != "" {
+			// 用户对生成结果的修改最能说明其偏好
+			fmt.Fprintf(&b, "（生成的是 %s，用户修改后执行）", c.redactor.Redact(e.Command))
+		}
 	}
+	return b.String()
+}
 
-	if !currentProvider.Enabled() {
-		return "", "", fmt.Errorf("LLM 提供商 %s 未正确配置", currentProvider.Name())
+// prepareOutput 对回传给 LLM 的命令输出脱敏，并在需要时请求用户确认
+func (c *Client) prepareOutput(ctx context.Context, text string) (string, bool) {
+	text = c.redactor.Redact(text)
+	if !c.requireApproval {
+		return text, true
 	}
+	if c.approver == nil {
+		return text, false
+	}
+	return text, c.approver(ctx, text)
+}
 
-	ctx := context.Background()
-	return currentProvider.AskSmart(ctx, prompt)
+// Presets 返回附加到提示词中的平台与地区预设
+func (c *Client) Presets() []string {
+	return c.presets
 }
 
-// GetProviderName 返回当前提供商名称
-func GetProviderName() string {
-	if currentProvider == nil {
+// ProviderName 返回当前提供商名称
+func (c *Client) ProviderName() string {
+	if c == nil || c.provider == nil {
 		return "未知"
 	}
-	return currentProvider.Name()
+	return c.provider.Name()
+}
+
+// runProbe 执行模型请求的只读探测，并格式化为可回传给模型的文本
+func (c *Client) runProbe(ctx context.Context, cmdStr string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[探测结果] $ %s\n", cmdStr)
+
+	var out string
+	var err error
+	switch {
+	case c.host != "":
+		out, err = c.probeRemote(ctx, cmdStr)
+	case probe.Allowed(cmdStr):
+		out, err = c.probes.Run(ctx, cmdStr)
+		_ = c.probes.Save()
+	default:
+		b.WriteString("拒绝执行：该命令不在只读探测白名单内，请改用其他方式或直接向用户提问")
+		return b.String()
+	}
+	if out != "" {
+		b.WriteString(out)
+		b.WriteString("\n")
+	}
+	if err != nil {
+		fmt.Fprintf(&b, "(执行失败: %v)", err)
+	}
+
+	text, ok := c.prepareOutput(ctx, strings.TrimSpace(b.String()))
+	if !ok {
+		return fmt.Sprintf("[探测结果] $ %s\n用户拒绝发送探测结果，请直接向用户提问", cmdStr)
+	}
+	return text
 }
@@ -0,0 +1,37 @@
+package llm
+
+import (
+	"context"
+	"sync"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// Endpoint 已配置的提供商及其服务地址
+type Endpoint = providers.Endpoint
+
+// ProbeResult 连通性检查的结果
+type ProbeResult = providers.ProbeResult
+
+// NetworkCheck 一个提供商的连通性检查
+type NetworkCheck struct {
+	Endpoint
+	ProbeResult
+}
+
+// CheckNetwork 并发检查配置中每个提供商能否按各自的代理设置连上服务地址
+func CheckNetwork(ctx context.Context, lc *config.LLMConfig) []NetworkCheck {
+	endpoints := providers.Endpoints(lc)
+	checks := make([]NetworkCheck, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks[i] = NetworkCheck{Endpoint: e, ProbeResult: providers.Probe(ctx, e)}
+		}()
+	}
+	wg.Wait()
+	return checks
+}
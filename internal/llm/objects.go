@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"termi.sh/termi/internal/objects"
+)
+
+// WithObjects 附加上一条 PowerShell、Nushell 命令输出的结构化对象，发送前同样经过脱敏与确认
+func WithObjects(o objects.Output) Option {
+	return func(c *Client) {
+		c.objects = &o
+	}
+}
+
+// withObjects 把上一条命令的输出对象以 JSON 附加到提示词，使"结束其中占用内存最多的进程"
+// 这类后续需求能按准确的字段名与取值生成命令；用户拒绝发送时按原提示词继续
+func (c *Client) withObjects(ctx context.Context, prompt string) string {
+	if c.objects == nil {
+		return prompt
+	}
+	text, ok := c.prepareOutput(ctx, c.objects.JSON)
+	if !ok {
+		return prompt
+	}
+	count := fmt.Sprintf("共 %d 个", c.objects.Total)
+	if c.objects.Kept < c.objects.Total {
+		count += fmt.Sprintf("，只列出前 %d 个", c.objects.Kept)
+	}
+	command := c.redactor.Redact(c.objects.Command)
+	return fmt.Sprintf("%s\n\n上一条命令 `%s` 输出的对象（JSON，%s）:\n```json\n%s\n```\n需求涉及这些数据时，按其中的字段名与取值生成命令，不要从表格文本中猜测字段。", prompt, command, count, text)
+}
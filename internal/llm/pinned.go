@@ -0,0 +1,26 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithPinned 设置本次会话中固定的上下文（如"目标主机是 10.0.0.5"），每次请求都会附带
+func WithPinned(facts []string) Option {
+	return func(c *Client) {
+		c.pinned = facts
+	}
+}
+
+// withPinned 在提示词前附加用户固定的上下文；它们是用户明确给出的约束，低带宽模式下同样附加
+func (c *Client) withPinned(prompt string) string {
+	if len(c.pinned) == 0 {
+		return prompt
+	}
+	var b strings.Builder
+	b.WriteString("用户固定的上下文（生成命令时必须遵守）:\n")
+	for _, fact := range c.pinned {
+		fmt.Fprintf(&b, "- %s\n", fact)
+	}
+	return b.String() + "\n" + prompt
+}
@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"termi.sh/termi/internal/piped"
+)
+
+// WithPipedInput 附加通过管道传给 termi 的内容（日志、报错输出、文件片段），发送前同样经过脱敏与确认
+func WithPipedInput(in piped.Input) Option {
+	return func(c *Client) {
+		c.piped = in
+	}
+}
+
+// PipedInput 返回附加的管道内容，没有时 Text 为空
+func (c *Client) PipedInput() piped.Input {
+	return c.piped
+}
+
+// withPipedInput 将管道内容附加到提示词，截断时告诉模型只看到了哪一部分
+func (c *Client) withPipedInput(ctx context.Context, prompt string) (string, error) {
+	if c.piped.Text == "" {
+		return prompt, nil
+	}
+	text, ok := c.prepareOutput(ctx, c.piped.Text)
+	if !ok {
+		return "", fmt.Errorf("用户拒绝发送管道输入")
+	}
+	note := ""
+	if c.piped.Truncated {
+		note = fmt.Sprintf("（原内容共 %d 字节，过长，只保留了%s部分）", c.piped.Size, c.piped.Kept())
+	}
+	return fmt.Sprintf("%s\n\n用户通过管道提供的内容%s，可能是日志、报错输出或文件片段，请结合它理解需求:\n```\n%s\n```", prompt, note, text), nil
+}
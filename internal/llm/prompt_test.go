@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"termi.sh/termi/internal/coreutils"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/piped"
+	"termi.sh/termi/internal/redact"
+	"termi.sh/termi/internal/skills"
+)
+
+// newPromptClient 创建只用于组装提示词的客户端
+func newPromptClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	t.Setenv("PATH", t.TempDir())
+	c, err := NewClient(nil, append([]Option{WithProvider(stubProvider{})}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestSkillsMatchQueryOnly(t *testing.T) {
+	store := skills.NewStore(t.TempDir())
+	if err := store.Add([]byte("---\nname: kubernetes\nkeywords: kubectl, pod\n---\n先用 kubectl get 确认资源\n")); err != nil {
+		t.Fatal(err)
+	}
+	const marker = "[技能: kubernetes]"
+	log := piped.Input{Text: "error: kubectl: command not found", Size: 33}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{"KeywordInQuery", "查看所有 pod", true},
+		{"KeywordOnlyInPipedInput", "这个报错是什么意思", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newPromptClient(t, WithSkills(store), WithPipedInput(log))
+			prompt, err := c.buildPrompt(context.Background(), tc.query, coreutils.Unknown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(prompt, marker); got != tc.want {
+				t.Errorf("skill attached = %v, want %v; prompt:\n%s", got, tc.want, prompt)
+			}
+		})
+	}
+}
+
+func TestExamples(t *testing.T) {
+	store := history.Open(filepath.Join(t.TempDir(), "history.jsonl"))
+	for _, e := range []history.Entry{
+		{
+			Query:   "调用接口 token=abc123secret",
+			Command: `curl -H "Authorization: Bearer abcdef123456789" https://api.example.com`,
+			Edited:  `curl -H "Authorization: Bearer abcdef123456789" https://api.example.com/v2`,
+			Action:  history.ActionCopied,
+		},
+		{Query: "压缩日志文件", Command: "gzip app.log", Action: history.ActionCopied},
+	} {
+		if err := store.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 管道输入中反复出现另一条历史的查询，不应影响示例的选择
+	log := strings.Repeat("调用接口失败，重试中\n", 50)
+
+	tests := []struct {
+		name        string
+		query       string
+		in          piped.Input
+		want, avoid []string
+	}{
+		{"Redacted", "调用接口", piped.Input{},
+			[]string{"token=[REDACTED]", "Bearer [REDACTED]", "（生成的是 curl"},
+			[]string{"abc123secret", "abcdef123456789"}},
+		{"QueryOnly", "压缩日志文件", piped.Input{Text: log, Size: len(log)},
+			[]string{"命令: gzip app.log"},
+			[]string{"api.example.com"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newPromptClient(t, WithFewShot(store, 1), WithPipedInput(tc.in),
+				WithRedactor(redact.New(redact.Options{Tokens: true})))
+			prompt, err := c.buildPrompt(context.Background(), tc.query, coreutils.Unknown)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tc.want {
+				if !strings.Contains(prompt, s) {
+					t.Errorf("prompt lacks %q:\n%s", s, prompt)
+				}
+			}
+			for _, s := range tc.avoid {
+				if strings.Contains(prompt, s) {
+					t.Errorf("prompt contains %q:\n%s", s, prompt)
+				}
+			}
+		})
+	}
+}
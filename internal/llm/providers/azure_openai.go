@@ -1,10 +1,9 @@
 package providers
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	openai "github.com/sashabaranov/go-openai"
@@ -14,8 +13,9 @@ import (
 
 // AzureOpenAIProvider Azure OpenAI 提供商实现
 type AzureOpenAIProvider struct {
-	client *openai.Client
-	config *config.AzureOpenAIConfig
+	client   *openai.Client
+	config   *config.AzureOpenAIConfig
+	jsonMode *jsonMode
 }
 
 // NewAzureOpenAIProvider 创建 Azure OpenAI 提供商
@@ -36,11 +36,13 @@ func NewAzureOpenAIProvider(cfg *config.AzureOpenAIConfig) (*AzureOpenAIProvider
 		clientConfig.APIVersion = "2023-12-01-preview"
 	}
 
+	clientConfig.HTTPClient = newHTTPClient(cfg.NetworkConfig)
 	client := openai.NewClientWithConfig(clientConfig)
 
 	return &AzureOpenAIProvider{
-		client: client,
-		config: cfg,
+		client:   client,
+		config:   cfg,
+		jsonMode: newJSONMode(false),
 	}, nil
 }
 
@@ -55,7 +57,7 @@ func (p *AzureOpenAIProvider) Enabled() bool {
 }
 
 // AskSmart 根据用户 query 返回 command 或 ask
-func (p *AzureOpenAIProvider) AskSmart(ctx context.Context, prompt string) (command string, ask string, err error) {
+func (p *AzureOpenAIProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
 	timeout := time.Duration(p.config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -64,33 +66,24 @@ func (p *AzureOpenAIProvider) AskSmart(ctx context.Context, prompt string) (comm
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: p.config.DeploymentID, // Azure 使用 deployment ID 作为模型名
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: systemPrompt(),
-			},
-			{Role: openai.ChatMessageRoleUser, Content: prompt},
-		},
-		Temperature:    0.2,
-		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
-	})
+	// Azure 使用 deployment ID 作为模型名
+	resp, err := p.jsonMode.chat(ctx, p.client, p.config.DeploymentID, prompt, p.config.GenerationConfig)
 	if err != nil {
-		return "", "", fmt.Errorf("Azure OpenAI API 调用失败: %w", err)
+		return nil, fmt.Errorf("Azure OpenAI API 调用失败: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", "", fmt.Errorf("Azure OpenAI API 返回空结果")
+		return nil, fmt.Errorf("Azure OpenAI API 返回空结果")
 	}
 
-	var out struct {
-		Command string `json:"command"`
-		Ask     string `json:"ask"`
-	}
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
-		return "", "", fmt.Errorf("解析 Azure OpenAI 响应失败: %w", err)
+	reply, err := decodeReply(ctx, resp.Choices[0].Message.Content)
+	if err != nil {
+		if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+			return nil, truncated("Azure OpenAI", err)
+		}
+		return nil, fmt.Errorf("解析 Azure OpenAI 响应失败: %w", err)
 	}
+	reply.setUsage(cmp.Or(resp.Model, p.config.DeploymentID), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
-	return strings.TrimSpace(out.Command), strings.TrimSpace(out.Ask), nil
+	return reply, nil
 }
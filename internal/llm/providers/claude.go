@@ -1,10 +1,9 @@
 package providers
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -25,7 +24,7 @@ func NewClaudeProvider(cfg *config.ClaudeConfig) (*ClaudeProvider, error) {
 		return nil, fmt.Errorf("Claude API Key 未配置")
 	}
 
-	options := []option.RequestOption{option.WithAPIKey(cfg.APIKey)}
+	options := []option.RequestOption{option.WithAPIKey(cfg.APIKey), option.WithHTTPClient(newHTTPClient(cfg.NetworkConfig))}
 
 	// 设置自定义 BaseURL（如果提供）
 	if cfg.BaseURL != "" {
@@ -51,7 +50,7 @@ func (p *ClaudeProvider) Enabled() bool {
 }
 
 // AskSmart 根据用户 query 返回 command 或 ask
-func (p *ClaudeProvider) AskSmart(ctx context.Context, prompt string) (command string, ask string, err error) {
+func (p *ClaudeProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
 	timeout := time.Duration(p.config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -66,25 +65,26 @@ func (p *ClaudeProvider) AskSmart(ctx context.Context, prompt string) (command s
 	}
 
 	message, err := p.client.Messages.New(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(model),
-		MaxTokens: int64(1000),
+		Model:         anthropic.Model(model),
+		MaxTokens:     int64(maxTokens(ctx, p.config.OutputTokens(1000))),
+		StopSequences: p.config.Stop,
 		System: []anthropic.TextBlockParam{
 			{
 				Type: "text",
-				Text: systemPrompt(),
+				Text: systemPrompt(ctx),
 			},
 		},
 		Messages: []anthropic.MessageParam{
 			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
 		},
-		Temperature: anthropic.Float(0.2),
+		Temperature: anthropic.Float(temperature(ctx)),
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("Claude API 调用失败: %w", err)
+		return nil, fmt.Errorf("Claude API 调用失败: %w", err)
 	}
 
 	if len(message.Content) == 0 {
-		return "", "", fmt.Errorf("Claude API 返回空结果")
+		return nil, fmt.Errorf("Claude API 返回空结果")
 	}
 
 	// 提取响应文本
@@ -96,17 +96,18 @@ func (p *ClaudeProvider) AskSmart(ctx context.Context, prompt string) (command s
 	}
 
 	if responseText == "" {
-		return "", "", fmt.Errorf("Claude API 返回空文本")
+		return nil, fmt.Errorf("Claude API 返回空文本")
 	}
 
 	// 解析 JSON 响应
-	var out struct {
-		Command string `json:"command"`
-		Ask     string `json:"ask"`
-	}
-	if err := json.Unmarshal([]byte(responseText), &out); err != nil {
-		return "", "", fmt.Errorf("解析 Claude 响应失败: %w, 原始响应: %s", err, responseText)
+	reply, err := decodeReply(ctx, responseText)
+	if err != nil {
+		if message.StopReason == anthropic.StopReasonMaxTokens {
+			return nil, truncated("Claude", err)
+		}
+		return nil, fmt.Errorf("解析 Claude 响应失败: %w, 原始响应: %s", err, responseText)
 	}
+	reply.setUsage(cmp.Or(string(message.Model), model), int(message.Usage.InputTokens), int(message.Usage.OutputTokens))
 
-	return strings.TrimSpace(out.Command), strings.TrimSpace(out.Ask), nil
+	return reply, nil
 }
@@ -0,0 +1,142 @@
+package providers
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+)
+
+// CommandProvider 外部命令提供商实现：每次请求运行一次用户指定的程序，
+// 请求以 JSON 写入标准输入，从标准输出读取 command/ask JSON
+type CommandProvider struct {
+	config *config.CommandConfig
+}
+
+// commandRequest 写入外部程序标准输入的请求
+type commandRequest struct {
+	Model       string   `json:"model,omitempty"`
+	System      string   `json:"system"`
+	Prompt      string   `json:"prompt"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Temperature float64  `json:"temperature"`
+}
+
+// commandStatus 外部程序在响应中附带的错误与用量，与 command/ask 字段写在同一个 JSON 对象中
+type commandStatus struct {
+	// Error 非空时表示请求失败，Status 为对应的 HTTP 状态码（如 429），用于判断是否重试
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+	Model  string `json:"model"`
+	Usage  struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// NewCommandProvider 创建外部命令提供商
+func NewCommandProvider(cfg *config.CommandConfig) (*CommandProvider, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("外部命令 path 未配置")
+	}
+	return &CommandProvider{config: cfg}, nil
+}
+
+// Name 返回提供商名称
+func (p *CommandProvider) Name() string {
+	return "外部命令"
+}
+
+// Enabled 返回是否已正确配置
+func (p *CommandProvider) Enabled() bool {
+	return p.config != nil && p.config.Path != ""
+}
+
+// AskSmart 根据用户 query 返回 command 或 ask
+func (p *CommandProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
+	timeout := time.Duration(p.config.Timeout) * time.Second
+	if timeout == 0 {
+		// 外部程序可能自己调用本地模型，默认超时与本地提供商一致
+		timeout = 60 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	input, err := json.Marshal(commandRequest{
+		Model:       p.config.Model,
+		System:      systemPrompt(ctx) + jsonInstruction,
+		Prompt:      prompt,
+		MaxTokens:   maxTokens(ctx, p.config.OutputTokens(0)),
+		Stop:        p.config.Stop,
+		Temperature: temperature(ctx),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	path := expandHome(p.config.Path)
+	cmd := exec.CommandContext(ctx, path, p.config.Args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("外部命令 %s 超时（%s）: %w", p.config.Path, timeout, ctx.Err())
+		}
+		message := fmt.Sprintf("外部命令 %s 执行失败: %v", p.config.Path, err)
+		if msg := lastLine(stderr.String()); msg != "" {
+			message += ": " + msg
+		}
+		return nil, errors.New(message)
+	}
+
+	responseText := strings.TrimSpace(stdout.String())
+	if responseText == "" {
+		return nil, fmt.Errorf("外部命令 %s 没有输出", p.config.Path)
+	}
+
+	var status commandStatus
+	if err := json.Unmarshal([]byte(extractJSON(responseText)), &status); err != nil {
+		return nil, fmt.Errorf("解析外部命令输出失败: %w, 原始输出: %s", err, responseText)
+	}
+	if status.Error != "" {
+		return nil, &StatusError{Code: status.Status, Message: "外部命令返回错误: " + status.Error}
+	}
+
+	reply, err := decodeReply(ctx, responseText)
+	if err != nil {
+		return nil, fmt.Errorf("解析外部命令输出失败: %w, 原始输出: %s", err, responseText)
+	}
+	reply.setUsage(cmp.Or(status.Model, p.config.Model), status.Usage.PromptTokens, status.Usage.CompletionTokens)
+
+	return reply, nil
+}
+
+// lastLine 返回程序错误输出的最后一行，通常是失败原因
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,26 @@
+package providers
+
+import (
+	"context"
+
+	"termi.sh/termi/internal/config"
+)
+
+type expertiseKey struct{}
+
+// WithExpertise 返回附带用户熟练程度的 context，系统提示词会据此调整命令说明的详略
+func WithExpertise(ctx context.Context, e config.Expertise) context.Context {
+	return context.WithValue(ctx, expertiseKey{}, e)
+}
+
+// expertisePrompt 返回按用户熟练程度调整说明详略的要求，默认程度时返回空字符串
+func expertisePrompt(ctx context.Context) string {
+	switch e, _ := ctx.Value(expertiseKey{}).(config.Expertise); e {
+	case config.Beginner:
+		return "\n- 用户是命令行新手：description 用通俗的语言说明命令做什么、会改变什么，必要时解释术语；同等效果下优先使用常见、易读的写法，例如长选项而不是短选项的组合"
+	case config.Expert:
+		return "\n- 用户是熟练的命令行用户：description 尽量简短，省略常识性说明；可以使用简洁的惯用写法"
+	default:
+		return ""
+	}
+}
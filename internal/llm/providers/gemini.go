@@ -1,10 +1,9 @@
 package providers
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"google.golang.org/genai"
@@ -25,8 +24,10 @@ func NewGeminiProvider(cfg *config.GeminiConfig) (*GeminiProvider, error) {
 	}
 
 	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
-		APIKey:  cfg.APIKey,
-		Backend: genai.BackendGeminiAPI,
+		APIKey:      cfg.APIKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: cfg.BaseURL},
+		HTTPClient:  newHTTPClient(cfg.NetworkConfig),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("创建 Gemini 客户端失败: %w", err)
@@ -49,7 +50,7 @@ func (p *GeminiProvider) Enabled() bool {
 }
 
 // AskSmart 根据用户 query 返回 command 或 ask
-func (p *GeminiProvider) AskSmart(ctx context.Context, prompt string) (command string, ask string, err error) {
+func (p *GeminiProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
 	timeout := time.Duration(p.config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -59,31 +60,37 @@ func (p *GeminiProvider) AskSmart(ctx context.Context, prompt string) (command s
 	defer cancel()
 
 	chat, err := p.client.Chats.Create(ctx, p.config.Model, &genai.GenerateContentConfig{
-		Temperature: genai.Ptr[float32](0.2),
+		Temperature:     genai.Ptr(float32(temperature(ctx))),
+		MaxOutputTokens: int32(maxTokens(ctx, p.config.OutputTokens(0))),
+		StopSequences:   p.config.Stop,
 		SystemInstruction: &genai.Content{
 			Parts: []*genai.Part{
-				{Text: systemPrompt()},
+				{Text: systemPrompt(ctx)},
 			},
 			Role: "system",
 		}}, nil)
 	if err != nil {
-		return "", "", fmt.Errorf("创建 Gemini 聊天失败: %w", err)
+		return nil, fmt.Errorf("创建 Gemini 聊天失败: %w", err)
 	}
 
 	result, err := chat.SendMessage(ctx, genai.Part{Text: prompt})
 	if err != nil {
-		return "", "", fmt.Errorf("Gemini API 调用失败: %w", err)
+		return nil, fmt.Errorf("Gemini API 调用失败: %w", err)
 	}
 
 	responseText := result.Text()
 	// 解析 JSON 响应
-	var out struct {
-		Command string `json:"command"`
-		Ask     string `json:"ask"`
+	reply, err := decodeReply(ctx, responseText)
+	if err != nil {
+		if len(result.Candidates) > 0 && result.Candidates[0].FinishReason == genai.FinishReasonMaxTokens {
+			return nil, truncated("Gemini", err)
+		}
+		return nil, fmt.Errorf("解析 Gemini 响应失败: %w, 原始响应: %s", err, responseText)
 	}
-	if err := json.Unmarshal([]byte(responseText), &out); err != nil {
-		return "", "", fmt.Errorf("解析 Gemini 响应失败: %w, 原始响应: %s", err, responseText)
+	reply.Model = p.config.Model
+	if u := result.UsageMetadata; u != nil {
+		reply.setUsage(cmp.Or(result.ModelVersion, p.config.Model), int(u.PromptTokenCount), int(u.TotalTokenCount-u.PromptTokenCount))
 	}
 
-	return strings.TrimSpace(out.Command), strings.TrimSpace(out.Ask), nil
+	return reply, nil
 }
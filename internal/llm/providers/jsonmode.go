@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"termi.sh/termi/internal/config"
+)
+
+// jsonInstruction 不使用结构化输出时追加到系统提示词，要求模型只输出 JSON
+const jsonInstruction = "\n\n只输出一个 JSON 对象，不要输出任何解释文字或 Markdown 代码块。"
+
+// chatCompleter OpenAI 兼容的 Chat Completions 客户端
+type chatCompleter interface {
+	CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error)
+}
+
+// jsonMode 记录端点是否支持 response_format: json_object。
+// 部分 OpenAI 兼容端点会拒绝该参数，首次被拒后改用提示词约束并在之后的请求中不再尝试
+type jsonMode struct {
+	unsupported atomic.Bool
+}
+
+// newJSONMode 创建 JSON 模式状态，disabled 表示从一开始就不使用结构化输出
+func newJSONMode(disabled bool) *jsonMode {
+	j := &jsonMode{}
+	j.unsupported.Store(disabled)
+	return j
+}
+
+// chat 发起一次 Chat Completions 请求，结构化输出被拒绝时自动降级重试
+func (j *jsonMode) chat(ctx context.Context, client chatCompleter, model, prompt string, gen config.GenerationConfig) (openai.ChatCompletionResponse, error) {
+	structured := !j.unsupported.Load()
+	resp, err := client.CreateChatCompletion(ctx, chatRequest(ctx, model, prompt, gen, structured))
+	if err != nil && structured && rejectsJSONMode(err) {
+		j.unsupported.Store(true)
+		resp, err = client.CreateChatCompletion(ctx, chatRequest(ctx, model, prompt, gen, false))
+	}
+	return resp, err
+}
+
+// chatRequest 构建请求，structured 为 false 时通过提示词约束输出格式
+func chatRequest(ctx context.Context, model, prompt string, gen config.GenerationConfig, structured bool) openai.ChatCompletionRequest {
+	system := systemPrompt(ctx)
+	var format *openai.ChatCompletionResponseFormat
+	if structured {
+		format = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+	} else {
+		system += jsonInstruction
+	}
+
+	return openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: system,
+			},
+			{Role: openai.ChatMessageRoleUser, Content: prompt},
+		},
+		Temperature:    float32(temperature(ctx)),
+		MaxTokens:      maxTokens(ctx, gen.OutputTokens(0)),
+		Stop:           gen.Stop,
+		ResponseFormat: format,
+	}
+}
+
+// rejectsJSONMode 判断错误是否可能由端点不支持 response_format 引起
+func rejectsJSONMode(err error) bool {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return isBadRequest(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return isBadRequest(reqErr.HTTPStatusCode)
+	}
+	return false
+}
+
+func isBadRequest(code int) bool {
+	return code == http.StatusBadRequest || code == http.StatusUnprocessableEntity
+}
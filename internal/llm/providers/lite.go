@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+)
+
+// liteMaxTokens 低带宽模式下单次响应的最大 token 数，一条命令的 JSON 足够
+const liteMaxTokens = 200
+
+type liteKey struct{}
+
+// WithLite 返回标记为低带宽模式的 context，提供商会改用精简的系统提示词并限制输出长度
+func WithLite(ctx context.Context) context.Context {
+	return context.WithValue(ctx, liteKey{}, true)
+}
+
+// isLite 判断请求是否处于低带宽模式
+func isLite(ctx context.Context) bool {
+	lite, _ := ctx.Value(liteKey{}).(bool)
+	return lite
+}
+
+// maxTokens 返回请求的最大输出 token 数，低带宽模式下使用更小的上限，否则使用配置值
+func maxTokens(ctx context.Context, configured int) int {
+	if isLite(ctx) {
+		return liteMaxTokens
+	}
+	return configured
+}
+
+// truncated 返回响应因达到输出上限被截断时的错误，提示用户调大 max_tokens
+func truncated(name string, err error) error {
+	return fmt.Errorf("%s 响应达到 max_tokens 上限被截断，请在配置中调大 max_tokens: %w", name, err)
+}
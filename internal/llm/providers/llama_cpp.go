@@ -2,6 +2,7 @@ package providers
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -29,11 +30,12 @@ func NewLlamaCPPProvider(cfg *config.LlamaCPPConfig) (*LlamaCPPProvider, error)
 		timeout = 30 * time.Second
 	}
 
+	httpClient := newHTTPClient(cfg.NetworkConfig)
+	httpClient.Timeout = timeout
+
 	return &LlamaCPPProvider{
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		config: cfg,
+		httpClient: httpClient,
+		config:     cfg,
 	}, nil
 }
 
@@ -48,7 +50,7 @@ func (p *LlamaCPPProvider) Enabled() bool {
 }
 
 // AskSmart 根据用户 query 返回 command 或 ask
-func (p *LlamaCPPProvider) AskSmart(ctx context.Context, prompt string) (command string, ask string, err error) {
+func (p *LlamaCPPProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
 	timeout := time.Duration(p.config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -62,60 +64,64 @@ func (p *LlamaCPPProvider) AskSmart(ctx context.Context, prompt string) (command
 
 	fullPrompt := fmt.Sprintf(`%s
 用户需求: %s
-请直接返回JSON格式的响应：`, systemPrompt(), prompt)
+请直接返回JSON格式的响应：`, systemPrompt(ctx), prompt)
 
 	reqBody := map[string]interface{}{
 		"prompt":      fullPrompt,
-		"max_tokens":  1000,
-		"temperature": 0.2,
+		"max_tokens":  maxTokens(ctx, p.config.OutputTokens(1000)),
+		"temperature": temperature(ctx),
 		"top_p":       0.8,
-		"stop":        []string{"<|im_end|>", "\n\n"},
+		"stop":        p.config.StopSequences([]string{"<|im_end|>", "\n\n"}),
 		"stream":      false,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", "", fmt.Errorf("构建请求失败: %w", err)
+		return nil, fmt.Errorf("构建请求失败: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", "", fmt.Errorf("创建请求失败: %w", err)
+		return nil, fmt.Errorf("创建请求失败: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
-		return "", "", fmt.Errorf("Llama-cpp API 调用失败: %w", err)
+		return nil, fmt.Errorf("Llama-cpp API 调用失败: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("Llama-cpp API 返回错误状态: %d", resp.StatusCode)
+		return nil, &StatusError{Code: resp.StatusCode, Message: fmt.Sprintf("Llama-cpp API 返回错误状态: %d", resp.StatusCode)}
 	}
 
 	var llamaResp struct {
-		Content string `json:"content"`
+		Content         string `json:"content"`
+		TokensEvaluated int    `json:"tokens_evaluated"`
+		TokensPredicted int    `json:"tokens_predicted"`
+		StoppedLimit    bool   `json:"stopped_limit"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&llamaResp); err != nil {
-		return "", "", fmt.Errorf("解析 Llama-cpp 响应失败: %w", err)
+		return nil, fmt.Errorf("解析 Llama-cpp 响应失败: %w", err)
 	}
 
 	responseText := strings.TrimSpace(llamaResp.Content)
 	if responseText == "" {
-		return "", "", fmt.Errorf("Llama-cpp API 返回空文本")
+		return nil, fmt.Errorf("Llama-cpp API 返回空文本")
 	}
 
 	// 解析 JSON 响应
-	var out struct {
-		Command string `json:"command"`
-		Ask     string `json:"ask"`
-	}
-	if err := json.Unmarshal([]byte(responseText), &out); err != nil {
-		return "", "", fmt.Errorf("解析 Llama-cpp 响应失败: %w, 原始响应: %s", err, responseText)
+	reply, err := decodeReply(ctx, responseText)
+	if err != nil {
+		if llamaResp.StoppedLimit {
+			return nil, truncated("Llama-cpp", err)
+		}
+		return nil, fmt.Errorf("解析 Llama-cpp 响应失败: %w, 原始响应: %s", err, responseText)
 	}
+	reply.setUsage(cmp.Or(p.config.Model, "llama.cpp"), llamaResp.TokensEvaluated, llamaResp.TokensPredicted)
 
-	return strings.TrimSpace(out.Command), strings.TrimSpace(out.Ask), nil
+	return reply, nil
 }
@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"termi.sh/termi/internal/config"
+)
+
+// ProxyFunc 返回按提供商网络设置选择代理的函数：no_proxy 时直连；设置了 proxy 时
+// 除 NO_PROXY 中的主机外都经过它；否则与其他程序一样取 HTTPS_PROXY、HTTP_PROXY 与 NO_PROXY
+func ProxyFunc(nc config.NetworkConfig) func(*http.Request) (*url.URL, error) {
+	switch {
+	case nc.NoProxy:
+		return nil
+	case nc.Proxy != "":
+		proxy := (&httpproxy.Config{
+			HTTPProxy:  nc.Proxy,
+			HTTPSProxy: nc.Proxy,
+			NoProxy:    cmp.Or(os.Getenv("NO_PROXY"), os.Getenv("no_proxy")),
+		}).ProxyFunc()
+		return func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+	return http.ProxyFromEnvironment
+}
+
+// newHTTPClient 创建按提供商网络设置选择代理、附加自定义请求头的 HTTP 客户端，其余传输参数与默认客户端相同
+func newHTTPClient(nc config.NetworkConfig) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = ProxyFunc(nc)
+	if len(nc.Headers) == 0 {
+		return &http.Client{Transport: transport}
+	}
+	return &http.Client{Transport: &headerTransport{base: transport, headers: nc.Headers}}
+}
+
+// headerTransport 为每个请求附加配置中的请求头，各提供商的 SDK 都经过它发出请求
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper 不应修改调用方的请求，复制后再设置
+	req = req.Clone(req.Context())
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// 未配置地址时各提供商 SDK 使用的官方地址
+const (
+	defaultOpenAIURL = "https://api.openai.com/v1"
+	defaultClaudeURL = "https://api.anthropic.com"
+	defaultGeminiURL = "https://generativelanguage.googleapis.com"
+)
+
+// names 各提供商的显示名称，与其 Name 方法的返回值相同
+var names = map[config.LLMProvider]string{
+	config.ProviderOpenAI:           "OpenAI",
+	config.ProviderAzureOpenAI:      "Azure OpenAI",
+	config.ProviderGemini:           "Gemini",
+	config.ProviderClaude:           "Claude",
+	config.ProviderLlamaCPP:         "Llama-cpp",
+	config.ProviderOllama:           "Ollama",
+	config.ProviderOpenAICompatible: "OpenAI 兼容端点",
+	config.ProviderCommand:          "外部命令",
+}
+
+// Name 返回提供商的显示名称，不需要创建提供商；未知的提供商返回配置中的原名
+func Name(p config.LLMProvider) string {
+	if name, ok := names[p]; ok {
+		return name
+	}
+	return string(p)
+}
+
+// Endpoint 已配置的提供商及其服务地址
+type Endpoint struct {
+	Provider config.LLMProvider
+	Name     string
+	URL      string
+	Network  config.NetworkConfig
+}
+
+// Endpoints 按配置中的顺序列出已配置的提供商，未配置地址的使用官方地址
+func Endpoints(lc *config.LLMConfig) []Endpoint {
+	var out []Endpoint
+	if c := lc.OpenAI; c != nil {
+		out = append(out, Endpoint{config.ProviderOpenAI, Name(config.ProviderOpenAI), cmp.Or(c.BaseURL, defaultOpenAIURL), c.NetworkConfig})
+	}
+	if c := lc.AzureOpenAI; c != nil {
+		out = append(out, Endpoint{config.ProviderAzureOpenAI, Name(config.ProviderAzureOpenAI), c.BaseURL, c.NetworkConfig})
+	}
+	if c := lc.Gemini; c != nil {
+		out = append(out, Endpoint{config.ProviderGemini, Name(config.ProviderGemini), cmp.Or(c.BaseURL, defaultGeminiURL), c.NetworkConfig})
+	}
+	if c := lc.Claude; c != nil {
+		out = append(out, Endpoint{config.ProviderClaude, Name(config.ProviderClaude), cmp.Or(c.BaseURL, defaultClaudeURL), c.NetworkConfig})
+	}
+	if c := lc.LlamaCPP; c != nil {
+		out = append(out, Endpoint{config.ProviderLlamaCPP, Name(config.ProviderLlamaCPP), c.BaseURL, c.NetworkConfig})
+	}
+	if c := lc.Ollama; c != nil {
+		out = append(out, Endpoint{config.ProviderOllama, Name(config.ProviderOllama), c.Endpoint(), c.NetworkConfig})
+	}
+	if c := lc.OpenAICompatible; c != nil {
+		out = append(out, Endpoint{config.ProviderOpenAICompatible, Name(config.ProviderOpenAICompatible), c.BaseURL, c.NetworkConfig})
+	}
+	return out
+}
+
+// ProbeResult 连通性检查的结果
+type ProbeResult struct {
+	Proxy   string // 实际经过的代理（隐藏鉴权信息），直连时为空
+	Status  int    // 端点返回的 HTTP 状态码，收到任何状态码都说明网络可达
+	Latency time.Duration
+	Err     error
+}
+
+// Probe 按提供商的网络设置请求其服务地址，检查网络与代理是否可用。
+// 不发送 API Key，也不调用模型，不消耗用量
+func Probe(ctx context.Context, e Endpoint) ProbeResult {
+	var r ProbeResult
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.URL, nil)
+	if err != nil {
+		r.Err = fmt.Errorf("地址无效: %w", err)
+		return r
+	}
+	if proxy := ProxyFunc(e.Network); proxy != nil {
+		u, err := proxy(req)
+		if err != nil {
+			r.Err = fmt.Errorf("代理设置无效: %w", err)
+			return r
+		}
+		if u != nil {
+			r.Proxy = u.Redacted()
+		}
+	}
+
+	start := time.Now()
+	resp, err := newHTTPClient(e.Network).Do(req)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	resp.Body.Close()
+	r.Status = resp.StatusCode
+	return r
+}
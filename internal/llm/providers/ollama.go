@@ -0,0 +1,138 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+)
+
+// OllamaProvider Ollama 提供商实现，使用原生的 /api/chat 接口
+type OllamaProvider struct {
+	httpClient *http.Client
+	config     *config.OllamaConfig
+}
+
+// NewOllamaProvider 创建 Ollama 提供商
+func NewOllamaProvider(cfg *config.OllamaConfig) (*OllamaProvider, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("Ollama Model 未配置")
+	}
+
+	return &OllamaProvider{
+		httpClient: newHTTPClient(cfg.NetworkConfig),
+		config:     cfg,
+	}, nil
+}
+
+// Name 返回提供商名称
+func (p *OllamaProvider) Name() string {
+	return "Ollama"
+}
+
+// Enabled 返回是否已正确配置
+func (p *OllamaProvider) Enabled() bool {
+	return p.httpClient != nil && p.config.Model != ""
+}
+
+// AskSmart 根据用户 query 返回 command 或 ask
+func (p *OllamaProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
+	timeout := time.Duration(p.config.Timeout) * time.Second
+	if timeout == 0 {
+		// 本地模型首次调用需要加载到内存，默认超时比云端提供商宽松
+		timeout = 60 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	options := map[string]any{"temperature": temperature(ctx)}
+	if n := maxTokens(ctx, p.config.OutputTokens(0)); n > 0 {
+		options["num_predict"] = n
+	}
+	if len(p.config.Stop) > 0 {
+		options["stop"] = p.config.Stop
+	}
+
+	// format: json 让 Ollama 约束模型只输出合法的 JSON
+	reqBody := map[string]any{
+		"model": p.config.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt(ctx)},
+			{"role": "user", "content": prompt},
+		},
+		"format":  "json",
+		"stream":  false,
+		"options": options,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	url := p.config.Endpoint() + "/api/chat"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Ollama API 调用失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// Ollama 的错误响应为 {"error": "..."}，例如模型尚未 pull 时的 model not found
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&errResp)
+		message := fmt.Sprintf("Ollama API 返回错误状态: %d", resp.StatusCode)
+		if errResp.Error != "" {
+			message += ": " + errResp.Error
+		}
+		statusErr := &StatusError{Code: resp.StatusCode, Message: message}
+		if modelMissing(resp.StatusCode, errResp.Error) {
+			return nil, &ModelNotFoundError{Model: p.config.Model, Endpoint: p.config.Endpoint(), Err: statusErr}
+		}
+		return nil, statusErr
+	}
+
+	var ollamaResp struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		DoneReason      string `json:"done_reason"`
+		PromptEvalCount int    `json:"prompt_eval_count"`
+		EvalCount       int    `json:"eval_count"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+		return nil, fmt.Errorf("解析 Ollama 响应失败: %w", err)
+	}
+
+	responseText := strings.TrimSpace(ollamaResp.Message.Content)
+	if responseText == "" {
+		return nil, fmt.Errorf("Ollama API 返回空文本")
+	}
+
+	reply, err := decodeReply(ctx, responseText)
+	if err != nil {
+		if ollamaResp.DoneReason == "length" {
+			return nil, truncated("Ollama", err)
+		}
+		return nil, fmt.Errorf("解析 Ollama 响应失败: %w, 原始响应: %s", err, responseText)
+	}
+	reply.setUsage(p.config.Model, ollamaResp.PromptEvalCount, ollamaResp.EvalCount)
+
+	return reply, nil
+}
@@ -0,0 +1,103 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+)
+
+// ModelNotFoundError Ollama 服务上没有配置的模型，可以用 PullOllamaModel 让服务下载后重试
+type ModelNotFoundError struct {
+	Model    string
+	Endpoint string
+	Err      error
+}
+
+// Error 实现 error 接口
+func (e *ModelNotFoundError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap 支持错误链，StatusCode 仍能取得 404
+func (e *ModelNotFoundError) Unwrap() error {
+	return e.Err
+}
+
+// modelMissing 判断 /api/chat 的错误响应是否表示模型尚未 pull，
+// Ollama 此时返回 404 与 model "xxx" not found, try pulling it first
+func modelMissing(code int, message string) bool {
+	return code == http.StatusNotFound && strings.Contains(strings.ToLower(message), "not found")
+}
+
+// PullProgress 下载模型时 Ollama 逐行返回的进度，Total 为 0 时当前阶段没有可计量的进度
+type PullProgress struct {
+	Status    string
+	Completed int64
+	Total     int64
+}
+
+// PullOllamaModel 让 cfg 指向的 Ollama 服务下载配置的模型，下载在服务所在的机器上进行。
+// 每收到一条进度就调用 progress；模型较大时可能持续很久，只随 ctx 取消，不使用配置的超时
+func PullOllamaModel(ctx context.Context, cfg *config.OllamaConfig, progress func(PullProgress)) error {
+	jsonData, err := json.Marshal(map[string]any{"model": cfg.Model, "stream": true})
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	url := cfg.Endpoint() + "/api/pull"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := newHTTPClient(cfg.NetworkConfig).Do(req)
+	if err != nil {
+		return fmt.Errorf("Ollama API 调用失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 流式响应为 NDJSON，每行一条进度；出错时同样以 {"error": "..."} 表示，可能出现在任意一行
+	var line struct {
+		Status    string `json:"status"`
+		Completed int64  `json:"completed"`
+		Total     int64  `json:"total"`
+		Error     string `json:"error"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = json.NewDecoder(resp.Body).Decode(&line)
+		message := fmt.Sprintf("Ollama API 返回错误状态: %d", resp.StatusCode)
+		if line.Error != "" {
+			message += ": " + line.Error
+		}
+		return &StatusError{Code: resp.StatusCode, Message: message}
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line.Status, line.Completed, line.Total, line.Error = "", 0, 0, ""
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("解析 Ollama 下载进度失败: %w", err)
+		}
+		if line.Error != "" {
+			return fmt.Errorf("Ollama 下载模型失败: %s", line.Error)
+		}
+		if progress != nil {
+			progress(PullProgress{Status: line.Status, Completed: line.Completed, Total: line.Total})
+		}
+		if line.Status == "success" {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取 Ollama 下载进度失败: %w", err)
+	}
+	return fmt.Errorf("Ollama 下载模型 %s 未完成就断开了连接", cfg.Model)
+}
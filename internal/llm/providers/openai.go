@@ -1,10 +1,10 @@
 package providers
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
+	"net/http"
 	"time"
 
 	openai "github.com/sashabaranov/go-openai"
@@ -13,8 +13,10 @@ import (
 
 // OpenAIProvider OpenAI 提供商实现
 type OpenAIProvider struct {
-	client *openai.Client
-	config *config.OpenAIConfig
+	client     *openai.Client
+	httpClient *http.Client // Responses API 使用
+	config     *config.OpenAIConfig
+	jsonMode   *jsonMode
 }
 
 // NewOpenAIProvider 创建 OpenAI 提供商
@@ -35,11 +37,15 @@ func NewOpenAIProvider(cfg *config.OpenAIConfig) (*OpenAIProvider, error) {
 		clientConfig.OrgID = cfg.OrgID
 	}
 
+	httpClient := newHTTPClient(cfg.NetworkConfig)
+	clientConfig.HTTPClient = httpClient
 	client := openai.NewClientWithConfig(clientConfig)
 
 	return &OpenAIProvider{
-		client: client,
-		config: cfg,
+		client:     client,
+		httpClient: httpClient,
+		config:     cfg,
+		jsonMode:   newJSONMode(cfg.DisableJSONMode),
 	}, nil
 }
 
@@ -54,7 +60,7 @@ func (p *OpenAIProvider) Enabled() bool {
 }
 
 // AskSmart 根据用户 query 返回 command 或 ask
-func (p *OpenAIProvider) AskSmart(ctx context.Context, prompt string) (command string, ask string, err error) {
+func (p *OpenAIProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
 	timeout := time.Duration(p.config.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -68,33 +74,27 @@ func (p *OpenAIProvider) AskSmart(ctx context.Context, prompt string) (command s
 		model = openai.GPT4Dot1Mini
 	}
 
-	resp, err := p.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: model,
-		Messages: []openai.ChatCompletionMessage{
-			{
-				Role:    openai.ChatMessageRoleSystem,
-				Content: systemPrompt(),
-			},
-			{Role: openai.ChatMessageRoleUser, Content: prompt},
-		},
-		Temperature:    0.2,
-		ResponseFormat: &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject},
-	})
+	if p.config.UseResponsesAPI {
+		return p.askResponses(ctx, model, prompt)
+	}
+
+	resp, err := p.jsonMode.chat(ctx, p.client, model, prompt, p.config.GenerationConfig)
 	if err != nil {
-		return "", "", fmt.Errorf("OpenAI API 调用失败: %w", err)
+		return nil, fmt.Errorf("OpenAI API 调用失败: %w", err)
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", "", fmt.Errorf("OpenAI API 返回空结果")
+		return nil, fmt.Errorf("OpenAI API 返回空结果")
 	}
 
-	var out struct {
-		Command string `json:"command"`
-		Ask     string `json:"ask"`
-	}
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &out); err != nil {
-		return "", "", fmt.Errorf("解析 OpenAI 响应失败: %w", err)
+	reply, err := decodeReply(ctx, resp.Choices[0].Message.Content)
+	if err != nil {
+		if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+			return nil, truncated("OpenAI", err)
+		}
+		return nil, fmt.Errorf("解析 OpenAI 响应失败: %w", err)
 	}
+	reply.setUsage(cmp.Or(resp.Model, model), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
-	return strings.TrimSpace(out.Command), strings.TrimSpace(out.Ask), nil
+	return reply, nil
 }
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"termi.sh/termi/internal/config"
+)
+
+// OpenAICompatibleProvider 任意 OpenAI 兼容端点（LM Studio、vLLM、LocalAI 等）的提供商实现
+type OpenAICompatibleProvider struct {
+	client   *openai.Client
+	config   *config.OpenAICompatibleConfig
+	jsonMode *jsonMode
+}
+
+// NewOpenAICompatibleProvider 创建 OpenAI 兼容端点提供商，未配置 API Key 时不发送 Authorization 头
+func NewOpenAICompatibleProvider(cfg *config.OpenAICompatibleConfig) (*OpenAICompatibleProvider, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("OpenAI 兼容端点 Base URL 未配置")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("OpenAI 兼容端点 Model 未配置")
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.APIKey)
+	clientConfig.BaseURL = cfg.BaseURL
+	clientConfig.HTTPClient = newHTTPClient(cfg.NetworkConfig)
+
+	return &OpenAICompatibleProvider{
+		client:   openai.NewClientWithConfig(clientConfig),
+		config:   cfg,
+		jsonMode: newJSONMode(cfg.DisableJSONMode),
+	}, nil
+}
+
+// Name 返回提供商名称
+func (p *OpenAICompatibleProvider) Name() string {
+	return "OpenAI 兼容端点"
+}
+
+// Enabled 返回是否已正确配置
+func (p *OpenAICompatibleProvider) Enabled() bool {
+	return p.client != nil && p.config.BaseURL != "" && p.config.Model != ""
+}
+
+// AskSmart 根据用户 query 返回 command 或 ask
+func (p *OpenAICompatibleProvider) AskSmart(ctx context.Context, prompt string) (*Reply, error) {
+	timeout := time.Duration(p.config.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := p.jsonMode.chat(ctx, p.client, p.config.Model, prompt, p.config.GenerationConfig)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI 兼容端点调用失败: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("OpenAI 兼容端点返回空结果")
+	}
+
+	reply, err := decodeReply(ctx, resp.Choices[0].Message.Content)
+	if err != nil {
+		if resp.Choices[0].FinishReason == openai.FinishReasonLength {
+			return nil, truncated("OpenAI 兼容端点", err)
+		}
+		return nil, fmt.Errorf("解析 OpenAI 兼容端点响应失败: %w", err)
+	}
+	reply.setUsage(cmp.Or(resp.Model, p.config.Model), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+
+	return reply, nil
+}
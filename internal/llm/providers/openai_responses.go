@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultOpenAIBaseURL OpenAI 官方 API 地址
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// responsesTools Responses API 支持的托管工具，默认全部关闭
+var responsesTools = map[string]map[string]any{
+	"web_search":       {"type": "web_search_preview"},
+	"code_interpreter": {"type": "code_interpreter", "container": map[string]any{"type": "auto"}},
+}
+
+// responsesOutput Responses API 响应中与文本输出相关的字段
+type responsesOutput struct {
+	Output []struct {
+		Type    string `json:"type"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Model string `json:"model"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+}
+
+// askResponses 通过 Responses API 请求模型
+func (p *OpenAIProvider) askResponses(ctx context.Context, model, prompt string) (*Reply, error) {
+	reqBody := map[string]any{
+		"model":        model,
+		"instructions": systemPrompt(ctx),
+		"input":        prompt,
+		"temperature":  temperature(ctx),
+		"text": map[string]any{
+			"format": map[string]any{"type": "json_object"},
+		},
+	}
+	if limit := maxTokens(ctx, p.config.OutputTokens(0)); limit > 0 {
+		reqBody["max_output_tokens"] = limit
+	}
+	if len(p.config.Tools) > 0 {
+		tools := make([]map[string]any, 0, len(p.config.Tools))
+		for _, name := range p.config.Tools {
+			tools = append(tools, responsesTools[name])
+		}
+		reqBody["tools"] = tools
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	baseURL := strings.TrimSuffix(p.config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/responses", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	if p.config.OrgID != "" {
+		req.Header.Set("OpenAI-Organization", p.config.OrgID)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI Responses API 调用失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 OpenAI Responses API 响应失败: %w", err)
+	}
+
+	var out responsesOutput
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("解析 OpenAI Responses API 响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Error != nil {
+			return nil, &StatusError{Code: resp.StatusCode, Message: fmt.Sprintf("OpenAI Responses API 返回错误状态 %d: %s", resp.StatusCode, out.Error.Message)}
+		}
+		return nil, &StatusError{Code: resp.StatusCode, Message: fmt.Sprintf("OpenAI Responses API 返回错误状态: %d", resp.StatusCode)}
+	}
+
+	var responseText strings.Builder
+	for _, item := range out.Output {
+		if item.Type != "message" {
+			continue
+		}
+		for _, c := range item.Content {
+			if c.Type == "output_text" {
+				responseText.WriteString(c.Text)
+			}
+		}
+	}
+	if responseText.Len() == 0 {
+		return nil, fmt.Errorf("OpenAI Responses API 返回空文本")
+	}
+
+	reply, err := decodeReply(ctx, responseText.String())
+	if err != nil {
+		if out.IncompleteDetails != nil && out.IncompleteDetails.Reason == "max_output_tokens" {
+			return nil, truncated("OpenAI", err)
+		}
+		return nil, fmt.Errorf("解析 OpenAI 响应失败: %w, 原始响应: %s", err, responseText.String())
+	}
+	reply.setUsage(cmp.Or(out.Model, model), out.Usage.InputTokens, out.Usage.OutputTokens)
+	return reply, nil
+}
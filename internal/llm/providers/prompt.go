@@ -1,20 +1,127 @@
 package providers
 
 import (
+	"context"
 	"fmt"
 	"runtime"
+	"strings"
 )
 
-func systemPrompt() string {
+// SystemPrompt 返回该请求发送给模型的系统提示词，用于录制与调试
+func SystemPrompt(ctx context.Context) string {
+	return systemPrompt(ctx)
+}
+
+type promptKey struct{}
+
+type candidatesKey struct{}
+
+type shellKey struct{}
+
+// WithShell 返回为指定 shell 生成命令的 context，name 为 shell 程序名，例如 zsh、fish、pwsh
+func WithShell(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, shellKey{}, name)
+}
+
+// shellLabel 返回提示词中目标 shell 的名称，未指定时为 Bash
+func shellLabel(ctx context.Context) string {
+	name, _ := ctx.Value(shellKey{}).(string)
+	switch name {
+	case "", "bash":
+		return "Bash"
+	case "pwsh", "powershell":
+		return "PowerShell"
+	case "cmd":
+		return "Windows cmd.exe"
+	case "nu":
+		return "Nushell"
+	case "sh":
+		return "POSIX sh"
+	default:
+		return name
+	}
+}
+
+// shellPrompt 提醒模型使用非 Bash shell 的语法，Bash 时返回空字符串
+func shellPrompt(label string) string {
+	if label == "Bash" {
+		return ""
+	}
+	return fmt.Sprintf("\n命令将直接交给 %s 执行，变量、引号、管道、条件与循环都必须使用 %s 的语法，不要输出 Bash 专有的写法。", label, label)
+}
+
+// defaultCandidates 未设置时请求的候选命令数量
+const defaultCandidates = 3
+
+// WithCandidates 返回请求 n 条候选命令的 context，n 为 1 时只要求一条命令
+func WithCandidates(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, candidatesKey{}, n)
+}
+
+// candidates 返回请求的候选命令数量
+func candidates(ctx context.Context) int {
+	if n, ok := ctx.Value(candidatesKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return defaultCandidates
+}
+
+// WithSystemPrompt 返回使用替代系统提示词的 context，用于提示词实验；{goos} 会替换为操作系统名，
+// {shell} 替换为目标 shell 的名称，末尾同样附加按用户熟练程度调整说明详略的要求。
+// 低带宽模式仍使用精简提示词
+func WithSystemPrompt(ctx context.Context, prompt string) context.Context {
+	return context.WithValue(ctx, promptKey{}, prompt)
+}
+
+func systemPrompt(ctx context.Context) string {
 	goos := runtime.GOOS
+	shell := shellLabel(ctx)
 
-	return fmt.Sprintf(`你是 %s 命令行专家。根据用户需求和对话历史，生成合适的 Bash 命令。
+	if isLite(ctx) {
+		return fmt.Sprintf(`%s %s 专家。只返回 JSON：{"command":"可执行命令"}，信息不足时返回 {"ask":"中文问题"}，不需要执行命令的提问返回 {"answer":"中文回答"}。`, goos, shell)
+	}
 
-如果信息充足，返回 JSON {"command":"..."}，其中 command 是可直接执行的 Bash 命令。
-如果需要更多信息，返回 JSON {"ask":"..."}，ask 用中文向用户提出具体的补充问题。
+	if prompt, _ := ctx.Value(promptKey{}).(string); prompt != "" {
+		return strings.NewReplacer("{goos}", goos, "{shell}", shell).Replace(prompt) + expertisePrompt(ctx)
+	}
+
+	return fmt.Sprintf(`你是 %s 命令行专家。根据用户需求和对话历史，生成合适的 %s 命令。%s
+
+如果信息充足，返回 JSON {"command":"...","approach":"...","description":"...","risk":"low"}，其中 command 是可直接执行的 %s 命令，approach 用简短中文说明实现方式（如"使用 find"、"使用 Python 单行脚本"），description 用一句中文说明命令的作用，risk 是命令的风险等级：low（只读或可轻易撤销）、medium（修改文件或配置）、high（删除数据、影响系统或难以撤销）。%s
+如果需要更多信息，返回 JSON {"ask":"..."}，ask 用中文向用户提出具体的补充问题。需要的是主机、端口、路径、数字等结构化取值时，同时返回 fields，例如 {"ask":"要连接哪台服务器？","fields":[{"name":"host","label":"主机","type":"host"},{"name":"port","label":"端口","type":"port"}]}，type 可选 text、number、port、path、host。
+如果用户只是在询问知识或需要计算（如"退出码 137 是什么意思"、"1 GiB 是多少字节"），不需要执行任何命令，返回 JSON {"answer":"..."}，answer 用中文直接回答，可以使用列表、行内代码等简单的 Markdown；不要为了回答而硬凑一条 echo 命令。
+如果需要了解本机环境（如系统版本、工具是否安装），返回 JSON {"need":{"run":"uname -r"}}，run 必须是只读探测命令，执行结果会在后续消息中以"[探测结果]"提供给你。
 
 注意：
 - 仔细理解用户的完整意图和上下文
 - 如果之前的对话中已经提供了相关信息，请充分利用
-- 生成的命令应该是安全、准确且可执行的`, goos)
+- 能通过探测获得的信息不要询问用户，已有探测结果时不要重复探测
+- 生成的命令应该是安全、准确且可执行的%s`, goos, shell, shellPrompt(shell), shell, alternativesPrompt(candidates(ctx)), expertisePrompt(ctx))
+}
+
+// alternativesPrompt 返回要求模型给出备选命令的说明，n 为候选命令总数
+func alternativesPrompt(n int) string {
+	if n <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(`
+存在其他合理做法时（例如使用不同的工具，或更安全、更快的写法），在 alternatives 中给出至多 %d 条备选命令，格式为 [{"command":"...","approach":"...","description":"...","risk":"..."}]，按推荐程度排序，command 始终是最推荐的一条；没有明显不同的做法时省略 alternatives。`, n-1)
+}
+
+type temperatureKey struct{}
+
+// defaultTemperature 未按意图指定时的采样温度，偏低以得到稳定、可复现的命令
+const defaultTemperature = 0.2
+
+// WithTemperature 返回使用指定采样温度的 context，用于按查询意图调整
+func WithTemperature(ctx context.Context, t float64) context.Context {
+	return context.WithValue(ctx, temperatureKey{}, t)
+}
+
+// temperature 返回请求的采样温度
+func temperature(ctx context.Context) float64 {
+	if t, ok := ctx.Value(temperatureKey{}).(float64); ok {
+		return t
+	}
+	return defaultTemperature
 }
@@ -0,0 +1,81 @@
+package providers_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/llm/providers"
+	"termi.sh/termi/internal/llm/providertest"
+)
+
+// forwardEnv 设置时测试程序作为外部命令提供商运行，值为伪造服务端的地址
+const forwardEnv = "TERMI_PROVIDERTEST_FORWARD"
+
+func TestMain(m *testing.M) {
+	if baseURL := os.Getenv(forwardEnv); baseURL != "" {
+		if err := providertest.Forward(baseURL, os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestOpenAIConformance(t *testing.T) {
+	providertest.Run(t, providertest.OpenAI, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewOpenAIProvider(&config.OpenAIConfig{APIKey: "test", Model: "m", BaseURL: baseURL, Timeout: timeout})
+	})
+}
+
+func TestOpenAICompatibleConformance(t *testing.T) {
+	providertest.Run(t, providertest.OpenAI, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewOpenAICompatibleProvider(&config.OpenAICompatibleConfig{Model: "m", BaseURL: baseURL, Timeout: timeout})
+	})
+}
+
+func TestAzureOpenAIConformance(t *testing.T) {
+	providertest.Run(t, providertest.OpenAI, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewAzureOpenAIProvider(&config.AzureOpenAIConfig{
+			APIKey: "test", BaseURL: baseURL, DeploymentID: "d", APIVersion: "2024-06-01", Timeout: timeout,
+		})
+	})
+}
+
+func TestClaudeConformance(t *testing.T) {
+	providertest.Run(t, providertest.Claude, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewClaudeProvider(&config.ClaudeConfig{APIKey: "test", Model: "m", BaseURL: baseURL, Timeout: timeout})
+	})
+}
+
+func TestGeminiConformance(t *testing.T) {
+	providertest.Run(t, providertest.Gemini, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewGeminiProvider(&config.GeminiConfig{APIKey: "test", Model: "m", BaseURL: baseURL, Timeout: timeout})
+	})
+}
+
+func TestLlamaCPPConformance(t *testing.T) {
+	providertest.Run(t, providertest.LlamaCPP, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewLlamaCPPProvider(&config.LlamaCPPConfig{BaseURL: baseURL, Timeout: timeout})
+	})
+}
+
+func TestOllamaConformance(t *testing.T) {
+	providertest.Run(t, providertest.Ollama, func(baseURL string, timeout int) (llm.Provider, error) {
+		return providers.NewOllamaProvider(&config.OllamaConfig{BaseURL: baseURL, Model: "m", Timeout: timeout})
+	})
+}
+
+func TestCommandConformance(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	providertest.Run(t, providertest.Command, func(baseURL string, timeout int) (llm.Provider, error) {
+		t.Setenv(forwardEnv, baseURL)
+		return providers.NewCommandProvider(&config.CommandConfig{Path: exe, Timeout: timeout})
+	})
+}
@@ -0,0 +1,206 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/telemetry"
+)
+
+// Reply 模型返回的结构化响应
+type Reply struct {
+	// Command 可直接执行的命令
+	Command string `json:"command"`
+	// Approach 命令的实现方式，例如 "使用 find"
+	Approach string `json:"approach,omitempty"`
+	// Description 命令作用的一句话说明
+	Description string `json:"description,omitempty"`
+	// Risk 模型自评的风险等级：low、medium、high
+	Risk string `json:"risk,omitempty"`
+	// Alternatives 其他可行的命令，按推荐程度排序
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+	// Ask 需要向用户补充询问的问题
+	Ask string `json:"ask"`
+	// Fields 问题需要的结构化取值（主机、端口、路径等），为空时按自由文本回答
+	Fields []Field `json:"fields,omitempty"`
+	// Answer 对知识性问题的直接回答，不需要执行命令时代替 Command
+	Answer string `json:"answer,omitempty"`
+	// Assumptions 在信息不足时生成命令所做的假设
+	Assumptions string `json:"assumptions,omitempty"`
+	// Explanation 对终端错误等上下文的解释
+	Explanation string `json:"explanation,omitempty"`
+	// Stages 管道各阶段的一句话说明
+	Stages []string `json:"stages,omitempty"`
+	// Lines 命令逐段的解释
+	Lines []Line `json:"lines,omitempty"`
+	// Rationale 选择某条候选命令的理由
+	Rationale string `json:"rationale,omitempty"`
+	// Tradeoffs 各候选命令在可移植性、速度与安全性上的取舍
+	Tradeoffs []Tradeoff `json:"tradeoffs,omitempty"`
+	// Actions 项目快捷操作
+	Actions []Action `json:"actions,omitempty"`
+	// Need 模型请求执行的本地只读探测
+	Need *Need `json:"need,omitempty"`
+	// Tokens 本次调用消耗的 token 数，提供商未返回用量时为 0
+	Tokens int `json:"-"`
+	// PromptTokens、CompletionTokens 本次调用的输入与输出 token 数
+	PromptTokens     int `json:"-"`
+	CompletionTokens int `json:"-"`
+	// Model 实际回答的模型，提供商返回了模型名时以返回的为准
+	Model string `json:"-"`
+	// Provider 实际回答的提供商名称，主提供商失败后改用 failover 时与主提供商不同
+	Provider string `json:"-"`
+	// Intent 识别出的查询意图，按 intents 配置路由；未配置 intents 时为空
+	Intent config.Intent `json:"-"`
+	// Notes 本地对命令所做的自动调整或兼容性提示
+	Notes []string `json:"-"`
+	// Latency 本次调用的耗时
+	Latency time.Duration `json:"-"`
+	// Slow 本次调用明显慢于该提供商近期的请求时记录耗时与提示词大小，否则为 nil
+	Slow *Slow `json:"-"`
+}
+
+// setUsage 记录本次调用的模型与输入、输出 token 数
+func (r *Reply) setUsage(model string, prompt, completion int) {
+	r.Model = model
+	r.PromptTokens, r.CompletionTokens = prompt, completion
+	r.Tokens = prompt + completion
+}
+
+// Slow 一次耗时超过提供商近期 p95 的请求
+type Slow struct {
+	Latency time.Duration // 本次耗时
+	P95     time.Duration // 该提供商近期请求耗时的 p95
+	Prompt  int           // 提示词的字节数
+}
+
+// Alternative 模型给出的一条备选命令
+type Alternative struct {
+	Command     string `json:"command"`
+	Approach    string `json:"approach,omitempty"`
+	Description string `json:"description,omitempty"`
+	Risk        string `json:"risk,omitempty"`
+	// Notes 本地对命令所做的自动调整或兼容性提示
+	Notes []string `json:"-"`
+}
+
+// Line 命令中的一个片段（程序、子命令、选项及其取值）及其含义
+type Line struct {
+	Code string `json:"code"`
+	Note string `json:"note"`
+}
+
+// Tradeoff 一条候选命令的取舍
+type Tradeoff struct {
+	Command     string `json:"command"`
+	Portability string `json:"portability,omitempty"`
+	Speed       string `json:"speed,omitempty"`
+	Safety      string `json:"safety,omitempty"`
+}
+
+// Action 模型建议的一条快捷操作
+type Action struct {
+	Title   string `json:"title"`
+	Command string `json:"command"`
+}
+
+// Field 追问中需要用户填写的一项取值
+type Field struct {
+	// Name 取值的标识，例如 host
+	Name string `json:"name"`
+	// Label 展示给用户的中文名称，例如 "主机"
+	Label string `json:"label,omitempty"`
+	// Type 取值类型：text、number、port、path、host，未知类型按 text 处理
+	Type string `json:"type,omitempty"`
+}
+
+// Need 模型发起的工具请求
+type Need struct {
+	// Run 需要执行的只读探测命令，例如 "uname -r"
+	Run string `json:"run"`
+}
+
+// decodeReply 解析模型返回的 JSON 文本，容忍代码块包裹与前后的说明文字
+func decodeReply(ctx context.Context, text string) (*Reply, error) {
+	_, span := telemetry.Start(ctx, "llm.parse", attribute.Int("llm.response.length", len(text)))
+	var out Reply
+	if err := json.Unmarshal([]byte(extractJSON(text)), &out); err != nil {
+		telemetry.End(span, err)
+		return nil, err
+	}
+	span.End()
+	out.Command = strings.TrimSpace(out.Command)
+	out.Ask = strings.TrimSpace(out.Ask)
+	out.Answer = strings.TrimSpace(out.Answer)
+	out.Approach = strings.TrimSpace(out.Approach)
+	out.Assumptions = strings.TrimSpace(out.Assumptions)
+	out.Explanation = strings.TrimSpace(out.Explanation)
+	out.Description = strings.TrimSpace(out.Description)
+	out.Risk = normalizeRisk(out.Risk)
+	alternatives := out.Alternatives[:0]
+	for _, alt := range out.Alternatives {
+		alt.Command = strings.TrimSpace(alt.Command)
+		if alt.Command == "" || alt.Command == out.Command {
+			continue
+		}
+		alt.Approach = strings.TrimSpace(alt.Approach)
+		alt.Description = strings.TrimSpace(alt.Description)
+		alt.Risk = normalizeRisk(alt.Risk)
+		alternatives = append(alternatives, alt)
+	}
+	out.Alternatives = alternatives
+	if out.Need != nil {
+		out.Need.Run = strings.TrimSpace(out.Need.Run)
+		if out.Need.Run == "" {
+			out.Need = nil
+		}
+	}
+	return &out, nil
+}
+
+// normalizeRisk 规范化风险等级，无法识别时返回空字符串
+func normalizeRisk(risk string) string {
+	switch risk = strings.ToLower(strings.TrimSpace(risk)); risk {
+	case "low", "medium", "high":
+		return risk
+	default:
+		return ""
+	}
+}
+
+// extractJSON 从模型输出中提取第一个完整的 JSON 对象；
+// 未使用结构化输出时，模型常会用 ```json 代码块包裹或附带解释文字
+func extractJSON(text string) string {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return text
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1]
+			}
+		}
+	}
+	return text[start:]
+}
@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"errors"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	openai "github.com/sashabaranov/go-openai"
+	"google.golang.org/genai"
+)
+
+// StatusError 直接请求 HTTP 接口的提供商在收到非成功状态时返回的错误
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// StatusCode 返回错误对应的 HTTP 状态码，兼容各 SDK 的错误类型，非 HTTP 错误返回 0
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+	var openaiErr *openai.APIError
+	if errors.As(err, &openaiErr) {
+		return openaiErr.HTTPStatusCode
+	}
+	var openaiReqErr *openai.RequestError
+	if errors.As(err, &openaiReqErr) {
+		return openaiReqErr.HTTPStatusCode
+	}
+	var claudeErr *anthropic.Error
+	if errors.As(err, &claudeErr) {
+		return claudeErr.StatusCode
+	}
+	var geminiErr genai.APIError
+	if errors.As(err, &geminiErr) {
+		return geminiErr.Code
+	}
+	return 0
+}
@@ -0,0 +1,319 @@
+// Package providertest 提供商实现的一致性测试套件。
+//
+// 新的提供商应在自己的测试中调用 Run，针对 httptest 伪造的服务端验证
+// JSON 解析与提取、错误归类、超时与取消等行为，例如:
+//
+//	func TestConformance(t *testing.T) {
+//		providertest.Run(t, providertest.OpenAI, func(baseURL string, timeout int) (llm.Provider, error) {
+//			return providers.NewOpenAIProvider(&config.OpenAIConfig{APIKey: "test", Model: "m", BaseURL: baseURL, Timeout: timeout})
+//		})
+//	}
+//
+// 内置提供商都在 providers 包的 provider_test.go 中运行该套件
+package providertest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"termi.sh/termi/internal/llm"
+)
+
+// API 描述提供商的 HTTP 接口，用于伪造服务端响应
+type API struct {
+	// Path 请求路径的后缀，例如 "/chat/completions"
+	Path string
+	// Reply 将模型输出的文本包装为该接口的成功响应体
+	Reply func(text string) any
+	// Error 构建该接口的错误响应体
+	Error func(status int, message string) any
+}
+
+// Factory 根据伪造服务端的地址与超时秒数创建被测提供商
+type Factory func(baseURL string, timeout int) (llm.Provider, error)
+
+// 内置提供商的接口描述
+var (
+	OpenAI = API{
+		Path: "/chat/completions",
+		Reply: func(text string) any {
+			return map[string]any{
+				"id":      "chatcmpl-test",
+				"object":  "chat.completion",
+				"choices": []any{map[string]any{"index": 0, "message": map[string]any{"role": "assistant", "content": text}, "finish_reason": "stop"}},
+				"usage":   map[string]any{"prompt_tokens": 1, "completion_tokens": 1, "total_tokens": 2},
+			}
+		},
+		Error: func(status int, message string) any {
+			return map[string]any{"error": map[string]any{"message": message, "type": "invalid_request_error"}}
+		},
+	}
+
+	Claude = API{
+		Path: "/v1/messages",
+		Reply: func(text string) any {
+			return map[string]any{
+				"id":          "msg_test",
+				"type":        "message",
+				"role":        "assistant",
+				"model":       "test",
+				"content":     []any{map[string]any{"type": "text", "text": text}},
+				"stop_reason": "end_turn",
+				"usage":       map[string]any{"input_tokens": 1, "output_tokens": 1},
+			}
+		},
+		Error: func(status int, message string) any {
+			return map[string]any{"type": "error", "error": map[string]any{"type": "api_error", "message": message}}
+		},
+	}
+
+	Gemini = API{
+		Path: ":generateContent",
+		Reply: func(text string) any {
+			return map[string]any{
+				"candidates":    []any{map[string]any{"content": map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}}}},
+				"usageMetadata": map[string]any{"totalTokenCount": 2},
+			}
+		},
+		Error: func(status int, message string) any {
+			return map[string]any{"error": map[string]any{"code": status, "message": message, "status": http.StatusText(status)}}
+		},
+	}
+
+	LlamaCPP = API{
+		Path: "/completion",
+		Reply: func(text string) any {
+			return map[string]any{"content": text, "tokens_evaluated": 1, "tokens_predicted": 1}
+		},
+		Error: func(status int, message string) any {
+			return map[string]any{"error": map[string]any{"code": status, "message": message}}
+		},
+	}
+
+	Ollama = API{
+		Path: "/api/chat",
+		Reply: func(text string) any {
+			return map[string]any{
+				"model":             "test",
+				"message":           map[string]any{"role": "assistant", "content": text},
+				"done":              true,
+				"done_reason":       "stop",
+				"prompt_eval_count": 1,
+				"eval_count":        1,
+			}
+		},
+		Error: func(status int, message string) any {
+			return map[string]any{"error": message}
+		},
+	}
+
+	// Command 外部命令提供商没有 HTTP 接口，被测程序用 Forward 把请求转发给伪造的服务端，
+	// 服务端以 output 字段返回程序应输出的文本
+	Command = API{
+		Path: "/command",
+		Reply: func(text string) any {
+			return map[string]any{"output": text}
+		},
+		Error: func(status int, message string) any {
+			return map[string]any{"error": message, "status": status}
+		},
+	}
+)
+
+// Forward 作为外部命令提供商运行的程序：把标准输入中的请求 POST 到 baseURL 上伪造的 Command 服务端，
+// 成功时输出响应中的 output，失败时原样输出带 error 与 status 的错误 JSON
+func Forward(baseURL string, stdin io.Reader, stdout io.Writer) error {
+	resp, err := http.Post(baseURL+Command.Path, "application/json", stdin)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, err = io.Copy(stdout, resp.Body)
+		return err
+	}
+	var body struct {
+		Output string `json:"output"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, body.Output)
+	return err
+}
+
+// server 伪造的提供商服务端，每个子测试设置自己的处理函数
+type server struct {
+	*httptest.Server
+	api API
+
+	mu      sync.Mutex
+	handler http.HandlerFunc
+
+	// done 在测试结束时关闭，释放仍在挂起的请求，避免 Close 长时间等待
+	done chan struct{}
+}
+
+func newServer(t *testing.T, api API) *server {
+	s := &server{api: api, done: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, api.Path) {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		h := s.handler
+		s.mu.Unlock()
+		h(w, r)
+	}))
+	t.Cleanup(s.Close)
+	t.Cleanup(func() { close(s.done) })
+	return s
+}
+
+func (s *server) handle(h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = h
+}
+
+// reply 让服务端以成功响应返回 text
+func (s *server) reply(text string) {
+	s.handle(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.api.Reply(text))
+	})
+}
+
+// fail 让服务端以指定状态码返回错误
+func (s *server) fail(status int) {
+	s.handle(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, status, s.api.Error(status, "conformance test error"))
+	})
+}
+
+// hang 让服务端一直不响应，直到客户端断开
+func (s *server) hang() {
+	s.handle(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-s.done:
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// Run 运行一致性测试套件
+func Run(t *testing.T, api API, newProvider Factory) {
+	t.Helper()
+	srv := newServer(t, api)
+
+	provider := func(t *testing.T, timeout int) llm.Provider {
+		t.Helper()
+		p, err := newProvider(srv.URL, timeout)
+		if err != nil {
+			t.Fatalf("创建提供商失败: %v", err)
+		}
+		if !p.Enabled() {
+			t.Fatalf("提供商 %s 未启用", p.Name())
+		}
+		return p
+	}
+
+	t.Run("Command", func(t *testing.T) {
+		srv.reply(`{"command":"ls -la","approach":"使用 ls"}`)
+		reply, err := provider(t, 5).AskSmart(context.Background(), "列出文件")
+		if err != nil {
+			t.Fatalf("AskSmart: %v", err)
+		}
+		if reply.Command != "ls -la" || reply.Approach != "使用 ls" {
+			t.Errorf("reply = %+v, want command %q approach %q", reply, "ls -la", "使用 ls")
+		}
+	})
+
+	t.Run("Ask", func(t *testing.T) {
+		srv.reply(`{"ask":"要压缩哪个目录？"}`)
+		reply, err := provider(t, 5).AskSmart(context.Background(), "压缩目录")
+		if err != nil {
+			t.Fatalf("AskSmart: %v", err)
+		}
+		if reply.Ask == "" || reply.Command != "" {
+			t.Errorf("reply = %+v, want a question", reply)
+		}
+	})
+
+	t.Run("ExtractFencedJSON", func(t *testing.T) {
+		srv.reply("好的：\n```json\n{\"command\":\"echo \\\"}\\\"\"}\n```")
+		reply, err := provider(t, 5).AskSmart(context.Background(), "输出括号")
+		if err != nil {
+			t.Fatalf("AskSmart: %v", err)
+		}
+		if reply.Command != `echo "}"` {
+			t.Errorf("command = %q, want %q", reply.Command, `echo "}"`)
+		}
+	})
+
+	errorCases := []struct {
+		name   string
+		status int
+		want   llm.ErrorType
+	}{
+		{"AuthError", http.StatusUnauthorized, llm.ErrorTypeAuth},
+		{"QuotaError", http.StatusTooManyRequests, llm.ErrorTypeQuota},
+	}
+	for _, tc := range errorCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv.fail(tc.status)
+			_, err := provider(t, 10).AskSmart(context.Background(), "列出文件")
+			assertErrorType(t, err, tc.want)
+		})
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		srv.hang()
+		start := time.Now()
+		_, err := provider(t, 1).AskSmart(context.Background(), "列出文件")
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("超时设置为 1 秒，但请求耗时 %v", elapsed)
+		}
+		assertErrorType(t, err, llm.ErrorTypeTimeout)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		srv.hang()
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := provider(t, 30).AskSmart(ctx, "列出文件")
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("取消后请求仍耗时 %v", elapsed)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("err = %v, want context.Canceled", err)
+		}
+	})
+}
+
+// assertErrorType 检查错误经 llm.Classify 归类后的类型
+func assertErrorType(t *testing.T, err error, want llm.ErrorType) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("期望返回错误")
+	}
+	var llmErr *llm.LLMError
+	if !errors.As(llm.Classify(err), &llmErr) || llmErr.Type != want {
+		t.Errorf("错误 %v 未被归类为类型 %d", err, want)
+	}
+}
@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// ModelNotFoundError Ollama 服务上没有配置的模型，可以用 PullModel 下载后重试
+type ModelNotFoundError = providers.ModelNotFoundError
+
+// PullProgress 下载模型的一条进度
+type PullProgress = providers.PullProgress
+
+// PullModel 让配置的 Ollama 服务下载 llm.ollama.model，每收到一条进度调用一次 progress。
+// Llama-cpp 在启动服务时就指定了模型文件，没有下载模型的接口
+func PullModel(ctx context.Context, cfg *config.Config, progress func(PullProgress)) error {
+	if cfg.LLM.Ollama == nil || cfg.LLM.Ollama.Model == "" {
+		return fmt.Errorf("Ollama Model 未配置")
+	}
+	return providers.PullOllamaModel(ctx, cfg.LLM.Ollama, progress)
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/project"
+)
+
+// QuickActions 根据项目说明生成几条常用快捷操作（运行测试、构建、查看过期依赖等）
+func (c *Client) QuickActions(ctx context.Context, description string) ([]project.Action, error) {
+	if c == nil || c.provider == nil {
+		return nil, fmt.Errorf("LLM 提供商未初始化")
+	}
+
+	prompt := fmt.Sprintf(`当前目录是一个软件项目:
+%s
+
+请给出 3 到 6 条在该项目中最常用的快捷操作，例如运行测试、构建、查看过期依赖、格式化代码。
+优先使用项目已有的脚本。返回 JSON {"actions":[{"title":"简短中文标题","command":"shell 命令"}]}。不要提问，也不要发起探测。`, description)
+
+	reply, err := c.ask(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []project.Action
+	for _, a := range reply.Actions {
+		if strings.TrimSpace(a.Command) == "" {
+			continue
+		}
+		actions = append(actions, project.Action{Title: a.Title, Command: a.Command})
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("模型没有返回快捷操作")
+	}
+	return actions, nil
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"termi.sh/termi/internal/llm/providers"
+	"termi.sh/termi/internal/redact"
+)
+
+// Recording 一次提供商交互的录制结果，作为提示词回归测试的 golden 文件
+type Recording struct {
+	Provider string           `json:"provider"`
+	System   string           `json:"system"`
+	Prompt   string           `json:"prompt"`
+	Reply    *providers.Reply `json:"reply,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
+// recordingProvider 将每次交互（脱敏后）写入 golden 文件的提供商装饰器
+type recordingProvider struct {
+	Provider
+	dir      string
+	redactor *redact.Redactor
+}
+
+// newRecordingProvider 包装提供商，将交互录制到 dir 目录
+func newRecordingProvider(p Provider, dir string, r *redact.Redactor) *recordingProvider {
+	return &recordingProvider{Provider: p, dir: dir, redactor: r}
+}
+
+// AskSmart 调用被包装的提供商并录制交互
+func (p *recordingProvider) AskSmart(ctx context.Context, prompt string) (*providers.Reply, error) {
+	reply, err := p.Provider.AskSmart(ctx, prompt)
+
+	rec := Recording{
+		Provider: p.Provider.Name(),
+		System:   providers.SystemPrompt(ctx),
+		Prompt:   p.redactor.Redact(prompt),
+		Reply:    reply,
+	}
+	if err != nil {
+		rec.Error = p.redactor.Redact(err.Error())
+	}
+	if werr := writeRecording(p.dir, prompt, &rec); werr != nil {
+		fmt.Fprintf(os.Stderr, "录制提供商交互失败: %v\n", werr)
+	}
+	return reply, err
+}
+
+// replayProvider 从 golden 文件回放交互的提供商，不发起任何网络请求
+type replayProvider struct {
+	dir string
+}
+
+// newReplayProvider 创建从 dir 目录回放的提供商
+func newReplayProvider(dir string) *replayProvider {
+	return &replayProvider{dir: dir}
+}
+
+// Name 返回提供商名称
+func (p *replayProvider) Name() string {
+	return "Replay"
+}
+
+// Enabled 返回是否已正确配置
+func (p *replayProvider) Enabled() bool {
+	return p.dir != ""
+}
+
+// AskSmart 返回与 prompt 对应的录制结果
+func (p *replayProvider) AskSmart(ctx context.Context, prompt string) (*providers.Reply, error) {
+	path := recordingPath(p.dir, prompt)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("未找到回放录制 %s: %w", filepath.Base(path), err)
+	}
+
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("解析回放录制失败: %w", err)
+	}
+	if rec.Error != "" {
+		return nil, NewGeneralError("回放的提供商错误", fmt.Errorf("%s", rec.Error))
+	}
+	if rec.Reply == nil {
+		return nil, fmt.Errorf("回放录制 %s 缺少 reply", filepath.Base(path))
+	}
+	return rec.Reply, nil
+}
+
+// recordingPath 以 prompt 的摘要作为录制文件名，回放时据此查找
+func recordingPath(dir, prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+func writeRecording(dir, prompt string, rec *Recording) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(recordingPath(dir, prompt), append(data, '\n'), 0600)
+}
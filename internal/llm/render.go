@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"context"
+
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// Prompt 一次请求发送给提供商的系统提示词与用户提示词
+type Prompt struct {
+	System string `json:"system"`
+	User   string `json:"user"`
+}
+
+// RenderPrompt 按 AskSmart 相同的流程组装 query 的最终提示词（环境信息、历史示例、技能包等），
+// 但不请求任何提供商，用于排查模型回答异常的原因，或对提示词模板的修改做快照测试。
+// 组装时会像正常请求一样执行只读的环境探测；模型在回复中发起的探测无法预先渲染
+func (c *Client) RenderPrompt(ctx context.Context, query string) (*Prompt, error) {
+	user, err := c.buildPrompt(ctx, query, c.detectUserland(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &Prompt{
+		System: providers.SystemPrompt(c.requestContext(ctx)),
+		User:   user,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"termi.sh/termi/internal/llm/providers"
+	"termi.sh/termi/internal/normalize"
+	"termi.sh/termi/internal/piped"
+	"termi.sh/termi/internal/redact"
+)
+
+// replayDir 提示词回归测试的 golden 文件，由 go test ./internal/llm -run TestPromptReplay -update 重新录制
+const replayDir = "testdata/replay"
+
+var update = flag.Bool("update", false, "重新录制 "+replayDir+" 中的 golden 文件")
+
+// replayCases 覆盖提示词组装的主要路径：每条查询连同其上下文组装出的提示词与系统提示词都应与录制时一致
+var replayCases = []struct {
+	name  string
+	query string
+	opts  []Option
+	reply providers.Reply
+}{
+	{
+		name:  "Plain",
+		query: "列出当前目录下最大的 5 个文件",
+		reply: providers.Reply{Command: "du -ah . | sort -rh | head -n 5", Approach: "使用 du 与 sort", Risk: "low"},
+	},
+	{
+		name:  "Dictionary",
+		query: "用 gerp 递归查找包含 TODO 的文件",
+		opts:  []Option{WithDictionary(normalize.New(nil))},
+		reply: providers.Reply{Command: "grep -rl TODO .", Approach: "使用 grep", Risk: "low"},
+	},
+	{
+		name:  "Pinned",
+		query: "重启 web 服务",
+		opts:  []Option{WithPinned([]string{"服务由 systemd 管理", "不要使用 sudo"})},
+		reply: providers.Reply{Command: "systemctl --user restart web", Approach: "使用 systemctl", Risk: "medium"},
+	},
+	{
+		name:  "Piped",
+		query: "这个报错是什么原因",
+		opts:  []Option{WithPipedInput(piped.Input{Text: "bash: ./deploy.sh: Permission denied\n"})},
+		reply: providers.Reply{Command: "chmod +x ./deploy.sh", Approach: "添加执行权限", Risk: "medium"},
+	},
+	{
+		name:  "Terminal",
+		query: "修复上面的错误",
+		opts:  []Option{WithTerminalOutput("$ git push\nfatal: The current branch feature has no upstream branch.")},
+		reply: providers.Reply{Command: "git push -u origin feature", Explanation: "当前分支没有设置上游分支", Risk: "low"},
+	},
+	{
+		name:  "Lite",
+		query: "查看磁盘使用情况",
+		opts:  []Option{WithLite(true)},
+		reply: providers.Reply{Command: "df -h"},
+	},
+	{
+		name:  "Ask",
+		query: "压缩日志",
+		reply: providers.Reply{Ask: "要压缩哪个目录下的日志？"},
+	},
+}
+
+// stubProvider 录制 golden 文件时代替真实模型返回预设的响应，名称与回放提供商一致
+type stubProvider struct {
+	reply providers.Reply
+}
+
+func (p stubProvider) Name() string  { return "Replay" }
+func (p stubProvider) Enabled() bool { return true }
+func (p stubProvider) AskSmart(ctx context.Context, prompt string) (*providers.Reply, error) {
+	reply := p.reply
+	return &reply, nil
+}
+
+// TestPromptReplay 回放 golden 文件中的模型响应，并检查组装出的提示词与系统提示词没有变化：
+// 提示词变化后找不到对应的录制，系统提示词变化后重新录制的文件与 golden 文件不同
+func TestPromptReplay(t *testing.T) {
+	// 不在 PATH 中留下任何程序，避免本机核心工具的检测结果进入提示词
+	t.Setenv("PATH", t.TempDir())
+	redactor := redact.New(redact.Options{Tokens: true})
+
+	if *update {
+		if err := os.RemoveAll(replayDir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range replayCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, inner := t.TempDir(), Provider(newReplayProvider(replayDir))
+			if *update {
+				dir, inner = replayDir, stubProvider{reply: tc.reply}
+			}
+			opts := append([]Option{WithProvider(newRecordingProvider(inner, dir, redactor))}, tc.opts...)
+			c, err := NewClient(nil, opts...)
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+
+			reply, err := c.AskSmart(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("AskSmart: %v（提示词可能已改变，确认无误后用 -update 重新录制）", err)
+			}
+			if reply.Command != tc.reply.Command || reply.Ask != tc.reply.Ask {
+				t.Errorf("reply = %+v, want command %q ask %q", reply, tc.reply.Command, tc.reply.Ask)
+			}
+			if *update {
+				return
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("期望录制一个文件，得到 %d 个: %v", len(entries), err)
+			}
+			name := entries[0].Name()
+			got, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join(replayDir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// golden 文件在 Linux 上录制，系统提示词中的操作系统名按 linux 比较
+			if strings.ReplaceAll(string(got), runtime.GOOS, "linux") != string(want) {
+				t.Errorf("录制结果与 %s 不同，系统提示词或响应处理可能已改变，确认无误后用 -update 重新录制\ngot:\n%s", name, got)
+			}
+		})
+	}
+}
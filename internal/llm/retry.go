@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"termi.sh/termi/internal/llm/providers"
+	"termi.sh/termi/internal/telemetry"
+)
+
+// maxRetryDelay 两次重试之间的最长等待时间
+const maxRetryDelay = 8 * time.Second
+
+// ask 在用量上限内向提供商发起请求。临时失败按指数退避重试，重试用尽或遇到其他错误时
+// 依次改用 failover 中的提供商，返回的 Reply 记录实际回答的提供商。
+// 主提供商最近反复失败时被降级，排到其他提供商之后
+func (c *Client) ask(ctx context.Context, prompt string) (*Reply, error) {
+	ctx = c.requestContext(ctx)
+	var err error
+	for _, p := range c.chain() {
+		var reply *Reply
+		reply, err = c.askWithRetry(ctx, p, prompt)
+		if err == nil {
+			c.health.record(p.Name(), true)
+			reply.Provider = p.Name()
+			return reply, nil
+		}
+		// 用量上限与用户取消对所有提供商都一样，不再尝试下一个，也不算提供商的失败
+		if errors.Is(err, ErrBudgetExceeded) || ctx.Err() != nil {
+			return nil, err
+		}
+		c.health.record(p.Name(), false)
+	}
+	return nil, err
+}
+
+// requestContext 附加低带宽模式、用户熟练程度、替代系统提示词、候选数量、目标 shell 与采样温度等请求选项
+func (c *Client) requestContext(ctx context.Context) context.Context {
+	if c.lite {
+		ctx = providers.WithLite(ctx)
+	}
+	if c.expertise != "" {
+		ctx = providers.WithExpertise(ctx, c.expertise)
+	}
+	if c.systemPrompt != "" {
+		ctx = providers.WithSystemPrompt(ctx, c.systemPrompt)
+	}
+	if c.candidates > 0 {
+		ctx = providers.WithCandidates(ctx, c.candidates)
+	}
+	if c.shell != "" && c.host == "" {
+		ctx = providers.WithShell(ctx, c.shell)
+	}
+	if t := c.intents[c.intent].Temperature; t != nil {
+		ctx = providers.WithTemperature(ctx, *t)
+	}
+	return ctx
+}
+
+// chain 返回本次请求依次尝试的提供商。主提供商被降级时排到最后；
+// 未配置 failover 时改用备用提供商顶替
+func (c *Client) chain() []Provider {
+	others := c.failover
+	if len(others) == 0 && c.fallback != nil {
+		others = []Provider{c.fallback}
+	}
+	if len(others) > 0 && c.health.demoted(c.provider.Name()) {
+		return append(slices.Clone(others), c.provider)
+	}
+	return append([]Provider{c.provider}, c.failover...)
+}
+
+// Demoted 返回主提供商是否因最近反复失败而被降级，降级后优先使用其他提供商
+func (c *Client) Demoted() bool {
+	if c == nil || c.provider == nil || len(c.failover) == 0 && c.fallback == nil {
+		return false
+	}
+	return c.health.demoted(c.provider.Name())
+}
+
+// askWithRetry 向单个提供商请求，临时失败时等待后重试，每次等待时间翻倍
+func (c *Client) askWithRetry(ctx context.Context, p Provider, prompt string) (*Reply, error) {
+	delay := c.retry.Delay()
+	for attempt := 1; ; attempt++ {
+		reply, err := c.askOnce(ctx, p, prompt, attempt)
+		if err == nil || attempt >= c.retry.MaxAttempts() || !transient(err) {
+			return reply, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxRetryDelay)
+	}
+}
+
+// askOnce 在用量上限内向提供商发起一次请求，每次重试都计入用量
+func (c *Client) askOnce(ctx context.Context, p Provider, prompt string, attempt int) (*Reply, error) {
+	if err := c.budget.reserve(); err != nil {
+		return nil, err
+	}
+	ctx, span := telemetry.Start(ctx, "llm.provider",
+		attribute.String("llm.provider", p.Name()),
+		attribute.Int("llm.attempt", attempt),
+		attribute.Bool("llm.lite", c.lite))
+	start := time.Now()
+	reply, err := p.AskSmart(ctx, prompt)
+	if err != nil {
+		err = Classify(err)
+		telemetry.End(span, err)
+		return nil, err
+	}
+	// 只统计成功的请求，超时与失败的耗时不代表提供商正常的响应速度
+	reply.Latency = time.Since(start)
+	reply.Slow = c.latency.observe(p.Name(), reply.Latency, len(prompt), c.lite)
+	span.SetAttributes(
+		attribute.Int("llm.tokens", reply.Tokens),
+		attribute.Int64("llm.latency_ms", reply.Latency.Milliseconds()),
+		attribute.Int("llm.prompt_bytes", len(prompt)),
+		attribute.Bool("llm.slow", reply.Slow != nil))
+	span.End()
+	c.budget.settle(reply.Tokens)
+	_ = c.stats.Record(p.Name(), reply.Model, reply.PromptTokens, reply.CompletionTokens)
+	return reply, nil
+}
+
+// transient 判断错误是否为值得重试的临时失败：超时、网络错误、429 与服务端错误
+func transient(err error) bool {
+	var llmErr *LLMError
+	if errors.As(err, &llmErr) {
+		switch llmErr.Type {
+		case ErrorTypeTimeout, ErrorTypeNetwork, ErrorTypeQuota:
+			return true
+		}
+	}
+	return providers.StatusCode(err) >= http.StatusInternalServerError
+}
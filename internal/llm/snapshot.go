@@ -0,0 +1,32 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"termi.sh/termi/internal/sysinfo"
+)
+
+// WithSnapshot 设置是否在性能类查询中附加本机资源快照
+func WithSnapshot(enabled bool) Option {
+	return func(c *Client) {
+		c.snapshot = enabled
+	}
+}
+
+// withSnapshot 用户查询 query 与性能相关时附加本机的负载、内存、进程与磁盘快照，使模型给出有针对性的命令。
+// 远程执行时本机快照没有意义；用户拒绝发送时按原提示词继续
+func (c *Client) withSnapshot(ctx context.Context, prompt, query string) string {
+	if !c.snapshot || c.host != "" || !sysinfo.Relevant(query) {
+		return prompt
+	}
+	snap := sysinfo.Snapshot(ctx)
+	if snap == "" {
+		return prompt
+	}
+	text, ok := c.prepareOutput(ctx, snap)
+	if !ok {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\n本机当前的资源快照（请据此给出针对具体进程或分区的命令，而不是泛泛的监控建议）:\n```\n%s\n```", prompt, text)
+}
@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+
+	"termi.sh/termi/internal/project"
+	"termi.sh/termi/internal/trust"
+)
+
+// withProjectTasks 附加当前目录已定义的项目任务（npm scripts、Makefile 目标、justfile recipes 等），
+// 让模型优先建议 "make test" 这类现成任务而不是重新拼写等价的原始命令。远程执行时本地任务没有意义，
+// 未受信任的目录不读取其项目文件
+func (c *Client) withProjectTasks(prompt string) string {
+	if c.projects == nil || c.host != "" {
+		return prompt
+	}
+	dir, err := os.Getwd()
+	if err != nil || !c.trust.Trusted(dir) {
+		return prompt
+	}
+	tasks := c.projects.CachedTasks(dir)
+	if len(tasks) == 0 {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\n当前目录已定义的项目任务（如果其中某个任务能完成需求，请直接建议运行它，而不是重新拼写等价的原始命令）:\n%s", prompt, project.RenderTasks(tasks))
+}
+
+// withToolchains 附加当前目录所属项目的类型、模块名或包名以及对应工具链的常用命令，
+// 让“运行测试”这类需求在 Go 项目中得到 go test ./...、在 pnpm 项目中得到 pnpm test。
+// 与项目任务一样，远程执行和未受信任的目录不附加
+func (c *Client) withToolchains(prompt string) string {
+	if c.projects == nil || c.host != "" {
+		return prompt
+	}
+	dir, err := os.Getwd()
+	if err != nil || !c.trust.Trusted(dir) {
+		return prompt
+	}
+	toolchains := project.Toolchains(dir)
+	if len(toolchains) == 0 {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\n当前目录所属的项目（需求涉及构建、测试、依赖管理时使用对应工具链的命令）:\n%s", prompt, project.RenderToolchains(dir, toolchains))
+}
+
+// Trust 返回工作目录信任记录，配置为信任所有目录时为 nil（视为全部受信任）
+func (c *Client) Trust() *trust.Store {
+	return c.trust
+}
@@ -0,0 +1,25 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// WithTerminalOutput 附加终端最近的输出（如 tmux 回滚内容），发送前同样经过脱敏与确认
+func WithTerminalOutput(text string) Option {
+	return func(c *Client) {
+		c.terminal = text
+	}
+}
+
+// withTerminalOutput 将终端输出附加到提示词，并要求模型解释其中的错误
+func (c *Client) withTerminalOutput(ctx context.Context, prompt string) (string, error) {
+	if c.terminal == "" {
+		return prompt, nil
+	}
+	text, ok := c.prepareOutput(ctx, c.terminal)
+	if !ok {
+		return "", fmt.Errorf("用户拒绝发送终端输出")
+	}
+	return fmt.Sprintf("%s\n\n终端最近的输出:\n```\n%s\n```\n请在 explanation 字段中用中文简要解释错误的原因，command 给出修复命令。", prompt, text), nil
+}
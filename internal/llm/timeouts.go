@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 配置的各级时限
+const (
+	StageAnalysis = "analysis" // 首次分析
+	StageClarify  = "clarify"  // 回答追问后的一轮分析
+	StageSession  = "session"  // 从首次分析到生成命令的整次会话
+)
+
+// DeadlineError 请求超过了 timeouts 中配置的时限
+type DeadlineError struct {
+	Stage string
+	Limit time.Duration
+}
+
+func (e *DeadlineError) Error() string {
+	name := map[string]string{StageAnalysis: "分析", StageClarify: "追问后的分析", StageSession: "本次会话"}[e.Stage]
+	return fmt.Sprintf("%s超过了 %s 的时限 (timeouts.%s)", name, e.Limit, e.Stage)
+}
+
+type sessionKey struct{}
+
+type clarifyKey struct{}
+
+// session 会话的开始时间与时限
+type session struct {
+	deadline time.Time
+	limit    time.Duration
+}
+
+// StartSession 从现在开始计算 timeouts.session，之后经返回的 ctx 发起的分析都受其限制；
+// 未配置会话时限时原样返回 ctx
+func (c *Client) StartSession(ctx context.Context) context.Context {
+	limit := c.timeouts.SessionLimit()
+	if limit <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, session{deadline: time.Now().Add(limit), limit: limit})
+}
+
+// WithClarifyRound 标记这是用户回答追问后的一轮分析，改用 timeouts.clarify 的时限
+func WithClarifyRound(ctx context.Context) context.Context {
+	return context.WithValue(ctx, clarifyKey{}, true)
+}
+
+// withDeadlines 按会话与本轮分析的时限派生 ctx，本轮时限嵌套在会话时限之内，
+// 哪一级先到期由 context.Cause 中的 DeadlineError 区分
+func (c *Client) withDeadlines(ctx context.Context) (context.Context, context.CancelFunc) {
+	var cancels []context.CancelFunc
+	if s, ok := ctx.Value(sessionKey{}).(session); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadlineCause(ctx, s.deadline, &DeadlineError{Stage: StageSession, Limit: s.limit})
+		cancels = append(cancels, cancel)
+	}
+
+	stage, limit := StageAnalysis, c.timeouts.AnalysisLimit()
+	if clarify, _ := ctx.Value(clarifyKey{}).(bool); clarify {
+		stage, limit = StageClarify, c.timeouts.ClarifyLimit()
+	}
+	if limit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, limit, &DeadlineError{Stage: stage, Limit: limit})
+		cancels = append(cancels, cancel)
+	}
+	return ctx, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// deadlineErr 请求因配置的时限而结束时返回对应的 DeadlineError，而不是提供商报告的超时
+func deadlineErr(ctx context.Context, err error) error {
+	var de *DeadlineError
+	if err != nil && ctx.Err() != nil && errors.As(context.Cause(ctx), &de) {
+		return de
+	}
+	return err
+}
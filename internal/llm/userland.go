@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+
+	"termi.sh/termi/internal/coreutils"
+)
+
+// WithTranslate 设置是否自动转换本机核心工具（GNU/BSD）不支持的写法
+func WithTranslate(on bool) Option {
+	return func(c *Client) {
+		c.translate = on
+	}
+}
+
+// detectUserland 探测本机核心工具的版本，远程执行时返回 Unknown
+func (c *Client) detectUserland(ctx context.Context) coreutils.Flavor {
+	if c.host != "" {
+		return coreutils.Unknown
+	}
+	f := coreutils.Detect(ctx, c.probes.Run)
+	_ = c.probes.Save()
+	return f
+}
+
+// adaptCommand 将命令转换为本机核心工具支持的写法，并在 Notes 中说明转换与无法转换的部分
+func (c *Client) adaptCommand(reply *Reply, f coreutils.Flavor) {
+	if !c.translate || reply.Command == "" || f == coreutils.Unknown {
+		return
+	}
+	reply.Command, reply.Notes = translateCommand(reply.Command, f, reply.Notes)
+	for i := range reply.Alternatives {
+		alt := &reply.Alternatives[i]
+		alt.Command, alt.Notes = translateCommand(alt.Command, f, alt.Notes)
+	}
+}
+
+// translateCommand 将命令转换为 f 的写法，并把所做的调整追加到 notes
+func translateCommand(command string, f coreutils.Flavor, notes []string) (string, []string) {
+	r := coreutils.Translate(command, f)
+	for _, change := range r.Changes {
+		notes = append(notes, fmt.Sprintf("已转换为 %s 写法: %s", f, change))
+	}
+	return r.Command, append(notes, r.Warnings...)
+}
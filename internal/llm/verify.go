@@ -0,0 +1,28 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// Verify 请求模型为已执行的命令生成一条只读、代价低的验证命令，无法验证时返回空字符串
+func (c *Client) Verify(ctx context.Context, query, command string) (string, error) {
+	if c == nil || c.provider == nil {
+		return "", fmt.Errorf("LLM 提供商未初始化")
+	}
+
+	prompt := fmt.Sprintf(`用户需求: %s
+已执行的命令: %s
+
+请不要重复上述命令，而是给出一条只读、执行代价低的 shell 命令，用于验证它是否达成了用户需求（例如检查文件是否存在且非空、服务是否在监听）。
+验证通过时该命令的退出码应为 0，否则非 0。不要提问，也不要发起探测；如果无法验证，command 字段留空。`, query, command)
+
+	reply, err := c.ask(ctx, c.withHostContext(prompt))
+	if err != nil {
+		return "", err
+	}
+	if reply.Ask != "" || reply.Need != nil || reply.Command == command {
+		return "", nil
+	}
+	return reply.Command, nil
+}
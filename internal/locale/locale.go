@@ -0,0 +1,147 @@
+// Package locale 根据本机平台、发行版与地区选择提示词预设，避免生成与本机工具不兼容的命令
+package locale
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// presets 预设名称 -> 附加到提示词中的指引
+var presets = map[string]string{
+	"macos":  "macOS 使用 BSD 版本的核心工具：不要使用 GNU 专有参数（如 grep -P、sed -i 不带后缀参数、date -d、readlink -f、find -printf、stat -c、xargs -r），改用 BSD 写法；确实需要时说明可通过 Homebrew 安装 GNU 版本（ggrep、gsed、gdate）。软件包使用 brew 安装。",
+	"debian": "软件包使用 apt 管理（apt install），服务使用 systemctl 管理。",
+	"dnf":    "软件包使用 dnf 管理（dnf install），服务使用 systemctl 管理。",
+	"yum":    "软件包使用 yum 管理，该系统版本较旧，没有 dnf。",
+	"arch":   "软件包使用 pacman 管理（pacman -S），AUR 软件需要 yay 等辅助工具。",
+	"alpine": "软件包使用 apk 管理（apk add）；核心工具为 BusyBox 版本，很多 GNU 长参数不可用，默认 shell 为 ash。",
+	"suse":   "软件包使用 zypper 管理（zypper install）。",
+	"cn":     "用户位于中国大陆：涉及下载或安装依赖时优先使用国内镜像（如 pip 使用 -i https://pypi.tuna.tsinghua.edu.cn/simple、npm 使用 --registry https://registry.npmmirror.com），避免直接访问可能无法连通的境外地址。",
+}
+
+// Known 返回预设名称是否存在
+func Known(name string) bool {
+	_, ok := presets[name]
+	return ok
+}
+
+// Detect 推断适用于本机的预设名称
+func Detect() []string {
+	var names []string
+	switch runtime.GOOS {
+	case "darwin":
+		names = append(names, "macos")
+	case "linux":
+		if name := distro(readOSRelease("/etc/os-release")); name != "" {
+			names = append(names, name)
+		}
+	}
+	if inMainlandChina() {
+		names = append(names, "cn")
+	}
+	return names
+}
+
+// Render 将预设渲染为提示词片段，没有预设时返回空字符串
+func Render(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		if g, ok := presets[name]; ok {
+			b.WriteString("- " + g + "\n")
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "本机环境约定:\n" + strings.TrimRight(b.String(), "\n")
+}
+
+// distro 根据 os-release 字段推断软件包管理相关的预设
+func distro(osRelease map[string]string) string {
+	ids := strings.Fields(osRelease["ID"] + " " + osRelease["ID_LIKE"])
+	has := func(want ...string) bool {
+		for _, id := range ids {
+			for _, w := range want {
+				if id == w {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("debian", "ubuntu"):
+		return "debian"
+	case has("alpine"):
+		return "alpine"
+	case has("arch", "manjaro"):
+		return "arch"
+	case has("suse", "opensuse", "sles"):
+		return "suse"
+	case has("fedora"):
+		return "dnf"
+	case has("rhel", "centos"):
+		// RHEL/CentOS 8 起使用 dnf
+		major, err := strconv.Atoi(strings.Split(osRelease["VERSION_ID"], ".")[0])
+		if err == nil && major < 8 {
+			return "yum"
+		}
+		return "dnf"
+	default:
+		return ""
+	}
+}
+
+// readOSRelease 解析 os-release 文件，文件不存在时返回空表
+func readOSRelease(path string) map[string]string {
+	out := map[string]string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return out
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		out[key] = strings.ToLower(strings.Trim(value, `"'`))
+	}
+	return out
+}
+
+// inMainlandChina 根据语言环境与时区判断用户是否位于中国大陆
+func inMainlandChina() bool {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			if strings.HasPrefix(v, "zh_CN") {
+				return true
+			}
+			break
+		}
+	}
+	switch os.Getenv("TZ") {
+	case "Asia/Shanghai", "Asia/Chongqing", "Asia/Harbin", "Asia/Urumqi", "PRC":
+		return true
+	}
+	return false
+}
+
+// UTF8 根据 LC_ALL、LC_CTYPE、LANG（按优先级取第一个非空值）判断终端字符集是否为 UTF-8。
+// 都未设置时为 C 语言环境，精简的服务器镜像常见这种情况；Windows 终端始终支持 Unicode
+func UTF8() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := strings.ToLower(os.Getenv(key)); v != "" {
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+// Package mux 识别当前终端所在的 tmux 或 GNU screen 会话，并在其状态栏中显示消息
+package mux
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Kind 终端复用器的种类
+type Kind string
+
+const (
+	None   Kind = ""       // 不在终端复用器中
+	Tmux   Kind = "tmux"   // tmux
+	Screen Kind = "screen" // GNU screen
+)
+
+// Detect 返回当前进程所在的终端复用器，tmux 嵌在 screen 中运行时以内层的 tmux 为准
+func Detect() Kind {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return Tmux
+	case os.Getenv("STY") != "":
+		return Screen
+	default:
+		return None
+	}
+}
+
+// Display 在终端复用器的状态栏中短暂显示消息，不在复用器中时什么也不做。
+// 切到其他窗口或窗格时依然能看到，适合提醒远程会话中的用户
+func Display(message string) error {
+	switch Detect() {
+	case Tmux:
+		args := []string{"display-message"}
+		if pane := os.Getenv("TMUX_PANE"); pane != "" {
+			args = append(args, "-t", pane)
+		}
+		// display-message 将消息当作格式字符串，# 需要写成 ##
+		return exec.Command("tmux", append(args, strings.ReplaceAll(message, "#", "##"))...).Run()
+	case Screen:
+		return exec.Command("screen", "-X", "echo", message).Run()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,115 @@
+package normalize
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// builtin 内置的常见拼写错误与工具别名。与英文单词或真实命令同形的写法（如 got、sl、tf）
+// 不收录，否则会改写正常的描述与命令
+var builtin = map[string]string{
+	"gerp":      "grep",
+	"grpe":      "grep",
+	"cd..":      "cd ..",
+	"gti":       "git",
+	"pign":      "ping",
+	"ptyhon":    "python",
+	"pyhton":    "python",
+	"dokcer":    "docker",
+	"docekr":    "docker",
+	"kubeclt":   "kubectl",
+	"kubctl":    "kubectl",
+	"k8s":       "kubernetes",
+	"ffmepg":    "ffmpeg",
+	"nignx":     "nginx",
+	"ngnix":     "nginx",
+	"systemclt": "systemctl",
+	"sytemctl":  "systemctl",
+}
+
+// wordRe 匹配可被替换的 ASCII 单词，中文等其他字符保持不变
+var wordRe = regexp.MustCompile(`[A-Za-z0-9_.+-]+`)
+
+// Dictionary 查询预处理词典，键为小写的错误写法或别名
+type Dictionary struct {
+	entries map[string]string
+}
+
+// New 使用内置词典与额外条目创建词典，额外条目优先
+func New(extra map[string]string) *Dictionary {
+	d := &Dictionary{entries: maps.Clone(builtin)}
+	for k, v := range extra {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" {
+			continue
+		}
+		if v == "" {
+			// 空值表示禁用该内置条目
+			delete(d.entries, k)
+			continue
+		}
+		d.entries[k] = v
+	}
+	return d
+}
+
+// Load 从 JSON 文件加载用户词典并与内置词典合并，文件不存在时仅使用内置词典
+func Load(path string) (*Dictionary, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(nil), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取词典文件失败: %w", err)
+	}
+
+	var extra map[string]string
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, fmt.Errorf("解析词典文件失败: %w", err)
+	}
+	return New(extra), nil
+}
+
+// Apply 将查询中的拼写错误和别名替换为规范写法。查询像一条命令时只改写首个词（程序名），
+// 参数中的文件名、分支名等保持原样
+func (d *Dictionary) Apply(query string) string {
+	if d == nil || len(d.entries) == 0 {
+		return query
+	}
+	replace := func(word string) string {
+		if repl, ok := d.entries[strings.ToLower(word)]; ok {
+			return repl
+		}
+		return word
+	}
+	if !commandLike(query) {
+		return wordRe.ReplaceAllStringFunc(query, replace)
+	}
+	loc := wordRe.FindStringIndex(query)
+	if loc == nil || strings.TrimSpace(query[:loc[0]]) != "" {
+		return query
+	}
+	return query[:loc[0]] + replace(query[loc[0]:loc[1]]) + query[loc[1]:]
+}
+
+// commandLike 判断查询是否像一条命令而不是自然语言描述：只有 ASCII 字符，
+// 且首个词之后带有选项、路径或 shell 操作符
+func commandLike(query string) bool {
+	for _, r := range query {
+		if r >= 0x80 {
+			return false
+		}
+	}
+	fields := strings.Fields(query)
+	for _, f := range fields[min(1, len(fields)):] {
+		if strings.HasPrefix(f, "-") || strings.ContainsAny(f, "/|&;<>=$*'\"") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Package objects 捕获 PowerShell 与 Nushell 命令输出的结构化对象：执行时把管道输出的对象
+// 另存为 JSON，下一次请求把它作为结构化数据附加到提示词，模型据此引用准确的字段名与取值，
+// 而不是从表格文本中猜测
+package objects
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+	"termi.sh/termi/internal/shellquote"
+)
+
+const (
+	// maxItems 附加到提示词的对象数量上限
+	maxItems = 50
+	// maxBytes 附加到提示词的 JSON 长度上限
+	maxBytes = 16 << 10
+	// freshFor 上一条命令的输出在这段时间内才附加到下一次请求
+	freshFor = 15 * time.Minute
+)
+
+// Output 一条命令输出的结构化对象
+type Output struct {
+	Command string    `json:"command"`
+	Shell   string    `json:"shell"`
+	Dir     string    `json:"dir"`
+	Time    time.Time `json:"time"`
+	JSON    string    `json:"json"`            // 对象数组的 JSON，超过上限时只保留前面的对象
+	Total   int       `json:"total,omitempty"` // 截断前的对象数量
+	Kept    int       `json:"kept,omitempty"`  // JSON 中保留的对象数量
+}
+
+// separators PowerShell 与 Nushell 中分隔管道阶段与语句的符号
+var separators = regexp.MustCompile(`\|\||&&|[|;\n]`)
+
+// cmdlet PowerShell 的 Verb-Noun 形式的命令
+var cmdlet = regexp.MustCompile(`^[A-Za-z]+-[A-Za-z]+$`)
+
+// psAliases 常用的 PowerShell 内置别名；ls、ps 等在类 Unix 系统上是外部程序，不在其中
+var psAliases = []string{"%", "?", "foreach", "where", "select", "sort", "group", "measure", "gci", "gps", "gsv", "gi", "gc", "gm"}
+
+// psPresentation 输出格式化记录或直接写到主机的命令，其输出不是数据对象
+var psPresentation = regexp.MustCompile(`(?i)^(?:Format|Out|Write|Export|ConvertTo)-`)
+
+// nuCommands 输出结构化数据的 Nushell 内置命令
+var nuCommands = []string{
+	"ls", "ps", "sys", "du", "open", "glob", "which", "date", "http",
+	"where", "filter", "select", "get", "reject", "rename", "update", "insert", "upsert", "default",
+	"sort", "sort-by", "reverse", "first", "last", "skip", "take", "uniq", "uniq-by", "group-by",
+	"length", "math", "each", "flatten", "compact", "enumerate", "transpose", "values", "columns",
+	"from", "parse", "detect", "split", "merge", "append", "prepend", "zip", "wrap", "find", "path", "str",
+}
+
+// Capturable 报告命令是否只由 PowerShell cmdlet 或 Nushell 内置命令组成，可以捕获其输出对象。
+// 含外部程序的命令不捕获：捕获会让它们的输出不再直接连接终端，交互式程序无法正常工作
+func Capturable(sh shellquote.Shell, command string) bool {
+	if sh != shellquote.PowerShell && sh != shellquote.Nushell || strings.TrimSpace(command) == "" {
+		return false
+	}
+	stages := separators.Split(command, -1)
+	for i, stage := range stages {
+		fields := strings.Fields(stage)
+		if len(fields) == 0 {
+			return false
+		}
+		name := fields[0]
+		switch sh {
+		case shellquote.PowerShell:
+			if !cmdlet.MatchString(name) && !slices.Contains(psAliases, strings.ToLower(name)) {
+				return false
+			}
+			if psPresentation.MatchString(name) {
+				return false
+			}
+		case shellquote.Nushell:
+			if !slices.Contains(nuCommands, name) {
+				return false
+			}
+			// to json、to text 等把数据转换成了文本
+			if i == len(stages)-1 && (name == "to" || name == "print" || name == "table") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Wrap 返回在终端中照常显示输出、同时把输出对象以 JSON 写入 path 的命令，保留命令的成败状态。
+// 序列化失败时不影响命令本身
+func Wrap(sh shellquote.Shell, command, path string) string {
+	quoted := sh.Quote(path)
+	if sh == shellquote.Nushell {
+		return fmt.Sprintf("let __termi_out = (%s)\ntry { $__termi_out | to json --raw | save --force %s }\n$__termi_out", command, quoted)
+	}
+	return fmt.Sprintf(`$__termi_out = [System.Collections.Generic.List[object]]::new()
+& { %s } | ForEach-Object { $__termi_out.Add($_); $_ }
+$__termi_ok = $?
+try { ConvertTo-Json -InputObject $__termi_out.ToArray() -Depth 3 -Compress -WarningAction SilentlyContinue | Set-Content -LiteralPath %s -Encoding utf8 } catch {}
+if (-not $__termi_ok) { exit 1 }`, command, quoted)
+}
+
+// Read 读取 Wrap 写入的 JSON，超过上限时只保留前面的对象。输出为空或只是文本（字符串）时返回 false
+func Read(path string) (data string, total, kept int, ok bool) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")) // Windows PowerShell 写入的 BOM
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", 0, 0, false
+	}
+	items, isList := value.([]any)
+	if !isList {
+		items = []any{value}
+	}
+	if !structured(items) {
+		return "", 0, 0, false
+	}
+
+	total = len(items)
+	items = items[:min(len(items), maxItems)]
+	for {
+		out, err := json.Marshal(items)
+		if err != nil {
+			return "", 0, 0, false
+		}
+		if len(out) <= maxBytes || len(items) == 1 {
+			if len(out) > maxBytes {
+				out = append(out[:maxBytes:maxBytes], "..."...)
+			}
+			return string(out), total, len(items), true
+		}
+		items = items[:len(items)/2]
+	}
+}
+
+// structured 报告输出中是否有对象、数字等数据，只有字符串时说明是外部程序或格式化后的文本
+func structured(items []any) bool {
+	for _, item := range items {
+		if _, isText := item.(string); !isText && item != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Save 记下最近一条命令的结构化输出，覆盖之前的记录
+func Save(path string, o Output) error {
+	data, err := json.Marshal(o)
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(path, data, 0600)
+}
+
+// Last 返回在同一目录、同一 shell 中刚刚执行的命令的结构化输出
+func Last(path, dir, shell string) (Output, bool) {
+	var o Output
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &o) != nil {
+		return Output{}, false
+	}
+	if o.Dir != dir || o.Shell != shell || time.Since(o.Time) > freshFor || o.JSON == "" {
+		return Output{}, false
+	}
+	return o, true
+}
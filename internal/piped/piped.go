@@ -0,0 +1,170 @@
+// Package piped 读取通过管道传给 termi 的内容，例如 kubectl logs pod | termi "为什么报错"。
+// 内容作为上下文附加到提示词中；读取后标准输入改为控制终端，交互界面照常工作
+package piped
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// Input 读取到的管道内容
+type Input struct {
+	Text      string // 截断并清理后的内容
+	Size      int    // 原始内容的字节数
+	Truncated bool
+	Strategy  string // 截断时保留的部分：tail、head 或 both
+}
+
+// Lines 返回附加内容的行数
+func (in Input) Lines() int {
+	if in.Text == "" {
+		return 0
+	}
+	return strings.Count(in.Text, "\n") + 1
+}
+
+// Kept 描述截断后保留的部分：结尾、开头或开头和结尾
+func (in Input) Kept() string {
+	switch in.Strategy {
+	case "head":
+		return "开头"
+	case "both":
+		return "开头和结尾"
+	default:
+		return "结尾"
+	}
+}
+
+// ErrBinary 管道内容不是文本
+var ErrBinary = errors.New("管道输入不是文本，未附加")
+
+// escapeSequence 匹配终端颜色等控制序列，带颜色的日志去掉后再发送
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// Attached 报告标准输入是否来自管道或重定向的文件，而不是终端或 /dev/null 这类设备
+func Attached() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// Read 读取全部内容，超过 limit 字节时按 strategy 保留结尾、开头或两端，截断处对齐到整行。
+// 内存占用不超过 limit 的两倍，不会因为输入很大而全部读入
+func Read(r io.Reader, limit int, strategy string) (Input, error) {
+	if strategy == "" {
+		strategy = "tail"
+	}
+	in := Input{Strategy: strategy}
+	headSize, tailSize := 0, limit
+	switch strategy {
+	case "head":
+		headSize, tailSize = limit, 0
+	case "both":
+		headSize, tailSize = limit/2, limit-limit/2
+	}
+
+	var head, tail []byte
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := r.Read(buf)
+		chunk := buf[:n]
+		in.Size += n
+		if room := headSize - len(head); room > 0 {
+			k := min(room, len(chunk))
+			head = append(head, chunk[:k]...)
+			chunk = chunk[k:]
+		}
+		if tailSize > 0 {
+			tail = append(tail, chunk...)
+			if len(tail) > 2*tailSize {
+				tail = append(tail[:0], tail[len(tail)-tailSize:]...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Input{}, err
+		}
+	}
+	if bytes.IndexByte(head, 0) >= 0 || bytes.IndexByte(tail, 0) >= 0 {
+		return Input{}, ErrBinary
+	}
+
+	in.Truncated = in.Size > limit
+	if len(tail) > tailSize {
+		tail = tail[len(tail)-tailSize:]
+	}
+	if !in.Truncated {
+		in.Text = clean(append(head, tail...))
+		return in, nil
+	}
+
+	// 截断处可能切在一行或一个多字节字符的中间，丢掉不完整的部分
+	if i := bytes.LastIndexByte(head, '\n'); i > 0 {
+		head = head[:i]
+	}
+	if i := bytes.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	switch strategy {
+	case "head":
+		in.Text = clean(head) + "\n…"
+	case "both":
+		in.Text = clean(head) + "\n…\n" + clean(tail)
+	default:
+		in.Text = "…\n" + clean(tail)
+	}
+	return in, nil
+}
+
+// clean 去掉控制序列、回车与无效的 UTF-8 字节
+func clean(b []byte) string {
+	s := escapeSequence.ReplaceAllString(string(b), "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "")
+	}
+	return strings.Trim(s, "\n")
+}
+
+// ReadFile 按与管道内容相同的规则读取文件，用于 termi --file 附加的文件
+func ReadFile(path string, limit int, strategy string) (Input, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Input{}, err
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return Input{}, fmt.Errorf("%s 是目录，只能附加文件", path)
+	}
+	in, err := Read(f, limit, strategy)
+	if errors.Is(err, ErrBinary) {
+		return Input{}, fmt.Errorf("%s 不是文本文件", path)
+	}
+	return in, err
+}
+
+// ReopenTTY 把标准输入换成控制终端，读完管道内容后交互界面、追问与执行的命令仍能读取键盘输入。
+// 没有控制终端时（CI、cron）返回错误，标准输入保持原样
+func ReopenTTY() error {
+	name := "/dev/tty"
+	if runtime.GOOS == "windows" {
+		name = "CONIN$"
+	}
+	f, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	os.Stdin = f
+	return nil
+}
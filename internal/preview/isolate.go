@@ -0,0 +1,143 @@
+package preview
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+)
+
+// ErrNoSandbox 当前系统无法提供隔离的沙箱
+var ErrNoSandbox = errors.New("沙箱需要 amd64 或 arm64 架构的 Linux 以及 bubblewrap (bwrap)")
+
+// SeccompFD Isolation 返回的 seccomp 程序在沙箱进程中的文件描述符，对应 exec.Cmd.ExtraFiles[0]
+const SeccompFD = 3
+
+// seccompArch 各架构的 AUDIT_ARCH 与 socket 系统调用号
+var seccompArch = map[string]struct{ audit, socket uint32 }{
+	"amd64": {0xc000003e, 41},
+	"arm64": {0xc00000b7, 198},
+}
+
+// Sandboxable 检查 Isolation 所需的系统与工具是否齐全
+func Sandboxable() error {
+	if _, ok := seccompArch[runtime.GOARCH]; runtime.GOOS != "linux" || !ok {
+		return ErrNoSandbox
+	}
+	if _, err := exec.LookPath("bwrap"); err != nil {
+		return ErrNoSandbox
+	}
+	return nil
+}
+
+// Isolation 返回 bwrap 的隔离参数与 seccomp 程序文件，调用方需将该文件放在 exec.Cmd.ExtraFiles[0]，
+// 运行结束后关闭。只读的根目录仍能连接其中的 unix socket（如 /var/run/docker.sock、D-Bus 会话总线），
+// 因此用 tmpfs 遮住 /run、/var/run 与 $XDG_RUNTIME_DIR，断开网络与 IPC，并以 seccomp 禁止新建
+// AF_UNIX socket，防止沙箱中的命令经由系统服务改动真实状态。keep 中的目录及其上级不会被遮住
+func Isolation(keep ...string) ([]string, *os.File, error) {
+	if err := Sandboxable(); err != nil {
+		return nil, nil, err
+	}
+
+	var args []string
+	masked := map[string]bool{}
+	for _, dir := range []string{"/run", "/var/run", os.Getenv("XDG_RUNTIME_DIR")} {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue
+		}
+		// /var/run 通常是指向 /run 的符号链接，只遮住真实的目录
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil || masked[real] || coveredBy(real, masked) || kept(real, keep) {
+			continue
+		}
+		if info, err := os.Stat(real); err != nil || !info.IsDir() {
+			continue
+		}
+		masked[real] = true
+		args = append(args, "--tmpfs", real)
+	}
+	args = append(args, "--unshare-net", "--unshare-ipc", "--seccomp", strconv.Itoa(SeccompFD))
+
+	arch := seccompArch[runtime.GOARCH]
+	f, err := seccompProgram(arch.audit, arch.socket)
+	if err != nil {
+		return nil, nil, err
+	}
+	return args, f, nil
+}
+
+// coveredBy 判断 path 是否位于已经遮住的目录之下
+func coveredBy(path string, masked map[string]bool) bool {
+	for dir := range masked {
+		if within(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// kept 判断遮住 dir 是否会连带遮住 keep 中的目录
+func kept(dir string, keep []string) bool {
+	for _, k := range keep {
+		if within(k, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// seccompProgram 将经典 BPF 形式的 seccomp 程序写入已删除的临时文件：socket(AF_UNIX, ...) 返回 EACCES，
+// 其他架构（如 amd64 上的 32 位调用）与 x32 调用号的系统调用一律返回 EACCES，其余系统调用放行
+func seccompProgram(audit, socket uint32) (*os.File, error) {
+	const (
+		ldAbs  = 0x20 // BPF_LD | BPF_W | BPF_ABS
+		jeq    = 0x15 // BPF_JMP | BPF_JEQ | BPF_K
+		jge    = 0x35 // BPF_JMP | BPF_JGE | BPF_K
+		ret    = 0x06 // BPF_RET | BPF_K
+		allow  = 0x7fff0000
+		deny   = 0x00050000 | uint32(syscall.EACCES)
+		afUnix = 1
+		x32Bit = 0x40000000
+	)
+	// seccomp_data: nr 位于偏移 0，arch 位于偏移 4，args[0] 的低 32 位位于偏移 16
+	prog := []struct {
+		code   uint16
+		jt, jf uint8
+		k      uint32
+	}{
+		{ldAbs, 0, 0, 4},
+		{jeq, 0, 5, audit}, // 其他架构 -> deny
+		{ldAbs, 0, 0, 0},
+		{jge, 3, 0, x32Bit}, // x32 调用号 -> deny
+		{jeq, 0, 3, socket}, // 不是 socket -> allow
+		{ldAbs, 0, 0, 16},
+		{jeq, 0, 1, afUnix}, // 不是 AF_UNIX -> allow
+		{ret, 0, 0, deny},
+		{ret, 0, 0, allow},
+	}
+
+	f, err := os.CreateTemp("", "termi-seccomp-")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(f.Name())
+	buf := make([]byte, 0, len(prog)*8)
+	for _, in := range prog {
+		buf = binary.LittleEndian.AppendUint16(buf, in.code)
+		buf = append(buf, in.jt, in.jf)
+		buf = binary.LittleEndian.AppendUint32(buf, in.k)
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
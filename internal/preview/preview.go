@@ -0,0 +1,274 @@
+// Package preview 在不产生实际影响的沙箱中预演命令，报告它会修改或删除哪些文件、连接哪些网络地址。
+// 沙箱由 bubblewrap 提供：根文件系统只读，当前目录挂载为用完即弃的写时复制层，网络与 IPC 断开，
+// /run 等目录中的 unix socket 被遮住且无法新建 unix socket；
+// strace 记录命令发起的文件与网络系统调用，被只读文件系统或断网拒绝的操作同样计入报告
+package preview
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeout 沙箱中运行命令的最长时间
+const timeout = 10 * time.Second
+
+// maxListed 报告中每类最多列出的条目数
+const maxListed = 15
+
+// ErrUnavailable 当前系统无法预演命令
+var ErrUnavailable = errors.New("沙箱预演需要 amd64 或 arm64 架构的 Linux 以及 bubblewrap (bwrap) 与 strace")
+
+// Report 命令在沙箱中的行为
+type Report struct {
+	Written  []string // 创建或修改的路径
+	Deleted  []string // 删除的路径
+	Network  []string // 连接的网络地址，例如 93.184.216.34:443
+	ExitCode int
+	TimedOut bool
+}
+
+// Available 检查预演所需的工具是否齐全
+func Available() error {
+	if _, ok := seccompArch[runtime.GOARCH]; runtime.GOOS != "linux" || !ok {
+		return ErrUnavailable
+	}
+	for _, name := range []string{"bwrap", "strace"} {
+		if _, err := exec.LookPath(name); err != nil {
+			return ErrUnavailable
+		}
+	}
+	return nil
+}
+
+// Run 在沙箱中以 dir 为工作目录运行命令并返回其行为报告，命令的任何改动都不会保留
+func Run(ctx context.Context, command, dir string) (*Report, error) {
+	if err := Available(); err != nil {
+		return nil, err
+	}
+	logDir, err := os.MkdirTemp("", "termi-preview-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(logDir)
+	logPath := filepath.Join(logDir, "trace")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	isolation, seccomp, err := Isolation(dir, logDir, os.TempDir())
+	if err != nil {
+		return nil, err
+	}
+	defer seccomp.Close()
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "bwrap", sandboxArgs(command, dir, logDir, logPath, isolation)...)
+	cmd.Stderr = &stderr
+	cmd.ExtraFiles = []*os.File{seccomp}
+	runErr := cmd.Run()
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		// 没有跟踪记录说明沙箱本身没有启动，例如内核不允许非特权用户命名空间
+		return nil, fmt.Errorf("沙箱启动失败: %s", firstLine(stderr.String(), runErr))
+	}
+	defer f.Close()
+
+	r := parse(bufio.NewScanner(f), dir, logDir)
+	r.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		r.ExitCode = exitErr.ExitCode()
+	}
+	return r, nil
+}
+
+// sandboxArgs 构建 bwrap 参数：只读根目录、当前目录的临时覆盖层、独立的 /tmp 与 isolation 中的隔离，
+// 只有跟踪日志所在目录可以真实写入
+func sandboxArgs(command, dir, logDir, logPath string, isolation []string) []string {
+	args := []string{"--ro-bind", "/", "/", "--dev", "/dev", "--proc", "/proc"}
+	// 当前目录位于 /tmp 下时保留原有 /tmp，否则覆盖层的源目录会被 tmpfs 遮住
+	if !within(dir, os.TempDir()) {
+		args = append(args, "--tmpfs", os.TempDir())
+	}
+	args = append(args,
+		"--overlay-src", dir, "--tmp-overlay", dir,
+		"--bind", logDir, logDir,
+	)
+	args = append(args, isolation...)
+	args = append(args,
+		"--unshare-pid", "--die-with-parent", "--new-session",
+		"--chdir", dir,
+		"strace", "-f", "-qq", "-e", "trace=file,network", "-e", "signal=none", "-o", logPath,
+		"sh", "-c", command,
+	)
+	return args
+}
+
+var (
+	// callLine 一条完整或未完成的系统调用记录，例如 `123 openat(AT_FDCWD, "a", O_WRONLY) = -1 EROFS (...)`
+	callLine = regexp.MustCompile(`^(?:\d+\s+)?(\w+)\((.*?)(?:\)\s+=\s+(-?\d+|\?)(?:\s+(\w+))?.*| <unfinished \.\.\.>)$`)
+	// quoted strace 输出中的字符串参数
+	quoted = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"`)
+	inet   = regexp.MustCompile(`sin_port=htons\((\d+)\), sin_addr=inet_addr\("([^"]+)"\)`)
+	inet6  = regexp.MustCompile(`sin6_port=htons\((\d+)\).*?inet_pton\(AF_INET6, "([^"]+)"`)
+	unix   = regexp.MustCompile(`sun_path="([^"]+)"`)
+)
+
+// writeFlags 以写方式打开文件的标志
+var writeFlags = regexp.MustCompile(`O_WRONLY|O_RDWR|O_CREAT|O_TRUNC`)
+
+// ignoredErrors 说明目标不存在或已存在、实际执行时同样不会产生影响的错误
+var ignoredErrors = map[string]bool{"ENOENT": true, "EEXIST": true, "ENOTDIR": true}
+
+// ignoredPrefixes 伪文件系统与沙箱自身的路径不计入报告
+var ignoredPrefixes = []string{"/dev/", "/proc/", "/sys/"}
+
+// parse 解析 strace 日志，dir 用于解析相对路径，logDir 为需要忽略的跟踪日志目录
+func parse(sc *bufio.Scanner, dir, logDir string) *Report {
+	r := &Report{}
+	written, deleted, network := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	add := func(set map[string]bool, list *[]string, path string) {
+		if path == "" || set[path] {
+			return
+		}
+		set[path] = true
+		*list = append(*list, path)
+	}
+	resolve := func(p string) string {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		p = filepath.Clean(p)
+		if within(p, logDir) {
+			return ""
+		}
+		for _, prefix := range ignoredPrefixes {
+			if strings.HasPrefix(p+"/", prefix) {
+				return ""
+			}
+		}
+		return p
+	}
+
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		m := callLine.FindStringSubmatch(sc.Text())
+		if m == nil || ignoredErrors[m[4]] {
+			continue
+		}
+		name, args := m[1], m[2]
+		var paths []string
+		for _, q := range quoted.FindAllStringSubmatch(args, -1) {
+			paths = append(paths, unescape(q[1]))
+		}
+
+		switch name {
+		case "open", "openat", "openat2":
+			if len(paths) > 0 && writeFlags.MatchString(args) {
+				add(written, &r.Written, resolve(paths[0]))
+			}
+		case "creat", "mkdir", "mkdirat", "truncate", "chmod", "fchmodat", "chown", "lchown", "fchownat", "utimensat", "mknod", "mknodat":
+			if len(paths) > 0 {
+				add(written, &r.Written, resolve(paths[0]))
+			}
+		case "symlink", "symlinkat", "link", "linkat":
+			// 最后一个字符串参数是新建的链接
+			if len(paths) > 0 {
+				add(written, &r.Written, resolve(paths[len(paths)-1]))
+			}
+		case "unlink", "unlinkat", "rmdir":
+			if len(paths) > 0 {
+				add(deleted, &r.Deleted, resolve(paths[0]))
+			}
+		case "rename", "renameat", "renameat2":
+			if len(paths) == 2 {
+				add(deleted, &r.Deleted, resolve(paths[0]))
+				add(written, &r.Written, resolve(paths[1]))
+			}
+		case "connect", "sendto", "sendmsg":
+			add(network, &r.Network, endpoint(args))
+		}
+	}
+	return r
+}
+
+// endpoint 从 sockaddr 参数中提取网络地址
+func endpoint(args string) string {
+	if m := inet.FindStringSubmatch(args); m != nil {
+		return m[2] + ":" + m[1]
+	}
+	if m := inet6.FindStringSubmatch(args); m != nil {
+		return "[" + m[2] + "]:" + m[1]
+	}
+	if m := unix.FindStringSubmatch(args); m != nil {
+		return "unix:" + m[1]
+	}
+	return ""
+}
+
+// Render 格式化报告
+func (r *Report) Render() string {
+	var b strings.Builder
+	b.WriteString("🔬 沙箱预演（改动已丢弃，网络与本机服务已断开）:\n")
+	section := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "  %s:\n", title)
+		for _, item := range items[:min(len(items), maxListed)] {
+			fmt.Fprintf(&b, "    %s\n", item)
+		}
+		if len(items) > maxListed {
+			fmt.Fprintf(&b, "    …还有 %d 项\n", len(items)-maxListed)
+		}
+	}
+	section("将创建或修改", r.Written)
+	section("将删除", r.Deleted)
+	section("将连接", r.Network)
+	if len(r.Written)+len(r.Deleted)+len(r.Network) == 0 {
+		b.WriteString("  未发现文件改动或网络访问\n")
+	}
+	if r.TimedOut {
+		fmt.Fprintf(&b, "  命令在 %s 内没有结束，以上结果可能不完整\n", timeout)
+	} else if r.ExitCode != 0 {
+		fmt.Fprintf(&b, "  沙箱中退出码为 %d（只读文件系统或断网可能导致提前退出，以上结果可能不完整）\n", r.ExitCode)
+	}
+	return b.String()
+}
+
+// within 判断 path 是否为 dir 或其子路径
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// unescape 还原 strace 对字符串参数的转义
+func unescape(s string) string {
+	if u, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return u
+	}
+	return s
+}
+
+func firstLine(stderr string, err error) string {
+	lines := slices.DeleteFunc(strings.Split(stderr, "\n"), func(l string) bool { return strings.TrimSpace(l) == "" })
+	if len(lines) > 0 {
+		return lines[0]
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return "未知错误"
+}
@@ -0,0 +1,183 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// defaultTTL 稳定环境信息（系统版本、工具版本、工具是否安装）的缓存时间
+const defaultTTL = 24 * time.Hour
+
+// errorTTL 失败探测的缓存时间，一次偶然的失败不会让工具在一整天里都显示为不可用
+const errorTTL = time.Minute
+
+// volatile 输出随时变化、不应缓存的探测程序
+var volatile = map[string]bool{
+	"df":   true,
+	"free": true,
+	"pwd":  true,
+	"ls":   true,
+}
+
+// cacheEntry 单条探测缓存
+type cacheEntry struct {
+	Output  string    `json:"output"`
+	Err     string    `json:"err,omitempty"`
+	Machine string    `json:"machine"`
+	Expires time.Time `json:"expires"`
+}
+
+// Cache 探测结果的磁盘缓存，过期或环境指纹变化后自动失效：升级系统、安装或卸载工具后
+// 全部失效；探测命令的结果还与工作目录及 git 分支绑定，例如 git branch、python --version
+// （pyenv、nvm 按目录切换版本）
+type Cache struct {
+	mu      sync.Mutex
+	once    sync.Once
+	path    string
+	fp      Fingerprint
+	entries map[string]cacheEntry
+}
+
+// OpenCache 打开探测缓存文件，文件不存在或损坏时从空缓存开始。文件在第一次使用时才读取
+func OpenCache(path string) *Cache {
+	return &Cache{path: path}
+}
+
+// open 读取缓存文件并计算环境指纹。推迟到第一次探测时进行，
+// 由离线命令库直接回答的请求不必遍历 PATH 中的目录
+func (c *Cache) open() {
+	c.once.Do(func() {
+		dir, _ := os.Getwd()
+		c.fp = TakeFingerprint(dir)
+		c.entries = map[string]cacheEntry{}
+		if data, err := os.ReadFile(c.path); err == nil {
+			_ = json.Unmarshal(data, &c.entries)
+		}
+	})
+}
+
+// Run 执行只读探测，命中有效缓存时直接返回缓存结果
+func (c *Cache) Run(ctx context.Context, cmdStr string) (string, error) {
+	if c == nil {
+		return Run(ctx, cmdStr)
+	}
+	c.open()
+
+	// 探测结果可能随目录变化，键中带上工作目录与分支的指纹
+	cmdStr = strings.Join(strings.Fields(cmdStr), " ")
+	key := c.fp.Place + ":" + cmdStr
+	if e, ok := c.lookup(key); ok {
+		if e.Err != "" {
+			return e.Output, errors.New(e.Err)
+		}
+		return e.Output, nil
+	}
+
+	out, err := Run(ctx, cmdStr)
+	if ttl := entryTTL(cmdStr, err); ttl > 0 && ctx.Err() == nil {
+		e := cacheEntry{Output: out, Machine: c.fp.Machine, Expires: time.Now().Add(ttl)}
+		if err != nil {
+			e.Err = err.Error()
+		}
+		c.store(key, e)
+	}
+	return out, err
+}
+
+// Installed 检测工具是否已安装（位于 PATH 中），结果与工作目录无关
+func (c *Cache) Installed(names ...string) map[string]bool {
+	c.open()
+	found := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := "which:" + name
+		if e, ok := c.lookup(key); ok {
+			found[name] = e.Output != ""
+			continue
+		}
+		path, _ := exec.LookPath(name)
+		found[name] = path != ""
+		c.store(key, cacheEntry{Output: path, Machine: c.fp.Machine, Expires: time.Now().Add(defaultTTL)})
+	}
+	return found
+}
+
+// Save 将缓存与其他进程在此期间写入的条目合并后写回磁盘，同一条目保留较晚过期的结果，
+// 并清理已过期或系统环境已变化的条目；其他目录的条目保留到过期
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.open()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return filelock.Update(c.path, 0600, func(data []byte) ([]byte, error) {
+		var disk map[string]cacheEntry
+		_ = json.Unmarshal(data, &disk)
+		for k, e := range disk {
+			if mine, ok := c.entries[k]; !ok || e.Expires.After(mine.Expires) {
+				c.entries[k] = e
+			}
+		}
+
+		now := time.Now()
+		for k, e := range c.entries {
+			if e.Machine != c.fp.Machine || now.After(e.Expires) {
+				delete(c.entries, k)
+			}
+		}
+		return json.Marshal(c.entries)
+	})
+}
+
+func (c *Cache) lookup(key string) (cacheEntry, bool) {
+	if c == nil {
+		return cacheEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || e.Machine != c.fp.Machine || time.Now().After(e.Expires) {
+		return cacheEntry{}, false
+	}
+	return e, true
+}
+
+func (c *Cache) store(key string, e cacheEntry) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = e
+}
+
+// entryTTL 返回一次探测结果的缓存时间，0 表示不缓存：超时的探测不缓存，其他失败只缓存 errorTTL
+func entryTTL(cmdStr string, err error) time.Duration {
+	switch {
+	case err == nil:
+		return ttlFor(cmdStr)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return 0
+	default:
+		return min(ttlFor(cmdStr), errorTTL)
+	}
+}
+
+// ttlFor 返回探测命令的缓存时间，0 表示不缓存
+func ttlFor(cmdStr string) time.Duration {
+	name, _, _ := strings.Cut(cmdStr, " ")
+	if volatile[name] {
+		return 0
+	}
+	return defaultTTL
+}
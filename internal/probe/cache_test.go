@@ -0,0 +1,69 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEntryTTL(t *testing.T) {
+	timeout := fmt.Errorf("探测超时: %w", context.DeadlineExceeded)
+	tests := []struct {
+		name   string
+		cmdStr string
+		err    error
+		want   time.Duration
+	}{
+		{"Success", "uname -a", nil, defaultTTL},
+		{"Volatile", "df -h", nil, 0},
+		{"Failure", "git --version", errors.New("exit status 1"), errorTTL},
+		{"VolatileFailure", "ls /nonexistent", errors.New("exit status 2"), 0},
+		{"Timeout", "java -version", timeout, 0},
+		{"Canceled", "node --version", context.Canceled, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := entryTTL(tc.cmdStr, tc.err); got != tc.want {
+				t.Errorf("entryTTL(%q, %v) = %v, want %v", tc.cmdStr, tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCacheRunExpiry(t *testing.T) {
+	if _, err := exec.LookPath("uname"); err != nil {
+		t.Skip("uname not installed")
+	}
+	c := OpenCache(filepath.Join(t.TempDir(), "probes.json"))
+	tests := []struct {
+		cmdStr  string
+		wantErr bool
+		ttl     time.Duration
+	}{
+		{"uname -s", false, defaultTTL},
+		{"uname --no-such-flag", true, errorTTL},
+	}
+	for _, tc := range tests {
+		t.Run(tc.cmdStr, func(t *testing.T) {
+			before := time.Now()
+			if _, err := c.Run(context.Background(), tc.cmdStr); (err != nil) != tc.wantErr {
+				t.Fatalf("Run() error = %v, want error %v", err, tc.wantErr)
+			}
+			e, ok := c.lookup(c.fp.Place + ":" + tc.cmdStr)
+			if !ok {
+				t.Fatal("result not cached")
+			}
+			if e.Expires.Before(before.Add(tc.ttl)) || e.Expires.After(time.Now().Add(tc.ttl)) {
+				t.Errorf("expires in %v, want %v", e.Expires.Sub(before), tc.ttl)
+			}
+			// 命中缓存时返回相同的结果
+			if _, err := c.Run(context.Background(), tc.cmdStr); (err != nil) != tc.wantErr {
+				t.Errorf("cached Run() error = %v, want error %v", err, tc.wantErr)
+			}
+		})
+	}
+}
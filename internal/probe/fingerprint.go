@@ -0,0 +1,92 @@
+package probe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// releaseFiles 记录系统版本的文件，升级系统或内核后内容改变
+var releaseFiles = []string{
+	"/etc/os-release",
+	"/proc/sys/kernel/osrelease",
+	"/System/Library/CoreServices/SystemVersion.plist",
+}
+
+// Fingerprint 环境指纹，影响命令写法的环境事实变化时随之改变，用作缓存键的一部分
+type Fingerprint struct {
+	Machine string // 系统版本与 PATH 中各目录的修改时间：升级系统、安装或卸载工具后改变
+	Place   string // 工作目录与 git 分支：换目录或切换分支后改变
+}
+
+// TakeFingerprint 计算在 dir 中的环境指纹，只读取文件与元数据，不启动子进程
+func TakeFingerprint(dir string) Fingerprint {
+	machine := []string{runtime.GOOS, runtime.GOARCH, os.Getenv("PATH")}
+	for _, path := range releaseFiles {
+		if data, err := os.ReadFile(path); err == nil {
+			machine = append(machine, string(data))
+		}
+	}
+	if root := os.Getenv("SystemRoot"); root != "" {
+		// Windows 没有版本文件，系统更新会替换内核文件
+		machine = append(machine, modTime(filepath.Join(root, "System32", "ntoskrnl.exe")))
+	}
+	// 安装或卸载工具会改变其所在目录的修改时间
+	for _, d := range filepath.SplitList(os.Getenv("PATH")) {
+		machine = append(machine, modTime(d))
+	}
+	return Fingerprint{Machine: digest(machine...), Place: digest(dir, gitHead(dir))}
+}
+
+// String 返回合并后的指纹
+func (f Fingerprint) String() string {
+	return f.Machine + "-" + f.Place
+}
+
+// gitHead 返回 dir 所在仓库的 HEAD（当前分支或分离的提交），不在仓库中时返回空字符串
+func gitHead(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if info, err := os.Stat(gitDir); err == nil {
+			if !info.IsDir() {
+				// worktree 与子模块的 .git 是指向真正目录的文件
+				data, err := os.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+				if !ok {
+					return ""
+				}
+				if !filepath.IsAbs(target) {
+					target = filepath.Join(dir, target)
+				}
+				gitDir = target
+			}
+			head, _ := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			return strings.TrimSpace(string(head))
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func modTime(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return info.ModTime().UTC().Format(time.RFC3339Nano)
+}
+
+func digest(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,203 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// maxOutput 单次探测输出的最大字节数，避免把大量无关内容送给 LLM
+const maxOutput = 2048
+
+// defaultTimeout 单次探测的超时时间
+const defaultTimeout = 5 * time.Second
+
+// allowed 只读探测白名单：程序名 -> 参数校验函数
+var allowed = map[string]func(args []string) bool{
+	"uname":       anyFlags,
+	"arch":        noArgs,
+	"sw_vers":     anyFlags,
+	"lsb_release": anyFlags,
+	"whoami":      noArgs,
+	"id":          anyFlags,
+	"hostname":    noArgs,
+	"pwd":         noArgs,
+	"nproc":       noArgs,
+	"df":          anyFlags,
+	"free":        anyFlags,
+	"which":       anyArgs,
+	"ls":          anyArgs,
+	"cat":         oneOf("/etc/os-release", "/etc/issue", "/proc/version"),
+}
+
+// versionFlags 任意已安装工具均可使用的只读版本查询参数
+var versionFlags = map[string]bool{
+	"--version": true,
+	"-version":  true,
+	"-V":        true,
+}
+
+// versionCommands 以 version 子命令查询版本的工具。其他程序的 version 只是普通参数，
+// 例如 rm version、mkdir version，不能当作只读探测
+var versionCommands = map[string]bool{
+	"go":        true,
+	"kubectl":   true,
+	"helm":      true,
+	"docker":    true,
+	"podman":    true,
+	"terraform": true,
+	"tofu":      true,
+	"gh":        true,
+	"oc":        true,
+	"minikube":  true,
+	"kind":      true,
+	"istioctl":  true,
+	"flux":      true,
+	"vault":     true,
+	"consul":    true,
+	"nomad":     true,
+}
+
+// versionQuery 判断 name args 是否为只读的版本查询
+func versionQuery(name string, args []string) bool {
+	if len(args) != 1 {
+		return false
+	}
+	return versionFlags[args[0]] || args[0] == "version" && versionCommands[name]
+}
+
+// Allowed 判断命令是否属于只读探测白名单
+func Allowed(cmdStr string) bool {
+	_, _, err := parse(cmdStr)
+	return err == nil
+}
+
+// Run 执行白名单内的只读探测命令，返回合并后的标准输出和标准错误
+func Run(ctx context.Context, cmdStr string) (string, error) {
+	name, args, err := parse(cmdStr)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	runErr := cmd.Run()
+	if runErr != nil && ctx.Err() != nil {
+		// 超时被结束的进程只报告 signal: killed，换成可识别的超时错误
+		runErr = fmt.Errorf("探测超时: %w", ctx.Err())
+	}
+
+	text := out.String()
+	if len(text) > maxOutput {
+		text = text[:maxOutput] + "\n...(已截断)"
+	}
+	return strings.TrimSpace(text), runErr
+}
+
+// parse 拆分并校验探测命令，不经过 shell，禁止任何 shell 元字符
+func parse(cmdStr string) (string, []string, error) {
+	if strings.ContainsAny(cmdStr, "|&;<>$`\\(){}*?'\"\n") {
+		return "", nil, fmt.Errorf("探测命令包含不允许的字符: %s", cmdStr)
+	}
+
+	fields := strings.Fields(cmdStr)
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("探测命令为空")
+	}
+
+	name, args := fields[0], fields[1:]
+	if check, ok := allowed[name]; ok && check(args) {
+		return name, args, nil
+	}
+	if versionQuery(name, args) && !strings.Contains(name, "/") {
+		if _, err := exec.LookPath(name); err == nil {
+			return name, args, nil
+		}
+	}
+	return "", nil, fmt.Errorf("探测命令不在只读白名单内: %s", cmdStr)
+}
+
+func noArgs(args []string) bool {
+	return len(args) == 0
+}
+
+func anyFlags(args []string) bool {
+	for _, a := range args {
+		if !strings.HasPrefix(a, "-") {
+			return false
+		}
+	}
+	return true
+}
+
+func anyArgs(args []string) bool {
+	return true
+}
+
+func oneOf(paths ...string) func(args []string) bool {
+	return func(args []string) bool {
+		if len(args) != 1 {
+			return false
+		}
+		for _, p := range paths {
+			if args[0] == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RunRemote 通过 SSH 在远程主机上执行白名单内的只读探测命令
+func RunRemote(ctx context.Context, host, cmdStr string) (string, error) {
+	name, args, err := parseRemote(cmdStr)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout*2)
+	defer cancel()
+
+	sshArgs := append([]string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=5", host, "--", name}, args...)
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ssh", sshArgs...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	runErr := cmd.Run()
+	if runErr != nil && ctx.Err() != nil {
+		// 超时被结束的进程只报告 signal: killed，换成可识别的超时错误
+		runErr = fmt.Errorf("探测超时: %w", ctx.Err())
+	}
+
+	text := out.String()
+	if len(text) > maxOutput {
+		text = text[:maxOutput] + "\n...(已截断)"
+	}
+	return strings.TrimSpace(text), runErr
+}
+
+// parseRemote 校验远程探测命令；远程主机上的工具无法在本地检查，版本查询不要求本地已安装
+func parseRemote(cmdStr string) (string, []string, error) {
+	if name, args, err := parse(cmdStr); err == nil {
+		return name, args, nil
+	}
+	fields := strings.Fields(cmdStr)
+	if len(fields) == 2 && versionQuery(fields[0], fields[1:]) && !strings.ContainsAny(cmdStr, "|&;<>$`\\(){}*?'\"\n/") {
+		return fields[0], fields[1:], nil
+	}
+	return "", nil, fmt.Errorf("探测命令不在只读白名单内: %s", cmdStr)
+}
+
+// AllowedRemote 判断命令是否可作为远程只读探测执行
+func AllowedRemote(cmdStr string) bool {
+	_, _, err := parseRemote(cmdStr)
+	return err == nil
+}
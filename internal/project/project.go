@@ -0,0 +1,166 @@
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// Kind 项目类型
+type Kind string
+
+const (
+	Node      Kind = "node"
+	Go        Kind = "go"
+	Rust      Kind = "rust"
+	Python    Kind = "python"
+	Terraform Kind = "terraform"
+)
+
+// markers 各项目类型的标志文件
+var markers = []struct {
+	kind  Kind
+	files []string
+}{
+	{Node, []string{"package.json"}},
+	{Go, []string{"go.mod"}},
+	{Rust, []string{"Cargo.toml"}},
+	{Python, []string{"pyproject.toml", "setup.py", "setup.cfg", "requirements.txt", "Pipfile"}},
+	{Terraform, []string{"*.tf"}},
+}
+
+// Detect 根据标志文件识别目录的项目类型，可能同时属于多种类型
+func Detect(dir string) []Kind {
+	var kinds []Kind
+	for _, m := range markers {
+		for _, pattern := range m.files {
+			if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) > 0 {
+				kinds = append(kinds, m.kind)
+				break
+			}
+		}
+	}
+	return kinds
+}
+
+// Describe 生成目录的简要说明供模型参考：项目类型、顶层文件以及已定义的项目任务
+func Describe(dir string, kinds []Kind) string {
+	var b strings.Builder
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	fmt.Fprintf(&b, "项目类型: %s\n", strings.Join(names, ", "))
+
+	if entries, err := os.ReadDir(dir); err == nil {
+		var files []string
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			name := e.Name()
+			if e.IsDir() {
+				name += "/"
+			}
+			files = append(files, name)
+		}
+		if len(files) > 40 {
+			files = files[:40]
+		}
+		fmt.Fprintf(&b, "顶层文件: %s\n", strings.Join(files, " "))
+	}
+
+	if tasks := Tasks(dir); len(tasks) > 0 {
+		names := make([]string, len(tasks))
+		for i, t := range tasks {
+			names[i] = t.Command
+		}
+		fmt.Fprintf(&b, "项目任务: %s\n", strings.Join(names, ", "))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Action 项目快捷操作，例如运行测试、构建
+type Action struct {
+	Title   string `json:"title"`
+	Command string `json:"command"`
+}
+
+// actionsTTL 快捷操作的有效期，环境没有变化时也定期重新生成
+const actionsTTL = 7 * 24 * time.Hour
+
+// entry 单个目录缓存的快捷操作
+type entry struct {
+	Dir         string    `json:"dir"`
+	Kinds       []Kind    `json:"kinds"`
+	Fingerprint string    `json:"fingerprint"`
+	Actions     []Action  `json:"actions"`
+	Created     time.Time `json:"created"`
+}
+
+// Store 按目录缓存快捷操作，环境与项目任务不变时每个目录只生成一次
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewStore 创建快捷操作缓存
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Load 读取目录的快捷操作。fingerprint 为生成时的环境指纹（系统、已安装工具、git 分支），
+// 它、项目类型或任务文件发生变化，以及超过有效期时视为未缓存
+func (s *Store) Load(dir string, kinds []Kind, fingerprint string) ([]Action, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path(dir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("读取快捷操作缓存失败: %w", err)
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false, fmt.Errorf("解析快捷操作缓存失败: %w", err)
+	}
+	if !slices.Equal(e.Kinds, kinds) || e.Fingerprint != fingerprint+signature(dir) ||
+		time.Since(e.Created) > actionsTTL || len(e.Actions) == 0 {
+		return nil, false, nil
+	}
+	return e.Actions, true, nil
+}
+
+// Save 写入目录的快捷操作及生成时的环境指纹
+func (s *Store) Save(dir string, kinds []Kind, fingerprint string, actions []Action) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := entry{Dir: dir, Kinds: kinds, Fingerprint: fingerprint + signature(dir), Actions: actions, Created: time.Now()}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("创建快捷操作缓存目录失败: %w", err)
+	}
+	return filelock.WriteFile(s.path(dir), data, 0600)
+}
+
+// path 返回目录对应的缓存文件路径，以绝对路径的哈希命名
+func (s *Store) path(dir string) string {
+	sum := sha256.Sum256([]byte(dir))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json")
+}
@@ -0,0 +1,222 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// maxTasks 附加到提示词中的任务数量上限
+const maxTasks = 40
+
+// Task 项目中已定义的任务，例如 npm script、Makefile 目标
+type Task struct {
+	Runner  string `json:"runner"`  // npm、make、just、task
+	Name    string `json:"name"`    // 任务名
+	Command string `json:"command"` // 运行该任务的命令，例如 "make test"
+}
+
+// taskFiles 定义任务的文件及其解析函数
+var taskFiles = []struct {
+	names []string
+	parse func(dir string, data []byte) []Task
+}{
+	{[]string{"package.json"}, parsePackageJSON},
+	{[]string{"Makefile", "makefile", "GNUmakefile"}, parseMakefile},
+	{[]string{"justfile", "Justfile", ".justfile"}, parseJustfile},
+	{[]string{"Taskfile.yml", "Taskfile.yaml"}, parseTaskfile},
+}
+
+// Tasks 解析目录中的 package.json scripts、Makefile 目标、justfile recipes 与 Taskfile 任务
+func Tasks(dir string) []Task {
+	var tasks []Task
+	for _, tf := range taskFiles {
+		for _, name := range tf.names {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			tasks = append(tasks, tf.parse(dir, data)...)
+			break
+		}
+	}
+	return tasks
+}
+
+// RenderTasks 将任务格式化为提示词片段
+func RenderTasks(tasks []Task) string {
+	if len(tasks) > maxTasks {
+		tasks = tasks[:maxTasks]
+	}
+	var b strings.Builder
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "- %s\n", t.Command)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// lockfiles 包管理器锁文件，决定 package.json scripts 的运行方式
+var lockfiles = []struct{ name, runner string }{
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "yarn"},
+	{"bun.lockb", "bun"},
+	{"bun.lock", "bun"},
+}
+
+// parsePackageJSON 按锁文件选择 npm、pnpm、yarn 或 bun 运行 scripts
+func parsePackageJSON(dir string, data []byte) []Task {
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+
+	runner := "npm"
+	for _, l := range lockfiles {
+		if _, err := os.Stat(filepath.Join(dir, l.name)); err == nil {
+			runner = l.runner
+			break
+		}
+	}
+	prefix := runner + " run "
+
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tasks := make([]Task, 0, len(names))
+	for _, name := range names {
+		tasks = append(tasks, Task{Runner: runner, Name: name, Command: prefix + name})
+	}
+	return tasks
+}
+
+// makeTarget 匹配 Makefile 中的显式目标，排除变量赋值（:=、::=）
+var makeTarget = regexp.MustCompile(`^([A-Za-z0-9][\w./-]*)\s*:([^=]|$)`)
+
+func parseMakefile(_ string, data []byte) []Task {
+	return scanLines(data, "make", "make ", func(line string) string {
+		if m := makeTarget.FindStringSubmatch(line); m != nil && !strings.Contains(m[1], "%") {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// justRecipe 匹配 justfile 中顶格的 recipe 定义，可带参数与 @ 前缀
+var justRecipe = regexp.MustCompile(`^@?([A-Za-z_][\w-]*)(?:\s+[^:]*)?:([^=]|$)`)
+
+func parseJustfile(_ string, data []byte) []Task {
+	return scanLines(data, "just", "just ", func(line string) string {
+		if m := justRecipe.FindStringSubmatch(line); m != nil && m[1] != "set" && m[1] != "export" && m[1] != "alias" {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// taskfileTask 匹配 Taskfile 中 tasks 下一级缩进的任务名
+var taskfileTask = regexp.MustCompile(`^  ([\w:.-]+):\s*(?:#.*)?$`)
+
+func parseTaskfile(_ string, data []byte) []Task {
+	inTasks := false
+	return scanLines(data, "task", "task ", func(line string) string {
+		if !strings.HasPrefix(line, " ") && line != "" {
+			inTasks = strings.HasPrefix(line, "tasks:")
+			return ""
+		}
+		if m := taskfileTask.FindStringSubmatch(line); inTasks && m != nil {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// scanLines 逐行提取任务名并去重
+func scanLines(data []byte, runner, prefix string, name func(line string) string) []Task {
+	var tasks []Task
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		n := name(strings.TrimRight(sc.Text(), "\r"))
+		if n == "" || seen[n] || strings.HasPrefix(n, ".") {
+			continue
+		}
+		seen[n] = true
+		tasks = append(tasks, Task{Runner: runner, Name: n, Command: prefix + n})
+	}
+	return tasks
+}
+
+// taskIndex 单个目录缓存的任务索引，signature 为任务文件的修改时间与大小
+type taskIndex struct {
+	Dir       string `json:"dir"`
+	Signature string `json:"signature"`
+	Tasks     []Task `json:"tasks"`
+}
+
+// CachedTasks 返回目录的任务索引，任务文件未变化时直接读取缓存，否则重新解析并写回
+func (s *Store) CachedTasks(dir string) []Task {
+	sig := signature(dir)
+	if sig == "" {
+		return nil
+	}
+
+	path := strings.TrimSuffix(s.path(dir), ".json") + ".tasks.json"
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var idx taskIndex
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &idx) == nil && idx.Signature == sig {
+		return idx.Tasks
+	}
+
+	idx = taskIndex{Dir: dir, Signature: sig, Tasks: Tasks(dir)}
+	if data, err := json.Marshal(idx); err == nil && os.MkdirAll(s.dir, 0700) == nil {
+		// 缓存写入失败只会导致下次重新解析
+		_ = filelock.WriteFile(path, data, 0600)
+	}
+	return idx.Tasks
+}
+
+// signature 根据任务文件的修改时间与大小生成索引签名，没有任务文件时返回空字符串
+func signature(dir string) string {
+	var parts []string
+	for _, tf := range taskFiles {
+		for _, name := range tf.names {
+			info, err := os.Stat(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			parts = append(parts, fmt.Sprintf("%s:%d:%d", name, info.ModTime().UnixNano(), info.Size()))
+		}
+	}
+	// 锁文件决定 package.json scripts 的运行方式
+	for _, l := range lockfiles {
+		if _, err := os.Stat(filepath.Join(dir, l.name)); err == nil {
+			parts = append(parts, l.name)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, ";")
+}
+
+// HasContext 报告目录中是否有会被读取并注入提示词的项目数据（项目标志文件、任务文件或 git 仓库）
+func HasContext(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	return len(Detect(dir)) > 0 || signature(dir) != ""
+}
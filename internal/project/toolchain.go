@@ -0,0 +1,174 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxAncestors 向上查找项目根目录的最大层数
+const maxAncestors = 8
+
+// Toolchain 当前目录所属项目的工具链信息，用于让模型选用对应的构建、测试命令
+type Toolchain struct {
+	Kind    Kind   // 项目类型
+	Root    string // 标志文件所在目录
+	Name    string // 模块名或包名，无法确定时为空
+	Manager string // 包管理或构建工具，例如 pnpm、poetry、uv
+}
+
+// Toolchains 从 dir 开始向上查找各类项目的标志文件，每种类型取最近的一个，
+// 在 git 仓库根目录或用户主目录处停止。Terraform 只认当前目录，它的命令总是作用于当前目录的配置
+func Toolchains(dir string) []Toolchain {
+	var out []Toolchain
+	home, _ := os.UserHomeDir()
+	found := map[Kind]bool{}
+	for i, cur := 0, dir; i < maxAncestors; i++ {
+		for _, kind := range Detect(cur) {
+			if found[kind] || kind == Terraform && cur != dir {
+				continue
+			}
+			found[kind] = true
+			out = append(out, inspect(kind, cur))
+		}
+		if _, err := os.Stat(filepath.Join(cur, ".git")); err == nil || cur == home {
+			break
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			break
+		}
+		cur = parent
+	}
+	return out
+}
+
+// inspect 读取项目的模块名或包名，以及锁文件指示的包管理工具
+func inspect(kind Kind, root string) Toolchain {
+	t := Toolchain{Kind: kind, Root: root}
+	read := func(name string) []byte {
+		data, _ := os.ReadFile(filepath.Join(root, name))
+		return data
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+
+	switch kind {
+	case Go:
+		t.Manager = "go"
+		if m := goModule.FindSubmatch(read("go.mod")); m != nil {
+			t.Name = string(m[1])
+		}
+	case Node:
+		t.Manager = "npm"
+		for _, l := range lockfiles {
+			if exists(l.name) {
+				t.Manager = l.runner
+				break
+			}
+		}
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(read("package.json"), &pkg) == nil {
+			t.Name = pkg.Name
+		}
+	case Rust:
+		t.Manager = "cargo"
+		t.Name = tomlName(read("Cargo.toml"), "[package]")
+	case Python:
+		t.Manager = "pip"
+		for _, l := range pythonManagers {
+			if exists(l.name) {
+				t.Manager = l.runner
+				break
+			}
+		}
+		data := read("pyproject.toml")
+		if t.Name = tomlName(data, "[project]"); t.Name == "" {
+			t.Name = tomlName(data, "[tool.poetry]")
+		}
+	case Terraform:
+		t.Manager = "terraform"
+	}
+	return t
+}
+
+var (
+	// goModule 匹配 go.mod 中的 module 指令
+	goModule = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+
+	// tomlKey 匹配 TOML 中的 name = "..."
+	tomlKey = regexp.MustCompile(`^name\s*=\s*["']([^"']+)["']`)
+)
+
+// pythonManagers Python 项目的锁文件及对应的工具
+var pythonManagers = []struct{ name, runner string }{
+	{"uv.lock", "uv"},
+	{"poetry.lock", "poetry"},
+	{"pdm.lock", "pdm"},
+	{"Pipfile.lock", "pipenv"},
+	{"Pipfile", "pipenv"},
+}
+
+// tomlName 读取 TOML 文件中指定表下的 name 字段
+func tomlName(data []byte, table string) string {
+	in := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "[") {
+			in = line == table
+			continue
+		}
+		if m := tomlKey.FindStringSubmatch(line); in && m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// toolchainHints 各工具链的常用命令，提示模型“运行测试”之类的需求在该项目中应该用什么命令
+var toolchainHints = map[string]string{
+	"go":        "测试 go test ./...，构建 go build ./...，静态检查 go vet ./...，整理依赖 go mod tidy，添加依赖 go get",
+	"npm":       "安装依赖 npm install，添加依赖 npm install <包>，运行脚本 npm run <脚本>，测试 npm test",
+	"pnpm":      "安装依赖 pnpm install，添加依赖 pnpm add <包>，运行脚本 pnpm run <脚本>，测试 pnpm test",
+	"yarn":      "安装依赖 yarn install，添加依赖 yarn add <包>，运行脚本 yarn run <脚本>，测试 yarn test",
+	"bun":       "安装依赖 bun install，添加依赖 bun add <包>，运行脚本 bun run <脚本>，测试 bun test",
+	"cargo":     "测试 cargo test，构建 cargo build，静态检查 cargo clippy，格式化 cargo fmt，添加依赖 cargo add",
+	"pip":       "测试 python -m pytest，安装依赖 python -m pip install -r requirements.txt 或 python -m pip install -e .",
+	"uv":        "测试 uv run pytest，安装依赖 uv sync，添加依赖 uv add <包>，运行脚本 uv run <命令>",
+	"poetry":    "测试 poetry run pytest，安装依赖 poetry install，添加依赖 poetry add <包>，运行脚本 poetry run <命令>",
+	"pdm":       "测试 pdm run pytest，安装依赖 pdm install，添加依赖 pdm add <包>，运行脚本 pdm run <命令>",
+	"pipenv":    "测试 pipenv run pytest，安装依赖 pipenv install，添加依赖 pipenv install <包>，运行脚本 pipenv run <命令>",
+	"terraform": "初始化 terraform init，预览 terraform plan，应用 terraform apply，格式化 terraform fmt，校验 terraform validate",
+}
+
+// RenderToolchains 将工具链信息格式化为提示词片段，dir 为当前目录，用于显示项目根目录的相对位置
+func RenderToolchains(dir string, toolchains []Toolchain) string {
+	var b strings.Builder
+	for _, t := range toolchains {
+		fmt.Fprintf(&b, "- %s 项目", t.Kind)
+		if t.Name != "" {
+			fmt.Fprintf(&b, " %s", t.Name)
+		}
+		if rel, err := filepath.Rel(dir, t.Root); err == nil && rel != "." {
+			fmt.Fprintf(&b, "（根目录 %s）", rel)
+		}
+		if t.Manager != "" {
+			fmt.Fprintf(&b, "，使用 %s", t.Manager)
+		}
+		if hint := toolchainHints[t.Manager]; hint != "" {
+			fmt.Fprintf(&b, ": %s", hint)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,265 @@
+// Package proxy 实现 termi serve --cache-proxy：对外提供 OpenAI 兼容的 Chat Completions 端点，
+// 在转发给配置的提供商前统一做脱敏、共享缓存与每日用量限制，团队成员的 termi 只需把
+// openai.base_url 指向代理即可共用同一套策略
+package proxy
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+)
+
+// maxBodyBytes 请求体大小上限
+const maxBodyBytes = 1 << 20
+
+// Server 缓存代理，可安全地并发处理请求
+type Server struct {
+	client *llm.Client
+	cfg    config.ServeConfig
+	cache  *cache
+
+	mu     sync.Mutex
+	day    string
+	budget *llm.Budget
+}
+
+// New 创建代理，client 为转发请求使用的提供商客户端
+func New(cfg config.ServeConfig, client *llm.Client) *Server {
+	return &Server{
+		client: client,
+		cfg:    cfg,
+		cache:  newCache(cfg.Size(), cfg.TTL()),
+	}
+}
+
+// Handler 返回代理的 HTTP 路由
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", s.handleChat)
+	mux.HandleFunc("POST /chat/completions", s.handleChat)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+// Serve 在配置的地址上运行代理直到 ctx 结束
+func (s *Server) Serve(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.cfg.Address(),
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid_api_key", "API key 无效")
+		return
+	}
+
+	var req openai.ChatCompletionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("无法解析请求: %v", err))
+		return
+	}
+	if req.Stream {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "代理不支持流式响应")
+		return
+	}
+	system, prompt := splitMessages(req.Messages)
+	if prompt == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "请求中没有用户消息")
+		return
+	}
+
+	// 先脱敏再计算缓存键，不同用户的相同需求可以命中同一条缓存，敏感内容也不会离开代理；
+	// 请求的模型不同时回复也不同，不共用缓存
+	system, prompt = s.client.Redact(system), s.client.Redact(prompt)
+	key := cacheKey(req.Model, system, prompt)
+	if content, ok := s.cache.get(key); ok {
+		writeCompletion(w, req.Model, content, 0)
+		return
+	}
+
+	// 每次向提供商发起请求前在用量上限内预留一次请求，并发的请求不会同时通过检查而超出当天的上限
+	reply, err := s.client.With(llm.WithBudget(s.todayBudget())).Forward(r.Context(), system, prompt)
+	if errors.Is(err, llm.ErrBudgetExceeded) {
+		writeError(w, http.StatusTooManyRequests, "insufficient_quota", "已达到代理今天的 LLM 用量上限")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "upstream_error", err.Error())
+		return
+	}
+
+	data, err := json.Marshal(reply)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	s.cache.put(key, string(data))
+	writeCompletion(w, req.Model, string(data), reply.Tokens)
+}
+
+// authorized 检查 Bearer token，未配置 api_keys 时允许所有请求
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.cfg.APIKeys) == 0 {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, key := range s.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// todayBudget 返回当天共享的用量上限，跨天后重新计数
+func (s *Server) todayBudget() *llm.Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if today := time.Now().Format(time.DateOnly); today != s.day {
+		s.day = today
+		s.budget = llm.NewBudget(s.cfg.DailyCalls, s.cfg.DailyTokens)
+	}
+	return s.budget
+}
+
+// Summary 返回当天的用量与缓存情况
+func (s *Server) Summary() string {
+	return fmt.Sprintf("%s，缓存 %d 条", s.todayBudget().Summary(), s.cache.len())
+}
+
+// splitMessages 拆分出系统提示词与用户提示词，多条用户消息按顺序拼接
+func splitMessages(messages []openai.ChatCompletionMessage) (system, prompt string) {
+	var systems, users []string
+	for _, m := range messages {
+		switch m.Role {
+		case openai.ChatMessageRoleSystem, "developer":
+			systems = append(systems, m.Content)
+		case openai.ChatMessageRoleUser:
+			users = append(users, m.Content)
+		}
+	}
+	return strings.Join(systems, "\n\n"), strings.Join(users, "\n\n")
+}
+
+func cacheKey(model, system, prompt string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + system + "\x00" + prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeCompletion(w http.ResponseWriter, model, content string, tokens int) {
+	writeJSON(w, http.StatusOK, openai.ChatCompletionResponse{
+		ID:      fmt.Sprintf("termi-%d", time.Now().UnixNano()),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openai.ChatCompletionChoice{{
+			Message:      openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			FinishReason: openai.FinishReasonStop,
+		}},
+		Usage: openai.Usage{TotalTokens: tokens},
+	})
+}
+
+// writeError 以 OpenAI 的错误格式返回，客户端据此给出对应提示
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"type": kind, "message": message},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// cache 有大小上限与有效期的内存缓存，超出上限时淘汰最久未使用的条目
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key     string
+	content string
+	expires time.Time
+}
+
+func newCache(size int, ttl time.Duration) *cache {
+	return &cache{size: size, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *cache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	e := el.Value.(*cacheEntry)
+	if time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return e.content, true
+}
+
+func (c *cache) put(key, content string) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, content: content, expires: time.Now().Add(c.ttl)})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *cache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/llm/providers"
+)
+
+// countingProvider 记录收到的请求数，每次请求耗时 delay
+type countingProvider struct {
+	calls atomic.Int32
+	delay time.Duration
+}
+
+func (p *countingProvider) Name() string  { return "Counting" }
+func (p *countingProvider) Enabled() bool { return true }
+func (p *countingProvider) AskSmart(ctx context.Context, prompt string) (*providers.Reply, error) {
+	p.calls.Add(1)
+	time.Sleep(p.delay)
+	return &providers.Reply{Command: "ls", Tokens: 10}, nil
+}
+
+// newTestServer 创建转发给 p 的代理
+func newTestServer(t *testing.T, cfg config.ServeConfig, p llm.Provider) *httptest.Server {
+	t.Helper()
+	client, err := llm.NewClient(nil, llm.WithProvider(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(New(cfg, client).Handler())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// chat 发送一条用户消息，返回状态码
+func chat(t *testing.T, url, model, prompt string) int {
+	t.Helper()
+	body := fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":%q}]}`, model, prompt)
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestDailyCallsConcurrent(t *testing.T) {
+	const limit, requests = 3, 10
+	p := &countingProvider{delay: 50 * time.Millisecond}
+	srv := newTestServer(t, config.ServeConfig{DailyCalls: limit}, p)
+
+	var wg sync.WaitGroup
+	var ok, rejected atomic.Int32
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch chat(t, srv.URL, "gpt-4o", fmt.Sprintf("列出文件 %d", i)) {
+			case http.StatusOK:
+				ok.Add(1)
+			case http.StatusTooManyRequests:
+				rejected.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := p.calls.Load(); got != limit {
+		t.Errorf("upstream calls = %d, want %d", got, limit)
+	}
+	if ok.Load() != limit || rejected.Load() != requests-limit {
+		t.Errorf("ok = %d, rejected = %d; want %d, %d", ok.Load(), rejected.Load(), limit, requests-limit)
+	}
+}
+
+func TestCacheKeyIncludesModel(t *testing.T) {
+	p := &countingProvider{}
+	srv := newTestServer(t, config.ServeConfig{}, p)
+
+	for _, tc := range []struct {
+		model string
+		calls int32
+	}{
+		{"gpt-4o", 1},
+		{"gpt-4o-mini", 2}, // 不同模型不共用缓存
+		{"gpt-4o", 2},      // 同一模型命中缓存
+	} {
+		if status := chat(t, srv.URL, tc.model, "列出文件"); status != http.StatusOK {
+			t.Fatalf("%s: status = %d", tc.model, status)
+		}
+		if got := p.calls.Load(); got != tc.calls {
+			t.Errorf("%s: upstream calls = %d, want %d", tc.model, got, tc.calls)
+		}
+	}
+}
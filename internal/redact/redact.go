@@ -0,0 +1,87 @@
+package redact
+
+import (
+	"os/user"
+	"regexp"
+	"strings"
+)
+
+// Options 脱敏选项
+type Options struct {
+	// IPs 是否脱敏 IPv4/IPv6 地址
+	IPs bool
+	// Tokens 是否脱敏疑似密钥、令牌、密码
+	Tokens bool
+	// Usernames 需要脱敏的用户名
+	Usernames []string
+}
+
+// rule 单条脱敏规则
+type rule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// Redactor 对发送给 LLM 的文本进行脱敏
+type Redactor struct {
+	rules []rule
+}
+
+var tokenRules = []rule{
+	// key=value / key: value 形式的敏感字段，保留字段名
+	{regexp.MustCompile(`(?i)\b((?:api[_-]?key|access[_-]?key|secret|token|passwd|password|pwd)["']?\s*[:=]\s*)["']?[^\s"']+["']?`), "${1}[REDACTED]"},
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=-]{8,}`), "${1}[REDACTED]"},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`), "[REDACTED]"},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`), "[REDACTED]"},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), "[REDACTED]"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[REDACTED]"},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), "[REDACTED]"},
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), "[REDACTED PRIVATE KEY]"},
+}
+
+var ipRules = []rule{
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), "[IP]"},
+	{regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){2,7}[0-9a-f]{1,4}\b`), "[IP]"},
+}
+
+// New 根据选项创建脱敏器
+func New(opts Options) *Redactor {
+	r := &Redactor{}
+	if opts.Tokens {
+		r.rules = append(r.rules, tokenRules...)
+	}
+	if opts.IPs {
+		r.rules = append(r.rules, ipRules...)
+	}
+	for _, name := range opts.Usernames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		r.rules = append(r.rules, rule{
+			re:   regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`),
+			repl: "[USER]",
+		})
+	}
+	return r
+}
+
+// Redact 返回脱敏后的文本
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, rl := range r.rules {
+		text = rl.re.ReplaceAllString(text, rl.repl)
+	}
+	return text
+}
+
+// CurrentUsername 返回当前系统用户名，获取失败时返回空字符串
+func CurrentUsername() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
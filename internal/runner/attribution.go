@@ -0,0 +1,43 @@
+package runner
+
+import (
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/shellquote"
+)
+
+// Attribution 命令的来源，开启 exec.attribution 时以行尾注释的形式附加在执行的命令后，
+// 使审计日志与 shell 历史能看出命令由哪个工具、哪个模型生成
+type Attribution struct {
+	Model  string    // 生成命令的模型，不是由模型生成时为空
+	Source string    // 不是由模型生成时的来源，例如 history、offline
+	Edited bool      // 用户修改过生成的命令
+	Time   time.Time // 执行时间
+}
+
+// Comment 返回形如 "# termi:model=gpt-4o ts=2025-01-02T03:04:05Z" 的注释
+func (a Attribution) Comment() string {
+	fields := []string{}
+	if a.Model != "" {
+		fields = append(fields, "model="+strings.Join(strings.Fields(a.Model), "_"))
+	} else if a.Source != "" {
+		fields = append(fields, "source="+strings.Join(strings.Fields(a.Source), "_"))
+	}
+	if a.Edited {
+		fields = append(fields, "edited=1")
+	}
+	fields = append(fields, "ts="+a.Time.UTC().Format(time.RFC3339))
+	return "# termi:" + strings.Join(fields, " ")
+}
+
+// Attribute 在命令末尾追加来源注释。cmd.exe 没有行尾注释，多行命令与以续行符结尾的命令
+// 追加后会改变含义（例如 here-document 的结束标记），这些情况原样返回
+func Attribute(command, shell string, a Attribution) string {
+	trimmed := strings.TrimRight(command, " \t")
+	if trimmed == "" || strings.Contains(trimmed, "\n") || strings.HasSuffix(trimmed, `\`) ||
+		shellquote.Parse(shell) == shellquote.Cmd {
+		return command
+	}
+	return trimmed + " " + a.Comment()
+}
@@ -0,0 +1,120 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"unsafe"
+
+	"github.com/charmbracelet/x/term"
+	"golang.org/x/sys/windows"
+)
+
+// runConPTY 在伪控制台中运行命令，将其输出写入 stdout，并把 stdin 转发给子进程
+func runConPTY(args []string, stdin *os.File, stdout io.Writer) error {
+	var ptyIn, cmdIn, cmdOut, ptyOut windows.Handle
+	if err := windows.CreatePipe(&ptyIn, &cmdIn, nil, 0); err != nil {
+		return fmt.Errorf("创建输入管道失败: %w", err)
+	}
+	if err := windows.CreatePipe(&cmdOut, &ptyOut, nil, 0); err != nil {
+		windows.CloseHandle(ptyIn)
+		windows.CloseHandle(cmdIn)
+		return fmt.Errorf("创建输出管道失败: %w", err)
+	}
+
+	var hpc windows.Handle
+	err := windows.CreatePseudoConsole(consoleSize(), ptyIn, ptyOut, 0, &hpc)
+	// 伪控制台持有管道的另一端，这里可以立即关闭
+	windows.CloseHandle(ptyIn)
+	windows.CloseHandle(ptyOut)
+	if err != nil {
+		windows.CloseHandle(cmdIn)
+		windows.CloseHandle(cmdOut)
+		return fmt.Errorf("创建伪控制台失败: %w", err)
+	}
+
+	input := os.NewFile(uintptr(cmdIn), "conpty-in")
+	output := os.NewFile(uintptr(cmdOut), "conpty-out")
+	defer input.Close()
+	defer output.Close()
+
+	proc, err := startInPseudoConsole(hpc, windows.ComposeCommandLine(args))
+	if err != nil {
+		windows.ClosePseudoConsole(hpc)
+		return err
+	}
+	defer windows.CloseHandle(proc)
+
+	// 原始模式下按键（含方向键、Ctrl 组合键）以 VT 序列原样转发给子进程
+	if state, err := term.MakeRaw(stdin.Fd()); err == nil {
+		defer term.Restore(stdin.Fd(), state)
+	}
+
+	copied := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(stdout, output)
+		close(copied)
+	}()
+	go func() {
+		_, _ = io.Copy(input, stdin)
+	}()
+
+	_, _ = windows.WaitForSingleObject(proc, windows.INFINITE)
+	var code uint32
+	exitErr := windows.GetExitCodeProcess(proc, &code)
+
+	// 关闭伪控制台后输出管道才会结束
+	windows.ClosePseudoConsole(hpc)
+	<-copied
+
+	if exitErr != nil {
+		return fmt.Errorf("获取退出码失败: %w", exitErr)
+	}
+	if code != 0 {
+		return &exitError{code: int(code)}
+	}
+	return nil
+}
+
+// startInPseudoConsole 创建挂载到伪控制台的子进程，返回进程句柄
+func startInPseudoConsole(hpc windows.Handle, cmdline string) (windows.Handle, error) {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return 0, fmt.Errorf("创建进程属性失败: %w", err)
+	}
+	defer attrs.Delete()
+
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&hpc)), unsafe.Sizeof(hpc)); err != nil {
+		return 0, fmt.Errorf("设置伪控制台属性失败: %w", err)
+	}
+
+	si := windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	si.Cb = uint32(unsafe.Sizeof(si))
+
+	cmdlinePtr, err := windows.UTF16PtrFromString(cmdline)
+	if err != nil {
+		return 0, err
+	}
+
+	var pi windows.ProcessInformation
+	flags := uint32(windows.EXTENDED_STARTUPINFO_PRESENT | windows.CREATE_UNICODE_ENVIRONMENT)
+	if err := windows.CreateProcess(nil, cmdlinePtr, nil, nil, false, flags, nil, nil, &si.StartupInfo, &pi); err != nil {
+		return 0, fmt.Errorf("启动进程失败: %w", err)
+	}
+	windows.CloseHandle(pi.Thread)
+	return pi.Process, nil
+}
+
+// consoleSize 返回当前控制台窗口大小，获取失败时使用 80x24
+func consoleSize() windows.Coord {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(os.Stdout.Fd()), &info); err != nil {
+		return windows.Coord{X: 80, Y: 24}
+	}
+	return windows.Coord{
+		X: info.Window.Right - info.Window.Left + 1,
+		Y: info.Window.Bottom - info.Window.Top + 1,
+	}
+}
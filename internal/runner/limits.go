@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+)
+
+// ErrNoNetworkUnavailable 当前系统无法断网执行命令
+var ErrNoNetworkUnavailable = errors.New("断网执行 (exec.limits.no_network) 需要 Linux 以及 bubblewrap (bwrap)")
+
+// Limits 本地执行命令时的资源上限，零值表示不限制。上限作用于 shell 及其启动的所有子进程
+type Limits struct {
+	CPU       time.Duration // CPU 时间，超过时进程收到 SIGXCPU 并被终止
+	Memory    int64         // 虚拟内存字节数，超过时内存分配失败
+	FileSize  int64         // 单个文件可写入的字节数，超过时进程收到 SIGXFSZ
+	NoNetwork bool          // 在断开网络的命名空间中执行
+}
+
+// LimitsFrom 按 exec.limits 配置创建资源上限
+func LimitsFrom(lc config.LimitsConfig) Limits {
+	return Limits{
+		CPU:       time.Duration(lc.CPU) * time.Second,
+		Memory:    int64(lc.MemoryMB) << 20,
+		FileSize:  int64(lc.FileMB) << 20,
+		NoNetwork: lc.NoNetwork,
+	}
+}
+
+// Enabled 报告是否设置了任意一项上限
+func (l Limits) Enabled() bool {
+	return l.CPU > 0 || l.Memory > 0 || l.FileSize > 0 || l.NoNetwork
+}
+
+// WithLimits 以资源上限执行命令；通过 SSH 在远程主机上执行时不生效
+func WithLimits(l Limits) Option {
+	return func(o *options) {
+		o.limits = l
+	}
+}
+
+// ulimitScript 返回设置资源上限后 exec 原命令的 sh 脚本。ulimit 的单位按 POSIX sh：
+// -t 为秒，-v 为 KB，-f 为 512 字节的块；某项无法设置（例如 macOS 不支持限制虚拟内存）时提示并继续执行
+func ulimitScript(l Limits) string {
+	var b strings.Builder
+	set := func(flag string, value int64, name string) {
+		fmt.Fprintf(&b, "ulimit -%s %d 2>/dev/null || echo 'termi: 当前系统无法设置%s上限，已忽略' >&2\n", flag, value, name)
+	}
+	if l.CPU > 0 {
+		// 软上限先到时进程收到可识别的 SIGXCPU，而不是同时到达硬上限时的 SIGKILL
+		secs := int64(max(l.CPU/time.Second, 1))
+		set("t", secs+1, "CPU 时间")
+		set("S -t", secs, "CPU 时间")
+	}
+	if l.Memory > 0 {
+		set("v", max(l.Memory>>10, 1), "内存")
+	}
+	if l.FileSize > 0 {
+		set("f", max(l.FileSize/512, 1), "文件大小")
+	}
+	b.WriteString(`exec "$@"`)
+	return b.String()
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"syscall"
+)
+
+// limitArgs 返回在资源上限下执行 args 的参数：先由 /bin/sh 设置 ulimit 再 exec 原命令，
+// 需要断网时再用 bwrap 放入独立的网络命名空间，文件系统与用户身份保持不变
+func limitArgs(args []string, l Limits) ([]string, error) {
+	if !l.Enabled() {
+		return args, nil
+	}
+	if l.CPU > 0 || l.Memory > 0 || l.FileSize > 0 {
+		args = append([]string{"/bin/sh", "-c", ulimitScript(l), "termi-limits"}, args...)
+	}
+	if l.NoNetwork {
+		if runtime.GOOS != "linux" {
+			return nil, ErrNoNetworkUnavailable
+		}
+		bwrap, err := exec.LookPath("bwrap")
+		if err != nil {
+			return nil, ErrNoNetworkUnavailable
+		}
+		args = append([]string{bwrap, "--dev-bind", "/", "/", "--unshare-net", "--die-with-parent", "--"}, args...)
+	}
+	return args, nil
+}
+
+// LimitExceeded 命令因超过 CPU 时间或文件大小上限被终止时返回超出的上限名称，否则返回空字符串。
+// shell 把子进程被信号终止报告为 128 加信号编号的退出码，两种情况都识别
+func LimitExceeded(err error) string {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return ""
+	}
+	sig := syscall.Signal(-1)
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		sig = ws.Signal()
+	} else if code := exitErr.ExitCode(); code > 128 {
+		sig = syscall.Signal(code - 128)
+	}
+	switch sig {
+	case syscall.SIGXCPU:
+		return "CPU 时间"
+	case syscall.SIGXFSZ:
+		return "文件大小"
+	}
+	return ""
+}
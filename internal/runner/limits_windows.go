@@ -0,0 +1,24 @@
+//go:build windows
+
+package runner
+
+import "errors"
+
+// ErrLimitsUnsupported Windows 上无法设置执行命令的资源上限
+var ErrLimitsUnsupported = errors.New("Windows 不支持 exec.limits 资源上限")
+
+// limitArgs Windows 上没有 ulimit，设置了任何上限时拒绝执行而不是静默忽略
+func limitArgs(args []string, l Limits) ([]string, error) {
+	if !l.Enabled() {
+		return args, nil
+	}
+	if l.NoNetwork {
+		return nil, ErrNoNetworkUnavailable
+	}
+	return nil, ErrLimitsUnsupported
+}
+
+// LimitExceeded Windows 上不设置资源上限，始终返回空字符串
+func LimitExceeded(err error) string {
+	return ""
+}
@@ -0,0 +1,87 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxPagedSize 分页查看时最多保留的输出字节数，超出的部分丢弃
+const maxPagedSize = 8 << 20
+
+// Paged 将命令输出照常写到终端，直到写满一屏；之后不再刷屏，完整的输出留待执行结束后分页查看
+type Paged struct {
+	mu        sync.Mutex
+	w         io.Writer
+	lines     int // 写到终端的最大行数
+	shown     int
+	overflow  bool
+	truncated bool
+	buf       bytes.Buffer
+}
+
+// NewPaged 创建最多向 w 写入 lines 行的 Paged
+func NewPaged(w io.Writer, lines int) *Paged {
+	return &Paged{w: w, lines: max(lines, 1)}
+}
+
+// Write 保留全部内容，未满一屏的部分同时写到终端
+func (p *Paged) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if room := maxPagedSize - p.buf.Len(); room < len(b) {
+		p.buf.Write(b[:max(room, 0)])
+		p.truncated = true
+	} else {
+		p.buf.Write(b)
+	}
+	if p.overflow {
+		return len(b), nil
+	}
+
+	rest := b
+	for p.shown < p.lines {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			_, err := p.w.Write(rest)
+			return len(b), err
+		}
+		if _, err := p.w.Write(rest[:i+1]); err != nil {
+			return len(b), err
+		}
+		p.shown++
+		rest = rest[i+1:]
+	}
+	if len(rest) > 0 {
+		p.overflow = true
+		fmt.Fprintf(p.w, "\x1b[0m... 输出超过一屏，执行结束后分页查看完整输出\n")
+	}
+	return len(b), nil
+}
+
+// Overflowed 报告输出是否超过了一屏
+func (p *Paged) Overflowed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.overflow
+}
+
+// String 返回保留的完整输出；超过上限时末尾注明后续内容已丢弃
+func (p *Paged) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.truncated {
+		return p.buf.String() + fmt.Sprintf("\n... 输出超过 %dMB，之后的内容未保留\n", maxPagedSize>>20)
+	}
+	return p.buf.String()
+}
+
+// WithPager 将标准输出写入 Paged 而不是直接写到终端，执行结束后可以分页查看完整输出
+//
+// 与 WithStdoutTail 相同，子进程的标准输出因此不再连接终端，全屏程序与彩色输出可能表现不同。
+func WithPager(p *Paged) Option {
+	return func(o *options) {
+		o.paged = p
+	}
+}
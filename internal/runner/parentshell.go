@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"termi.sh/termi/internal/shellquote"
+)
+
+// parentShellBuiltins 只修改当前 shell 状态（工作目录、环境变量、别名等）的内建命令，
+// 在子进程中执行后不会影响用户的交互式 shell
+var parentShellBuiltins = map[string]bool{
+	"cd":      true,
+	"pushd":   true,
+	"popd":    true,
+	"export":  true,
+	"unset":   true,
+	"alias":   true,
+	"unalias": true,
+	"source":  true,
+	".":       true,
+	"set":     true,
+	"shopt":   true,
+	"umask":   true,
+	"ulimit":  true,
+}
+
+// segmentSep 拆分命令序列的分隔符
+var segmentSep = regexp.MustCompile(`;|&&|\|\||\||\n`)
+
+// assignRe 匹配 FOO=bar 形式的 shell 变量赋值
+var assignRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// ParentShellEffects 返回命令中只会影响当前 shell 状态的语句；pure 表示命令完全由这类语句组成
+func ParentShellEffects(cmdStr string) (statements []string, pure bool) {
+	pure = true
+	for _, seg := range segmentSep.Split(cmdStr, -1) {
+		seg = strings.TrimSpace(seg)
+		if seg == "" {
+			continue
+		}
+		fields := strings.Fields(seg)
+		if parentShellBuiltins[fields[0]] || (len(fields) == 1 && assignRe.MatchString(fields[0])) {
+			statements = append(statements, seg)
+			continue
+		}
+		pure = false
+	}
+	return statements, pure && len(statements) > 0
+}
+
+// WriteSnippet 将命令写入可 source 的脚本文件，返回文件路径
+func WriteSnippet(dir, cmdStr string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("创建目录失败: %w", err)
+	}
+	path := filepath.Join(dir, "last.sh")
+	content := "# generated by termi, run: source " + shellquote.POSIX.Quote(path) + "\n" + cmdStr + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", fmt.Errorf("写入脚本失败: %w", err)
+	}
+	return path, nil
+}
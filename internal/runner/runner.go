@@ -1,22 +1,146 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"os/exec"
+
+	"termi.sh/termi/internal/objects"
+	"termi.sh/termi/internal/shellquote"
 )
 
+// options 命令执行选项
+type options struct {
+	recorder *Recorder
+	host     string
+	stderr   *Tail
+	stdout   *Tail
+	paged    *Paged
+	shell    string
+	limits   Limits
+	objects  string
+}
+
+// Option 命令执行的函数式选项
+type Option func(*options)
+
+// WithRecorder 将执行过程的终端输出同时录制到 Recorder
+//
+// 在类 Unix 系统上录制时子进程的标准输出不再直接连接终端，部分程序会因此关闭颜色输出；
+// Windows 上通过 ConPTY 录制，子进程仍然运行在伪控制台中。
+func WithRecorder(r *Recorder) Option {
+	return func(o *options) {
+		o.recorder = r
+	}
+}
+
+// WithStderrTail 将标准错误同时写入 Tail，以便命令失败后分析错误原因
+//
+// 与录制相同，类 Unix 系统上子进程的标准错误因此不再直接连接终端，
+// 部分程序（如 curl、git）会改为不显示进度条。
+func WithStderrTail(t *Tail) Option {
+	return func(o *options) {
+		o.stderr = t
+	}
+}
+
+// WithStdoutTail 将标准输出同时写入 Tail，以便执行后复制命令的输出
+//
+// 子进程的标准输出因此不再直接连接终端，分页器、全屏程序与彩色输出可能表现不同，
+// 只应在用户要求复制输出时使用。
+func WithStdoutTail(t *Tail) Option {
+	return func(o *options) {
+		o.stdout = t
+	}
+}
+
+// WithShell 用指定的 shell 执行命令，例如 zsh、fish、pwsh、cmd、nu；
+// 未指定时按 shellquote.Name 的规则取 $SHELL 或系统默认的 shell
+func WithShell(name string) Option {
+	return func(o *options) {
+		o.shell = name
+	}
+}
+
+// WithObjectCapture 在 PowerShell 与 Nushell 中执行只由内置命令组成的命令时，把输出对象以 JSON 写入 path，
+// 终端中的输出不变；其他 shell、含外部程序的命令与远程执行不捕获
+func WithObjectCapture(path string) Option {
+	return func(o *options) {
+		o.objects = path
+	}
+}
+
+// WithSSHHost 通过 SSH 在远程主机上执行命令，并分配伪终端以支持交互
+func WithSSHHost(host string) Option {
+	return func(o *options) {
+		o.host = host
+	}
+}
+
 // Run 执行 shell 命令，并将标准输入输出直接连接到当前终端，实现完整交互体验。
-func Run(cmdStr string) error {
+func Run(cmdStr string, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	args := shellArgs(shellquote.Name(o.shell), o.capture(cmdStr))
+	if o.host != "" {
+		args = []string{"ssh", "-t", o.host, "--", cmdStr}
+	} else {
+		var err error
+		if args, err = limitArgs(args, o.limits); err != nil {
+			return err
+		}
+	}
+
 	fmt.Println("---------------------------")
-	cmd := exec.Command("bash", "-c", cmdStr)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+	return execute(args, &o)
+}
+
+// capture 需要捕获输出对象时返回包装后的命令，否则原样返回
+func (o *options) capture(cmdStr string) string {
+	sh := shellquote.Parse(shellquote.Name(o.shell))
+	if o.objects == "" || o.host != "" || !objects.Capturable(sh, cmdStr) {
+		return cmdStr
+	}
+	return objects.Wrap(sh, cmdStr, o.objects)
+}
 
-	if err := cmd.Start(); err != nil {
-		return err
+// shellArgs 返回用 shell 执行命令的参数
+func shellArgs(shell, cmdStr string) []string {
+	switch shellquote.Parse(shell) {
+	case shellquote.PowerShell:
+		return []string{shell, "-NoLogo", "-NoProfile", "-Command", cmdStr}
+	case shellquote.Cmd:
+		return []string{"cmd", "/C", cmdStr}
+	default:
+		// bash、zsh、sh、fish、nu 等都以 -c 接收命令字符串
+		return []string{shell, "-c", cmdStr}
 	}
-	// 等待命令结束，同时让用户实时看到输出 / 与之交互
-	return cmd.Wait()
+}
+
+// ExitCode 从命令执行错误中提取退出码，无法识别时返回 -1
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return -1
+}
+
+// exitError 非 os/exec 启动的进程以非零状态退出
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// ExitCode 返回进程退出码
+func (e *exitError) ExitCode() int {
+	return e.code
 }
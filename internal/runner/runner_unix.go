@@ -0,0 +1,38 @@
+//go:build !windows
+
+package runner
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// execute 启动子进程，标准输入输出直接连接当前终端
+func execute(args []string, o *options) error {
+	var stdout io.Writer = os.Stdout
+	if o.paged != nil {
+		stdout = o.paged
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+
+	if o.recorder != nil {
+		cmd.Stdout = io.MultiWriter(stdout, o.recorder)
+		cmd.Stderr = io.MultiWriter(os.Stderr, o.recorder)
+	}
+	if o.stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, o.stderr)
+	}
+	if o.stdout != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, o.stdout)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// 等待命令结束，同时让用户实时看到输出 / 与之交互
+	return cmd.Wait()
+}
@@ -0,0 +1,73 @@
+//go:build windows
+
+package runner
+
+import (
+	"io"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/windows"
+)
+
+// utf8CodePage Windows 控制台 UTF-8 代码页
+const utf8CodePage = 65001
+
+// execute 启动子进程。需要录制输出时通过 ConPTY 运行，使交互式程序（ssh、python REPL）
+// 仍然认为自己连接着控制台；否则直接继承当前控制台。
+func execute(args []string, o *options) error {
+	restore := prepareConsole()
+	defer restore()
+
+	var stdout io.Writer = os.Stdout
+	if o.paged != nil {
+		stdout = o.paged
+	}
+	if o.stdout != nil {
+		stdout = io.MultiWriter(stdout, o.stdout)
+	}
+	if o.recorder != nil {
+		return runConPTY(args, os.Stdin, io.MultiWriter(stdout, o.recorder))
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	if o.stderr != nil {
+		cmd.Stderr = io.MultiWriter(os.Stderr, o.stderr)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
+// prepareConsole 开启 ANSI 转义序列透传并切换到 UTF-8 代码页，返回恢复原状态的函数
+func prepareConsole() func() {
+	out := windows.Handle(os.Stdout.Fd())
+
+	var mode uint32
+	modeErr := windows.GetConsoleMode(out, &mode)
+	if modeErr == nil {
+		_ = windows.SetConsoleMode(out, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	}
+
+	inCP, inErr := windows.GetConsoleCP()
+	outCP, outErr := windows.GetConsoleOutputCP()
+	_ = windows.SetConsoleCP(utf8CodePage)
+	_ = windows.SetConsoleOutputCP(utf8CodePage)
+
+	return func() {
+		if modeErr == nil {
+			_ = windows.SetConsoleMode(out, mode)
+		}
+		if inErr == nil {
+			_ = windows.SetConsoleCP(inCP)
+		}
+		if outErr == nil {
+			_ = windows.SetConsoleOutputCP(outCP)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+
+	"termi.sh/termi/internal/shellquote"
+)
+
+// streamWaitDelay 取消后等待子进程关闭输出的时间，避免后台的孙进程占住管道使 Stream 无法返回
+const streamWaitDelay = 2 * time.Second
+
+// Stream 执行 shell 命令，标准输出与标准错误都写入 w，不连接标准输入；ctx 取消时结束子进程。
+// 用于在界面内展示命令的输出，需要终端交互的命令（输入 sudo 密码、全屏程序）应使用 Run。
+// 通过 SSH 执行时不分配伪终端并禁止交互式认证，需要密码时直接失败而不是等待输入
+func Stream(ctx context.Context, cmdStr string, w io.Writer, opts ...Option) error {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	args := shellArgs(shellquote.Name(o.shell), o.capture(cmdStr))
+	if o.host != "" {
+		args = []string{"ssh", "-o", "BatchMode=yes", o.host, "--", cmdStr}
+	} else {
+		var err error
+		if args, err = limitArgs(args, o.limits); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = streamWaitDelay
+	cmd.Stdout, cmd.Stderr = w, w
+	if o.recorder != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, o.recorder)
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, o.recorder)
+	}
+	if o.stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, o.stderr)
+	}
+	if o.stdout != nil {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, o.stdout)
+	}
+	return cmd.Run()
+}
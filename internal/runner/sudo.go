@@ -0,0 +1,36 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+
+	"termi.sh/termi/internal/glyph"
+)
+
+// sudoRe 匹配作为独立命令出现的 sudo（行首、管道、分号、&&、子 shell 之后）
+var sudoRe = regexp.MustCompile(`(^|[\s;&|(` + "`" + `])sudo(\s|$)`)
+
+// UsesSudo 判断命令是否会调用 sudo
+func UsesSudo(cmdStr string) bool {
+	return sudoRe.MatchString(cmdStr)
+}
+
+// PrevalidateSudo 在真正执行前验证 sudo 凭据，避免长管道在输出被重定向时
+// 中途卡在密码提示上或因提示超时而失败。凭据已缓存时不会再次提示。
+func PrevalidateSudo() error {
+	if exec.Command("sudo", "-n", "true").Run() == nil {
+		return nil
+	}
+
+	glyph.Println("🔐 该命令需要 sudo 权限，请先验证凭据:")
+	cmd := exec.Command("sudo", "-v")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sudo 凭据验证失败: %w", err)
+	}
+	return nil
+}
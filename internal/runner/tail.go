@@ -0,0 +1,33 @@
+package runner
+
+import "sync"
+
+// Tail 保留写入内容的最后若干字节，用于在命令失败后分析其错误输出
+type Tail struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+// NewTail 创建最多保留 max 字节的 Tail
+func NewTail(max int) *Tail {
+	return &Tail{max: max}
+}
+
+// Write 追加内容，超出上限时丢弃最早的部分
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// String 返回保留的内容
+func (t *Tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
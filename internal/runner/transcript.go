@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Format 录制文件格式
+type Format string
+
+const (
+	// FormatAsciinema asciinema v2 cast 格式，可用 asciinema play 回放
+	FormatAsciinema Format = "asciinema"
+	// FormatScript script(1) typescript 格式，可直接 cat 查看
+	FormatScript Format = "script"
+)
+
+// Recorder 录制命令执行过程中的终端输出
+type Recorder struct {
+	mu     sync.Mutex
+	file   *os.File
+	format Format
+	start  time.Time
+
+	// pending 上次写入末尾不完整的 UTF-8 字符，与下一段输出拼接后再记录，
+	// 避免被拆开的中文等多字节字符在 cast 文件中变成 U+FFFD
+	pending []byte
+}
+
+// NewRecorder 创建录制文件并写入文件头
+func NewRecorder(path string, format Format, command string) (*Recorder, error) {
+	if format == "" {
+		format = FormatAsciinema
+	}
+	if format != FormatAsciinema && format != FormatScript {
+		return nil, fmt.Errorf("不支持的录制格式: %s", format)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("创建录制文件失败: %w", err)
+	}
+
+	r := &Recorder{file: f, format: format, start: time.Now()}
+	if err := r.writeHeader(command); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	return r, nil
+}
+
+// Path 返回录制文件路径
+func (r *Recorder) Path() string {
+	return r.file.Name()
+}
+
+// Write 记录一段输出，实现 io.Writer
+func (r *Recorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.format == FormatScript {
+		return r.file.Write(p)
+	}
+
+	data := append(r.pending, p...)
+	n := completeLen(data)
+	r.pending = append([]byte(nil), data[n:]...)
+	if n > 0 {
+		if err := r.writeEvent(data[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// writeEvent 写入一条 asciinema 输出事件
+func (r *Recorder) writeEvent(p []byte) error {
+	event, err := json.Marshal([]any{time.Since(r.start).Seconds(), "o", string(p)})
+	if err != nil {
+		return err
+	}
+	_, err = r.file.Write(append(event, '\n'))
+	return err
+}
+
+// completeLen 返回 p 中去掉末尾不完整 UTF-8 字符后的长度；无效的字节不等待后续输出
+func completeLen(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(p)
+}
+
+// Close 写入文件尾并关闭录制文件
+func (r *Recorder) Close(exitErr error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) > 0 {
+		// 输出以不完整的字符结束，按原样记录
+		_ = r.writeEvent(r.pending)
+		r.pending = nil
+	}
+	if r.format == FormatScript {
+		status := 0
+		if exitErr != nil {
+			status = ExitCode(exitErr)
+		}
+		fmt.Fprintf(r.file, "\nScript done on %s [COMMAND_EXIT_CODE=\"%d\"]\n", time.Now().Format(time.RFC1123Z), status)
+	}
+	return r.file.Close()
+}
+
+func (r *Recorder) writeHeader(command string) error {
+	if r.format == FormatScript {
+		_, err := fmt.Fprintf(r.file, "Script started on %s [COMMAND=%q]\n", r.start.Format(time.RFC1123Z), command)
+		return err
+	}
+
+	width, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		width, height = 80, 24
+	}
+	header, err := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": r.start.Unix(),
+		"command":   command,
+		"title":     "termi: " + command,
+		"env": map[string]string{
+			"SHELL": os.Getenv("SHELL"),
+			"TERM":  os.Getenv("TERM"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.file.Write(append(header, '\n'))
+	return err
+}
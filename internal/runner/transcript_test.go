@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// castOutput 读取 asciinema cast 文件中全部输出事件，按顺序拼接
+func castOutput(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var out []string
+	sc := bufio.NewScanner(f)
+	sc.Scan() // 文件头
+	for sc.Scan() {
+		var event []any
+		if err := json.Unmarshal(sc.Bytes(), &event); err != nil {
+			t.Fatalf("invalid event %s: %v", sc.Bytes(), err)
+		}
+		out = append(out, event[2].(string))
+	}
+	return out
+}
+
+func TestRecorderSplitRunes(t *testing.T) {
+	text := "构建完成 ✓ 用时 3s\n"
+	tests := []struct {
+		name   string
+		chunks func([]byte) [][]byte
+	}{
+		{"Bytewise", func(b []byte) [][]byte {
+			out := make([][]byte, len(b))
+			for i := range b {
+				out[i] = b[i : i+1]
+			}
+			return out
+		}},
+		// "构" 的三个字节被拆到两次写入中
+		{"MidRune", func(b []byte) [][]byte { return [][]byte{b[:1], b[1:4], b[4:]} }},
+		{"Whole", func(b []byte) [][]byte { return [][]byte{b} }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.cast")
+			r, err := NewRecorder(path, FormatAsciinema, "make")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, chunk := range tc.chunks([]byte(text)) {
+				if n, err := r.Write(chunk); err != nil || n != len(chunk) {
+					t.Fatalf("Write() = %d, %v", n, err)
+				}
+			}
+			if err := r.Close(nil); err != nil {
+				t.Fatal(err)
+			}
+
+			events := castOutput(t, path)
+			got := strings.Join(events, "")
+			if got != text {
+				t.Errorf("output = %q, want %q", got, text)
+			}
+			for _, e := range events {
+				if strings.ContainsRune(e, '�') {
+					t.Errorf("event %q contains U+FFFD", e)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorderFlushesPendingOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.cast")
+	r, err := NewRecorder(path, FormatAsciinema, "cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 输出以不完整的字符结束，关闭时仍要记录
+	r.Write([]byte("ok \xe4\xb8"))
+	if err := r.Close(nil); err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(castOutput(t, path), "")
+	if !strings.HasPrefix(got, "ok ") || len(got) <= len("ok ") {
+		t.Errorf("output = %q, want the trailing bytes recorded", got)
+	}
+}
@@ -0,0 +1,53 @@
+package safety
+
+import (
+	"crypto/rand"
+	"regexp"
+	"strings"
+)
+
+// criticalRule 最高风险等级的命令规则，target 为捕获操作对象的分组序号，0 表示无法提取
+type criticalRule struct {
+	re     *regexp.Regexp
+	target int
+	desc   string
+}
+
+var criticalRules = []criticalRule{
+	{regexp.MustCompile(`(?:^|[\s;&|(])rm\s+(?:-\S+\s+)*-\S*[rR]\S*\s+(?:-\S+\s+)*(/\*?|/(?:bin|boot|dev|etc|home|lib\w*|opt|root|sbin|srv|usr|var)/?\*?|~/?\*?|\*|\.{1,2}/?)(?:\s|$)`), 1, "递归删除"},
+	{regexp.MustCompile(`(?:^|[\s;&|(])mkfs(?:\.\w+)?\s+(?:-\S+\s+)*(\S+)`), 1, "格式化文件系统"},
+	{regexp.MustCompile(`(?:^|[\s;&|(])dd\s+.*\bof=(/dev/\S+)`), 1, "覆写块设备"},
+	{regexp.MustCompile(`(?:^|[\s;&|(])(?:shred|wipefs)\s+(?:-\S+\s+)*(/dev/\S+)`), 1, "擦除设备"},
+	{regexp.MustCompile(`>\s*(/dev/(?:sd|nvme|hd|vd|disk)\S*)`), 1, "覆写块设备"},
+	{regexp.MustCompile(`(?i)\bdrop\s+(?:database|schema)\s+(?:if\s+exists\s+)?[` + "`" + `"']?(\w+)`), 1, "删除数据库"},
+	{regexp.MustCompile(`(?:^|[\s;&|(])kubectl\s+delete\s+(?:ns|namespace)\s+(\S+)`), 1, "删除 Kubernetes 命名空间"},
+	{regexp.MustCompile(`(?:^|[\s;&|(])terraform\s+(?:destroy|apply\s+.*-destroy)`), 0, "销毁 Terraform 管理的资源"},
+	{regexp.MustCompile(`(?:^|[\s;&|(])chmod\s+(?:-\S+\s+)*-R\s+\S+\s+(/|/etc|/usr|/var|/bin)(?:\s|$)`), 1, "递归修改系统目录权限"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\|:&\s*\};:`), 0, "fork 炸弹"},
+}
+
+// critical 判断命令是否属于最高风险等级，返回风险说明和可提取的操作对象（如路径、数据库名）
+func critical(cmdStr string) (desc, target string, ok bool) {
+	for _, r := range criticalRules {
+		m := r.re.FindStringSubmatch(cmdStr)
+		if m == nil {
+			continue
+		}
+		if r.target > 0 {
+			target = strings.Trim(m[r.target], `'"`)
+		}
+		return r.desc, target, true
+	}
+	return "", "", false
+}
+
+// randomToken 生成 6 位随机确认码，避开易混淆的字符
+func randomToken() string {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return string(b)
+}
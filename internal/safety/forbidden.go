@@ -0,0 +1,97 @@
+package safety
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"termi.sh/termi/internal/glyph"
+)
+
+// ErrForbidden 命令命中 safety.forbidden 且没有输入正确的覆盖码
+var ErrForbidden = errors.New("命令被 safety.forbidden 禁止，不能执行或复制")
+
+// forbiddenRule 一条禁止规则
+type forbiddenRule struct {
+	re   *regexp.Regexp
+	text string // 配置中的原文，用于说明
+}
+
+// tokenChars 组成路径、主机名与标识符的字符；禁止规则的两端不能紧挨着这些字符，
+// 因此 "rm -rf /" 不会命中 rm -rf /tmp/x，"prod_db" 不会命中 prod_db_replica
+const tokenChars = `\w./-`
+
+// compileForbidden 编译禁止规则：以 re: 开头的是正则表达式，其他按整词匹配、不区分大小写，
+// 规则中的空白可以匹配任意长度的空白
+func compileForbidden(patterns []string) ([]forbiddenRule, error) {
+	out := make([]forbiddenRule, 0, len(patterns))
+	for _, p := range patterns {
+		expr, isRegexp := strings.CutPrefix(p, "re:")
+		if !isRegexp {
+			words := strings.Fields(p)
+			for i := range words {
+				words[i] = regexp.QuoteMeta(words[i])
+			}
+			expr = `(?i)(?:^|[^` + tokenChars + `])` + strings.Join(words, `\s+`) + `(?:$|[^` + tokenChars + `])`
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, forbiddenRule{re: re, text: p})
+	}
+	return out, nil
+}
+
+// Unlocks 报告 code 是否是解除该命令禁止的覆盖码；没有配置覆盖码时总是 false
+func (r Result) Unlocks(code string) bool {
+	if !r.Forbidden || r.override == "" || code == "" {
+		return false
+	}
+	want, got := r.override, code
+	if hash, ok := strings.CutPrefix(r.override, "sha256:"); ok {
+		sum := sha256.Sum256([]byte(code))
+		want, got = strings.ToLower(hash), hex.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1
+}
+
+// Overridable 报告是否配置了覆盖码，没有时禁止的命令无法以任何方式使用
+func (r Result) Overridable() bool {
+	return r.override != ""
+}
+
+// ConfirmOverride 在终端中要求输入覆盖码，输入不显示在屏幕上；命令没有被禁止时直接通过
+func ConfirmOverride(r Result) error {
+	if !r.Forbidden {
+		return nil
+	}
+	glyph.Printf("⛔ 命令被禁止（%s）。\n", strings.Join(r.Reasons, "；"))
+	if !r.Overridable() {
+		return ErrForbidden
+	}
+	fmt.Print("请输入覆盖码以继续: ")
+	var code string
+	if term.IsTerminal(os.Stdin.Fd()) {
+		b, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Println()
+		if err != nil {
+			return ErrForbidden
+		}
+		code = string(b)
+	} else {
+		code, _ = bufio.NewReader(os.Stdin).ReadString('\n')
+	}
+	if !r.Unlocks(strings.TrimSpace(code)) {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package safety
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"termi.sh/termi/internal/glyph"
+)
+
+// 提示词注入检查：需求、探测输出或项目文件中夹带的指令可能诱导模型生成把本地数据发往外部主机的命令。
+// 这类命令不删除任何东西，风险规则识别不出来，因此与风险等级分开检查，命中后必须由用户审查才能执行
+
+// ErrNotReviewed 用户未确认疑似提示词注入的命令
+var ErrNotReviewed = errors.New("疑似提示词注入的命令未通过审查，已取消执行")
+
+var (
+	// statementSep 分隔多条命令，管道在命令内部再拆分
+	statementSep = regexp.MustCompile(`&&|\|\||;|\n`)
+
+	// senders 能把数据发送到网络的程序
+	senders = map[string]bool{
+		"curl": true, "wget": true, "http": true, "https": true,
+		"nc": true, "ncat": true, "netcat": true, "socat": true, "telnet": true,
+	}
+
+	// resolvers 可以把数据编码进查询域名外传的 DNS 工具
+	resolvers = map[string]bool{"dig": true, "nslookup": true, "host": true}
+
+	// uploadFlag curl、wget、httpie 携带请求体或上传文件的选项
+	uploadFlag = regexp.MustCompile(`(?:^|\s)(?:-d|--data(?:-\w+)?|-F|--form(?:-string)?|-T|--upload-file|--post-data|--post-file|--body-data|--body-file)(?:[=\s]|$)|\s\w+=@|\s\w+:=@`)
+
+	// substitution 参数中嵌入本地数据：命令替换或变量展开
+	substitution = regexp.MustCompile("\\$\\(|`|\\$\\{?[A-Za-z_]")
+
+	// urlHost 提取 URL 中的主机名
+	urlHost = regexp.MustCompile(`(?i)\b(?:https?|ftp|wss?)://(?:[^@/\s'"]*@)?(\[[^\]]+\]|[^/\s:?#'"]+)`)
+
+	envDump    = regexp.MustCompile(`(?:^|[\s;&|(])(?:env|printenv|set|export\s+-p|declare\s+-x)(?:\s*$|\s*[|;&)>])|/proc/\w+/environ`)
+	secretVar  = regexp.MustCompile(`(?i)\$\{?\w*(?:KEY|TOKEN|SECRET|PASSW(?:OR)?D|CREDENTIALS?)\w*\}?`)
+	secretFile = regexp.MustCompile(`(?:~|\$HOME|\$\{HOME\}|/root|/home/[\w.-]+)/\.(?:ssh|aws|gnupg|netrc|docker/config|kube/config|config/gcloud|git-credentials)|/etc/shadow|(?:^|[\s/'"])\.env\b`)
+
+	reverseShell = regexp.MustCompile(`/dev/(?:tcp|udp)/|(?:^|[\s;&|(])n(?:c|cat|etcat)\s+(?:\S+\s+)*-[ec]\s|socat\s.*\bexec:`)
+)
+
+// Inspect 检查命令是否像提示词注入的产物：把环境变量、凭据或本地数据发送到未知主机，
+// 通过 DNS 查询外传数据，或反弹 shell。需求中出现的主机、trusted_hosts 及本机视为已知主机。
+// 返回命中的说明，没有可疑之处时返回 nil；nil 检查器只认本机
+func (a *Analyzer) Inspect(cmd, query string) []string {
+	var reasons []string
+	add := func(reason string) {
+		if !slices.Contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	if reverseShell.MatchString(cmd) {
+		add("反弹 shell，把本机的 shell 交给远程主机控制")
+	}
+	for _, statement := range statementSep.Split(cmd, -1) {
+		stages := strings.Split(statement, "|")
+		for i, stage := range stages {
+			program, args := splitProgram(stage)
+			if !senders[program] && !resolvers[program] {
+				continue
+			}
+			// 管道输入、请求体、重定向输入或参数中的替换都会把本地数据带出去
+			sends := i > 0 || substitution.MatchString(args) || strings.Contains(args, "<")
+			if program != "nc" && program != "ncat" && program != "netcat" && program != "socat" && program != "telnet" {
+				sends = sends || uploadFlag.MatchString(" "+args)
+			}
+			if !sends {
+				continue
+			}
+			host := targetHost(program, args)
+			if a.knownHost(host, query) {
+				continue
+			}
+
+			what := "本地数据"
+			switch {
+			case envDump.MatchString(statement) || secretVar.MatchString(statement):
+				what = "环境变量中的密钥"
+			case secretFile.MatchString(statement):
+				what = "密钥或凭据文件"
+			}
+			if host == "" {
+				host = "无法确定的主机"
+			}
+			if resolvers[program] {
+				add(fmt.Sprintf("通过 DNS 查询把%s外传到 %s", what, host))
+			} else {
+				add(fmt.Sprintf("把%s发送到未知主机 %s", what, host))
+			}
+		}
+	}
+	return reasons
+}
+
+// splitProgram 返回命令的程序名与其余参数，跳过 sudo 与前置的环境变量赋值
+func splitProgram(stage string) (string, string) {
+	fields := strings.Fields(stage)
+	for i, field := range fields {
+		if field == "sudo" || field == "(" || strings.Contains(field, "=") && !strings.HasPrefix(field, "-") {
+			continue
+		}
+		return filepath.Base(strings.TrimLeft(field, "(")), strings.Join(fields[i+1:], " ")
+	}
+	return "", ""
+}
+
+// targetHost 提取网络命令的目标主机，无法确定（例如主机名来自变量）时返回空字符串
+func targetHost(program, args string) string {
+	if m := urlHost.FindStringSubmatch(args); m != nil {
+		return strings.ToLower(strings.Trim(m[1], "[]"))
+	}
+	var operands []string
+	for _, field := range strings.Fields(args) {
+		if !strings.HasPrefix(field, "-") && !strings.HasPrefix(field, "@") && !strings.HasPrefix(field, "<") {
+			operands = append(operands, strings.Trim(field, `'"`))
+		}
+	}
+	if len(operands) == 0 {
+		return ""
+	}
+	host := operands[0]
+	switch {
+	case resolvers[program]:
+		// 查询的域名本身就是外传的目标，取它的注册域名部分
+		if labels := strings.Split(strings.TrimSuffix(host, "."), "."); len(labels) >= 2 {
+			host = strings.Join(labels[len(labels)-2:], ".")
+		}
+	case program == "curl" || program == "wget" || program == "http" || program == "https":
+		// 没有协议前缀的 URL，例如 curl example.com/upload
+		host, _, _ = strings.Cut(host, "/")
+		host, _, _ = strings.Cut(host, ":")
+	}
+	if substitution.MatchString(host) {
+		return ""
+	}
+	return strings.ToLower(host)
+}
+
+// knownHost 判断主机是否可信：本机、trusted_hosts 中的域名及其子域名，或需求中提到的主机
+func (a *Analyzer) knownHost(host, query string) bool {
+	switch {
+	case host == "":
+		return false
+	case host == "localhost" || host == "::1" || strings.HasPrefix(host, "127."):
+		return true
+	case query != "" && strings.Contains(strings.ToLower(query), host):
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	for _, trusted := range a.trusted {
+		trusted = strings.ToLower(strings.TrimPrefix(trusted, "."))
+		if host == trusted || strings.HasSuffix(host, "."+trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfirmReview 展示疑似提示词注入的原因，要求用户输入随机确认码表示已审查命令，
+// 随机码防止把注入内容里预先写好的确认词一并粘贴进来
+func ConfirmReview(cmd string, reasons []string) error {
+	if len(reasons) == 0 {
+		return nil
+	}
+	token := randomToken()
+	glyph.Printf("🛡  命令疑似提示词注入的产物（%s），可能把本机数据发送出去。\n", strings.Join(reasons, "；"))
+	fmt.Printf("请逐字审查命令:\n  %s\n", cmd)
+	fmt.Printf("确认无误后输入 %s 执行: ", token)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != token {
+		return ErrNotReviewed
+	}
+	return nil
+}
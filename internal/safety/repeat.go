@@ -0,0 +1,101 @@
+package safety
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"termi.sh/termi/internal/glyph"
+)
+
+// 幂等性检查：修复循环中重试失败的命令、从历史记录重新执行时，只读或可以安全重复的命令直接执行；
+// 追加写入、删除、创建资源、发起写操作的 API 请求等重复执行会再次产生副作用的命令需要明确确认。
+// 失败的命令也可能在出错前已经部分生效，风险规则只看单次执行，识别不出这类问题
+
+// ErrNotRepeated 用户未确认重复执行非幂等的命令
+var ErrNotRepeated = errors.New("未确认重复执行非幂等的命令，已取消执行")
+
+// sideEffect 一条非幂等操作规则
+type sideEffect struct {
+	re   *regexp.Regexp
+	desc string
+}
+
+var sideEffects = []sideEffect{
+	{regexp.MustCompile(`>>|(?:^|[\s;&|(])tee\s+(?:-\S+\s+)*-\S*a`), "追加写入文件"},
+	{command(`(?:rm|rmdir|unlink|shred)\s`), "删除文件"},
+	{command(`find\s.*\s(?:-delete|-exec\s+rm)\b`), "删除 find 匹配的文件"},
+	{command(`mv\s`), "移动文件，重复执行时源文件已不存在或会覆盖目标"},
+	{command(`git\s+(?:commit|stash|cherry-pick|revert|merge|rebase|am|tag)\b`), "创建新的 git 提交或改写历史"},
+	{command(`(?:kubectl\s+(?:delete|create)|docker\s+(?:rm|rmi|run|create)|podman\s+(?:rm|rmi|run|create))\b`), "创建或删除容器与集群资源"},
+	{command(`(?:useradd|adduser|groupadd|userdel|groupdel)(?:\s|$)`), "创建或删除系统用户"},
+	{command(`(?:sendmail|mailx?)(?:\s|$)`), "发送邮件"},
+	{regexp.MustCompile(`(?i)\b(?:insert\s+into|delete\s+from|update\s+\w+\s+set|drop\s+table)\b`), "写入或删除数据库中的数据"},
+}
+
+var (
+	// httpClient 发起 HTTP 请求的程序
+	httpClient = command(`(?:curl|wget|http|https)\s`)
+
+	// httpMethod curl 的 -X/--request、wget 的 --method 与 httpie 的方法参数
+	httpMethod = regexp.MustCompile(`(?i)(?:-X\s*|--request[=\s]+|--method[=\s]+|(?:^|\s)https?\s+(?:-\S+\s+)*)['"]?(GET|HEAD|OPTIONS|PUT|POST|PATCH|DELETE)\b`)
+)
+
+// SideEffects 返回重复执行命令会再次产生的副作用；只读或可以安全重复的命令返回 nil。
+// 使用 POST、PATCH 或带请求体的 HTTP 请求（支付、下单、发消息等接口）视为非幂等，
+// GET、PUT、DELETE 按 HTTP 语义视为幂等
+func SideEffects(cmd string) []string {
+	var reasons []string
+	add := func(reason string) {
+		if !slices.Contains(reasons, reason) {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	for _, e := range sideEffects {
+		if e.re.MatchString(cmd) {
+			add(e.desc)
+		}
+	}
+	for _, stmt := range statementSep.Split(cmd, -1) {
+		for _, stage := range strings.Split(stmt, "|") {
+			if httpClient.MatchString(stage) && writeRequest(stage) {
+				add("发起写操作的 HTTP 请求（如下单、支付、发送消息）")
+			}
+		}
+	}
+	return reasons
+}
+
+// writeRequest 判断一次 HTTP 请求是否为非幂等的写操作：显式指定了 POST、PATCH，
+// 或带请求体而没有指定方法（curl、wget 此时默认 POST）
+func writeRequest(stage string) bool {
+	if m := httpMethod.FindStringSubmatch(stage); m != nil {
+		switch strings.ToUpper(m[1]) {
+		case "POST", "PATCH":
+			return true
+		default:
+			return false
+		}
+	}
+	return uploadFlag.MatchString(stage)
+}
+
+// ConfirmRepeat 列出重复执行会再次产生的副作用，要求用户输入 yes 确认再次执行
+func ConfirmRepeat(cmd string, reasons []string) error {
+	if len(reasons) == 0 {
+		return nil
+	}
+	glyph.Printf("🔁 这是一次重复执行，命令包含非幂等操作（%s），再次执行会重复这些副作用；\n", strings.Join(reasons, "；"))
+	fmt.Println("   上一次执行失败时也可能已经部分生效，请先确认当前状态。")
+	fmt.Printf("请输入 yes 以再次执行 %s: ", cmd)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != "yes" {
+		return ErrNotRepeated
+	}
+	return nil
+}
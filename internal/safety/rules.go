@@ -0,0 +1,308 @@
+package safety
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rulesCheckInterval 两次检查规则目录是否变化的最短间隔，界面每次重绘都会检查命令
+const rulesCheckInterval = time.Second
+
+// UserRule 规则目录中用户定义的一条风险规则，附带必须命中与不应命中的示例命令
+type UserRule struct {
+	File    string
+	Line    int
+	Pattern string
+	Level   Level
+	Message string
+	Match   []string // 必须命中的示例命令，至少一条
+	Ignore  []string // 不应命中的示例命令
+
+	re *regexp.Regexp
+}
+
+// levels 规则文件中 level 的取值
+var levels = map[string]Level{
+	"caution":  Caution,
+	"high":     High,
+	"critical": Critical,
+}
+
+// ParseRules 解析并验证一个规则文件，name 用于错误信息。文件是 YAML 列表的子集：
+//
+//	# 注释
+//	- pattern: '\bpsql\b.*\bprod-'
+//	  level: high                  # caution、high 或 critical
+//	  message: 在生产数据库上执行语句
+//	  match:
+//	    - psql -h prod-db1 -c "delete from users"
+//	  ignore:
+//	    - psql -h staging-db1
+//
+// pattern 是匹配整条命令的正则表达式，建议用单引号包裹；match 与 ignore 中的示例在加载时逐条验证
+func ParseRules(name string, data []byte) ([]UserRule, error) {
+	var (
+		out  []UserRule
+		cur  *UserRule
+		list *[]string // 当前正在填写的 match 或 ignore
+	)
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		n := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "\t") {
+			return nil, fmt.Errorf("%s:%d: 不能使用 Tab 缩进", name, n)
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		item, isItem := strings.CutPrefix(trimmed, "- ")
+		if trimmed == "-" {
+			item, isItem = "", true
+		}
+		switch {
+		case indent == 0 && isItem:
+			out = append(out, UserRule{File: name, Line: n})
+			cur, list = &out[len(out)-1], nil
+			if item == "" {
+				continue
+			}
+			trimmed = item
+		case indent == 0:
+			return nil, fmt.Errorf("%s:%d: 每条规则应以 \"- \" 开头", name, n)
+		case isItem:
+			if list == nil {
+				return nil, fmt.Errorf("%s:%d: 列表项只能出现在 match 或 ignore 下", name, n)
+			}
+			v, err := scalar(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+			}
+			*list = append(*list, v)
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("%s:%d: 每条规则应以 \"- \" 开头", name, n)
+		}
+
+		key, raw, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: 应为 \"键: 值\"", name, n)
+		}
+		value, err := scalar(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+		}
+		list = nil
+		switch key = strings.TrimSpace(key); key {
+		case "pattern":
+			cur.Pattern = value
+		case "level":
+			level, ok := levels[strings.ToLower(value)]
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: level 应为 caution、high 或 critical，而不是 %q", name, n, value)
+			}
+			cur.Level = level
+		case "message":
+			cur.Message = value
+		case "match", "ignore":
+			if value != "" {
+				return nil, fmt.Errorf("%s:%d: %s 应为列表，每行一条 \"- 示例命令\"", name, n, key)
+			}
+			list = &cur.Match
+			if key == "ignore" {
+				list = &cur.Ignore
+			}
+		default:
+			return nil, fmt.Errorf("%s:%d: 未知的键 %q（可用 pattern、level、message、match、ignore）", name, n, key)
+		}
+	}
+
+	for i := range out {
+		if err := out[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, out[i].Line, err)
+		}
+	}
+	return out, nil
+}
+
+// compile 编译规则并用示例命令验证
+func (r *UserRule) compile() error {
+	switch {
+	case r.Pattern == "":
+		return fmt.Errorf("缺少 pattern")
+	case r.Level == Safe:
+		return fmt.Errorf("缺少 level")
+	case r.Message == "":
+		return fmt.Errorf("缺少 message")
+	case len(r.Match) == 0:
+		return fmt.Errorf("至少需要一条 match 示例")
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("pattern 无效: %w", err)
+	}
+	for _, cmd := range r.Match {
+		if !re.MatchString(cmd) {
+			return fmt.Errorf("pattern 没有命中 match 示例: %s", cmd)
+		}
+	}
+	for _, cmd := range r.Ignore {
+		if re.MatchString(cmd) {
+			return fmt.Errorf("pattern 命中了 ignore 示例: %s", cmd)
+		}
+	}
+	r.re = re
+	return nil
+}
+
+// scalar 解析单引号、双引号或不加引号的值，并去掉行尾注释
+func scalar(raw string) (string, error) {
+	v := strings.TrimSpace(raw)
+	var rest string
+	switch {
+	case strings.HasPrefix(v, "'"):
+		var b strings.Builder
+		i := 1
+		for ; i < len(v); i++ {
+			if v[i] != '\'' {
+				b.WriteByte(v[i])
+				continue
+			}
+			if i+1 < len(v) && v[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+				continue
+			}
+			break
+		}
+		if i >= len(v) {
+			return "", fmt.Errorf("单引号未闭合")
+		}
+		v, rest = b.String(), v[i+1:]
+	case strings.HasPrefix(v, `"`):
+		end := 1
+		for end < len(v) && v[end] != '"' {
+			if v[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(v) {
+			return "", fmt.Errorf("双引号未闭合")
+		}
+		s, err := strconv.Unquote(v[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("双引号中的转义无效，正则表达式请改用单引号: %s", v[:end+1])
+		}
+		v, rest = s, v[end+1:]
+	default:
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		return v, nil
+	}
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("引号后有多余内容: %s", rest)
+	}
+	return v, nil
+}
+
+// LoadRules 读取目录中的所有 .yaml、.yml 规则文件。验证失败的文件整体跳过，
+// 其错误与成功加载的规则一并返回；目录不存在时没有规则也没有错误
+func LoadRules(dir string) ([]UserRule, []error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []error{fmt.Errorf("读取规则目录失败: %w", err)}
+	}
+	var (
+		rules []UserRule
+		errs  []error
+	)
+	for _, e := range entries {
+		if e.IsDir() || !isRuleFile(e.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("读取规则文件失败: %w", err))
+			continue
+		}
+		parsed, err := ParseRules(path, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules = append(rules, parsed...)
+	}
+	return rules, errs
+}
+
+func isRuleFile(name string) bool {
+	ext := filepath.Ext(name)
+	return !strings.HasPrefix(name, ".") && (ext == ".yaml" || ext == ".yml")
+}
+
+// ruleDir 规则目录的热加载：检查时发现文件增删或修改就重新加载
+type ruleDir struct {
+	dir string
+
+	mu      sync.Mutex
+	checked time.Time
+	sig     string
+	rules   []UserRule
+	errs    []error
+}
+
+// current 返回目录中当前有效的规则与加载错误，距上次检查不足 rulesCheckInterval 时直接返回上次的结果
+func (d *ruleDir) current() ([]UserRule, []error) {
+	if d == nil {
+		return nil, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if time.Since(d.checked) < rulesCheckInterval {
+		return d.rules, d.errs
+	}
+	d.checked = time.Now()
+	if sig := dirSignature(d.dir); sig != d.sig {
+		d.sig = sig
+		d.rules, d.errs = LoadRules(d.dir)
+	}
+	return d.rules, d.errs
+}
+
+// dirSignature 由规则文件的名称、大小与修改时间组成，任何一个文件变化时签名随之变化
+func dirSignature(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var parts []string
+	for _, e := range entries {
+		if e.IsDir() || !isRuleFile(e.Name()) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", e.Name(), info.Size(), info.ModTime().UnixNano()))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ";")
+}
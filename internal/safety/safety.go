@@ -0,0 +1,250 @@
+// Package safety 在执行前用本地规则检查命令的风险，不依赖 LLM 的判断：
+// 破坏性文件操作、磁盘操作、管道执行远程脚本、提权、fork 炸弹等，
+// 以及用户在 safety.d 目录中补充的规则
+package safety
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+)
+
+var (
+	// ErrNotConfirmed 用户未通过高危命令的输入确认
+	ErrNotConfirmed = errors.New("高危命令未确认，已取消执行")
+
+	// ErrBlocked 命令命中配置的 blocklist，不允许执行
+	ErrBlocked = errors.New("命令命中 safety.blocklist，不允许执行")
+)
+
+// Level 风险等级
+type Level int
+
+const (
+	Safe     Level = iota // 未命中任何规则
+	Caution               // 提权、结束进程、修改权限等需要留意的操作
+	High                  // 删除数据、执行远程脚本、重启等难以撤销的操作，执行前需输入 yes 确认
+	Critical              // 格式化、递归删除系统目录等，执行前需输入操作对象确认
+)
+
+func (l Level) String() string {
+	switch l {
+	case Caution:
+		return "注意"
+	case High:
+		return "高危"
+	case Critical:
+		return "极高危"
+	default:
+		return "安全"
+	}
+}
+
+// Result 一条命令的检查结果
+type Result struct {
+	Level   Level
+	Reasons []string // 命中规则的说明
+	Target  string   // 极高危命令的操作对象（如路径、数据库名），用于输入确认
+	Blocked bool     // 命中 blocklist，只能复制不能执行
+	Allowed bool     // 命中 allowlist，不提示风险也不要求确认
+
+	// Forbidden 命中 forbidden，输入覆盖码之前既不能执行也不能复制
+	Forbidden bool
+	override  string // 配置的覆盖码
+}
+
+// rule 一条风险规则
+type rule struct {
+	re    *regexp.Regexp
+	level Level
+	desc  string
+}
+
+// command 匹配出现在命令开头或 ;、&&、|、( 之后的程序名
+func command(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[\s;&|(])` + pattern)
+}
+
+var rules = []rule{
+	{command(`rm\s+(?:-\S+\s+)*-\S*[rRf]`), High, "强制或递归删除文件"},
+	{command(`find\s.*\s(?:-delete|-exec\s+rm)\b`), High, "批量删除 find 匹配的文件"},
+	{command(`dd\s`), High, "dd 直接读写磁盘或文件"},
+	{command(`(?:curl|wget)\s[^|]*\|\s*(?:sudo\s+)?(?:ba|z|da|fi)?sh\b`), High, "下载远程脚本并直接执行"},
+	{command(`(?:ba|z)?sh\s+(?:-c\s+)?["']?\$\((?:curl|wget)\s`), High, "下载远程脚本并直接执行"},
+	{command(`chmod\s+(?:-\S+\s+)*(?:-R\s+)?0?777\b`), High, "开放所有用户的读写执行权限"},
+	{command(`(?:shutdown|reboot|halt|poweroff)\b`), High, "关机或重启"},
+	{command(`git\s+(?:reset\s+--hard|clean\s+-\S*f|push\s+.*(?:--force\b|-f\b))`), High, "丢弃本地修改或强制覆盖远程分支"},
+	{command(`(?:iptables|nft)\s+(?:-F|flush)\b`), High, "清空防火墙规则"},
+	{command(`crontab\s+-r\b`), High, "删除全部定时任务"},
+	{command(`truncate\s`), High, "截断文件内容"},
+	{regexp.MustCompile(`(?:^|[^>])>\s*/(?:etc|boot|usr|bin|sbin)/\S+`), High, "覆盖系统文件"},
+	{command(`kubectl\s+delete\b`), High, "删除 Kubernetes 资源"},
+	{command(`docker\s+(?:system|volume)\s+prune\b`), High, "清理 Docker 数据"},
+	{regexp.MustCompile(`(?i)\b(?:drop\s+table|truncate\s+table|delete\s+from\s+\w+\s*(?:;|$))`), High, "删除数据库中的数据"},
+	{command(`(?:sudo|doas|su)\b`), Caution, "以管理员权限执行"},
+	{command(`(?:kill|pkill|killall)\b`), Caution, "结束进程"},
+	{command(`(?:chmod|chown|chgrp)\s+(?:-\S+\s+)*-R\b`), Caution, "递归修改权限或属主"},
+	{command(`systemctl\s+(?:stop|disable|mask|restart)\b`), Caution, "停止或重启系统服务"},
+	{command(`mv\s`), Caution, "移动或覆盖文件"},
+}
+
+// Analyzer 按内置规则、safety.d 中的用户规则与配置的 forbidden、blocklist、allowlist 检查命令
+type Analyzer struct {
+	forbid   []forbiddenRule
+	override string
+	block    []*regexp.Regexp
+	allow    []*regexp.Regexp
+	trusted  []string // 提示词注入检查中视为已知的主机
+	user     *ruleDir
+	strict   bool // 命中规则的风险等级提高一级
+}
+
+// New 根据配置创建检查器，blocklist 与 allowlist 为正则表达式
+func New(sc config.SafetyConfig) (*Analyzer, error) {
+	forbid, err := compileForbidden(sc.Forbidden)
+	if err != nil {
+		return nil, fmt.Errorf("safety.forbidden 无效: %w", err)
+	}
+	block, err := compile(sc.Blocklist)
+	if err != nil {
+		return nil, fmt.Errorf("safety.blocklist 无效: %w", err)
+	}
+	allow, err := compile(sc.Allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("safety.allowlist 无效: %w", err)
+	}
+	return &Analyzer{
+		forbid:   forbid,
+		override: sc.OverrideCode,
+		block:    block,
+		allow:    allow,
+		trusted:  sc.TrustedHosts,
+		user:     &ruleDir{dir: config.SafetyRulesDir()},
+	}, nil
+}
+
+// WithStrict 返回设置了严格程度的检查器副本。严格时命中规则的风险等级提高一级（最高为极高危），
+// 需要留意的命令也要输入 yes 确认；forbidden、blocklist 与 allowlist 不受影响
+func (a *Analyzer) WithStrict(strict bool) *Analyzer {
+	if a == nil {
+		if !strict {
+			return nil
+		}
+		return &Analyzer{strict: true}
+	}
+	clone := *a
+	clone.strict = strict
+	return &clone
+}
+
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// Analyze 检查命令的风险；nil 检查器只使用内置规则。
+// forbidden 优先于 blocklist，blocklist 优先于 allowlist，allowlist 优先于内置规则
+func (a *Analyzer) Analyze(cmd string) Result {
+	var r Result
+	if a != nil {
+		for _, f := range a.forbid {
+			if f.re.MatchString(cmd) {
+				return Result{Level: Critical, Reasons: []string{"命中禁止规则: " + f.text}, Forbidden: true, override: a.override}
+			}
+		}
+		for _, re := range a.block {
+			if re.MatchString(cmd) {
+				return Result{Level: Critical, Reasons: []string{"命中 blocklist: " + re.String()}, Blocked: true}
+			}
+		}
+		for _, re := range a.allow {
+			if re.MatchString(cmd) {
+				return Result{Allowed: true}
+			}
+		}
+	}
+
+	if desc, target, ok := critical(cmd); ok {
+		r.Level = Critical
+		r.Target = target
+		r.Reasons = append(r.Reasons, desc)
+	}
+	for _, rl := range rules {
+		if !rl.re.MatchString(cmd) {
+			continue
+		}
+		r.Level = max(r.Level, rl.level)
+		if !slices.Contains(r.Reasons, rl.desc) {
+			r.Reasons = append(r.Reasons, rl.desc)
+		}
+	}
+	if a != nil {
+		user, _ := a.user.current()
+		for _, ur := range user {
+			if !ur.re.MatchString(cmd) {
+				continue
+			}
+			r.Level = max(r.Level, ur.Level)
+			if !slices.Contains(r.Reasons, ur.Message) {
+				r.Reasons = append(r.Reasons, ur.Message)
+			}
+		}
+		if a.strict && r.Level > Safe && r.Level < Critical {
+			r.Level++
+			r.Reasons = append(r.Reasons, "该类查询使用严格的安全检查，风险等级提高一级")
+		}
+	}
+	return r
+}
+
+// Confirm 按检查结果在终端中要求用户确认：高危命令需输入 yes，极高危命令需重新输入操作对象名称
+// （无法提取或过于简短时改为随机确认码），比单次回车更难误触发。blocklist 中的命令直接拒绝，
+// 禁止的命令需要输入覆盖码。e 决定风险说明的详略，确认方式不受影响
+func Confirm(r Result, e config.Expertise) error {
+	switch {
+	case r.Forbidden:
+		return ConfirmOverride(r)
+	case r.Blocked:
+		return ErrBlocked
+	case r.Allowed || r.Level < High:
+		return nil
+	}
+
+	token := "yes"
+	if r.Level == Critical {
+		token = r.Target
+		if len(token) < 3 || strings.ContainsAny(token, "*~") || token == "/" {
+			token = randomToken()
+		}
+	}
+
+	switch e {
+	case config.Expert:
+		glyph.Printf("⚠️  %s: %s\n", r.Level, strings.Join(r.Reasons, "；"))
+	case config.Beginner:
+		glyph.Printf("⚠️  %s操作（%s），执行后可能无法恢复。\n", r.Level, strings.Join(r.Reasons, "；"))
+		fmt.Println("   请确认命令的作用对象（文件、目录、主机、数据库等）正是你想要的，并且重要数据已有备份；不确定时直接回车取消。")
+	default:
+		glyph.Printf("⚠️  %s操作（%s），执行后可能无法恢复。\n", r.Level, strings.Join(r.Reasons, "；"))
+	}
+	fmt.Printf("请输入 %s 以确认执行: ", token)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != token {
+		return ErrNotConfirmed
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package safety
+
+import (
+	"regexp"
+	"testing"
+)
+
+// ruleCase 一条内置规则的示例：match 中的命令必须命中，ignore 中的命令不应命中
+type ruleCase struct {
+	desc          string
+	match, ignore []string
+}
+
+// checkRules 逐条检查规则与示例，cases 与规则按顺序一一对应，规则增删时必须同步补充示例
+func checkRules(t *testing.T, res []*regexp.Regexp, descs []string, cases []ruleCase) {
+	t.Helper()
+	if len(cases) != len(res) {
+		t.Fatalf("%d rules but %d cases, every built-in rule needs a case", len(res), len(cases))
+	}
+	for i, tc := range cases {
+		if tc.desc != descs[i] {
+			t.Fatalf("case %d is %q, rule is %q", i, tc.desc, descs[i])
+		}
+		if len(tc.match) == 0 || len(tc.ignore) == 0 {
+			t.Fatalf("case %d (%s) needs both match and ignore commands", i, tc.desc)
+		}
+		for _, cmd := range tc.match {
+			if !res[i].MatchString(cmd) {
+				t.Errorf("rule %d (%s) does not match %q", i, tc.desc, cmd)
+			}
+		}
+		for _, cmd := range tc.ignore {
+			if res[i].MatchString(cmd) {
+				t.Errorf("rule %d (%s) matches %q", i, tc.desc, cmd)
+			}
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	cases := []ruleCase{
+		{"强制或递归删除文件",
+			[]string{"rm -rf build", "cd /tmp && rm -f a.txt", "rm -v -r logs"},
+			[]string{"rm a.txt", "rm -i notes.txt", "confirm -f"}},
+		{"批量删除 find 匹配的文件",
+			[]string{"find . -name '*.log' -delete", `find /tmp -type f -exec rm {} \;`},
+			[]string{"find . -name '*.log'", "find . -newer a -print"}},
+		{"dd 直接读写磁盘或文件",
+			[]string{"dd if=/dev/zero of=disk.img bs=1M count=10", "sudo dd if=a of=b"},
+			[]string{"git add .", "ddrescue in.img out.img"}},
+		{"下载远程脚本并直接执行",
+			[]string{"curl -fsSL https://get.docker.com | sh", "wget -qO- https://x.io/i.sh | sudo bash"},
+			[]string{"curl -fsSL https://x.io/i.sh -o install.sh", "curl https://api.x.io | jq ."}},
+		{"下载远程脚本并直接执行",
+			[]string{`sh -c "$(curl -fsSL https://x.io/install.sh)"`, "bash $(wget -qO- https://x.io)"},
+			[]string{"bash install.sh", `sh -c "$(cat script.sh)"`}},
+		{"开放所有用户的读写执行权限",
+			[]string{"chmod 777 /srv/www", "chmod -R 0777 ."},
+			[]string{"chmod 755 script.sh", "chmod 7770 dir"}},
+		{"关机或重启",
+			[]string{"sudo shutdown -h now", "reboot", "systemctl poweroff"},
+			[]string{"uptime", "echo rebooting"}},
+		{"丢弃本地修改或强制覆盖远程分支",
+			[]string{"git reset --hard HEAD~1", "git clean -fdx", "git push origin main --force", "git push -f"},
+			[]string{"git reset --soft HEAD~1", "git push origin main", "git clean -n"}},
+		{"清空防火墙规则",
+			[]string{"iptables -F", "nft flush ruleset"},
+			[]string{"iptables -L", "nft list ruleset"}},
+		{"删除全部定时任务",
+			[]string{"crontab -r"},
+			[]string{"crontab -l", "crontab -e"}},
+		{"截断文件内容",
+			[]string{"truncate -s 0 app.log"},
+			[]string{"echo truncated", "ls truncate.go"}},
+		{"覆盖系统文件",
+			[]string{"echo 127.0.0.1 x > /etc/hosts", ">/usr/bin/python3"},
+			[]string{"echo x >> /etc/hosts", "cat /etc/hosts > hosts.bak"}},
+		{"删除 Kubernetes 资源",
+			[]string{"kubectl delete pod web-0", "kubectl -n dev get po && kubectl delete deploy api"},
+			[]string{"kubectl get pods", "kubectl describe pod web-0"}},
+		{"清理 Docker 数据",
+			[]string{"docker system prune -af", "docker volume prune"},
+			[]string{"docker image prune", "docker volume ls"}},
+		{"删除数据库中的数据",
+			[]string{`psql -c "DROP TABLE users"`, `mysql -e "delete from sessions;"`, `psql -c "truncate table logs"`},
+			[]string{`mysql -e "delete from sessions where id = 1;"`, `psql -c "select * from users"`}},
+		{"以管理员权限执行",
+			[]string{"sudo apt update", "su - root", "doas reboot"},
+			[]string{"sudoku", "echo subsystem", "pseudo"}},
+		{"结束进程",
+			[]string{"kill -9 1234", "pkill nginx", "ps aux | killall node"},
+			[]string{"echo skilled", "killer"}},
+		{"递归修改权限或属主",
+			[]string{"chown -R www-data:www-data /srv/www", "chmod -R g+w ."},
+			[]string{"chown www-data file", "chmod g+w file"}},
+		{"停止或重启系统服务",
+			[]string{"systemctl restart nginx", "sudo systemctl disable sshd"},
+			[]string{"systemctl status nginx", "systemctl start nginx"}},
+		{"移动或覆盖文件",
+			[]string{"mv a.txt b.txt", "cd src && mv old new"},
+			[]string{"mvn package", "ls mv"}},
+	}
+	res := make([]*regexp.Regexp, len(rules))
+	descs := make([]string, len(rules))
+	for i, r := range rules {
+		res[i], descs[i] = r.re, r.desc
+	}
+	checkRules(t, res, descs, cases)
+}
+
+func TestCriticalRules(t *testing.T) {
+	cases := []ruleCase{
+		{"递归删除",
+			[]string{"rm -rf /", "sudo rm -rf /usr/*", "rm -fr ~", "rm -r .", "rm -rf *"},
+			[]string{"rm -rf ./build", "rm -rf /tmp/cache", "rm -f /", "rm -rf /etc/nginx/conf.d"}},
+		{"格式化文件系统",
+			[]string{"mkfs.ext4 /dev/sdb1", "sudo mkfs -t xfs /dev/sdc"},
+			[]string{"man mkfs", "ls /sbin/mkfs.ext4"}},
+		{"覆写块设备",
+			[]string{"dd if=image.iso of=/dev/sdb bs=4M"},
+			[]string{"dd if=/dev/zero of=disk.img", "dd if=/dev/sda of=backup.img"}},
+		{"擦除设备",
+			[]string{"wipefs -a /dev/sdb", "shred -vz /dev/nvme0n1"},
+			[]string{"shred -u secret.txt", "wipefs --help"}},
+		{"覆写块设备",
+			[]string{"cat image > /dev/sda", "echo 0 >/dev/nvme0n1"},
+			[]string{"echo hi > /dev/null", "ls > /dev/stderr"}},
+		{"删除数据库",
+			[]string{`mysql -e "DROP DATABASE IF EXISTS shop"`, "psql -c 'drop schema audit cascade'"},
+			[]string{`mysql -e "CREATE DATABASE shop"`, `psql -c "drop table users"`}},
+		{"删除 Kubernetes 命名空间",
+			[]string{"kubectl delete namespace staging", "kubectl delete ns dev"},
+			[]string{"kubectl delete pod staging-0", "kubectl get ns"}},
+		{"销毁 Terraform 管理的资源",
+			[]string{"terraform destroy", "terraform apply -auto-approve -destroy"},
+			[]string{"terraform apply", "terraform plan -destroy"}},
+		{"递归修改系统目录权限",
+			[]string{"chmod -R 777 /", "sudo chmod -R 755 /etc"},
+			[]string{"chmod -R 755 /etc/nginx", "chmod -R 755 ./dist"}},
+		{"fork 炸弹",
+			[]string{":(){ :|:& };:"},
+			[]string{": > file", "echo ok"}},
+	}
+	res := make([]*regexp.Regexp, len(criticalRules))
+	descs := make([]string, len(criticalRules))
+	for i, r := range criticalRules {
+		res[i], descs[i] = r.re, r.desc
+	}
+	checkRules(t, res, descs, cases)
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		cmd    string
+		level  Level
+		target string
+	}{
+		{"ls -la", Safe, ""},
+		{"sudo apt update", Caution, ""},
+		{"rm -rf build", High, ""},
+		{"rm -rf /", Critical, "/"},
+		{"mkfs.ext4 /dev/sdb1", Critical, "/dev/sdb1"},
+		{"dd if=image.iso of=/dev/sdb bs=4M", Critical, "/dev/sdb"},
+		{`mysql -e "DROP DATABASE IF EXISTS shop"`, Critical, "shop"},
+		{"kubectl delete namespace staging", Critical, "staging"},
+		{"terraform destroy", Critical, ""},
+	}
+	var a *Analyzer
+	for _, tc := range tests {
+		t.Run(tc.cmd, func(t *testing.T) {
+			r := a.Analyze(tc.cmd)
+			if r.Level != tc.level || r.Target != tc.target {
+				t.Errorf("Analyze(%q) = %v %q, want %v %q", tc.cmd, r.Level, r.Target, tc.level, tc.target)
+			}
+		})
+	}
+}
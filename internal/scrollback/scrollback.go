@@ -0,0 +1,76 @@
+// Package scrollback 从 tmux 或 GNU screen 中截取当前窗口的终端回滚内容
+package scrollback
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"termi.sh/termi/internal/mux"
+)
+
+// ErrUnavailable 当前终端不在 tmux 或 screen 会话中
+var ErrUnavailable = errors.New("未检测到 tmux 或 screen 会话，无法读取终端回滚内容")
+
+// Capture 返回当前窗口最近 lines 行的终端输出，去掉末尾的空行
+func Capture(lines int) (string, error) {
+	var text string
+	var err error
+	switch mux.Detect() {
+	case mux.Tmux:
+		text, err = captureTmux(lines)
+	case mux.Screen:
+		text, err = captureScreen()
+	default:
+		return "", ErrUnavailable
+	}
+	if err != nil {
+		return "", err
+	}
+	return tail(text, lines), nil
+}
+
+// captureTmux 通过 capture-pane 读取当前窗格，-J 合并自动换行的行
+func captureTmux(lines int) (string, error) {
+	args := []string{"capture-pane", "-p", "-J", "-S", "-" + strconv.Itoa(lines)}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	out, err := exec.Command("tmux", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("读取 tmux 回滚内容失败: %w", err)
+	}
+	return string(out), nil
+}
+
+// captureScreen 通过 hardcopy -h 将当前窗口连同回滚内容写入临时文件
+func captureScreen() (string, error) {
+	f, err := os.CreateTemp("", "termi-screen-*.txt")
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	if err := exec.Command("screen", "-X", "hardcopy", "-h", path).Run(); err != nil {
+		return "", fmt.Errorf("读取 screen 回滚内容失败: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取 screen 回滚内容失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// tail 返回最后 n 个非空尾部的行
+func tail(text string, n int) string {
+	lines := strings.Split(strings.TrimRight(text, "\n \t"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,205 @@
+// Package share 把会话记录（需求、追问、选中的命令与说明）发布到粘贴服务，
+// 得到可以发给同事的链接。内容由调用方在发布前脱敏
+package share
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+)
+
+const (
+	// defaultTimeout 发布请求的超时时间
+	defaultTimeout = 15 * time.Second
+	// defaultGistURL GitHub gist 接口地址
+	defaultGistURL = "https://api.github.com/gists"
+)
+
+// Exchange 一轮追问与回答
+type Exchange struct {
+	Question string
+	Answer   string
+}
+
+// Step 命令中的一个片段及其含义
+type Step struct {
+	Code string
+	Note string
+}
+
+// Transcript 要分享的会话记录
+type Transcript struct {
+	Time        time.Time
+	Query       string
+	Exchanges   []Exchange
+	Command     string
+	Description string
+	Notes       []string // 自动调整或兼容性提示
+	Steps       []Step   // 逐段解释，未请求过解释时为空
+	Why         string   // 为什么选这条命令，未请求过时为空
+	Host        string   // SSH 目标主机，本机为空
+}
+
+// Title 返回分享的标题
+func (t Transcript) Title() string {
+	return "termi: " + strings.Join(strings.Fields(t.Query), " ")
+}
+
+// Markdown 将会话记录格式化为 Markdown
+func (t Transcript) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", strings.Join(strings.Fields(t.Query), " "))
+	for _, e := range t.Exchanges {
+		fmt.Fprintf(&b, "> **%s**\n> %s\n\n", strings.TrimSpace(e.Question), strings.TrimSpace(e.Answer))
+	}
+	fence := "```"
+	for strings.Contains(t.Command, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(&b, "%sbash\n%s\n%s\n", fence, t.Command, fence)
+	if t.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", t.Description)
+	}
+	for _, n := range t.Notes {
+		fmt.Fprintf(&b, "\n- %s", n)
+	}
+	if len(t.Notes) > 0 {
+		b.WriteString("\n")
+	}
+	if len(t.Steps) > 0 {
+		b.WriteString("\n## 逐段解释\n\n")
+		for _, s := range t.Steps {
+			fmt.Fprintf(&b, "- `%s`: %s\n", s.Code, s.Note)
+		}
+	}
+	if t.Why != "" {
+		fmt.Fprintf(&b, "\n## 为什么选这条命令\n\n%s\n", t.Why)
+	}
+	fmt.Fprintf(&b, "\n---\n_%s", t.Time.Format("2006-01-02 15:04"))
+	if t.Host != "" {
+		fmt.Fprintf(&b, " · 在 %s 上执行", t.Host)
+	}
+	b.WriteString(" · 由 termi 生成_\n")
+	return b.String()
+}
+
+// Publisher 发布会话记录的粘贴服务
+type Publisher interface {
+	// Name 返回服务名称，用于提示
+	Name() string
+
+	// Publish 发布 Markdown 正文并返回分享链接
+	Publish(ctx context.Context, title, body string) (string, error)
+}
+
+// New 根据配置创建粘贴服务，未配置时返回 nil
+func New(sc *config.ShareConfig) (Publisher, error) {
+	if sc == nil {
+		return nil, nil
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	switch sc.Type {
+	case config.ShareGist:
+		return &gist{url: cmp.Or(sc.URL, defaultGistURL), headers: sc.Headers, public: sc.Public}, nil
+	default:
+		return &paste{url: sc.URL, headers: sc.Headers, field: cmp.Or(sc.URLField, "url")}, nil
+	}
+}
+
+// gist 创建 GitHub gist
+type gist struct {
+	url     string
+	headers map[string]string
+	public  bool
+}
+
+func (g *gist) Name() string { return "GitHub Gist" }
+
+func (g *gist) Publish(ctx context.Context, title, body string) (string, error) {
+	data, err := json.Marshal(map[string]any{
+		"description": title,
+		"public":      g.public,
+		"files":       map[string]any{"termi.md": map[string]string{"content": body}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("构建请求失败: %w", err)
+	}
+	resp, err := post(ctx, g.url, "application/json", g.headers, data)
+	if err != nil {
+		return "", err
+	}
+	var out struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(resp, &out); err != nil || out.HTMLURL == "" {
+		return "", fmt.Errorf("gist 响应中没有链接")
+	}
+	return out.HTMLURL, nil
+}
+
+// paste 把 Markdown 正文原样 POST 到内部粘贴服务
+type paste struct {
+	url     string
+	headers map[string]string
+	field   string
+}
+
+func (p *paste) Name() string { return "粘贴服务" }
+
+func (p *paste) Publish(ctx context.Context, _, body string) (string, error) {
+	resp, err := post(ctx, p.url, "text/markdown; charset=utf-8", p.headers, []byte(body))
+	if err != nil {
+		return "", err
+	}
+	var out map[string]any
+	if json.Unmarshal(resp, &out) == nil {
+		if link, ok := out[p.field].(string); ok && link != "" {
+			return link, nil
+		}
+		return "", fmt.Errorf("粘贴服务响应中没有 %s 字段", p.field)
+	}
+	link := strings.TrimSpace(string(resp))
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return "", fmt.Errorf("粘贴服务没有返回链接")
+	}
+	return link, nil
+}
+
+// post 发送请求并返回响应正文，URL 与请求头中的 $VAR 按环境变量展开
+func post(ctx context.Context, url, contentType string, headers map[string]string, data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, os.ExpandEnv(url), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发布失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("发布失败: HTTP %d", resp.StatusCode)
+	}
+	return body, nil
+}
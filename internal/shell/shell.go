@@ -0,0 +1,145 @@
+// Package shell 与交互式 shell 的集成：输出命令行按键绑定（widget），
+// 并通过临时文件把用户选中的命令交还给 shell 的命令行缓冲区，供编辑后由 shell 自己执行
+package shell
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// BufferEnv widget 启动 termi 时设置的环境变量，值为交还命令的临时文件路径
+const BufferEnv = "TERMI_BUFFER_FILE"
+
+// widgets 各 shell 的按键绑定脚本：Ctrl+G 把命令行上的需求交给 termi，选中的命令替换命令行内容
+var widgets = map[string]string{
+	"bash": `# termi command-line widget: eval "$(termi shell-init bash)"
+# 在命令行输入需求后按 Ctrl+G，选中的命令会替换命令行内容，按回车由当前 shell 执行
+__termi_widget() {
+  [ -z "$READLINE_LINE" ] && return
+  local __termi_out
+  __termi_out="$(mktemp "${TMPDIR:-/tmp}/termi-buffer.XXXXXX")" || return
+  TERMI_BUFFER_FILE="$__termi_out" command termi -- "$READLINE_LINE" </dev/tty >/dev/tty
+  if [ -s "$__termi_out" ]; then
+    READLINE_LINE="$(cat "$__termi_out")"
+    READLINE_POINT=${#READLINE_LINE}
+    __termi_inserted="$READLINE_LINE"
+    __termi_histnum="$(__termi_last_histnum)"
+  fi
+  rm -f "$__termi_out"
+}
+__termi_last_histnum() {
+  HISTTIMEFORMAT= history 1 | awk '{print $1}'
+}
+# 显示提示符前检查交还的命令是否原样执行、执行结果如何，报告给 termi 用于改进之后的建议
+__termi_check_edit() {
+  local __termi_status=$? __termi_ran
+  if [ -n "$__termi_inserted" ] && [ "$(__termi_last_histnum)" != "$__termi_histnum" ]; then
+    __termi_ran="$(HISTTIMEFORMAT= history 1 | sed 's/^ *[0-9]*[* ] *//')"
+    (command termi history --edited "$__termi_inserted" --exit "$__termi_status" -- "$__termi_ran" >/dev/null 2>&1 &)
+  fi
+  __termi_inserted=
+  return $__termi_status
+}
+bind -x '"\C-g": __termi_widget'
+PROMPT_COMMAND="__termi_check_edit${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+`,
+	"zsh": `# termi command-line widget: eval "$(termi shell-init zsh)"
+# 在命令行输入需求后按 Ctrl+G，选中的命令会替换命令行内容，按回车由当前 shell 执行
+__termi_widget() {
+  [[ -z "$BUFFER" ]] && return
+  local __termi_out
+  __termi_out="$(mktemp "${TMPDIR:-/tmp}/termi-buffer.XXXXXX")" || return
+  zle -I
+  TERMI_BUFFER_FILE="$__termi_out" command termi -- "$BUFFER" </dev/tty >/dev/tty
+  if [[ -s "$__termi_out" ]]; then
+    BUFFER="$(<"$__termi_out")"
+    CURSOR=${#BUFFER}
+    __termi_inserted="$BUFFER"
+  fi
+  rm -f "$__termi_out"
+  zle reset-prompt
+}
+zle -N __termi_widget
+bindkey '^G' __termi_widget
+# 执行交还的命令后把实际执行的命令与结果报告给 termi，用于改进之后的建议
+__termi_preexec() {
+  [[ -n "$__termi_inserted" ]] && __termi_ran="$1"
+}
+__termi_precmd() {
+  local __termi_status=$?
+  if [[ -n "$__termi_ran" ]]; then
+    command termi history --edited "$__termi_inserted" --exit $__termi_status -- "$__termi_ran" >/dev/null 2>&1 &!
+  fi
+  __termi_inserted= __termi_ran=
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __termi_preexec
+add-zsh-hook precmd __termi_precmd
+`,
+	"fish": `# termi command-line widget: termi shell-init fish | source
+# 在命令行输入需求后按 Ctrl+G，选中的命令会替换命令行内容，按回车由当前 shell 执行
+function __termi_widget
+    set -l query (commandline)
+    test -z "$query"; and return
+    set -l out (mktemp -t termi-buffer.XXXXXX); or return
+    env TERMI_BUFFER_FILE=$out termi -- $query </dev/tty >/dev/tty
+    if test -s $out
+        commandline -r -- (string collect <$out)
+        commandline -f end-of-line
+        set -g __termi_inserted (string collect <$out)
+    end
+    rm -f $out
+    commandline -f repaint
+end
+bind \cg __termi_widget
+# 执行交还的命令后把实际执行的命令与结果报告给 termi，用于改进之后的建议
+function __termi_postexec --on-event fish_postexec
+    set -l st $status
+    if set -q __termi_inserted
+        command termi history --edited $__termi_inserted --exit $st -- $argv[1] >/dev/null 2>&1 &
+    end
+    set -e __termi_inserted
+end
+function __termi_cancel --on-event fish_cancel
+    set -e __termi_inserted
+end
+`,
+}
+
+// Shells 返回支持的 shell 名称
+func Shells() []string {
+	names := make([]string, 0, len(widgets))
+	for name := range widgets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Widget 返回 shell 的按键绑定脚本
+func Widget(name string) (string, error) {
+	script, ok := widgets[name]
+	if !ok {
+		return "", fmt.Errorf("暂不支持的 shell: %s（支持 %s）", name, strings.Join(Shells(), "、"))
+	}
+	return script, nil
+}
+
+// Active 返回 termi 是否由 widget 启动，此时选中的命令应交还给 shell 而不是由 termi 执行
+func Active() bool {
+	return os.Getenv(BufferEnv) != ""
+}
+
+// Handoff 把命令写入 widget 提供的临时文件，widget 会用它替换命令行内容
+func Handoff(command string) error {
+	path := os.Getenv(BufferEnv)
+	if path == "" {
+		return fmt.Errorf("未通过 shell widget 启动")
+	}
+	if err := os.WriteFile(path, []byte(command), 0600); err != nil {
+		return fmt.Errorf("写入命令行缓冲区失败: %w", err)
+	}
+	return nil
+}
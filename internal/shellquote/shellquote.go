@@ -0,0 +1,131 @@
+package shellquote
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Shell 目标 shell，决定值的引用与转义规则
+type Shell int
+
+const (
+	POSIX      Shell = iota // sh、bash、zsh 等
+	Fish                    // fish 的单引号内 \ 与 ' 需要转义
+	PowerShell              // PowerShell 的单引号内 ' 需写成 ''
+	Cmd                     // Windows cmd.exe 的双引号内 " 需写成 ""
+	Nushell                 // Nushell 的单引号字符串不处理转义，含 ' 时改用原始字符串 r#'...'#
+)
+
+// String 返回 shell 名称
+func (s Shell) String() string {
+	switch s {
+	case Fish:
+		return "fish"
+	case PowerShell:
+		return "powershell"
+	case Cmd:
+		return "cmd"
+	case Nushell:
+		return "nu"
+	default:
+		return "sh"
+	}
+}
+
+// Parse 根据 shell 名称或路径（例如 /usr/bin/fish、pwsh.exe）确定引用规则，无法识别时按 POSIX 处理
+func Parse(name string) Shell {
+	base := strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".exe")
+	switch base {
+	case "fish":
+		return Fish
+	case "pwsh", "powershell":
+		return PowerShell
+	case "cmd":
+		return Cmd
+	case "nu", "nushell":
+		return Nushell
+	default:
+		return POSIX
+	}
+}
+
+// Name 返回执行命令所用的 shell 程序名（小写、不含 .exe）：优先使用配置的 shell，其次是 $SHELL，
+// 都没有时类 Unix 系统使用 bash，Windows 使用 PowerShell（Git Bash 等会设置 $SHELL）
+func Name(configured string) string {
+	name := configured
+	if name == "" {
+		name = os.Getenv("SHELL")
+	}
+	if name == "" {
+		if runtime.GOOS == "windows" {
+			return "powershell"
+		}
+		return "bash"
+	}
+	name = strings.TrimSuffix(strings.ToLower(filepath.Base(name)), ".exe")
+	if name == "nushell" {
+		return "nu"
+	}
+	return name
+}
+
+// Detect 返回当前用户所用的 shell：优先读取 $SHELL，Windows 上未设置时视为 PowerShell
+func Detect() Shell {
+	if sh := os.Getenv("SHELL"); sh != "" {
+		return Parse(sh)
+	}
+	if runtime.GOOS == "windows" {
+		return PowerShell
+	}
+	return POSIX
+}
+
+// safe 各 shell 中无需引用的值：zsh 会展开开头的 =（=ls 变为 ls 的路径），PowerShell 将 a,b 视为数组，
+// cmd.exe 把 , 与 = 当作参数分隔符，Nushell 同样不放行这两个字符
+var safe = map[Shell]*regexp.Regexp{
+	POSIX:      regexp.MustCompile(`^[A-Za-z0-9_./:+,-][A-Za-z0-9_./:=+,-]*$`),
+	Fish:       regexp.MustCompile(`^[A-Za-z0-9_./:=+,-]+$`),
+	PowerShell: regexp.MustCompile(`^[A-Za-z0-9_./:=+-]+$`),
+	Cmd:        regexp.MustCompile(`^[A-Za-z0-9_./:+-]+$`),
+	Nushell:    regexp.MustCompile(`^[A-Za-z0-9_./:+-]+$`),
+}
+
+// Quote 将任意值转义为目标 shell 中的单个参数，防止空格导致分词以及引号、$、反引号等引起注入
+func (s Shell) Quote(v string) string {
+	if re, ok := safe[s]; ok && re.MatchString(v) {
+		return v
+	}
+	switch s {
+	case Fish:
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+	case PowerShell:
+		// PowerShell 将弯引号也视为单引号，同样需要成对转义
+		return "'" + strings.NewReplacer(`'`, `''`, "‘", "‘‘", "’", "’’").Replace(v) + "'"
+	case Cmd:
+		// %VAR% 在双引号内仍会展开，无法可靠转义，但不会造成分词或注入其他命令
+		return `"` + strings.ReplaceAll(v, `"`, `""`) + `"`
+	case Nushell:
+		if !strings.Contains(v, "'") {
+			return "'" + v + "'"
+		}
+		hashes := "#"
+		for strings.Contains(v, "'"+hashes) {
+			hashes += "#"
+		}
+		return "r" + hashes + "'" + v + "'" + hashes
+	default:
+		return "'" + strings.ReplaceAll(v, `'`, `'\''`) + "'"
+	}
+}
+
+// Join 将多个值分别转义后以空格拼接
+func (s Shell) Join(values ...string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = s.Quote(v)
+	}
+	return strings.Join(quoted, " ")
+}
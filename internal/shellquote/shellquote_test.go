@@ -0,0 +1,43 @@
+package shellquote
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	inputs := []string{"a,b", "=cmd", "key=val", "a b", "it's", `say "hi"`, "$HOME"}
+	tests := []struct {
+		shell Shell
+		want  []string
+	}{
+		{POSIX, []string{"a,b", "'=cmd'", "key=val", "'a b'", `'it'\''s'`, `'say "hi"'`, "'$HOME'"}},
+		{Fish, []string{"a,b", "=cmd", "key=val", "'a b'", `'it\'s'`, `'say "hi"'`, "'$HOME'"}},
+		{PowerShell, []string{"'a,b'", "=cmd", "key=val", "'a b'", "'it''s'", `'say "hi"'`, "'$HOME'"}},
+		{Cmd, []string{`"a,b"`, `"=cmd"`, `"key=val"`, `"a b"`, `"it's"`, `"say ""hi"""`, `"$HOME"`}},
+		{Nushell, []string{"'a,b'", "'=cmd'", "'key=val'", "'a b'", "r#'it's'#", `'say "hi"'`, "'$HOME'"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.shell.String(), func(t *testing.T) {
+			for i, v := range inputs {
+				if got := tc.shell.Quote(v); got != tc.want[i] {
+					t.Errorf("Quote(%q) = %s, want %s", v, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := map[string]Shell{
+		"/bin/bash":    POSIX,
+		"/usr/bin/zsh": POSIX,
+		"fish":         Fish,
+		"pwsh.exe":     PowerShell,
+		"PowerShell":   PowerShell,
+		"cmd.exe":      Cmd,
+		"nushell":      Nushell,
+	}
+	for name, want := range tests {
+		if got := Parse(name); got != want {
+			t.Errorf("Parse(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Package sink 将用户认可的命令发送到执行之外的目的地：运行手册、Slack、工单系统等
+package sink
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/config"
+)
+
+// defaultTimeout 网络类 sink 的请求超时时间
+const defaultTimeout = 10 * time.Second
+
+// Entry 发送给 sink 的一条命令
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Query    string    `json:"query"`
+	Command  string    `json:"command"`
+	Host     string    `json:"host,omitempty"`      // SSH 目标主机，本机为空
+	ExitCode *int      `json:"exit_code,omitempty"` // 已执行时的退出码
+}
+
+// Sink 命令的输出目的地
+type Sink interface {
+	// Name 返回配置中声明的名称
+	Name() string
+
+	// Send 发送一条命令
+	Send(ctx context.Context, e Entry) error
+}
+
+// New 根据配置创建 sink
+func New(sc config.SinkConfig) (Sink, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	switch sc.Type {
+	case config.SinkRunbook:
+		return &runbook{name: sc.Name, path: expandHome(sc.Path)}, nil
+	case config.SinkSlack:
+		return &webhook{name: sc.Name, url: sc.URL, method: http.MethodPost, body: slackBody}, nil
+	case config.SinkWebhook:
+		return &webhook{
+			name:    sc.Name,
+			url:     sc.URL,
+			method:  cmp.Or(strings.ToUpper(sc.Method), http.MethodPost),
+			headers: sc.Headers,
+			body:    json.Marshal,
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的 sink 类型: %s", sc.Type)
+	}
+}
+
+// Load 根据配置创建全部 sink
+func Load(configs []config.SinkConfig) ([]Sink, error) {
+	out := make([]Sink, 0, len(configs))
+	for _, sc := range configs {
+		s, err := New(sc)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// runbook 以 Markdown 形式追加到运行手册文件
+type runbook struct {
+	name string
+	path string
+}
+
+func (r *runbook) Name() string { return r.name }
+
+func (r *runbook) Send(_ context.Context, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("创建运行手册目录失败: %w", err)
+	}
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开运行手册失败: %w", err)
+	}
+	defer f.Close()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n## %s\n\n", e.Query)
+	fmt.Fprintf(&b, "_%s", e.Time.Format("2006-01-02 15:04"))
+	if e.Host != "" {
+		fmt.Fprintf(&b, " · %s", e.Host)
+	}
+	b.WriteString("_\n\n```bash\n" + e.Command + "\n```\n")
+	if _, err := f.WriteString(b.String()); err != nil {
+		return fmt.Errorf("写入运行手册失败: %w", err)
+	}
+	return nil
+}
+
+// webhook 通过 HTTP 请求发送，Slack 与通用 REST 接口仅请求体不同
+type webhook struct {
+	name    string
+	url     string
+	method  string
+	headers map[string]string
+	body    func(any) ([]byte, error)
+}
+
+func (w *webhook) Name() string { return w.name }
+
+func (w *webhook) Send(ctx context.Context, e Entry) error {
+	data, err := w.body(e)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, w.method, os.ExpandEnv(w.url), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送到 %s 失败: %w", w.name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("发送到 %s 失败: HTTP %d", w.name, resp.StatusCode)
+	}
+	return nil
+}
+
+// slackBody 构建 Slack incoming webhook 的消息体
+func slackBody(v any) ([]byte, error) {
+	e := v.(Entry)
+	text := fmt.Sprintf("*%s*\n```%s```", e.Query, e.Command)
+	if e.Host != "" {
+		text += "\n主机: " + e.Host
+	}
+	return json.Marshal(map[string]string{"text": text})
+}
+
+// expandHome 展开路径开头的 ~/
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
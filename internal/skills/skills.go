@@ -0,0 +1,303 @@
+package skills
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// maxMatched 单次请求最多注入的技能包数量
+const maxMatched = 2
+
+//go:embed builtin/*.md
+var builtinFS embed.FS
+
+// Skill 技能包：匹配领域查询时附加到提示词中的约束与示例
+type Skill struct {
+	Name        string
+	Description string
+	Keywords    []string
+	Body        string
+	Enabled     bool
+}
+
+// Store 管理安装在本地目录中的技能包
+type Store struct {
+	dir string
+}
+
+// state 技能包启用状态，默认安装即启用
+type state struct {
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+// NewStore 创建技能包存储
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Builtin 返回内置可安装的技能包名称
+func Builtin() []string {
+	entries, _ := builtinFS.ReadDir("builtin")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".md"))
+	}
+	return names
+}
+
+// List 返回已安装的技能包
+func (s *Store) List() ([]Skill, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取技能包目录失败: %w", err)
+	}
+
+	st, err := s.loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Skill
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取技能包失败: %w", err)
+		}
+		sk, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("技能包 %s 格式错误: %w", e.Name(), err)
+		}
+		sk.Enabled = !slices.Contains(st.Disabled, sk.Name)
+		list = append(list, *sk)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Install 安装技能包，src 可以是内置技能包名称、本地文件路径或 http(s) URL
+func (s *Store) Install(src string) (*Skill, error) {
+	data, err := readSource(src)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("技能包格式错误: %w", err)
+	}
+	if err := s.Add(data); err != nil {
+		return nil, err
+	}
+	sk.Enabled = true
+	return sk, nil
+}
+
+// Add 安装并启用已读取的技能包内容，同名技能包会被覆盖
+func (s *Store) Add(data []byte) error {
+	sk, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("技能包格式错误: %w", err)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建技能包目录失败: %w", err)
+	}
+	if err := filelock.WriteFile(filepath.Join(s.dir, sk.Name+".md"), data, 0644); err != nil {
+		return fmt.Errorf("写入技能包失败: %w", err)
+	}
+	return s.SetEnabled(sk.Name, true)
+}
+
+// SetEnabled 启用或禁用已安装的技能包
+func (s *Store) SetEnabled(name string, enabled bool) error {
+	if _, err := os.Stat(filepath.Join(s.dir, name+".md")); err != nil {
+		return fmt.Errorf("技能包 %s 未安装", name)
+	}
+
+	// 在文件锁内读取最新状态再修改，其他 termi 进程同时启用或禁用的技能包不会被覆盖
+	path := filepath.Join(s.dir, "state.json")
+	return filelock.Update(path, 0644, func([]byte) ([]byte, error) {
+		st, err := s.loadState()
+		if err != nil {
+			return nil, err
+		}
+		st.Disabled = slices.DeleteFunc(st.Disabled, func(n string) bool { return n == name })
+		if !enabled {
+			st.Disabled = append(st.Disabled, name)
+		}
+		return json.MarshalIndent(st, "", "  ")
+	})
+}
+
+// Match 返回与查询匹配的已启用技能包，按命中关键词数量排序
+func (s *Store) Match(query string) ([]Skill, error) {
+	if s == nil {
+		return nil, nil
+	}
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	q := strings.ToLower(query)
+	type scored struct {
+		skill Skill
+		hits  int
+	}
+	var matched []scored
+	for _, sk := range list {
+		if !sk.Enabled {
+			continue
+		}
+		hits := 0
+		for _, kw := range sk.Keywords {
+			if strings.Contains(q, strings.ToLower(kw)) {
+				hits++
+			}
+		}
+		if hits > 0 {
+			matched = append(matched, scored{sk, hits})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].hits > matched[j].hits })
+
+	var out []Skill
+	for i := 0; i < len(matched) && i < maxMatched; i++ {
+		out = append(out, matched[i].skill)
+	}
+	return out, nil
+}
+
+// Named 按 names 的顺序返回其中已安装且已启用的技能包，未安装的名称被忽略
+func (s *Store) Named(names []string) ([]Skill, error) {
+	if s == nil || len(names) == 0 {
+		return nil, nil
+	}
+	list, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var out []Skill
+	for _, name := range names {
+		i := slices.IndexFunc(list, func(sk Skill) bool { return sk.Name == name })
+		if i >= 0 && list[i].Enabled {
+			out = append(out, list[i])
+		}
+	}
+	return out, nil
+}
+
+// Render 将技能包格式化为附加到提示词中的文本
+func Render(list []Skill) string {
+	var b strings.Builder
+	for _, sk := range list {
+		fmt.Fprintf(&b, "[技能: %s]\n%s\n", sk.Name, strings.TrimSpace(sk.Body))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Parse 解析带 front matter 的 Markdown 技能包
+//
+//	---
+//	name: git
+//	description: Git 常用操作
+//	keywords: git, 分支, rebase
+//	---
+//	约束与示例正文
+func Parse(data []byte) (*Skill, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		return nil, fmt.Errorf("缺少 front matter")
+	}
+	header, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return nil, fmt.Errorf("front matter 未结束")
+	}
+
+	sk := &Skill{Body: strings.TrimSpace(body)}
+	for _, line := range strings.Split(header, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "name":
+			sk.Name = value
+		case "description":
+			sk.Description = value
+		case "keywords":
+			for _, kw := range strings.Split(value, ",") {
+				if kw = strings.TrimSpace(kw); kw != "" {
+					sk.Keywords = append(sk.Keywords, kw)
+				}
+			}
+		}
+	}
+
+	if sk.Name == "" || strings.ContainsAny(sk.Name, `/\. `) {
+		return nil, fmt.Errorf("name 为空或包含非法字符")
+	}
+	if len(sk.Keywords) == 0 {
+		return nil, fmt.Errorf("keywords 不能为空")
+	}
+	return sk, nil
+}
+
+func (s *Store) loadState() (*state, error) {
+	var st state
+	data, err := os.ReadFile(filepath.Join(s.dir, "state.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return &st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取技能包状态失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("解析技能包状态失败: %w", err)
+	}
+	return &st, nil
+}
+
+// readSource 读取技能包内容
+func readSource(src string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(src)
+		if err != nil {
+			return nil, fmt.Errorf("下载技能包失败: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("下载技能包失败: HTTP %d", resp.StatusCode)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	case slices.Contains(Builtin(), src):
+		return builtinFS.ReadFile(path.Join("builtin", src+".md"))
+	default:
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return nil, fmt.Errorf("读取技能包失败: %w", err)
+		}
+		return data, nil
+	}
+}
@@ -0,0 +1,235 @@
+// Package stats 按天、提供商与模型累计每次请求的 token 用量，并按价格表估算费用，
+// 供团队共用付费 API 时了解用量。只记录 token 数，不记录请求内容
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/filelock"
+)
+
+// dayLayout 统计按本地日期归档
+const dayLayout = "2006-01-02"
+
+// Row 某一天某个提供商与模型的累计用量
+type Row struct {
+	Day              string `json:"day"`
+	Provider         string `json:"provider"`
+	Model            string `json:"model"`
+	Requests         int    `json:"requests"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// Store 用量统计的磁盘存储
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open 打开用量统计文件，文件在第一次记录时创建
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// Load 读取全部统计，文件不存在时返回空列表
+func (s *Store) Load() ([]Row, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *Store) load() ([]Row, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rows []Row
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// Record 把一次请求的用量累加到当天对应提供商与模型的记录中。每次都在文件锁内重新读取文件，
+// 同时运行的多个 termi 进程不会互相覆盖彼此的记录
+func (s *Store) Record(provider, model string, prompt, completion int) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := time.Now().Format(dayLayout)
+	return filelock.Update(s.path, 0600, func(data []byte) ([]byte, error) {
+		var rows []Row
+		if err := json.Unmarshal(data, &rows); err != nil {
+			// 损坏的文件无法累加，从头开始记录
+			rows = nil
+		}
+		i := indexOf(rows, day, provider, model)
+		if i < 0 {
+			rows = append(rows, Row{Day: day, Provider: provider, Model: model})
+			i = len(rows) - 1
+		}
+		rows[i].Requests++
+		rows[i].PromptTokens += prompt
+		rows[i].CompletionTokens += completion
+		return json.Marshal(rows)
+	})
+}
+
+func indexOf(rows []Row, day, provider, model string) int {
+	for i, r := range rows {
+		if r.Day == day && r.Provider == provider && r.Model == model {
+			return i
+		}
+	}
+	return -1
+}
+
+// Usage 一段时间内某个提供商与模型（或某一天）的用量汇总
+type Usage struct {
+	Day              string  `json:"day,omitempty"` // 按天汇总时的日期
+	Provider         string  `json:"provider,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`   // 估算费用（美元）
+	Priced           bool    `json:"priced"` // 是否找到了模型价格，为 false 时 Cost 为 0
+}
+
+// Tokens 返回输入与输出 token 的总数
+func (u Usage) Tokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Since 返回最近 days 天（含今天）的起始日期
+func Since(days int) string {
+	return time.Now().AddDate(0, 0, 1-days).Format(dayLayout)
+}
+
+// Summarize 按提供商与模型汇总 since 当天及之后的用量并估算费用，按费用、token 数从多到少排列
+func Summarize(rows []Row, since string, pricing map[string]config.Price) []Usage {
+	index := map[[2]string]int{}
+	var out []Usage
+	for _, r := range rows {
+		if r.Day < since {
+			continue
+		}
+		key := [2]string{r.Provider, r.Model}
+		i, ok := index[key]
+		if !ok {
+			out = append(out, Usage{Provider: r.Provider, Model: r.Model})
+			i = len(out) - 1
+			index[key] = i
+		}
+		out[i].Requests += r.Requests
+		out[i].PromptTokens += r.PromptTokens
+		out[i].CompletionTokens += r.CompletionTokens
+	}
+	for i := range out {
+		out[i].Cost, out[i].Priced = Cost(out[i].Provider, out[i].Model, out[i].PromptTokens, out[i].CompletionTokens, pricing)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Cost != out[j].Cost {
+			return out[i].Cost > out[j].Cost
+		}
+		return out[i].Tokens() > out[j].Tokens()
+	})
+	return out
+}
+
+// Daily 按天汇总 since 当天及之后的用量，按日期排列
+func Daily(rows []Row, since string, pricing map[string]config.Price) []Usage {
+	byDay := map[string]*Usage{}
+	var days []string
+	for _, r := range rows {
+		if r.Day < since {
+			continue
+		}
+		u, ok := byDay[r.Day]
+		if !ok {
+			u = &Usage{Day: r.Day, Priced: true}
+			byDay[r.Day] = u
+			days = append(days, r.Day)
+		}
+		cost, priced := Cost(r.Provider, r.Model, r.PromptTokens, r.CompletionTokens, pricing)
+		u.Requests += r.Requests
+		u.PromptTokens += r.PromptTokens
+		u.CompletionTokens += r.CompletionTokens
+		u.Cost += cost
+		u.Priced = u.Priced && priced
+	}
+	sort.Strings(days)
+	out := make([]Usage, 0, len(days))
+	for _, d := range days {
+		out = append(out, *byDay[d])
+	}
+	return out
+}
+
+// defaultPricing 常用模型的公开标价（美元 / 百万 token），价格调整后可在配置的
+// stats.pricing 中覆盖
+var defaultPricing = map[string]config.Price{
+	"gpt-4.1":           {Input: 2, Output: 8},
+	"gpt-4.1-mini":      {Input: 0.4, Output: 1.6},
+	"gpt-4.1-nano":      {Input: 0.1, Output: 0.4},
+	"gpt-4o":            {Input: 2.5, Output: 10},
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.6},
+	"gpt-3.5-turbo":     {Input: 0.5, Output: 1.5},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4},
+	"claude-3-5-sonnet": {Input: 3, Output: 15},
+	"gemini-1.5-flash":  {Input: 0.075, Output: 0.3},
+	"gemini-1.5-pro":    {Input: 1.25, Output: 5},
+	"gemini-2.0-flash":  {Input: 0.1, Output: 0.4},
+}
+
+// localProviders 在本机运行的提供商不产生费用
+var localProviders = map[string]bool{"Ollama": true, "Llama-cpp": true}
+
+// Cost 估算用量的费用（美元）。模型名按最长前缀匹配价格表，例如 gpt-4o-mini-2024-07-18
+// 使用 gpt-4o-mini 的价格；配置的价格优先于内置价格。找不到价格时 priced 为 false
+func Cost(provider, model string, prompt, completion int, pricing map[string]config.Price) (cost float64, priced bool) {
+	if localProviders[provider] {
+		return 0, true
+	}
+	p, ok := lookup(model, pricing)
+	if !ok {
+		p, ok = lookup(model, defaultPricing)
+	}
+	if !ok {
+		return 0, false
+	}
+	return (float64(prompt)*p.Input + float64(completion)*p.Output) / 1e6, true
+}
+
+// lookup 返回与模型名最长前缀匹配的价格
+func lookup(model string, pricing map[string]config.Price) (config.Price, bool) {
+	var best string
+	for name := range pricing {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return config.Price{}, false
+	}
+	return pricing[best], true
+}
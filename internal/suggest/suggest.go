@@ -1,7 +1,131 @@
 package suggest
 
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Risk 候选命令的风险等级
+type Risk string
+
+const (
+	RiskLow    Risk = "low"    // 只读或可轻易撤销
+	RiskMedium Risk = "medium" // 修改文件或配置
+	RiskHigh   Risk = "high"   // 删除数据、影响系统或难以撤销
+)
+
 // Suggestion 表示一条候选命令
 type Suggestion struct {
-	Text   string // 真实命令
-	Source string // 例如 llm
+	Text        string   // 真实命令
+	Sources     []string // 来源，例如 llm、history，合并后可能有多个
+	Group       string   // 实现方式分组，例如 "使用 find"
+	Notes       []string // 自动调整或兼容性提示，例如 GNU/BSD 写法转换
+	Description string   // 命令作用的一句话说明
+	Risk        Risk     // 风险等级，未知时为空
+}
+
+// simpleQuoted 匹配不含特殊字符、加不加引号含义都相同的参数
+var simpleQuoted = regexp.MustCompile(`^(['"])([A-Za-z0-9_./:@%+,=-]+)['"]$`)
+
+// Canonical 返回命令的规范形式，用于判断不同来源的命令是否实质相同：
+// 折叠空白、去掉结尾分号、去掉多余的简单引号
+func Canonical(command string) string {
+	fields := strings.Fields(strings.TrimRight(strings.TrimSpace(command), "; "))
+	for i, f := range fields {
+		if m := simpleQuoted.FindStringSubmatch(f); m != nil && m[1] == f[len(f)-1:] {
+			fields[i] = m[2]
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// Merge 合并规范形式相同的候选，保留第一次出现的命令文本并汇总所有来源
+func Merge(list []Suggestion) []Suggestion {
+	index := map[string]int{}
+	var out []Suggestion
+	for _, s := range list {
+		key := Canonical(s.Text)
+		if key == "" {
+			continue
+		}
+		if i, ok := index[key]; ok {
+			for _, src := range s.Sources {
+				if !slices.Contains(out[i].Sources, src) {
+					out[i].Sources = append(out[i].Sources, src)
+				}
+			}
+			if out[i].Group == "" {
+				out[i].Group = s.Group
+			}
+			if len(out[i].Notes) == 0 {
+				out[i].Notes = s.Notes
+			}
+			if out[i].Description == "" {
+				out[i].Description = s.Description
+			}
+			if out[i].Risk == "" {
+				out[i].Risk = s.Risk
+			}
+			continue
+		}
+		index[key] = len(out)
+		s.Sources = slices.Clone(s.Sources)
+		out = append(out, s)
+	}
+	return out
+}
+
+// wrappers 不代表实现方式的前缀命令，推断分组时跳过
+var wrappers = map[string]bool{
+	"sudo":    true,
+	"env":     true,
+	"time":    true,
+	"nohup":   true,
+	"nice":    true,
+	"command": true,
+	"exec":    true,
+}
+
+// Approach 根据命令的主程序推断实现方式，例如 "find . -name x" → "使用 find"
+func Approach(command string) string {
+	for _, field := range strings.Fields(command) {
+		if strings.HasPrefix(field, "-") || strings.Contains(field, "=") || wrappers[field] {
+			continue
+		}
+		return "使用 " + filepath.Base(field)
+	}
+	return ""
+}
+
+// GroupByApproach 为缺少分组的候选推断分组，并按分组首次出现的顺序稳定重排，
+// 使同一实现方式的候选在列表中相邻
+func GroupByApproach(list []Suggestion) []Suggestion {
+	var order []string
+	groups := map[string][]Suggestion{}
+	for _, s := range list {
+		if s.Group == "" {
+			s.Group = Approach(s.Text)
+		}
+		if _, ok := groups[s.Group]; !ok {
+			order = append(order, s.Group)
+		}
+		groups[s.Group] = append(groups[s.Group], s)
+	}
+
+	out := make([]Suggestion, 0, len(list))
+	for _, g := range order {
+		out = append(out, groups[g]...)
+	}
+	return out
+}
+
+// GroupCount 返回候选中不同分组的数量
+func GroupCount(list []Suggestion) int {
+	seen := map[string]bool{}
+	for _, s := range list {
+		seen[s.Group] = true
+	}
+	return len(seen)
 }
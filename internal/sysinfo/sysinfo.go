@@ -0,0 +1,134 @@
+package sysinfo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeout 采集整份快照的超时时间
+const timeout = 3 * time.Second
+
+// topN 每类进程排行保留的条数
+const topN = 5
+
+// keywords 性能类查询的中文关键词，命中时才采集快照
+var keywords = []string{"慢", "卡顿", "很卡", "性能", "负载", "cpu", "内存", "占用", "磁盘满", "磁盘空间", "swap", "oom"}
+
+// englishKeywords 英文关键词按单词匹配，避免 download、flag 之类误命中
+var englishKeywords = regexp.MustCompile(`(?i)\b(slow\w*|lag\w*|performance|load average|high load|memory|ram|disk (?:full|space)|hog\w*|freez\w*)\b`)
+
+// Relevant 判断查询是否与本机性能相关
+func Relevant(query string) bool {
+	q := strings.ToLower(query)
+	for _, k := range keywords {
+		if strings.Contains(q, k) {
+			return true
+		}
+	}
+	return englishKeywords.MatchString(query)
+}
+
+// Snapshot 采集负载、内存、CPU/内存占用最高的进程以及磁盘使用率的简要快照。
+// 只读取 /proc 并运行 ps、df 等只读命令，某一项失败时跳过该项；不支持的平台返回空字符串
+func Snapshot(ctx context.Context) string {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var sections []string
+	add := func(title, body string) {
+		if body = strings.TrimSpace(body); body != "" {
+			sections = append(sections, title+":\n"+body)
+		}
+	}
+
+	add("负载", loadAverage(ctx))
+	add("内存", memory(ctx))
+	if runtime.GOOS == "linux" {
+		add("CPU 占用最高的进程", head(run(ctx, "ps", "-eo", "pid,pcpu,pmem,comm", "--sort=-pcpu"), topN+1))
+		add("内存占用最高的进程", head(run(ctx, "ps", "-eo", "pid,pcpu,pmem,comm", "--sort=-pmem"), topN+1))
+		add("磁盘", head(run(ctx, "df", "-hP", "-x", "tmpfs", "-x", "devtmpfs", "-x", "squashfs", "-x", "overlay"), 8))
+	} else {
+		add("CPU 占用最高的进程", head(run(ctx, "ps", "-Aro", "pid,pcpu,pmem,comm"), topN+1))
+		add("内存占用最高的进程", head(run(ctx, "ps", "-Amo", "pid,pcpu,pmem,comm"), topN+1))
+		add("磁盘", head(run(ctx, "df", "-h", "-l"), 8))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// loadAverage 返回 1/5/15 分钟平均负载及 CPU 核数
+func loadAverage(ctx context.Context) string {
+	var load string
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		if f := strings.Fields(string(data)); len(f) >= 3 {
+			load = strings.Join(f[:3], " ")
+		}
+	} else {
+		// macOS: "{ 1.23 1.45 1.67 }"
+		load = strings.Trim(strings.TrimSpace(run(ctx, "sysctl", "-n", "vm.loadavg")), "{} ")
+	}
+	if load == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s（%d 核）", load, runtime.NumCPU())
+}
+
+// memory 返回内存与 swap 的使用概况
+func memory(ctx context.Context) string {
+	if runtime.GOOS == "darwin" {
+		total, _ := strconv.ParseInt(strings.TrimSpace(run(ctx, "sysctl", "-n", "hw.memsize")), 10, 64)
+		swap := strings.TrimSpace(run(ctx, "sysctl", "-n", "vm.swapusage"))
+		if total == 0 {
+			return swap
+		}
+		return fmt.Sprintf("总计 %d MiB\nswap %s", total>>20, swap)
+	}
+
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return ""
+	}
+	kb := map[string]int64{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) >= 2 {
+			v, _ := strconv.ParseInt(f[1], 10, 64)
+			kb[strings.TrimSuffix(f[0], ":")] = v
+		}
+	}
+	if kb["MemTotal"] == 0 {
+		return ""
+	}
+	return fmt.Sprintf("总计 %d MiB，可用 %d MiB\nswap 总计 %d MiB，已用 %d MiB",
+		kb["MemTotal"]>>10, kb["MemAvailable"]>>10, kb["SwapTotal"]>>10, (kb["SwapTotal"]-kb["SwapFree"])>>10)
+}
+
+// run 执行只读命令并返回标准输出，失败时返回空字符串
+func run(ctx context.Context, name string, args ...string) string {
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// head 保留前 n 行
+func head(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,208 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// exportTimeout 单次导出的超时时间，避免收集端不可用时拖慢退出
+const exportTimeout = 5 * time.Second
+
+// exporter 以 OTLP/HTTP JSON 编码导出 span
+type exporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newExporter(endpoint string, headers map[string]string) *exporter {
+	return &exporter{
+		url:     strings.TrimRight(endpoint, "/") + "/v1/traces",
+		headers: headers,
+		client:  &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// ExportSpans 将一批 span 发送到收集端
+func (e *exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(encode(spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("导出追踪数据失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("导出追踪数据失败: %s %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Shutdown 导出器不持有需要释放的资源
+func (e *exporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// 以下类型对应 OTLP 的 JSON 编码（opentelemetry-proto trace/v1），只包含 termi 用到的字段
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// encode 按 resource 与 scope 分组编码 span
+func encode(spans []sdktrace.ReadOnlySpan) otlpRequest {
+	var req otlpRequest
+	index := map[string]int{}
+	for _, s := range spans {
+		key := s.Resource().Encoded(attribute.DefaultEncoder()) + "\x00" + s.InstrumentationScope().Name
+		i, ok := index[key]
+		if !ok {
+			i = len(req.ResourceSpans)
+			index[key] = i
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource: otlpResource{Attributes: encodeAttrs(s.Resource().Attributes())},
+				ScopeSpans: []otlpScopeSpans{{
+					Scope: otlpScope{Name: s.InstrumentationScope().Name, Version: s.InstrumentationScope().Version},
+				}},
+			})
+		}
+		ss := &req.ResourceSpans[i].ScopeSpans[0]
+		ss.Spans = append(ss.Spans, encodeSpan(s))
+	}
+	return req
+}
+
+func encodeSpan(s sdktrace.ReadOnlySpan) otlpSpan {
+	out := otlpSpan{
+		TraceID:           s.SpanContext().TraceID().String(),
+		SpanID:            s.SpanContext().SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()),
+		StartTimeUnixNano: unixNano(s.StartTime()),
+		EndTimeUnixNano:   unixNano(s.EndTime()),
+		Attributes:        encodeAttrs(s.Attributes()),
+		Status:            encodeStatus(s.Status()),
+	}
+	if s.Parent().IsValid() {
+		out.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, ev := range s.Events() {
+		out.Events = append(out.Events, otlpEvent{
+			TimeUnixNano: unixNano(ev.Time),
+			Name:         ev.Name,
+			Attributes:   encodeAttrs(ev.Attributes),
+		})
+	}
+	return out
+}
+
+// encodeStatus 转换状态码：SDK 中 Error=1、Ok=2，OTLP 中 OK=1、ERROR=2
+func encodeStatus(st sdktrace.Status) otlpStatus {
+	switch st.Code {
+	case codes.Error:
+		return otlpStatus{Code: 2, Message: st.Description}
+	case codes.Ok:
+		return otlpStatus{Code: 1}
+	default:
+		return otlpStatus{}
+	}
+}
+
+func encodeAttrs(attrs []attribute.KeyValue) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, kv := range attrs {
+		var v map[string]any
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			v = map[string]any{"boolValue": kv.Value.AsBool()}
+		case attribute.INT64:
+			// OTLP JSON 将 64 位整数编码为字符串
+			v = map[string]any{"intValue": strconv.FormatInt(kv.Value.AsInt64(), 10)}
+		case attribute.FLOAT64:
+			v = map[string]any{"doubleValue": kv.Value.AsFloat64()}
+		default:
+			v = map[string]any{"stringValue": kv.Value.Emit()}
+		}
+		out = append(out, otlpKeyValue{Key: string(kv.Key), Value: v})
+	}
+	return out
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
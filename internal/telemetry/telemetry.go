@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"termi.sh/termi/internal/config"
+)
+
+// scope 上报 span 时使用的 instrumentation scope 名称
+const scope = "termi.sh/termi"
+
+// Setup 按配置注册全局 tracer provider，未配置端点时不做任何事（span 为空实现，几乎无开销）。
+// 返回的 shutdown 会在退出前导出尚未发送的 span
+func Setup(cfg *config.TelemetryConfig) (shutdown func(context.Context) error, err error) {
+	endpoint := cfg.OTLPEndpoint()
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	if _, err := url.ParseRequestURI(endpoint); err != nil {
+		return nil, fmt.Errorf("无效的 OTLP 端点 %q: %w", endpoint, err)
+	}
+
+	headers := make(map[string]string, len(cfg.Headers))
+	for k, v := range cfg.Headers {
+		headers[k] = os.ExpandEnv(v)
+	}
+
+	name := cfg.ServiceName
+	if name == "" {
+		name = "termi"
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newExporter(endpoint, headers)),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", name))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Start 开始一个 span，调用方负责以 End 结束
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(scope).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End 结束 span，err 不为空时记录错误并将状态置为失败
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, strings.TrimSpace(err.Error()))
+	}
+	span.End()
+}
@@ -0,0 +1,139 @@
+// Package trust 记录用户对工作目录的信任决定。只有受信任目录中的项目文件
+// （package.json scripts、Makefile、README 等）才会被读取并注入提示词，
+// 避免克隆下来的恶意仓库借项目文件做提示词注入或外泄数据
+package trust
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"termi.sh/termi/internal/filelock"
+)
+
+// Entry 单个目录的信任决定，对其所有子目录同样生效
+type Entry struct {
+	Dir       string    `json:"dir"`
+	Trusted   bool      `json:"trusted"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Store 信任决定的磁盘存储，文件不存在或损坏时视为尚未做出任何决定
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]Entry
+}
+
+// Open 打开信任记录文件
+func Open(path string) *Store {
+	s := &Store{path: path, entries: map[string]Entry{}}
+	if data, err := os.ReadFile(path); err == nil {
+		var list []Entry
+		if json.Unmarshal(data, &list) == nil {
+			for _, e := range list {
+				s.entries[e.Dir] = e
+			}
+		}
+	}
+	return s
+}
+
+// Lookup 查找 dir 或其最近的上级目录上的信任决定，decided 为 false 表示用户尚未做出选择。
+// nil Store 视为全部受信任，供禁用信任检查时使用
+func (s *Store) Lookup(dir string) (trusted, decided bool) {
+	if s == nil {
+		return true, true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for d := normalize(dir); ; {
+		if e, ok := s.entries[d]; ok {
+			return e.Trusted, true
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return false, false
+		}
+		d = parent
+	}
+}
+
+// Trusted 报告 dir 是否受信任，未做出决定的目录视为不受信任
+func (s *Store) Trusted(dir string) bool {
+	trusted, _ := s.Lookup(dir)
+	return trusted
+}
+
+// Set 记录对 dir 的信任决定并立即写回磁盘
+func (s *Store) Set(dir string, trusted bool) error {
+	if s == nil {
+		return nil
+	}
+	dir = normalize(dir)
+	return s.update(func() {
+		s.entries[dir] = Entry{Dir: dir, Trusted: trusted, DecidedAt: time.Now()}
+	})
+}
+
+// Remove 撤销对 dir 的决定，之后会重新询问
+func (s *Store) Remove(dir string) error {
+	if s == nil {
+		return nil
+	}
+	dir = normalize(dir)
+	return s.update(func() {
+		delete(s.entries, dir)
+	})
+}
+
+// List 按目录排序返回全部决定
+func (s *Store) List() []Entry {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+func (s *Store) list() []Entry {
+	list := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Dir < list[j].Dir })
+	return list
+}
+
+// update 在文件锁内重新读取磁盘上的决定，应用修改后写回，
+// 同时运行的其他 termi 进程做出的决定不会被覆盖
+func (s *Store) update(change func()) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filelock.Update(s.path, 0600, func(data []byte) ([]byte, error) {
+		var list []Entry
+		if json.Unmarshal(data, &list) == nil {
+			s.entries = make(map[string]Entry, len(list))
+			for _, e := range list {
+				s.entries[e.Dir] = e
+			}
+		}
+		change()
+		return json.MarshalIndent(s.list(), "", "  ")
+	})
+}
+
+// normalize 转为解析过符号链接的绝对路径，使同一目录的不同写法对应同一条记录
+func normalize(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = real
+	}
+	return filepath.Clean(dir)
+}
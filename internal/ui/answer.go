@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	// inlineCode matches `code` spans in the model's answer
+	inlineCode = regexp.MustCompile("`([^`]+)`")
+	// strong matches **bold** spans in the model's answer
+	strong = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	// bullet matches list items, "- ", "* " or "1. "
+	bullet = regexp.MustCompile(`^(\s*)(?:[-*+]|(\d+)\.)\s+`)
+)
+
+// transitionToAnswer shows the model's answer to an informational question; there is
+// nothing to run, so the view only offers copying it or quitting
+func (m *AppModel) transitionToAnswer(answer string) *AppModel {
+	m.answer = answer
+	m.state = StateAnswer
+	return m
+}
+
+func (m *AppModel) handleAnswerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "c":
+		m.answerCopyErr = m.clipboard.Copy(m.answer + "\n")
+		if m.answerCopyErr == nil {
+			m.answerCopied = true
+			return m, tea.Quit
+		}
+	case "enter", "q", "esc", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *AppModel) renderAnswerView() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("💬 回答:") + "\n\n")
+	s.WriteString(lipgloss.NewStyle().Width(m.termWidth()-2).Render(renderAnswer(m.answer)) + "\n")
+	if m.slowQuery != nil {
+		s.WriteString(faintStyle().Render("\n🐢 "+m.slowNote()) + "\n")
+	}
+	if m.answerCopyErr != nil {
+		s.WriteString(m.errorStyle.Render("\n复制失败: "+m.answerCopyErr.Error()) + "\n")
+	}
+	s.WriteString(faintStyle().Render("\nc: 复制回答, Enter/q/Esc: 退出, ?: 帮助"))
+	return s.String()
+}
+
+// renderAnswer formats the light Markdown models tend to use: headings and **bold** become
+// bold, list markers become bullets, and `code` spans are highlighted. Fenced code blocks
+// are shown indented, without the fences
+func renderAnswer(text string) string {
+	bold := lipgloss.NewStyle().Bold(true)
+	code := lipgloss.NewStyle().Foreground(theme.code)
+
+	var out []string
+	fenced := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			out = append(out, "    "+code.Render(line))
+			continue
+		}
+		if heading := strings.TrimLeft(line, "#"); heading != line && strings.HasPrefix(heading, " ") {
+			out = append(out, bold.Render(strings.TrimSpace(heading)))
+			continue
+		}
+		if m := bullet.FindStringSubmatch(line); m != nil {
+			marker := "•"
+			if m[2] != "" {
+				marker = m[2] + "."
+			}
+			line = fmt.Sprintf("%s  %s %s", m[1], marker, line[len(m[0]):])
+		}
+		line = strong.ReplaceAllStringFunc(line, func(s string) string {
+			return bold.Render(strong.FindStringSubmatch(s)[1])
+		})
+		line = inlineCode.ReplaceAllStringFunc(line, func(s string) string {
+			return code.Render(inlineCode.FindStringSubmatch(s)[1])
+		})
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
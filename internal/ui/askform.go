@@ -0,0 +1,273 @@
+package ui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/llm"
+)
+
+// maxFormFields bounds the form so a confused model can't produce a wall of inputs
+const maxFormFields = 6
+
+// formFieldWidth is the visible width of a field; textinput shows only the first rune of the
+// placeholder when no width is set
+const formFieldWidth = 40
+
+// maxPathSuggestions bounds the directory entries offered for path completion
+const maxPathSuggestions = 200
+
+// fieldPlaceholders hint at the expected value of each field type
+var fieldPlaceholders = map[string]string{
+	"text":   "",
+	"number": "数字",
+	"port":   "1-65535",
+	"path":   "Tab 补全路径",
+	"host":   "主机名或 IP 地址",
+}
+
+// hostnameRe matches DNS names such as web-1.example.com
+var hostnameRe = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*\.?$`)
+
+// formField is one typed input of a clarifying question
+type formField struct {
+	llm.Field
+	input textinput.Model
+	err   string
+}
+
+// newAskForm builds inputs for the values the model marked as structured. It returns nil
+// when the question should be answered as free text.
+func newAskForm(fields []llm.Field) []formField {
+	var out []formField
+	seen := map[string]bool{}
+	for _, f := range fields {
+		f.Name = strings.TrimSpace(f.Name)
+		if f.Name == "" || seen[f.Name] {
+			continue
+		}
+		seen[f.Name] = true
+		f.Type = strings.ToLower(strings.TrimSpace(f.Type))
+		if _, ok := fieldPlaceholders[f.Type]; !ok {
+			f.Type = "text"
+		}
+		f.Label = cmp.Or(strings.TrimSpace(f.Label), f.Name)
+
+		in := textinput.New()
+		in.Prompt = ""
+		in.Placeholder = fieldPlaceholders[f.Type]
+		in.Width = formFieldWidth
+		in.ShowSuggestions = f.Type == "path"
+		out = append(out, formField{Field: f, input: in})
+		if len(out) == maxFormFields {
+			break
+		}
+	}
+	return out
+}
+
+// validateField checks a value against the field's type
+func validateField(f llm.Field, v string) error {
+	if v == "" {
+		return errors.New("不能为空")
+	}
+	switch f.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return errors.New("需要是数字")
+		}
+	case "port":
+		if n, err := strconv.Atoi(v); err != nil || n < 1 || n > 65535 {
+			return errors.New("端口需要是 1-65535 之间的整数")
+		}
+	case "host":
+		if net.ParseIP(strings.Trim(v, "[]")) == nil && !hostnameRe.MatchString(v) {
+			return errors.New("不是有效的主机名或 IP 地址")
+		}
+	}
+	return nil
+}
+
+// formAnswer renders the filled-in values as the answer recorded in the conversation
+func formAnswer(fields []llm.Field, values []string) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.Label + ": " + values[i]
+	}
+	return strings.Join(parts, "；")
+}
+
+// pathSuggestions lists the entries of the directory being typed, offered as ghost text
+// that Tab accepts. Hidden entries are only offered once the name starts with a dot.
+func pathSuggestions(value string) []string {
+	dir, prefix := filepath.Split(value)
+	read := cmp.Or(dir, ".")
+	if rest, ok := strings.CutPrefix(read, "~"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		read = home + rest
+	}
+	entries, err := os.ReadDir(read)
+	if err != nil {
+		return nil
+	}
+
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		s := dir + name
+		if e.IsDir() {
+			s += string(filepath.Separator)
+		}
+		out = append(out, s)
+		if len(out) == maxPathSuggestions {
+			break
+		}
+	}
+	return out
+}
+
+// focusField moves the cursor to the i-th field of the form
+func (m *AppModel) focusField(i int) tea.Cmd {
+	m.askForm[m.askFocus].input.Blur()
+	m.askFocus = i
+	return m.askForm[i].input.Focus()
+}
+
+// updateFormField passes a message to the focused field, refreshing path completions as
+// the value changes. Completing from the local disk would mislead for a remote host.
+func (m *AppModel) updateFormField(msg tea.Msg) tea.Cmd {
+	f := &m.askForm[m.askFocus]
+	before := f.input.Value()
+	var cmd tea.Cmd
+	f.input, cmd = f.input.Update(msg)
+	if f.Type == "path" && m.client.Host() == "" && f.input.Value() != before {
+		f.input.SetSuggestions(pathSuggestions(f.input.Value()))
+	}
+	if f.input.Value() != before {
+		f.err = ""
+	}
+	return cmd
+}
+
+func (m *AppModel) handleAskFormKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := &m.askForm[m.askFocus]
+	last := m.askFocus == len(m.askForm)-1
+	switch msg.Type {
+	case tea.KeyEnter:
+		if err := validateField(f.Field, strings.TrimSpace(f.input.Value())); err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+		if !last {
+			return m, m.focusField(m.askFocus + 1)
+		}
+		return m.submitAskForm()
+	case tea.KeyTab:
+		// Tab completes a path when there is something to complete, otherwise moves on
+		if f.Type == "path" && len(f.input.MatchedSuggestions()) > 0 && f.input.CurrentSuggestion() != f.input.Value() {
+			return m, m.updateFormField(msg)
+		}
+		if !last {
+			return m, m.focusField(m.askFocus + 1)
+		}
+		return m, nil
+	case tea.KeyDown:
+		if !last {
+			return m, m.focusField(m.askFocus + 1)
+		}
+		return m, nil
+	case tea.KeyShiftTab, tea.KeyUp:
+		if m.askFocus > 0 {
+			return m, m.focusField(m.askFocus - 1)
+		}
+		return m, nil
+	case tea.KeyCtrlO:
+		m.showAllHistory = !m.showAllHistory
+		return m, nil
+	case tea.KeyCtrlC, tea.KeyEsc:
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, m.updateFormField(msg)
+}
+
+// submitAskForm validates every field, focusing the first invalid one, and sends the answers
+func (m *AppModel) submitAskForm() (tea.Model, tea.Cmd) {
+	fields := make([]llm.Field, len(m.askForm))
+	values := make([]string, len(m.askForm))
+	for i := range m.askForm {
+		f := &m.askForm[i]
+		fields[i], values[i] = f.Field, strings.TrimSpace(f.input.Value())
+		if err := validateField(f.Field, values[i]); err != nil {
+			f.err = err.Error()
+			return m, m.focusField(i)
+		}
+	}
+	m.contextHistory = m.contextHistory.add(exchange{question: m.inputPrompt, answer: formAnswer(fields, values)})
+	m.askForm = nil
+	return m, m.startAnalysis()
+}
+
+func (m *AppModel) renderAskForm() string {
+	width := 0
+	for _, f := range m.askForm {
+		width = max(width, runewidth.StringWidth(f.Label))
+	}
+	var s strings.Builder
+	for i, f := range m.askForm {
+		marker := "  "
+		if i == m.askFocus {
+			marker = m.titleStyle.Render("› ")
+		}
+		s.WriteString(marker + runewidth.FillRight(f.Label, width) + "  " + f.input.View() + "\n")
+		if f.err != "" {
+			s.WriteString(strings.Repeat(" ", width+4) + m.errorStyle.Render("✗ "+f.err) + "\n")
+		}
+	}
+	return s.String()
+}
+
+// askFormPlain asks for each field on its own line, repeating a field until its value is valid
+func askFormPlain(form []formField) (string, bool) {
+	fields := make([]llm.Field, len(form))
+	values := make([]string, len(form))
+	for i, f := range form {
+		fields[i] = f.Field
+		hint := ""
+		if p := fieldPlaceholders[f.Type]; p != "" && f.Type != "path" {
+			hint = " (" + p + ")"
+		}
+		for {
+			fmt.Printf("  %s%s: ", f.Label, hint)
+			line, ok := readLine()
+			if !ok {
+				return "", false
+			}
+			values[i] = strings.TrimSpace(line)
+			err := validateField(f.Field, values[i])
+			if err == nil {
+				break
+			}
+			glyph.Printf("  ✗ %v\n", err)
+		}
+	}
+	return formAnswer(fields, values), true
+}
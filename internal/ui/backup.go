@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"termi.sh/termi/internal/backup"
+	"termi.sh/termi/internal/config"
+)
+
+// backup copies the files a local command is about to modify or delete when enabled in
+// config, so `termi undo` can restore them. A failed backup is reported but does not
+// stop the command the user already confirmed.
+func (m *AppModel) backup(command string) {
+	if note := m.backupNote(command); note != "" {
+		fmt.Print(note + "\n\n")
+	}
+}
+
+// backupNote takes the backup and returns what to tell the user about it, if anything
+func (m *AppModel) backupNote(command string) string {
+	if m.cfg == nil || !m.cfg.Exec.Backup || m.client.Host() != "" {
+		return ""
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	op, err := backup.Protect(config.BackupsDir(), command, dir, m.cfg.Exec.BackupBytes())
+	switch {
+	case errors.Is(err, backup.ErrTooLarge):
+		return fmt.Sprintf("🗄  %v，本次不备份", err)
+	case err != nil:
+		return fmt.Sprintf("🗄  备份失败: %v", err)
+	case op != nil:
+		return fmt.Sprintf("🗄  已备份 %d 个受影响的路径，可用 termi undo 恢复", len(op.Entries))
+	}
+	return ""
+}
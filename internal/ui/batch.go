@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/approval"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/runner"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/shell"
+	"termi.sh/termi/internal/suggest"
+)
+
+// toggleMark marks or unmarks the highlighted candidate for batch execution
+func (m *AppModel) toggleMark() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	if m.marked == nil {
+		m.marked = map[int]bool{}
+	}
+	if m.marked[m.cursor] {
+		delete(m.marked, m.cursor)
+	} else {
+		if model, cmd, held := m.guardForbidden(m.candidates[m.cursor].Text, m.toggleMark); held {
+			return model, cmd
+		}
+		m.marked[m.cursor] = true
+	}
+	return m, nil
+}
+
+// markedCommands returns the marked candidates in list order
+func (m *AppModel) markedCommands() []string {
+	var cmds []string
+	for i, c := range m.candidates {
+		if m.marked[i] {
+			cmds = append(cmds, c.Text)
+		}
+	}
+	return cmds
+}
+
+// openPlan shows the combined plan of the marked commands before running them
+func (m *AppModel) openPlan() (tea.Model, tea.Cmd) {
+	if m.queueing() {
+		return m.queueCommands(m.markedCommands())
+	}
+	if shell.Active() {
+		return m.handoff(strings.Join(m.markedCommands(), " && "))
+	}
+	if model, cmd, refused := m.refuseExecution(m.markedCommands()); refused {
+		return model, cmd
+	}
+	m.batch = m.markedCommands()
+	m.state = StatePlan
+	return m, nil
+}
+
+func (m *AppModel) handlePlanKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "y":
+		m.stepwise = false
+	case "s":
+		m.stepwise = true
+	case "t":
+		// Interactive commands need the terminal, so leave the TUI and run them there
+		m.stepwise = false
+		m.state = StateCompleted
+		return m, tea.Quit
+	case "esc", "q":
+		m.batch, m.batchTasks = nil, nil
+		m.state = StateSelecting
+		return m, nil
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	default:
+		return m, nil
+	}
+	if !m.planNeedsTerminal() {
+		return m.startPlan()
+	}
+	m.state = StateCompleted
+	return m, tea.Quit
+}
+
+func (m *AppModel) renderPlanView() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render(fmt.Sprintf("📋 执行计划（%d 条命令）:", len(m.batch))))
+	s.WriteString("\n\n")
+	for i, cmd := range m.batch {
+		prefix := fmt.Sprintf("%d. ", i+1)
+		badge := m.safetyBadge(suggest.Suggestion{Text: cmd})
+		line := prefix + renderCommand(cmd, m.termWidth()-textWidth(badge)-1, len(prefix), m.itemStyle)
+		if badge != "" {
+			line += " " + badge
+		}
+		s.WriteString(line + "\n")
+		if i < len(m.batchTasks) {
+			s.WriteString(faintStyle().Render(strings.Repeat(" ", len(prefix))+"🧾 "+m.batchTasks[i]) + "\n")
+		}
+	}
+
+	s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).
+		Render("\n⚠ 命令按顺序在各自的 shell 中运行，任一条失败即停止；cd、export 不会影响后续命令"))
+	s.WriteString("\n")
+	keys := "\nEnter: 全部执行, s: 逐条确认执行, t: 在终端中执行（交互式命令）, Esc/q: 返回, ?: 帮助"
+	if m.planNeedsTerminal() {
+		s.WriteString(faintStyle().Render("部分命令需要在终端中确认，将退出界面执行") + "\n")
+		keys = "\nEnter: 全部执行, s: 逐条确认执行, Esc/q: 返回, ?: 帮助"
+	}
+	s.WriteString(faintStyle().Render(keys))
+	return s.String()
+}
+
+// runBatch executes the planned commands in order after the TUI exits,
+// asking before each one in step-by-step mode and stopping at the first failure
+func (m *AppModel) runBatch() error {
+	defer m.copyCapturedOutput()
+	for i, command := range m.batch {
+		fmt.Printf("\n[%d/%d] %s\n", i+1, len(m.batch), command)
+		if m.stepwise && !confirm("执行该命令?") {
+			fmt.Println("已跳过")
+			continue
+		}
+		fmt.Println()
+
+		transcript, execErr := m.run(command)
+		if errors.Is(execErr, safety.ErrNotConfirmed) || errors.Is(execErr, safety.ErrNotReviewed) || errors.Is(execErr, safety.ErrBlocked) || errors.Is(execErr, safety.ErrForbidden) || errors.Is(execErr, approval.ErrNotApproved) || errors.Is(execErr, safety.ErrNotRepeated) {
+			fmt.Println(execErr)
+			return nil
+		}
+		exitCode := runner.ExitCode(execErr)
+		m.recordShellHistory(command)
+		m.record(history.Entry{
+			Command:    command,
+			Action:     history.ActionExecuted,
+			ExitCode:   &exitCode,
+			Transcript: transcript,
+		})
+		if execErr != nil {
+			if remaining := len(m.batch) - i - 1; remaining > 0 {
+				fmt.Printf("\n剩余 %d 条命令未执行\n", remaining)
+			}
+			return fmt.Errorf("第 %d 条命令执行失败: %w", i+1, execErr)
+		}
+		m.sendAuto(command, exitCode)
+	}
+	return nil
+}
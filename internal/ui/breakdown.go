@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/llm"
+)
+
+// breakdownPageSize is how many rendered lines the explanation panel shows at once
+const breakdownPageSize = 14
+
+// breakdownMsg carries the LLM's line-by-line explanation of a command
+type breakdownMsg struct {
+	command string
+	lines   []llm.Line
+	err     error
+}
+
+// openBreakdown explains the selected command piece by piece, asking the LLM on first use
+func (m *AppModel) openBreakdown() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	command := m.candidates[m.cursor].Text
+	m.state = StateBreakdown
+	m.breakdownStart = 0
+	if _, ok := m.breakdowns[command]; ok {
+		return m, nil
+	}
+
+	client, ctx := m.client, m.ctx
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		lines, err := client.ExplainCommand(ctx, command)
+		return breakdownMsg{command: command, lines: lines, err: err}
+	})
+}
+
+func (m *AppModel) handleBreakdown(msg breakdownMsg) (tea.Model, tea.Cmd) {
+	m.breakdowns[msg.command] = msg.lines
+	if msg.err != nil {
+		m.breakdowns[msg.command] = nil
+		m.breakdownErr = m.formatLLMError(msg.err)
+	}
+	return m, nil
+}
+
+func (m *AppModel) handleBreakdownKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := max(0, len(m.breakdownLines())-breakdownPageSize)
+	switch msg.String() {
+	case "up", "k":
+		m.breakdownStart = max(0, m.breakdownStart-1)
+	case "down", "j":
+		m.breakdownStart = min(last, m.breakdownStart+1)
+	case "pgup", "b":
+		m.breakdownStart = max(0, m.breakdownStart-breakdownPageSize)
+	case "pgdown", " ":
+		m.breakdownStart = min(last, m.breakdownStart+breakdownPageSize)
+	case "enter":
+		return m.executeCommand()
+	case "e":
+		return m.openEdit()
+	case "esc", "q", "x":
+		m.state = StateSelecting
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// breakdownLines renders the explanation of the selected command, one piece per line
+// followed by its indented note, so the panel can scroll by rendered line
+func (m *AppModel) breakdownLines() []string {
+	command := m.candidates[m.cursor].Text
+	faint := faintStyle()
+	var out []string
+	for _, l := range m.breakdowns[command] {
+		out = append(out, m.selectedStyle.Render(l.Code))
+		// Notes are wrapped here rather than by the terminal so the panel scrolls by row
+		for _, note := range wrapText(l.Note, m.termWidth()-4) {
+			out = append(out, faint.Render("    "+note))
+		}
+	}
+	return out
+}
+
+func (m *AppModel) renderBreakdownView() string {
+	command := m.candidates[m.cursor].Text
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("📖 命令解释:") + "\n\n")
+	s.WriteString(indent(command) + "\n\n")
+
+	faint := faintStyle()
+	lines, ok := m.breakdowns[command]
+	switch {
+	case !ok:
+		s.WriteString(m.spinner.View() + " 正在逐段解释命令...\n")
+	case lines == nil && m.breakdownErr != nil:
+		s.WriteString(m.errorStyle.Render("无法获取解释: "+m.breakdownErr.Error()) + "\n")
+	default:
+		rendered := m.breakdownLines()
+		end := min(len(rendered), m.breakdownStart+breakdownPageSize)
+		s.WriteString(strings.Join(rendered[m.breakdownStart:end], "\n") + "\n")
+		if len(rendered) > breakdownPageSize {
+			s.WriteString(faint.Render(fmt.Sprintf("\n  第 %d-%d 行，共 %d 行", m.breakdownStart+1, end, len(rendered))) + "\n")
+		}
+	}
+
+	s.WriteString(faint.Render("\n↑/↓: 滚动, Enter: 执行, e: 编辑, Esc/q: 返回, ?: 帮助"))
+	return s.String()
+}
@@ -0,0 +1,42 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/cmddb"
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/suggest"
+)
+
+// useCommandDB answers simple queries from the offline command database without
+// calling the LLM; it reports whether the query matched
+func (m *AppModel) useCommandDB() bool {
+	// Piped context changes what the query means, so only the LLM can take it into account
+	if m.cfg == nil || m.cfg.DisableCommandDB || m.fixInput != "" || m.client.Host() != "" || m.client.PipedInput().Text != "" || len(m.client.Files()) > 0 {
+		return false
+	}
+	db, err := cmddb.Load(config.CommandDBPath())
+	if err != nil {
+		return false
+	}
+	command, description, ok := db.Lookup(m.query)
+	if !ok {
+		return false
+	}
+
+	candidates := []suggest.Suggestion{{Text: command, Sources: []string{"offline"}}}
+	candidates = append(candidates, m.historyCandidates()...)
+	m.candidates = suggest.GroupByApproach(suggest.Merge(candidates))
+	m.offlineHit = description
+	m.cursor = 0
+	m.state = StateSelecting
+	return true
+}
+
+// askLLM discards the offline answer and sends the query to the LLM after all
+func (m *AppModel) askLLM() (tea.Model, tea.Cmd) {
+	m.offlineHit = ""
+	m.candidates = nil
+	m.marked = nil
+	return m, m.startAnalysis()
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// conversationDisplayLimit is how many of the latest rounds the asking view shows by default
+const conversationDisplayLimit = 3
+
+// exchange is one question the model asked and how the user answered it
+type exchange struct {
+	question string
+	answer   string
+	timedOut bool // the user didn't answer in time; the model is told to assume instead
+}
+
+// conversation holds the questions answered so far for the current query, oldest first
+type conversation []exchange
+
+// add records an answer. A question the model asks again replaces the earlier round, so the
+// prompt and the view never carry the same question twice
+func (c conversation) add(e exchange) conversation {
+	for i, prev := range c {
+		if strings.TrimSpace(prev.question) == strings.TrimSpace(e.question) {
+			c = append(c[:i:i], c[i+1:]...)
+			break
+		}
+	}
+	return append(c, e)
+}
+
+// prompt renders the query followed by each answered question once
+func (c conversation) prompt(query string) string {
+	if len(c) == 0 {
+		return query
+	}
+	var b strings.Builder
+	b.WriteString(query)
+	b.WriteString("\n\n已向用户确认的信息:")
+	for i, e := range c {
+		fmt.Fprintf(&b, "\n%d. 问: %s\n   答: %s", i+1, e.question, e.display())
+	}
+	if c[len(c)-1].timedOut {
+		b.WriteString("\n\n用户没有回答最后一个问题。不要再提问，请按最合理的假设直接给出命令，并在 assumptions 字段中用中文说明所做的假设")
+	}
+	return b.String()
+}
+
+// display is the answer as shown to the user and the model
+func (e exchange) display() string {
+	if e.timedOut {
+		return "（未回答）"
+	}
+	return e.answer
+}
+
+// visible returns the rounds the asking view shows and how many earlier ones are folded
+func (c conversation) visible(all bool) (shown conversation, folded int) {
+	if all || len(c) <= conversationDisplayLimit {
+		return c, 0
+	}
+	folded = len(c) - conversationDisplayLimit
+	return c[folded:], folded
+}
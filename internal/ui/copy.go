@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/clipboard"
+)
+
+// copyFormat is one entry of the copy submenu
+type copyFormat int
+
+const (
+	copyRaw copyFormat = iota
+	copyWithComment
+	copyAsFunction
+	copyToScript
+)
+
+var copyFormatLabels = []string{
+	"复制命令",
+	"复制并附带注释 (# generated by termi)",
+	"复制为 shell 函数定义",
+	"保存为可执行脚本文件",
+}
+
+// funcNameRe matches a valid shell function name
+var funcNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// openCopyMenu switches to the copy submenu for the selected candidate
+func (m *AppModel) openCopyMenu() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	if model, cmd, held := m.guardForbidden(m.candidates[m.cursor].Text, m.openCopyMenu); held {
+		return model, cmd
+	}
+	m.copyCursor = 0
+	m.copyInputMode = false
+	m.state = StateCopyMenu
+	return m, nil
+}
+
+func (m *AppModel) handleCopyMenuKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.copyInputMode {
+		switch msg.Type {
+		case tea.KeyEnter:
+			return m.applyCopyFormat(copyFormat(m.copyCursor), strings.TrimSpace(m.textInput.Value()))
+		case tea.KeyEsc:
+			m.copyInputMode = false
+			m.textInput.Blur()
+		case tea.KeyCtrlC:
+			m.state = StateCanceled
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.copyCursor > 0 {
+			m.copyCursor--
+		}
+	case "down", "j":
+		if m.copyCursor < len(copyFormatLabels)-1 {
+			m.copyCursor++
+		}
+	case "1", "2", "3", "4":
+		m.copyCursor = int(msg.String()[0] - '1')
+		return m.chooseCopyFormat()
+	case "enter":
+		return m.chooseCopyFormat()
+	case "esc", "q":
+		m.state = StateSelecting
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// chooseCopyFormat applies the highlighted format, asking for a name or path first if needed
+func (m *AppModel) chooseCopyFormat() (tea.Model, tea.Cmd) {
+	switch copyFormat(m.copyCursor) {
+	case copyAsFunction:
+		m.copyInputMode = true
+		m.textInput.SetValue("termi_cmd")
+		m.textInput.Focus()
+		return m, nil
+	case copyToScript:
+		m.copyInputMode = true
+		m.textInput.SetValue("termi-script.sh")
+		m.textInput.Focus()
+		return m, nil
+	default:
+		return m.applyCopyFormat(copyFormat(m.copyCursor), "")
+	}
+}
+
+func (m *AppModel) applyCopyFormat(format copyFormat, arg string) (tea.Model, tea.Cmd) {
+	command := m.candidates[m.cursor].Text
+	comment := "# generated by termi: " + strings.ReplaceAll(m.originalQuery, "\n", " ")
+
+	switch format {
+	case copyWithComment:
+		return m.copyText(command, comment+"\n"+command)
+	case copyAsFunction:
+		if !funcNameRe.MatchString(arg) {
+			m.state = StateError
+			m.err = fmt.Errorf("无效的函数名: %q", arg)
+			return m, nil
+		}
+		return m.copyText(command, fmt.Sprintf("%s\n%s() {\n  %s\n}", comment, arg, command))
+	case copyToScript:
+		path, err := saveScript(arg, comment, command)
+		if err != nil {
+			m.state = StateError
+			m.err = err
+			return m, nil
+		}
+		m.savedPath = path
+		m.copiedCommand = command
+		m.state = StateSaved
+		return m, tea.Quit
+	default:
+		return m.copyText(command, command)
+	}
+}
+
+// saveScript writes the command to an executable script file
+func saveScript(path, comment, command string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("文件路径不能为空")
+	}
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[2:])
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("文件已存在: %s", path)
+	}
+
+	content := "#!/usr/bin/env bash\n" + comment + "\n" + command + "\n"
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		return "", fmt.Errorf("保存脚本失败: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path, nil
+	}
+	return abs, nil
+}
+
+func (m *AppModel) renderCopyMenuView() string {
+	var s strings.Builder
+
+	s.WriteString(m.titleStyle.Render("📋 复制方式:"))
+	s.WriteString("\n\n")
+	s.WriteString(faintStyle().Render(m.candidates[m.cursor].Text))
+	s.WriteString("\n\n")
+
+	for i, label := range copyFormatLabels {
+		line := fmt.Sprintf("%d. %s", i+1, label)
+		if i == m.copyCursor {
+			s.WriteString(m.selectedStyle.Render("➜ " + line))
+		} else {
+			s.WriteString("  " + m.itemStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+
+	if m.copyInputMode {
+		label := "函数名: "
+		if copyFormat(m.copyCursor) == copyToScript {
+			label = "保存路径: "
+		}
+		s.WriteString("\n" + label + m.textInput.View() + "\n")
+		s.WriteString(faintStyle().Render("\nEnter: 确认, Esc: 返回"))
+		return s.String()
+	}
+
+	s.WriteString(faintStyle().Render("\n↑/↓ 或 1-4: 选择, Enter: 确认, Esc: 返回, ?: 帮助"))
+	return s.String()
+}
+
+// loadClipboard creates the clipboard backend selected in config, falling back to the system clipboard
+func loadClipboard(m *AppModel) clipboard.Clipboard {
+	if m.cfg == nil {
+		return clipboard.System()
+	}
+	cb, err := clipboard.New(m.cfg.Clipboard)
+	if err != nil {
+		return clipboard.System()
+	}
+	return cb
+}
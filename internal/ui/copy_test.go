@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"termi.sh/termi/internal/clipboard"
+)
+
+func TestCopyText(t *testing.T) {
+	fake := &clipboard.Fake{}
+	m := &AppModel{clipboard: fake}
+
+	_, cmd := m.copyText("ls -la", "ls -la  # 列出文件")
+	if cmd == nil {
+		t.Fatal("copyText returned no command")
+	}
+	m.handleCopied(cmd().(copiedMsg))
+
+	if got := fake.Text(); got != "ls -la  # 列出文件" {
+		t.Errorf("clipboard = %q, want the copied text", got)
+	}
+	if m.state != StateCopied || m.copiedCommand != "ls -la" {
+		t.Errorf("state = %v, copiedCommand = %q", m.state, m.copiedCommand)
+	}
+}
+
+func TestCopyTextError(t *testing.T) {
+	m := &AppModel{clipboard: &clipboard.Fake{Err: errors.New("no clipboard")}}
+
+	_, cmd := m.copyText("pwd", "pwd")
+	m.handleCopied(cmd().(copiedMsg))
+
+	if m.state != StateError || m.err == nil {
+		t.Errorf("state = %v, err = %v, want StateError", m.state, m.err)
+	}
+}
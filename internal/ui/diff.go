@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/editpreview"
+	"termi.sh/termi/internal/glyph"
+)
+
+// diffPageSize is how many diff lines the preview panel shows at once
+const diffPageSize = 16
+
+// diffMsg carries the result of running an in-place edit on temp copies of its files
+type diffMsg struct {
+	command string
+	files   []editpreview.File
+	err     error
+}
+
+// previewable reports whether the command edits files in place and can be previewed locally.
+// The edit script runs for real, if only in the sandbox, so nothing is previewed in copy-only
+// mode or for a command that may not run at all
+func (m *AppModel) previewable(command string) bool {
+	if m.client.Host() != "" || m.copyOnlyMode() || !editpreview.Supported(command) || editpreview.Available() != nil {
+		return false
+	}
+	r := m.safety.Analyze(command)
+	return !r.Forbidden && !r.Blocked
+}
+
+// openDiff applies the selected sed/perl/awk/patch edit to temp copies and shows the resulting diff
+func (m *AppModel) openDiff() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	command := m.candidates[m.cursor].Text
+	if !m.previewable(command) {
+		return m, nil
+	}
+	m.state = StateDiff
+	m.diffStart = 0
+	if _, ok := m.editDiffs[command]; ok {
+		return m, nil
+	}
+
+	ctx := m.ctx
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		dir, err := os.Getwd()
+		if err != nil {
+			return diffMsg{command: command, err: err}
+		}
+		files, err := editpreview.Run(ctx, command, dir)
+		return diffMsg{command: command, files: files, err: err}
+	})
+}
+
+func (m *AppModel) handleDiff(msg diffMsg) (tea.Model, tea.Cmd) {
+	m.editDiffs[msg.command] = msg.files
+	if msg.err != nil {
+		m.editDiffs[msg.command] = nil
+		m.editDiffErr = msg.err
+	}
+	return m, nil
+}
+
+func (m *AppModel) handleDiffKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := max(0, len(m.diffLines())-diffPageSize)
+	switch msg.String() {
+	case "up", "k":
+		m.diffStart = max(0, m.diffStart-1)
+	case "down", "j":
+		m.diffStart = min(last, m.diffStart+1)
+	case "pgup", "b":
+		m.diffStart = max(0, m.diffStart-diffPageSize)
+	case "pgdown", " ":
+		m.diffStart = min(last, m.diffStart+diffPageSize)
+	case "enter":
+		return m.executeCommand()
+	case "e":
+		return m.openEdit()
+	case "esc", "q", "d":
+		m.state = StateSelecting
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// diffLines renders the preview of the selected command, colored like `git diff`
+func (m *AppModel) diffLines() []string {
+	return renderDiff(m.editDiffs[m.candidates[m.cursor].Text], m.termWidth())
+}
+
+// renderDiff renders the changes to each file with a file header, removed lines in red and
+// added lines in green. Lines wider than width are cut so each takes one row of a scrolling
+// panel; 0 leaves them to the terminal
+func renderDiff(files []editpreview.File, width int) []string {
+	faint := faintStyle()
+	removed := lipgloss.NewStyle().Foreground(theme.danger)
+	added := lipgloss.NewStyle().Foreground(theme.success)
+	var out []string
+	for _, f := range files {
+		header := lipgloss.NewStyle().Bold(true)
+		out = append(out, header.Render("--- a/"+f.Path), header.Render("+++ b/"+f.Path))
+		if len(f.Lines) == 0 {
+			out = append(out, faint.Render("    (没有修改)"))
+		}
+		for _, l := range f.Lines {
+			if width > 0 {
+				l = runewidth.Truncate(l, width, "…")
+			}
+			switch {
+			case strings.HasPrefix(l, "@@"):
+				out = append(out, lipgloss.NewStyle().Foreground(theme.accent).Render(l))
+			case strings.HasPrefix(l, "-"):
+				out = append(out, removed.Render(l))
+			case strings.HasPrefix(l, "+"):
+				out = append(out, added.Render(l))
+			default:
+				out = append(out, faint.Render(l))
+			}
+		}
+	}
+	return out
+}
+
+func (m *AppModel) renderDiffView() string {
+	command := m.candidates[m.cursor].Text
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("✏ 修改预览:") + "\n\n")
+	s.WriteString(indent(command) + "\n\n")
+
+	faint := faintStyle()
+	_, ok := m.editDiffs[command]
+	switch {
+	case !ok:
+		s.WriteString(m.spinner.View() + " 正在临时副本上运行命令...\n")
+	case m.editDiffs[command] == nil && m.editDiffErr != nil:
+		s.WriteString(m.errorStyle.Render("无法预览: "+m.editDiffErr.Error()) + "\n")
+	default:
+		rendered := m.diffLines()
+		end := min(len(rendered), m.diffStart+diffPageSize)
+		s.WriteString(strings.Join(rendered[m.diffStart:end], "\n") + "\n")
+		if len(rendered) > diffPageSize {
+			s.WriteString(faint.Render(fmt.Sprintf("\n  第 %d-%d 行，共 %d 行", m.diffStart+1, end, len(rendered))) + "\n")
+		}
+		s.WriteString(faint.Render("\n  以上是在临时副本上的运行结果，原文件尚未修改") + "\n")
+	}
+
+	s.WriteString(faint.Render("\n↑/↓: 滚动, Enter: 执行, e: 编辑, Esc/q: 返回, ?: 帮助"))
+	return s.String()
+}
+
+// previewEditPlain prints the diff an in-place edit would make before plain mode asks to run it
+func (m *AppModel) previewEditPlain(command string) {
+	if !m.previewable(command) {
+		fmt.Println("该命令无法预览修改")
+		return
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	files, err := editpreview.Run(m.ctx, command, dir)
+	if err != nil {
+		fmt.Printf("无法预览修改: %v\n\n", err)
+		return
+	}
+	glyph.Println("✏ 在临时副本上预览的修改（原文件尚未修改）:")
+	for _, l := range renderDiff(files, 0) {
+		fmt.Println(l)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorMsg carries the command saved in $EDITOR
+type editorMsg struct {
+	command string
+	err     error
+}
+
+// openEdit puts the selected command in the text input so flags can be tweaked before running
+func (m *AppModel) openEdit() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	if model, cmd, held := m.guardForbidden(m.candidates[m.cursor].Text, m.openEdit); held {
+		return model, cmd
+	}
+	m.state = StateEdit
+	m.err = nil
+	m.textInput.Placeholder = ""
+	m.textInput.SetValue(m.candidates[m.cursor].Text)
+	m.textInput.CursorEnd()
+	return m, m.textInput.Focus()
+}
+
+func (m *AppModel) handleEditKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		return m.runEdited(m.textInput.Value())
+	case tea.KeyEsc:
+		m.textInput.SetValue("")
+		m.state = StateSelecting
+	case tea.KeyCtrlE:
+		return m, m.openEditor(m.textInput.Value())
+	case tea.KeyCtrlC:
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// runEdited replaces the selected candidate with the edited command and runs it like any
+// other candidate, so it goes through the same safety checks
+func (m *AppModel) runEdited(command string) (tea.Model, tea.Cmd) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return m, nil
+	}
+	m.textInput.SetValue("")
+	m.state = StateSelecting
+	if original := m.candidates[m.cursor].Text; command != original {
+		m.editedFrom = original
+		m.candidates[m.cursor].Text = command
+		m.candidates[m.cursor].Notes = append(m.candidates[m.cursor].Notes, "已手动修改")
+	}
+	return m.executeCommand()
+}
+
+// openEditor edits the command in $VISUAL or $EDITOR (vi if neither is set) in a temp file
+func (m *AppModel) openEditor(command string) tea.Cmd {
+	f, err := os.CreateTemp("", "termi-*.sh")
+	if err != nil {
+		return func() tea.Msg { return editorMsg{err: err} }
+	}
+	path := f.Name()
+	_, err = f.WriteString(command + "\n")
+	f.Close()
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return editorMsg{err: err} }
+	}
+
+	editor := strings.Fields(os.Getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorMsg{err: err}
+		}
+		data, err := os.ReadFile(path)
+		return editorMsg{command: strings.TrimSpace(string(data)), err: err}
+	})
+}
+
+// handleEditor runs what was saved in the editor; an empty file goes back to the text input
+func (m *AppModel) handleEditor(msg editorMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		return m, nil
+	}
+	if msg.command == "" {
+		return m, nil
+	}
+	return m.runEdited(msg.command)
+}
+
+func (m *AppModel) renderEditView() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("✏️  编辑命令") + "\n\n")
+	s.WriteString(m.textInput.View() + "\n")
+	s.WriteString(m.renderSafetyReasons(m.textInput.Value()))
+	if m.err != nil {
+		s.WriteString(m.errorStyle.Render("\n无法打开编辑器: "+m.err.Error()) + "\n")
+	}
+	s.WriteString(faintStyle().Render("\nEnter: 执行, Ctrl+E: 在 $EDITOR 中编辑, Esc: 返回, ?: 帮助"))
+	return s.String()
+}
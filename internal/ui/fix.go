@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/fix"
+)
+
+// renderFixDiff shows how the proposed fix differs from the pasted command
+func (m *AppModel) renderFixDiff() string {
+	if m.fixInput == "" || m.cursor >= len(m.candidates) {
+		return ""
+	}
+
+	ops := fix.Diff(m.fixInput, m.candidates[m.cursor].Text)
+	changed := false
+	words := make([]string, len(ops))
+	for i, op := range ops {
+		switch op.Kind {
+		case fix.OpDelete:
+			changed = true
+			words[i] = lipgloss.NewStyle().Foreground(theme.danger).Strikethrough(true).Render(op.Text)
+		case fix.OpInsert:
+			changed = true
+			words[i] = lipgloss.NewStyle().Foreground(theme.success).Render(op.Text)
+		default:
+			words[i] = faintStyle().Render(op.Text)
+		}
+	}
+
+	if !changed {
+		return lipgloss.NewStyle().Foreground(theme.muted).Render("\n🩺 与原命令相同，无需修改") + "\n"
+	}
+	return "\n🩺 相对原命令的修改:\n  " + strings.Join(words, " ") + "\n"
+}
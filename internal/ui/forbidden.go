@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/safety"
+)
+
+// guardForbidden asks for the override code before a forbidden command is run, copied, edited,
+// sent or marked. It reports whether the action was held back; next resumes it once the code
+// is accepted
+func (m *AppModel) guardForbidden(command string, next func() (tea.Model, tea.Cmd)) (tea.Model, tea.Cmd, bool) {
+	r := m.safety.Analyze(command)
+	if !r.Forbidden || m.overridden[command] {
+		return nil, nil, false
+	}
+	m.overrideReturn = m.state
+	m.overrideCommand = command
+	m.overrideNext = next
+	m.overrideFailed = false
+	m.state = StateOverride
+	if !r.Overridable() {
+		return m, nil, true
+	}
+	m.textInput.Placeholder = ""
+	m.textInput.SetValue("")
+	m.textInput.EchoMode = textinput.EchoPassword
+	return m, m.textInput.Focus(), true
+}
+
+func (m *AppModel) handleOverrideKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		r := m.safety.Analyze(m.overrideCommand)
+		if !r.Unlocks(strings.TrimSpace(m.textInput.Value())) {
+			m.overrideFailed = r.Overridable()
+			m.textInput.SetValue("")
+			if !r.Overridable() {
+				return m.leaveOverride(), nil
+			}
+			return m, nil
+		}
+		if m.overridden == nil {
+			m.overridden = map[string]bool{}
+		}
+		m.overridden[m.overrideCommand] = true
+		next := m.overrideNext
+		m.leaveOverride()
+		return next()
+	case tea.KeyEsc:
+		return m.leaveOverride(), nil
+	case tea.KeyCtrlC:
+		m.leaveOverride()
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// leaveOverride returns to the view the action was started from
+func (m *AppModel) leaveOverride() *AppModel {
+	m.textInput.SetValue("")
+	m.textInput.EchoMode = textinput.EchoNormal
+	m.overrideNext = nil
+	m.state = m.overrideReturn
+	return m
+}
+
+func (m *AppModel) renderOverrideView() string {
+	r := m.safety.Analyze(m.overrideCommand)
+	var s strings.Builder
+	s.WriteString(m.errorStyle.Bold(true).Render("⛔ 命令被禁止") + "\n\n")
+	s.WriteString(lipgloss.NewStyle().Strikethrough(true).Render(m.overrideCommand) + "\n\n")
+	s.WriteString(strings.Join(r.Reasons, "；") + "\n\n")
+	if !r.Overridable() {
+		s.WriteString("该命令不能执行、复制或编辑，未配置覆盖码 (safety.override_code)\n\n")
+		s.WriteString(faintStyle().Render("Enter/Esc: 返回"))
+		return s.String()
+	}
+	s.WriteString("输入覆盖码后才能继续:\n")
+	s.WriteString(m.textInput.View() + "\n")
+	if m.overrideFailed {
+		s.WriteString(m.errorStyle.Render("覆盖码不正确") + "\n")
+	}
+	s.WriteString(faintStyle().Render("\nEnter: 确认, Esc: 返回"))
+	return s.String()
+}
+
+// forbiddenStyle strikes through commands that may not be used without the override code
+func (m *AppModel) forbiddenStyle(command string, style lipgloss.Style) lipgloss.Style {
+	if m.overridden[command] || !m.safety.Analyze(command).Forbidden {
+		return style
+	}
+	return style.Strikethrough(true)
+}
+
+// confirmOverridePlain asks for the override code on the terminal when a forbidden command is
+// chosen outside the TUI, remembering it for the rest of the run
+func (m *AppModel) confirmOverridePlain(command string) error {
+	r := m.safety.Analyze(command)
+	if !r.Forbidden || m.overridden[command] {
+		return nil
+	}
+	if err := safety.ConfirmOverride(r); err != nil {
+		return err
+	}
+	if m.overridden == nil {
+		m.overridden = map[string]bool{}
+	}
+	m.overridden[command] = true
+	return nil
+}
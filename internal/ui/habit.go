@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/habit"
+	"termi.sh/termi/internal/history"
+)
+
+// pendingHabit returns an edit the user has repeatedly made to generated commands
+// and not yet decided on, or nil. It is offered once per request, not in repair rounds.
+func (m *AppModel) pendingHabit() *habit.Pattern {
+	store := m.client.Habits()
+	if store == nil || len(m.repairs) > 0 {
+		return nil
+	}
+	entries, err := history.Open(config.HistoryPath()).Load()
+	if err != nil {
+		return nil
+	}
+	return store.Pending(entries)
+}
+
+// openHabit offers to turn a repeated manual edit into a preference or rewrite rule
+func (m *AppModel) openHabit(p *habit.Pattern) tea.Cmd {
+	m.habit = p
+	m.state = StateHabit
+	return nil
+}
+
+func (m *AppModel) handleHabitKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// As with trust, the decision still applies to this run if it can't be saved
+	var mode habit.Mode
+	switch msg.String() {
+	case "p":
+		mode = habit.ModePrompt
+	case "a":
+		mode = habit.ModeRewrite
+	case "n":
+		mode = habit.ModeIgnore
+	case "enter", "esc":
+		// Decide later; it is offered again next time
+		return m, m.analyze()
+	case "q", "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	default:
+		return m, nil
+	}
+	_ = m.client.Habits().Decide(*m.habit, mode)
+	return m, m.analyze()
+}
+
+func (m *AppModel) renderHabitView() string {
+	p := m.habit
+	var b strings.Builder
+	b.WriteString(m.titleStyle.Render("✏️  发现了一个修改习惯"))
+	b.WriteString(fmt.Sprintf("\n\n你已经 %d 次在生成的 %s 命令中手动加上 %s 并执行成功。\n\n", p.Count, p.Program, m.selectedStyle.Render(p.Args)))
+	b.WriteString("  p: 记为偏好，生成命令时告知 AI（由 AI 判断何时适用）\n")
+	b.WriteString(fmt.Sprintf("  a: 自动添加到生成的 %s 命令中\n", p.Program))
+	b.WriteString("  n: 不需要，不再提示\n\n")
+	b.WriteString(faintStyle().Render("Enter/Esc: 以后再说, q: 退出；决定保存在 habits.json 中，可随时编辑"))
+	return b.String()
+}
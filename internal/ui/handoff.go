@@ -0,0 +1,20 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/shell"
+)
+
+// handoff places the command on the parent shell's command line instead of running it,
+// when termi was started by the `termi shell-init` widget
+func (m *AppModel) handoff(command string) (tea.Model, tea.Cmd) {
+	if err := shell.Handoff(m.attributed(command)); err != nil {
+		m.state = StateError
+		m.err = err
+		return m, nil
+	}
+	m.selectedCommand = command
+	m.state = StateHandoff
+	return m, tea.Quit
+}
@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"cmp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/config"
+)
+
+// binding is one row of the help overlay
+type binding struct {
+	key  string
+	desc string
+}
+
+// isHelpKey reports whether msg should toggle the help overlay. In text input
+// states `?` is only treated as help while the input is empty, so it can still be typed.
+func (m *AppModel) isHelpKey(msg tea.KeyMsg) bool {
+	if m.showHelp {
+		switch msg.String() {
+		case "?", "esc", "q":
+			return true
+		}
+		return false
+	}
+	// The override code may contain "?", so it never opens the overlay
+	if msg.String() != "?" || m.state == StateOverride {
+		return false
+	}
+	if m.state == StateAsking && m.askForm != nil {
+		return m.askForm[m.askFocus].input.Value() == ""
+	}
+	if m.state == StateAsking || m.state == StateEdit || (m.state == StateCopyMenu && m.copyInputMode) || (m.state == StatePin && m.pinEditing) {
+		return m.textInput.Value() == ""
+	}
+	return true
+}
+
+// bindings lists the keys active in the current state
+func (m *AppModel) bindings() []binding {
+	switch m.state {
+	case StateAnalyzing:
+		if m.slow {
+			b := []binding{{"w", "继续等待"}}
+			if m.client.Fallback() != nil {
+				b = append(b, binding{"f", "切换到备用提供商"})
+			}
+			return append(b, binding{"o", "使用历史中的离线建议"}, binding{"q / Ctrl+C", "退出"})
+		}
+		return []binding{{"q / Ctrl+C", "取消分析并退出"}}
+	case StateBudget:
+		return []binding{{"c / Enter", "追加额度并继续"}, {"q / Esc", "退出"}}
+	case StateAsking:
+		b := []binding{{"Enter", "提交回答"}, {"Esc / Ctrl+C", "取消"}}
+		if m.askForm != nil {
+			b = []binding{
+				{"Enter", "校验当前项，最后一项时提交"},
+				{"↓ / Tab", "下一项"},
+				{"↑ / Shift+Tab", "上一项"},
+				{"Tab", "在路径项中补全路径"},
+				{"Esc / Ctrl+C", "取消"},
+			}
+		}
+		if len(m.contextHistory) > conversationDisplayLimit {
+			b = append(b, binding{"Ctrl+O", "显示全部 / 折叠较早的对话历史"})
+		}
+		return b
+	case StateApproving:
+		return []binding{{"y / Enter", "发送给 AI"}, {"n / Esc", "拒绝发送"}, {"Ctrl+C", "取消"}}
+	case StateSelecting:
+		b := []binding{
+			{"↑ / k", "上一条"},
+			{"↓ / j", "下一条"},
+			{"Enter", "执行选中的命令；已多选时查看执行计划"},
+			{"y", "执行并将输出复制到剪贴板"},
+			{"空格", "标记/取消标记，多条命令按顺序批量执行"},
+			{"e", "编辑命令后再执行"},
+			{"x", "逐段解释命令的含义"},
+			{"w", "为什么选这条命令，与其他候选的取舍"},
+			{"f", "查看命令流程图（各管道阶段的作用）"},
+			{"c", "复制（可选注释、函数、脚本格式）"},
+			{"s", "生成可 source 的脚本"},
+		}
+		if m.queueing() {
+			b[2] = binding{"Enter", "选定该任务的命令（可多选），最后一项任务后审阅合并的执行计划"}
+		}
+		if m.cursor < len(m.candidates) && m.previewable(m.candidates[m.cursor].Text) {
+			b = append(b, binding{"d", "在临时副本上预览 sed -i 等命令对文件的修改"})
+		}
+		if len(m.sinks) > 0 {
+			b = append(b, binding{"o", "发送到配置的 sink（运行手册、Slack 等）"})
+		}
+		if m.share != nil {
+			b = append(b, binding{"u", "分享脱敏后的会话记录，并复制链接"})
+		}
+		if m.offlineHit != "" {
+			b = append(b, binding{"a", "离线命令不符合需求时改为询问 AI"})
+		}
+		b = append(b, binding{"P", "固定上下文（如目标主机、命名空间），之后每次请求都会附带"})
+		return append(b, binding{"q / Esc / Ctrl+C", "退出"})
+	case StatePin:
+		if m.pinEditing {
+			return []binding{{"Enter", "保存（清空后保存即删除）"}, {"Esc", "取消编辑"}}
+		}
+		return []binding{{"↑ / ↓", "选择"}, {"Enter", "编辑或新增"}, {"d", "删除"}, {"Esc / q", "完成，内容有变化时重新生成"}}
+	case StateTrust:
+		return []binding{{"y", "信任此目录及其子目录"}, {"n / Enter", "本次不读取项目文件"}, {"N", "不信任且不再询问"}, {"q / Esc", "退出"}}
+	case StateHabit:
+		return []binding{{"p", "记为偏好，附加到提示词"}, {"a", "自动添加到生成的命令"}, {"n", "不再提示"}, {"Enter / Esc", "以后再说"}, {"q", "退出"}}
+	case StatePlan:
+		return []binding{{"Enter / y", "按顺序全部执行，输出显示在各命令下方"}, {"s", "逐条确认后执行"}, {"t", "退出界面在终端中执行，适合需要交互的命令"}, {"Esc / q", "返回修改选择"}}
+	case StatePlanRunning:
+		if m.planFinished() {
+			return []binding{{"↑ / ↓ / k / j", "选择命令"}, {"空格 / Tab", "展开或折叠输出"}, {"PgUp / PgDn", "滚动输出"}, {"Enter / q / Esc", "退出"}}
+		}
+		return []binding{{"↑ / ↓ / k / j", "选择命令"}, {"空格 / Tab", "展开或折叠输出"}, {"PgUp / PgDn", "滚动输出"}, {"Enter / y", "逐条模式下执行当前命令"}, {"n", "逐条模式下跳过当前命令"}, {"Ctrl+C", "中止执行计划"}}
+	case StateExplain:
+		return []binding{{"Enter", "执行该命令"}, {"Esc / q / f", "返回"}}
+	case StateEdit:
+		return []binding{{"Enter", "执行修改后的命令"}, {"Ctrl+E", "在 $EDITOR 中编辑，保存退出后执行"}, {"Esc", "放弃修改并返回"}, {"Ctrl+C", "退出"}}
+	case StateAnswer:
+		return []binding{{"c", "复制回答并退出"}, {"Enter / q / Esc", "退出"}}
+	case StateOverride:
+		return []binding{{"Enter", "确认覆盖码"}, {"Esc", "返回"}, {"Ctrl+C", "退出"}}
+	case StateBreakdown:
+		return []binding{{"↑ / ↓ / k / j", "滚动"}, {"PgUp / PgDn", "翻页"}, {"Enter", "执行该命令"}, {"e", "编辑命令"}, {"Esc / q / x", "返回"}}
+	case StateRationale:
+		return []binding{{"Enter", "执行该命令"}, {"e", "编辑命令"}, {"Esc / q / w", "返回"}}
+	case StateDiff:
+		return []binding{{"↑ / ↓ / k / j", "滚动"}, {"PgUp / PgDn", "翻页"}, {"Enter", "执行该命令"}, {"e", "编辑命令"}, {"Esc / q / d", "返回"}}
+	case StateError:
+		var bindings []binding
+		if len(m.switches) > 0 {
+			bindings = []binding{{"↑ / ↓", "选择提供商"}, {"1-9", "直接用该提供商重试"}, {"Enter", "用选中的提供商重试"}}
+		}
+		if m.missingModel != nil {
+			bindings = append(bindings, binding{"p", "让 Ollama 服务下载模型后重试"})
+		}
+		if len(bindings) > 0 {
+			return append(bindings, binding{"q / Esc", "退出"})
+		}
+		return []binding{{"q / Ctrl+C", "退出"}}
+	case StatePulling:
+		return []binding{{"Esc / q", "取消下载"}, {"Ctrl+C", "取消下载并退出"}}
+	case StateShare:
+		return []binding{{"Enter / y", "发布并复制链接"}, {"Esc / n / q", "返回"}}
+	case StateSinkMenu:
+		return []binding{{"↑ / ↓", "选择"}, {"1-9", "直接发送"}, {"Enter", "发送"}, {"Esc / q", "返回"}}
+	case StateCopyMenu:
+		if m.copyInputMode {
+			return []binding{{"Enter", "确认"}, {"Esc", "返回"}}
+		}
+		return []binding{{"↑ / ↓", "选择"}, {"1-4", "直接选择"}, {"Enter", "确认"}, {"Esc / q", "返回"}}
+	default:
+		return []binding{{"q / Ctrl+C", "退出"}}
+	}
+}
+
+// settings lists configuration highlights shown in the help overlay
+func (m *AppModel) settings() []binding {
+	s := []binding{{"提供商", m.client.ProviderName()}}
+	if m.cfg == nil {
+		return s
+	}
+	s = append(s, binding{"模型", cmp.Or(m.cfg.LLM.Model(), "默认")})
+	if m.cfg.LLM.Fallback != "" {
+		s = append(s, binding{"备用提供商", string(m.cfg.LLM.Fallback)})
+	}
+	if host := m.client.Host(); host != "" {
+		s = append(s, binding{"目标主机", host})
+	}
+	if presets := m.client.Presets(); len(presets) > 0 {
+		s = append(s, binding{"环境预设", strings.Join(presets, ", ")})
+	}
+	s = append(s, binding{"低带宽模式", onOff(m.cfg.Lite)})
+	s = append(s, binding{"发送前确认", onOff(m.cfg.Redact.Approve)})
+	s = append(s, binding{"执行录制", onOff(m.cfg.Record.Enabled)})
+	s = append(s, binding{"执行后验证", onOff(m.cfg.Exec.Verify)})
+	s = append(s, binding{"来源注释", onOff(m.cfg.Exec.Attribution)})
+	s = append(s, binding{"历史记录", onOff(!m.cfg.History.Disabled)})
+	s = append(s, binding{"仅复制模式", onOff(m.cfg.Safety.CopyOnly)})
+	s = append(s, binding{"剪贴板", cmp.Or(string(m.cfg.Clipboard.Backend), string(config.ClipboardSystem))})
+	return s
+}
+
+func onOff(on bool) string {
+	if on {
+		return "开"
+	}
+	return "关"
+}
+
+func (m *AppModel) renderHelpView() string {
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.accent).
+		Padding(0, 1)
+	keyStyle := lipgloss.NewStyle().Bold(true).Foreground(theme.selected)
+	labelStyle := lipgloss.NewStyle().Foreground(theme.muted)
+
+	table := func(rows []binding, style lipgloss.Style) string {
+		width := 0
+		for _, r := range rows {
+			width = max(width, lipgloss.Width(r.key))
+		}
+		lines := make([]string, len(rows))
+		for i, r := range rows {
+			lines[i] = style.Width(width).Render(r.key) + "  " + r.desc
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	keys := panel.Render(m.titleStyle.Render("⌨ 快捷键") + "\n\n" + table(m.bindings(), keyStyle))
+	conf := panel.Render(m.titleStyle.Render("⚙ 当前配置") + "\n\n" + table(m.settings(), labelStyle))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, keys, " ", conf) + "\n\n" +
+		faintStyle().Render("?/Esc: 关闭帮助")
+}
@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/llm"
+)
+
+const (
+	// historyPageSize is how many entries the history browser shows at once
+	historyPageSize = 8
+	// historyStatusWidth is the column width of historyStatus, in terminal cells
+	historyStatusWidth = 10
+)
+
+// historyAction is what the user chose to do with a past entry
+type historyAction int
+
+const (
+	historyNone historyAction = iota
+	historyRerun
+	historyCopy
+)
+
+// historyBrowser lists past entries newest first with a fuzzy filter. Typing always
+// edits the filter, so the actions are on Enter and control keys.
+type historyBrowser struct {
+	store    *history.Store
+	entries  []history.Entry // oldest first, as stored
+	shown    []history.Entry // entries matching the filter, newest first
+	cursor   int
+	filter   textinput.Model
+	deleting bool // Ctrl+D was pressed once; a second press deletes
+	status   string
+
+	chosen history.Entry
+	action historyAction
+
+	titleStyle    lipgloss.Style
+	selectedStyle lipgloss.Style
+	errorStyle    lipgloss.Style
+	faintStyle    lipgloss.Style
+}
+
+// RunHistory opens the history browser, pre-filtered by pattern, and re-runs or copies
+// the chosen command through the regular flow so it is checked and recorded again
+func RunHistory(cfg *config.Config, client *llm.Client, pattern string) error {
+	applyTheme(cfg)
+	store := history.Open(config.HistoryPath())
+	entries, err := store.Load()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("还没有历史记录")
+		return nil
+	}
+	if !interactiveTerminal() {
+		return printHistory(history.Search(entries, pattern))
+	}
+
+	ti := textinput.New()
+	ti.Prompt = "🔍 "
+	ti.Placeholder = "输入关键字模糊搜索需求或命令"
+	ti.SetValue(pattern)
+	ti.Focus()
+	b := &historyBrowser{
+		store:         store,
+		entries:       entries,
+		filter:        ti,
+		titleStyle:    lipgloss.NewStyle().Bold(true).Foreground(theme.title),
+		selectedStyle: lipgloss.NewStyle().Foreground(theme.selected).Bold(true),
+		errorStyle:    lipgloss.NewStyle().Foreground(theme.danger),
+		faintStyle:    faintStyle(),
+	}
+	b.refilter()
+
+	if _, err := tea.NewProgram(b).Run(); err != nil {
+		return fmt.Errorf("界面运行出错: %w", err)
+	}
+
+	m := NewAppModel(cfg, client, b.chosen.Query)
+	switch b.action {
+	case historyRerun:
+		m.rerun = true
+		m.selectedCommand = b.chosen.Final()
+		m.state = StateCompleted
+	case historyCopy:
+		m.copyPlain(b.chosen.Final())
+	default:
+		return nil
+	}
+	return m.finish(cfg)
+}
+
+// printHistory lists entries when there is no terminal to browse them in
+func printHistory(entries []history.Entry) error {
+	for _, e := range entries {
+		fmt.Printf("%s  %s %s\n  %s\n", e.Time.Format("2006-01-02 15:04"), runewidth.FillRight(historyStatus(e), historyStatusWidth), e.Query, e.Final())
+	}
+	return nil
+}
+
+// historyStatus summarizes what was done with the command
+func historyStatus(e history.Entry) string {
+	switch e.Action {
+	case history.ActionExecuted:
+		if e.ExitCode == nil {
+			return "执行"
+		}
+		if *e.ExitCode == 0 {
+			return "执行 ✓"
+		}
+		return fmt.Sprintf("执行 ✗%d", *e.ExitCode)
+	case history.ActionCopied:
+		return "复制"
+	case history.ActionSaved:
+		return "保存"
+	case history.ActionSent:
+		return "发送"
+	case history.ActionInserted:
+		return "命令行"
+	case history.ActionEdited:
+		return "修改后执行"
+	default:
+		return string(e.Action)
+	}
+}
+
+// refilter applies the filter and keeps the cursor within the results
+func (b *historyBrowser) refilter() {
+	b.shown = history.Search(b.entries, b.filter.Value())
+	b.cursor = min(b.cursor, max(len(b.shown)-1, 0))
+}
+
+func (b *historyBrowser) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (b *historyBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		b.filter, cmd = b.filter.Update(msg)
+		return b, cmd
+	}
+
+	if key.String() != "ctrl+d" {
+		b.deleting = false
+	}
+	switch key.String() {
+	case "esc", "ctrl+c":
+		return b, tea.Quit
+	case "up", "ctrl+p":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+		return b, nil
+	case "down", "ctrl+n":
+		if b.cursor < len(b.shown)-1 {
+			b.cursor++
+		}
+		return b, nil
+	case "enter", "ctrl+y":
+		if len(b.shown) == 0 {
+			return b, nil
+		}
+		b.chosen = b.shown[b.cursor]
+		b.action = historyRerun
+		if key.String() == "ctrl+y" {
+			b.action = historyCopy
+		}
+		return b, tea.Quit
+	case "ctrl+d":
+		return b.delete()
+	}
+
+	before := b.filter.Value()
+	var cmd tea.Cmd
+	b.filter, cmd = b.filter.Update(msg)
+	if b.filter.Value() != before {
+		b.cursor = 0
+		b.status = ""
+		b.refilter()
+	}
+	return b, cmd
+}
+
+// delete removes the selected entry from the store after a second Ctrl+D
+func (b *historyBrowser) delete() (tea.Model, tea.Cmd) {
+	if len(b.shown) == 0 {
+		return b, nil
+	}
+	if !b.deleting {
+		b.deleting = true
+		b.status = "再按一次 Ctrl+D 删除这条记录"
+		return b, nil
+	}
+	b.deleting = false
+
+	e := b.shown[b.cursor]
+	if err := b.store.Delete(e); err != nil {
+		b.status = b.errorStyle.Render(err.Error())
+		return b, nil
+	}
+	for i := range b.entries {
+		if b.entries[i].Time.Equal(e.Time) && b.entries[i].Command == e.Command {
+			b.entries = append(b.entries[:i], b.entries[i+1:]...)
+			break
+		}
+	}
+	b.status = "已删除"
+	b.refilter()
+	return b, nil
+}
+
+func (b *historyBrowser) View() string {
+	return glyph.Text(b.view())
+}
+
+func (b *historyBrowser) view() string {
+	var s strings.Builder
+	s.WriteString(b.titleStyle.Render(fmt.Sprintf("📜 历史记录 (%d/%d)", len(b.shown), len(b.entries))))
+	s.WriteString("\n\n" + b.filter.View() + "\n\n")
+
+	if len(b.shown) == 0 {
+		s.WriteString(b.faintStyle.Render("  没有匹配的记录") + "\n")
+	}
+	// Keep the cursor on the visible page
+	start := max(0, b.cursor-historyPageSize+1)
+	end := min(len(b.shown), start+historyPageSize)
+	for i := start; i < end; i++ {
+		e := b.shown[i]
+		head := fmt.Sprintf("%s  %s %s", e.Time.Format("01-02 15:04"), runewidth.FillRight(historyStatus(e), historyStatusWidth), e.Query)
+		if i == b.cursor {
+			s.WriteString(b.selectedStyle.Render("▶ "+head) + "\n")
+			s.WriteString(b.selectedStyle.Render(indent(e.Final())) + "\n")
+		} else {
+			s.WriteString("  " + head + "\n")
+			s.WriteString(b.faintStyle.Render(indent(e.Final())) + "\n")
+		}
+	}
+	if end < len(b.shown) {
+		s.WriteString(b.faintStyle.Render(fmt.Sprintf("  …还有 %d 条", len(b.shown)-end)) + "\n")
+	}
+
+	if b.status != "" {
+		s.WriteString("\n" + b.status + "\n")
+	}
+	s.WriteString(b.faintStyle.Render("\n↑/↓: 选择, Enter: 重新执行, Ctrl+Y: 复制, Ctrl+D: 删除, Esc: 退出"))
+	return s.String()
+}
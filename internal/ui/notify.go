@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/mux"
+)
+
+// flashDuration is how long the screen stays in reverse video for a visual flash
+const flashDuration = 120 * time.Millisecond
+
+// notify emits the configured cues (bell, flash, title) for users who switched
+// focus away while waiting on the model. Inside tmux or screen, where the outer
+// terminal may never see the title or the bell, any enabled cue also shows the
+// message in the multiplexer's status line.
+func (m *AppModel) notify(title string) tea.Cmd {
+	if m.cfg == nil {
+		return nil
+	}
+	nc := m.cfg.Notify
+
+	var cmds []tea.Cmd
+	if nc.Title {
+		cmds = append(cmds, tea.SetWindowTitle("termi: "+title))
+	}
+	if nc.Bell {
+		cmds = append(cmds, func() tea.Msg {
+			_, _ = os.Stderr.WriteString("\a")
+			return nil
+		})
+	}
+	if nc.Flash {
+		cmds = append(cmds, func() tea.Msg {
+			// DECSCNM: toggle reverse video on the whole screen
+			_, _ = os.Stderr.WriteString("\x1b[?5h")
+			time.Sleep(flashDuration)
+			_, _ = os.Stderr.WriteString("\x1b[?5l")
+			return nil
+		})
+	}
+	if len(cmds) > 0 && mux.Detect() != mux.None {
+		cmds = append(cmds, func() tea.Msg {
+			_ = mux.Display("termi: " + title)
+			return nil
+		})
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"os"
+	"time"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/objects"
+	"termi.sh/termi/internal/shellquote"
+)
+
+// captureObjects returns a temp file to receive the command's output objects when it runs
+// locally in PowerShell or Nushell, or "" when nothing is captured
+func (m *AppModel) captureObjects() string {
+	if m.cfg == nil || m.cfg.Exec.NoObjectCapture || m.client.Host() != "" {
+		return ""
+	}
+	if sh := shellquote.Parse(m.client.Shell()); sh != shellquote.PowerShell && sh != shellquote.Nushell {
+		return ""
+	}
+	f, err := os.CreateTemp("", "termi-objects-*.json")
+	if err != nil {
+		return ""
+	}
+	f.Close()
+	return f.Name()
+}
+
+// saveObjects keeps the captured objects for the next query in this directory, or forgets
+// an earlier command's objects once a newer command produced none
+func (m *AppModel) saveObjects(command, path string) {
+	defer os.Remove(path)
+	data, total, kept, ok := objects.Read(path)
+	dir, err := os.Getwd()
+	if !ok || err != nil {
+		_ = os.Remove(config.ObjectsPath())
+		return
+	}
+	_ = objects.Save(config.ObjectsPath(), objects.Output{
+		Command: command,
+		Shell:   m.client.Shell(),
+		Dir:     dir,
+		Time:    time.Now(),
+		JSON:    data,
+		Total:   total,
+		Kept:    kept,
+	})
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/runner"
+)
+
+// outputTailSize caps how much of a command's stdout is kept for copying
+const outputTailSize = 64 * 1024
+
+// escapeSequence matches CSI and OSC terminal escape sequences, which would garble pasted output
+var escapeSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// captureOutput returns the stdout tail to tee the command into when its output is to be copied;
+// batch commands share one tail so the output of the whole plan is copied
+func (m *AppModel) captureOutput() *runner.Tail {
+	if !m.copyOutput {
+		return nil
+	}
+	if m.stdout == nil {
+		m.stdout = runner.NewTail(outputTailSize)
+	}
+	return m.stdout
+}
+
+// copyCapturedOutput copies the captured stdout, or its last lines when configured, to the clipboard
+func (m *AppModel) copyCapturedOutput() {
+	if m.stdout == nil {
+		return
+	}
+	raw := m.stdout.String()
+	text := strings.TrimRight(escapeSequence.ReplaceAllString(strings.ReplaceAll(raw, "\r\n", "\n"), ""), "\n")
+	lines := strings.Split(text, "\n")
+	if len(raw) >= outputTailSize && len(lines) > 1 {
+		// The first line was probably cut in half when the tail overflowed
+		lines = lines[1:]
+	}
+	if n := m.copyLines(); n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	text = strings.Join(lines, "\n")
+	if strings.TrimSpace(text) == "" {
+		fmt.Println("\n命令没有输出，未复制")
+		return
+	}
+
+	if err := m.clipboard.Copy(text + "\n"); err != nil {
+		fmt.Printf("\n复制输出失败: %v\n", err)
+		return
+	}
+	note := ""
+	if len(raw) >= outputTailSize {
+		note = fmt.Sprintf("，输出过长，只保留了最后 %d KB", outputTailSize/1024)
+	}
+	glyph.Printf("\n📋 已将输出的 %d 行复制到%s%s\n", len(lines), m.clipboard.Name(), note)
+}
+
+func (m *AppModel) copyLines() int {
+	if m.cfg == nil {
+		return 0
+	}
+	return m.cfg.Exec.CopyLines
+}
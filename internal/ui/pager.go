@@ -0,0 +1,294 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/runner"
+)
+
+// pagerReserve is how many terminal lines are left for the notice and the summary below
+// the output before it is held back for the pager
+const pagerReserve = 4
+
+// pagedOutput returns the writer that holds back output beyond one screen when exec.pager is
+// set and both ends are a terminal, or nil to write straight to the terminal
+func (m *AppModel) pagedOutput() *runner.Paged {
+	if m.cfg == nil || m.cfg.Exec.Pager == "" || !interactiveTerminal() {
+		return nil
+	}
+	_, height, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || height <= pagerReserve*2 {
+		return nil
+	}
+	return runner.NewPaged(os.Stdout, height-pagerReserve)
+}
+
+// pageOutput shows the full output of the last command when it did not fit on one screen,
+// in $PAGER or the internal pager
+func (m *AppModel) pageOutput() {
+	if m.paged == nil || !m.paged.Overflowed() {
+		return
+	}
+	text := m.paged.String()
+	if m.cfg.Exec.Pager == config.PagerAuto {
+		if fields := strings.Fields(os.Getenv("PAGER")); len(fields) > 0 {
+			cmd := exec.Command(fields[0], fields[1:]...)
+			cmd.Stdin = strings.NewReader(text)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err == nil {
+				return
+			}
+			glyph.Printf("⚠ $PAGER (%s) 运行失败，改用内置分页器\n", fields[0])
+		}
+	}
+	if _, err := tea.NewProgram(newOutputPager(text), tea.WithAltScreen()).Run(); err != nil {
+		fmt.Printf("分页器运行出错: %v\n", err)
+	}
+}
+
+// outputPager scrolls through a command's output with less-like keys and a regexp search
+type outputPager struct {
+	output    []string // the output's lines, as drawn on a terminal
+	lines     []string // output wrapped to the screen width
+	start     int
+	height    int
+	search    textinput.Model
+	searching bool
+	pattern   *regexp.Regexp
+	matches   []int // indexes of the lines matching pattern
+	status    string
+
+	matchStyle lipgloss.Style
+	faintStyle lipgloss.Style
+}
+
+func newOutputPager(text string) *outputPager {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	lines := pagerLines(text)
+	return &outputPager{
+		output:     lines,
+		lines:      lines,
+		height:     20,
+		search:     ti,
+		matchStyle: lipgloss.NewStyle().Reverse(true),
+		faintStyle: faintStyle(),
+	}
+}
+
+// pagerLines splits output into display lines: escape sequences are dropped, a carriage
+// return keeps only what was drawn last, and tabs are expanded
+func pagerLines(text string) []string {
+	text = escapeSequence.ReplaceAllString(strings.ReplaceAll(text, "\r\n", "\n"), "")
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if j := strings.LastIndex(line, "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = expandTabs(line)
+	}
+	return lines
+}
+
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := 8 - col%8
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col += runewidth.RuneWidth(r)
+	}
+	return b.String()
+}
+
+func (p *outputPager) Init() tea.Cmd {
+	return nil
+}
+
+func (p *outputPager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Wrap here so every line takes one row and scrolling stays in step with the screen
+		p.lines = p.lines[:0:0]
+		for _, line := range p.output {
+			p.lines = append(p.lines, wrapText(line, msg.Width)...)
+		}
+		p.height = max(msg.Height-1, 1)
+		p.matchLines()
+		p.scroll(0)
+	case tea.KeyMsg:
+		if p.searching {
+			return p.handleSearchKey(msg)
+		}
+		return p.handleKey(msg)
+	}
+	return p, nil
+}
+
+func (p *outputPager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p.status = ""
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return p, tea.Quit
+	case "up", "k":
+		p.scroll(-1)
+	case "down", "j", "enter":
+		p.scroll(1)
+	case "pgup", "b":
+		p.scroll(-p.height)
+	case "pgdown", " ", "f":
+		p.scroll(p.height)
+	case "g", "home":
+		p.start = 0
+	case "G", "end":
+		p.start = p.last()
+	case "/":
+		p.searching = true
+		p.search.SetValue("")
+		return p, p.search.Focus()
+	case "n":
+		p.jump(true)
+	case "N":
+		p.jump(false)
+	}
+	return p, nil
+}
+
+func (p *outputPager) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		p.searching = false
+		p.search.Blur()
+		p.find(p.search.Value())
+		return p, nil
+	case tea.KeyEsc, tea.KeyCtrlC:
+		p.searching = false
+		p.search.Blur()
+		return p, nil
+	}
+	var cmd tea.Cmd
+	p.search, cmd = p.search.Update(msg)
+	return p, cmd
+}
+
+// find searches for query, case-insensitively unless it contains an upper-case letter, and
+// jumps to the first match at or below the top of the screen
+func (p *outputPager) find(query string) {
+	p.pattern, p.matches = nil, nil
+	if query == "" {
+		return
+	}
+	expr := query
+	if strings.ToLower(query) == query {
+		expr = "(?i)" + query
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		p.status = "无效的正则表达式: " + err.Error()
+		return
+	}
+	p.pattern = re
+	p.matchLines()
+	for _, i := range p.matches {
+		if i >= p.start {
+			p.start = min(i, p.last())
+			return
+		}
+	}
+	if len(p.matches) == 0 {
+		p.status = "没有找到: " + query
+		return
+	}
+	p.status = "下方没有匹配，按 N 向上查找"
+}
+
+// matchLines finds the lines matching the search pattern
+func (p *outputPager) matchLines() {
+	p.matches = nil
+	if p.pattern == nil {
+		return
+	}
+	for i, line := range p.lines {
+		if p.pattern.MatchString(line) {
+			p.matches = append(p.matches, i)
+		}
+	}
+}
+
+// jump scrolls the next match below, or the previous one above, the top line to the top
+func (p *outputPager) jump(forward bool) {
+	if p.pattern == nil {
+		return
+	}
+	if forward {
+		for _, i := range p.matches {
+			if i > p.start {
+				p.start = min(i, p.last())
+				return
+			}
+		}
+	} else {
+		for j := len(p.matches) - 1; j >= 0; j-- {
+			if i := p.matches[j]; i < p.start {
+				p.start = i
+				return
+			}
+		}
+	}
+	p.status = "没有更多匹配"
+}
+
+func (p *outputPager) scroll(n int) {
+	p.start = max(0, min(p.last(), p.start+n))
+}
+
+// last is the top line when the end of the output is at the bottom of the screen
+func (p *outputPager) last() int {
+	return max(0, len(p.lines)-p.height)
+}
+
+func (p *outputPager) View() string {
+	var s strings.Builder
+	end := min(len(p.lines), p.start+p.height)
+	for _, line := range p.lines[p.start:end] {
+		if p.pattern != nil {
+			line = p.pattern.ReplaceAllStringFunc(line, func(m string) string { return p.matchStyle.Render(m) })
+		}
+		s.WriteString(line + "\n")
+	}
+	for i := end - p.start; i < p.height; i++ {
+		s.WriteString(p.faintStyle.Render("~") + "\n")
+	}
+
+	switch {
+	case p.searching:
+		s.WriteString(p.search.View())
+	case p.status != "":
+		s.WriteString(p.faintStyle.Render(p.status))
+	default:
+		s.WriteString(p.faintStyle.Render(fmt.Sprintf("第 %d-%d 行，共 %d 行 · ↑/↓ 空格/b: 翻页 /: 搜索 n/N: 下一个/上一个 q: 退出",
+			p.start+1, end, len(p.lines))))
+	}
+	return s.String()
+}
@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/llm"
+)
+
+// openPins shows the pinned-context editor; the last row adds a new fact
+func (m *AppModel) openPins() (tea.Model, tea.Cmd) {
+	m.pinReturn = m.state
+	m.pinCursor = len(m.pins)
+	m.pinsBefore = slices.Clone(m.pins)
+	m.state = StatePin
+	return m.editPin()
+}
+
+// editPin starts editing the fact under the cursor, or a new one on the last row
+func (m *AppModel) editPin() (tea.Model, tea.Cmd) {
+	m.pinEditing = true
+	m.textInput.Placeholder = "例如：目标主机是 10.0.0.5、使用 staging 命名空间"
+	m.textInput.SetValue("")
+	if m.pinCursor < len(m.pins) {
+		m.textInput.SetValue(m.pins[m.pinCursor])
+	}
+	m.textInput.CursorEnd()
+	return m, m.textInput.Focus()
+}
+
+func (m *AppModel) handlePinKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pinEditing {
+		switch msg.Type {
+		case tea.KeyEnter:
+			fact := strings.TrimSpace(m.textInput.Value())
+			switch {
+			case m.pinCursor < len(m.pins) && fact == "":
+				m.pins = slices.Delete(m.pins, m.pinCursor, m.pinCursor+1)
+			case m.pinCursor < len(m.pins):
+				m.pins[m.pinCursor] = fact
+			case fact != "":
+				m.pins = append(m.pins, fact)
+				m.pinCursor = len(m.pins)
+			}
+			m.pinEditing = false
+			m.textInput.SetValue("")
+		case tea.KeyEsc:
+			m.pinEditing = false
+			m.textInput.SetValue("")
+		case tea.KeyCtrlC:
+			m.state = StateCanceled
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		if m.pinCursor > 0 {
+			m.pinCursor--
+		}
+	case "down", "j":
+		if m.pinCursor < len(m.pins) {
+			m.pinCursor++
+		}
+	case "enter":
+		return m.editPin()
+	case "d", "x":
+		if m.pinCursor < len(m.pins) {
+			m.pins = slices.Delete(m.pins, m.pinCursor, m.pinCursor+1)
+		}
+	case "esc", "q", "P":
+		return m.closePins()
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// closePins leaves the editor; when the facts changed, the query is analyzed again with them
+func (m *AppModel) closePins() (tea.Model, tea.Cmd) {
+	m.state = m.pinReturn
+	if slices.Equal(m.pins, m.pinsBefore) {
+		return m, nil
+	}
+	m.client = m.client.With(llm.WithPinned(slices.Clone(m.pins)))
+	if m.state != StateSelecting {
+		return m, nil
+	}
+	m.offlineHit = ""
+	m.marked = nil
+	return m, m.startAnalysis()
+}
+
+func (m *AppModel) renderPinView() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("📌 固定上下文") + "\n")
+	s.WriteString(faintStyle().Render("固定的内容会附加到本次会话之后的每次请求中") + "\n\n")
+
+	for i := 0; i <= len(m.pins); i++ {
+		text := "+ 新增"
+		if i < len(m.pins) {
+			text = m.pins[i]
+		}
+		switch {
+		case i == m.pinCursor && m.pinEditing:
+			s.WriteString(m.selectedStyle.Render("➜ ") + m.textInput.View() + "\n")
+		case i == m.pinCursor:
+			s.WriteString(m.selectedStyle.Render("➜ "+text) + "\n")
+		default:
+			s.WriteString("  " + text + "\n")
+		}
+	}
+
+	help := "\n↑/↓: 选择, Enter: 编辑, d: 删除, Esc/q: 完成"
+	if m.pinEditing {
+		help = "\nEnter: 保存（清空后保存即删除）, Esc: 取消编辑"
+	}
+	s.WriteString(faintStyle().Render(help))
+	return s.String()
+}
+
+// renderPinnedBar shows the pinned facts above the main views
+func (m *AppModel) renderPinnedBar() string {
+	if len(m.pins) == 0 {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.accent).
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(theme.muted).
+		Render("📌 "+strings.Join(m.pins, " · ")) + "\n"
+}
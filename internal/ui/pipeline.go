@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// stagesMsg carries the LLM's per-stage descriptions of a pipeline
+type stagesMsg struct {
+	command string
+	stages  []string
+	err     error
+}
+
+// splitPipeline splits a command on unquoted single pipes, leaving `||` intact
+func splitPipeline(cmd string) []string {
+	var stages []string
+	var cur strings.Builder
+	var quote rune
+	escaped := false
+	runes := []rune(cmd)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '|':
+			if i+1 < len(runes) && runes[i+1] == '|' {
+				cur.WriteString("||")
+				i++
+				continue
+			}
+			if i+1 < len(runes) && runes[i+1] == '&' { // |& also pipes stderr
+				i++
+			}
+			stages = append(stages, strings.TrimSpace(cur.String()))
+			cur.Reset()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	return append(stages, strings.TrimSpace(cur.String()))
+}
+
+// openExplain shows the flow diagram of the selected command, asking the LLM on first use
+func (m *AppModel) openExplain() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	command := m.candidates[m.cursor].Text
+	m.state = StateExplain
+	if _, ok := m.stageNotes[command]; ok {
+		return m, nil
+	}
+
+	stages := splitPipeline(command)
+	client, ctx := m.client, m.ctx
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		notes, err := client.ExplainStages(ctx, stages)
+		return stagesMsg{command: command, stages: notes, err: err}
+	})
+}
+
+func (m *AppModel) handleStages(msg stagesMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		// Fall back to a diagram without descriptions
+		m.stageNotes[msg.command] = nil
+		m.explainErr = m.formatLLMError(msg.err)
+		return m, nil
+	}
+	m.stageNotes[msg.command] = msg.stages
+	return m, nil
+}
+
+func (m *AppModel) renderExplainView() string {
+	command := m.candidates[m.cursor].Text
+	stages := splitPipeline(command)
+
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("🔍 命令流程:"))
+	s.WriteString("\n\n")
+
+	notes, ok := m.stageNotes[command]
+	if !ok {
+		s.WriteString(m.spinner.View() + " 正在分析各阶段的作用...\n")
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.accent).
+		Padding(0, 1).
+		MaxWidth(76)
+	faint := faintStyle()
+
+	for i, stage := range stages {
+		content := m.selectedStyle.Render(stage)
+		if i < len(notes) {
+			content += "\n" + faint.Render(notes[i])
+		}
+		s.WriteString(box.Render(content))
+		s.WriteString("\n")
+		if i < len(stages)-1 {
+			s.WriteString(lipgloss.NewStyle().Foreground(theme.accent).Render("   │\n   ▼"))
+			s.WriteString("\n")
+		}
+	}
+
+	if ok && notes == nil && m.explainErr != nil {
+		s.WriteString(m.errorStyle.Render("\n无法获取说明: " + m.explainErr.Error()))
+		s.WriteString("\n")
+	}
+
+	s.WriteString(faint.Render("\nEnter: 执行, Esc/q: 返回, ?: 帮助"))
+	return s.String()
+}
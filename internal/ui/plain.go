@@ -0,0 +1,278 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/shell"
+	"termi.sh/termi/internal/suggest"
+)
+
+// interactiveTerminal reports whether the full-screen TUI can be used
+func interactiveTerminal() bool {
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// readLine reads one line from stdin byte by byte, so that input piped to termi is not
+// swallowed by a buffer when several prompts read in turn; ok is false at end of input
+func readLine() (line string, ok bool) {
+	var b strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				return strings.TrimRight(b.String(), "\r"), true
+			}
+			b.WriteByte(buf[0])
+			continue
+		}
+		if err != nil {
+			return b.String(), b.Len() > 0
+		}
+	}
+}
+
+// runPlain drives the same flow as the TUI with numbered, line-based prompts on plain
+// stdin/stdout, for environments where bubbletea cannot run (no TTY, ssh without a pty, CI)
+func (m *AppModel) runPlain(cfg *config.Config) error {
+	if !m.client.Enabled() {
+		return fmt.Errorf("LLM 未启用，请设置 OPENAI_API_KEY 环境变量")
+	}
+	if m.query == "" {
+		return fmt.Errorf("非交互终端中请在命令后输入需求")
+	}
+	m.client = m.client.With(llm.WithApprover(approvePlain))
+	m.trustPlain()
+
+	if !m.useCommandDB() {
+		if err := m.analyzePlain(); err != nil {
+			if errors.Is(err, errCanceled) {
+				fmt.Println("操作已取消")
+				return nil
+			}
+			return err
+		}
+	}
+	if m.answer != "" {
+		glyph.Printf("\n💬 %s\n", renderAnswer(m.answer))
+		if m.slowQuery != nil {
+			glyph.Printf("\n🐢 %s\n", m.slowNote())
+		}
+		return nil
+	}
+	if err := m.selectPlain(); err != nil {
+		if errors.Is(err, errCanceled) {
+			fmt.Println("操作已取消")
+			return nil
+		}
+		return err
+	}
+	return m.finish(cfg)
+}
+
+// errCanceled the user quit or input ended in plain mode
+var errCanceled = errors.New("canceled")
+
+// analyzePlain asks the LLM until it produces a command or answers the question, reading
+// answers to its own questions from stdin
+func (m *AppModel) analyzePlain() error {
+	for {
+		if status := m.repairStatus(); status != "" {
+			glyph.Printf("🔧 正在修复，%s\n", status)
+		} else {
+			glyph.Printf("🧠 正在分析: %s\n", m.query)
+		}
+		ctx := m.ctx
+		if len(m.contextHistory) > 0 {
+			ctx = llm.WithClarifyRound(ctx)
+		}
+		if m.fixInput != "" {
+			ctx = llm.WithVerbatim(ctx)
+		}
+		reply, err := m.client.AskSmart(ctx, m.fullQuery())
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			fmt.Printf("用量达到上限 (%s)\n", m.client.Budget().Summary())
+			if !confirm("追加额度并继续?") {
+				return errCanceled
+			}
+			m.client.Budget().Extend()
+			continue
+		}
+		var missing *llm.ModelNotFoundError
+		if errors.As(err, &missing) && m.pullPlain(missing) {
+			continue
+		}
+		if err != nil {
+			return m.formatLLMError(err)
+		}
+
+		switch {
+		case reply.Ask != "":
+			var answer string
+			if form := newAskForm(reply.Fields); form != nil {
+				glyph.Printf("❓ %s\n", reply.Ask)
+				var ok bool
+				if answer, ok = askFormPlain(form); !ok {
+					return errCanceled
+				}
+			} else {
+				glyph.Printf("❓ %s\n> ", reply.Ask)
+				line, ok := readLine()
+				if answer = strings.TrimSpace(line); !ok || answer == "" {
+					return errCanceled
+				}
+			}
+			m.contextHistory = m.contextHistory.add(exchange{question: reply.Ask, answer: answer})
+		case reply.Command != "":
+			m.transitionToSelecting(reply)
+			return nil
+		case reply.Answer != "":
+			m.answer = reply.Answer
+			m.slowQuery = reply.Slow
+			return nil
+		default:
+			return fmt.Errorf("LLM 未能生成可执行命令，请尝试提供更详细的描述")
+		}
+	}
+}
+
+// selectPlain lists the candidates and reads the choice: a number runs it, c<number> copies it
+func (m *AppModel) selectPlain() error {
+	if m.explanation != "" {
+		glyph.Printf("\n💡 错误分析: %s\n", m.explanation)
+	}
+	glyph.Println("\n🚀 候选命令:")
+	for i, c := range m.candidates {
+		line := fmt.Sprintf("  %d. %s  [%s]", i+1, c.Text, strings.Join(c.Sources, ", "))
+		if r := m.safety.Analyze(c.Text); !r.Allowed && r.Level > safety.Safe {
+			line += fmt.Sprintf("  ⚠ %s: %s", r.Level, strings.Join(r.Reasons, "；"))
+		}
+		if reasons := m.safety.Inspect(c.Text, m.originalQuery); len(reasons) > 0 {
+			line += "  🛡 疑似提示词注入: " + strings.Join(reasons, "；")
+		}
+		glyph.Println(line)
+		if c.Description != "" {
+			fmt.Printf("     %s\n", c.Description)
+		}
+	}
+	if m.offlineHit != "" {
+		glyph.Printf("\n📚 来自离线命令库: %s\n", m.offlineHit)
+	}
+	if note := m.pipedNote(); note != "" {
+		glyph.Printf("\n📎 %s\n", note)
+	}
+	if note := m.intentNote(); note != "" {
+		glyph.Printf("\n🧭 %s\n", note)
+	}
+	if m.answeredBy != "" {
+		glyph.Printf("\n🔀 %s\n", m.failoverNote())
+	}
+	if m.slowQuery != nil {
+		glyph.Printf("\n🐢 %s\n", m.slowNote())
+	}
+	if m.assumptions != "" {
+		glyph.Printf("\n⚠ 基于假设: %s\n", m.assumptions)
+	}
+
+	verb := "执行"
+	switch {
+	case shell.Active():
+		verb = "放到命令行"
+	case m.copyOnlyMode():
+		verb = "复制"
+	}
+	preview := ""
+	if slices.ContainsFunc(m.candidates, func(c suggest.Suggestion) bool { return m.previewable(c.Text) }) {
+		preview = "，d+序号预览修改（如 d1）"
+	}
+	for {
+		fmt.Printf("\n输入序号%s，c+序号复制（如 c1）%s，q 退出 [1]: ", verb, preview)
+		input, ok := readLine()
+		input = strings.ToLower(strings.TrimSpace(input))
+		if !ok || input == "q" {
+			return errCanceled
+		}
+
+		copyOnly := strings.HasPrefix(input, "c")
+		previewOnly := strings.HasPrefix(input, "d")
+		n := 1
+		s := input
+		if copyOnly || previewOnly {
+			s = input[1:]
+		}
+		if s != "" {
+			var err error
+			if n, err = strconv.Atoi(s); err != nil || n < 1 || n > len(m.candidates) {
+				fmt.Printf("请输入 1-%d 之间的序号\n", len(m.candidates))
+				continue
+			}
+		}
+		m.cursor = n - 1
+		command := m.candidates[m.cursor].Text
+		if previewOnly {
+			m.previewEditPlain(command)
+			continue
+		}
+		if err := m.confirmOverridePlain(command); err != nil {
+			return err
+		}
+
+		if copyOnly {
+			m.copyPlain(command)
+			return nil
+		}
+		if m.queueing() {
+			return m.queuePlain(command)
+		}
+		if shell.Active() {
+			m.executeCommand()
+			return nil
+		}
+		if _, _, refused := m.refuseExecution([]string{command}); refused {
+			m.copyPlain(command)
+			return nil
+		}
+		if !confirm(fmt.Sprintf("执行 %s ?", command)) {
+			return errCanceled
+		}
+		m.executeCommand()
+		return nil
+	}
+}
+
+// copyPlain copies the command synchronously and records the outcome in the model state
+func (m *AppModel) copyPlain(command string) {
+	if err := m.confirmOverridePlain(command); err != nil {
+		m.state = StateError
+		m.err = err
+		return
+	}
+	m.copiedCommand, m.copiedText = command, command
+	if err := m.clipboard.Copy(command); err != nil {
+		m.state = StateError
+		m.err = fmt.Errorf("复制失败: %v", err)
+		return
+	}
+	m.state = StateCopied
+}
+
+// approvePlain shows output about to be sent to the LLM and asks on stdin
+func approvePlain(_ context.Context, text string) bool {
+	fmt.Printf("\n以下内容将发送给 AI:\n%s\n", indent(text))
+	return confirm("确认发送?")
+}
@@ -0,0 +1,366 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+	"go.opentelemetry.io/otel/attribute"
+
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/runner"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/telemetry"
+)
+
+const (
+	// planOutputSize caps how much output of each step is kept for the pane
+	planOutputSize = 64 * 1024
+	// planPaneHeight is how many output lines an expanded step shows at once
+	planPaneHeight = 8
+	// planPanePrefix indents a step's output under its command
+	planPanePrefix = "     │ "
+)
+
+// stepStatus is where a plan step is in its run
+type stepStatus int
+
+const (
+	stepPending stepStatus = iota
+	stepWaiting            // step-by-step mode, waiting for the user to run or skip it
+	stepRunning
+	stepDone
+	stepFailed
+	stepSkipped
+)
+
+// planStep is one command of a plan running inside the TUI
+type planStep struct {
+	command    string
+	executedAs string
+	output     *runner.Tail
+	status     stepStatus
+	exitCode   int
+	err        error
+	started    time.Time
+	elapsed    time.Duration
+	collapsed  bool
+	scroll     int // lines scrolled back from the newest output
+}
+
+// planStepMsg reports that a step finished
+type planStepMsg struct {
+	index int
+	err   error
+}
+
+// planNeedsTerminal reports whether some planned command has to run on the terminal: it asks
+// for a confirmation, a review or a sudo password, or the session is being recorded
+func (m *AppModel) planNeedsTerminal() bool {
+	if m.cfg != nil && m.cfg.Record.Enabled {
+		return true
+	}
+	for _, command := range m.batch {
+		r := m.safety.Analyze(command)
+		if r.Forbidden && m.overridden[command] {
+			r.Forbidden, r.Allowed = false, true
+		}
+		if r.Forbidden || r.Blocked || (!r.Allowed && r.Level >= safety.High) || m.approval.Required(command, r) {
+			return true
+		}
+		if len(m.safety.Inspect(command, m.originalQuery)) > 0 || len(m.repeatEffects(command, r)) > 0 {
+			return true
+		}
+		if m.client.Host() == "" && runner.UsesSudo(command) {
+			return true
+		}
+	}
+	return false
+}
+
+// startPlan runs the planned commands inside the TUI, streaming each one's output under it
+func (m *AppModel) startPlan() (tea.Model, tea.Cmd) {
+	m.planSteps = make([]planStep, len(m.batch))
+	for i, command := range m.batch {
+		m.planSteps[i] = planStep{command: command, output: runner.NewTail(planOutputSize)}
+	}
+	m.planCtx, m.planCancel = context.WithCancel(m.ctx)
+	m.planNext, m.planCursor = 0, 0
+	m.state = StatePlanRunning
+	return m, m.advancePlan()
+}
+
+// advancePlan starts the next step, or waits for the user's go-ahead in step-by-step mode
+func (m *AppModel) advancePlan() tea.Cmd {
+	if m.planNext >= len(m.planSteps) {
+		return nil
+	}
+	m.planCursor = m.planNext
+	if m.stepwise {
+		m.planSteps[m.planNext].status = stepWaiting
+		return nil
+	}
+	return m.runPlanStep()
+}
+
+// runPlanStep runs the next step in the background, writing its output into the step's pane
+func (m *AppModel) runPlanStep() tea.Cmd {
+	i := m.planNext
+	step := &m.planSteps[i]
+	step.status, step.started = stepRunning, time.Now()
+	step.executedAs = m.attributed(step.command)
+	if note := m.backupNote(step.command); note != "" {
+		fmt.Fprintln(step.output, note)
+	}
+
+	opts := m.runOptions()
+	if stdout := m.captureOutput(); stdout != nil {
+		opts = append(opts, runner.WithStdoutTail(stdout))
+	}
+	ctx, command, output := m.planCtx, step.executedAs, step.output
+	remote := m.client.Host() != ""
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		_, span := telemetry.Start(ctx, "runner.exec", attribute.Bool("termi.remote", remote))
+		err := runner.Stream(ctx, command, output, opts...)
+		span.SetAttributes(attribute.Int("process.exit_code", runner.ExitCode(err)))
+		telemetry.End(span, err)
+		return planStepMsg{index: i, err: err}
+	})
+}
+
+func (m *AppModel) handlePlanStep(msg planStepMsg) (tea.Model, tea.Cmd) {
+	step := &m.planSteps[msg.index]
+	step.err, step.exitCode = msg.err, runner.ExitCode(msg.err)
+	step.elapsed = time.Since(step.started)
+	m.client.LearnExecution(step.command, step.exitCode)
+	if msg.err != nil {
+		step.status = stepFailed
+		m.planNext = len(m.planSteps)
+	} else {
+		// Fold finished output away so the running step's pane stays in view
+		step.status, step.collapsed = stepDone, true
+		m.planNext++
+	}
+	if m.planInterrupted {
+		m.planQuit = true
+		return m, tea.Quit
+	}
+	return m, m.advancePlan()
+}
+
+// planRunning reports whether a step is still executing
+func (m *AppModel) planRunning() bool {
+	return m.planNext < len(m.planSteps) && m.planSteps[m.planNext].status == stepRunning
+}
+
+// planFinished reports whether every step has run or been skipped, or the plan stopped at a failure
+func (m *AppModel) planFinished() bool {
+	return m.planNext >= len(m.planSteps)
+}
+
+func (m *AppModel) handlePlanRunKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	step := &m.planSteps[m.planCursor]
+	waiting := !m.planFinished() && m.planSteps[m.planNext].status == stepWaiting
+	switch msg.String() {
+	case "up", "k":
+		m.planCursor = max(m.planCursor-1, 0)
+	case "down", "j":
+		m.planCursor = min(m.planCursor+1, len(m.planSteps)-1)
+	case " ", "tab":
+		step.collapsed = !step.collapsed
+	case "pgup":
+		step.scroll += planPaneHeight
+	case "pgdown":
+		step.scroll = max(step.scroll-planPaneHeight, 0)
+	case "enter", "y":
+		if waiting {
+			return m, m.runPlanStep()
+		}
+		if m.planFinished() {
+			m.planQuit = true
+			return m, tea.Quit
+		}
+	case "n":
+		if waiting {
+			m.planSteps[m.planNext].status = stepSkipped
+			m.planNext++
+			return m, m.advancePlan()
+		}
+	case "q", "esc":
+		if m.planFinished() || waiting {
+			m.planInterrupted = waiting
+			m.planQuit = true
+			return m, tea.Quit
+		}
+	case "ctrl+c":
+		m.planCancel()
+		// Wait for the killed step to report back so it is recorded, unless asked twice
+		if m.planRunning() && !m.planInterrupted {
+			m.planInterrupted = true
+			return m, nil
+		}
+		m.planInterrupted = !m.planFinished()
+		m.planQuit = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// finishPlan records the steps that ran inside the TUI once it has exited, the same way
+// runBatch does for commands run on the terminal
+func (m *AppModel) finishPlan() error {
+	defer m.copyCapturedOutput()
+	var failure error
+	for i, step := range m.planSteps {
+		if step.status != stepDone && step.status != stepFailed {
+			continue
+		}
+		m.executedAs = step.executedAs
+		m.recordShellHistory(step.command)
+		m.record(history.Entry{
+			Command:  step.command,
+			Action:   history.ActionExecuted,
+			ExitCode: &step.exitCode,
+		})
+		if step.status == stepFailed {
+			failure = fmt.Errorf("第 %d 条命令执行失败: %w", i+1, step.err)
+			continue
+		}
+		m.sendAuto(step.command, step.exitCode)
+	}
+	if m.planInterrupted {
+		fmt.Println("执行计划已中断")
+		return nil
+	}
+	return failure
+}
+
+// paneLines splits output into display lines, dropping escape sequences and keeping only
+// the last state of lines redrawn with \r, such as progress bars
+func paneLines(raw string, truncated bool) []string {
+	text := escapeSequence.ReplaceAllString(strings.ReplaceAll(raw, "\r\n", "\n"), "")
+	text = strings.TrimRight(strings.ReplaceAll(text, "\t", "    "), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if truncated && len(lines) > 1 {
+		// The first line was probably cut in half when the tail overflowed
+		lines = lines[1:]
+	}
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if j := strings.LastIndex(line, "\r"); j >= 0 {
+			line = line[j+1:]
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+func (m *AppModel) renderPlanRunView() string {
+	faint := faintStyle()
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render(fmt.Sprintf("📋 执行计划（%d 条命令）:", len(m.planSteps))))
+	s.WriteString("\n\n")
+
+	for i := range m.planSteps {
+		step := &m.planSteps[i]
+		marker := "  "
+		if i == m.planCursor && !m.planQuit {
+			marker = m.titleStyle.Render("› ")
+		}
+		prefix := fmt.Sprintf("%s %d. ", m.stepIcon(step.status), i+1)
+		var suffix string
+		switch step.status {
+		case stepDone:
+			suffix = faint.Render(fmt.Sprintf("  %.1fs", step.elapsed.Seconds()))
+		case stepFailed:
+			suffix = m.errorStyle.Render(fmt.Sprintf("  退出码 %d", step.exitCode))
+		case stepSkipped:
+			suffix = faint.Render("  已跳过")
+		}
+		width := 2 + textWidth(prefix)
+		line := marker + prefix + renderCommand(step.command, m.termWidth()-textWidth(suffix), width, m.itemStyle) + suffix
+		s.WriteString(line + "\n")
+		s.WriteString(m.renderStepOutput(step))
+	}
+
+	s.WriteString("\n")
+	failed := slices.IndexFunc(m.planSteps, func(s planStep) bool { return s.status == stepFailed })
+	switch {
+	case m.planInterrupted:
+		s.WriteString(m.errorStyle.Render("🚫 已中断") + "\n")
+	case failed >= 0:
+		msg := fmt.Sprintf("✗ 第 %d 条命令执行失败", failed+1)
+		if remaining := len(m.planSteps) - failed - 1; remaining > 0 {
+			msg += fmt.Sprintf("，剩余 %d 条命令未执行", remaining)
+		}
+		s.WriteString(m.errorStyle.Render(msg) + "\n")
+	case m.planFinished():
+		s.WriteString(m.successStyle.Render("✅ 执行计划已完成") + "\n")
+	case m.planSteps[m.planNext].status == stepWaiting:
+		s.WriteString(m.titleStyle.Render(fmt.Sprintf("执行第 %d 条命令? Enter/y: 执行, n: 跳过, q: 停止", m.planNext+1)) + "\n")
+	}
+	if m.planQuit {
+		return s.String()
+	}
+
+	keys := "↑/↓: 选择, 空格: 展开/折叠输出, PgUp/PgDn: 滚动输出, "
+	if m.planFinished() {
+		keys += "Enter/q: 退出"
+	} else {
+		keys += "Ctrl+C: 中止"
+	}
+	s.WriteString(faint.Render("\n" + keys + ", ?: 帮助"))
+	return s.String()
+}
+
+// renderStepOutput renders the visible window of a step's output, or a note when it is folded
+func (m *AppModel) renderStepOutput(step *planStep) string {
+	faint := faintStyle()
+	raw := step.output.String()
+	lines := paneLines(raw, len(raw) >= planOutputSize)
+	if len(lines) == 0 {
+		return ""
+	}
+	if step.collapsed {
+		return faint.Render(fmt.Sprintf("     ▸ %d 行输出已折叠", len(lines))) + "\n"
+	}
+
+	step.scroll = min(step.scroll, max(len(lines)-planPaneHeight, 0))
+	end := len(lines) - step.scroll
+	start := max(end-planPaneHeight, 0)
+	var s strings.Builder
+	if start > 0 {
+		s.WriteString(faint.Render(fmt.Sprintf("     ⋮ 上方还有 %d 行", start)) + "\n")
+	}
+	width := m.termWidth() - runewidth.StringWidth(planPanePrefix)
+	for _, line := range lines[start:end] {
+		s.WriteString(faint.Render(planPanePrefix) + runewidth.Truncate(line, width, "…") + "\n")
+	}
+	if step.scroll > 0 {
+		s.WriteString(faint.Render(fmt.Sprintf("     ⋮ 下方还有 %d 行", step.scroll)) + "\n")
+	}
+	return s.String()
+}
+
+func (m *AppModel) stepIcon(status stepStatus) string {
+	switch status {
+	case stepWaiting:
+		return m.titleStyle.Render("?")
+	case stepRunning:
+		return m.spinner.View()
+	case stepDone:
+		return m.successStyle.Render("✓")
+	case stepFailed:
+		return m.errorStyle.Render("✗")
+	case stepSkipped:
+		return faintStyle().Render("-")
+	}
+	return faintStyle().Render("○")
+}
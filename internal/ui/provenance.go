@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"termi.sh/termi/internal/runner"
+	"termi.sh/termi/internal/shellquote"
+)
+
+// shellHistoryEnv is set by the `termi init` shell integration to a file whose
+// lines are added to the interactive shell's history once termi exits
+const shellHistoryEnv = "TERMI_HISTORY_FILE"
+
+// recordShellHistory hands an executed command to the shell integration, prefixed
+// with a no-op `: termi '<query>';` when provenance is enabled so that history
+// searches find it by intent, and ending in the attribution comment it ran with.
+// Remote and multi-line commands are left out: recalling them from local history
+// would run something different from what termi ran.
+func (m *AppModel) recordShellHistory(command string) {
+	path := os.Getenv(shellHistoryEnv)
+	if path == "" || m.client.Host() != "" || strings.Contains(command, "\n") {
+		return
+	}
+
+	line := command
+	if m.executedAs != "" {
+		line = m.executedAs
+	}
+	if m.cfg != nil && m.cfg.Exec.Provenance && m.originalQuery != "" {
+		query := strings.Join(strings.Fields(m.originalQuery), " ")
+		line = ": termi " + shellquote.POSIX.Quote(query) + "; " + line
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		fmt.Printf("写入 shell 历史失败: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		fmt.Printf("写入 shell 历史失败: %v\n", err)
+	}
+}
+
+// attributed appends the `# termi:model=... ts=...` comment to a command about to be run when
+// exec.attribution is enabled. Commands that didn't come from the model are marked with their
+// source instead, and ones the user changed in the editor as edited.
+func (m *AppModel) attributed(command string) string {
+	if m.cfg == nil || !m.cfg.Exec.Attribution {
+		return command
+	}
+
+	a := runner.Attribution{Time: time.Now()}
+	origin := command
+	if m.editedFrom != "" && command == m.selectedCommand {
+		origin, a.Edited = m.editedFrom, true
+	}
+	for _, c := range m.candidates {
+		if c.Text != origin {
+			continue
+		}
+		if slices.Contains(c.Sources, "llm") {
+			a.Model = m.model
+		} else if len(c.Sources) > 0 {
+			a.Source = c.Sources[0]
+		}
+		break
+	}
+
+	// Remote commands run in the login shell over SSH, which is POSIX in practice
+	sh := m.client.Shell()
+	if m.client.Host() != "" {
+		sh = ""
+	}
+	return runner.Attribute(command, sh, a)
+}
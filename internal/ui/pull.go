@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/llm"
+)
+
+// pullBarWidth is the width of the download progress bar in cells
+const pullBarWidth = 30
+
+// pullProgressMsg reports one line of progress from the Ollama server downloading the model
+type pullProgressMsg struct {
+	round    int
+	progress llm.PullProgress
+}
+
+// pulledMsg reports the end of the model download
+type pulledMsg struct {
+	round int
+	err   error
+}
+
+// startPull asks the Ollama server to download the missing model and shows its progress
+func (m *AppModel) startPull() (tea.Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.pullRound++
+	round, updates, cfg := m.pullRound, make(chan tea.Msg), m.cfg
+	m.state = StatePulling
+	m.pull = llm.PullProgress{}
+	m.pullUpdates, m.pullCancel = updates, cancel
+
+	go func() {
+		defer cancel()
+		send := func(msg tea.Msg) {
+			select {
+			case updates <- msg:
+			case <-ctx.Done():
+			}
+		}
+		err := llm.PullModel(ctx, cfg, func(p llm.PullProgress) {
+			send(pullProgressMsg{round: round, progress: p})
+		})
+		send(pulledMsg{round: round, err: err})
+	}()
+	return m, tea.Batch(m.spinner.Tick, waitPull(updates))
+}
+
+// waitPull delivers the next message of the download running in the background
+func waitPull(updates <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+func (m *AppModel) handlePullProgress(msg pullProgressMsg) (tea.Model, tea.Cmd) {
+	if m.state != StatePulling || msg.round != m.pullRound {
+		return m, nil
+	}
+	m.pull = msg.progress
+	return m, waitPull(m.pullUpdates)
+}
+
+// handlePulled retries the query once the model is on the server, or returns to the error
+// screen, where the download can be started again
+func (m *AppModel) handlePulled(msg pulledMsg) (tea.Model, tea.Cmd) {
+	if m.state != StatePulling || msg.round != m.pullRound {
+		return m, nil
+	}
+	m.pullCancel, m.pullUpdates = nil, nil
+	if msg.err != nil {
+		m.state = StateError
+		m.err = fmt.Errorf("下载模型 %s 失败: %w", m.missingModel.Model, msg.err)
+		return m, m.notify("出错")
+	}
+	m.missingModel = nil
+	m.switches = nil
+	m.err = nil
+	return m, tea.Batch(m.notify("模型已下载"), m.startAnalysis())
+}
+
+// stopPull cancels the download; what the server has fetched so far is kept and the
+// next pull resumes from there
+func (m *AppModel) stopPull() {
+	if m.pullCancel != nil {
+		m.pullCancel()
+	}
+	m.pullCancel, m.pullUpdates = nil, nil
+	m.pullRound++
+}
+
+func (m *AppModel) handlePullKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.stopPull()
+		m.state = StateError
+	case "ctrl+c":
+		m.stopPull()
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *AppModel) renderPullView() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("📥 正在下载模型 "+m.missingModel.Model) + "\n\n")
+	status := m.pull.Status
+	if status == "" {
+		status = "正在连接 Ollama 服务..."
+	}
+	s.WriteString(m.spinner.View() + " " + status + "\n")
+	if p := m.pull; p.Total > 0 {
+		done := int(min(p.Completed, p.Total) * pullBarWidth / p.Total)
+		s.WriteString(fmt.Sprintf("\n%s%s %3d%%  %s / %s\n",
+			strings.Repeat("█", done), faintStyle().Render(strings.Repeat("·", pullBarWidth-done)),
+			min(p.Completed, p.Total)*100/p.Total, byteSize(p.Completed), byteSize(p.Total)))
+	}
+	s.WriteString(faintStyle().Render("\n下载在 " + m.missingModel.Endpoint + " 上进行，完成后自动重试 · Esc: 取消下载"))
+	return s.String()
+}
+
+// byteSize formats a download size the way Ollama reports it
+func byteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.0f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.0f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// pullPlain offers to download the missing model in plain mode, printing each new stage of
+// the download; it reports whether the model is now on the server
+func (m *AppModel) pullPlain(missing *llm.ModelNotFoundError) bool {
+	glyph.Printf("⚠ Ollama 服务 %s 上没有模型 %s\n", missing.Endpoint, missing.Model)
+	if !confirm("在该服务上下载这个模型?") {
+		return false
+	}
+	last, percent := "", int64(-1)
+	err := llm.PullModel(m.ctx, m.cfg, func(p llm.PullProgress) {
+		if p.Status != last {
+			last, percent = p.Status, -1
+			fmt.Println(p.Status)
+		}
+		// Without a terminal every update is a new line, so only each tenth is printed
+		if p.Total > 0 && p.Completed*10/p.Total > percent {
+			percent = p.Completed * 10 / p.Total
+			fmt.Printf("  %d%%  %s / %s\n", percent*10, byteSize(p.Completed), byteSize(p.Total))
+		}
+	})
+	if err != nil {
+		glyph.Printf("❌ 下载模型 %s 失败: %v\n", missing.Model, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/shell"
+)
+
+// queuedTask is a task queued with -m and the commands chosen for it
+type queuedTask struct {
+	query    string
+	commands []string
+}
+
+// RunTasks analyses the queued tasks one after another in a single session; each round sees
+// the earlier tasks and the commands chosen for them, and the commands of all tasks are
+// reviewed together as one plan after the last task
+func RunTasks(cfg *config.Config, client *llm.Client, tasks []string) error {
+	m := NewAppModel(cfg, client, tasks[0])
+	m.total = len(tasks)
+	m.pending = tasks[1:]
+	return m.start()
+}
+
+// queueing reports whether this round is one of several tasks queued with -m
+func (m *AppModel) queueing() bool {
+	return m.total > 1
+}
+
+// queuePrompt prefixes the query with the earlier tasks and the commands chosen for them, so
+// the model can build on their results instead of repeating them
+func (m *AppModel) queuePrompt(query string) string {
+	if len(m.done) == 0 {
+		return query
+	}
+	var b strings.Builder
+	b.WriteString("本次会话按顺序完成多项任务，前面的任务已选定以下命令，它们会在当前任务的命令之前依次执行:")
+	for i, t := range m.done {
+		fmt.Fprintf(&b, "\n%d. 任务: %s\n   命令: %s", i+1, t.query, strings.Join(t.commands, " && "))
+	}
+	fmt.Fprintf(&b, "\n\n当前任务: %s", query)
+	return b.String()
+}
+
+// queueCommands records the commands chosen for the current task. Earlier tasks move on to
+// the next one in a new round; after the last task the combined plan is shown for review
+func (m *AppModel) queueCommands(commands []string) (tea.Model, tea.Cmd) {
+	if len(m.pending) > 0 {
+		m.done = append(m.done, queuedTask{query: m.query, commands: commands})
+		m.state = StateQueued
+		return m, tea.Quit
+	}
+	plan, tasks := m.queuedPlan(commands)
+	if shell.Active() {
+		return m.handoff(strings.Join(plan, " && "))
+	}
+	if model, cmd, refused := m.refuseExecution(plan); refused {
+		return model, cmd
+	}
+	m.batch, m.batchTasks = plan, tasks
+	m.state = StatePlan
+	return m, nil
+}
+
+// queuedPlan returns the commands of every task in order followed by the last task's, with
+// the task each command belongs to
+func (m *AppModel) queuedPlan(last []string) (plan, tasks []string) {
+	for _, t := range append(m.done, queuedTask{query: m.query, commands: last}) {
+		for _, c := range t.commands {
+			plan = append(plan, c)
+			tasks = append(tasks, t.query)
+		}
+	}
+	return plan, tasks
+}
+
+// nextTask returns the round for the next queued task; pins carry over like the earlier tasks
+func (m *AppModel) nextTask() *AppModel {
+	n := NewAppModel(m.cfg, m.client, m.pending[0])
+	n.pins = m.pins
+	n.total = m.total
+	n.pending = m.pending[1:]
+	n.done = m.done
+	return n
+}
+
+// queueProgress is the position of the current task, e.g. "任务 2/3"
+func (m *AppModel) queueProgress() string {
+	return fmt.Sprintf("任务 %d/%d", len(m.done)+1, m.total)
+}
+
+// renderQueueBar shows which of the queued tasks is being worked on
+func (m *AppModel) renderQueueBar() string {
+	if !m.queueing() {
+		return ""
+	}
+	text := "🧾 " + m.queueProgress()
+	if len(m.pending) > 0 {
+		text += " · 下一项: " + m.pending[0]
+	} else {
+		text += " · 最后一项，选定后审阅完整计划"
+	}
+	return lipgloss.NewStyle().
+		Foreground(theme.accent).
+		Border(lipgloss.NormalBorder(), false, false, true, false).
+		BorderForeground(theme.muted).
+		Render(text) + "\n"
+}
+
+// queuePlain records the choice in plain mode; after the last task it lists the combined plan
+// and asks once before running it
+func (m *AppModel) queuePlain(command string) error {
+	if len(m.pending) > 0 {
+		m.queueCommands([]string{command})
+		return nil
+	}
+	plan, tasks := m.queuedPlan([]string{command})
+	if shell.Active() {
+		m.handoff(strings.Join(plan, " && "))
+		return nil
+	}
+	glyph.Printf("\n📋 执行计划（%d 条命令）:\n", len(plan))
+	for i, c := range plan {
+		line := fmt.Sprintf("  %d. %s", i+1, c)
+		if r := m.safety.Analyze(c); !r.Allowed && r.Level > safety.Safe {
+			line += fmt.Sprintf("  ⚠ %s: %s", r.Level, strings.Join(r.Reasons, "；"))
+		}
+		glyph.Println(line)
+		fmt.Printf("     %s\n", tasks[i])
+	}
+	if _, _, refused := m.refuseExecution(plan); refused {
+		m.copyPlain(strings.Join(plan, "\n"))
+		return nil
+	}
+	if !confirm("按顺序执行以上命令?") {
+		return errCanceled
+	}
+	m.batch = plan
+	m.state = StateCompleted
+	return nil
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"errors"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/probe"
+	"termi.sh/termi/internal/project"
+	"termi.sh/termi/internal/suggest"
+)
+
+// quickActionsMsg carries the project's quick actions, cached or freshly generated
+type quickActionsMsg struct {
+	actions []project.Action
+	err     error
+}
+
+// errUntrusted the project files of an untrusted directory can't be used for quick actions
+var errUntrusted = errors.New("当前目录未受信任，不会读取项目文件生成快捷操作；可运行 termi trust 信任此目录")
+
+// HasQuickActions reports whether the current directory is a recognized project,
+// in which case termi without a query offers quick actions instead of usage help
+func HasQuickActions() bool {
+	dir, err := os.Getwd()
+	return err == nil && len(project.Detect(dir)) > 0
+}
+
+// loadQuickActions reads the cached actions for the cwd, generating and caching them on first use
+func (m *AppModel) loadQuickActions() tea.Cmd {
+	m.state = StateAnalyzing
+	client, ctx := m.client, m.ctx
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		dir, err := os.Getwd()
+		if err != nil {
+			return quickActionsMsg{err: err}
+		}
+		if !client.Trust().Trusted(dir) {
+			return quickActionsMsg{err: errUntrusted}
+		}
+		kinds := project.Detect(dir)
+		store := project.NewStore(config.ProjectsDir())
+		fingerprint := probe.TakeFingerprint(dir).String()
+		if actions, ok, err := store.Load(dir, kinds, fingerprint); err == nil && ok {
+			return quickActionsMsg{actions: actions}
+		}
+
+		actions, err := client.QuickActions(ctx, project.Describe(dir, kinds))
+		if err != nil {
+			return quickActionsMsg{err: err}
+		}
+		// A failed cache write only costs a regeneration next time
+		_ = store.Save(dir, kinds, fingerprint, actions)
+		return quickActionsMsg{actions: actions}
+	})
+}
+
+func (m *AppModel) handleQuickActions(msg quickActionsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.state = StateError
+		m.err = msg.err
+		if !errors.Is(msg.err, errUntrusted) {
+			m.err = m.formatLLMError(msg.err)
+		}
+		return m, tea.Quit
+	}
+
+	m.candidates = m.candidates[:0]
+	for _, a := range msg.actions {
+		m.candidates = append(m.candidates, suggest.Suggestion{Text: a.Command, Sources: []string{"project"}, Group: a.Title})
+	}
+	m.candidates = suggest.Merge(m.candidates)
+	m.state = StateSelecting
+	return m, m.notify("快捷操作已就绪")
+}
@@ -0,0 +1,103 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/llm"
+)
+
+// rationaleMsg carries the LLM's reasons for choosing a command over the other candidates
+type rationaleMsg struct {
+	command   string
+	rationale *llm.Rationale
+	err       error
+}
+
+// openRationale shows why the selected command suits the query and how the candidates
+// compare, asking the LLM on first use
+func (m *AppModel) openRationale() (tea.Model, tea.Cmd) {
+	if m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	command := m.candidates[m.cursor].Text
+	m.state = StateRationale
+	if _, ok := m.rationales[command]; ok {
+		return m, nil
+	}
+
+	candidates := make([]string, len(m.candidates))
+	for i, c := range m.candidates {
+		candidates[i] = c.Text
+	}
+	client, ctx, query := m.client, m.ctx, m.originalQuery
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		r, err := client.ExplainChoice(ctx, query, command, candidates)
+		return rationaleMsg{command: command, rationale: r, err: err}
+	})
+}
+
+func (m *AppModel) handleRationale(msg rationaleMsg) (tea.Model, tea.Cmd) {
+	m.rationales[msg.command] = msg.rationale
+	if msg.err != nil {
+		m.rationaleErr = m.formatLLMError(msg.err)
+	}
+	return m, nil
+}
+
+func (m *AppModel) handleRationaleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		return m.executeCommand()
+	case "e":
+		return m.openEdit()
+	case "esc", "q", "w":
+		m.state = StateSelecting
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m *AppModel) renderRationaleView() string {
+	command := m.candidates[m.cursor].Text
+	faint := faintStyle()
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("🤔 为什么是这条命令:") + "\n\n")
+	s.WriteString(indent(command) + "\n\n")
+
+	r, ok := m.rationales[command]
+	switch {
+	case !ok:
+		s.WriteString(m.spinner.View() + " 正在比较各候选命令...\n")
+	case r == nil:
+		s.WriteString(m.errorStyle.Render("无法获取选择理由: "+m.rationaleErr.Error()) + "\n")
+	default:
+		s.WriteString(r.Why + "\n")
+		if len(m.candidates) > 1 {
+			s.WriteString("\n" + m.titleStyle.Render("取舍对比:") + "\n")
+		}
+		for i, t := range r.Tradeoffs {
+			if i >= len(m.candidates) {
+				break
+			}
+			line := "  " + m.candidates[i].Text
+			if i == m.cursor {
+				line = m.selectedStyle.Render("➜ " + m.candidates[i].Text)
+			}
+			s.WriteString(line + "\n")
+			for _, aspect := range []struct{ name, note string }{
+				{"可移植性", t.Portability}, {"速度", t.Speed}, {"安全性", t.Safety},
+			} {
+				if aspect.note != "" {
+					s.WriteString(faint.Render("    "+aspect.name+": "+aspect.note) + "\n")
+				}
+			}
+		}
+	}
+
+	s.WriteString(faint.Render("\nEnter: 执行, e: 编辑, Esc/q: 返回, ?: 帮助"))
+	return s.String()
+}
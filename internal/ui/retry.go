@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/failure"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/safety"
+)
+
+// stderrTailSize is how much of a command's stderr is kept for failure analysis
+const stderrTailSize = 4096
+
+// interruptedExitCode is the status of a command the user stopped with Ctrl+C
+const interruptedExitCode = 130
+
+// offerRepair classifies a failed command locally and, if the user agrees, returns the
+// model for the next repair round: a reprompt targeted at the failure class that lists
+// the earlier failed attempts, with the captured stderr sent along (redacted like any
+// other command output). Rounds continue until a command succeeds or the user declines.
+func (m *AppModel) offerRepair(command string, exitCode int) (*AppModel, bool) {
+	if exitCode < 0 || exitCode == interruptedExitCode {
+		return nil, false
+	}
+	stderr := m.stderrText()
+	class := failure.Classify(command, exitCode, stderr)
+	question := fmt.Sprintf("\n命令执行失败（%s，退出码 %d），让 AI 根据失败原因修复?", class, exitCode)
+	if len(m.repairs) > 0 {
+		question = fmt.Sprintf("\n第 %d 次修复后仍然失败（%s，退出码 %d），继续让 AI 修复?", len(m.repairs), class, exitCode)
+	}
+	effects := safety.SideEffects(command)
+	if len(effects) > 0 {
+		question = fmt.Sprintf("\n⚠ 命令包含非幂等操作（%s），失败前可能已经部分生效，重试前请先确认当前状态%s", strings.Join(effects, "；"), question)
+	}
+	if !confirm(question) {
+		return nil, false
+	}
+
+	client := m.client
+	if stderr != "" {
+		client = client.With(llm.WithTerminalOutput(stderr))
+	}
+	attempts := append(m.repairs[:len(m.repairs):len(m.repairs)], failure.Attempt{Command: command, ExitCode: exitCode, Class: class, SideEffects: effects})
+	next := m.respawn(client, failure.Reprompt(m.originalQuery, attempts))
+	next.repairs = attempts
+	return next, true
+}
+
+func (m *AppModel) stderrText() string {
+	if m.stderr == nil {
+		return ""
+	}
+	return strings.TrimSpace(m.stderr.String())
+}
+
+// repairStatus describes the failure the current repair round is fixing, "" outside the loop
+func (m *AppModel) repairStatus() string {
+	if len(m.repairs) == 0 {
+		return ""
+	}
+	last := m.repairs[len(m.repairs)-1]
+	return fmt.Sprintf("第 %d 轮修复: %s（%s，退出码 %d）", len(m.repairs), last.Command, last.Class, last.ExitCode)
+}
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/approval"
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/preview"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/suggest"
+)
+
+// loadSafety creates the safety analyzer; invalid patterns were already rejected by Validate
+func loadSafety(m *AppModel) *safety.Analyzer {
+	if m.cfg == nil {
+		return nil
+	}
+	a, err := safety.New(m.cfg.Safety)
+	if err != nil {
+		return nil
+	}
+	return a
+}
+
+// loadApproval creates the change approval gate; the config was already checked by Validate
+func loadApproval(m *AppModel) *approval.Gate {
+	if m.cfg == nil {
+		return nil
+	}
+	g, err := approval.New(m.cfg.Safety.Approval, config.ApprovalsPath())
+	if err != nil {
+		return nil
+	}
+	return g
+}
+
+// safetyBadge renders the local risk assessment of a candidate, falling back to the model's own
+func (m *AppModel) safetyBadge(item suggest.Suggestion) string {
+	r := m.safety.Analyze(item.Text)
+	switch {
+	case r.Forbidden && m.overridden[item.Text]:
+		return lipgloss.NewStyle().Foreground(theme.danger).Bold(true).Render("⛔已解除禁止")
+	case r.Forbidden:
+		return lipgloss.NewStyle().Foreground(theme.danger).Bold(true).Render("⛔已禁止")
+	case r.Blocked:
+		return lipgloss.NewStyle().Foreground(theme.danger).Bold(true).Render("⛔禁止执行")
+	case len(m.safety.Inspect(item.Text, m.originalQuery)) > 0:
+		return lipgloss.NewStyle().Foreground(theme.danger).Bold(true).Render("🛡疑似注入")
+	case r.Allowed:
+		return ""
+	case r.Level >= safety.High:
+		return lipgloss.NewStyle().Foreground(theme.danger).Bold(true).Render("▲" + r.Level.String())
+	case r.Level == safety.Caution:
+		return lipgloss.NewStyle().Foreground(theme.warning).Render("●注意")
+	}
+	return riskBadge(item.Risk)
+}
+
+// renderSafetyReasons explains why the highlighted candidate was flagged
+func (m *AppModel) renderSafetyReasons(command string) string {
+	return m.renderInjection(command) + m.renderRisk(command)
+}
+
+// renderInjection warns that the command looks like the product of a prompt injection
+func (m *AppModel) renderInjection(command string) string {
+	reasons := m.safety.Inspect(command, m.originalQuery)
+	if len(reasons) == 0 {
+		return ""
+	}
+	text := "\n🛡 疑似提示词注入: " + strings.Join(reasons, "；") + "，执行前需要逐字审查并输入确认码"
+	return lipgloss.NewStyle().Foreground(theme.danger).Render(text) + "\n"
+}
+
+// renderRisk explains which risk rules the command matched
+func (m *AppModel) renderRisk(command string) string {
+	r := m.safety.Analyze(command)
+	if r.Allowed || len(r.Reasons) == 0 {
+		return ""
+	}
+	color := theme.warning
+	if r.Level >= safety.High {
+		color = theme.danger
+	}
+	text := "\n⚠ " + r.Level.String() + ": " + strings.Join(r.Reasons, "；")
+	expert := m.expertise() == config.Expert
+	switch {
+	case r.Forbidden && m.overridden[command]:
+		text += "，已输入覆盖码"
+	case r.Forbidden && r.Overridable():
+		text += "，输入覆盖码后才能执行、复制或编辑"
+	case r.Forbidden:
+		text += "，不能执行、复制或编辑"
+	case r.Blocked:
+		text += "，只能复制"
+	case m.copyOnlyMode() || expert:
+	case r.Level >= safety.High:
+		text += "，执行前需要输入确认"
+		if m.cfg != nil && m.cfg.Safety.Preview && m.client.Host() == "" {
+			text += "（会先在沙箱中预演）"
+		}
+	}
+	if m.expertise() == config.Beginner && !r.Forbidden && !r.Blocked {
+		text += "\n  " + levelAdvice[r.Level]
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(text) + "\n"
+}
+
+// levelAdvice explains to beginners what a risk level means for them and what to check first
+var levelAdvice = map[safety.Level]string{
+	safety.Caution:  "这类操作会改变系统或其他进程的状态，执行前确认目标是否正确；按 x 可查看逐段解释",
+	safety.High:     "这类操作可能删除数据或影响整个系统，通常无法撤销；不确定时先按 x 查看逐段解释，或按 c 复制后自己检查",
+	safety.Critical: "这类操作可能让系统或磁盘上的数据无法恢复，除非完全清楚它会做什么，否则不要执行",
+}
+
+// expertise returns the user's configured command-line expertise
+func (m *AppModel) expertise() config.Expertise {
+	if m.cfg == nil {
+		return ""
+	}
+	return m.cfg.Expertise
+}
+
+// copyOnlyMode reports whether config forbids executing any command
+func (m *AppModel) copyOnlyMode() bool {
+	return m.cfg != nil && m.cfg.Safety.CopyOnly
+}
+
+// repeatEffects returns the side effects to confirm before running the command again: it is
+// re-run from history or retried in the repair loop and is not idempotent. Commands that
+// already ask for yes, or are allowlisted, are not asked about twice
+func (m *AppModel) repeatEffects(command string, r safety.Result) []string {
+	if !m.rerun && len(m.repairs) == 0 {
+		return nil
+	}
+	if r.Allowed || r.Level >= safety.High {
+		return nil
+	}
+	return safety.SideEffects(command)
+}
+
+// refuseExecution copies the commands instead of running them, in copy-only mode or
+// when any of them is blocklisted; it reports whether execution was refused
+func (m *AppModel) refuseExecution(commands []string) (tea.Model, tea.Cmd, bool) {
+	reason := ""
+	if m.copyOnlyMode() {
+		reason = "已开启仅复制模式 (safety.copy_only)"
+	}
+	for _, c := range commands {
+		if reason == "" && m.safety.Analyze(c).Blocked {
+			reason = "命令命中 safety.blocklist"
+		}
+	}
+	if reason == "" {
+		return m, nil, false
+	}
+	m.refused = reason
+	text := strings.Join(commands, "\n")
+	model, cmd := m.copyText(text, text)
+	return model, cmd, true
+}
+
+// previewImpact runs a high-risk local command in the sandbox when enabled, printing which files
+// and endpoints it would touch so the confirmation that follows is an informed one
+func (m *AppModel) previewImpact(command string, r safety.Result) {
+	if m.cfg == nil || !m.cfg.Safety.Preview || r.Blocked || r.Allowed || r.Level < safety.High || m.client.Host() != "" {
+		return
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	glyph.Println("🔬 正在沙箱中预演命令...")
+	report, err := preview.Run(m.ctx, command, dir)
+	if err != nil {
+		fmt.Printf("无法预演: %v\n\n", err)
+		return
+	}
+	glyph.Println(report.Render())
+}
@@ -0,0 +1,423 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/llm"
+)
+
+// pingTimeout bounds the wizard's connection test
+const pingTimeout = 20 * time.Second
+
+// setupField is one value the wizard asks for, stored at llm.<section>.<key>
+type setupField struct {
+	key      string
+	label    string
+	hint     string
+	secret   bool
+	optional bool
+	value    string   // default when the config has none
+	models   []string // offered as a list, with a last entry for typing another name
+}
+
+// setupProvider is one entry of the wizard's provider list
+type setupProvider struct {
+	id      config.LLMProvider
+	section string // JSON key of the provider's section under llm
+	name    string
+	fields  []setupField
+}
+
+var setupProviders = []setupProvider{
+	{config.ProviderOpenAI, "openai", "OpenAI", []setupField{
+		{key: "api_key", label: "API Key", secret: true},
+		{key: "model", label: "模型", models: []string{"gpt-4o-mini", "gpt-4o", "gpt-4.1-mini", "gpt-4.1"}},
+		{key: "base_url", label: "Base URL", hint: "留空使用官方地址", optional: true},
+	}},
+	{config.ProviderClaude, "claude", "Anthropic Claude", []setupField{
+		{key: "api_key", label: "API Key", secret: true},
+		{key: "model", label: "模型", models: []string{"claude-3-5-haiku-latest", "claude-3-5-sonnet-latest", "claude-3-haiku-20240307"}},
+	}},
+	{config.ProviderGemini, "gemini", "Google Gemini", []setupField{
+		{key: "api_key", label: "API Key", secret: true},
+		{key: "model", label: "模型", models: []string{"gemini-2.0-flash", "gemini-1.5-flash", "gemini-1.5-pro"}},
+	}},
+	{config.ProviderAzureOpenAI, "azure_openai", "Azure OpenAI", []setupField{
+		{key: "api_key", label: "API Key", secret: true},
+		{key: "base_url", label: "Endpoint", hint: "例如 https://<资源名>.openai.azure.com/"},
+		{key: "deployment_id", label: "Deployment ID"},
+		{key: "api_version", label: "API Version", value: "2023-12-01-preview"},
+	}},
+	{config.ProviderOllama, "ollama", "Ollama（本地）", []setupField{
+		{key: "base_url", label: "服务地址", value: "http://localhost:11434"},
+		{key: "model", label: "模型", value: "llama3.2"},
+	}},
+	{config.ProviderLlamaCPP, "llama_cpp", "Llama.cpp（本地）", []setupField{
+		{key: "base_url", label: "服务地址", value: "http://localhost:8080"},
+		{key: "model", label: "模型", hint: "留空使用服务加载的模型", optional: true},
+	}},
+	{config.ProviderOpenAICompatible, "openai_compatible", "OpenAI 兼容端点（LM Studio、vLLM 等）", []setupField{
+		{key: "base_url", label: "Base URL", value: "http://localhost:1234/v1"},
+		{key: "api_key", label: "API Key", hint: "不需要鉴权时留空", secret: true, optional: true},
+		{key: "model", label: "模型"},
+	}},
+	{config.ProviderCommand, "command", "外部命令（自建或内部模型）", []setupField{
+		{key: "path", label: "程序路径", hint: "从标准输入读取请求 JSON，向标准输出写入 command/ask JSON"},
+		{key: "model", label: "模型", hint: "原样传给程序，不需要时留空", optional: true},
+	}},
+}
+
+type setupStep int
+
+const (
+	setupChooseProvider setupStep = iota
+	setupEnterField
+	setupChooseModel
+	setupTesting
+	setupTested
+)
+
+// pingMsg carries the result of the connection test
+type pingMsg struct{ err error }
+
+// setupModel is the `termi config init` wizard. It edits a copy of the existing config, so
+// sections other than the chosen provider are kept when it is saved
+type setupModel struct {
+	cfg      *config.Config
+	step     setupStep
+	cursor   int // provider list, then model list
+	provider setupProvider
+	field    int
+	input    textinput.Model
+	spinner  spinner.Model
+	err      error // invalid input or failed connection test
+	saved    bool
+	canceled bool
+
+	titleStyle    lipgloss.Style
+	selectedStyle lipgloss.Style
+	errorStyle    lipgloss.Style
+	successStyle  lipgloss.Style
+}
+
+// RunSetup walks through choosing a provider, entering its key and model and testing the
+// connection, then writes the result to the config file. cfg is the existing config file,
+// or nil to start from an empty one
+func RunSetup(cfg *config.Config) error {
+	if !interactiveTerminal() {
+		return errors.New("配置向导需要在终端中运行，也可以使用 termi config set 逐项设置")
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	applyTheme(cfg)
+	input := textinput.New()
+	input.CharLimit = 512
+	sp := spinner.New()
+	sp.Spinner = spinnerKind()
+
+	m := &setupModel{
+		cfg:           cfg,
+		input:         input,
+		spinner:       sp,
+		titleStyle:    lipgloss.NewStyle().Bold(true).Foreground(theme.title),
+		selectedStyle: lipgloss.NewStyle().Foreground(theme.selected).Bold(true),
+		errorStyle:    lipgloss.NewStyle().Foreground(theme.danger),
+		successStyle:  lipgloss.NewStyle().Foreground(theme.success),
+	}
+	for i, p := range setupProviders {
+		if p.id == cfg.LLM.Provider {
+			m.cursor = i
+		}
+	}
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		return fmt.Errorf("界面运行出错: %w", err)
+	}
+	switch {
+	case m.canceled:
+		fmt.Println("操作已取消，配置未修改")
+	case m.saved:
+		glyph.Printf("✅ 已保存到 %s\n", config.Path())
+	}
+	return nil
+}
+
+func (m *setupModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m *setupModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			m.canceled = true
+			return m, tea.Quit
+		}
+		return m.handleKey(msg)
+	case pingMsg:
+		m.step = setupTested
+		m.err = msg.err
+		return m, nil
+	case spinner.TickMsg:
+		if m.step != setupTesting {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+func (m *setupModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch m.step {
+	case setupChooseProvider:
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = min(len(setupProviders)-1, m.cursor+1)
+		case "enter":
+			m.provider = setupProviders[m.cursor]
+			m.err = nil
+			return m, m.openField(0)
+		case "esc", "q":
+			m.canceled = true
+			return m, tea.Quit
+		}
+	case setupChooseModel:
+		models := m.provider.fields[m.field].models
+		switch msg.String() {
+		case "up", "k":
+			m.cursor = max(0, m.cursor-1)
+		case "down", "j":
+			m.cursor = min(len(models), m.cursor+1)
+		case "enter":
+			if m.cursor == len(models) {
+				// The last entry types a model that is not listed
+				m.step = setupEnterField
+				m.input.SetValue("")
+				return m, m.input.Focus()
+			}
+			return m, m.commit(models[m.cursor])
+		case "esc":
+			return m, m.back()
+		}
+	case setupEnterField:
+		switch msg.Type {
+		case tea.KeyEnter:
+			return m, m.commit(strings.TrimSpace(m.input.Value()))
+		case tea.KeyEsc:
+			return m, m.back()
+		}
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	case setupTested:
+		switch msg.String() {
+		case "enter", "y", "s":
+			// s saves even though the test failed, e.g. for a server that is not running yet
+			if m.err != nil && msg.String() != "s" {
+				return m, nil
+			}
+			if err := m.cfg.SaveConfig(); err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.saved = true
+			return m, tea.Quit
+		case "r":
+			return m, m.test()
+		case "e", "esc":
+			m.err = nil
+			return m, m.openField(0)
+		case "q":
+			m.canceled = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+// openField asks for the i-th field of the chosen provider, prefilled with the current value
+func (m *setupModel) openField(i int) tea.Cmd {
+	m.field = i
+	f := m.provider.fields[i]
+	current := m.current(f)
+	if f.models != nil {
+		m.step = setupChooseModel
+		m.cursor = 0
+		for j, name := range f.models {
+			if name == current {
+				m.cursor = j
+			}
+		}
+		return nil
+	}
+
+	m.step = setupEnterField
+	m.input.Reset()
+	m.input.EchoMode = textinput.EchoNormal
+	m.input.Placeholder = f.hint
+	if f.secret {
+		// Never show a saved key; leaving the field empty keeps it
+		m.input.EchoMode = textinput.EchoPassword
+		m.input.EchoCharacter = '•'
+		if current != "" {
+			m.input.Placeholder = "留空保留已保存的 " + config.Mask(current)
+		}
+	} else {
+		m.input.SetValue(current)
+		m.input.CursorEnd()
+	}
+	return m.input.Focus()
+}
+
+// current returns the field's value in the config, or its default
+func (m *setupModel) current(f setupField) string {
+	if v, err := m.cfg.Get("llm." + m.provider.section + "." + f.key); err == nil {
+		if s, ok := v.(string); ok && s != "" {
+			return s
+		}
+	}
+	return f.value
+}
+
+// commit stores the value of the current field and moves on; after the last field the
+// provider becomes the primary one and the connection is tested
+func (m *setupModel) commit(value string) tea.Cmd {
+	f := m.provider.fields[m.field]
+	if value == "" && f.secret {
+		value = m.current(f)
+	}
+	if value == "" && !f.optional {
+		m.err = fmt.Errorf("%s 不能为空", f.label)
+		return nil
+	}
+	if err := m.cfg.Set("llm."+m.provider.section+"."+f.key, value); err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	if m.field+1 < len(m.provider.fields) {
+		return m.openField(m.field + 1)
+	}
+
+	m.input.Blur()
+	m.cfg.LLM.Provider = m.provider.id
+	if err := m.cfg.LLM.Validate(); err != nil {
+		cmd := m.openField(0)
+		m.err = err
+		return cmd
+	}
+	return m.test()
+}
+
+// back returns to the previous field, or to the provider list from the first one
+func (m *setupModel) back() tea.Cmd {
+	m.err = nil
+	if m.field == 0 {
+		m.step = setupChooseProvider
+		for i, p := range setupProviders {
+			if p.id == m.provider.id {
+				m.cursor = i
+			}
+		}
+		m.input.Blur()
+		return nil
+	}
+	return m.openField(m.field - 1)
+}
+
+// test sends a tiny request to the configured provider
+func (m *setupModel) test() tea.Cmd {
+	m.step = setupTesting
+	m.err = nil
+	cfg, err := m.cfg.ExpandEnv()
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		if err != nil {
+			return pingMsg{err: err}
+		}
+		client, err := llm.NewClient(cfg)
+		if err != nil {
+			return pingMsg{err: err}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		defer cancel()
+		return pingMsg{err: client.Ping(ctx)}
+	})
+}
+
+func (m *setupModel) View() string {
+	return glyph.Text(m.view())
+}
+
+func (m *setupModel) view() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("🛠  Termi 配置向导") + "\n\n")
+	faint := faintStyle()
+
+	switch m.step {
+	case setupChooseProvider:
+		s.WriteString("选择 LLM 提供商:\n\n")
+		for i, p := range setupProviders {
+			if i == m.cursor {
+				s.WriteString(m.selectedStyle.Render("▶ "+p.name) + "\n")
+			} else {
+				s.WriteString("  " + p.name + "\n")
+			}
+		}
+		s.WriteString(m.renderError())
+		s.WriteString(faint.Render("\n↑/↓: 选择, Enter: 确认, Esc/q: 退出"))
+	case setupChooseModel:
+		f := m.provider.fields[m.field]
+		s.WriteString(fmt.Sprintf("%s · 选择%s:\n\n", m.provider.name, f.label))
+		for i, name := range append(f.models, "其他（手动输入）") {
+			if i == m.cursor {
+				s.WriteString(m.selectedStyle.Render("▶ "+name) + "\n")
+			} else {
+				s.WriteString("  " + name + "\n")
+			}
+		}
+		s.WriteString(m.renderError())
+		s.WriteString(faint.Render("\n↑/↓: 选择, Enter: 确认, Esc: 返回上一步"))
+	case setupEnterField:
+		f := m.provider.fields[m.field]
+		s.WriteString(fmt.Sprintf("%s · %s", m.provider.name, f.label))
+		if f.optional {
+			s.WriteString(faint.Render("（可选）"))
+		}
+		s.WriteString(":\n\n" + m.input.View() + "\n")
+		s.WriteString(m.renderError())
+		s.WriteString(faint.Render("\nEnter: 下一步, Esc: 返回上一步, Ctrl+C: 退出"))
+	case setupTesting:
+		s.WriteString(m.spinner.View() + " 正在测试 " + m.provider.name + " 的连接...\n")
+	case setupTested:
+		if m.err == nil {
+			s.WriteString(m.successStyle.Render("✅ 连接成功") + "\n")
+			s.WriteString(faint.Render("\nEnter: 保存到 "+config.Path()+", e: 重新填写, q: 放弃"))
+			break
+		}
+		s.WriteString(m.errorStyle.Render("❌ 连接失败: "+m.err.Error()) + "\n")
+		s.WriteString(faint.Render("\nr: 重试, e: 重新填写, s: 仍然保存, q: 放弃"))
+	}
+	return s.String()
+}
+
+func (m *setupModel) renderError() string {
+	if m.err == nil {
+		return ""
+	}
+	return "\n" + m.errorStyle.Render(m.err.Error()) + "\n"
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/share"
+	"termi.sh/termi/internal/suggest"
+)
+
+// sharePreviewLines caps how much of the transcript the confirmation screen shows
+const sharePreviewLines = 20
+
+// sharedMsg reports the result of publishing the session transcript
+type sharedMsg struct {
+	url string
+	err error
+}
+
+// loadShare creates the paste service declared in config; invalid ones were already rejected by Validate
+func loadShare(m *AppModel) share.Publisher {
+	if m.cfg == nil {
+		return nil
+	}
+	p, err := share.New(m.cfg.Share)
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+// transcript collects the query, the answered questions, the selected command and whatever
+// explanations were already fetched for it; nothing is requested from the LLM just to share
+func (m *AppModel) transcript(item suggest.Suggestion) share.Transcript {
+	t := share.Transcript{
+		Time:        time.Now(),
+		Query:       m.originalQuery,
+		Command:     item.Text,
+		Description: item.Description,
+		Notes:       item.Notes,
+		Host:        m.client.Host(),
+	}
+	for _, e := range m.contextHistory {
+		if !e.timedOut {
+			t.Exchanges = append(t.Exchanges, share.Exchange{Question: e.question, Answer: e.answer})
+		}
+	}
+	for _, l := range m.breakdowns[item.Text] {
+		t.Steps = append(t.Steps, share.Step{Code: l.Code, Note: l.Note})
+	}
+	if r := m.rationales[item.Text]; r != nil {
+		t.Why = r.Why
+	}
+	return t
+}
+
+// openShare shows the redacted transcript for the selected command; nothing leaves the
+// machine until the user confirms
+func (m *AppModel) openShare() (tea.Model, tea.Cmd) {
+	if m.share == nil || m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	if model, cmd, held := m.guardForbidden(m.candidates[m.cursor].Text, m.openShare); held {
+		return model, cmd
+	}
+	t := m.transcript(m.candidates[m.cursor])
+	m.shareTitle = m.client.Redact(t.Title())
+	m.shareText = m.client.Redact(t.Markdown())
+	m.state = StateShare
+	return m, nil
+}
+
+func (m *AppModel) handleShareKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "y":
+		return m.publish()
+	case "esc", "q", "n":
+		m.state = StateSelecting
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// publish uploads the transcript in the background
+func (m *AppModel) publish() (tea.Model, tea.Cmd) {
+	m.state = StateSharing
+	p, ctx, title, text := m.share, m.ctx, m.shareTitle, m.shareText
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		url, err := p.Publish(ctx, title, text)
+		return sharedMsg{url: url, err: err}
+	})
+}
+
+func (m *AppModel) handleShared(msg sharedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.state = StateError
+		m.err = fmt.Errorf("分享到%s失败: %w", m.share.Name(), msg.err)
+		return m, nil
+	}
+	m.sharedURL = msg.url
+	m.shareCopyErr = m.clipboard.Copy(msg.url)
+	m.state = StateShared
+	return m, tea.Quit
+}
+
+func (m *AppModel) renderShareView() string {
+	var s strings.Builder
+
+	s.WriteString(m.titleStyle.Render("🔗 分享到" + m.share.Name() + ":"))
+	s.WriteString("\n\n")
+
+	lines := strings.Split(strings.TrimRight(m.shareText, "\n"), "\n")
+	if len(lines) > sharePreviewLines {
+		lines = append(lines[:sharePreviewLines], fmt.Sprintf("... 另有 %d 行", len(lines)-sharePreviewLines))
+	}
+	s.WriteString(faintStyle().Render(strings.Join(lines, "\n")))
+	s.WriteString("\n")
+
+	s.WriteString(faintStyle().Render("\n以上内容已按脱敏规则处理，发布后持有链接的人都能查看。Enter/y: 发布并复制链接, Esc/n: 返回"))
+	return s.String()
+}
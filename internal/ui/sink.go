@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/sink"
+)
+
+// sentMsg reports the result of sending a command to a sink
+type sentMsg struct {
+	name string
+	err  error
+}
+
+// loadSinks creates the sinks declared in config; invalid ones were already rejected by Validate
+func loadSinks(m *AppModel) []sink.Sink {
+	if m.cfg == nil {
+		return nil
+	}
+	sinks, err := sink.Load(m.cfg.Sinks)
+	if err != nil {
+		return nil
+	}
+	return sinks
+}
+
+// sinkEntry builds the payload for the given command
+func (m *AppModel) sinkEntry(command string, exitCode *int) sink.Entry {
+	return sink.Entry{
+		Time:     time.Now(),
+		Query:    m.originalQuery,
+		Command:  command,
+		Host:     m.client.Host(),
+		ExitCode: exitCode,
+	}
+}
+
+func (m *AppModel) openSinkMenu() (tea.Model, tea.Cmd) {
+	if len(m.sinks) == 0 || m.cursor >= len(m.candidates) {
+		return m, nil
+	}
+	if model, cmd, held := m.guardForbidden(m.candidates[m.cursor].Text, m.openSinkMenu); held {
+		return model, cmd
+	}
+	m.sinkCursor = 0
+	m.state = StateSinkMenu
+	return m, nil
+}
+
+func (m *AppModel) handleSinkMenuKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "up", "k":
+		if m.sinkCursor > 0 {
+			m.sinkCursor--
+		}
+	case "down", "j":
+		if m.sinkCursor < len(m.sinks)-1 {
+			m.sinkCursor++
+		}
+	case "enter":
+		return m.sendToSink()
+	case "esc", "q":
+		m.state = StateSelecting
+	case "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(m.sinks) {
+			m.sinkCursor = int(key[0] - '1')
+			return m.sendToSink()
+		}
+	}
+	return m, nil
+}
+
+// sendToSink sends the selected candidate to the highlighted sink in the background
+func (m *AppModel) sendToSink() (tea.Model, tea.Cmd) {
+	target := m.sinks[m.sinkCursor]
+	m.sentCommand = m.candidates[m.cursor].Text
+	entry := m.sinkEntry(m.sentCommand, nil)
+	m.state = StateSending
+	ctx := m.ctx
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return sentMsg{name: target.Name(), err: target.Send(ctx, entry)}
+	})
+}
+
+func (m *AppModel) handleSent(msg sentMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.state = StateError
+		m.err = msg.err
+		return m, nil
+	}
+	m.sentTo = msg.name
+	m.state = StateSent
+	return m, tea.Quit
+}
+
+// sendAuto forwards an executed command to every sink marked auto
+func (m *AppModel) sendAuto(command string, exitCode int) {
+	if m.cfg == nil {
+		return
+	}
+	entry := m.sinkEntry(command, &exitCode)
+	for i, s := range m.sinks {
+		if !m.cfg.Sinks[i].Auto {
+			continue
+		}
+		if err := s.Send(m.ctx, entry); err != nil {
+			glyph.Printf("⚠ %v\n", err)
+			continue
+		}
+		glyph.Printf("📤 已发送到 %s\n", s.Name())
+	}
+}
+
+func (m *AppModel) renderSinkMenuView() string {
+	var s strings.Builder
+
+	s.WriteString(m.titleStyle.Render("📤 发送到:"))
+	s.WriteString("\n\n")
+	s.WriteString(faintStyle().Render(m.candidates[m.cursor].Text))
+	s.WriteString("\n\n")
+
+	for i, target := range m.sinks {
+		line := fmt.Sprintf("%d. %s (%s)", i+1, target.Name(), m.cfg.Sinks[i].Type)
+		if i == m.sinkCursor {
+			s.WriteString(m.selectedStyle.Render("➜ " + line))
+		} else {
+			s.WriteString("  " + m.itemStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+
+	s.WriteString(faintStyle().Render("\n↑/↓ 或数字: 选择, Enter: 发送, Esc: 返回, ?: 帮助"))
+	return s.String()
+}
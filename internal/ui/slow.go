@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/history"
+	"termi.sh/termi/internal/suggest"
+)
+
+const (
+	// offlineCandidateLimit caps how many past commands are offered as offline suggestions
+	offlineCandidateLimit = 5
+	// offlineCandidateScore is looser than historyCandidateScore since nothing better is available
+	offlineCandidateScore = 0.3
+)
+
+// softTimeoutMsg fires when an analysis has been running longer than the soft deadline
+type softTimeoutMsg struct {
+	round int
+}
+
+// startAnalysis sends the current query to the LLM and arms the soft deadline
+func (m *AppModel) startAnalysis() tea.Cmd {
+	m.state = StateAnalyzing
+	m.slow = false
+	m.offlineEmpty = false
+	return tea.Batch(m.spinner.Tick, m.analyzeLLMCmd(), m.softTimeoutCmd())
+}
+
+// softTimeoutCmd starts the soft deadline for the current analysis, if enabled
+func (m *AppModel) softTimeoutCmd() tea.Cmd {
+	if m.cfg == nil || m.cfg.SoftTimeoutSeconds() == 0 {
+		return nil
+	}
+	round := m.analyzeRound
+	return tea.Tick(time.Duration(m.cfg.SoftTimeoutSeconds())*time.Second, func(time.Time) tea.Msg {
+		return softTimeoutMsg{round: round}
+	})
+}
+
+func (m *AppModel) handleSoftTimeout(msg softTimeoutMsg) (tea.Model, tea.Cmd) {
+	if m.state != StateAnalyzing || msg.round != m.analyzeRound {
+		return m, nil
+	}
+	m.slow = true
+	return m, m.notify("响应较慢")
+}
+
+// handleSlowKey handles the interim options shown once the soft deadline passed
+func (m *AppModel) handleSlowKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "w":
+		m.slow = false
+		return m, nil
+	case "f":
+		if fallback := m.client.Fallback(); fallback != nil {
+			m.cancelAnalysis()
+			m.client = fallback
+			return m, m.startAnalysis()
+		}
+	case "o":
+		return m.useOffline()
+	}
+	return m, nil
+}
+
+// useOffline abandons the pending request and offers past commands for similar queries
+func (m *AppModel) useOffline() (tea.Model, tea.Cmd) {
+	if m.cfg == nil || m.cfg.History.Disabled {
+		m.offlineEmpty = true
+		return m, nil
+	}
+	entries, err := history.Open(config.HistoryPath()).Load()
+	if err != nil {
+		m.offlineEmpty = true
+		return m, nil
+	}
+
+	var candidates []suggest.Suggestion
+	for _, e := range history.Matches(entries, m.originalQuery, offlineCandidateLimit, offlineCandidateScore) {
+		candidates = append(candidates, suggest.Suggestion{Text: e.Final(), Sources: []string{"history"}})
+	}
+	if len(candidates) == 0 {
+		m.offlineEmpty = true
+		return m, nil
+	}
+
+	m.cancelAnalysis()
+	m.assumptions = ""
+	m.explanation = ""
+	m.candidates = suggest.GroupByApproach(suggest.Merge(candidates))
+	m.cursor = 0
+	m.state = StateSelecting
+	return m, nil
+}
+
+// cancelAnalysis aborts the in-flight LLM request; its late reply is ignored
+func (m *AppModel) cancelAnalysis() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.analyzeRound++
+}
+
+func (m *AppModel) renderAnalyzingView() string {
+	var s strings.Builder
+
+	switch {
+	case m.query == "":
+		s.WriteString(m.titleStyle.Render("🧰 项目快捷操作") + "\n\n")
+		s.WriteString(m.spinner.View() + " 正在为当前项目生成快捷操作...\n\n")
+	case len(m.repairs) > 0:
+		s.WriteString(m.titleStyle.Render("🔧 修复中") + "\n\n")
+		s.WriteString(m.spinner.View() + " 正在根据错误输出修复命令\n")
+		s.WriteString(faintStyle().Render(m.repairStatus()) + "\n\n")
+	case m.fixInput != "":
+		s.WriteString(m.titleStyle.Render("🩺 诊断中") + "\n\n")
+		s.WriteString(m.spinner.View() + " 正在诊断并修复命令: " +
+			lipgloss.NewStyle().Italic(true).Render(m.fixInput) + "\n\n")
+	default:
+		s.WriteString(m.titleStyle.Render("🧠 分析中") + "\n\n")
+		s.WriteString(m.spinner.View() + " 正在分析您的需求: " +
+			lipgloss.NewStyle().Italic(true).Render(m.query) + "\n\n")
+	}
+
+	if !m.slow {
+		s.WriteString(faintStyle().Render("请稍候..."))
+		return s.String()
+	}
+
+	s.WriteString(m.errorStyle.Render("⏳ " + m.client.ProviderName() + " 响应较慢"))
+	s.WriteString("\n\n")
+	options := []string{"w: 继续等待"}
+	if m.client.Fallback() != nil {
+		options = append(options, "f: 切换到备用提供商 "+m.client.Fallback().ProviderName())
+	}
+	options = append(options, "o: 使用历史中的离线建议", "q: 退出")
+	for _, opt := range options {
+		s.WriteString("  " + opt + "\n")
+	}
+	if m.offlineEmpty {
+		s.WriteString("\n" + faintStyle().Render("没有找到相似的历史命令"))
+	}
+	return s.String()
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/config"
+)
+
+// switchTargets lists the other fully configured providers the failed query can be retried with
+func (m *AppModel) switchTargets() []config.LLMProvider {
+	if m.cfg == nil {
+		return nil
+	}
+	var targets []config.LLMProvider
+	for _, p := range m.cfg.LLM.Configured() {
+		if client := m.client.Switch(p); client != nil && client.ProviderName() != m.client.ProviderName() {
+			targets = append(targets, p)
+		}
+	}
+	return targets
+}
+
+// handleErrorKey lets the user retry a failed query with another provider from the error screen
+func (m *AppModel) handleErrorKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key := msg.String(); key {
+	case "up", "k":
+		if m.switchCursor > 0 {
+			m.switchCursor--
+		}
+	case "down", "j":
+		if m.switchCursor < len(m.switches)-1 {
+			m.switchCursor++
+		}
+	case "enter":
+		if len(m.switches) > 0 {
+			return m.switchProvider()
+		}
+	case "p":
+		if m.missingModel != nil {
+			return m.startPull()
+		}
+	case "q", "esc", "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(m.switches) {
+			m.switchCursor = int(key[0] - '1')
+			return m.switchProvider()
+		}
+	}
+	return m, nil
+}
+
+// switchProvider resends the same query, with the answers given so far, to the highlighted provider
+func (m *AppModel) switchProvider() (tea.Model, tea.Cmd) {
+	client := m.client.Switch(m.switches[m.switchCursor])
+	if client == nil {
+		return m, nil
+	}
+	m.client = client
+	m.switches = nil
+	m.switchCursor = 0
+	m.err = nil
+	return m, m.startAnalysis()
+}
+
+// providerLabel names a provider and the model it is configured with
+func (m *AppModel) providerLabel(p config.LLMProvider) string {
+	lc := m.cfg.LLM
+	lc.Provider = p
+	name := m.client.Switch(p).ProviderName()
+	if model := lc.Model(); model != "" {
+		return name + " · " + model
+	}
+	return name
+}
+
+func (m *AppModel) renderErrorView() string {
+	var s strings.Builder
+	s.WriteString(m.titleStyle.Render("❌ 错误") + "\n\n")
+	s.WriteString(m.errorStyle.Render(fmt.Sprintf("发生错误: %v", m.err)) + "\n\n")
+	pull := ""
+	if m.missingModel != nil {
+		s.WriteString(fmt.Sprintf("按 p 让 Ollama 服务下载 %s，完成后自动重试\n\n", m.missingModel.Model))
+		pull = "p: 下载模型, "
+	}
+	if len(m.switches) == 0 {
+		if pull == "" {
+			s.WriteString(faintStyle().Render("按 q 退出"))
+		} else {
+			s.WriteString(faintStyle().Render(pull + "q: 退出"))
+		}
+		return s.String()
+	}
+
+	s.WriteString("改用其他提供商重试:\n")
+	for i, p := range m.switches {
+		line := fmt.Sprintf("%d. %s", i+1, m.providerLabel(p))
+		if i == m.switchCursor {
+			s.WriteString(m.selectedStyle.Render("➜ " + line))
+		} else {
+			s.WriteString("  " + m.itemStyle.Render(line))
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString(faintStyle().Render("\n↑/↓ 或数字: 选择, Enter: 重试, " + pull + "q: 退出, ?: 帮助"))
+	return s.String()
+}
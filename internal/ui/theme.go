@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+)
+
+// palette is the set of colors the interface draws with, named by what they
+// mean rather than by hue so that a theme can remap them consistently
+type palette struct {
+	title    lipgloss.Color // screen titles
+	selected lipgloss.Color // the highlighted item and key names
+	accent   lipgloss.Color // spinner, borders and arrows that frame content
+	muted    lipgloss.Color // secondary borders and labels
+	faint    lipgloss.Color // hints and help text; empty uses the terminal's faint attribute
+	danger   lipgloss.Color // errors, high-risk and forbidden commands, removed text
+	warning  lipgloss.Color // cautions and notes that deserve a look
+	success  lipgloss.Color // completed steps and added text
+	code     lipgloss.Color // inline code in answers
+}
+
+// palettes are the built-in themes
+var palettes = map[config.ThemeName]palette{
+	config.ThemeDefault: {
+		title:    "99",
+		selected: "212",
+		accent:   "69",
+		muted:    "8",
+		danger:   "196",
+		warning:  "214",
+		success:  "46",
+		code:     "#FFB86C",
+	},
+	// Bright foregrounds only, and no faint text: the faint attribute is
+	// nearly invisible on some terminals and to low-vision users
+	config.ThemeHighContrast: {
+		title:    "15",
+		selected: "11",
+		accent:   "14",
+		muted:    "7",
+		faint:    "250",
+		danger:   "9",
+		warning:  "208",
+		success:  "10",
+		code:     "15",
+	},
+	// Okabe-Ito colors, which stay distinguishable under the common forms of
+	// color blindness; success is blue so it never has to be told from red
+	config.ThemeColorblind: {
+		title:    "#56B4E9",
+		selected: "#F0E442",
+		accent:   "#56B4E9",
+		muted:    "8",
+		danger:   "#D55E00",
+		warning:  "#E69F00",
+		success:  "#0072B2",
+		code:     "#CC79A7",
+	},
+}
+
+// theme is the palette in effect; UIs apply the configured one as they start
+var theme = palettes[config.ThemeDefault]
+
+// applyTheme selects the configured theme and applies its color overrides
+func applyTheme(cfg *config.Config) {
+	theme = palettes[config.ThemeDefault]
+	if cfg == nil {
+		return
+	}
+	glyph.Configure(cfg.Theme.Glyphs)
+	if p, ok := palettes[cfg.Theme.Name]; ok {
+		theme = p
+	}
+	for role, color := range cfg.Theme.Colors {
+		c := lipgloss.Color(color)
+		switch role {
+		case "title":
+			theme.title = c
+		case "selected":
+			theme.selected = c
+		case "accent":
+			theme.accent = c
+		case "muted":
+			theme.muted = c
+		case "faint":
+			theme.faint = c
+		case "danger":
+			theme.danger = c
+		case "warning":
+			theme.warning = c
+		case "success":
+			theme.success = c
+		case "code":
+			theme.code = c
+		}
+	}
+}
+
+// faintStyle renders secondary text: faint by default, or in the theme's
+// faint color where the attribute would be too hard to read
+func faintStyle() lipgloss.Style {
+	if theme.faint != "" {
+		return lipgloss.NewStyle().Foreground(theme.faint)
+	}
+	return lipgloss.NewStyle().Faint(true)
+}
+
+// spinnerKind is the braille dot spinner, or a plain line on terminals without Unicode
+func spinnerKind() spinner.Spinner {
+	if glyph.ASCII() {
+		return spinner.Line
+	}
+	return spinner.Dot
+}
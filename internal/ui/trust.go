@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/project"
+)
+
+// untrustedDir returns the cwd when its project files would be sent to the LLM but
+// the user has not yet decided whether to trust it, and "" otherwise
+func (m *AppModel) untrustedDir() string {
+	store := m.client.Trust()
+	if store == nil {
+		return ""
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	// A query reads project tasks and git status, and only for local commands;
+	// quick actions read the whole directory
+	if m.query != "" && (m.client.Host() != "" || m.cfg != nil && m.cfg.DisableProjectTasks && !m.cfg.Context.Git) {
+		return ""
+	}
+	if _, decided := store.Lookup(dir); decided || !project.HasContext(dir) {
+		return ""
+	}
+	return dir
+}
+
+// openTrust asks once per directory whether its project files may be included in prompts
+func (m *AppModel) openTrust(dir string) tea.Cmd {
+	m.trustDir = dir
+	m.state = StateTrust
+	return nil
+}
+
+func (m *AppModel) handleTrustKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The decision applies to this run even if it can't be saved; then it is asked again next time
+	store := m.client.Trust()
+	switch msg.String() {
+	case "y":
+		_ = store.Set(m.trustDir, true)
+	case "n", "enter":
+		// Only this run goes without project context
+	case "N":
+		_ = store.Set(m.trustDir, false)
+	case "q", "esc", "ctrl+c":
+		m.state = StateCanceled
+		return m, tea.Quit
+	default:
+		return m, nil
+	}
+	return m, m.begin()
+}
+
+func (m *AppModel) renderTrustView() string {
+	var b strings.Builder
+	b.WriteString(m.titleStyle.Render("🔐 是否信任此目录？"))
+	b.WriteString("\n\n  " + m.trustDir + "\n\n")
+	b.WriteString("信任后，termi 会读取其中的 package.json scripts、Makefile 目标、顶层文件列表等项目文件并发送给 LLM，\n")
+	b.WriteString("以便建议 \"make test\" 这类现成任务。\n")
+	b.WriteString(m.errorStyle.Render("只信任来源可靠的目录：恶意仓库可能借这些文件诱导模型生成危险命令。"))
+	b.WriteString("\n\n")
+	b.WriteString(faintStyle().Render("y: 信任, n/Enter: 本次不读取, N: 不信任且不再询问, q: 退出"))
+	return b.String()
+}
+
+// trustPlain asks the trust question on stdin; no answer leaves this run without project context
+func (m *AppModel) trustPlain() {
+	dir := m.untrustedDir()
+	if dir == "" {
+		return
+	}
+	glyph.Printf("🔐 是否信任此目录？%s\n", dir)
+	fmt.Println("信任后会读取其中的 package.json scripts、Makefile 目标等项目文件并发送给 LLM，只信任来源可靠的目录。")
+	fmt.Print("y 信任，n 本次不读取，N 不信任且不再询问 [n]: ")
+	answer, _ := readLine()
+	switch strings.TrimSpace(answer) {
+	case "y", "Y", "yes":
+		_ = m.client.Trust().Set(dir, true)
+	case "N":
+		_ = m.client.Trust().Set(dir, false)
+	}
+}
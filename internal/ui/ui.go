@@ -1,20 +1,40 @@
 package ui
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"go.opentelemetry.io/otel/attribute"
 
+	"termi.sh/termi/internal/approval"
+	"termi.sh/termi/internal/clipboard"
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/editpreview"
+	"termi.sh/termi/internal/failure"
+	"termi.sh/termi/internal/fix"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/habit"
+	"termi.sh/termi/internal/history"
 	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/piped"
 	"termi.sh/termi/internal/runner"
+	"termi.sh/termi/internal/safety"
+	"termi.sh/termi/internal/share"
+	"termi.sh/termi/internal/shell"
+	"termi.sh/termi/internal/shellquote"
+	"termi.sh/termi/internal/sink"
 	"termi.sh/termi/internal/suggest"
+	"termi.sh/termi/internal/telemetry"
 )
 
 // AppState represents the different states of the application
@@ -24,19 +44,55 @@ const (
 	StateInit AppState = iota
 	StateAnalyzing
 	StateAsking
+	StateApproving
 	StateSelecting
 	StateExecuting
 	StateCompleted
 	StateError
 	StateCanceled
 	StateCopied
+	StateSnippet
+	StateCopyMenu
+	StateSaved
+	StateBudget
+	StateSinkMenu
+	StateSending
+	StateSent
+	StateExplain
+	StatePlan
+	StatePin
+	StateHandoff
+	StateTrust
+	StateHabit
+	StateEdit
+	StateBreakdown
+	StateRationale
+	StateAnswer
+	StateOverride
+	StatePlanRunning
+	StateShare
+	StateSharing
+	StateShared
+	StateQueued
+	StateDiff
+	StatePulling
+)
+
+const (
+	// historyCandidateLimit caps how many past commands are offered next to the LLM's
+	historyCandidateLimit = 2
+	// historyCandidateScore is the minimum query similarity for a past command to be offered
+	historyCandidateScore = 0.6
 )
 
 // AppModel is the main application model that handles the entire flow
 type AppModel struct {
+	cfg           *config.Config
+	client        *llm.Client
 	state         AppState
 	query         string
 	originalQuery string
+	fixInput      string // the pasted command when the query looks like a command to fix
 	candidates    []suggest.Suggestion
 	cursor        int
 	spinner       spinner.Model
@@ -45,13 +101,152 @@ type AppModel struct {
 	// For user input state
 	inputPrompt string
 	textInput   textinput.Model
+	askRound    int               // incremented per question so stale timeouts are ignored
+	askForm     []formField       // typed inputs when the question asks for structured values
+	askFocus    int               // the focused field of askForm
+	askDeadline time.Time         // zero when no timeout is pending
+	assumptions string            // assumptions the model made when the user didn't answer
+	explanation string            // the model's explanation of the error, in `termi why`
+	repairs     []failure.Attempt // failed commands of this request's repair loop, oldest first
+	rerun       bool              // the command is re-run from history
+
+	// Forbidden commands unlocked with the override code during this run, and the
+	// action waiting for the code
+	overridden      map[string]bool
+	overrideCommand string
+	overrideNext    func() (tea.Model, tea.Cmd)
+	overrideReturn  AppState
+	overrideFailed  bool
+
+	// The model's answer when the query was a question rather than a task
+	answer        string
+	answerCopied  bool
+	answerCopyErr error
+
+	// In-flight analysis; replies from abandoned rounds are ignored
+	analyzeRound int
+	cancel       context.CancelFunc
+	slow         bool          // soft deadline passed, interim options are shown
+	offlineEmpty bool          // the user asked for offline suggestions but none matched
+	offlineHit   string        // description of the offline command database match shown instead of asking the LLM
+	answeredBy   string        // the failover provider that answered after the primary one failed
+	intent       config.Intent // the intent the query was classified as, empty unless intents are configured
+	slowQuery    *llm.Slow     // set when the reply took longer than the provider's recent p95
+	model        string        // the model that generated the LLM candidates
+	executedAs   string        // the command as last handed to the shell, with the attribution comment when enabled
+	paged        *runner.Paged // output of the last command held back for the pager, nil when written straight through
+
+	// Other configured providers offered on the error screen to retry the failed query with
+	switches     []config.LLMProvider
+	switchCursor int
+
+	// The model missing on the Ollama server, offered for download on the error screen, and
+	// the download in progress; updates from abandoned downloads are ignored
+	missingModel *llm.ModelNotFoundError
+	pull         llm.PullProgress
+	pullRound    int
+	pullUpdates  <-chan tea.Msg
+	pullCancel   context.CancelFunc
 
 	// Context for conversation with LLM
-	contextHistory []string
+	contextHistory conversation
+	showAllHistory bool // the asking view lists every round instead of the latest few
+
+	// Pending approval of output about to be sent to the LLM
+	program         *tea.Program
+	pendingApproval *approvalMsg
 
 	// Execution related
 	selectedCommand string
+	editedFrom      string       // the generated command the user edited into selectedCommand
+	stderr          *runner.Tail // the end of the last command's stderr, to explain failures
+	stdout          *runner.Tail // the command's stdout, captured only when it is to be copied
+	copyOutput      bool         // copy the command's output to the clipboard after it runs
+	clipboard       clipboard.Clipboard
+	safety          *safety.Analyzer
+	approval        *approval.Gate // change approval required before running matching commands
+	refused         string         // why the command was copied instead of executed
 	copiedCommand   string
+	copiedText      string
+	snippetPath     string
+	savedPath       string
+
+	// Batch execution of marked candidates
+	marked     map[int]bool
+	batch      []string
+	stepwise   bool     // confirm each batch command before running it
+	batchTasks []string // the queued task each batch command belongs to, when queued with -m
+
+	// Tasks queued with -m: how many in total, those still to come and those already chosen
+	total   int
+	pending []string
+	done    []queuedTask
+
+	// The plan running inside the TUI; planNext is the step running or about to run
+	planSteps       []planStep
+	planNext        int
+	planCursor      int
+	planCtx         context.Context
+	planCancel      context.CancelFunc
+	planInterrupted bool
+	planQuit        bool // the TUI is exiting, so the final frame drops the key hints
+
+	// Copy submenu
+	copyCursor    int
+	copyInputMode bool
+
+	// Sinks the selected command can be sent to
+	sinks       []sink.Sink
+	sinkCursor  int
+	sentCommand string
+	sentTo      string
+
+	// Session transcript shared with `u`: the redacted text awaiting confirmation, then its link
+	share        share.Publisher
+	shareTitle   string
+	shareText    string
+	sharedURL    string
+	shareCopyErr error
+
+	// Pipeline flow diagrams, keyed by command; nil when the LLM could not describe it
+	stageNotes map[string][]string
+	explainErr error
+
+	// Line-by-line explanations, keyed by command; nil when the LLM could not explain it
+	breakdowns     map[string][]llm.Line
+	breakdownErr   error
+	breakdownStart int // first rendered line shown in the scrollable panel
+
+	// Previews of in-place file edits, keyed by command; nil when the edit could not be previewed
+	editDiffs   map[string][]editpreview.File
+	editDiffErr error
+	diffStart   int // first diff line shown in the scrollable panel
+
+	// Reasons for choosing each command over the other candidates, fetched on demand
+	rationales   map[string]*llm.Rationale
+	rationaleErr error
+
+	// Carries the root trace span of this invocation
+	ctx context.Context
+
+	// Facts pinned for the session with `P`, included in every subsequent prompt
+	pins       []string
+	pinsBefore []string // pins when the editor was opened, to detect changes
+	pinCursor  int
+	pinEditing bool
+	pinReturn  AppState
+
+	// Directory awaiting the one-time trust decision before its project files are read
+	trustDir string
+
+	// Repeated manual edit awaiting the user's decision before analysis starts
+	habit *habit.Pattern
+
+	// Help overlay toggled with `?`
+	showHelp bool
+
+	// Terminal width, used to soft-wrap long commands
+	width int
 
 	// Styles
 	titleStyle    lipgloss.Style
@@ -62,126 +257,447 @@ type AppModel struct {
 }
 
 // NewAppModel creates a new application model
-func NewAppModel(query string) *AppModel {
+func NewAppModel(cfg *config.Config, client *llm.Client, query string) *AppModel {
+	applyTheme(cfg)
 	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("69"))
+	s.Spinner = spinnerKind()
+	s.Style = lipgloss.NewStyle().Foreground(theme.accent)
 
 	// Initialize text input
 	ti := textinput.New()
 
-	return &AppModel{
+	var fixInput string
+	if fix.LooksLikeCommand(query) {
+		fixInput = strings.TrimSpace(query)
+	}
+
+	m := &AppModel{
+		cfg:           cfg,
+		client:        client,
+		ctx:           context.Background(),
 		state:         StateInit,
 		query:         query,
 		originalQuery: query,
+		fixInput:      fixInput,
 		spinner:       s,
 		textInput:     ti,
-		titleStyle:    lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99")),
+		titleStyle:    lipgloss.NewStyle().Bold(true).Foreground(theme.title),
 		itemStyle:     lipgloss.NewStyle(),
-		selectedStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true),
-		errorStyle:    lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
-		successStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("46")),
+		selectedStyle: lipgloss.NewStyle().Foreground(theme.selected).Bold(true),
+		errorStyle:    lipgloss.NewStyle().Foreground(theme.danger),
+		successStyle:  lipgloss.NewStyle().Foreground(theme.success),
 	}
+	m.sinks = loadSinks(m)
+	m.share = loadShare(m)
+	m.clipboard = loadClipboard(m)
+	m.safety = loadSafety(m)
+	m.approval = loadApproval(m)
+	m.stageNotes = map[string][]string{}
+	m.breakdowns = map[string][]llm.Line{}
+	m.editDiffs = map[string][]editpreview.File{}
+	m.rationales = map[string]*llm.Rationale{}
+	m.copyOutput = cfg != nil && (cfg.Exec.CopyOutput || cfg.Exec.CopyLines > 0)
+	return m
 }
 
 // RunApp starts the main application flow
-func RunApp(query string) error {
-	m := NewAppModel(query)
+func RunApp(cfg *config.Config, client *llm.Client, query string) error {
+	return NewAppModel(cfg, client, query).start()
+}
+
+// start runs the interaction for the model's query and then acts on its outcome
+func (m *AppModel) start() error {
+	ctx, span := telemetry.Start(context.Background(), "termi.run",
+		attribute.String("llm.provider", m.client.ProviderName()),
+		attribute.Int("termi.repair_round", len(m.repairs)))
+	defer span.End()
+
+	// Every analysis of this run, including rounds after clarifying questions, shares the session deadline
+	ctx = m.client.StartSession(ctx)
+	m.ctx = ctx
+	if !interactiveTerminal() {
+		return m.runPlain(m.cfg)
+	}
+	client := m.client
 	p := tea.NewProgram(m)
+	m.program = p
+	m.client = client.With(llm.WithApprover(m.approveOutput))
 	finalModel, err := p.Run()
 	if err != nil {
-		return fmt.Errorf("界面运行出错: %w", err)
+		if m.state != StateInit && m.state != StateAnalyzing {
+			return fmt.Errorf("界面运行出错: %w", err)
+		}
+		// The terminal could not be set up; start over with line-based prompts
+		m.cancelAnalysis()
+		fmt.Printf("无法启动终端界面 (%v)，改用纯文本模式\n", err)
+		plain := m.respawn(client, m.query)
+		plain.ctx = ctx
+		return plain.runPlain(m.cfg)
 	}
 
 	// Check if we need to execute a command after TUI exit
 	if appModel, ok := finalModel.(*AppModel); ok {
-		switch appModel.state {
-		case StateCompleted:
-			if appModel.selectedCommand != "" {
-				fmt.Printf("\n执行命令: %s\n\n", appModel.selectedCommand)
-				if execErr := runner.Run(appModel.selectedCommand); execErr != nil {
-					return fmt.Errorf("命令执行失败: %w", execErr)
-				}
+		return appModel.finish(m.cfg)
+	}
+	return nil
+}
+
+// respawn returns a fresh model for query that keeps what carries over between rounds
+// of the same request: the user's original query, the repair attempts and the pins
+func (m *AppModel) respawn(client *llm.Client, query string) *AppModel {
+	n := NewAppModel(m.cfg, client, query)
+	n.originalQuery = m.originalQuery
+	n.repairs = m.repairs
+	n.pins = m.pins
+	return n
+}
+
+// finish acts on the state the interaction ended in: runs, copies, saves or sends the command
+func (m *AppModel) finish(cfg *config.Config) error {
+	switch m.state {
+	case StatePlanRunning:
+		return m.finishPlan()
+	case StateCompleted:
+		if len(m.batch) > 0 {
+			return m.runBatch()
+		}
+		if m.selectedCommand != "" {
+			fmt.Printf("\n执行命令: %s\n\n", m.selectedCommand)
+			transcript, execErr := m.run(m.selectedCommand)
+			if errors.Is(execErr, safety.ErrNotConfirmed) || errors.Is(execErr, safety.ErrNotReviewed) || errors.Is(execErr, safety.ErrBlocked) || errors.Is(execErr, safety.ErrForbidden) || errors.Is(execErr, approval.ErrNotApproved) || errors.Is(execErr, safety.ErrNotRepeated) {
+				fmt.Println(execErr)
+				return nil
+			}
+			m.pageOutput()
+			exitCode := runner.ExitCode(execErr)
+			m.recordShellHistory(m.selectedCommand)
+			e := history.Entry{
+				Command:    m.selectedCommand,
+				Action:     history.ActionExecuted,
+				ExitCode:   &exitCode,
+				Transcript: transcript,
+			}
+			if m.editedFrom != "" {
+				// Recorded like an edit on the command line, so repeated tweaks become habits
+				e.Command, e.Action, e.Edited = m.editedFrom, history.ActionEdited, m.selectedCommand
 			}
-		case StateCopied:
-			if appModel.copiedCommand != "" {
-				fmt.Printf("📋 已复制到剪贴板: \n  %s\n", appModel.copiedCommand)
+			m.record(e)
+			m.copyCapturedOutput()
+			if execErr != nil {
+				if limit := runner.LimitExceeded(execErr); limit != "" && m.cfg != nil && runner.LimitsFrom(m.cfg.Exec.Limits).Enabled() {
+					glyph.Printf("\n⏱ 命令超过了 exec.limits 设置的%s上限，已被终止\n", limit)
+				}
+				if next, ok := m.offerRepair(m.selectedCommand, exitCode); ok {
+					return next.start()
+				}
+				return fmt.Errorf("命令执行失败: %w", execErr)
 			}
-		case StateError:
-			return fmt.Errorf("应用错误: %w", appModel.err)
-		case StateCanceled:
-			fmt.Println("操作已取消")
-			return nil
+			m.sendAuto(m.selectedCommand, exitCode)
+			m.verify(m.selectedCommand)
+		}
+	case StateCopied:
+		if m.refused != "" {
+			glyph.Printf("⛔ %s，未执行命令\n", m.refused)
+		}
+		if m.copiedCommand != "" {
+			glyph.Printf("📋 已复制到%s: \n%s\n", m.clipboard.Name(), indent(m.copiedText))
+			m.record(history.Entry{
+				Command: m.copiedCommand,
+				Action:  history.ActionCopied,
+			})
+		}
+	case StateError:
+		return fmt.Errorf("应用错误: %w", m.err)
+	case StateSaved:
+		glyph.Printf("💾 已保存为可执行脚本: %s\n", m.savedPath)
+		m.record(history.Entry{
+			Command: m.copiedCommand,
+			Action:  history.ActionSaved,
+		})
+	case StateSent:
+		glyph.Printf("📤 已发送到 %s:\n%s\n", m.sentTo, indent(m.sentCommand))
+	case StateShared:
+		glyph.Printf("🔗 已分享到%s: %s\n", m.share.Name(), m.sharedURL)
+		if m.shareCopyErr != nil {
+			glyph.Printf("⚠ 复制链接失败: %v\n", m.shareCopyErr)
+		} else {
+			glyph.Printf("📋 链接已复制到%s\n", m.clipboard.Name())
+		}
+		m.record(history.Entry{
+			Command: m.sentCommand,
+			Action:  history.ActionSent,
+		})
+	case StateSnippet:
+		glyph.Println("⚠ 该命令只会改变当前 shell 的工作目录或环境变量，在 termi 中执行不会生效。")
+		fmt.Printf("已写入可 source 的脚本，请在当前 shell 中运行:\n  source %s\n", shellquote.Parse(m.client.Shell()).Quote(m.snippetPath))
+	case StateHandoff:
+		m.record(history.Entry{
+			Command: m.selectedCommand,
+			Action:  history.ActionInserted,
+		})
+	case StateQueued:
+		return m.nextTask().start()
+	case StateAnswer:
+		fmt.Println(renderAnswer(m.answer))
+		if m.answerCopied {
+			glyph.Printf("\n📋 已复制到%s\n", m.clipboard.Name())
 		}
+		if len(m.pending) > 0 {
+			fmt.Printf("\n%s 没有生成命令，其余 %d 项任务未处理\n", m.queueProgress(), len(m.pending))
+		}
+	case StateCanceled:
+		fmt.Println("操作已取消")
+		return nil
 	}
 
 	return nil
 }
 
-// Message types for AppModel
-type llmAnalysisMsg struct {
-	command string
-	ask     string
-	err     error
+// run executes the command, recording a transcript when enabled in config.
+// It returns the transcript path, if any, alongside the execution error.
+func (m *AppModel) run(command string) (string, error) {
+	m.executedAs = ""
+	r := m.safety.Analyze(command)
+	if r.Forbidden && m.overridden[command] {
+		// The override code was already entered for this command; asking again is redundant
+		r.Forbidden, r.Allowed = false, true
+	}
+	m.previewImpact(command, r)
+	req, err := m.gate(command, r)
+	if err != nil {
+		return "", err
+	}
+	m.backup(command)
+	if m.needsSudoPrevalidate(command) {
+		if err := runner.PrevalidateSudo(); err != nil {
+			return "", err
+		}
+	}
+
+	_, span := telemetry.Start(m.ctx, "runner.exec", attribute.Bool("termi.remote", m.client.Host() != ""))
+	transcript, execErr := m.execute(command)
+	span.SetAttributes(attribute.Int("process.exit_code", runner.ExitCode(execErr)))
+	telemetry.End(span, execErr)
+	m.approval.Executed(req, runner.ExitCode(execErr))
+	return transcript, execErr
 }
 
-type copiedMsg struct {
-	success bool
-	err     error
+// gate asks for every confirmation the analysis result r calls for before command runs:
+// the risk level, an injection review, repeated side effects and approval. The returned
+// request, if any, is to be passed to approval.Executed once the command has run
+func (m *AppModel) gate(command string, r safety.Result) (*approval.Request, error) {
+	_, span := telemetry.Start(m.ctx, "safety.check",
+		attribute.Bool("termi.critical", r.Level == safety.Critical),
+		attribute.String("termi.risk", r.Level.String()),
+		attribute.Bool("termi.blocked", r.Blocked))
+	err := safety.Confirm(r, m.expertise())
+	if err == nil {
+		// Injection artifacts are reviewed even when the risk rules found nothing or the
+		// allowlist matched: exfiltration does not look destructive
+		err = safety.ConfirmReview(command, m.safety.Inspect(command, m.originalQuery))
+	}
+	if err == nil {
+		err = safety.ConfirmRepeat(command, m.repeatEffects(command, r))
+	}
+	telemetry.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	if !m.approval.Required(command, r) {
+		return nil, nil
+	}
+	req := approval.NewRequest(command, m.originalQuery, m.client.Host(), r)
+	if err := m.approval.Approve(m.ctx, req); err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 
-// copyToClipboard copies text to the system clipboard
-func copyToClipboard(text string) error {
-	var cmd *exec.Cmd
+// runOptions runs commands over SSH on the target host, or locally in the configured shell
+// under the exec.limits resource limits
+func (m *AppModel) runOptions() []runner.Option {
+	if host := m.client.Host(); host != "" {
+		return []runner.Option{runner.WithSSHHost(host)}
+	}
+	opts := []runner.Option{runner.WithShell(m.client.Shell())}
+	if m.cfg != nil {
+		opts = append(opts, runner.WithLimits(runner.LimitsFrom(m.cfg.Exec.Limits)))
+	}
+	return opts
+}
 
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("pbcopy")
-	case "linux":
-		if _, err := exec.LookPath("xclip"); err == nil {
-			cmd = exec.Command("xclip", "-selection", "clipboard")
-		} else if _, err := exec.LookPath("xsel"); err == nil {
-			cmd = exec.Command("xsel", "--clipboard", "--input")
-		} else {
-			return fmt.Errorf("no clipboard utility found (install xclip or xsel)")
-		}
-	case "windows":
-		cmd = exec.Command("clip")
-	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+// execute runs the command locally or over SSH, through the recorder when enabled
+func (m *AppModel) execute(command string) (string, error) {
+	opts := m.runOptions()
+	m.stderr = nil
+	if m.cfg == nil || !m.cfg.Exec.NoStderrCapture {
+		m.stderr = runner.NewTail(stderrTailSize)
+		opts = append(opts, runner.WithStderrTail(m.stderr))
+	}
+	if stdout := m.captureOutput(); stdout != nil {
+		opts = append(opts, runner.WithStdoutTail(stdout))
+	}
+	if m.paged = m.pagedOutput(); m.paged != nil {
+		opts = append(opts, runner.WithPager(m.paged))
+	}
+	if path := m.captureObjects(); path != "" {
+		opts = append(opts, runner.WithObjectCapture(path))
+		defer m.saveObjects(command, path)
 	}
 
-	cmd.Stdin = strings.NewReader(text)
-	return cmd.Run()
+	m.executedAs = m.attributed(command)
+	if m.cfg == nil || !m.cfg.Record.Enabled {
+		execErr := runner.Run(m.executedAs, opts...)
+		m.client.LearnExecution(command, runner.ExitCode(execErr))
+		return "", execErr
+	}
+
+	rec, err := newRecorder(&m.cfg.Record, command)
+	if err != nil {
+		return "", err
+	}
+	execErr := runner.Run(m.executedAs, append(opts, runner.WithRecorder(rec))...)
+	m.client.LearnExecution(command, runner.ExitCode(execErr))
+	if err := rec.Close(execErr); err != nil {
+		fmt.Printf("保存录制文件失败: %v\n", err)
+		return "", execErr
+	}
+	glyph.Printf("\n🎬 执行过程已录制: %s\n", rec.Path())
+	return rec.Path(), execErr
+}
+
+// needsSudoPrevalidate reports whether sudo credentials should be validated before running
+func (m *AppModel) needsSudoPrevalidate(command string) bool {
+	if m.cfg != nil && m.cfg.Exec.NoSudoPrevalidate {
+		return false
+	}
+	return runtime.GOOS != "windows" && m.client.Host() == "" && runner.UsesSudo(command)
+}
+
+// record appends an entry for the original query to the history store
+func (m *AppModel) record(e history.Entry) {
+	if m.cfg == nil || m.cfg.History.Disabled {
+		return
+	}
+	e.Query = m.originalQuery
+	e.Variant = m.client.Variant()
+	if err := history.Open(config.HistoryPath()).Append(e); err != nil {
+		fmt.Printf("保存历史记录失败: %v\n", err)
+	}
+}
+
+// newRecorder creates a transcript file named after the current time
+func newRecorder(rc *config.RecordConfig, command string) (*runner.Recorder, error) {
+	dir := rc.TranscriptDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建录制目录失败: %w", err)
+	}
+
+	format := runner.Format(rc.Format)
+	ext := ".cast"
+	if format == runner.FormatScript {
+		ext = ".typescript"
+	}
+	name := time.Now().Format("20060102-150405") + ext
+	return runner.NewRecorder(filepath.Join(dir, name), format, command)
+}
+
+// indent prefixes every line of text with two spaces
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
+}
+
+// Message types for AppModel
+type llmAnalysisMsg struct {
+	round int
+	reply *llm.Reply
+	err   error
+}
+
+// approvalMsg asks the user to approve text before it is sent to the LLM
+type approvalMsg struct {
+	text  string
+	reply chan bool
+}
+
+// askTimeoutMsg fires when a clarifying question went unanswered for too long
+type askTimeoutMsg struct {
+	round int
+}
+
+type copiedMsg struct {
+	success bool
+	err     error
 }
 
 // Init initializes the AppModel
 func (m *AppModel) Init() tea.Cmd {
-	if !llm.Enabled() {
+	if !m.client.Enabled() {
 		m.state = StateError
 		m.err = fmt.Errorf("LLM 未启用，请设置 OPENAI_API_KEY 环境变量")
 		return nil
 	}
+	if dir := m.untrustedDir(); dir != "" {
+		return m.openTrust(dir)
+	}
+	return m.begin()
+}
 
-	m.state = StateAnalyzing
-	return tea.Batch(
-		m.spinner.Tick,
-		m.analyzeLLMCmd(),
-	)
+// begin starts the flow for the query: quick actions, an offline match or the LLM
+func (m *AppModel) begin() tea.Cmd {
+	if m.query == "" {
+		return m.loadQuickActions()
+	}
+	if p := m.pendingHabit(); p != nil {
+		return m.openHabit(p)
+	}
+	return m.analyze()
+}
+
+// analyze answers the query from the offline command database or the LLM
+func (m *AppModel) analyze() tea.Cmd {
+	if m.useCommandDB() {
+		return nil
+	}
+	return m.startAnalysis()
 }
 
 // Update handles messages and state transitions
 func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
-	// Update textinput when in asking state
-	if m.state == StateAsking {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		if m.isHelpKey(key) {
+			m.showHelp = !m.showHelp
+			return m, nil
+		}
+		// The overlay swallows other keys, except Ctrl+C which always cancels
+		if m.showHelp && key.Type != tea.KeyCtrlC {
+			return m, nil
+		}
+	}
+
+	// Update textinput when in asking state or entering a copy target or override code.
+	// Keys for a form are routed by handleAskFormKey, which decides between fields
+	if m.state == StateAsking && m.askForm != nil {
+		if _, isKey := msg.(tea.KeyMsg); !isKey {
+			cmd = m.updateFormField(msg)
+		}
+	} else if m.state == StateAsking || m.state == StateEdit || m.state == StateOverride || (m.state == StateCopyMenu && m.copyInputMode) || (m.state == StatePin && m.pinEditing) {
 		m.textInput, cmd = m.textInput.Update(msg)
 	}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		// Any keystroke while asking means the user is answering; stop the countdown
+		if m.state == StateAsking {
+			m.askDeadline = time.Time{}
+		}
 		return m.handleKeyMsg(msg)
+	case askTimeoutMsg:
+		return m.handleAskTimeout(msg)
+	case softTimeoutMsg:
+		return m.handleSoftTimeout(msg)
 	case spinner.TickMsg:
 		var spinnerCmd tea.Cmd
 		m.spinner, spinnerCmd = m.spinner.Update(msg)
@@ -190,38 +706,132 @@ func (m *AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleLLMAnalysis(msg)
 	case copiedMsg:
 		return m.handleCopied(msg)
+	case sentMsg:
+		return m.handleSent(msg)
+	case sharedMsg:
+		return m.handleShared(msg)
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		return m, cmd
+	case stagesMsg:
+		return m.handleStages(msg)
+	case breakdownMsg:
+		return m.handleBreakdown(msg)
+	case diffMsg:
+		return m.handleDiff(msg)
+	case rationaleMsg:
+		return m.handleRationale(msg)
+	case planStepMsg:
+		return m.handlePlanStep(msg)
+	case pullProgressMsg:
+		return m.handlePullProgress(msg)
+	case pulledMsg:
+		return m.handlePulled(msg)
+	case editorMsg:
+		return m.handleEditor(msg)
+	case quickActionsMsg:
+		return m.handleQuickActions(msg)
+	case approvalMsg:
+		m.pendingApproval = &msg
+		m.state = StateApproving
+		return m, nil
 	}
 	return m, cmd
 }
 
-// View renders the current state
+// View renders the current state, in ASCII on terminals without Unicode
 func (m *AppModel) View() string {
+	return glyph.Text(m.view())
+}
+
+func (m *AppModel) view() string {
+	if m.showHelp {
+		return m.renderHelpView()
+	}
+
 	switch m.state {
 	case StateInit:
 		return m.titleStyle.Render("🚀 Termi") + "\n\n" +
 			m.spinner.View() + " 初始化中..."
 	case StateAnalyzing:
-		return m.titleStyle.Render("🧠 分析中") + "\n\n" +
-			m.spinner.View() + " 正在分析您的需求: " +
-			lipgloss.NewStyle().Italic(true).Render(m.query) + "\n\n" +
-			lipgloss.NewStyle().Faint(true).Render("请稍候...")
+		return m.renderPinnedBar() + m.renderQueueBar() + m.renderAnalyzingView()
 	case StateAsking:
-		return m.renderAskingView()
+		return m.renderPinnedBar() + m.renderQueueBar() + m.renderAskingView()
+	case StatePin:
+		return m.renderPinView()
+	case StateTrust:
+		return m.renderTrustView()
+	case StateHabit:
+		return m.renderHabitView()
+	case StateApproving:
+		return m.renderApprovingView()
+	case StateCopyMenu:
+		return m.renderCopyMenuView()
+	case StateSinkMenu:
+		return m.renderSinkMenuView()
+	case StateShare:
+		return m.renderShareView()
+	case StateExplain:
+		return m.renderExplainView()
+	case StateEdit:
+		return m.renderEditView()
+	case StateBreakdown:
+		return m.renderBreakdownView()
+	case StateDiff:
+		return m.renderDiffView()
+	case StateRationale:
+		return m.renderRationaleView()
+	case StateAnswer:
+		return m.renderAnswerView()
+	case StateOverride:
+		return m.renderOverrideView()
+	case StateSending:
+		return m.titleStyle.Render("📤 发送中") + "\n\n" +
+			m.spinner.View() + " 正在发送到 " + m.sinks[m.sinkCursor].Name() + "..."
+	case StateSent:
+		return m.successStyle.Render("📤 已发送")
+	case StateSharing:
+		return m.titleStyle.Render("🔗 分享中") + "\n\n" +
+			m.spinner.View() + " 正在发布到" + m.share.Name() + "..."
+	case StateShared:
+		return m.successStyle.Render("🔗 已分享")
+	case StateBudget:
+		return m.titleStyle.Render("💰 已达到本次调用的 LLM 用量上限") + "\n\n" +
+			m.client.Budget().Summary() + "\n\n" +
+			faintStyle().Render("可能陷入了反复追问或探测，c/Enter: 追加额度并继续, q/Esc: 退出")
 	case StateSelecting:
-		return m.renderSelectingView()
+		return m.renderPinnedBar() + m.renderQueueBar() + m.renderSelectingView()
+	case StatePlan:
+		return m.renderPlanView()
+	case StatePlanRunning:
+		return m.renderPlanRunView()
 	case StateExecuting:
 		return m.titleStyle.Render("⚡ 执行中") + "\n\n" +
 			m.spinner.View() + " 正在执行命令...\n\n" +
-			lipgloss.NewStyle().Faint(true).Render("请稍候...")
+			faintStyle().Render("请稍候...")
 	case StateCompleted:
-		return m.successStyle.Render("✅ 准备执行命令")
+		view := m.successStyle.Render("✅ 准备执行命令")
+		if tokens := m.client.Budget().Tokens(); tokens > 0 {
+			view += faintStyle().Render(fmt.Sprintf("  · %d token", tokens))
+		}
+		return view
+	case StateQueued:
+		return m.successStyle.Render(fmt.Sprintf("🧾 已选定任务 %d/%d 的命令", len(m.done), m.total))
+	case StateSnippet:
+		return m.successStyle.Render("📄 已生成 source 脚本")
+	case StateCopied:
+		return m.successStyle.Render("📋 已复制")
+	case StateHandoff:
+		return m.successStyle.Render("⌨ 已放到命令行")
+	case StateSaved:
+		return m.successStyle.Render("💾 已保存脚本")
 	case StateError:
-		return m.titleStyle.Render("❌ 错误") + "\n\n" +
-			m.errorStyle.Render(fmt.Sprintf("发生错误: %v", m.err)) + "\n\n" +
-			lipgloss.NewStyle().Faint(true).Render("按 q 退出")
+		return m.renderErrorView()
+	case StatePulling:
+		return m.renderPullView()
 	case StateCanceled:
 		return m.titleStyle.Render("🚫 已取消") + "\n\n" +
-			lipgloss.NewStyle().Faint(true).Render("操作已取消")
+			faintStyle().Render("操作已取消")
 	default:
 		return m.errorStyle.Render("未知状态")
 	}
@@ -229,25 +839,42 @@ func (m *AppModel) View() string {
 
 // Helper methods
 func (m *AppModel) analyzeLLMCmd() tea.Cmd {
+	ctx, cancel := context.WithCancel(m.ctx)
+	if len(m.contextHistory) > 0 {
+		ctx = llm.WithClarifyRound(ctx)
+	}
+	if m.fixInput != "" {
+		ctx = llm.WithVerbatim(ctx)
+	}
+	m.cancel = cancel
+	round := m.analyzeRound
+	client := m.client
 	return func() tea.Msg {
-		// Build full context with history
-		fullQuery := m.query
-		if len(m.contextHistory) > 0 {
-			fullQuery = strings.Join(m.contextHistory, " ") + " " + m.query
-		}
-
-		cmd, ask, err := llm.AskSmart(fullQuery)
+		reply, err := client.AskSmart(ctx, m.fullQuery())
+		cancel()
 		return llmAnalysisMsg{
-			command: cmd,
-			ask:     ask,
-			err:     err,
+			round: round,
+			reply: reply,
+			err:   err,
 		}
 	}
 }
 
+// fullQuery builds the prompt for the LLM from the query and the answered questions
+func (m *AppModel) fullQuery() string {
+	query := m.query
+	if m.fixInput != "" {
+		query = fix.Prompt(m.fixInput)
+	}
+	return m.contextHistory.prompt(m.queuePrompt(query))
+}
+
 func (m *AppModel) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch m.state {
 	case StateAsking:
+		if m.askForm != nil {
+			return m.handleAskFormKey(msg)
+		}
 		switch msg.Type {
 		case tea.KeyEnter:
 			input := strings.TrimSpace(m.textInput.Value())
@@ -255,14 +882,87 @@ func (m *AppModel) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 			// Add question and answer to context history
-			m.contextHistory = append(m.contextHistory, m.inputPrompt+" "+input)
+			m.contextHistory = m.contextHistory.add(exchange{question: m.inputPrompt, answer: input})
 			m.textInput.SetValue("")
-			m.state = StateAnalyzing
-			return m, tea.Batch(m.spinner.Tick, m.analyzeLLMCmd())
+			return m, m.startAnalysis()
+		case tea.KeyCtrlO:
+			m.showAllHistory = !m.showAllHistory
+			return m, nil
 		case tea.KeyCtrlC, tea.KeyEsc:
 			m.state = StateCanceled
 			return m, tea.Quit
 		}
+	case StateCopyMenu:
+		return m.handleCopyMenuKey(msg)
+	case StateSinkMenu:
+		return m.handleSinkMenuKey(msg)
+	case StateShare:
+		return m.handleShareKey(msg)
+	case StatePlan:
+		return m.handlePlanKey(msg)
+	case StatePlanRunning:
+		return m.handlePlanRunKey(msg)
+	case StatePin:
+		return m.handlePinKey(msg)
+	case StateTrust:
+		return m.handleTrustKey(msg)
+	case StateHabit:
+		return m.handleHabitKey(msg)
+	case StateEdit:
+		return m.handleEditKey(msg)
+	case StateBreakdown:
+		return m.handleBreakdownKey(msg)
+	case StateDiff:
+		return m.handleDiffKey(msg)
+	case StateRationale:
+		return m.handleRationaleKey(msg)
+	case StateAnswer:
+		return m.handleAnswerKey(msg)
+	case StateOverride:
+		return m.handleOverrideKey(msg)
+	case StateError:
+		return m.handleErrorKey(msg)
+	case StatePulling:
+		return m.handlePullKey(msg)
+	case StateExplain:
+		switch msg.String() {
+		case "enter":
+			return m.executeCommand()
+		case "esc", "q", "f":
+			m.state = StateSelecting
+		case "ctrl+c":
+			m.state = StateCanceled
+			return m, tea.Quit
+		}
+	case StateBudget:
+		switch msg.String() {
+		case "c", "enter":
+			m.client.Budget().Extend()
+			return m, m.startAnalysis()
+		case "q", "esc", "ctrl+c":
+			m.state = StateCanceled
+			return m, tea.Quit
+		}
+	case StateAnalyzing:
+		if msg.Type == tea.KeyCtrlC || msg.String() == "q" {
+			m.cancelAnalysis()
+			m.state = StateCanceled
+			return m, tea.Quit
+		}
+		if m.slow {
+			return m.handleSlowKey(msg)
+		}
+	case StateApproving:
+		switch msg.String() {
+		case "y", "enter":
+			return m.resolveApproval(true)
+		case "n", "esc":
+			return m.resolveApproval(false)
+		case "ctrl+c":
+			m.resolveApproval(false)
+			m.state = StateCanceled
+			return m, tea.Quit
+		}
 	case StateSelecting:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
@@ -277,7 +977,12 @@ func (m *AppModel) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.cursor++
 			}
 		case tea.KeyEnter:
+			if len(m.marked) > 0 {
+				return m.openPlan()
+			}
 			return m.executeCommand()
+		case tea.KeySpace:
+			return m.toggleMark()
 		}
 		// Additional vim-style navigation
 		switch msg.String() {
@@ -293,7 +998,35 @@ func (m *AppModel) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.state = StateCanceled
 			return m, tea.Quit
 		case "c":
-			return m.copyCommand()
+			return m.openCopyMenu()
+		case "s":
+			return m.emitSnippet()
+		case "o":
+			return m.openSinkMenu()
+		case "u":
+			return m.openShare()
+		case "f":
+			return m.openExplain()
+		case "e":
+			return m.openEdit()
+		case "x":
+			return m.openBreakdown()
+		case "d":
+			return m.openDiff()
+		case "w":
+			return m.openRationale()
+		case "a":
+			if m.offlineHit != "" {
+				return m.askLLM()
+			}
+		case "P":
+			return m.openPins()
+		case "y":
+			m.copyOutput = true
+			if len(m.marked) > 0 {
+				return m.openPlan()
+			}
+			return m.executeCommand()
 		}
 	default:
 		if msg.Type == tea.KeyCtrlC || msg.String() == "q" {
@@ -304,27 +1037,153 @@ func (m *AppModel) handleKeyMsg(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// approveOutput blocks the LLM call until the user approves or rejects the text
+func (m *AppModel) approveOutput(ctx context.Context, text string) bool {
+	if m.program == nil {
+		return false
+	}
+	reply := make(chan bool, 1)
+	m.program.Send(approvalMsg{text: text, reply: reply})
+	select {
+	case ok := <-reply:
+		return ok
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (m *AppModel) resolveApproval(ok bool) (tea.Model, tea.Cmd) {
+	if m.pendingApproval != nil {
+		m.pendingApproval.reply <- ok
+		m.pendingApproval = nil
+	}
+	m.state = StateAnalyzing
+	return m, m.spinner.Tick
+}
+
 func (m *AppModel) handleLLMAnalysis(msg llmAnalysisMsg) (tea.Model, tea.Cmd) {
+	if msg.round != m.analyzeRound {
+		return m, nil
+	}
+	m.cancel = nil
+	m.slow = false
+
+	if errors.Is(msg.err, llm.ErrBudgetExceeded) {
+		m.state = StateBudget
+		return m, m.notify("用量达到上限")
+	}
 	if msg.err != nil {
 		m.state = StateError
 		m.err = m.formatLLMError(msg.err)
-		return m, nil
+		m.switches = m.switchTargets()
+		m.switchCursor = 0
+		m.missingModel = nil
+		errors.As(msg.err, &m.missingModel)
+		return m, m.notify("出错")
+	}
+
+	if msg.reply.Ask != "" {
+		return m.transitionToAsking(msg.reply.Ask, msg.reply.Fields), tea.Batch(m.notify("等待回答"), m.askTimeoutCmd())
 	}
 
-	if msg.ask != "" {
-		return m.transitionToAsking(msg.ask), nil
+	if msg.reply.Command != "" {
+		return m.transitionToSelecting(msg.reply), m.notify("命令已生成")
 	}
 
-	if msg.command != "" {
-		return m.transitionToSelecting(msg.command), nil
+	if msg.reply.Answer != "" {
+		m.slowQuery = msg.reply.Slow
+		return m.transitionToAnswer(msg.reply.Answer), m.notify("已回答")
 	}
 
 	m.state = StateError
 	m.err = fmt.Errorf("LLM 未能生成可执行命令，请尝试提供更详细的描述")
-	return m, nil
+	return m, m.notify("出错")
+}
+
+// pipedNote describes the stdin content and files sent along with the query, empty when there were none
+func (m *AppModel) pipedNote() string {
+	var notes []string
+	if in := m.client.PipedInput(); in.Text != "" {
+		notes = append(notes, "已附加管道输入"+attachmentSize(in))
+	}
+	for _, f := range m.client.Files() {
+		notes = append(notes, "已附加文件 "+f.Path+attachmentSize(f.Input))
+	}
+	return strings.Join(notes, "；")
+}
+
+// attachmentSize describes how much of an attachment was sent
+func attachmentSize(in piped.Input) string {
+	note := fmt.Sprintf("（%d 行", in.Lines())
+	if in.Truncated {
+		note += fmt.Sprintf("，原内容 %d KB 过长，只发送了%s", in.Size>>10, in.Kept())
+	}
+	return note + "）"
+}
+
+// failoverNote tells the user the primary provider failed and which one answered instead
+func (m *AppModel) failoverNote() string {
+	primary := m.client.ProviderFor(m.intent)
+	if primary == m.client.ProviderName() && m.client.Demoted() {
+		return fmt.Sprintf("%s 最近多次请求失败，已暂时改用 %s，稍后会自动恢复", primary, m.answeredBy)
+	}
+	return fmt.Sprintf("%s 请求失败，本次由 %s 回答", primary, m.answeredBy)
+}
+
+// intentNote describes how the query was handled for its intent, empty when its intent has no settings
+func (m *AppModel) intentNote() string {
+	if m.cfg == nil || m.intent == "" {
+		return ""
+	}
+	ic := m.cfg.Intents[m.intent]
+	var notes []string
+	if ic.Routed() {
+		target := m.client.ProviderFor(m.intent)
+		if ic.Model != "" {
+			target += " · " + ic.Model
+		}
+		notes = append(notes, "改用 "+target)
+	}
+	if ic.Temperature != nil {
+		notes = append(notes, fmt.Sprintf("温度 %g", *ic.Temperature))
+	}
+	if len(ic.Skills) > 0 {
+		notes = append(notes, "附加技能包 "+strings.Join(ic.Skills, "、"))
+	}
+	if ic.Safety == config.SafetyStrict {
+		notes = append(notes, "严格的安全检查")
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("按 %s 类查询处理：%s", m.intent, strings.Join(notes, "，"))
+}
+
+// slowNote tells the user the reply was unusually slow for this provider, with the prompt
+// size, since a large context is the usual cause
+func (m *AppModel) slowNote() string {
+	s := m.slowQuery
+	note := fmt.Sprintf("本次请求耗时 %.1fs，超过近期 95%% 的请求（%.1fs），提示词 %.1f KB",
+		s.Latency.Seconds(), s.P95.Seconds(), float64(s.Prompt)/1024)
+	if m.cfg.Lite {
+		return note + "，可能是网络或提供商本身较慢"
+	}
+	return note + "，上下文过大？试试 --lite"
 }
 
 func (m *AppModel) formatLLMError(err error) error {
+	if errors.Is(err, llm.ErrBudgetExceeded) {
+		return fmt.Errorf("%w (%s)", err, m.client.Budget().Summary())
+	}
+	var deadline *llm.DeadlineError
+	if errors.As(err, &deadline) {
+		return deadline
+	}
+	var missing *llm.ModelNotFoundError
+	if errors.As(err, &missing) {
+		return fmt.Errorf("Ollama 服务 %s 上没有模型 %s", missing.Endpoint, missing.Model)
+	}
+
 	var llmErr *llm.LLMError
 	if errors.As(err, &llmErr) {
 		switch llmErr.Type {
@@ -355,16 +1214,97 @@ func (m *AppModel) formatLLMError(err error) error {
 	}
 }
 
-func (m *AppModel) transitionToAsking(ask string) *AppModel {
+func (m *AppModel) transitionToAsking(ask string, fields []llm.Field) *AppModel {
 	m.state = StateAsking
+	m.askRound++
 	m.inputPrompt = ask
 	m.textInput.SetValue("")
+	m.askForm, m.askFocus = newAskForm(fields), 0
+	if m.askForm != nil {
+		m.askForm[0].input.Focus()
+		return m
+	}
 	m.textInput.Focus()
 	return m
 }
 
-func (m *AppModel) transitionToSelecting(command string) *AppModel {
-	m.candidates = []suggest.Suggestion{{Text: command, Source: "llm"}}
+// historyCandidates returns previously accepted commands for near-identical queries
+func (m *AppModel) historyCandidates() []suggest.Suggestion {
+	if m.cfg == nil || m.cfg.History.Disabled {
+		return nil
+	}
+	entries, err := history.Open(config.HistoryPath()).Load()
+	if err != nil {
+		return nil
+	}
+
+	var out []suggest.Suggestion
+	for _, e := range history.Matches(entries, m.originalQuery, historyCandidateLimit, historyCandidateScore) {
+		out = append(out, suggest.Suggestion{Text: e.Final(), Sources: []string{"history"}})
+	}
+	return out
+}
+
+// askTimeoutCmd starts the countdown for the current clarifying question, if configured
+func (m *AppModel) askTimeoutCmd() tea.Cmd {
+	if m.cfg == nil || m.cfg.AskTimeout <= 0 {
+		return nil
+	}
+	timeout := time.Duration(m.cfg.AskTimeout) * time.Second
+	m.askDeadline = time.Now().Add(timeout)
+	round := m.askRound
+	return tea.Tick(timeout, func(time.Time) tea.Msg {
+		return askTimeoutMsg{round: round}
+	})
+}
+
+// handleAskTimeout proceeds with the model's best guess when the question went unanswered
+func (m *AppModel) handleAskTimeout(msg askTimeoutMsg) (tea.Model, tea.Cmd) {
+	if m.state != StateAsking || msg.round != m.askRound || m.askDeadline.IsZero() {
+		return m, nil
+	}
+
+	m.askDeadline = time.Time{}
+	m.contextHistory = m.contextHistory.add(exchange{question: m.inputPrompt, timedOut: true})
+	m.askForm = nil
+	m.textInput.SetValue("")
+	return m, m.startAnalysis()
+}
+
+func (m *AppModel) transitionToSelecting(reply *llm.Reply) *AppModel {
+	m.assumptions = reply.Assumptions
+	m.explanation = reply.Explanation
+	m.slowQuery = reply.Slow
+	m.model = reply.Model
+	m.answeredBy = ""
+	if reply.Provider != "" && reply.Provider != m.client.ProviderFor(reply.Intent) {
+		m.answeredBy = reply.Provider
+	}
+	m.intent = reply.Intent
+	if m.cfg != nil {
+		m.safety = m.safety.WithStrict(m.cfg.StrictSafety(m.intent))
+	}
+	candidates := []suggest.Suggestion{{
+		Text:        reply.Command,
+		Sources:     []string{"llm"},
+		Group:       reply.Approach,
+		Notes:       reply.Notes,
+		Description: reply.Description,
+		Risk:        suggest.Risk(reply.Risk),
+	}}
+	for _, alt := range reply.Alternatives {
+		candidates = append(candidates, suggest.Suggestion{
+			Text:        alt.Command,
+			Sources:     []string{"llm"},
+			Group:       alt.Approach,
+			Notes:       alt.Notes,
+			Description: alt.Description,
+			Risk:        suggest.Risk(alt.Risk),
+		})
+	}
+	candidates = append(candidates, m.historyCandidates()...)
+	m.candidates = suggest.GroupByApproach(suggest.Merge(candidates))
+	m.marked = nil
 	m.state = StateSelecting
 	return m
 }
@@ -375,6 +1315,33 @@ func (m *AppModel) executeCommand() (tea.Model, tea.Cmd) {
 	}
 
 	choice := m.candidates[m.cursor]
+	if model, cmd, held := m.guardForbidden(choice.Text, m.executeCommand); held {
+		return model, cmd
+	}
+
+	if m.queueing() {
+		return m.queueCommands([]string{choice.Text})
+	}
+
+	// Quick actions have no query; record them in history under their title
+	if m.originalQuery == "" {
+		m.originalQuery = choice.Group
+	}
+
+	// The shell runs it, so this is not an execution and cd/export take effect too
+	if shell.Active() {
+		return m.handoff(choice.Text)
+	}
+
+	if model, cmd, refused := m.refuseExecution([]string{choice.Text}); refused {
+		return model, cmd
+	}
+
+	// Running a pure cd/export in a child process is a silent no-op; hand it back instead
+	if _, pure := runner.ParentShellEffects(choice.Text); pure && m.client.Host() == "" {
+		return m.emitSnippet()
+	}
+
 	m.selectedCommand = choice.Text
 	m.state = StateCompleted
 
@@ -392,10 +1359,15 @@ func (m *AppModel) renderAskingView() string {
 
 	// Show conversation history if any
 	if len(m.contextHistory) > 0 {
-		s.WriteString(lipgloss.NewStyle().Faint(true).Render("对话历史:"))
+		s.WriteString(faintStyle().Render("对话历史:"))
 		s.WriteString("\n")
-		for i, ctx := range m.contextHistory {
-			s.WriteString(lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("%d. %s", i+1, ctx)))
+		shown, folded := m.contextHistory.visible(m.showAllHistory)
+		if folded > 0 {
+			s.WriteString(faintStyle().Render(fmt.Sprintf("… 较早的 %d 轮已折叠，Ctrl+O 显示全部", folded)))
+			s.WriteString("\n")
+		}
+		for i, e := range shown {
+			s.WriteString(faintStyle().Render(fmt.Sprintf("%d. 问: %s\n   答: %s", folded+i+1, e.question, e.display())))
 			s.WriteString("\n")
 		}
 		s.WriteString("\n")
@@ -406,14 +1378,51 @@ func (m *AppModel) renderAskingView() string {
 	s.WriteString(prompt)
 	s.WriteString("\n\n")
 
-	// Input line using textinput component
-	s.WriteString(m.textInput.View())
-	s.WriteString("\n\n")
+	// Input line using textinput component, or one line per field for structured values
+	if m.askForm != nil {
+		s.WriteString(m.renderAskForm())
+		s.WriteString("\n")
+	} else {
+		s.WriteString(m.textInput.View())
+		s.WriteString("\n\n")
+	}
+
+	if !m.askDeadline.IsZero() {
+		remaining := max(0, int(time.Until(m.askDeadline).Seconds()+0.5))
+		s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).
+			Render(fmt.Sprintf("⏱ %d 秒内未回答将按最合理的假设生成命令", remaining)))
+		s.WriteString("\n\n")
+	}
 
 	// Help text
-	helpText := lipgloss.NewStyle().
-		Faint(true).
-		Render("Enter: 提交, Ctrl+C/Esc: 取消")
+	help := "Enter: 提交, Ctrl+C/Esc: 取消, ?: 帮助"
+	if m.askForm != nil {
+		help = "Enter: 下一项/提交, ↑/↓: 切换, Tab: 补全路径, Ctrl+C/Esc: 取消, ?: 帮助"
+	}
+	helpText := faintStyle().
+		Render(help)
+	s.WriteString(helpText)
+
+	return s.String()
+}
+
+func (m *AppModel) renderApprovingView() string {
+	var s strings.Builder
+
+	s.WriteString(m.titleStyle.Render("📤 以下内容将发送给 AI (已脱敏):"))
+	s.WriteString("\n\n")
+
+	if m.pendingApproval != nil {
+		box := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(theme.muted).
+			Padding(0, 1)
+		s.WriteString(box.Render(m.pendingApproval.text))
+		s.WriteString("\n\n")
+	}
+
+	helpText := faintStyle().
+		Render("y/Enter: 发送, n/Esc: 拒绝, Ctrl+C: 取消, ?: 帮助")
 	s.WriteString(helpText)
 
 	return s.String()
@@ -426,54 +1435,201 @@ func (m *AppModel) renderSelectingView() string {
 
 	var s strings.Builder
 
+	if m.explanation != "" {
+		s.WriteString(m.titleStyle.Render("💡 错误分析:") + "\n")
+		s.WriteString(lipgloss.NewStyle().Width(80).Render(m.explanation) + "\n\n")
+	}
+	if status := m.repairStatus(); status != "" {
+		s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).Render("🔧 "+status) + "\n\n")
+	}
+
 	// Title
 	title := m.titleStyle.Render("🚀 选择要执行的命令:")
 	s.WriteString(title + "\n\n")
 
-	// Command list
+	// Command list, with group headers when candidates use different approaches
+	grouped := suggest.GroupCount(m.candidates) > 1
 	for i, item := range m.candidates {
+		if grouped && item.Group != "" && (i == 0 || m.candidates[i-1].Group != item.Group) {
+			if i > 0 {
+				s.WriteString("\n")
+			}
+			s.WriteString(lipgloss.NewStyle().Bold(true).Foreground(theme.muted).Render("▸ " + item.Group))
+			s.WriteString("\n")
+		}
 		var line string
+		mark := ""
+		if len(m.marked) > 0 {
+			mark = "[ ] "
+			if m.marked[i] {
+				mark = "[x] "
+			}
+		}
+		source := faintStyle().
+			Foreground(theme.muted).
+			Render(fmt.Sprintf("[%s]", strings.Join(item.Sources, ", ")))
+		if badge := m.safetyBadge(item); badge != "" {
+			source += " " + badge
+		}
 		if m.cursor == i {
 			// Selected item
-			cursor := m.selectedStyle.Render("➜ ")
-			cmdText := m.selectedStyle.Render(item.Text)
-			source := lipgloss.NewStyle().
-				Faint(true).
-				Foreground(lipgloss.Color("8")).
-				Render(fmt.Sprintf("[%s]", item.Source))
+			cursor := m.selectedStyle.Render("➜ " + mark)
+			cmdText := renderCommand(item.Text, m.termWidth()-textWidth(source)-1, 2+len(mark), m.forbiddenStyle(item.Text, m.selectedStyle))
 			line = cursor + cmdText + " " + source
 		} else {
 			// Unselected item
-			cursor := "  "
-			cmdText := m.itemStyle.Render(item.Text)
-			source := lipgloss.NewStyle().
-				Faint(true).
-				Foreground(lipgloss.Color("8")).
-				Render(fmt.Sprintf("[%s]", item.Source))
+			cursor := "  " + mark
+			cmdText := renderCommand(item.Text, m.termWidth()-textWidth(source)-1, 2+len(mark), m.forbiddenStyle(item.Text, m.itemStyle))
 			line = cursor + cmdText + " " + source
 		}
 		s.WriteString(line + "\n")
 	}
 
+	if m.cursor < len(m.candidates) {
+		s.WriteString(m.renderSafetyReasons(m.candidates[m.cursor].Text))
+		if m.previewable(m.candidates[m.cursor].Text) {
+			s.WriteString(faintStyle().Render("\n✏ 该命令会就地修改文件，按 d 预览修改结果") + "\n")
+		}
+	}
+
+	if m.cursor < len(m.candidates) && m.candidates[m.cursor].Description != "" {
+		s.WriteString(lipgloss.NewStyle().Foreground(theme.accent).
+			Render("\n💬 " + m.candidates[m.cursor].Description))
+		s.WriteString("\n")
+	}
+
+	if m.offlineHit != "" {
+		s.WriteString(faintStyle().
+			Render("\n📚 来自离线命令库: " + m.offlineHit + "，不符合需求时按 a 询问 AI"))
+		s.WriteString("\n")
+	}
+
+	if note := m.pipedNote(); note != "" {
+		s.WriteString(faintStyle().Render("\n📎 " + note))
+		s.WriteString("\n")
+	}
+
+	if note := m.intentNote(); note != "" {
+		s.WriteString(faintStyle().Render("\n🧭 " + note))
+		s.WriteString("\n")
+	}
+
+	if m.answeredBy != "" {
+		s.WriteString(faintStyle().
+			Render("\n🔀 " + m.failoverNote()))
+		s.WriteString("\n")
+	}
+
+	if m.slowQuery != nil {
+		s.WriteString(faintStyle().
+			Render("\n🐢 " + m.slowNote()))
+		s.WriteString("\n")
+	}
+
+	if m.assumptions != "" {
+		s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).
+			Render("\n⚠ 基于假设: " + m.assumptions))
+		s.WriteString("\n")
+	}
+
+	if m.cursor < len(m.candidates) {
+		for _, note := range m.candidates[m.cursor].Notes {
+			s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).Render("\n⚠ " + note))
+			s.WriteString("\n")
+		}
+	}
+
+	s.WriteString(m.renderFixDiff())
+
+	if m.cursor < len(m.candidates) && m.client.Host() == "" {
+		if stmts, _ := runner.ParentShellEffects(m.candidates[m.cursor].Text); len(stmts) > 0 {
+			s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).
+				Render(fmt.Sprintf("\n⚠ %s 不会影响当前 shell，按 s 生成可 source 的脚本", strings.Join(stmts, "; "))))
+			s.WriteString("\n")
+		}
+	}
+
+	if m.cursor < len(m.candidates) && m.needsSudoPrevalidate(m.candidates[m.cursor].Text) {
+		s.WriteString(lipgloss.NewStyle().Foreground(theme.warning).
+			Render("\n🔐 该命令需要 sudo，执行前会先验证凭据 (sudo -v)"))
+		s.WriteString("\n")
+	}
+
 	// Help text
-	helpText := lipgloss.NewStyle().
-		Faint(true).
-		Render("\n↑/↓ 或 k/j: 选择, Enter: 执行, c: 复制, q/Esc: 退出")
+	enter := "执行"
+	switch {
+	case len(m.pending) > 0:
+		enter = "选定，继续下一项任务"
+	case m.queueing():
+		enter = "选定并审阅执行计划"
+	case shell.Active():
+		enter = "放到命令行"
+	case m.copyOnlyMode():
+		enter = "复制"
+	}
+	keys := "\n↑/↓ 或 k/j: 选择, Enter: " + enter + ", 空格: 多选, e: 编辑, x: 解释, w: 为什么, f: 流程图, c: 复制, s: 生成 source 脚本, "
+	if enter == "执行" {
+		keys += "y: 执行并复制输出, "
+	}
+	if m.cursor < len(m.candidates) && m.previewable(m.candidates[m.cursor].Text) {
+		keys += "d: 预览修改, "
+	}
+	if len(m.sinks) > 0 {
+		keys += "o: 发送到, "
+	}
+	if m.share != nil {
+		keys += "u: 分享, "
+	}
+	if m.offlineHit != "" {
+		keys += "a: 询问 AI, "
+	}
+	keys += "P: 固定上下文, "
+	helpText := faintStyle().
+		Render(keys + "q/Esc: 退出, ?: 帮助")
 	s.WriteString(helpText)
 
 	return s.String()
 }
 
-func (m *AppModel) copyCommand() (tea.Model, tea.Cmd) {
+// riskBadge renders the model's risk assessment of a candidate; low risk is not shown
+func riskBadge(risk suggest.Risk) string {
+	switch risk {
+	case suggest.RiskMedium:
+		return lipgloss.NewStyle().Foreground(theme.warning).Render("●中风险")
+	case suggest.RiskHigh:
+		return lipgloss.NewStyle().Foreground(theme.danger).Bold(true).Render("▲高风险")
+	default:
+		return ""
+	}
+}
+
+// emitSnippet writes the selected command to a sourceable script and exits
+func (m *AppModel) emitSnippet() (tea.Model, tea.Cmd) {
 	if m.cursor >= len(m.candidates) {
 		return m, nil
 	}
+	if model, cmd, held := m.guardForbidden(m.candidates[m.cursor].Text, m.emitSnippet); held {
+		return model, cmd
+	}
 
-	choice := m.candidates[m.cursor]
-	m.copiedCommand = choice.Text
+	path, err := runner.WriteSnippet(config.CacheDir(), m.candidates[m.cursor].Text)
+	if err != nil {
+		m.state = StateError
+		m.err = err
+		return m, nil
+	}
+	m.snippetPath = path
+	m.state = StateSnippet
+	return m, tea.Quit
+}
+
+// copyText copies text to the clipboard; command is the underlying raw command
+func (m *AppModel) copyText(command, text string) (tea.Model, tea.Cmd) {
+	m.copiedCommand = command
+	m.copiedText = text
 
 	return m, func() tea.Msg {
-		err := copyToClipboard(choice.Text)
+		err := m.clipboard.Copy(text)
 		return copiedMsg{
 			success: err == nil,
 			err:     err,
@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/probe"
+	"termi.sh/termi/internal/runner"
+)
+
+// verify asks the LLM for a cheap check of whether the executed command achieved
+// the user's intent, runs it and reports pass/fail
+func (m *AppModel) verify(command string) {
+	if m.cfg == nil || !m.cfg.Exec.Verify {
+		return
+	}
+
+	glyph.Println("\n🔍 正在生成验证命令...")
+	check, err := m.client.Verify(m.ctx, m.originalQuery, command)
+	if err != nil {
+		fmt.Printf("生成验证命令失败: %v\n", m.formatLLMError(err))
+		return
+	}
+	if check == "" {
+		fmt.Println("该命令无法自动验证")
+		return
+	}
+
+	fmt.Printf("验证命令: %s\n", check)
+	if m.copyOnlyMode() {
+		fmt.Println("已开启仅复制模式 (safety.copy_only)，不运行验证命令")
+		return
+	}
+	// The check comes from the model like any other command and passes the same gates;
+	// forbidden commands are refused outright rather than offered an override
+	r := m.safety.Analyze(check)
+	if r.Forbidden {
+		fmt.Println("验证命令被禁止执行 (safety.forbidden)，已跳过验证")
+		return
+	}
+	if !isReadOnlyCheck(check) && !confirm("该验证命令不在只读白名单内，是否运行?") {
+		fmt.Println("已跳过验证")
+		return
+	}
+	req, err := m.gate(check, r)
+	if err != nil {
+		fmt.Printf("已跳过验证: %v\n", err)
+		return
+	}
+
+	err = runner.Run(check, m.runOptions()...)
+	m.approval.Executed(req, runner.ExitCode(err))
+	if err != nil {
+		glyph.Printf("❌ 验证未通过 (退出码 %d)\n", runner.ExitCode(err))
+		return
+	}
+	glyph.Println("✅ 验证通过")
+}
+
+// isReadOnlyCheck reports whether a verification command can run without confirmation:
+// either a whitelisted probe or a plain `test` expression without shell metacharacters
+func isReadOnlyCheck(cmd string) bool {
+	if probe.Allowed(cmd) {
+		return true
+	}
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 || strings.ContainsAny(cmd, "|&;<>$`\\(){}\n") {
+		return false
+	}
+	return fields[0] == "test" || (fields[0] == "[" && fields[len(fields)-1] == "]")
+}
+
+// confirm asks a yes/no question on the terminal after the TUI has exited
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := readLine()
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
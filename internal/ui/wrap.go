@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// defaultWidth is assumed until the terminal reports its size
+const defaultWidth = 80
+
+// softWrapMark ends display lines that were wrapped by termi rather than by the command itself;
+// it follows a space when the line broke between words and touches the text when a word was split
+const softWrapMark = "↩"
+
+// wrapCommand splits a command into display lines no wider than width, breaking only
+// at spaces where possible. It is used purely for rendering: what gets copied or
+// executed is always the original text, so no newline is ever inserted into it.
+func wrapCommand(text string, width int) []string {
+	width = max(width-runewidth.StringWidth(" "+softWrapMark), 10)
+
+	var lines []string
+	for _, raw := range strings.Split(text, "\n") {
+		var cur strings.Builder
+		curWidth := 0
+		for i, word := range strings.Split(raw, " ") {
+			w := runewidth.StringWidth(word)
+			if i > 0 {
+				if curWidth+1+w <= width {
+					cur.WriteString(" " + word)
+					curWidth += 1 + w
+					continue
+				}
+				lines = append(lines, cur.String()+" "+softWrapMark)
+				cur.Reset()
+				curWidth = 0
+			}
+			// Words wider than a line (long URLs, base64) are broken by display width
+			for w > width {
+				head := runewidth.Truncate(word, width-curWidth, "")
+				if head == "" {
+					break
+				}
+				lines = append(lines, cur.String()+head+softWrapMark)
+				cur.Reset()
+				curWidth = 0
+				word = word[len(head):]
+				w = runewidth.StringWidth(word)
+			}
+			cur.WriteString(word)
+			curWidth += w
+		}
+		lines = append(lines, cur.String())
+	}
+	return lines
+}
+
+// renderCommand renders a wrapped command with continuation lines indented under the first,
+// fading the soft-wrap marks so they read as decoration rather than part of the command
+func renderCommand(text string, width, indent int, style lipgloss.Style) string {
+	faint := faintStyle()
+	lines := wrapCommand(text, width-indent)
+	for i, line := range lines {
+		if body, ok := strings.CutSuffix(line, softWrapMark); ok {
+			line = style.Render(body) + faint.Render(softWrapMark)
+		} else {
+			line = style.Render(line)
+		}
+		if i > 0 {
+			line = strings.Repeat(" ", indent) + line
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textWidth measures rendered text in terminal cells, ignoring styling. Like wrapCommand it
+// follows the locale, so East Asian ambiguous-width symbols take two cells under a CJK locale
+// (RUNEWIDTH_EASTASIAN=0 overrides this for terminals that draw them narrow)
+func textWidth(s string) int {
+	return runewidth.StringWidth(escapeSequence.ReplaceAllString(s, ""))
+}
+
+// wrapText splits prose into lines no wider than width, breaking at the last space where
+// there is one and between characters otherwise, as Chinese text has no spaces
+func wrapText(text string, width int) []string {
+	width = max(width, 10)
+	var lines []string
+	for _, raw := range strings.Split(text, "\n") {
+		for runewidth.StringWidth(raw) > width {
+			head := runewidth.Truncate(raw, width, "")
+			if i := strings.LastIndexByte(head, ' '); i > 0 {
+				head = head[:i]
+			}
+			lines = append(lines, head)
+			raw = strings.TrimLeft(raw[len(head):], " ")
+		}
+		lines = append(lines, raw)
+	}
+	return lines
+}
+
+// termWidth returns the terminal width, or a default before the first resize message
+func (m *AppModel) termWidth() int {
+	if m.width <= 0 {
+		return defaultWidth
+	}
+	return m.width
+}
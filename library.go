@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/library"
+	"termi.sh/termi/internal/skills"
+)
+
+// runLibrary 处理 termi library 子命令：订阅团队共享的技能包仓库并审阅其中的技能包
+func runLibrary(args []string) error {
+	lib := library.Open(config.LibraryPath(), config.LibraryDir())
+
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		return listLibrary(lib)
+	case "add":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("用法: termi library add <git 仓库地址> [名称]")
+		}
+		name := ""
+		if len(args) == 3 {
+			name = args[2]
+		}
+		src, err := lib.Add(args[1], name)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已订阅 %s，运行 termi library sync 拉取其中的技能包\n", src.Name)
+		return nil
+	case "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("用法: termi library remove <名称>")
+		}
+		if err := lib.Remove(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("已取消订阅 %s，已安装的技能包仍保留，可用 termi skills disable 禁用\n", args[1])
+		return nil
+	case "sync":
+		return syncLibrary(lib, args[1:])
+	case "review":
+		if len(args) > 2 {
+			return fmt.Errorf("用法: termi library review [名称]")
+		}
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return reviewLibrary(lib, name)
+	default:
+		return fmt.Errorf("未知的 library 子命令: %s（可用: list, add, remove, sync, review）", args[0])
+	}
+}
+
+func listLibrary(lib *library.Library) error {
+	sources, err := lib.Sources()
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		fmt.Println("尚未订阅任何共享仓库，用 termi library add <git 仓库地址> 订阅")
+		return nil
+	}
+	items, err := lib.Items("")
+	if err != nil {
+		return err
+	}
+	for _, src := range sources {
+		synced := "尚未同步"
+		if !src.SyncedAt.IsZero() {
+			synced = "同步于 " + src.SyncedAt.Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%s  %s（%s）\n", src.Name, src.URL, synced)
+		for _, it := range items {
+			if it.Source == src.Name {
+				fmt.Printf("  %s %s  %s\n", runewidth.FillRight(it.Skill.Name, 12), it.Status, it.Skill.Description)
+			}
+		}
+	}
+	return nil
+}
+
+// syncLibrary 同步指定的仓库，未指定时同步所有订阅的仓库
+func syncLibrary(lib *library.Library, names []string) error {
+	if len(names) == 0 {
+		sources, err := lib.Sources()
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			fmt.Println("尚未订阅任何共享仓库，用 termi library add <git 仓库地址> 订阅")
+			return nil
+		}
+		for _, src := range sources {
+			names = append(names, src.Name)
+		}
+	}
+
+	var failed []string
+	for _, name := range names {
+		fmt.Printf("同步 %s...\n", name)
+		if err := lib.Sync(context.Background(), name); err != nil {
+			fmt.Printf("  %v\n", err)
+			failed = append(failed, name)
+		}
+	}
+
+	items, err := lib.Items("")
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, it := range items {
+		if it.Status != library.Approved {
+			pending++
+		}
+	}
+	if pending > 0 {
+		fmt.Printf("%d 个技能包是新的或有更新，运行 termi library review 审阅后才会启用\n", pending)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("同步失败: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// reviewLibrary 逐个展示待审阅的技能包，用户确认后安装。有更新的技能包在确认前继续使用上次确认的版本
+func reviewLibrary(lib *library.Library, name string) error {
+	items, err := lib.Items(name)
+	if err != nil {
+		return err
+	}
+	store := skills.NewStore(config.SkillsDir())
+	in := bufio.NewReader(os.Stdin)
+
+	reviewed := 0
+	for _, it := range items {
+		if it.Status == library.Approved {
+			continue
+		}
+		reviewed++
+		fmt.Printf("\n[%s] %s/%s（%s）\n", it.Status, it.Source, it.Path, it.Skill.Name)
+		if it.Skill.Description != "" {
+			fmt.Printf("描述: %s\n", it.Skill.Description)
+		}
+		fmt.Printf("关键词: %s\n", strings.Join(it.Skill.Keywords, ", "))
+		glyph.Printf("%s\n%s\n%s\n", strings.Repeat("─", 40), it.Skill.Body, strings.Repeat("─", 40))
+		fmt.Print("命中关键词时以上内容会附加到提示词中，启用? [y/N/q] ")
+		answer, _ := in.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			if err := lib.Approve(it, store); err != nil {
+				return err
+			}
+			fmt.Printf("已启用技能包 %s\n", it.Skill.Name)
+		case "q":
+			return nil
+		default:
+			fmt.Println("已跳过，下次审阅时会再次询问")
+		}
+	}
+	if reviewed == 0 {
+		fmt.Println("没有待审阅的技能包")
+	}
+	return nil
+}
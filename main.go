@@ -1,53 +1,291 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/hosts"
 	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/objects"
+	"termi.sh/termi/internal/piped"
+	"termi.sh/termi/internal/shellquote"
+	"termi.sh/termi/internal/telemetry"
 	"termi.sh/termi/internal/ui"
 )
 
 func main() {
 	if err := run(); err != nil {
+		var exit exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		fmt.Printf("错误: %v\n", err)
 		os.Exit(1)
 	}
 }
 
 func run() error {
-	if len(os.Args) < 2 {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "skills":
+			return runSkills(args[1:])
+		case "library":
+			return runLibrary(args[1:])
+		case "why":
+			return runWhy(args[1:])
+		case "fix":
+			return runFix(args[1:])
+		case "init":
+			return runInit(args[1:])
+		case "shell-init":
+			return runShellInit(args[1:])
+		case "experiments":
+			return runExperiments()
+		case "trust":
+			return runTrust(args[1:])
+		case "serve":
+			return runServe(args[1:])
+		case "history":
+			return runHistory(args[1:])
+		case "undo":
+			return runUndo(args[1:])
+		case "config":
+			return runConfig(args[1:])
+		case "stats":
+			return runStats(args[1:])
+		case "bench":
+			return runBench(args[1:])
+		case "doctor":
+			return runDoctor(args[1:])
+		case "prompt":
+			return runPrompt(args[1:])
+		case "safety":
+			return runSafety(args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet("termi", flag.ContinueOnError)
+	fs.Usage = func() { _ = showUsage() }
+	host := fs.String("host", "", "在远程主机上执行，例如 user@server")
+	lite := fs.Bool("lite", false, "低带宽模式")
+	providerFromEnv := fs.Bool("provider-from-env", false, "提供商取自环境变量，即使存在配置文件")
+	var out headlessOptions
+	fs.BoolVar(&out.print, "p", false, "只输出生成的命令")
+	fs.BoolVar(&out.print, "print", false, "只输出生成的命令")
+	fs.BoolVar(&out.json, "json", false, "以 JSON 输出候选命令、追问与解释")
+	fs.BoolVar(&out.yes, "y", false, "不经选择直接执行")
+	fs.BoolVar(&out.yes, "yes", false, "不经选择直接执行")
+	var files listFlag
+	fs.Var(&files, "f", "附加文件作为上下文，可重复指定")
+	fs.Var(&files, "file", "附加文件作为上下文，可重复指定")
+	var tasks listFlag
+	fs.Var(&tasks, "m", "排队一项任务，可重复指定，各项任务的命令合并为一个执行计划")
+	copyOutput := fs.Bool("copy-output", false, "执行后将命令的输出复制到剪贴板")
+	copyLines := fs.Int("copy-lines", 0, "只复制输出的最后 N 行")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = fs.Args()
+
+	// 写在 -m 之后的需求作为最后一项任务
+	if len(tasks) > 0 && len(args) > 0 {
+		tasks = append(tasks, strings.Join(args, " "))
+		args = nil
+	}
+	if len(tasks) > 0 && out.enabled() {
+		return fmt.Errorf("-m 排队的任务需要在交互模式中审阅合并后的执行计划，不能与 --print、--json、--yes 同时使用")
+	}
+
+	// 只通过管道提供了内容时，默认解释其中的错误
+	pipedOnly := len(args) == 0 && len(tasks) == 0 && piped.Attached()
+	if pipedOnly {
+		args = []string{pipedQuery}
+	}
+	if len(args) == 0 && out.enabled() {
+		return fmt.Errorf("--print、--json、--yes 需要在参数中提供需求")
+	}
+	if len(args) == 0 && len(tasks) == 0 && len(files) > 0 {
+		return fmt.Errorf("--file 需要在参数中提供需求，例如 termi --file nginx.conf 为什么加载失败")
+	}
+	if out.enabled() && (*copyOutput || *copyLines > 0) {
+		return fmt.Errorf("--copy-output 只能在交互模式中使用，非交互模式请直接通过管道处理输出")
+	}
+	// 在可识别的项目目录中不带需求运行时，提供项目快捷操作
+	if len(args) == 0 && len(tasks) == 0 && !ui.HasQuickActions() {
 		return showUsage()
 	}
 
-	cfg, err := config.LoadConfig()
+	cfg, src, err := config.Load(*providerFromEnv)
 	if err != nil {
 		showConfigHelp(err)
 		return err
 	}
+	glyph.Configure(cfg.Theme.Glyphs)
+	if src.Conflict() {
+		glyph.Fprintf(os.Stderr, "ⓘ %s\n", src.Describe())
+	}
+
+	if pipedOnly && cfg.Stdin.Disabled {
+		return showUsage()
+	}
+
+	var opts []llm.Option
+	if !cfg.Stdin.Disabled && piped.Attached() {
+		in, err := piped.Read(os.Stdin, cfg.Stdin.Limit(), cfg.Stdin.Truncate)
+		if err != nil && !errors.Is(err, piped.ErrBinary) {
+			return fmt.Errorf("读取管道输入失败: %w", err)
+		}
+		if err != nil {
+			glyph.Fprintf(os.Stderr, "⚠ %v\n", err)
+		}
+		// 没有控制终端时（CI、cron）保持原样，交互读取到的是输入结束
+		_ = piped.ReopenTTY()
+		if in.Text != "" {
+			opts = append(opts, llm.WithPipedInput(in))
+		}
+	}
+	if len(files) > 0 {
+		attached, err := readFiles(cfg, files)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, llm.WithFiles(attached))
+	}
+	if *host != "" {
+		opts = append(opts, llm.WithHost(*host, hosts.NewStore(config.HostsDir())))
+	} else if o, ok := lastObjects(cfg); ok {
+		opts = append(opts, llm.WithObjects(o))
+	}
+	if *lite {
+		cfg.Lite = true
+	}
+	if *copyOutput {
+		cfg.Exec.CopyOutput = true
+	}
+	if *copyLines > 0 {
+		cfg.Exec.CopyLines = *copyLines
+	}
 
-	if err := llm.Initialize(cfg); err != nil {
+	shutdown, err := telemetry.Setup(&cfg.Telemetry)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "导出追踪数据失败: %v\n", err)
+		}
+	}()
+
+	client, err := llm.NewClient(cfg, opts...)
+	if err != nil {
 		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
 	}
+	warnSafetyRules()
+
+	if len(tasks) > 0 {
+		return ui.RunTasks(cfg, client, tasks)
+	}
+	query := strings.Join(args, " ")
+	if out.enabled() {
+		return runHeadless(cfg, client, query, out)
+	}
+	return ui.RunApp(cfg, client, query)
+}
+
+// listFlag 可重复指定的参数（--file、-m）
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// readFiles 读取 --file 指定的文件，长度限制与截断方式与管道输入相同（stdin.max_kb、stdin.truncate）
+func readFiles(cfg *config.Config, paths []string) ([]llm.File, error) {
+	var files []llm.File
+	for _, path := range paths {
+		in, err := piped.ReadFile(path, cfg.Stdin.Limit(), cfg.Stdin.Truncate)
+		if err != nil {
+			return nil, fmt.Errorf("读取附加文件失败: %w", err)
+		}
+		files = append(files, llm.File{Path: path, Input: in})
+	}
+	return files, nil
+}
 
-	query := strings.Join(os.Args[1:], " ")
-	return ui.RunApp(query)
+// lastObjects 取出当前目录中上一条 PowerShell、Nushell 命令刚刚输出的对象（exec.no_object_capture 关闭）
+func lastObjects(cfg *config.Config) (objects.Output, bool) {
+	if cfg.Exec.NoObjectCapture {
+		return objects.Output{}, false
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return objects.Output{}, false
+	}
+	return objects.Last(config.ObjectsPath(), dir, shellquote.Name(cfg.Shell))
 }
 
+// pipedQuery 只通过管道提供内容、没有输入需求时发送给模型的默认需求
+const pipedQuery = "解释管道输入中的错误，并给出修复命令"
+
 func showUsage() error {
 	fmt.Println("请在命令后输入自然语言，例如：\n  termi 我想对 baidu.com 发起 ping")
+	fmt.Println("\n诊断上一条失败的命令并给出修正后的命令（需要 termi init 的 shell 集成）：\n  termi fix [补充说明]")
+	fmt.Println("\n排队多项任务，在同一会话中依次生成命令，最后审阅合并的执行计划：\n  termi -m \"压缩当前目录下的所有图片\" -m \"然后上传到 s3\"")
+	fmt.Println("\n在远程主机上执行：\n  termi --host user@server 查看磁盘占用")
+	fmt.Println("\n把日志、报错输出或文件片段通过管道交给 termi 作为上下文：\n  kubectl logs pod | termi 为什么报错，给我修复命令")
+	fmt.Println("\n附加文件作为上下文（可重复指定，内容发送前同样会脱敏）：\n  termi --file nginx.conf 为什么这个配置加载失败")
+	fmt.Println("\n在 tmux/screen 中解释终端里最近的错误：\n  termi why [-n 行数] [补充说明]")
+	fmt.Println("\n在脚本或快捷键中使用（只输出命令 / 输出 JSON / 直接执行）：\n  termi -p 查看本机 ip\n  termi --json 查看本机 ip\n  termi --yes 统计当前目录文件数")
+	fmt.Println("\n执行后把输出复制到剪贴板（也可以在候选列表中按 y）：\n  termi --copy-output 查看本机 ip\n  termi --copy-lines 20 查看最近的系统日志")
+	fmt.Println("\n低带宽模式（精简提示词，适合计量网络或小模型）：\n  termi --lite 统计当前目录文件数")
+	fmt.Println("\n把执行过的命令写入当前 shell 的历史（在 ~/.bashrc 或 ~/.zshrc 中加入）：\n  eval \"$(termi init bash)\"")
+	fmt.Println("\n在命令行输入需求后按 Ctrl+G，把选中的命令放到命令行上编辑后执行（在 ~/.zshrc 中加入，bash、fish 类似）：\n  eval \"$(termi shell-init zsh)\"")
+	fmt.Println("\n搜索历史记录，重新执行、复制或删除其中的命令：\n  termi history [关键字]")
+	fmt.Println("\n恢复最近一次执行前自动备份的文件（需在配置中开启 exec.backup）：\n  termi undo [--list]")
+	fmt.Println("\n配置 LLM 提供商（交互式向导），或查看、修改配置项：\n  termi config init\n  termi config show | get <键> | set <键> <值>")
+	fmt.Println("\n查看最近的 token 用量与估算费用：\n  termi stats [--days 30] [--daily]")
+	fmt.Println("\n查看提示词实验各变体的采纳率：\n  termi experiments")
+	fmt.Println("\n为团队提供带共享缓存、脱敏与每日用量限制的 OpenAI 兼容代理：\n  termi serve --cache-proxy --listen 0.0.0.0:8787")
+	fmt.Println("\n压测命令生成流程（--mock 使用内置的模拟提供商），报告耗时分位与错误率：\n  termi bench --concurrency 20 --requests 500 [--mock]")
+	fmt.Println("\n同时存在配置文件与 OPENAI_API_KEY 等环境变量时以配置文件为准，改用环境变量中的提供商：\n  termi --provider-from-env 查看本机 ip")
+	fmt.Println("\n输出发送给模型的完整提示词而不请求提供商，用于排查回答异常或对比模板修改：\n  termi prompt render --query \"查看本机 ip\" [--json]")
+	fmt.Println("\n检查配置及其来源，以及各提供商能否按各自的代理设置连通：\n  termi doctor [--provider-from-env]")
+	fmt.Println("\n订阅团队共享的技能包 git 仓库，同步后逐个审阅确认才会启用：\n  termi library add <git 仓库地址>\n  termi library sync && termi library review")
+	fmt.Println("\n验证 safety.d 中补充的风险规则及其示例命令，或查看一条命令的检查结果：\n  termi safety check [文件...]\n  termi safety test <命令>")
+	fmt.Println("\n信任当前目录，允许读取其中的项目文件（查看、拒绝、重置用 list、deny、reset）：\n  termi trust")
+	fmt.Println("\n在 Node、Go、Rust、Terraform 项目目录中直接运行 termi，可选择运行测试、构建等快捷操作")
 	return nil
 }
 
 func showConfigHelp(err error) {
 	fmt.Printf("加载配置失败: %v\n", err)
-	fmt.Println("\n请设置以下环境变量之一：")
+	fmt.Println("\n运行 termi config init 通过向导完成配置，或者")
+	fmt.Println("设置以下环境变量之一：")
 	fmt.Println("  OPENAI_API_KEY - 使用 OpenAI")
 	fmt.Println("  AZURE_OPENAI_API_KEY - 使用 Azure OpenAI")
 	fmt.Println("  GEMINI_API_KEY - 使用 Google Gemini")
 	fmt.Println("  ANTHROPIC_API_KEY - 使用 Anthropic Claude")
 	fmt.Println("  LLAMA_CPP_BASE_URL - 使用 Llama.cpp 服务")
+	fmt.Println("  OPENAI_COMPATIBLE_BASE_URL - 使用 OpenAI 兼容端点（LM Studio、vLLM 等），配合 OPENAI_COMPATIBLE_MODEL")
+	fmt.Println("  OLLAMA_HOST - 使用 Ollama 服务，模型由 OLLAMA_MODEL 指定（默认 llama3.2）")
 	fmt.Println("\n或创建配置文件: ~/.config/termi/config.json")
+	fmt.Println("存在配置文件时环境变量不生效，加 --provider-from-env 可改用环境变量中的提供商")
 }
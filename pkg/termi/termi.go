@@ -0,0 +1,196 @@
+// Package termi 以库的形式提供 termi 的命令生成流程：读取配置、请求 LLM 提供商、
+// 解析模型回复，并用本地安全规则检查每条候选命令。部署工具、聊天机器人等 Go 程序
+// 可以直接嵌入，不必调用 termi 可执行文件再解析它的输出。
+//
+// 本包只生成与检查命令，从不执行命令；是否执行、如何确认由调用方决定。
+// 生成过程中模型可能请求在本机执行只读的环境探测（如 uname、which），
+// 与命令行中的行为相同。
+//
+//	cfg, err := termi.LoadConfig()
+//	if err != nil {
+//		return err
+//	}
+//	client, err := termi.New(cfg)
+//	if err != nil {
+//		return err
+//	}
+//	res, err := client.Suggest(ctx, "列出占用 8080 端口的进程")
+//	if err != nil {
+//		return err
+//	}
+//	if best, ok := res.Best(); ok && best.Risk < termi.High {
+//		fmt.Println(best.Command)
+//	}
+package termi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/safety"
+)
+
+// ErrNoCommand 模型既没有给出命令，也没有追问或直接回答
+var ErrNoCommand = errors.New("LLM 未能生成可执行命令，请尝试提供更详细的描述")
+
+// Config termi 的配置，格式与 termi 命令行使用的 config.json 相同
+type Config struct {
+	c *config.Config
+}
+
+// LoadConfig 与 termi 命令行一样加载配置：读取配置文件，不存在时从环境变量读取
+func LoadConfig() (*Config, error) {
+	c, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{c: c}, nil
+}
+
+// ParseConfig 从 JSON 解析配置，格式与 config.json 相同，配置值中的 ${VAR} 会被展开。
+// 适合把配置放在调用方自己的配置文件或密钥管理中的场景
+func ParseConfig(data []byte) (*Config, error) {
+	var c config.Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	expanded, err := c.ExpandEnv()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{c: expanded}, nil
+}
+
+// Validate 检查配置是否完整有效
+func (c *Config) Validate() error {
+	return c.c.Validate()
+}
+
+// Risk 本地安全规则评估的风险等级
+type Risk int
+
+const (
+	Safe     Risk = Risk(safety.Safe)     // 未命中任何规则
+	Caution  Risk = Risk(safety.Caution)  // 提权、结束进程、修改权限等需要留意的操作
+	High     Risk = Risk(safety.High)     // 删除数据、执行远程脚本、重启等难以撤销的操作
+	Critical Risk = Risk(safety.Critical) // 格式化、递归删除系统目录等
+)
+
+func (r Risk) String() string {
+	return safety.Level(r).String()
+}
+
+// Suggestion 一条候选命令及其检查结果
+type Suggestion struct {
+	Command     string
+	Approach    string // 命令的实现方式，例如 "使用 find"
+	Description string
+	Risk        Risk     // 本地规则检查的风险等级
+	Reasons     []string // 本地规则命中的说明，命中 allowlist 时为空
+	Notes       []string // 自动调整或兼容性提示
+	Blocked     bool     // 命中 safety.blocklist，不应执行
+	Forbidden   bool     // 命中 safety.forbidden，既不应执行也不应展示给他人复制
+	Injection   []string // 疑似提示词注入的说明，非空时应交给人审查
+}
+
+// Result 一次请求的结果：模型追问时 Ask 非空，不需要执行命令时 Answer 非空，否则至少有一条候选命令
+type Result struct {
+	Query       string
+	Suggestions []Suggestion // 按推荐程度排序
+	Ask         string       // 模型需要补充的信息，补充后可重新请求
+	Answer      string       // 知识性问题的直接回答
+	Explanation string
+	Assumptions string // 信息不足时生成命令所做的假设
+	Provider    string // 实际回答的提供商，主提供商失败后可能是 failover 中的提供商
+	Model       string // 实际回答的模型
+	Intent      string // 识别出的查询意图（file、network、git、package、text），未配置 intents 时为空
+}
+
+// Best 返回最推荐的候选命令
+func (r *Result) Best() (Suggestion, bool) {
+	if len(r.Suggestions) == 0 {
+		return Suggestion{}, false
+	}
+	return r.Suggestions[0], true
+}
+
+// Client 命令生成客户端
+type Client struct {
+	llm      *llm.Client
+	analyzer *safety.Analyzer
+	cfg      *config.Config
+}
+
+// New 根据配置创建客户端。与 termi 命令行共用缓存、技能包与历史记录等数据目录
+func New(cfg *Config) (*Client, error) {
+	if cfg == nil {
+		return nil, errors.New("配置不能为空")
+	}
+	analyzer, err := safety.New(cfg.c.Safety)
+	if err != nil {
+		return nil, err
+	}
+	client, err := llm.NewClient(cfg.c)
+	if err != nil {
+		return nil, fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+	return &Client{llm: client, analyzer: analyzer, cfg: cfg.c}, nil
+}
+
+// Suggest 根据自然语言描述生成命令，并检查每条候选命令的风险
+func (c *Client) Suggest(ctx context.Context, query string) (*Result, error) {
+	reply, err := c.llm.AskSmart(c.llm.StartSession(ctx), query)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{
+		Query:       query,
+		Ask:         reply.Ask,
+		Answer:      reply.Answer,
+		Explanation: reply.Explanation,
+		Assumptions: reply.Assumptions,
+		Provider:    reply.Provider,
+		Model:       reply.Model,
+		Intent:      string(reply.Intent),
+	}
+	if reply.Ask != "" || reply.Command == "" && reply.Answer != "" {
+		return res, nil
+	}
+	if reply.Command == "" {
+		return nil, ErrNoCommand
+	}
+	// 严格的安全检查只适用于配置了 safety: strict 的意图的查询生成的命令
+	analyzer := c.analyzer.WithStrict(c.cfg.StrictSafety(reply.Intent))
+	res.Suggestions = append(res.Suggestions, check(analyzer, query, reply.Command, reply.Approach, reply.Description, reply.Notes))
+	for _, alt := range reply.Alternatives {
+		res.Suggestions = append(res.Suggestions, check(analyzer, query, alt.Command, alt.Approach, alt.Description, alt.Notes))
+	}
+	return res, nil
+}
+
+// Check 用本地安全规则检查一条命令，不请求 LLM。query 为生成该命令的请求，用于识别提示词注入，可以为空
+func (c *Client) Check(query, command string) Suggestion {
+	return check(c.analyzer, query, command, "", "", nil)
+}
+
+func check(analyzer *safety.Analyzer, query, command, approach, description string, notes []string) Suggestion {
+	r := analyzer.Analyze(command)
+	s := Suggestion{
+		Command:     command,
+		Approach:    approach,
+		Description: description,
+		Risk:        Risk(r.Level),
+		Notes:       notes,
+		Blocked:     r.Blocked,
+		Forbidden:   r.Forbidden,
+		Injection:   analyzer.Inspect(command, query),
+	}
+	if !r.Allowed {
+		s.Reasons = r.Reasons
+	}
+	return s
+}
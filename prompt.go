@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+)
+
+const promptUsage = `用法:
+  termi prompt render --query <需求> [--lite] [--file 文件] [--json]
+      输出发送给模型的完整提示词（系统提示词，以及附加了环境信息、历史示例、技能包等上下文的用户提示词），
+      不请求任何提供商`
+
+// runPrompt 处理 termi prompt 子命令
+func runPrompt(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(promptUsage)
+		return nil
+	}
+	switch args[0] {
+	case "render":
+		return runPromptRender(args[1:])
+	default:
+		return fmt.Errorf("未知的 prompt 子命令: %s\n\n%s", args[0], promptUsage)
+	}
+}
+
+// runPromptRender 按正常请求的流程组装提示词并输出，用于排查模型回答异常的原因，
+// 以及在修改提示词模板前后对比输出
+func runPromptRender(args []string) error {
+	fs := flag.NewFlagSet("prompt render", flag.ContinueOnError)
+	query := fs.String("query", "", "用户需求")
+	lite := fs.Bool("lite", false, "按低带宽模式组装")
+	asJSON := fs.Bool("json", false, "以 JSON 输出，便于保存为快照")
+	var files listFlag
+	fs.Var(&files, "f", "附加文件作为上下文，可重复指定")
+	fs.Var(&files, "file", "附加文件作为上下文，可重复指定")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *query == "" {
+		*query = strings.Join(fs.Args(), " ")
+	}
+	if *query == "" {
+		return fmt.Errorf("请用 --query 提供需求\n\n%s", promptUsage)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		showConfigHelp(err)
+		return err
+	}
+	if *lite {
+		cfg.Lite = true
+	}
+	// 不会发送任何内容，开启了 redact.approve 时直接展示脱敏后的附加内容
+	opts := []llm.Option{llm.WithApprover(func(context.Context, string) bool { return true })}
+	if len(files) > 0 {
+		attached, err := readFiles(cfg, files)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, llm.WithFiles(attached))
+	}
+	client, err := llm.NewClient(cfg, opts...)
+	if err != nil {
+		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+
+	p, err := client.RenderPrompt(context.Background(), *query)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(struct {
+			*llm.Prompt
+			Variant string `json:"variant,omitempty"`
+		}{p, client.Variant()}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if v := client.Variant(); v != "" {
+		fmt.Printf("# 提示词实验变体: %s\n\n", v)
+	}
+	fmt.Printf("# 系统提示词\n\n%s\n\n# 用户提示词\n\n%s\n", p.System, p.User)
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/glyph"
+	"termi.sh/termi/internal/safety"
+)
+
+// runSafety 处理 termi safety 子命令：验证 safety.d 中的规则文件，或查看一条命令的检查结果
+func runSafety(args []string) error {
+	if len(args) == 0 {
+		args = []string{"check"}
+	}
+
+	switch args[0] {
+	case "check":
+		return checkSafetyRules(args[1:])
+	case "test":
+		if len(args) < 2 {
+			return fmt.Errorf("用法: termi safety test <命令>")
+		}
+		sc := config.SafetyConfig{}
+		if cfg, _, err := config.Load(false); err == nil {
+			sc = cfg.Safety
+		}
+		analyzer, err := safety.New(sc)
+		if err != nil {
+			return err
+		}
+		warnSafetyRules()
+		r := analyzer.Analyze(strings.Join(args[1:], " "))
+		switch {
+		case r.Forbidden:
+			fmt.Println("禁止（safety.forbidden）")
+		case r.Blocked:
+			fmt.Println("禁止执行（safety.blocklist）")
+		case r.Allowed:
+			fmt.Println("安全（safety.allowlist）")
+		default:
+			fmt.Println(r.Level)
+		}
+		for _, reason := range r.Reasons {
+			fmt.Printf("  - %s\n", reason)
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的 safety 子命令: %s（可用: check, test）", args[0])
+	}
+}
+
+// checkSafetyRules 逐个验证规则文件及其示例命令，默认检查 safety.d 目录；有文件无效时以非零状态退出
+func checkSafetyRules(paths []string) error {
+	if len(paths) == 0 {
+		dir := config.SafetyRulesDir()
+		yaml, _ := filepath.Glob(filepath.Join(dir, "*.yaml"))
+		yml, _ := filepath.Glob(filepath.Join(dir, "*.yml"))
+		paths = append(yaml, yml...)
+		if len(paths) == 0 {
+			fmt.Printf("%s 中没有规则文件\n", dir)
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, path := range paths {
+		rules, err := readSafetyRules(path)
+		if err != nil {
+			failed++
+			glyph.Printf("✗ %v\n", err)
+			continue
+		}
+		examples := 0
+		for _, r := range rules {
+			examples += len(r.Match) + len(r.Ignore)
+		}
+		glyph.Printf("✓ %s: %d 条规则，%d 个示例全部通过\n", path, len(rules), examples)
+	}
+	if failed > 0 {
+		return exitError{code: 1}
+	}
+	return nil
+}
+
+func readSafetyRules(path string) ([]safety.UserRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return safety.ParseRules(path, data)
+}
+
+// warnSafetyRules 提示 safety.d 中因无效而没有生效的规则文件
+func warnSafetyRules() {
+	_, errs := safety.LoadRules(config.SafetyRulesDir())
+	for _, err := range errs {
+		glyph.Fprintf(os.Stderr, "⚠ 规则文件未生效: %v\n", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/proxy"
+	"termi.sh/termi/internal/telemetry"
+)
+
+// runServe 处理 termi serve 子命令，目前只有 --cache-proxy 一种模式
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cacheProxy := fs.Bool("cache-proxy", false, "提供 OpenAI 兼容端点，带共享缓存、脱敏与每日用量限制")
+	listen := fs.String("listen", "", "监听地址，默认 127.0.0.1:8787")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if !*cacheProxy {
+		return fmt.Errorf("用法: termi serve --cache-proxy [--listen 地址]")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		showConfigHelp(err)
+		return err
+	}
+	if *listen != "" {
+		cfg.Serve.Listen = *listen
+	}
+	if err := cfg.Serve.Validate(); err != nil {
+		return err
+	}
+
+	shutdown, err := telemetry.Setup(&cfg.Telemetry)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "导出追踪数据失败: %v\n", err)
+		}
+	}()
+
+	client, err := llm.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+
+	srv := proxy.New(cfg.Serve, client)
+	addr := cfg.Serve.Address()
+	fmt.Printf("termi 缓存代理已启动: http://%s/v1 -> %s\n", addr, client.ProviderName())
+	if len(cfg.Serve.APIKeys) == 0 && !loopback(addr) {
+		fmt.Println("警告: 未配置 serve.api_keys，任何能访问该地址的人都可以使用代理")
+	}
+	fmt.Println("客户端在配置中设置 \"openai\": {\"base_url\": \"http://" + addr + "/v1\", \"api_key\": \"<serve.api_keys 中的一项>\"}")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = srv.Serve(ctx)
+	fmt.Printf("\n代理已停止，今日用量: %s\n", srv.Summary())
+	return err
+}
+
+// loopback 判断监听地址是否只对本机开放
+func loopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/shell"
+)
+
+// shellInit 各 shell 的集成脚本：每次显示提示符前记下上一条命令及其退出码，供 termi fix 使用；
+// 包装 termi 函数，执行结束后把 termi 实际执行的命令写入当前 shell 的历史
+var shellInit = map[string]string{
+	"bash": `# termi shell integration: eval "$(termi init bash)"
+__termi_record() {
+  __termi_last_status=$?
+  __termi_last_command="$(HISTTIMEFORMAT= builtin history 1)"
+  __termi_last_command="${__termi_last_command#"${__termi_last_command%%[![:space:]]*}"}"
+  __termi_last_command="${__termi_last_command#* }"
+  __termi_last_command="${__termi_last_command#"${__termi_last_command%%[![:space:]]*}"}"
+  return $__termi_last_status
+}
+case ";${PROMPT_COMMAND:-};" in
+  *";__termi_record;"*) ;;
+  *) PROMPT_COMMAND="__termi_record${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+termi() {
+  local __termi_hist __termi_status __termi_line
+  __termi_hist="$(mktemp "${TMPDIR:-/tmp}/termi-history.XXXXXX")" || __termi_hist=/dev/null
+  TERMI_LAST_COMMAND="${__termi_last_command:-}" TERMI_LAST_STATUS="${__termi_last_status:-}" \
+    TERMI_HISTORY_FILE="$__termi_hist" command termi "$@"
+  __termi_status=$?
+  while IFS= read -r __termi_line; do
+    [ -n "$__termi_line" ] && history -s -- "$__termi_line"
+  done < "$__termi_hist"
+  [ "$__termi_hist" = /dev/null ] || rm -f "$__termi_hist"
+  return $__termi_status
+}
+`,
+	"zsh": `# termi shell integration: eval "$(termi init zsh)"
+__termi_preexec() { __termi_pending="$1"; }
+__termi_record() {
+  __termi_last_status=$?
+  __termi_last_command="${__termi_pending:-$__termi_last_command}"
+  __termi_pending=
+  return $__termi_last_status
+}
+(( ${precmd_functions[(I)__termi_record]} )) || precmd_functions=(__termi_record $precmd_functions)
+(( ${preexec_functions[(I)__termi_preexec]} )) || preexec_functions+=(__termi_preexec)
+termi() {
+  local __termi_hist __termi_status __termi_line
+  __termi_hist="$(mktemp "${TMPDIR:-/tmp}/termi-history.XXXXXX")" || __termi_hist=/dev/null
+  TERMI_LAST_COMMAND="${__termi_last_command:-}" TERMI_LAST_STATUS="${__termi_last_status:-}" \
+    TERMI_HISTORY_FILE="$__termi_hist" command termi "$@"
+  __termi_status=$?
+  while IFS= read -r __termi_line; do
+    [[ -n "$__termi_line" ]] && print -s -r -- "$__termi_line"
+  done < "$__termi_hist"
+  [ "$__termi_hist" = /dev/null ] || rm -f "$__termi_hist"
+  return $__termi_status
+}
+`,
+}
+
+// runInit 处理 termi init 子命令：输出 shell 集成脚本
+func runInit(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: termi init <bash|zsh>")
+	}
+	script, ok := shellInit[args[0]]
+	if !ok {
+		return fmt.Errorf("暂不支持的 shell: %s（支持 bash、zsh）", args[0])
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// runShellInit 处理 termi shell-init 子命令：输出把选中命令放到命令行上的按键绑定脚本
+func runShellInit(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: termi shell-init <%s>", strings.Join(shell.Shells(), "|"))
+	}
+	script, err := shell.Widget(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
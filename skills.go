@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/skills"
+)
+
+// runSkills 处理 termi skills 子命令
+func runSkills(args []string) error {
+	store := skills.NewStore(config.SkillsDir())
+
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch args[0] {
+	case "list":
+		list, err := store.List()
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			fmt.Println("尚未安装任何技能包")
+		}
+		for _, sk := range list {
+			status := "已启用"
+			if !sk.Enabled {
+				status = "已禁用"
+			}
+			fmt.Printf("  %s %s  %s\n", runewidth.FillRight(sk.Name, 12), status, sk.Description)
+		}
+		fmt.Printf("\n可安装的内置技能包: %s\n", strings.Join(skills.Builtin(), ", "))
+		return nil
+	case "install":
+		if len(args) < 2 {
+			return fmt.Errorf("用法: termi skills install <内置名称|文件路径|URL>")
+		}
+		sk, err := store.Install(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("已安装技能包 %s\n", sk.Name)
+		return nil
+	case "enable", "disable":
+		if len(args) < 2 {
+			return fmt.Errorf("用法: termi skills %s <名称>", args[0])
+		}
+		if err := store.SetEnabled(args[1], args[0] == "enable"); err != nil {
+			return err
+		}
+		fmt.Printf("技能包 %s 已%s\n", args[1], map[string]string{"enable": "启用", "disable": "禁用"}[args[0]])
+		return nil
+	default:
+		return fmt.Errorf("未知的 skills 子命令: %s（可用: list, install, enable, disable）", args[0])
+	}
+}
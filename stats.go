@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/stats"
+)
+
+// runStats 处理 termi stats 子命令，按提供商与模型汇总最近一段时间的 token 用量与估算费用
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	days := fs.Int("days", 30, "统计最近多少天（含今天）")
+	daily := fs.Bool("daily", false, "同时按天列出用量")
+	asJSON := fs.Bool("json", false, "以 JSON 输出")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return fmt.Errorf("--days 必须大于 0")
+	}
+
+	// 价格表只取自配置文件，没有配置 LLM 提供商时也能查看用量
+	var pricing map[string]config.Price
+	cfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	if cfg != nil {
+		pricing = cfg.Stats.Pricing
+	}
+
+	rows, err := stats.Open(config.StatsPath()).Load()
+	if err != nil {
+		return fmt.Errorf("读取用量统计失败: %w", err)
+	}
+	since := stats.Since(*days)
+	usage := stats.Summarize(rows, since, pricing)
+
+	if *asJSON {
+		out := struct {
+			Since  string        `json:"since"`
+			Models []stats.Usage `json:"models"`
+			Daily  []stats.Usage `json:"daily,omitempty"`
+		}{Since: since, Models: usage}
+		if *daily {
+			out.Daily = stats.Daily(rows, since, pricing)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	if len(usage) == 0 {
+		if cfg != nil && cfg.Stats.Disabled {
+			fmt.Println("用量统计已关闭 (stats.disabled)")
+			return nil
+		}
+		fmt.Printf("最近 %d 天没有 LLM 请求记录\n", *days)
+		return nil
+	}
+
+	fmt.Printf("最近 %d 天（%s 起）的用量:\n\n", *days, since)
+	printUsageHeader("提供商 / 模型")
+	var total stats.Usage
+	total.Priced = true
+	var unpriced []string
+	for _, u := range usage {
+		printUsage(u.Provider+" "+u.Model, u)
+		total.Requests += u.Requests
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.Cost += u.Cost
+		if !u.Priced {
+			total.Priced = false
+			unpriced = append(unpriced, u.Model)
+		}
+	}
+	if len(usage) > 1 {
+		printUsage("合计", total)
+	}
+
+	if *daily {
+		fmt.Println()
+		printUsageHeader("日期")
+		for _, u := range stats.Daily(rows, since, pricing) {
+			printUsage(u.Day, u)
+		}
+	}
+
+	if len(unpriced) > 0 {
+		fmt.Printf("\n未找到价格，费用未计入: %s\n可在 %s 的 stats.pricing 中设置（美元 / 百万 token），例如 \"%s\": {\"input\": 0.4, \"output\": 1.6}\n",
+			strings.Join(unpriced, "、"), config.Path(), unpriced[0])
+	}
+	fmt.Println("\n费用按公开标价估算，仅供参考，以提供商账单为准")
+	return nil
+}
+
+func printUsageHeader(label string) {
+	fmt.Printf("  %s %s %s %s %s\n", runewidth.FillRight(label, 36), runewidth.FillLeft("请求", 8),
+		runewidth.FillLeft("输入 token", 12), runewidth.FillLeft("输出 token", 12), runewidth.FillLeft("估算费用", 12))
+}
+
+func printUsage(label string, u stats.Usage) {
+	cost := "-"
+	if u.Priced || u.Cost > 0 {
+		cost = fmt.Sprintf("$%.4f", u.Cost)
+	}
+	fmt.Printf("  %s %8d %12d %12d %12s\n", runewidth.FillRight(runewidth.Truncate(label, 36, "…"), 36), u.Requests, u.PromptTokens, u.CompletionTokens, cost)
+}
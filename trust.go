@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-runewidth"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/trust"
+)
+
+// runTrust 处理 termi trust 子命令：信任、拒绝或重置目录，默认作用于当前目录
+func runTrust(args []string) error {
+	store := trust.Open(config.TrustPath())
+
+	action := "add"
+	if len(args) > 0 {
+		switch args[0] {
+		case "list", "add", "deny", "reset":
+			action, args = args[0], args[1:]
+		}
+	}
+
+	if action == "list" {
+		list := store.List()
+		if len(list) == 0 {
+			fmt.Println("尚未对任何目录做出信任决定")
+		}
+		for _, e := range list {
+			status := "信任"
+			if !e.Trusted {
+				status = "不信任"
+			}
+			fmt.Printf("  %s %s  %s\n", runewidth.FillRight(status, 6), e.DecidedAt.Format("2006-01-02"), e.Dir)
+		}
+		return nil
+	}
+
+	if len(args) > 1 {
+		return fmt.Errorf("用法: termi trust [list | add | deny | reset] [目录]")
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("目录不存在: %s", dir)
+	}
+
+	switch action {
+	case "deny":
+		if err := store.Set(dir, false); err != nil {
+			return err
+		}
+		fmt.Printf("不再读取 %s 中的项目文件\n", dir)
+	case "reset":
+		if err := store.Remove(dir); err != nil {
+			return err
+		}
+		fmt.Printf("已清除 %s 的信任决定，下次运行时会重新询问\n", dir)
+	default:
+		if err := store.Set(dir, true); err != nil {
+			return err
+		}
+		fmt.Printf("已信任 %s 及其子目录，其中的项目文件会附加到提示词中\n", dir)
+	}
+	return nil
+}
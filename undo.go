@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"termi.sh/termi/internal/backup"
+	"termi.sh/termi/internal/config"
+)
+
+// runUndo 处理 termi undo 子命令：把最近一次执行前备份的文件恢复原状，--list 列出全部备份
+func runUndo(args []string) error {
+	fs := flag.NewFlagSet("undo", flag.ContinueOnError)
+	list := fs.Bool("list", false, "列出可以恢复的备份")
+	yes := fs.Bool("y", false, "不确认直接恢复")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *list {
+		ops, err := backup.List(config.BackupsDir())
+		if err != nil {
+			return err
+		}
+		if len(ops) == 0 {
+			fmt.Println("没有可以恢复的备份")
+		}
+		for _, op := range ops {
+			fmt.Printf("%s  %d 个路径  %s\n", op.Time.Format("2006-01-02 15:04:05"), len(op.Entries), op.Command)
+		}
+		return nil
+	}
+
+	op, err := backup.Last(config.BackupsDir())
+	if err != nil {
+		return err
+	}
+	if op == nil {
+		fmt.Println("没有可以恢复的备份（执行前备份需要在配置中设置 \"exec\": {\"backup\": true}）")
+		return nil
+	}
+
+	fmt.Printf("最近一次备份: %s\n  命令: %s\n  目录: %s\n", op.Time.Format("2006-01-02 15:04:05"), op.Command, op.Dir)
+	for _, e := range op.Entries {
+		action := "恢复"
+		if e.Absent {
+			action = "删除"
+		}
+		fmt.Printf("  %s %s\n", action, e.Path)
+	}
+	if !*yes {
+		fmt.Print("将以上路径恢复到执行前的状态，之后的改动会丢失，继续? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("已取消")
+			return nil
+		}
+	}
+	if err := op.Restore(); err != nil {
+		return fmt.Errorf("恢复失败，备份仍保留在 %s: %w", config.BackupsDir(), err)
+	}
+	fmt.Println("已恢复")
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"termi.sh/termi/internal/config"
+	"termi.sh/termi/internal/llm"
+	"termi.sh/termi/internal/scrollback"
+	"termi.sh/termi/internal/ui"
+)
+
+// whyQuery 解释终端错误时发送给模型的默认需求
+const whyQuery = "解释终端输出中最近一次出现的错误，并给出修复命令"
+
+// runWhy 处理 termi why 子命令：截取 tmux/screen 回滚内容并解释最近的错误
+func runWhy(args []string) error {
+	fs := flag.NewFlagSet("why", flag.ContinueOnError)
+	lines := fs.Int("n", 200, "读取的终端行数")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	output, err := scrollback.Capture(*lines)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(output) == "" {
+		return fmt.Errorf("终端回滚内容为空")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		showConfigHelp(err)
+		return err
+	}
+	client, err := llm.NewClient(cfg, llm.WithTerminalOutput(output))
+	if err != nil {
+		return fmt.Errorf("初始化 LLM 提供商失败: %w", err)
+	}
+
+	query := whyQuery
+	if extra := strings.Join(fs.Args(), " "); extra != "" {
+		query += "：" + extra
+	}
+	return ui.RunApp(cfg, client, query)
+}